@@ -3,8 +3,10 @@ package main
 import (
 	"fmt"
 	"os"
+	"strings"
 
 	"github.com/xlttj/kprtfwd/pkg/cmd"
+	"github.com/xlttj/kprtfwd/pkg/devmode"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 	"github.com/xlttj/kprtfwd/pkg/ui"
 
@@ -12,8 +14,21 @@ import (
 )
 
 func main() {
+	// In developer mode kprtfwd runs itself in place of kubectl
+	if devmode.IsKubectl() {
+		os.Exit(devmode.RunKubectl(os.Args[1:], os.Stdout, os.Stderr))
+	}
+
 	logging.LogDebug("Logger test: main started")
 
+	// A workspace selects the database every command and the TUI use
+	cmd.TakeWorkspaceFlag()
+
+	// KPRTFWD_DEV puts every command in developer mode, --dev just the TUI
+	if devmode.Enabled() {
+		cmd.EnableDevMode()
+	}
+
 	// Check for help flags first
 	if len(os.Args) > 1 {
 		arg := os.Args[1]
@@ -23,7 +38,10 @@ func main() {
 	}
 
 	// Parse command line arguments
-	if len(os.Args) > 1 {
+	var startup cmd.StartupOptions
+	if len(os.Args) > 1 && strings.HasPrefix(os.Args[1], "-") {
+		startup = cmd.ParseStartupFlags(os.Args[1:])
+	} else if len(os.Args) > 1 {
 		sub := os.Args[1]
 		switch sub {
 		case "help":
@@ -32,6 +50,54 @@ func main() {
 		case "prune":
 			cmd.HandlePruneCommand()
 			return
+		case "ports":
+			cmd.HandlePortsCommand()
+			return
+		case "settings":
+			cmd.HandleSettingsCommand()
+			return
+		case "stats":
+			cmd.HandleStatsCommand()
+			return
+		case "report":
+			cmd.HandleReportCommand()
+			return
+		case "ids":
+			cmd.HandleIDsCommand()
+			return
+		case "lint":
+			cmd.HandleLintCommand()
+			return
+		case "db":
+			cmd.HandleDBCommand()
+			return
+		case "connect":
+			cmd.HandleConnectCommand()
+			return
+		case "templates":
+			cmd.HandleTemplatesCommand()
+			return
+		case "import":
+			cmd.HandleImportCommand()
+			return
+		case "stop":
+			cmd.HandleStopCommand()
+			return
+		case "run":
+			cmd.HandleRunCommand()
+			return
+		case "start":
+			cmd.HandleStartCommand()
+			return
+		case "list":
+			cmd.HandleListCommand()
+			return
+		case "export":
+			cmd.HandleExportCommand()
+			return
+		case "digest":
+			cmd.HandleDigestCommand()
+			return
 		default:
 			// Unknown command
 			fmt.Printf("Error: unknown command '%s'\n\n", sub)
@@ -40,8 +106,24 @@ func main() {
 	}
 
 	// Default behavior - start TUI
+	if startup.Dev && !devmode.Enabled() {
+		cmd.EnableDevMode()
+	}
+	if startup.Accessible {
+		ui.EnableAccessibleMode()
+	}
 	model := ui.NewModel()
-	p := tea.NewProgram(model, tea.WithAltScreen())
+	if err := model.Startup(startup.Project, startup.StartAll); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		model.Cleanup()
+		os.Exit(1)
+	}
+	// Screen readers follow the normal screen better than the alternate one
+	var opts []tea.ProgramOption
+	if !ui.AccessibleMode() {
+		opts = append(opts, tea.WithAltScreen())
+	}
+	p := tea.NewProgram(model, opts...)
 	if _, err := p.Run(); err != nil {
 		fmt.Printf("Error: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// HandleConnectCommand handles the connect subcommand logic
+func HandleConnectCommand() {
+	// Flags end at the forward ID; what follows belongs to the client
+	for _, arg := range os.Args[2:] {
+		if !strings.HasPrefix(arg, "-") || arg == "--" {
+			break
+		}
+		if arg == "-h" || arg == "--help" {
+			showConnectHelp()
+			os.Exit(0)
+		}
+	}
+
+	connectCmd := flag.NewFlagSet("connect", flag.ExitOnError)
+	wait := connectCmd.Duration("wait", 15*time.Second, "How long to wait for the local port to accept connections")
+	connectCmd.Usage = showConnectHelp
+	if err := connectCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	args := connectCmd.Args()
+	if len(args) == 0 {
+		fmt.Printf("Error: expected a forward ID\n\n")
+		showConnectHelp()
+		os.Exit(1)
+	}
+	id, command := args[0], args[1:]
+	if len(command) > 0 && command[0] == "--" {
+		command = command[1:]
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	cfg, ok := store.GetConfigByID(id)
+	if !ok {
+		fmt.Printf("Error: no forward with ID '%s'\n", id)
+		os.Exit(1)
+	}
+
+	client := clientCommand(cfg, command)
+	if client == nil {
+		fmt.Printf("Error: %s has no open command; give one after -- or set it in the TUI's action menu\n", id)
+		os.Exit(1)
+	}
+
+	// A forward the TUI (or another connect) runs is used as it is
+	host := config.ListenHosts(cfg.Listen)[0]
+	var pf *k8s.PortForwarder
+	if !portListening(host, cfg.PortLocal) {
+		kubectl.ApplySettings(store.GetSettings())
+		pf = k8s.NewPortForwarder()
+		pf.ApplySettings(store.GetSettings())
+		fmt.Fprintf(os.Stderr, "Starting %s on %s...\n", id, portSpan(cfg.PortLocal, cfg.Ports()))
+		if err := pf.Start(cfg); err != nil {
+			fmt.Printf("Error starting %s: %v\n", id, err)
+			os.Exit(1)
+		}
+		recordConnectEvent(store, id, config.ForwardEventStart)
+		if !waitListening(host, cfg.PortLocal, *wait) {
+			pf.CleanupAll()
+			recordConnectEvent(store, id, config.ForwardEventStop)
+			fmt.Printf("Error: %s did not accept connections within %s\n", id, *wait)
+			os.Exit(1)
+		}
+	}
+
+	// Ctrl+C belongs to the client; kprtfwd only cleans up once it exits
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	client.Stdin, client.Stdout, client.Stderr = os.Stdin, os.Stdout, os.Stderr
+	runErr := client.Run()
+	signal.Stop(interrupts)
+
+	if pf != nil {
+		pf.CleanupAll()
+		recordConnectEvent(store, id, config.ForwardEventStop)
+	}
+	if runErr != nil {
+		var exitErr *exec.ExitError
+		if errors.As(runErr, &exitErr) {
+			os.Exit(exitErr.ExitCode())
+		}
+		fmt.Printf("Error running client: %v\n", runErr)
+		os.Exit(1)
+	}
+}
+
+// clientCommand returns the client to run against cfg: command with its
+// placeholders replaced if given, else cfg's open command through the shell,
+// else nil
+func clientCommand(cfg config.PortForwardConfig, command []string) *exec.Cmd {
+	if len(command) > 0 {
+		args := make([]string, len(command))
+		for i, arg := range command {
+			args[i] = cfg.ExpandPlaceholders(arg)
+		}
+		return exec.Command(args[0], args[1:]...)
+	}
+	if cfg.OpenCommand == "" {
+		return nil
+	}
+	line := cfg.ExpandPlaceholders(cfg.OpenCommand)
+	if runtime.GOOS == "windows" {
+		return exec.Command("cmd", "/C", line)
+	}
+	return exec.Command("sh", "-c", line)
+}
+
+// waitListening polls host:port until it accepts connections or timeout passes
+func waitListening(host string, port int, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for !portListening(host, port) {
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return true
+}
+
+// recordConnectEvent adds an ad hoc start or stop to the history `kprtfwd
+// stats` summarizes
+func recordConnectEvent(store *config.SQLiteConfigStore, id, kind string) {
+	event := config.ForwardEvent{ForwardID: id, Kind: kind, At: time.Now()}
+	if err := store.RecordForwardEvents([]config.ForwardEvent{event}); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record the %s of %s: %v\n", kind, id, err)
+	}
+}
+
+// showConnectHelp displays help for the connect command
+func showConnectHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s connect - Run a client against a forward, starting it if needed
+
+Usage:
+  %s connect [options] <id> [-- <command> [args...]]
+
+Runs a client such as psql, mysql or redis-cli against the forward's local
+port. If nothing listens on the port yet, the forward is started for the
+client's lifetime and stopped when it exits; a forward the TUI runs is used
+as it is. Without a command, the forward's open command (set with "Edit open
+command" in the TUI's action menu) runs through the shell. The exit status is
+the client's.
+
+Placeholders, replaced in the command and its arguments:
+  {{host}} {{port}} {{url}} {{service}} {{namespace}} {{context}} {{id}}
+
+Options:
+  --wait duration       How long to wait for the local port (default 15s)
+  -h, --help            Show this help message
+
+Examples:
+  %s connect prod.data.pg
+  %s connect prod.data.pg -- psql -h {{host}} -p {{port}} -U app
+  %s connect stg.cache.redis -- redis-cli -p {{port}}
+`, programName, programName, programName, programName, programName)
+}
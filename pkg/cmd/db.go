@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// HandleDBCommand handles the db subcommand logic
+func HandleDBCommand() {
+	args := os.Args[2:]
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			showDBHelp()
+			os.Exit(0)
+		}
+	}
+
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+	if len(args) != 0 {
+		fmt.Printf("Error: 'db %s' takes no arguments\n\n", action)
+		showDBHelp()
+		os.Exit(1)
+	}
+
+	dbPath, err := config.DatabasePath()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch action {
+	case "status":
+		encrypted, err := config.DatabaseEncrypted()
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if encrypted {
+			fmt.Printf("%s is encrypted\n", dbPath)
+		} else {
+			fmt.Printf("%s is not encrypted\n", dbPath)
+		}
+	case "encrypt":
+		err := config.EncryptDatabase()
+		if errors.Is(err, config.ErrAlreadyEncrypted) {
+			fmt.Println("The database is already encrypted")
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if os.Getenv(config.EnvDBKey) != "" {
+			fmt.Printf("✅ Encrypted %s with the key from %s\n", dbPath, config.EnvDBKey)
+		} else {
+			fmt.Printf("✅ Encrypted %s, the key is in the OS credential store\n", dbPath)
+		}
+	case "decrypt":
+		err := config.DecryptDatabase()
+		if errors.Is(err, config.ErrNotEncrypted) {
+			fmt.Println("The database is not encrypted")
+			return
+		} else if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Decrypted %s\n", dbPath)
+	default:
+		fmt.Printf("Error: unknown db action '%s'\n\n", action)
+		showDBHelp()
+		os.Exit(1)
+	}
+}
+
+// showDBHelp displays help for the db command
+func showDBHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s db - Encrypt or decrypt the configuration database
+
+Usage:
+  %s db [status|encrypt|decrypt]
+
+The database records cluster contexts, namespaces and service names. Once
+encrypted, it is only ever decrypted into memory; every change is written
+back encrypted. Quit kprtfwd before encrypting or decrypting.
+
+The key is a random 256-bit key kept in the OS credential store (the macOS
+Keychain, or the Secret Service through secret-tool on Linux). Where there is
+no credential store, set %s to 64 hex digits instead, both when
+encrypting and on every later run.
+
+Actions:
+  status    Show whether the database is encrypted (default)
+  encrypt   Encrypt the database and remove the plain file
+  decrypt   Turn the database back into a plain file
+
+Options:
+  -h, --help  Show this help message
+
+Examples:
+  %s db encrypt                     Encrypt with a key in the credential store
+  KPRTFWD_DB_KEY=$(openssl rand -hex 32) %s db encrypt
+`, programName, programName, config.EnvDBKey, programName, programName)
+}
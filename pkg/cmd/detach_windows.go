@@ -0,0 +1,14 @@
+//go:build windows
+
+package cmd
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// detach makes cmd outlive the console it is started from
+func detach(cmd *exec.Cmd) {
+	const detachedProcess = 0x00000008 // DETACHED_PROCESS
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP}
+}
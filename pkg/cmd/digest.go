@@ -0,0 +1,193 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// digestSendTimeout bounds posting the digest and running its command
+const digestSendTimeout = time.Minute
+
+// HandleDigestCommand handles the digest subcommand logic
+func HandleDigestCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showDigestHelp()
+			os.Exit(0)
+		}
+	}
+
+	digestCmd := flag.NewFlagSet("digest", flag.ExitOnError)
+	dryRun := digestCmd.Bool("dry-run", false, "Print the digest without sending it")
+	always := digestCmd.Bool("always", false, "Send the digest even when it lists no forward")
+	asJSON := digestCmd.Bool("json", false, "Print JSON instead of text")
+	digestCmd.Usage = showDigestHelp
+	if err := digestCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if digestCmd.NArg() > 0 {
+		fmt.Printf("Error: unexpected argument '%s'\n\n", digestCmd.Arg(0))
+		showDigestHelp()
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	settings := store.GetSettings()
+	hosts, err := config.HostStates()
+	if err != nil {
+		fmt.Printf("Error reading the running kprtfwd: %v\n", err)
+		os.Exit(1)
+	}
+	events, err := store.ForwardEvents()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	digest, err := config.BuildDigest(store.GetAll(), hosts, events, settings, time.Now())
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *asJSON {
+		data, err := json.MarshalIndent(digest, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(digest.Text())
+	}
+
+	webhook, command := settings[config.SettingDigestWebhook], settings[config.SettingDigestCommand]
+	switch {
+	case *dryRun:
+		return
+	case webhook == "" && command == "":
+		fmt.Printf("Not sent: set %s or %s to send the digest\n", config.SettingDigestWebhook, config.SettingDigestCommand)
+		return
+	case len(digest.Forwards) == 0 && !*always:
+		return
+	}
+	failed := false
+	if webhook != "" {
+		if err := postDigest(webhook, digest); err != nil {
+			fmt.Printf("Error posting the digest to %s: %v\n", webhook, err)
+			failed = true
+		} else {
+			fmt.Printf("Posted the digest to %s\n", webhook)
+		}
+	}
+	if command != "" {
+		if err := runDigestCommand(command, digest); err != nil {
+			fmt.Printf("Error running %s: %v\n", config.SettingDigestCommand, err)
+			failed = true
+		} else {
+			fmt.Printf("Ran %s\n", config.SettingDigestCommand)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// postDigest posts digest to url as JSON, with its text in a "text" field
+// for chat webhooks such as Slack's
+func postDigest(url string, digest config.Digest) error {
+	payload := struct {
+		Text string `json:"text"`
+		config.Digest
+	}{Text: digest.Text(), Digest: digest}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), digestSendTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("the server answered %s", resp.Status)
+	}
+	return nil
+}
+
+// runDigestCommand runs command through the shell with the digest's text on
+// stdin and the number of forwards it lists in KPRTFWD_DIGEST_COUNT
+func runDigestCommand(command string, digest config.Digest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), digestSendTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), "KPRTFWD_DIGEST_COUNT="+strconv.Itoa(len(digest.Forwards)))
+	cmd.Stdin = bytes.NewReader([]byte(digest.Text()))
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	err := cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		return fmt.Errorf("timed out after %s", digestSendTimeout)
+	}
+	return err
+}
+
+// showDigestHelp displays help for the digest command
+func showDigestHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s digest - Report long-running forwards into protected clusters
+
+Usage:
+  %s digest [options]
+
+Lists the forwards that %s selects, e.g. context:prod-*, which a running
+kprtfwd (the TUI or kprtfwd run) has been serving for longer than
+%s (default 4h), and sends the list to %s as JSON (with the
+text in a "text" field, as Slack and Teams webhooks take it) and to
+%s, which gets it as text on stdin. Nothing is sent when no
+forward is listed, unless --always is given.
+
+Run it daily from cron or a systemd timer to let a security team know of
+production tunnels left open.
+
+Options:
+  --dry-run             Print the digest without sending it
+  --always              Send the digest even when it lists no forward
+  --json                Print JSON instead of text
+  -h, --help            Show this help message
+
+Examples:
+  %s settings set security.protected 'context:prod-* OR tag:prod'
+  %s settings set digest.webhook https://hooks.slack.com/services/...
+  %s digest --dry-run
+  0 9 * * * %s digest           crontab line sending it every morning
+`, programName, programName, config.SettingProtected, config.SettingDigestThreshold, config.SettingDigestWebhook,
+		config.SettingDigestCommand, programName, programName, programName, programName)
+}
@@ -0,0 +1,148 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// HandleExportCommand handles the export subcommand logic
+func HandleExportCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showExportHelp()
+			os.Exit(0)
+		}
+	}
+
+	exportCmd := flag.NewFlagSet("export", flag.ExitOnError)
+	format := exportCmd.String("format", "yaml", "Output format: yaml, json or shell")
+	project := exportCmd.String("project", "", "Export the forwards of this project instead of every forward")
+	output := exportCmd.String("o", "", "Write the export to this file instead of stdout")
+	withSettings := exportCmd.Bool("settings", false, "Include the stored settings, e.g. the theme and kubectl timeouts")
+	exportCmd.Usage = showExportHelp
+	if err := exportCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if exportCmd.NArg() > 0 {
+		fmt.Printf("Error: unexpected argument '%s'\n\n", exportCmd.Arg(0))
+		showExportHelp()
+		os.Exit(1)
+	}
+	if *format != "yaml" && *format != "json" && *format != "shell" {
+		fmt.Printf("Error: unknown format '%s' (use yaml, json or shell)\n", *format)
+		os.Exit(1)
+	}
+	if *withSettings && *format == "shell" {
+		fmt.Printf("Error: --settings needs --format yaml or json\n")
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	kubectl.ApplySettings(store.GetSettings())
+
+	configs, err := runConfigs(store, *project, "")
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(configs) == 0 {
+		fmt.Println("No forwards to export.")
+		os.Exit(1)
+	}
+
+	source := "workspace " + config.CurrentWorkspace()
+	if *project != "" {
+		source += ", project " + *project
+	}
+	header := []string{fmt.Sprintf("Port forwards exported from kprtfwd on %s (%s)", time.Now().Format("2006-01-02"), source)}
+
+	dump := config.NewConfigDump(configs, store.GetAllProjects())
+	if *withSettings {
+		dump.Settings = config.DumpSettings(store.GetStoredSettings())
+	}
+	var out string
+	mode := os.FileMode(0o644)
+	switch *format {
+	case "shell":
+		out = k8s.ShellScript(configs, header)
+		mode = 0o755
+	case "json":
+		data, err := json.MarshalIndent(dump, "", "  ")
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		out = string(data) + "\n"
+	default:
+		header = append(header, "kprtfwd import adds these forwards and projects to another configuration")
+		out = config.DumpYAML(dump, header)
+	}
+
+	if *output == "" {
+		fmt.Print(out)
+		return
+	}
+	if err := os.WriteFile(*output, []byte(out), mode); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Export of %d forward(s) and %d setting(s) written to %s\n", len(configs), len(dump.Settings), *output)
+}
+
+// showExportHelp displays help for the export command
+func showExportHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s export - Export forwards as YAML, JSON or a kubectl script
+
+Usage:
+  %s export [options]
+
+Prints every forward, or those of one project, with the projects they are
+in. Commit the YAML or JSON to git to share canonical forward definitions:
+kprtfwd import reads it back, into another workspace or on another machine.
+The kubeconfig a forward's context was found in stays out, as it belongs to
+this machine.
+
+--settings adds the stored settings, the theme, kubectl timeouts and the
+like, so that kprtfwd import sets up a new machine as this one in one go.
+Settings from KPRTFWD_* environment variables are not included, nor are the
+paths, URLs and commands of this machine: kubectl.path, kubectl.ca_bundle.*,
+metrics.textfile, digest.webhook and digest.command.
+
+--format shell prints a POSIX shell script instead, which runs kubectl
+port-forward for each forward in the background and stops them all on
+Ctrl+C: for teammates who do not use kprtfwd, or CI, where only kubectl is
+at hand. The commands are those kprtfwd runs, with the same context,
+kubeconfig and ports. What only kprtfwd does for a forward, starting it on
+the first connection, terminating or originating TLS, or picking the pod of
+a pod selector, is noted in a comment above it. Nothing restarts a broken
+forward.
+
+Options:
+  --format string       Output format: yaml, json or shell (default yaml)
+  --project name        Export the forwards of this project instead of every forward
+  -o string             Write the export to this file instead of stdout; a
+                        shell script is made executable
+  --settings            Include the stored settings, e.g. the theme and kubectl timeouts
+  -h, --help            Show this help message
+
+Examples:
+  %s export -o forwards.yaml           Save every forward for git
+  %s import forwards.yaml              Add them on another machine
+  %s export --format shell -o fwd.sh   Save them as a kubectl script
+  %s export --settings -o setup.yaml   Take the whole setup to a new laptop
+`, programName, programName, programName, programName, programName, programName)
+}
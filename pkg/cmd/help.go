@@ -19,14 +19,39 @@ A terminal-based UI application for managing Kubernetes port forwards
 with project support and browser integration.
 
 Usage:
-  %s [command]
+  %s [--workspace <name>] [command]
+  %s [--workspace <name>] [--project <name>] [--start-all] [--accessible] [--dev]
 
 Available Commands:
   prune    Remove local services that no longer exist in the cluster
+  ports    Rewrite the local ports of many forwards at once
+  ids      Rename many forwards to a naming scheme at once
+  settings View and change persistent settings (e.g. kubectl timeouts)
+  stats    Summarize the configuration and how often forwards are used
+  report   Export the forwards, their state and recent errors as Markdown or JSON
+  lint     Check the configuration for clashing ports, stale project entries and odd IDs
+  db       Encrypt the configuration database at rest, or decrypt it
+  connect  Run a client (psql, redis-cli, ...) against a forward, starting it if needed
+  templates Define a forward once for every context matching a pattern
+  import   Import a kprtfwd export, or propose forwards for rendered manifests (helm template, kustomize build)
+  start    Start forwards or projects in the running kprtfwd, from scripts and other terminals
+  stop     Stop forwards or projects, or every forward of every running kprtfwd at once (stop --all)
+  list     List the forwards and their state in the running kprtfwd
+  run      Run every forward, or a project's, in the foreground without the TUI
+  export   Export forwards and projects as YAML or JSON, or as a kubectl port-forward script
+  digest   Send a list of long-running forwards into protected clusters to a webhook or command
   help     Show help information
 
 Options:
-  -h, --help  Show help information
+  -h, --help        Show help information
+  --project <name>  Activate a project and start its forwards on startup
+  --start-all       Start every configured forward on startup
+  --accessible      Screen-reader-friendly output: a plain list, no borders,
+                    status changes spelled out (also setting ui.accessible)
+  --dev             Developer mode: a simulated cluster instead of kubectl, with
+                    its own database (~/.kprtfwd/dev.db; also KPRTFWD_DEV=1)
+  --workspace <name>  Use a separate set of forwards, projects and settings
+                    (a database in ~/.kprtfwd/workspaces; also KPRTFWD_WORKSPACE)
 
 Interactive Mode:
   Run without any command to start the interactive TUI where you can:
@@ -38,14 +63,33 @@ Interactive Mode:
 
 Examples:
   %s                            Start interactive TUI
+  %s --project backend          Start the TUI with 'backend' active
+  %s --workspace acme           Start the TUI in the 'acme' workspace
+  %s --dev                      Try the TUI against a simulated cluster
   %s prune --context staging    Remove stale services from staging
+  %s ports rewrite --project api +10000   Move a project's local ports
+  %s ids rename --template '{{context}}.{{service}}'   Tidy up generated IDs
+  %s settings list              Show stored and available settings
+  %s stats                      Find forwards that are never started
+  %s report -o report.md        Save a snapshot for an incident ticket
+  %s lint --format json         Check the configuration, machine-readable
+  %s db encrypt                 Encrypt the database with a key in the keychain
+  %s connect prod.data.pg       Open the forward's client, starting it if needed
+  %s templates add eu-staging.payments.api '*-staging'   Mirror a forward across clusters
+  helm template shop ./chart | %s import -y   Forward a chart's services before deploying it
+  %s start backend              Start a project's forwards from a script
+  %s list --json                See what runs, machine-readable
+  %s stop --all                 Panic stop: stop every running forward
+  %s run --project backend      Keep a project's forwards up without the TUI
+  %s export -o forwards.yaml    Share the forwards and projects in git
+  %s digest --dry-run           See which production forwards have been open for hours
   %s help                       Show this help message
 
 For more information about a specific command, use:
   %s <command> --help
 
 Project Repository: https://github.com/xlttj/kprtfwd
-`, programName, programName, programName, programName, programName)
+`, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName)
 }
 
 // ShowMainHelpAndExit displays help and exits with code 0
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+)
+
+// HandleIDsCommand handles the ids subcommand logic
+func HandleIDsCommand() {
+	args := os.Args[2:]
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			showIDsHelp()
+			os.Exit(0)
+		}
+	}
+	if len(args) == 0 || args[0] != "rename" {
+		fmt.Printf("Error: expected 'ids rename'\n\n")
+		showIDsHelp()
+		os.Exit(1)
+	}
+
+	renameCmd := flag.NewFlagSet("ids rename", flag.ExitOnError)
+	templateFlag := renameCmd.String("template", "", "Template for the new IDs, e.g. {{context}}.{{namespace}}.{{service}}")
+	project := renameCmd.String("project", "", "Only rename forwards in this project")
+	ctxFlag := renameCmd.String("context", "", "Only rename forwards of this Kubernetes context")
+	namespaceFilter := renameCmd.String("namespace", "*", "Namespace filter with wildcard support (e.g., 'my-app-*')")
+	acceptAll := renameCmd.Bool("y", false, "Rename without prompting")
+	renameCmd.Usage = showIDsHelp
+	if err := renameCmd.Parse(args[1:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if *templateFlag == "" {
+		fmt.Printf("Error: --template is required\n\n")
+		showIDsHelp()
+		os.Exit(1)
+	}
+	template, err := config.ParseIDTemplate(*templateFlag)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	var members []string
+	if *project != "" {
+		for _, p := range store.GetProjects() {
+			if p.Name == *project {
+				members = p.Forwards
+			}
+		}
+		if members == nil {
+			fmt.Printf("Error: project '%s' not found\n", *project)
+			os.Exit(1)
+		}
+	}
+
+	var selected, others []config.PortForwardConfig
+	for _, cfg := range store.GetAll() {
+		if (*project == "" || slices.Contains(members, cfg.ID)) &&
+			(*ctxFlag == "" || cfg.Context == *ctxFlag) &&
+			discovery.MatchesWildcardPattern(cfg.Namespace, *namespaceFilter) {
+			selected = append(selected, cfg)
+		} else {
+			others = append(others, cfg)
+		}
+	}
+	if len(selected) == 0 {
+		fmt.Println("No port forwards match.")
+		return
+	}
+
+	renames, err := config.ProposeRenames(selected, others, template)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(renames) == 0 {
+		fmt.Printf("All %d matching forward(s) already follow %s.\n", len(selected), template)
+		return
+	}
+
+	fmt.Printf("Renaming %d of %d forward(s) to %s:\n", len(renames), len(selected), template)
+	for _, r := range renames {
+		fmt.Printf("  - %s → %s\n", r.From, r.To)
+	}
+	if !*acceptAll {
+		reader := bufio.NewReader(os.Stdin)
+		renames = chooseRenames(reader, renames)
+		if len(renames) == 0 {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	// Skipping a rename can leave a chosen one colliding with the ID it
+	// would have freed; the store refuses that and changes nothing
+	if err := store.RenamePortForwards(renames); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Renamed %d forward(s); projects, snapshots and history follow the new IDs.\n", len(renames))
+}
+
+// chooseRenames asks whether to apply all renames, none, or pick them one by
+// one, and returns the chosen ones
+func chooseRenames(reader *bufio.Reader, renames []config.IDRename) []config.IDRename {
+	fmt.Print("Apply? [y]es / [N]o / [s]elect one by one: ")
+	resp, _ := reader.ReadString('\n')
+	switch strings.TrimSpace(strings.ToLower(resp)) {
+	case "y", "yes":
+		return renames
+	case "s", "select":
+	default:
+		return nil
+	}
+
+	var chosen []config.IDRename
+	for _, r := range renames {
+		if confirm(reader, fmt.Sprintf("  %s → %s? [y/N]: ", r.From, r.To)) {
+			chosen = append(chosen, r)
+		}
+	}
+	return chosen
+}
+
+// showIDsHelp displays help for the ids command
+func showIDsHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s ids - Rename many forwards to a naming scheme at once
+
+Usage:
+  %s ids rename --template <template> [options]
+
+Proposes a new ID for every matching forward from the template, shows the
+list and applies it after confirmation. Projects, discovery snapshots and the
+start/stop history follow the new IDs. Forwards that would get the same ID
+have their remote port appended. A running kprtfwd TUI stops the forwards
+it started under an old ID; start them again under the new one.
+
+Placeholders:
+  {{context}}           The forward's context, sanitized like discovery IDs
+  {{namespace}}         Its namespace
+  {{service}}           Its service
+  {{port}}              Its remote port
+  {{local_port}}        Its local port
+
+Options:
+  --template string     Template for the new IDs (required)
+  --project string      Only rename forwards in this project
+  --context string      Only rename forwards of this Kubernetes context
+  --namespace string    Namespace filter with wildcard support (default "*")
+  -y                    Rename without prompting for confirmation
+  -h, --help            Show this help message
+
+Examples:
+  %s ids rename --template '{{context}}.{{namespace}}.{{service}}'
+  %s ids rename --context staging --template 'stg.{{service}}.{{port}}'
+`, programName, programName, programName, programName)
+}
@@ -0,0 +1,384 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// HandleImportCommand handles the import subcommand logic
+func HandleImportCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showImportHelp()
+			os.Exit(0)
+		}
+	}
+
+	importCmd := flag.NewFlagSet("import", flag.ExitOnError)
+	ctxFlag := importCmd.String("context", "", "Kubernetes context the forwards use (defaults to current context)")
+	namespace := importCmd.String("namespace", "default", "Namespace of services whose manifest names none")
+	acceptAll := importCmd.Bool("y", false, "Add the proposed forwards without prompting")
+	importStrategy := importCmd.String("strategy", config.ImportMerge, "For a kprtfwd export: merge, or overwrite forwards, projects and settings that exist already")
+	dryRun := importCmd.Bool("dry-run", false, "For a kprtfwd export: show what would change without changing it")
+	allowCommands := importCmd.Bool("allow-commands", false, "For a kprtfwd export: import the hooks, open commands, kubectl arguments and local paths it sets")
+	importCmd.Usage = showImportHelp
+	if err := importCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if importCmd.NArg() > 1 {
+		fmt.Printf("Error: 'import' expects one manifest file, or - for stdin\n\n")
+		showImportHelp()
+		os.Exit(1)
+	}
+	source := "-"
+	if importCmd.NArg() == 1 {
+		source = importCmd.Arg(0)
+	}
+	if err := config.ValidateKubernetesName("namespace", *namespace); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		fmt.Printf("Error reading manifests: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	kubectl.ApplySettings(store.GetSettings())
+
+	if dump, ok, err := readConfigDump(data); ok {
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		importConfigDump(store, dump, *importStrategy, *dryRun, *acceptAll, *allowCommands, source == "-")
+		return
+	}
+
+	// The release may not be deployed yet, but the context has to exist for
+	// the forwards to start one day
+	kubeContext := *ctxFlag
+	if kubeContext == "" {
+		if kubeContext, err = discovery.CurrentContext(); err != nil {
+			fmt.Printf("Error: no --context given and the current context is unknown: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if err := config.ValidateContextName(kubeContext); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	result, err := discovery.ParseManifests(data, kubeContext, *namespace)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(result.Services) == 0 {
+		fmt.Println("No forwardable services in the manifests.")
+		return
+	}
+
+	// Propose a forward per service port, with local ports from the
+	// context's ports.local strategy, as discovery would
+	strategy := config.LocalPortStrategy(store.GetSettings(), kubeContext)
+	taken := slices.Clone(store.GetAll())
+	var proposed []config.PortForwardConfig
+	fmt.Printf("Services in the manifests, for context %s:\n", kubeContext)
+	for _, cfg := range result.GenerateConfig() {
+		if existing, ok := configuredService(taken, cfg); ok {
+			fmt.Printf("  = %s (%s/%s:%d) already forwarded by %s\n", cfg.ID, cfg.Namespace, cfg.Service, cfg.PortRemote, existing)
+			continue
+		}
+		if _, exists := store.GetConfigByID(cfg.ID); exists {
+			fmt.Printf("  ! %s exists for another service, skipped\n", cfg.ID)
+			continue
+		}
+		cfg.PortLocal = config.DefaultLocalPort(strategy, cfg.PortRemote, taken)
+		taken = append(taken, cfg)
+		proposed = append(proposed, cfg)
+		fmt.Printf("  + %s (%s/%s) %d→%d\n", cfg.ID, cfg.Namespace, cfg.Service, cfg.PortLocal, cfg.PortRemote)
+	}
+	if len(proposed) == 0 {
+		fmt.Println("✅ Every service port is forwarded already.")
+		return
+	}
+
+	if !*acceptAll {
+		// Stdin held the manifests, so there is no one to ask
+		if source == "-" {
+			fmt.Printf("Nothing added. Run again with -y to add these %d forward(s).\n", len(proposed))
+			return
+		}
+		if !confirm(bufio.NewReader(os.Stdin), fmt.Sprintf("Add %d forward(s)? [y/N]: ", len(proposed))) {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	added := 0
+	for _, cfg := range proposed {
+		if err := store.Add(cfg); err != nil {
+			fmt.Printf("Error adding %s: %v\n", cfg.ID, err)
+			continue
+		}
+		if snap, ok := manifestSnapshot(result, cfg); ok {
+			if err := store.SetServiceSnapshot(cfg.ID, snap); err != nil {
+				// The forward itself was added; prune just has less to go on
+				logging.LogError("Failed to record import snapshot for %s: %v", cfg.ID, err)
+			}
+		}
+		added++
+	}
+	fmt.Printf("✅ Added %d forward(s).\n", added)
+}
+
+// readConfigDump reads data as a kprtfwd export, in JSON or YAML. It reports
+// false when data is something else, such as manifests. YAML that does not
+// parse is an error either way, so it is reported here rather than passed on.
+func readConfigDump(data []byte) (config.ConfigDump, bool, error) {
+	var doc any
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		if json.Unmarshal(trimmed, &doc) != nil {
+			return config.ConfigDump{}, false, nil
+		}
+	} else {
+		docs, err := discovery.ParseYAML(data)
+		if err != nil {
+			return config.ConfigDump{}, true, err
+		}
+		if len(docs) != 1 {
+			return config.ConfigDump{}, false, nil
+		}
+		doc = docs[0]
+	}
+	m, ok := doc.(map[string]any)
+	if !ok {
+		return config.ConfigDump{}, false, nil
+	}
+	if _, ok := m["kprtfwd"]; !ok {
+		return config.ConfigDump{}, false, nil
+	}
+	raw, err := json.Marshal(m)
+	if err != nil {
+		return config.ConfigDump{}, true, err
+	}
+	dump, err := config.DecodeConfigDump(raw)
+	return dump, true, err
+}
+
+// importConfigDump shows what importing dump by strategy changes and, once
+// confirmed, imports it. Commands the forwards would gain are listed in full
+// and imported only with allowCommands: kprtfwd runs them later unasked.
+func importConfigDump(store *config.SQLiteConfigStore, dump config.ConfigDump, strategy string, dryRun, acceptAll, allowCommands, fromStdin bool) {
+	plan, err := store.ImportConfigDump(dump, strategy, true)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("kprtfwd export with %d forward(s), %d project(s) and %d setting(s):\n", len(dump.Forwards), len(dump.Projects), len(dump.Settings))
+	commands := make(map[string][]config.ImportedCommand)
+	for _, c := range plan.Commands {
+		commands[c.ID] = append(commands[c.ID], c)
+	}
+	for _, id := range plan.Added {
+		fmt.Printf("  + %s\n", id)
+		printImportedCommands(commands[id])
+	}
+	for _, id := range plan.Updated {
+		fmt.Printf("  ~ %s replaced by the export's\n", id)
+		printImportedCommands(commands[id])
+	}
+	for _, id := range plan.Kept {
+		fmt.Printf("  ! %s differs here, kept (--strategy overwrite replaces it)\n", id)
+	}
+	for _, id := range plan.Unchanged {
+		fmt.Printf("  = %s\n", id)
+	}
+	for _, name := range plan.ProjectsAdded {
+		fmt.Printf("  + project %s\n", name)
+	}
+	for _, name := range plan.ProjectsUpdated {
+		fmt.Printf("  ~ project %s gets the export's forwards\n", name)
+	}
+	localSettings := make(map[string]bool)
+	for _, c := range commands[""] {
+		localSettings[c.Field] = true
+	}
+	for _, key := range plan.SettingsAdded {
+		fmt.Printf("  + setting %s = %s\n", key, dump.Settings[key])
+		if localSettings[key] {
+			fmt.Println("      $ a path, URL or command of this machine")
+		}
+	}
+	for _, key := range plan.SettingsUpdated {
+		fmt.Printf("  ~ setting %s = %s, replacing %s\n", key, dump.Settings[key], store.GetStoredSettings()[key])
+		if localSettings[key] {
+			fmt.Println("      $ a path, URL or command of this machine")
+		}
+	}
+	for _, key := range plan.SettingsKept {
+		fmt.Printf("  ! setting %s differs here, kept (--strategy overwrite replaces it)\n", key)
+	}
+
+	changes := len(plan.Added) + len(plan.Updated) + len(plan.ProjectsAdded) + len(plan.ProjectsUpdated) +
+		len(plan.SettingsAdded) + len(plan.SettingsUpdated)
+	if changes == 0 {
+		fmt.Println("✅ Nothing to import: the configuration has the export's forwards, projects and settings already.")
+		return
+	}
+	if len(plan.Commands) > 0 && !allowCommands {
+		fmt.Printf("The export sets %d command(s) or local path(s), marked $ above, that kprtfwd runs or trusts without asking.\n", len(plan.Commands))
+		if dryRun {
+			fmt.Println("Dry run: nothing changed. Importing them takes --allow-commands.")
+			return
+		}
+		fmt.Println("Nothing imported. Check them, then run again with --allow-commands to import them.")
+		os.Exit(1)
+	}
+	if dryRun {
+		fmt.Println("Dry run: nothing changed.")
+		return
+	}
+	if !acceptAll {
+		// Stdin held the export, so there is no one to ask
+		if fromStdin {
+			fmt.Println("Nothing imported. Run again with -y to import.")
+			return
+		}
+		if !confirm(bufio.NewReader(os.Stdin), fmt.Sprintf("Make these %d change(s)? [y/N]: ", changes)) {
+			fmt.Println("Aborted.")
+			return
+		}
+	}
+
+	result, err := store.ImportConfigDump(dump, strategy, false)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Imported: %d forward(s) added, %d replaced, %d project(s) added, %d updated, %d setting(s) stored.\n",
+		len(result.Added), len(result.Updated), len(result.ProjectsAdded), len(result.ProjectsUpdated),
+		len(result.SettingsAdded)+len(result.SettingsUpdated))
+	if len(result.Added) > 0 || len(result.Updated) > 0 {
+		fmt.Println("Run kprtfwd lint to check the local ports for clashes.")
+	}
+}
+
+// printImportedCommands lists commands under the forward they belong to
+func printImportedCommands(commands []config.ImportedCommand) {
+	for _, c := range commands {
+		fmt.Printf("      $ %s: %q\n", c.Field, c.Value)
+	}
+}
+
+// configuredService returns the ID of the configured forward to cfg's
+// service port, if there is one
+func configuredService(configs []config.PortForwardConfig, cfg config.PortForwardConfig) (string, bool) {
+	for _, c := range configs {
+		if c.Context == cfg.Context && c.Namespace == cfg.Namespace && c.Service == cfg.Service && c.PortRemote == cfg.PortRemote {
+			return c.ID, true
+		}
+	}
+	return "", false
+}
+
+// manifestSnapshot returns what the manifests say about cfg's service port,
+// recorded like a discovery snapshot so prune can later spot a rename
+func manifestSnapshot(result *discovery.DiscoveryResult, cfg config.PortForwardConfig) (config.ServiceSnapshot, bool) {
+	for _, s := range result.Services {
+		svc := s.ServiceInfo
+		if svc.Namespace != cfg.Namespace || svc.Name != cfg.Service {
+			continue
+		}
+		for _, port := range svc.Ports {
+			if int(port.Port) == cfg.PortRemote {
+				return config.ServiceSnapshot{
+					Type:         svc.Type,
+					Labels:       svc.Labels,
+					TargetPort:   port.TargetPort,
+					PortName:     port.Name,
+					DiscoveredAt: time.Now(),
+				}, true
+			}
+		}
+	}
+	return config.ServiceSnapshot{}, false
+}
+
+// showImportHelp displays help for the import command
+func showImportHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s import - Import a kprtfwd export, or forwards for rendered manifests
+
+Reads Kubernetes manifests, such as the output of 'helm template' or
+'kustomize build', and proposes a forward for every port of every Service in
+them, named and numbered as discovery would. The release does not have to be
+deployed: nothing is asked of the cluster except, without --context, the
+current context's name.
+
+Service ports that already have a forward are left alone. ExternalName
+services are skipped, as they have no pods to forward to.
+
+A file written by kprtfwd export --format yaml or json is imported as it is:
+its forwards and projects, and settings if it has them, are added to the
+configuration. A forward, project or setting that exists already is kept
+with --strategy merge, the default, though a project gains the export's
+forwards; --strategy overwrite replaces them with the export's. Nothing the
+export does not name is removed.
+
+The hooks, open commands and kubectl arguments an export gives forwards are
+listed in full, as kprtfwd runs them later without asking, as are the
+settings naming a path, URL or command of the machine, such as kubectl.path;
+an export that sets any is only imported with --allow-commands.
+
+Usage:
+  %s import [options] [file]    Read the manifests or export from file, or stdin if none or -
+
+Options:
+  --context string      Kubernetes context the forwards use (defaults to current context)
+  --namespace string    Namespace of services whose manifest names none (default "default");
+                        pass the release's namespace, as 'helm template' leaves it out
+  -y                    Add the proposed forwards without prompting; required
+                        when the manifests come from stdin
+  --strategy string     For an export: merge or overwrite (default merge)
+  --dry-run             For an export: show what would change, change nothing
+  --allow-commands      For an export: import the hooks, open commands,
+                        kubectl arguments and local paths it sets, after
+                        checking them
+  -h, --help            Show this help message
+
+Examples:
+  helm template shop ./charts/shop -n shop | %s import --namespace shop -y
+  kustomize build overlays/dev | %s import --context dev -y
+  %s import --context staging rendered.yaml
+  %s import --strategy overwrite team-forwards.yaml
+`, programName, programName, programName, programName, programName, programName)
+}
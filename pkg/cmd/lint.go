@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// HandleLintCommand handles the lint subcommand logic
+func HandleLintCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showLintHelp()
+			os.Exit(0)
+		}
+	}
+
+	lintCmd := flag.NewFlagSet("lint", flag.ExitOnError)
+	format := lintCmd.String("format", "text", "Output format: text or json")
+	idPattern := lintCmd.String("id-pattern", "", "Regular expression every forward ID must match")
+	strict := lintCmd.Bool("strict", false, "Fail on warnings too")
+	lintCmd.Usage = showLintHelp
+	if err := lintCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if *format != "text" && *format != "json" {
+		fmt.Printf("Error: unknown format '%s' (use text or json)\n", *format)
+		os.Exit(2)
+	}
+	var pattern *regexp.Regexp
+	if *idPattern != "" {
+		var err error
+		if pattern, err = regexp.Compile(*idPattern); err != nil {
+			fmt.Printf("Error: invalid --id-pattern: %v\n", err)
+			os.Exit(2)
+		}
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(2)
+	}
+	findings := config.Lint(store.GetAll(), store.GetAllProjects(), pattern)
+	store.Close()
+
+	errorCount := 0
+	for _, f := range findings {
+		if f.Severity == config.LintError {
+			errorCount++
+		}
+	}
+
+	if *format == "json" {
+		if findings == nil {
+			findings = []config.LintFinding{}
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(findings); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(2)
+		}
+	} else {
+		for _, f := range findings {
+			subject := f.ID
+			if subject == "" {
+				subject = f.Project
+			}
+			fmt.Printf("%-7s %-18s %s: %s\n", f.Severity, f.Rule, subject, f.Message)
+		}
+		if len(findings) == 0 {
+			fmt.Println("✅ No problems found")
+		} else {
+			fmt.Printf("\n%d error(s), %d warning(s)\n", errorCount, len(findings)-errorCount)
+		}
+	}
+
+	if errorCount > 0 || (*strict && len(findings) > 0) {
+		os.Exit(1)
+	}
+}
+
+// showLintHelp displays help for the lint command
+func showLintHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s lint - Check the configuration for problems
+
+Usage:
+  %s lint [options]
+
+Rules:
+  local-port-clash  (error)   Two forwards share a local port, so only one can run
+  missing-forward   (error)   A project lists a forward ID that no longer exists
+  id-convention     (warning) An ID does not start with <context>.<namespace>.
+                              as discovery generates them, or does not match
+                              --id-pattern
+  no-project        (warning) A forward belongs to no project (only checked
+                              once there are projects)
+
+Exit status is 0 when there are no errors (or, with --strict, no findings at
+all), 1 when there are, and 2 when the check could not run. Point KPRTFWD_DB
+at a copy of a database to lint it, e.g. in a pre-commit hook.
+
+Options:
+  --format string       Output format: text or json (default text)
+  --id-pattern string   Regular expression every forward ID must match
+  --strict              Fail on warnings too
+  -h, --help            Show this help message
+
+Examples:
+  %s lint                              Show all problems
+  %s lint --format json --strict       Machine-readable, fail on any finding
+  %s lint --id-pattern '^[a-z0-9-]+(\.[a-z0-9-]+){2,}$'
+`, programName, programName, programName, programName, programName)
+}
@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// listedForward is a forward as kprtfwd list --json shows it
+type listedForward struct {
+	ID        string `json:"id"`
+	Context   string `json:"context"`
+	Namespace string `json:"namespace"`
+	Target    string `json:"target"` // svc/NAME, pod/NAME, ...
+	PortLocal string `json:"port_local"`
+	Status    string `json:"status"` // "stopped" unless a running kprtfwd runs it
+	Detail    string `json:"detail,omitempty"`
+	PID       int    `json:"pid,omitempty"` // of the kprtfwd running it
+	Mode      string `json:"mode,omitempty"`
+}
+
+// HandleListCommand handles the list subcommand logic
+func HandleListCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showListHelp()
+			os.Exit(0)
+		}
+	}
+
+	listCmd := flag.NewFlagSet("list", flag.ExitOnError)
+	asJSON := listCmd.Bool("json", false, "Print JSON instead of a table")
+	listCmd.Usage = showListHelp
+	if err := listCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if listCmd.NArg() > 0 {
+		fmt.Printf("Error: unexpected argument '%s'\n\n", listCmd.Arg(0))
+		showListHelp()
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	hosts, err := config.HostStates()
+	if err != nil {
+		fmt.Printf("Error reading the running kprtfwd: %v\n", err)
+		os.Exit(1)
+	}
+
+	forwards := []listedForward{}
+	for _, cfg := range store.GetAll() {
+		f := listedForward{
+			ID:        cfg.ID,
+			Context:   cfg.Context,
+			Namespace: cfg.Namespace,
+			Target:    cfg.Target(),
+			PortLocal: portSpan(cfg.PortLocal, cfg.Ports()),
+			Status:    "stopped",
+		}
+		if host, hf, ok := findHostForward(hosts, cfg.ID); ok {
+			f.Status, f.Detail, f.PID, f.Mode = hf.Status, hf.Detail, host.PID, host.Mode
+		}
+		forwards = append(forwards, f)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(forwards); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(forwards) == 0 {
+		fmt.Println("No forwards configured.")
+		return
+	}
+	fmt.Printf("%-36s %-30s %-11s %-9s %s\n", "ID", "TARGET", "LOCAL", "STATUS", "HOST")
+	for _, f := range forwards {
+		host := ""
+		if f.PID != 0 {
+			host = fmt.Sprintf("%s %d", f.Mode, f.PID)
+		}
+		fmt.Printf("%-36s %-30s %-11s %-9s %s\n", f.ID, f.Target, f.PortLocal, f.Status, host)
+		if f.Detail != "" {
+			fmt.Printf("  %s\n", f.Detail)
+		}
+	}
+}
+
+// showListHelp displays help for the list command
+func showListHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s list - List the forwards and what runs them
+
+Usage:
+  %s list [options]
+
+Lists every forward of the workspace with its state in the running kprtfwd
+(the TUI or kprtfwd run) that has it, and that kprtfwd's PID. Forwards no
+running kprtfwd has are stopped.
+
+Options:
+  --json                Print JSON instead of a table
+  -h, --help            Show this help message
+
+Examples:
+  %s list
+  %s list --json | jq -r '.[] | select(.status == "failed") | .id'
+`, programName, programName, programName, programName)
+}
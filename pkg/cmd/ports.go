@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+)
+
+// HandlePortsCommand handles the ports subcommand logic
+func HandlePortsCommand() {
+	args := os.Args[2:]
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			showPortsHelp()
+			os.Exit(0)
+		}
+	}
+	if len(args) == 0 || args[0] != "rewrite" {
+		fmt.Printf("Error: expected 'ports rewrite'\n\n")
+		showPortsHelp()
+		os.Exit(1)
+	}
+
+	rewriteCmd := flag.NewFlagSet("ports rewrite", flag.ExitOnError)
+	project := rewriteCmd.String("project", "", "Only rewrite forwards in this project")
+	ctxFlag := rewriteCmd.String("context", "", "Only rewrite forwards of this Kubernetes context")
+	namespaceFilter := rewriteCmd.String("namespace", "*", "Namespace filter with wildcard support (e.g., 'my-app-*')")
+	acceptAll := rewriteCmd.Bool("y", false, "Rewrite without prompting")
+
+	rewriteCmd.Usage = showPortsHelp
+
+	// A "-1000" rule would otherwise be parsed as an unknown flag
+	var flagArgs, ruleArgs []string
+	for _, arg := range args[1:] {
+		if _, err := strconv.Atoi(arg); err == nil && strings.HasPrefix(arg, "-") {
+			ruleArgs = append(ruleArgs, arg)
+		} else {
+			flagArgs = append(flagArgs, arg)
+		}
+	}
+	if err := rewriteCmd.Parse(flagArgs); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	// "prefix 1" may arrive quoted or as two arguments
+	rule, err := config.ParsePortRewrite(strings.Join(append(rewriteCmd.Args(), ruleArgs...), " "))
+	if err != nil {
+		fmt.Printf("Error: %v\n\n", err)
+		showPortsHelp()
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	var members []string
+	if *project != "" {
+		for _, p := range store.GetProjects() {
+			if p.Name == *project {
+				members = p.Forwards
+			}
+		}
+		if members == nil {
+			fmt.Printf("Error: project '%s' not found\n", *project)
+			os.Exit(1)
+		}
+	}
+
+	var selected, others []config.PortForwardConfig
+	for _, cfg := range store.GetAll() {
+		if (*project == "" || slices.Contains(members, cfg.ID)) &&
+			(*ctxFlag == "" || cfg.Context == *ctxFlag) &&
+			discovery.MatchesWildcardPattern(cfg.Namespace, *namespaceFilter) {
+			selected = append(selected, cfg)
+		} else {
+			others = append(others, cfg)
+		}
+	}
+	if len(selected) == 0 {
+		fmt.Println("No port forwards match.")
+		return
+	}
+
+	rewritten, err := config.RewriteLocalPorts(selected, others, rule)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Rewriting %d local port(s) with %q:\n", len(rewritten), rule.String())
+	for i, cfg := range rewritten {
+		fmt.Printf("  - %s: %d → %d\n", cfg.ID, selected[i].PortLocal, cfg.PortLocal)
+	}
+	if !*acceptAll && !confirm(bufio.NewReader(os.Stdin), "Apply? [y/N]: ") {
+		fmt.Println("Aborted.")
+		return
+	}
+	if err := store.UpdatePortForwards(rewritten); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Rewrote %d local port(s). Running forwards use the new ports once restarted.\n", len(rewritten))
+}
+
+// showPortsHelp displays help for the ports command
+func showPortsHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s ports - Change the local ports of many forwards at once
+
+Usage:
+  %s ports rewrite [options] <rule>
+
+Rules:
+  +N                    Add N to each local port (8080 → 18080 with +10000)
+  -N                    Subtract N from each local port
+  prefix D              Put the digits D in front of each local port (8080 → 18080 with prefix 1)
+
+A port range moves as a block, following its first port. Nothing changes if
+any rewritten port is out of range or collides with another forward.
+
+Options:
+  --project string      Only rewrite forwards in this project
+  --context string      Only rewrite forwards of this Kubernetes context
+  --namespace string    Namespace filter with wildcard support (default "*")
+  -y                    Rewrite without prompting for confirmation
+  -h, --help            Show this help message
+
+Examples:
+  %s ports rewrite --project backend +10000      Move a project's ports up by 10000
+  %s ports rewrite --context staging prefix 2    8080 → 28080 for every staging forward
+`, programName, programName, programName, programName)
+}
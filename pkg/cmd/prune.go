@@ -5,10 +5,12 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
 	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
 )
 
 // HandlePruneCommand handles the prune subcommand logic
@@ -36,6 +38,15 @@ func HandlePruneCommand() {
 		os.Exit(1)
 	}
 
+	// Load local configs (and kubectl settings, before any kubectl call)
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	kubectl.ApplySettings(store.GetSettings())
+
 	// Discover current services in the cluster
 	discoveryOpts := discovery.Options{
 		NamespaceFilter: *namespaceFilter,
@@ -50,62 +61,123 @@ func HandlePruneCommand() {
 	if *verbose {
 		fmt.Printf("Prune in context: %s, namespace filter: %s\n", getContextDisplay(actualContext), *namespaceFilter)
 	}
-	// Build discovered service set namespace/name
-	discovered := make(map[string]bool)
-	for _, svc := range result.Services {
-		key := svc.ServiceInfo.Namespace + "/" + svc.ServiceInfo.Name
-		discovered[key] = true
+	// Find stale entries, and among them the ones whose service reappears
+	// under a new name
+	stale := discovery.FindStaleForwards(store.GetAll(), result, store.GetServiceSnapshot)
+	if len(stale) == 0 {
+		fmt.Printf("✅ No stale services to remove.\n")
+		return
 	}
-	// Load local configs
-	store, err := config.NewSQLiteConfigStore()
-	if err != nil {
-		fmt.Printf("Error opening config store: %v\n", err)
-		os.Exit(1)
+	fmt.Printf("Found %d stale service(s):\n", len(stale))
+	staleIDs := make([]string, len(stale))
+	for i, s := range stale {
+		staleIDs[i] = s.Config.ID
 	}
-	defer store.Close()
-	configs := store.GetAll()
-	// Find stale entries
-	var stale []config.PortForwardConfig
-	for _, cfg := range configs {
-		if cfg.Context != actualContext {
-			continue
+	emptied := config.EmptiedProjects(config.ProjectLosses(store.GetAllProjects(), staleIDs))
+	projects := config.ProjectsByForward(store.GetAllProjects())
+	renamed := 0
+	for _, s := range stale {
+		cfg := s.Config
+		if s.RenamedTo != nil {
+			renamed++
+			fmt.Printf("  - %s (%s/%s:%d) renamed to %s\n", cfg.ID, cfg.Namespace, cfg.Service, cfg.PortRemote, s.RenamedTo.Name)
+		} else {
+			fmt.Printf("  - %s (%s/%s:%d) deleted\n", cfg.ID, cfg.Namespace, cfg.Service, cfg.PortRemote)
 		}
-		if !discovery.MatchesWildcardPattern(cfg.Namespace, *namespaceFilter) {
-			continue
+		if names := projects[cfg.ID]; len(names) > 0 {
+			for i, name := range names {
+				if slices.Contains(emptied, name) {
+					names[i] += " (left empty if all are deleted)"
+				}
+			}
+			fmt.Printf("      in projects: %s\n", strings.Join(names, ", "))
+		}
+		if *verbose {
+			if snap, ok := store.GetServiceSnapshot(cfg.ID); ok {
+				fmt.Printf("      discovered %s as %s, target port %s, labels %s\n",
+					snap.DiscoveredAt.Format("2006-01-02"), snap.Type, snap.TargetPort, snap.LabelString())
+			}
 		}
-		key := cfg.Namespace + "/" + cfg.Service
-		if !discovered[key] {
-			stale = append(stale, cfg)
+	}
+	reader := bufio.NewReader(os.Stdin)
+	// Renamed services keep their forward, pointed at the new name
+	retargeted := 0
+	if renamed > 0 {
+		if *acceptAll || confirm(reader, "Point renamed services' forwards at their new names? [y/N]: ") {
+			var remaining []discovery.StaleForward
+			for _, s := range stale {
+				if s.RenamedTo == nil {
+					remaining = append(remaining, s)
+					continue
+				}
+				cfg := s.Config
+				cfg.Service = s.RenamedTo.Name
+				if err := store.UpdatePortForward(cfg.ID, cfg); err != nil {
+					fmt.Printf("Error updating %s: %v\n", cfg.ID, err)
+					continue
+				}
+				retargeted++
+			}
+			stale = remaining
+			fmt.Printf("🔁 Updated %d renamed service(s).\n", retargeted)
 		}
 	}
 	if len(stale) == 0 {
-		fmt.Printf("✅ No stale services to remove.\n")
 		return
 	}
-	fmt.Printf("Found %d stale service(s):\n", len(stale))
+	staleIDs = staleIDs[:0]
 	for _, s := range stale {
-		fmt.Printf("  - %s (%s/%s:%d)\n", s.ID, s.Namespace, s.Service, s.PortRemote)
-	}
-	if !*acceptAll {
-		fmt.Print("Delete these services from local config? [y/N]: ")
-		reader := bufio.NewReader(os.Stdin)
-		resp, _ := reader.ReadString('\n')
-		resp = strings.TrimSpace(strings.ToLower(resp))
-		if resp != "y" && resp != "yes" {
-			fmt.Println("Aborted.")
-			return
-		}
+		staleIDs = append(staleIDs, s.Config.ID)
+	}
+	losses := config.ProjectLosses(store.GetAllProjects(), staleIDs)
+	if len(losses) > 0 {
+		fmt.Printf("Deleting removes forwards from projects: %s\n", config.JoinProjectLosses(losses))
+	}
+	if !*acceptAll && !confirm(reader, fmt.Sprintf("Delete %d service(s) from local config? [y/N]: ", len(stale))) {
+		fmt.Println("Aborted.")
+		return
 	}
 	// Delete
 	deleted := 0
 	for _, s := range stale {
-		if err := store.DeletePortForward(s.ID); err != nil {
-			fmt.Printf("Error deleting %s: %v\n", s.ID, err)
+		if err := store.DeletePortForward(s.Config.ID); err != nil {
+			fmt.Printf("Error deleting %s: %v\n", s.Config.ID, err)
 			continue
 		}
 		deleted++
 	}
 	fmt.Printf("🧹 Removed %d stale service(s).\n", deleted)
+
+	// Projects the deletes left empty, unless a delete failed
+	emptied = nil
+	for _, project := range store.GetAllProjects() {
+		if len(project.Forwards) == 0 && slices.Contains(config.EmptiedProjects(losses), project.Name) {
+			emptied = append(emptied, project.Name)
+		}
+	}
+	if len(emptied) == 0 {
+		return
+	}
+	if !*acceptAll && !confirm(reader, fmt.Sprintf("Delete the %d project(s) left empty (%s)? [y/N]: ", len(emptied), strings.Join(emptied, ", "))) {
+		return
+	}
+	removed := 0
+	for _, name := range emptied {
+		if err := store.DeleteProject(name); err != nil {
+			fmt.Printf("Error deleting project %s: %v\n", name, err)
+			continue
+		}
+		removed++
+	}
+	fmt.Printf("🗂 Removed %d empty project(s).\n", removed)
+}
+
+// confirm prints prompt and reports whether the user answered yes
+func confirm(reader *bufio.Reader, prompt string) bool {
+	fmt.Print(prompt)
+	resp, _ := reader.ReadString('\n')
+	resp = strings.TrimSpace(strings.ToLower(resp))
+	return resp == "y" || resp == "yes"
 }
 
 // getContextDisplay formats the context name for display
@@ -131,8 +203,9 @@ Options:
   --context string      Kubernetes context to use (defaults to current context)
   --namespace string    Namespace filter with wildcard support (default "*")
                         Examples: 'app-*', '*-prod', 'staging'
-  -y                    Delete without prompting for confirmation
-  -v                    Enable verbose output
+  -y                    Update and delete without prompting for confirmation,
+                        including projects the deletes leave empty
+  -v                    Enable verbose output (includes discovery snapshots)
   -h, --help            Show this help message
 
 Examples:
@@ -146,8 +219,15 @@ How it works:
   1. Discovers current services in the specified cluster/namespaces
   2. Compares against your local port forward configurations
   3. Identifies configurations for services that no longer exist
-  4. Prompts for confirmation before removal (unless -y is used)
+  4. Flags a missing service as renamed when another service in its namespace
+     has the type, labels and port recorded when it was discovered
+  5. Offers to point renamed services' forwards at the new name, then prompts
+     before removing the rest (unless -y is used), naming the projects that
+     lose forwards
+  6. Offers to delete the projects that are left without forwards
 
 This helps keep your local configuration in sync with your cluster state.
+A running kprtfwd TUI stops the forwards it started for removed entries
+within a few seconds.
 `, programName, programName, programName, programName, programName, programName, programName)
 }
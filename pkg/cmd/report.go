@@ -0,0 +1,298 @@
+package cmd
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// Forward states in a report. The TUI runs in its own process, so a report
+// works them out from the recorded start/stop history and whether the local
+// port is listening.
+const (
+	reportStateRunning      = "running"
+	reportStateNotListening = "not listening" // started, but the local port is free
+	reportStateStopped      = "stopped"
+	reportStatePortInUse    = "stopped, port in use" // another process holds the local port
+)
+
+// report is a snapshot of the configured forwards and their environment
+type report struct {
+	GeneratedAt   time.Time       `json:"generated_at"`
+	Versions      reportVersions  `json:"versions"`
+	ActiveProject string          `json:"active_project,omitempty"`
+	Forwards      []reportForward `json:"forwards"`
+	RecentErrors  []string        `json:"recent_errors"`
+}
+
+type reportVersions struct {
+	Kprtfwd string `json:"kprtfwd"`
+	Go      string `json:"go"`
+	OS      string `json:"os"`
+	Kubectl string `json:"kubectl"`
+}
+
+type reportForward struct {
+	ID          string     `json:"id"`
+	Context     string     `json:"context"`
+	Namespace   string     `json:"namespace"`
+	Service     string     `json:"service"`
+	PortLocal   string     `json:"port_local"`
+	PortRemote  string     `json:"port_remote"`
+	Mode        string     `json:"mode"`
+	Projects    []string   `json:"projects,omitempty"`
+	State       string     `json:"state"`
+	LastStarted *time.Time `json:"last_started,omitempty"`
+}
+
+// HandleReportCommand handles the report subcommand logic
+func HandleReportCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showReportHelp()
+			os.Exit(0)
+		}
+	}
+
+	reportCmd := flag.NewFlagSet("report", flag.ExitOnError)
+	format := reportCmd.String("format", "markdown", "Output format: markdown or json")
+	output := reportCmd.String("o", "", "Write the report to this file instead of stdout")
+	errorCount := reportCmd.Int("errors", 20, "How many recent errors from the log to include")
+	reportCmd.Usage = showReportHelp
+	if err := reportCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if *format != "markdown" && *format != "json" {
+		fmt.Printf("Error: unknown format '%s' (use markdown or json)\n", *format)
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+	kubectl.ApplySettings(store.GetSettings())
+
+	events, err := store.ForwardEvents()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	r := buildReport(store.GetAll(), store.GetAllProjects(), events)
+	r.ActiveProject = store.GetActiveProjectName()
+	if recent := logging.RecentErrors(*errorCount); recent != nil {
+		r.RecentErrors = recent
+	}
+
+	out := io.Writer(os.Stdout)
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		out = f
+	}
+	if *format == "json" {
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(r)
+	} else {
+		err = writeMarkdownReport(out, r)
+	}
+	if err != nil {
+		fmt.Printf("Error writing report: %v\n", err)
+		os.Exit(1)
+	}
+	if *output != "" {
+		fmt.Printf("Report of %d forward(s) written to %s\n", len(r.Forwards), *output)
+	}
+}
+
+// buildReport gathers versions and the state of every forward in cfgs
+func buildReport(cfgs []config.PortForwardConfig, projects []config.Project, events []config.ForwardEvent) report {
+	r := report{GeneratedAt: time.Now(), Versions: reportVersions{
+		Kprtfwd: "unknown",
+		Go:      runtime.Version(),
+		OS:      runtime.GOOS + "/" + runtime.GOARCH,
+	}, RecentErrors: []string{}}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		r.Versions.Kprtfwd = info.Main.Version
+	}
+	if version, err := kubectl.ClientVersion(); err == nil {
+		r.Versions.Kubectl = version
+	} else {
+		r.Versions.Kubectl = "unavailable: " + strings.ReplaceAll(err.Error(), "\n", " ")
+	}
+
+	inProjects := make(map[string][]string)
+	for _, project := range projects {
+		for _, id := range project.Forwards {
+			inProjects[id] = append(inProjects[id], project.Name)
+		}
+	}
+
+	usage := config.SummarizeUsage(cfgs, events)
+	r.Forwards = make([]reportForward, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		f := reportForward{
+			ID:         cfg.ID,
+			Context:    cfg.Context,
+			Namespace:  cfg.Namespace,
+			Service:    cfg.Service,
+			PortLocal:  portSpan(cfg.PortLocal, cfg.Ports()),
+			PortRemote: portSpan(cfg.PortRemote, cfg.Ports()),
+			Mode:       forwardMode(cfg),
+			Projects:   inProjects[cfg.ID],
+		}
+		if !usage[i].LastStart.IsZero() {
+			f.LastStarted = &usage[i].LastStart
+		}
+		listening := portListening(config.ListenHosts(cfg.Listen)[0], cfg.PortLocal)
+		switch {
+		case usage[i].Up && listening:
+			f.State = reportStateRunning
+		case usage[i].Up:
+			f.State = reportStateNotListening
+		case listening:
+			f.State = reportStatePortInUse
+		default:
+			f.State = reportStateStopped
+		}
+		r.Forwards = append(r.Forwards, f)
+	}
+	return r
+}
+
+// writeMarkdownReport renders r for pasting into a ticket
+func writeMarkdownReport(w io.Writer, r report) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# kprtfwd report\n\nGenerated %s\n\n", r.GeneratedAt.Format("2006-01-02 15:04:05 -0700"))
+
+	b.WriteString("## Versions\n\n| Component | Version |\n|-----------|---------|\n")
+	fmt.Fprintf(&b, "| kprtfwd | %s |\n| Go | %s |\n| OS | %s |\n| kubectl | %s |\n\n",
+		r.Versions.Kprtfwd, r.Versions.Go, r.Versions.OS, markdownCell(r.Versions.Kubectl))
+
+	b.WriteString("## Forwards\n\n")
+	if r.ActiveProject != "" {
+		fmt.Fprintf(&b, "Active project: %s\n\n", r.ActiveProject)
+	}
+	if len(r.Forwards) == 0 {
+		b.WriteString("No forwards configured.\n\n")
+	} else {
+		b.WriteString("| ID | Context | Namespace | Service | Local | Remote | Mode | Projects | State | Last started |\n")
+		b.WriteString("|----|---------|-----------|---------|-------|--------|------|----------|-------|--------------|\n")
+		for _, f := range r.Forwards {
+			lastStarted := "-"
+			if f.LastStarted != nil {
+				lastStarted = f.LastStarted.Format("2006-01-02 15:04:05")
+			}
+			projects := "-"
+			if len(f.Projects) > 0 {
+				projects = strings.Join(f.Projects, ", ")
+			}
+			fmt.Fprintf(&b, "| %s | %s | %s | %s | %s | %s | %s | %s | %s | %s |\n",
+				f.ID, getContextDisplay(f.Context), f.Namespace, f.Service, f.PortLocal, f.PortRemote,
+				markdownCell(f.Mode), projects, f.State, lastStarted)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## Recent errors\n\n")
+	if len(r.RecentErrors) == 0 {
+		b.WriteString("None in the log.\n")
+	} else {
+		fmt.Fprintf(&b, "```\n%s\n```\n", strings.Join(r.RecentErrors, "\n"))
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+// forwardMode summarizes the options that change how a forward runs
+func forwardMode(cfg config.PortForwardConfig) string {
+	var parts []string
+	if cfg.Lazy {
+		parts = append(parts, "lazy")
+	}
+	if cfg.TLSMode != config.TLSModeNone {
+		parts = append(parts, "TLS "+cfg.TLSMode)
+	}
+	if cfg.Listen != config.ListenIPv4 {
+		parts = append(parts, "listen "+strings.Join(config.ListenHosts(cfg.Listen), "+"))
+	}
+	if cfg.KubectlArgs != "" {
+		parts = append(parts, "kubectl "+cfg.KubectlArgs)
+	}
+	if len(parts) == 0 {
+		return "plain"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// portSpan renders a port, or a range of count ports starting at it
+func portSpan(port, count int) string {
+	if count > 1 {
+		return fmt.Sprintf("%d-%d", port, port+count-1)
+	}
+	return strconv.Itoa(port)
+}
+
+// portListening reports whether something accepts connections on host:port
+func portListening(host string, port int) bool {
+	conn, err := net.DialTimeout("tcp", net.JoinHostPort(host, strconv.Itoa(port)), 500*time.Millisecond)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// markdownCell escapes the characters that would break a table cell
+func markdownCell(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
+// showReportHelp displays help for the report command
+func showReportHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s report - Export a snapshot of the forwards and their state
+
+Usage:
+  %s report [options]
+
+Lists every configured forward with its state, the versions of kprtfwd, Go,
+the OS and kubectl, and the latest errors from the log. Attach it to an
+incident ticket to show which tunnels were up when something broke.
+
+A forward is "running" when the TUI recorded its start and its local port is
+listening, "not listening" when it was started but the port is free, and
+"stopped, port in use" when another process holds the port.
+
+Options:
+  --format string       Output format: markdown or json (default markdown)
+  -o string             Write the report to this file instead of stdout
+  --errors int          How many recent errors from the log to include (default 20)
+  -h, --help            Show this help message
+
+Examples:
+  %s report                         Print a Markdown report
+  %s report --format json -o r.json Save the report as JSON
+`, programName, programName, programName, programName)
+}
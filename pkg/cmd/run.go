@@ -0,0 +1,350 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// runCheckInterval is how often run checks the forwards, as the TUI's status
+// refresh does
+const runCheckInterval = 2 * time.Second
+
+// HandleRunCommand handles the run subcommand logic
+func HandleRunCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showRunHelp()
+			os.Exit(0)
+		}
+	}
+
+	runCmd := flag.NewFlagSet("run", flag.ExitOnError)
+	project := runCmd.String("project", "", "Run the forwards of this project instead of every forward")
+	ids := runCmd.String("ids", "", "Run these forwards, comma-separated, instead of every forward")
+	allowExposed := runCmd.Bool("allow-exposed", false, "Also start forwards that listen outside loopback")
+	exitIdle := runCmd.Bool("exit-idle", false, "Exit once kprtfwd stop stopped every forward")
+	runCmd.Usage = showRunHelp
+	if err := runCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if runCmd.NArg() > 0 {
+		fmt.Printf("Error: unexpected argument '%s'\n\n", runCmd.Arg(0))
+		showRunHelp()
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	if *project != "" && *ids != "" {
+		fmt.Printf("Error: give --project or --ids, not both\n")
+		os.Exit(1)
+	}
+	configs, err := runConfigs(store, *project, *ids)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	settings := store.GetSettings()
+	confirmExposed := !*allowExposed && settings[config.SettingConfirmExposed] != "false"
+	if confirmExposed {
+		kept := configs[:0]
+		for _, cfg := range configs {
+			if exposedSkipped(cfg) {
+				continue
+			}
+			kept = append(kept, cfg)
+		}
+		configs = kept
+	}
+	if len(configs) == 0 {
+		fmt.Println("No forwards to run.")
+		os.Exit(1)
+	}
+
+	kubectl.ApplySettings(settings)
+	pf := k8s.NewPortForwarder()
+	pf.ApplySettings(settings)
+
+	// Catch the signals before the first kubectl starts, so none is left behind
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer config.RemoveHostState()
+
+	stopAllSeen := config.StopAllRequestedAt()
+	for _, cfg := range configs {
+		if err := pf.Start(cfg); err != nil {
+			runLog("%s failed to start: %v", cfg.ID, err)
+		}
+	}
+	states := make(map[string]runState, len(configs))
+	up := reportRunChanges(store, pf, configs, states)
+	runLog("Running %d forward(s), %d up; Ctrl+C stops them", len(configs), up)
+	publishRunState(pf, configs)
+
+	ticker := time.NewTicker(runCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case sig := <-signals:
+			runLog("Got %s, stopping", sig)
+			stopRun(store, pf, configs, states)
+			return
+		case <-ticker.C:
+		}
+
+		if at := config.StopAllRequestedAt(); at.After(stopAllSeen) {
+			runLog("Panic stop requested (kprtfwd stop --all), stopping")
+			n := pf.StopAllFor(k8s.StopReasonPanic)
+			if err := config.AnswerStopAll(at, n); err != nil {
+				runLog("Could not answer the panic stop: %v", err)
+			}
+			stopRun(store, pf, configs, states)
+			return
+		}
+		configs = handleRunRequests(store, pf, configs, states, confirmExposed)
+		if *exitIdle && len(configs) == 0 {
+			runLog("Every forward was stopped, exiting")
+			return
+		}
+		pf.MarkBroken(pf.CheckHealth())
+		for _, id := range pf.AutoRestart(configs) {
+			runLog("%s restarted automatically", id)
+		}
+		reportRunChanges(store, pf, configs, states)
+		publishRunState(pf, configs)
+	}
+}
+
+// exposedSkipped logs and reports whether cfg listens outside loopback, for
+// forwards run only starts with --allow-exposed
+func exposedSkipped(cfg config.PortForwardConfig) bool {
+	address := cfg.ExposedAddress()
+	if address == "" {
+		return false
+	}
+	runLog("%s listens on %s and would expose %s/%s to your network; skipped (see --allow-exposed)", cfg.ID, address, cfg.Namespace, cfg.Service)
+	return true
+}
+
+// handleRunRequests carries out the requests of kprtfwd start and stop and
+// returns the forwards run now has: a started forward joins them, a stopped
+// one leaves, along with its entry in states
+func handleRunRequests(store *config.SQLiteConfigStore, pf *k8s.PortForwarder, configs []config.PortForwardConfig, states map[string]runState, confirmExposed bool) []config.PortForwardConfig {
+	reqs, err := config.TakeControlRequests()
+	if err != nil {
+		runLog("Could not read the start and stop requests: %v", err)
+		return configs
+	}
+	for _, req := range reqs {
+		idx := slices.IndexFunc(configs, func(c config.PortForwardConfig) bool { return c.ID == req.ID })
+		switch req.Action {
+		case config.ControlStart:
+			cfg, ok := store.GetConfigByID(req.ID)
+			if !ok {
+				runLog("Asked to start %s, which does not exist", req.ID)
+				continue
+			}
+			if confirmExposed && exposedSkipped(cfg) {
+				continue
+			}
+			if idx < 0 {
+				configs = append(configs, cfg)
+			}
+			if pf.IsRunning(cfg.ID) || pf.IsQueued(cfg.ID) {
+				continue
+			}
+			runLog("Starting %s (kprtfwd start)", cfg.ID)
+			if err := pf.Start(cfg); err != nil {
+				runLog("%s failed to start: %v", cfg.ID, err)
+			}
+		case config.ControlStop:
+			if idx < 0 {
+				continue
+			}
+			runLog("Stopping %s (kprtfwd stop)", req.ID)
+			if err := pf.Stop(req.ID); err != nil {
+				runLog("Could not stop %s: %v", req.ID, err)
+			}
+			if states[req.ID].up {
+				recordConnectEvent(store, req.ID, config.ForwardEventStop)
+			}
+			delete(states, req.ID)
+			configs = slices.Delete(configs, idx, idx+1)
+		}
+	}
+	return configs
+}
+
+// publishRunState publishes the state of the forwards run has for kprtfwd
+// list, start and stop
+func publishRunState(pf *k8s.PortForwarder, configs []config.PortForwardConfig) {
+	snapshot := pf.Snapshot()
+	host := config.HostState{Mode: config.HostModeRun}
+	for _, cfg := range configs {
+		status, detail := forwardStatus(snapshot[cfg.ID])
+		host.Forwards = append(host.Forwards, config.HostForward{ID: cfg.ID, Status: status, Detail: detail})
+	}
+	if err := config.PublishHostState(host); err != nil {
+		runLog("Could not publish the state of the forwards: %v", err)
+	}
+}
+
+// runConfigs returns the forwards of the project named project, or those
+// with the comma-separated ids, or every forward when both are empty
+func runConfigs(store *config.SQLiteConfigStore, project, ids string) ([]config.PortForwardConfig, error) {
+	if ids != "" {
+		var configs []config.PortForwardConfig
+		for _, id := range strings.Split(ids, ",") {
+			cfg, ok := store.GetConfigByID(id)
+			if !ok {
+				return nil, fmt.Errorf("no forward with ID '%s'", id)
+			}
+			configs = append(configs, cfg)
+		}
+		return configs, nil
+	}
+	if project == "" {
+		return store.GetAll(), nil
+	}
+	for _, p := range store.GetAllProjects() {
+		if p.Name != project {
+			continue
+		}
+		var configs []config.PortForwardConfig
+		for _, id := range p.Forwards {
+			if cfg, ok := store.GetConfigByID(id); ok {
+				configs = append(configs, cfg)
+			}
+		}
+		return configs, nil
+	}
+	return nil, fmt.Errorf("no project named '%s'", project)
+}
+
+// forwardStatus names a forward's state in a word, as kprtfwd list shows
+// it, with the reason when it is not serving
+func forwardStatus(state k8s.ForwardState) (status, detail string) {
+	switch {
+	case state.Failed:
+		return "failed", state.ErrorReason
+	case state.Queued:
+		return "queued", ""
+	case state.Standby:
+		return "standby", ""
+	case state.Running && state.Health == k8s.HealthDead:
+		return "dead", state.HealthDetail
+	case state.Running && state.Health == k8s.HealthDegraded:
+		return "degraded", state.HealthDetail
+	case state.Running:
+		return "running", ""
+	}
+	return "stopped", ""
+}
+
+// runStatus describes a forward's state in one line, with the reason when it
+// is not serving
+func runStatus(state k8s.ForwardState) string {
+	status, detail := forwardStatus(state)
+	switch {
+	case status == "standby":
+		return "standing by (kubectl starts on the first connection)"
+	case detail != "":
+		return status + ": " + detail
+	}
+	return status
+}
+
+// runState is the last state run logged for a forward
+type runState struct {
+	status string // runStatus
+	up     bool   // kubectl runs, or a lazy forward listens
+}
+
+// reportRunChanges logs each forward whose status differs from the one in
+// states, records the starts and stops for `kprtfwd stats`, updates states
+// and returns how many forwards are up
+func reportRunChanges(store *config.SQLiteConfigStore, pf *k8s.PortForwarder, configs []config.PortForwardConfig, states map[string]runState) int {
+	snapshot := pf.Snapshot()
+	up := 0
+	for _, cfg := range configs {
+		state := snapshot[cfg.ID]
+		current := runState{status: runStatus(state), up: state.Running || state.Standby}
+		if current.up {
+			up++
+		}
+		previous := states[cfg.ID]
+		if previous == current {
+			continue
+		}
+		states[cfg.ID] = current
+		runLog("%s (%s, local %s): %s", cfg.ID, cfg.Target(), portSpan(cfg.PortLocal, cfg.Ports()), current.status)
+		switch {
+		case current.up && !previous.up:
+			recordConnectEvent(store, cfg.ID, config.ForwardEventStart)
+		case !current.up && previous.up:
+			recordConnectEvent(store, cfg.ID, config.ForwardEventStop)
+		}
+	}
+	return up
+}
+
+// stopRun stops every forward and logs how each one ended
+func stopRun(store *config.SQLiteConfigStore, pf *k8s.PortForwarder, configs []config.PortForwardConfig, states map[string]runState) {
+	pf.CleanupAll()
+	reportRunChanges(store, pf, configs, states)
+	runLog("Stopped")
+}
+
+// runLog prints a timestamped line to stdout
+func runLog(format string, args ...any) {
+	fmt.Printf("%s %s\n", time.Now().Format("2006-01-02 15:04:05"), fmt.Sprintf(format, args...))
+}
+
+// showRunHelp displays help for the run command
+func showRunHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s run - Run forwards in the foreground, without the TUI
+
+Usage:
+  %s run [options]
+
+Starts every forward, or those of one project, and keeps them up until
+interrupted (Ctrl+C or SIGTERM), then stops them. Broken forwards are restarted
+as in the TUI, and every change of state is logged to stdout with a
+timestamp. For scripts, docker-compose-style dev environments and CI.
+
+kprtfwd stop --all stops it too. Forwards that listen outside loopback are
+skipped unless --allow-exposed is given or security.confirm_exposed is false.
+
+kprtfwd start and kprtfwd stop add forwards to it and take them away, and
+kprtfwd list shows their state.
+
+Options:
+  --project name        Run the forwards of this project instead of every forward
+  --ids id,...          Run these forwards instead of every forward
+  --allow-exposed       Also start forwards that listen outside loopback
+  --exit-idle           Exit once kprtfwd stop stopped every forward
+  -h, --help            Show this help message
+
+Examples:
+  %s run                        Run every forward
+  %s run --project payments     Run the forwards of the payments project
+  %s --workspace ci run         Run the forwards of the ci workspace
+`, programName, programName, programName, programName, programName)
+}
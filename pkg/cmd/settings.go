@@ -0,0 +1,159 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// HandleSettingsCommand handles the settings subcommand logic
+func HandleSettingsCommand() {
+	args := os.Args[2:]
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			showSettingsHelp()
+			os.Exit(0)
+		}
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	wantArgs := map[string]int{"list": 0, "get": 1, "set": 2, "unset": 1}
+	n, known := wantArgs[action]
+	if !known {
+		fmt.Printf("Error: unknown settings action '%s'\n\n", action)
+		showSettingsHelp()
+		os.Exit(1)
+	}
+	if len(args) != n {
+		fmt.Printf("Error: 'settings %s' expects %d argument(s), got %d\n\n", action, n, len(args))
+		showSettingsHelp()
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	switch action {
+	case "list":
+		listSettings(store)
+	case "get":
+		value, ok := store.GetSetting(args[0])
+		if !ok {
+			fmt.Printf("%s is not set (built-in default applies)\n", args[0])
+			return
+		}
+		fmt.Println(value)
+	case "set":
+		if err := store.SetSetting(args[0], args[1]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ %s = %s\n", args[0], args[1])
+		warnIfOverridden(args[0])
+	case "unset":
+		if err := store.UnsetSetting(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ %s unset\n", args[0])
+		warnIfOverridden(args[0])
+	}
+}
+
+// warnIfOverridden tells that a stored change has no effect while the
+// setting's environment variable is set
+func warnIfOverridden(key string) {
+	if value, ok := config.EnvOverrides()[key]; ok {
+		fmt.Printf("⚠️  %s=%s overrides it while set\n", config.SettingEnvVar(key), value)
+	}
+}
+
+// listSettings prints stored settings and environment overrides followed by
+// the keys that can be set
+func listSettings(store *config.SQLiteConfigStore) {
+	settings := store.GetStoredSettings()
+	if len(settings) == 0 {
+		fmt.Println("No settings stored; built-in defaults apply.")
+	} else {
+		keys := make([]string, 0, len(settings))
+		for key := range settings {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s = %s\n", key, settings[key])
+		}
+	}
+
+	overrides := config.EnvOverrides()
+	if len(overrides) > 0 {
+		fmt.Println("\nOverridden from the environment:")
+		keys := make([]string, 0, len(overrides))
+		for key := range overrides {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			fmt.Printf("%s = %s (%s)\n", key, overrides[key], config.SettingEnvVar(key))
+		}
+	}
+
+	fmt.Println("\nAvailable settings:")
+	for _, spec := range config.SettingSpecs() {
+		fmt.Printf("  %-28s %s\n", spec.Key, spec.Description)
+	}
+}
+
+// showSettingsHelp displays help for the settings command
+func showSettingsHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s settings - View and change persistent settings
+
+Settings are stored in the kprtfwd database and apply to the TUI and to
+every subcommand.
+
+Environment variables override them, for containers and CI: KPRTFWD_ and the
+key in upper case with '.' and '-' as '_' (KPRTFWD_KUBECTL_RETRIES), except
+KPRTFWD_KUBECTL for kubectl.path and KPRTFWD_THEME for ui.theme.
+Per-context and per-command settings have no variable. KPRTFWD_DB points at
+another database than ~/.kprtfwd/kprtfwd.db.
+
+Precedence, highest first:
+  1. KPRTFWD_* environment variable
+  2. Setting stored with 'settings set'
+  3. Built-in default
+A per-context setting still wins over the general one for its context, even
+when the general one comes from the environment.
+
+Usage:
+  %s settings [list]              List stored and available settings
+  %s settings get <key>           Print the value of a setting
+  %s settings set <key> <value>   Store a setting
+  %s settings unset <key>         Remove a setting (built-in default applies)
+
+Options:
+  -h, --help            Show this help message
+
+Examples:
+  %s settings set kubectl.timeout.get-services 2m   Allow slow clusters more time
+  %s settings set kubectl.timeout 20s                Use one timeout for every kubectl call
+  %s settings set kubectl.retries 0                  Disable the retry on transient failures
+  %s settings unset kubectl.timeout                  Restore the per-command defaults
+  KPRTFWD_LOG_LEVEL=debug %s                         Run once with debug logging
+
+Built-in kubectl timeouts: current-context 10s, get-contexts 10s,
+get-namespaces 30s, get-services 60s. Timeouts and transient failures such
+as "connection reset by peer" are retried once by default.
+`, programName, programName, programName, programName, programName, programName, programName, programName, programName, programName)
+}
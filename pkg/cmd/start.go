@@ -0,0 +1,300 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// upStatuses are the host statuses of a forward that serves its local port
+var upStatuses = map[string]bool{"running": true, "active": true, "standby": true, "degraded": true}
+
+// HandleStartCommand handles the start subcommand logic
+func HandleStartCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showStartHelp()
+			os.Exit(0)
+		}
+	}
+
+	startCmd := flag.NewFlagSet("start", flag.ExitOnError)
+	wait := startCmd.Duration("wait", 15*time.Second, "How long to wait for the forwards to come up")
+	startCmd.Usage = showStartHelp
+	if err := startCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if startCmd.NArg() == 0 {
+		fmt.Printf("Error: expected forward IDs or project names\n\n")
+		showStartHelp()
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	ids, err := resolveTargets(store, startCmd.Args())
+	if err == nil && store.GetSettings()[config.SettingConfirmExposed] != "false" {
+		err = checkNotExposed(store, ids)
+	}
+	store.Close()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	hosts, err := config.HostStates()
+	if err != nil {
+		fmt.Printf("Error reading the running kprtfwd: %v\n", err)
+		os.Exit(1)
+	}
+
+	// A forward a host already has is started again there only if it is
+	// down; the others go to the most recently started host
+	var pending []string
+	hostOf := make(map[string]int) // pending forward ID -> PID of its host; 0 for a new one
+	for _, id := range ids {
+		host, fwd, ok := findHostForward(hosts, id)
+		switch {
+		case ok && upStatuses[fwd.Status]:
+			fmt.Printf("%s: already %s (kprtfwd %d)\n", id, fwd.Status, host.PID)
+			continue
+		case ok:
+			hostOf[id] = host.PID
+		case len(hosts) > 0:
+			hostOf[id] = hosts[0].PID
+		}
+		pending = append(pending, id)
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	requests := make(map[int][]config.ControlRequest)
+	for _, id := range pending {
+		requests[hostOf[id]] = append(requests[hostOf[id]], config.ControlRequest{Action: config.ControlStart, ID: id})
+	}
+	sentAt := time.Now()
+	for pid, reqs := range requests {
+		if pid != 0 {
+			err = config.SendControlRequests(pid, reqs)
+		} else if pid, err = startRunHost(reqs); err == nil {
+			for _, r := range reqs {
+				hostOf[r.ID] = pid
+			}
+		}
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	results := awaitHosts(hostOf, sentAt, *wait, func(fwd config.HostForward, listed bool) bool {
+		return listed && fwd.Status != "queued"
+	})
+	failed := false
+	for _, id := range pending {
+		r, ok := results[id]
+		switch {
+		case !ok:
+			fmt.Printf("%s: not up after %s\n", id, *wait)
+			failed = true
+		case !upStatuses[r.fwd.Status]:
+			fmt.Printf("%s: %s\n", id, hostForwardStatus(r.fwd))
+			failed = true
+		default:
+			fmt.Printf("%s: %s (kprtfwd %d)\n", id, hostForwardStatus(r.fwd), r.host.PID)
+		}
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// resolveTargets returns the forward IDs that args name: each is a forward ID
+// or a project name, for all of the project's forwards
+func resolveTargets(store *config.SQLiteConfigStore, args []string) ([]string, error) {
+	var ids []string
+	seen := make(map[string]bool)
+	add := func(id string) {
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	projects := store.GetAllProjects()
+	for _, arg := range args {
+		if _, ok := store.GetConfigByID(arg); ok {
+			add(arg)
+			continue
+		}
+		found := false
+		for _, p := range projects {
+			if p.Name == arg {
+				found = true
+				for _, id := range p.Forwards {
+					if _, ok := store.GetConfigByID(id); ok {
+						add(id)
+					}
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("'%s' is neither a forward ID nor a project", arg)
+		}
+	}
+	return ids, nil
+}
+
+// checkNotExposed returns an error naming the first of ids that listens
+// outside loopback: starting one needs the TUI's confirmation
+func checkNotExposed(store *config.SQLiteConfigStore, ids []string) error {
+	for _, id := range ids {
+		cfg, _ := store.GetConfigByID(id)
+		if address := cfg.ExposedAddress(); address != "" {
+			return fmt.Errorf("%s listens on %s and would expose %s/%s to your network; start it in the TUI or with kprtfwd run --allow-exposed", id, address, cfg.Namespace, cfg.Service)
+		}
+	}
+	return nil
+}
+
+// findHostForward returns the host that lists the forward id, and its state
+// there
+func findHostForward(hosts []config.HostState, id string) (config.HostState, config.HostForward, bool) {
+	for _, h := range hosts {
+		for _, f := range h.Forwards {
+			if f.ID == id {
+				return h, f, true
+			}
+		}
+	}
+	return config.HostState{}, config.HostForward{}, false
+}
+
+// hostForwardStatus describes a forward's state on its host in one line
+func hostForwardStatus(f config.HostForward) string {
+	if f.Detail != "" {
+		return f.Status + ": " + f.Detail
+	}
+	return f.Status
+}
+
+// hostResult is where awaitHosts found a forward, and its state there
+type hostResult struct {
+	host config.HostState
+	fwd  config.HostForward
+}
+
+// awaitHosts polls the published state of the hosts until done holds for
+// each forward in targets, as its host, targets[id] by PID, published it after
+// since, or timeout passes. done is told whether the host lists the forward
+// at all; a host that exited lists none. It returns the forwards for which
+// done held.
+func awaitHosts(targets map[string]int, since time.Time, timeout time.Duration, done func(fwd config.HostForward, listed bool) bool) map[string]hostResult {
+	results := make(map[string]hostResult)
+	deadline := time.Now().Add(timeout)
+	for {
+		hosts, _ := config.HostStates()
+		for id, pid := range targets {
+			if _, ok := results[id]; ok {
+				continue
+			}
+			idx := slices.IndexFunc(hosts, func(h config.HostState) bool { return h.PID == pid })
+			var host config.HostState
+			if idx >= 0 {
+				host = hosts[idx]
+				if !host.UpdatedAt.After(since) {
+					continue // the request is not carried out yet
+				}
+			} else if time.Since(since) < 2*runCheckInterval {
+				continue // a new host may not have published yet
+			}
+			_, fwd, listed := findHostForward([]config.HostState{host}, id)
+			if done(fwd, listed) {
+				results[id] = hostResult{host: host, fwd: fwd}
+			}
+		}
+		if len(results) == len(targets) || time.Now().After(deadline) {
+			return results
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+// startRunHost starts a kprtfwd run in the background for the forwards reqs
+// start, which exits once kprtfwd stop stopped them all, and returns its PID.
+// Its output goes to ~/.kprtfwd/logs/run.log.
+func startRunHost(reqs []config.ControlRequest) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, fmt.Errorf("cannot find the kprtfwd binary: %w", err)
+	}
+	ids := make([]string, len(reqs))
+	for i, r := range reqs {
+		ids[i] = r.ID
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return 0, fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	logPath := filepath.Join(homeDir, ".kprtfwd", "logs", "run.log")
+	if err := os.MkdirAll(filepath.Dir(logPath), 0o700); err != nil {
+		return 0, err
+	}
+	logFile, err := os.OpenFile(logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return 0, err
+	}
+	defer logFile.Close() // the child has its own copy
+
+	// The workspace travels in the environment
+	cmd := exec.Command(exe, "run", "--ids", strings.Join(ids, ","), "--exit-idle")
+	cmd.Stdout, cmd.Stderr = logFile, logFile
+	detach(cmd)
+	if err := cmd.Start(); err != nil {
+		return 0, fmt.Errorf("cannot start kprtfwd run: %w", err)
+	}
+	pid := cmd.Process.Pid
+	_ = cmd.Process.Release()
+	fmt.Printf("No kprtfwd was running; started kprtfwd run in the background (PID %d, output in %s)\n", pid, logPath)
+	return pid, nil
+}
+
+// showStartHelp displays help for the start command
+func showStartHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s start - Start forwards from a script or another terminal
+
+Usage:
+  %s start [options] <id|project>...
+
+Starts the forwards with the given IDs, and every forward of the given
+projects, in the running kprtfwd (the TUI or kprtfwd run) of the workspace.
+When none runs, a kprtfwd run is started in the background to host them; it
+exits once kprtfwd stop stopped them all. Waits for the forwards to come up
+and exits with status 1 if any did not.
+
+Forwards that listen outside loopback are refused: start them in the TUI,
+which asks for confirmation, or with kprtfwd run --allow-exposed.
+
+Options:
+  --wait duration       How long to wait for the forwards to come up (default 15s)
+  -h, --help            Show this help message
+
+Examples:
+  %s start prod.data.pg
+  %s start backend              Start every forward of the backend project
+  %s list                       See what runs
+  %s stop backend               Stop them again
+`, programName, programName, programName, programName, programName, programName)
+}
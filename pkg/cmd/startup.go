@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/devmode"
+)
+
+// StartupOptions are the flags accepted when starting the TUI without a
+// command
+type StartupOptions struct {
+	Project    string // project to activate before the TUI shows
+	StartAll   bool   // start every configured forward
+	Accessible bool   // screen-reader-friendly output, as the ui.accessible setting
+	Dev        bool   // simulate a cluster instead of running kubectl
+}
+
+// TakeWorkspaceFlag selects the workspace given by a --workspace flag in
+// front of the command (or of the TUI's flags) and removes the flag from
+// os.Args, so the command parses its arguments as usual
+func TakeWorkspaceFlag() {
+	if len(os.Args) < 2 {
+		return
+	}
+	flagName := strings.TrimLeft(os.Args[1], "-")
+	if flagName == os.Args[1] {
+		return // a command, not a flag
+	}
+	name, ok := strings.CutPrefix(flagName, "workspace=")
+	consumed := 1
+	if !ok && flagName == "workspace" {
+		if len(os.Args) < 3 {
+			fmt.Printf("Error: --workspace needs a workspace name\n")
+			os.Exit(1)
+		}
+		name, ok, consumed = os.Args[2], true, 2
+	}
+	if !ok {
+		return
+	}
+	if err := config.SetWorkspace(name); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	os.Args = append(os.Args[:1], os.Args[1+consumed:]...)
+}
+
+// EnableDevMode switches to developer mode before any store is opened, or
+// exits if that is impossible
+func EnableDevMode() {
+	if err := devmode.Enable(); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ParseStartupFlags parses the flags given to kprtfwd without a command
+func ParseStartupFlags(args []string) StartupOptions {
+	var opts StartupOptions
+	startCmd := flag.NewFlagSet("kprtfwd", flag.ExitOnError)
+	startCmd.StringVar(&opts.Project, "project", "", "Activate this project (and start its forwards)")
+	startCmd.BoolVar(&opts.StartAll, "start-all", false, "Start every configured forward")
+	startCmd.BoolVar(&opts.Accessible, "accessible", false, "Screen-reader-friendly output")
+	startCmd.BoolVar(&opts.Dev, "dev", false, "Developer mode: simulate a cluster instead of running kubectl")
+	startCmd.Usage = showMainHelp
+	if err := startCmd.Parse(args); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if startCmd.NArg() > 0 {
+		fmt.Printf("Error: unexpected argument '%s'\n\n", startCmd.Arg(0))
+		ShowMainHelpAndExit()
+	}
+	return opts
+}
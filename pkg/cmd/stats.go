@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// HandleStatsCommand handles the stats subcommand logic
+func HandleStatsCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showStatsHelp()
+			os.Exit(0)
+		}
+	}
+
+	statsCmd := flag.NewFlagSet("stats", flag.ExitOnError)
+	top := statsCmd.Int("top", 10, "How many of the most started forwards to list")
+	statsCmd.Usage = showStatsHelp
+	if err := statsCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	events, err := store.ForwardEvents()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	printStats(store.GetAll(), len(store.GetAllProjects()), events, *top)
+}
+
+// printStats prints the config summary and, if there is any history, the
+// usage of the forwards
+func printStats(cfgs []config.PortForwardConfig, projects int, events []config.ForwardEvent, top int) {
+	// Forwards per context, and per namespace within it
+	perContext := make(map[string]int)
+	perNamespace := make(map[string]map[string]int)
+	for _, cfg := range cfgs {
+		perContext[cfg.Context]++
+		if perNamespace[cfg.Context] == nil {
+			perNamespace[cfg.Context] = make(map[string]int)
+		}
+		perNamespace[cfg.Context][cfg.Namespace]++
+	}
+
+	fmt.Println("Configuration")
+	fmt.Printf("  %d forward(s) in %d context(s), %d project(s)\n\n", len(cfgs), len(perContext), projects)
+	if len(cfgs) == 0 {
+		return
+	}
+
+	fmt.Println("Forwards per context and namespace")
+	for _, context := range sortedKeys(perContext) {
+		fmt.Printf("  %-36s %4d\n", getContextDisplay(context), perContext[context])
+		for _, namespace := range sortedKeys(perNamespace[context]) {
+			fmt.Printf("    %-34s %4d\n", namespace, perNamespace[context][namespace])
+		}
+	}
+	fmt.Println()
+
+	if len(events) == 0 {
+		fmt.Println("No start/stop history yet; it is recorded while the kprtfwd TUI runs.")
+		return
+	}
+
+	usage := config.SummarizeUsage(cfgs, events)
+	var started, never []config.ForwardUsage
+	var sessions int
+	var uptime time.Duration
+	for _, u := range usage {
+		if u.Starts == 0 {
+			never = append(never, u)
+			continue
+		}
+		started = append(started, u)
+		sessions += u.Sessions
+		uptime += u.Uptime
+	}
+	sort.SliceStable(started, func(i, j int) bool { return started[i].Starts > started[j].Starts })
+
+	fmt.Printf("Most started forwards (history since %s)\n", events[0].At.Format("2006-01-02"))
+	fmt.Printf("  %6s  %-10s  %-16s  %s\n", "STARTS", "AVG UPTIME", "LAST STARTED", "FORWARD")
+	for _, u := range started[:min(top, len(started))] {
+		avg := "-"
+		if u.Sessions > 0 {
+			avg = formatUptime(u.AverageUptime())
+		}
+		fmt.Printf("  %6d  %-10s  %-16s  %s\n", u.Starts, avg, u.LastStart.Format("2006-01-02 15:04"), u.ID)
+	}
+	fmt.Println()
+
+	if sessions > 0 {
+		fmt.Printf("Average uptime: %s over %d session(s)\n\n", formatUptime(uptime/time.Duration(sessions)), sessions)
+	}
+
+	if len(never) > 0 {
+		fmt.Printf("Never started since %s (%d) - candidates for pruning:\n", events[0].At.Format("2006-01-02"), len(never))
+		for _, u := range never {
+			fmt.Printf("  - %s\n", u.ID)
+		}
+	}
+}
+
+// sortedKeys returns the keys of m in order
+func sortedKeys(m map[string]int) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// formatUptime renders d rounded to what matters for an uptime: "45s",
+// "12m", "3h20m", "2d4h"
+func formatUptime(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 24*time.Hour:
+		return fmt.Sprintf("%dh%dm", int(d.Hours()), int(d.Minutes())%60)
+	default:
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+}
+
+// showStatsHelp displays help for the stats command
+func showStatsHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s stats - Summarize the configuration and how forwards are used
+
+Usage:
+  %s stats [options]
+
+Shows how many forwards are configured per context and namespace, which
+forwards are started most often and how long they stay up, and which were
+never started - candidates for pruning. Usage comes from the start/stop
+history the TUI records while it runs.
+
+Options:
+  --top int             How many of the most started forwards to list (default 10)
+  -h, --help            Show this help message
+
+Examples:
+  %s stats                  Show the summary
+  %s stats --top 25         List the 25 most started forwards
+`, programName, programName, programName, programName)
+}
@@ -0,0 +1,152 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// HandleStopCommand handles the stop subcommand logic
+func HandleStopCommand() {
+	for _, arg := range os.Args[2:] {
+		if arg == "-h" || arg == "--help" {
+			showStopHelp()
+			os.Exit(0)
+		}
+	}
+
+	stopCmd := flag.NewFlagSet("stop", flag.ExitOnError)
+	all := stopCmd.Bool("all", false, "Stop every forward of every running kprtfwd")
+	wait := stopCmd.Duration("wait", 5*time.Second, "How long to wait for the running kprtfwd to answer")
+	stopCmd.Usage = showStopHelp
+	if err := stopCmd.Parse(os.Args[2:]); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if *all == (stopCmd.NArg() > 0) {
+		fmt.Printf("Error: expected forward IDs or project names, or --all\n\n")
+		showStopHelp()
+		os.Exit(1)
+	}
+	if !*all {
+		stopForwards(stopCmd.Args(), *wait)
+		return
+	}
+
+	at, err := config.RequestStopAll()
+	if err != nil {
+		fmt.Printf("Error requesting the stop: %v\n", err)
+		os.Exit(1)
+	}
+
+	// Each TUI answers on its next status refresh. Waiting the full time
+	// catches every one, however many run.
+	time.Sleep(*wait)
+	answers, err := config.StopAllAnswers(at)
+	if err != nil {
+		fmt.Printf("Error reading the answers: %v\n", err)
+		os.Exit(1)
+	}
+	if len(answers) == 0 {
+		fmt.Println("No running kprtfwd answered; no forwards are running.")
+		return
+	}
+	total := 0
+	for _, a := range answers {
+		fmt.Printf("  kprtfwd %d (workspace %s): stopped %d forward(s)\n", a.PID, a.Workspace, a.Stopped)
+		total += a.Stopped
+	}
+	fmt.Printf("Stopped %d forward(s) in %d running kprtfwd.\n", total, len(answers))
+}
+
+// stopForwards stops the forwards that args name, by ID or project, in
+// whichever running kprtfwd of the workspace runs them
+func stopForwards(args []string, wait time.Duration) {
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	ids, err := resolveTargets(store, args)
+	store.Close()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	hosts, err := config.HostStates()
+	if err != nil {
+		fmt.Printf("Error reading the running kprtfwd: %v\n", err)
+		os.Exit(1)
+	}
+
+	hostOf := make(map[string]int) // forward ID -> PID of the host running it
+	requests := make(map[int][]config.ControlRequest)
+	for _, id := range ids {
+		host, _, ok := findHostForward(hosts, id)
+		if !ok {
+			fmt.Printf("%s: not running\n", id)
+			continue
+		}
+		hostOf[id] = host.PID
+		requests[host.PID] = append(requests[host.PID], config.ControlRequest{Action: config.ControlStop, ID: id})
+	}
+	sentAt := time.Now()
+	for pid, reqs := range requests {
+		if err := config.SendControlRequests(pid, reqs); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	results := awaitHosts(hostOf, sentAt, wait, func(fwd config.HostForward, listed bool) bool {
+		return !listed || !upStatuses[fwd.Status] && fwd.Status != "queued"
+	})
+	failed := false
+	for _, id := range ids {
+		pid, ok := hostOf[id]
+		if !ok {
+			continue
+		}
+		if _, stopped := results[id]; !stopped {
+			fmt.Printf("%s: kprtfwd %d did not stop it within %s\n", id, pid, wait)
+			failed = true
+			continue
+		}
+		fmt.Printf("%s: stopped (kprtfwd %d)\n", id, pid)
+	}
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func showStopHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s stop - Stop forwards, or every running forward at once
+
+Usage:
+  %s stop [options] <id|project>...
+  %s stop --all [options]
+
+Stops the forwards with the given IDs, and every forward of the given
+projects, in the running kprtfwd (the TUI or kprtfwd run) of the workspace
+that runs them; see kprtfwd start.
+
+With --all, a panic stop: every running kprtfwd, in any workspace, stops all its
+forwards, across every project and context, and drops its start queue. Each
+one answers on its next status refresh (every 2 seconds) with how many it
+stopped. In the TUI, Ctrl+S pressed twice does the same.
+
+Options:
+  --all                 Stop every forward of every running kprtfwd
+  --wait duration       How long to wait for the running kprtfwd to answer (default 5s)
+  -h, --help            Show this help message
+
+Examples:
+  %s stop prod.data.pg      Stop one forward
+  %s stop backend           Stop every forward of the backend project
+  %s stop --all             Stop everything, everywhere
+`, programName, programName, programName, programName, programName, programName)
+}
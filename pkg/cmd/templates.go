@@ -0,0 +1,190 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// HandleTemplatesCommand handles the templates subcommand logic
+func HandleTemplatesCommand() {
+	args := os.Args[2:]
+	for _, arg := range args {
+		if arg == "-h" || arg == "--help" {
+			showTemplatesHelp()
+			os.Exit(0)
+		}
+	}
+
+	action := "list"
+	if len(args) > 0 {
+		action = args[0]
+		args = args[1:]
+	}
+
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		fmt.Printf("Error opening config store: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	switch action {
+	case "list":
+		listTemplates(store)
+	case "add":
+		addTemplate(store, args)
+	case "remove":
+		if len(args) != 1 {
+			fmt.Printf("Error: 'templates remove' expects a template ID\n\n")
+			showTemplatesHelp()
+			os.Exit(1)
+		}
+		if err := store.DeleteForwardTemplate(args[0]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("✅ Removed template %s; the forwards made from it stay\n", args[0])
+	case "sync":
+		syncTemplates(store)
+	default:
+		fmt.Printf("Error: unknown templates action '%s'\n\n", action)
+		showTemplatesHelp()
+		os.Exit(1)
+	}
+}
+
+// listTemplates prints each template with the number of forwards made from it
+func listTemplates(store *config.SQLiteConfigStore) {
+	templates := store.ForwardTemplates()
+	if len(templates) == 0 {
+		fmt.Println("No templates. Create one from a forward with 'templates add <forward-id> <context-pattern>'.")
+		return
+	}
+	made := make(map[string]int)
+	for _, cfg := range store.GetAll() {
+		if cfg.Template != "" {
+			made[cfg.Template]++
+		}
+	}
+	fmt.Printf("%-30s %-24s %-40s %s\n", "ID", "CONTEXTS", "SERVICE", "FORWARDS")
+	for _, tmpl := range templates {
+		target := fmt.Sprintf("%s/%s %s→%d", tmpl.Namespace, tmpl.Service, portSpan(tmpl.PortLocal, tmpl.Ports()), tmpl.PortRemote)
+		fmt.Printf("%-30s %-24s %-40s %d\n", tmpl.ID, tmpl.Context, target, made[tmpl.ID])
+	}
+}
+
+// addTemplate turns a copy of an existing forward into a template for the
+// contexts matching a pattern, replacing a template with the same ID, then
+// expands it
+func addTemplate(store *config.SQLiteConfigStore, args []string) {
+	addCmd := flag.NewFlagSet("templates add", flag.ExitOnError)
+	id := addCmd.String("id", "", "Template ID (default: the forward's ID without its context part)")
+	addCmd.Usage = showTemplatesHelp
+	if err := addCmd.Parse(args); err != nil {
+		fmt.Printf("Error parsing arguments: %v\n", err)
+		os.Exit(1)
+	}
+	if addCmd.NArg() != 2 {
+		fmt.Printf("Error: 'templates add' expects a forward ID and a context pattern\n\n")
+		showTemplatesHelp()
+		os.Exit(1)
+	}
+	source, pattern := addCmd.Arg(0), addCmd.Arg(1)
+
+	cfg, ok := store.GetConfigByID(source)
+	if !ok {
+		fmt.Printf("Error: no forward with ID '%s'\n", source)
+		os.Exit(1)
+	}
+	tmpl := cfg
+	tmpl.Context = pattern
+	tmpl.ID = *id
+	if tmpl.ID == "" {
+		tmpl.ID = strings.TrimPrefix(cfg.ID, config.SanitizeIDPart(cfg.Context)+".")
+	}
+	if err := store.SetForwardTemplate(tmpl); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ Saved template %s for contexts %s\n", tmpl.ID, pattern)
+
+	// The source forward is the template's forward in its own context
+	if ok, _ := path.Match(pattern, cfg.Context); ok && cfg.Template == "" {
+		err := store.ModifyPortForward(cfg.ID, func(c *config.PortForwardConfig) error {
+			c.Template = tmpl.ID
+			return nil
+		})
+		if err != nil {
+			fmt.Printf("Warning: could not link %s to the template: %v\n", cfg.ID, err)
+		}
+	}
+	syncTemplates(store)
+}
+
+// syncTemplates expands the templates across the kubeconfig's contexts and
+// prints what changed
+func syncTemplates(store *config.SQLiteConfigStore) {
+	kubectl.ApplySettings(store.GetSettings())
+	contexts, err := kubectl.Contexts()
+	if err != nil {
+		fmt.Printf("Error listing kubectl contexts: %v\n", err)
+		os.Exit(1)
+	}
+	sync, err := store.SyncForwardTemplates(contexts)
+	for _, id := range sync.Added {
+		fmt.Printf("  + %s\n", id)
+	}
+	for _, id := range sync.Updated {
+		fmt.Printf("  ~ %s\n", id)
+	}
+	for _, id := range sync.Skipped {
+		fmt.Printf("  ! %s exists and was not made from a template, skipped\n", id)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("✅ %d forwards added, %d updated from templates\n", len(sync.Added), len(sync.Updated))
+}
+
+// showTemplatesHelp displays help for the templates command
+func showTemplatesHelp() {
+	programName := os.Args[0]
+	fmt.Fprintf(os.Stderr, `%s templates - Define a forward once for many contexts
+
+A template is a forward whose context is a pattern such as '*-staging'. It
+is expanded into one forward per matching kubectl context, with the ID
+<context>.<template ID>. The TUI expands templates whenever it lists the
+contexts (at startup and when the kubeconfig changes), so a new cluster gets
+its forwards without copying them by hand.
+
+To change a template, edit the forward it was made from and add it again
+under the same template ID. Expanded forwards follow the change, except for
+their ID and local port, which you may change to resolve clashes. Deleting
+an expanded forward only lasts until the next expansion; narrow the pattern
+instead.
+
+Usage:
+  %s templates [list]                                  List the templates
+  %s templates add [--id <id>] <forward-id> <pattern>  Make or replace a template from a forward
+  %s templates remove <id>                             Remove a template (its forwards stay)
+  %s templates sync                                    Expand the templates now
+
+Options:
+  --id string           Template ID (default: the forward's ID without its context part)
+  -h, --help            Show this help message
+
+Patterns use * (any characters), ? (one character) and [a-z] classes.
+
+Examples:
+  %s templates add eu-staging.payments.api '*-staging'
+  %s templates add --id redis prod-eu.cache.redis 'prod-*'
+  %s templates sync
+`, programName, programName, programName, programName, programName, programName, programName, programName)
+}
@@ -0,0 +1,119 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// Several kprtfwd processes use the database at once: the TUI keeps it open
+// while `kprtfwd import`, `prune`, `ids rename` or `templates add` run in
+// another terminal, or a second TUI writes to it. A plain database is opened
+// in WAL mode, so readers never wait for a writer; with a busy timeout, so a
+// writer waits for another instead of failing; and with transactions that take
+// the write lock when they begin, so a read and the write depending on it
+// (ModifyPortForward) cannot interleave with another process' write. An
+// encrypted database is a private in-memory copy written back whole, so
+// persist refuses to overwrite a file written by someone else meanwhile.
+// Either way ChangeStamp tells a long-running process when to re-read what it
+// derived from the database.
+
+// busyTimeout is how long a write waits for another process' write to finish
+const busyTimeout = 5 * time.Second
+
+// ErrChangedElsewhere is returned by a write to an encrypted database that
+// another kprtfwd wrote since this one read it. The store has re-read the
+// database and the write was dropped; repeating it applies it to the current
+// data.
+var ErrChangedElsewhere = errors.New("the database was changed by another kprtfwd meanwhile; it was reloaded, try again")
+
+// plainDSN returns the data source name a plain database at path is opened
+// with
+func plainDSN(path string) string {
+	return fmt.Sprintf("%s?_pragma=busy_timeout(%d)&_pragma=journal_mode(WAL)&_txlock=immediate", path, busyTimeout.Milliseconds())
+}
+
+// fileStamp returns a fingerprint of the files' modification times and sizes
+func fileStamp(paths ...string) string {
+	var b strings.Builder
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:-;", path)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", path, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String()
+}
+
+// ChangeStamp returns a fingerprint of the database files. It changes
+// whenever a write is committed, by this process or another, and costs only a
+// stat per file, so it can be polled; on a change, call Reload and re-read.
+func (cs *SQLiteConfigStore) ChangeStamp() string {
+	if cs.encKey != nil {
+		return fileStamp(cs.dbPath + encryptedSuffix)
+	}
+	// In WAL mode commits go to the -wal file until it is checkpointed
+	return fileStamp(cs.dbPath, cs.dbPath+"-wal")
+}
+
+// Reload catches up with writes by other kprtfwd processes: an encrypted
+// database is re-read if it was written since this store last read or wrote
+// it, and the active project's forwards are looked up again. Reads of a plain
+// database always see the latest commit anyway.
+func (cs *SQLiteConfigStore) Reload() error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	if cs.encKey != nil && fileStamp(cs.dbPath+encryptedSuffix) != cs.seenStamp {
+		if err := cs.reloadLocked(); err != nil {
+			return err
+		}
+	}
+	if cs.activeProject != nil {
+		for _, p := range cs.getProjectsUnsafe() {
+			if p.Name == cs.activeProject.Name {
+				cs.activeProject = &Project{Name: p.Name, Forwards: append([]string{}, p.Forwards...)}
+			}
+		}
+	}
+	return nil
+}
+
+// ModifyPortForward re-reads the forward id, applies change to it and stores
+// the result, in one transaction: a change made meanwhile by another kprtfwd
+// is built upon rather than overwritten. change may not alter the ID; an
+// error from it aborts the update and is returned as is.
+func (cs *SQLiteConfigStore) ModifyPortForward(id string, change func(cfg *PortForwardConfig) error) error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	cfg, err := scanPortForward(tx.QueryRow(`SELECT `+portForwardColumns+` FROM port_forwards WHERE id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("port forward with ID '%s' not found", id)
+	} else if err != nil {
+		return fmt.Errorf("failed to read port forward: %w", err)
+	}
+	if err := change(&cfg); err != nil {
+		return err
+	}
+	if cfg.ID != id {
+		return fmt.Errorf("cannot rename %s while modifying it", id)
+	}
+	if err := updatePortForwardTx(tx, id, cfg); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return cs.persist()
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// A command writing while the TUI has the database open: the TUI's store
+// notices through ChangeStamp and its read-modify-writes build on the
+// command's change.
+func TestTwoStoresShareAPlainDatabase(t *testing.T) {
+	tui := newTestStore(t)
+	web := PortForwardConfig{ID: "prod.web", Context: "prod", Namespace: "shop", Service: "web", PortRemote: 80, PortLocal: 8080}
+	if err := tui.Add(web); err != nil {
+		t.Fatal(err)
+	}
+	stamp := tui.ChangeStamp()
+
+	cmd, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Close()
+	if err := cmd.ModifyPortForward(web.ID, func(c *PortForwardConfig) error {
+		c.Lazy = true
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if tui.ChangeStamp() == stamp {
+		t.Fatal("ChangeStamp did not change after another store wrote")
+	}
+	if err := tui.ModifyPortForward(web.ID, func(c *PortForwardConfig) error {
+		c.Tags = "shop"
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	got, _ := cmd.GetConfigByID(web.ID)
+	if !got.Lazy || got.Tags != "shop" {
+		t.Fatalf("after both changes = lazy %v, tags %v; want both kept", got.Lazy, got.Tags)
+	}
+
+	if err := tui.ModifyPortForward(web.ID, func(c *PortForwardConfig) error {
+		c.ID = "prod.www"
+		return nil
+	}); err == nil {
+		t.Fatal("ModifyPortForward allowed a rename")
+	}
+	if err := tui.ModifyPortForward("prod.missing", func(*PortForwardConfig) error { return nil }); err == nil {
+		t.Fatal("ModifyPortForward of a missing forward succeeded")
+	}
+}
+
+// An encrypted database is written back whole, so a store that missed
+// another's write must not overwrite it.
+func TestEncryptedWriteRefusesToOverwriteAnotherStore(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(EnvDBKey, strings.Repeat("ab", 32))
+	setup, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	setup.Close()
+	if err := EncryptDatabase(); err != nil {
+		t.Fatal(err)
+	}
+
+	tui, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tui.Close()
+	cmd, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cmd.Close()
+
+	web := PortForwardConfig{ID: "prod.web", Context: "prod", Namespace: "shop", Service: "web", PortRemote: 80, PortLocal: 8080}
+	if err := cmd.Add(web); err != nil {
+		t.Fatal(err)
+	}
+	api := PortForwardConfig{ID: "prod.api", Context: "prod", Namespace: "shop", Service: "api", PortRemote: 80, PortLocal: 8081}
+	if err := tui.Add(api); !errors.Is(err, ErrChangedElsewhere) {
+		t.Fatalf("writing over another store's write = %v, want ErrChangedElsewhere", err)
+	}
+	if _, ok := tui.GetConfigByID(web.ID); !ok {
+		t.Fatal("the refused store did not reload the other's forward")
+	}
+	if err := tui.Add(api); err != nil {
+		t.Fatalf("repeating the write after the reload: %v", err)
+	}
+
+	if err := cmd.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if got := cmd.Len(); got != 2 {
+		t.Fatalf("after reloading, the other store has %d forwards, want 2", got)
+	}
+}
@@ -0,0 +1,205 @@
+package config
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Running kprtfwd processes, TUIs and `kprtfwd run`, are controlled from
+// other terminals through files in ~/.kprtfwd/control, like a panic stop:
+// each host publishes the state of its forwards in <pid>.json on every status
+// refresh, and takes the start and stop requests `kprtfwd start` and
+// `kprtfwd stop` append to <pid>.requests.
+
+const controlDirName = "control"
+
+// hostStateMaxAge is how old a host's published state may get before the
+// host is taken for gone: it refreshes every couple of seconds while it runs
+const hostStateMaxAge = 10 * time.Second
+
+// processStartedAt is when this process started, as far as hosts go
+var processStartedAt = time.Now()
+
+// Host modes
+const (
+	HostModeTUI = "tui" // the interactive TUI
+	HostModeRun = "run" // kprtfwd run
+)
+
+// Control request actions
+const (
+	ControlStart = "start"
+	ControlStop  = "stop"
+)
+
+// HostState is what a running kprtfwd publishes about itself
+type HostState struct {
+	PID       int           `json:"pid"`
+	Workspace string        `json:"workspace"`
+	Mode      string        `json:"mode"` // HostModeTUI or HostModeRun
+	StartedAt time.Time     `json:"started_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+	Forwards  []HostForward `json:"forwards"` // the forwards it does not leave stopped
+}
+
+// HostForward is the state of one forward a host runs
+type HostForward struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`           // "running", "standby", "queued", "failed", ...
+	Detail string `json:"detail,omitempty"` // why it failed or is degraded
+}
+
+// ControlRequest asks a host to start or stop a forward
+type ControlRequest struct {
+	Action string // ControlStart or ControlStop
+	ID     string
+}
+
+// controlPath returns the path of name in ~/.kprtfwd/control
+func controlPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kprtfwd", controlDirName, name), nil
+}
+
+// PublishHostState records the state of this process's forwards for other
+// commands to read. PID, Workspace and the times are filled in.
+func PublishHostState(state HostState) error {
+	state.PID = os.Getpid()
+	state.Workspace = CurrentWorkspace()
+	state.StartedAt = processStartedAt
+	state.UpdatedAt = time.Now()
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+	path, err := controlPath(strconv.Itoa(state.PID) + ".json")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	// Readers never see a half-written file
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// RemoveHostState withdraws this process's published state and any request
+// left for it, as it exits
+func RemoveHostState() {
+	pid := strconv.Itoa(os.Getpid())
+	for _, name := range []string{pid + ".json", pid + ".requests"} {
+		if path, err := controlPath(name); err == nil {
+			_ = os.Remove(path)
+		}
+	}
+}
+
+// HostStates returns the state of every running kprtfwd of the current
+// workspace, most recently started first. State left behind by a process
+// that died without withdrawing it is removed.
+func HostStates() ([]HostState, error) {
+	dir, err := controlPath("")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	workspace := CurrentWorkspace()
+	var hosts []HostState
+	for _, e := range entries {
+		if !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var state HostState
+		if err := json.Unmarshal(data, &state); err != nil {
+			continue
+		}
+		if time.Since(state.UpdatedAt) > hostStateMaxAge {
+			_ = os.Remove(path)
+			_ = os.Remove(strings.TrimSuffix(path, ".json") + ".requests")
+			continue
+		}
+		if state.Workspace == workspace {
+			hosts = append(hosts, state)
+		}
+	}
+	sort.Slice(hosts, func(i, j int) bool { return hosts[i].StartedAt.After(hosts[j].StartedAt) })
+	return hosts, nil
+}
+
+// SendControlRequests asks the kprtfwd with the given PID to carry out reqs
+// on its next status refresh
+func SendControlRequests(pid int, reqs []ControlRequest) error {
+	path, err := controlPath(strconv.Itoa(pid) + ".requests")
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	var b strings.Builder
+	for _, r := range reqs {
+		fmt.Fprintf(&b, "%s %s\n", r.Action, r.ID)
+	}
+	_, err = f.WriteString(b.String()) // one write: appends do not interleave
+	return err
+}
+
+// TakeControlRequests returns the requests sent to this process since the
+// last call, and forgets them
+func TakeControlRequests() ([]ControlRequest, error) {
+	path, err := controlPath(strconv.Itoa(os.Getpid()) + ".requests")
+	if err != nil {
+		return nil, err
+	}
+	// Renamed first, so a request appended meanwhile waits for the next call
+	taken := path + ".taken"
+	if err := os.Rename(path, taken); os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer os.Remove(taken)
+	f, err := os.Open(taken)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var reqs []ControlRequest
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		action, id, ok := strings.Cut(scanner.Text(), " ")
+		if !ok || (action != ControlStart && action != ControlStop) {
+			continue
+		}
+		reqs = append(reqs, ControlRequest{Action: action, ID: id})
+	}
+	return reqs, scanner.Err()
+}
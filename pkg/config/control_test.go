@@ -0,0 +1,65 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestHostStatesAndControlRequests(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvWorkspace, "")
+
+	if hosts, err := HostStates(); err != nil || len(hosts) != 0 {
+		t.Fatalf("HostStates before any host = %v, %v; want none", hosts, err)
+	}
+	state := HostState{Mode: HostModeRun, Forwards: []HostForward{{ID: "dev.ns.api", Status: "running"}}}
+	if err := PublishHostState(state); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := HostStates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hosts) != 1 || hosts[0].PID != os.Getpid() || hosts[0].Forwards[0].ID != "dev.ns.api" {
+		t.Fatalf("HostStates = %+v, want this process running dev.ns.api", hosts)
+	}
+
+	t.Setenv(EnvWorkspace, "other")
+	if hosts, _ := HostStates(); len(hosts) != 0 {
+		t.Errorf("HostStates in another workspace = %+v, want none", hosts)
+	}
+	t.Setenv(EnvWorkspace, "")
+
+	reqs := []ControlRequest{{Action: ControlStart, ID: "dev.ns.api"}, {Action: ControlStop, ID: "dev.ns.db"}}
+	if err := SendControlRequests(os.Getpid(), reqs); err != nil {
+		t.Fatal(err)
+	}
+	got, err := TakeControlRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 2 || got[0] != reqs[0] || got[1] != reqs[1] {
+		t.Errorf("TakeControlRequests = %+v, want %+v", got, reqs)
+	}
+	if got, _ := TakeControlRequests(); len(got) != 0 {
+		t.Errorf("second TakeControlRequests = %+v, want none", got)
+	}
+
+	// State a process left behind when it died is dropped
+	stale := filepath.Join(home, ".kprtfwd", controlDirName, strconv.Itoa(os.Getpid())+".json")
+	old := time.Now().Add(-time.Minute)
+	data := []byte(`{"pid": 1, "workspace": "default", "mode": "tui", "updated_at": "` + old.Format(time.RFC3339Nano) + `"}`)
+	if err := os.WriteFile(stale, data, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if hosts, _ := HostStates(); len(hosts) != 0 {
+		t.Errorf("HostStates with stale state = %+v, want none", hosts)
+	}
+	if _, err := os.Stat(stale); !os.IsNotExist(err) {
+		t.Errorf("stale state was not removed: %v", err)
+	}
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Digest lists the forwards into protected clusters that have been running
+// for longer than a threshold, for kprtfwd digest to send to a security team
+type Digest struct {
+	Workspace string          `json:"workspace"`
+	Generated time.Time       `json:"generated"`
+	Threshold string          `json:"threshold"` // e.g. "4h0m0s"
+	Forwards  []DigestForward `json:"forwards"`
+}
+
+// DigestForward is one long-running protected forward in a Digest
+type DigestForward struct {
+	ID        string    `json:"id"`
+	Context   string    `json:"context"`
+	Namespace string    `json:"namespace"`
+	Target    string    `json:"target"` // svc/NAME, pod/NAME, ...
+	PortLocal int       `json:"port_local"`
+	Status    string    `json:"status"`
+	Since     time.Time `json:"since"`
+	Host      string    `json:"host"` // e.g. "tui 4711"
+}
+
+// upHostStatuses are the host statuses of a forward that serves its local port
+var upHostStatuses = map[string]bool{"running": true, "active": true, "standby": true, "degraded": true}
+
+// DigestThreshold returns SettingDigestThreshold, or DefaultDigestThreshold
+// when it is unset
+func DigestThreshold(settings map[string]string) time.Duration {
+	if d, err := time.ParseDuration(settings[SettingDigestThreshold]); err == nil && d > 0 {
+		return d
+	}
+	return DefaultDigestThreshold
+}
+
+// BuildDigest lists the forwards of cfgs that SettingProtected selects, that
+// a host in hosts serves and that have been up since before now minus the
+// threshold, longest-running first. When a forward started is read from the
+// start/stop history in events, oldest first, else taken as when its host
+// started. It returns an error when SettingProtected is unset.
+func BuildDigest(cfgs []PortForwardConfig, hosts []HostState, events []ForwardEvent, settings map[string]string, now time.Time) (Digest, error) {
+	text := settings[SettingProtected]
+	if text == "" {
+		return Digest{}, fmt.Errorf("no protected forwards defined; set %s, e.g. to context:prod-*", SettingProtected)
+	}
+	protected, err := ParseGroupExpr(text)
+	if err != nil {
+		return Digest{}, fmt.Errorf("invalid %s: %w", SettingProtected, err)
+	}
+	threshold := DigestThreshold(settings)
+	digest := Digest{Workspace: CurrentWorkspace(), Generated: now, Threshold: threshold.String()}
+
+	usage := SummarizeUsage(cfgs, events)
+	for i, cfg := range cfgs {
+		if !protected.Match(cfg) {
+			continue
+		}
+		host, status, ok := servingHost(hosts, cfg.ID)
+		if !ok {
+			continue
+		}
+		since := host.StartedAt
+		if u := usage[i]; u.Up && u.LastStart.After(since) {
+			since = u.LastStart // restarted since its host started
+		}
+		if now.Sub(since) < threshold {
+			continue
+		}
+		digest.Forwards = append(digest.Forwards, DigestForward{
+			ID:        cfg.ID,
+			Context:   cfg.Context,
+			Namespace: cfg.Namespace,
+			Target:    cfg.Target(),
+			PortLocal: cfg.PortLocal,
+			Status:    status,
+			Since:     since,
+			Host:      fmt.Sprintf("%s %d", host.Mode, host.PID),
+		})
+	}
+	sort.SliceStable(digest.Forwards, func(i, j int) bool {
+		return digest.Forwards[i].Since.Before(digest.Forwards[j].Since)
+	})
+	return digest, nil
+}
+
+// servingHost returns the host in hosts that serves the forward id, and the
+// forward's status there
+func servingHost(hosts []HostState, id string) (HostState, string, bool) {
+	for _, h := range hosts {
+		for _, f := range h.Forwards {
+			if f.ID == id && upHostStatuses[f.Status] {
+				return h, f.Status, true
+			}
+		}
+	}
+	return HostState{}, "", false
+}
+
+// Text renders the digest for people: a summary line, then one line per
+// forward with how long it has been running
+func (d Digest) Text() string {
+	var b strings.Builder
+	if len(d.Forwards) == 0 {
+		fmt.Fprintf(&b, "kprtfwd (workspace %s): no protected forward has been running for more than %s\n", d.Workspace, d.Threshold)
+		return b.String()
+	}
+	fmt.Fprintf(&b, "kprtfwd (workspace %s): %d protected forward(s) running for more than %s\n", d.Workspace, len(d.Forwards), d.Threshold)
+	for _, f := range d.Forwards {
+		running := d.Generated.Sub(f.Since).Truncate(time.Minute)
+		fmt.Fprintf(&b, "  %s  %s/%s %s on localhost:%d, %s for %s (since %s, %s)\n",
+			f.ID, f.Context, f.Namespace, f.Target, f.PortLocal, f.Status, running, f.Since.Format("2006-01-02 15:04"), f.Host)
+	}
+	return b.String()
+}
@@ -0,0 +1,59 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBuildDigest(t *testing.T) {
+	t.Setenv(EnvWorkspace, "")
+	now := time.Date(2026, 5, 4, 9, 0, 0, 0, time.UTC)
+	cfgs := []PortForwardConfig{
+		{ID: "prod.data.pg", Context: "prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432},
+		{ID: "prod.data.redis", Context: "prod", Namespace: "data", Service: "redis", PortRemote: 6379, PortLocal: 6379},
+		{ID: "prod.web.api", Context: "prod", Namespace: "web", Service: "api", PortRemote: 80, PortLocal: 8080},
+		{ID: "dev.data.pg", Context: "dev", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 15432},
+	}
+	hosts := []HostState{{
+		PID: 4711, Mode: HostModeTUI, StartedAt: now.Add(-30 * time.Hour),
+		Forwards: []HostForward{
+			{ID: "prod.data.pg", Status: "running"},
+			{ID: "prod.data.redis", Status: "running"},
+			{ID: "prod.web.api", Status: "failed"},
+			{ID: "dev.data.pg", Status: "running"},
+		},
+	}}
+	events := []ForwardEvent{
+		{ForwardID: "prod.data.pg", Kind: ForwardEventStart, At: now.Add(-26 * time.Hour)},
+		{ForwardID: "prod.data.redis", Kind: ForwardEventStart, At: now.Add(-29 * time.Hour)},
+		{ForwardID: "prod.data.redis", Kind: ForwardEventStop, At: now.Add(-2 * time.Hour)},
+		{ForwardID: "prod.data.redis", Kind: ForwardEventStart, At: now.Add(-time.Hour)},
+	}
+
+	if _, err := BuildDigest(cfgs, hosts, events, map[string]string{}, now); err == nil {
+		t.Fatal("BuildDigest without protected forwards: want an error")
+	}
+
+	settings := map[string]string{SettingProtected: "context:prod"}
+	digest, err := BuildDigest(cfgs, hosts, events, settings, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// redis restarted an hour ago and api is not up; dev is not protected
+	if len(digest.Forwards) != 1 || digest.Forwards[0].ID != "prod.data.pg" {
+		t.Fatalf("digest lists %+v, want prod.data.pg only", digest.Forwards)
+	}
+	if f := digest.Forwards[0]; !f.Since.Equal(now.Add(-26*time.Hour)) || f.Host != "tui 4711" {
+		t.Errorf("prod.data.pg since %s on %q, want 26h ago on tui 4711", f.Since, f.Host)
+	}
+	if text := digest.Text(); !strings.Contains(text, "prod.data.pg") || !strings.Contains(text, "26h0m0s") {
+		t.Errorf("Text() = %q, want the forward and how long it runs", text)
+	}
+
+	settings[SettingDigestThreshold] = "30m"
+	digest, _ = BuildDigest(cfgs, hosts, events, settings, now)
+	if len(digest.Forwards) != 2 || digest.Forwards[0].ID != "prod.data.pg" || digest.Forwards[1].ID != "prod.data.redis" {
+		t.Errorf("digest with a 30m threshold lists %+v, want pg, then redis", digest.Forwards)
+	}
+}
@@ -0,0 +1,470 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// A config dump holds the forwards and projects of a workspace in a file, so
+// a team can keep canonical definitions in git: kprtfwd export writes one as
+// YAML or JSON and kprtfwd import reads it back into another workspace or
+// machine. What only makes sense on the machine that wrote it, the kubeconfig
+// a forward's context was found in and the template it was expanded from, is
+// left out. A dump may also carry the stored settings, the theme among them,
+// to set up kprtfwd on a new machine in one go.
+
+// DumpVersion is the format version of the dumps this kprtfwd writes
+const DumpVersion = 1
+
+// ConfigDump is the content of a dump file
+type ConfigDump struct {
+	// Version is DumpVersion; its key also tells a dump from other files
+	Version  int             `json:"kprtfwd"`
+	Forwards []DumpedForward `json:"forwards"`
+	Projects []DumpedProject `json:"projects,omitempty"`
+	// Settings are stored settings by key, when the export included them
+	Settings map[string]string `json:"settings,omitempty"`
+}
+
+// DumpedForward is a forward in a dump, its fields named as in the database
+type DumpedForward struct {
+	ID            string `json:"id"`
+	Context       string `json:"context,omitempty"`
+	Namespace     string `json:"namespace"`
+	TargetKind    string `json:"target_kind,omitempty"`
+	Service       string `json:"service"`
+	PortRemote    int    `json:"port_remote"`
+	PortLocal     int    `json:"port_local"`
+	PortCount     int    `json:"port_count,omitempty"`
+	Lazy          bool   `json:"lazy,omitempty"`
+	TLSMode       string `json:"tls_mode,omitempty"`
+	TLSServerName string `json:"tls_server_name,omitempty"`
+	KubectlArgs   string `json:"kubectl_args,omitempty"`
+	Listen        string `json:"listen,omitempty"`
+	Tags          string `json:"tags,omitempty"`
+	OpenCommand   string `json:"open_command,omitempty"`
+	PodSelector   string `json:"pod_selector,omitempty"`
+	StartHook     string `json:"start_hook,omitempty"`
+	StopHook      string `json:"stop_hook,omitempty"`
+	HealthPath    string `json:"health_path,omitempty"`
+}
+
+// DumpedProject is a project in a dump
+type DumpedProject struct {
+	Name     string   `json:"name"`
+	Forwards []string `json:"forwards"`
+}
+
+// NewConfigDump returns the dump of cfgs and of projects, which keep only
+// their forwards among cfgs; projects left with none are dropped
+func NewConfigDump(cfgs []PortForwardConfig, projects []Project) ConfigDump {
+	d := ConfigDump{Version: DumpVersion, Forwards: []DumpedForward{}}
+	ids := make(map[string]bool, len(cfgs))
+	for _, cfg := range cfgs {
+		d.Forwards = append(d.Forwards, dumpForward(cfg))
+		ids[cfg.ID] = true
+	}
+	for _, p := range projects {
+		dp := DumpedProject{Name: p.Name}
+		for _, id := range p.Forwards {
+			if ids[id] {
+				dp.Forwards = append(dp.Forwards, id)
+			}
+		}
+		if len(dp.Forwards) > 0 {
+			d.Projects = append(d.Projects, dp)
+		}
+	}
+	return d
+}
+
+// DumpSettings returns the settings of stored a dump carries: those kprtfwd
+// knows, so that importing them cannot fail on a key, except the paths and
+// commands of this machine (SettingSpec.Local)
+func DumpSettings(stored map[string]string) map[string]string {
+	settings := make(map[string]string, len(stored))
+	for key, value := range stored {
+		if spec, ok := LookupSettingSpec(key); ok && !spec.Local {
+			settings[key] = value
+		}
+	}
+	return settings
+}
+
+func dumpForward(cfg PortForwardConfig) DumpedForward {
+	return DumpedForward{
+		ID: cfg.ID, Context: cfg.Context, Namespace: cfg.Namespace, TargetKind: cfg.TargetKind, Service: cfg.Service,
+		PortRemote: cfg.PortRemote, PortLocal: cfg.PortLocal, PortCount: cfg.PortCount, Lazy: cfg.Lazy,
+		TLSMode: cfg.TLSMode, TLSServerName: cfg.TLSServerName, KubectlArgs: cfg.KubectlArgs, Listen: cfg.Listen,
+		Tags: cfg.Tags, OpenCommand: cfg.OpenCommand, PodSelector: cfg.PodSelector,
+		StartHook: cfg.StartHook, StopHook: cfg.StopHook, HealthPath: cfg.HealthPath,
+	}
+}
+
+// Config returns the forward f defines
+func (f DumpedForward) Config() PortForwardConfig {
+	return PortForwardConfig{
+		ID: f.ID, Context: f.Context, Namespace: f.Namespace, TargetKind: f.TargetKind, Service: f.Service,
+		PortRemote: f.PortRemote, PortLocal: f.PortLocal, PortCount: f.PortCount, Lazy: f.Lazy,
+		TLSMode: f.TLSMode, TLSServerName: f.TLSServerName, KubectlArgs: f.KubectlArgs, Listen: f.Listen,
+		Tags: f.Tags, OpenCommand: f.OpenCommand, PodSelector: f.PodSelector,
+		StartHook: f.StartHook, StopHook: f.StopHook, HealthPath: f.HealthPath,
+	}
+}
+
+// DecodeConfigDump reads a dump from its JSON form. Unknown fields are
+// errors, so a misspelt one in a hand-edited file is not silently dropped.
+func DecodeConfigDump(data []byte) (ConfigDump, error) {
+	var d ConfigDump
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&d); err != nil {
+		return d, fmt.Errorf("not a valid kprtfwd export: %w", err)
+	}
+	switch {
+	case d.Version < 1:
+		return d, fmt.Errorf("not a valid kprtfwd export: kprtfwd: %d is not a format version", d.Version)
+	case d.Version > DumpVersion:
+		return d, fmt.Errorf("the export has format version %d; this kprtfwd reads up to %d, upgrade it", d.Version, DumpVersion)
+	}
+	return d, nil
+}
+
+// DumpYAML returns d as block YAML, with header lines as comments at the top.
+// Strings are always double-quoted, so none is read back as a number or bool.
+func DumpYAML(d ConfigDump, header []string) string {
+	var b strings.Builder
+	for _, line := range header {
+		b.WriteString("# " + line + "\n")
+	}
+	fmt.Fprintf(&b, "kprtfwd: %d\n", d.Version)
+	if len(d.Forwards) == 0 {
+		b.WriteString("forwards: []\n")
+	} else {
+		b.WriteString("forwards:\n")
+	}
+	for _, f := range d.Forwards {
+		v := reflect.ValueOf(f)
+		prefix := "  - "
+		for i := 0; i < v.NumField(); i++ {
+			field := v.Field(i)
+			if field.IsZero() && strings.HasSuffix(v.Type().Field(i).Tag.Get("json"), ",omitempty") {
+				continue
+			}
+			key, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			fmt.Fprintf(&b, "%s%s: %s\n", prefix, key, dumpYAMLValue(field))
+			prefix = "    "
+		}
+	}
+	if len(d.Projects) > 0 {
+		b.WriteString("projects:\n")
+	}
+	for _, p := range d.Projects {
+		fmt.Fprintf(&b, "  - name: %s\n    forwards:\n", strconv.Quote(p.Name))
+		for _, id := range p.Forwards {
+			fmt.Fprintf(&b, "      - %s\n", strconv.Quote(id))
+		}
+	}
+	if len(d.Settings) > 0 {
+		b.WriteString("settings:\n")
+	}
+	keys := slices.Sorted(maps.Keys(d.Settings))
+	for _, key := range keys {
+		fmt.Fprintf(&b, "  %s: %s\n", strconv.Quote(key), strconv.Quote(d.Settings[key]))
+	}
+	return b.String()
+}
+
+// dumpYAMLValue formats a string, int or bool field of a dump
+func dumpYAMLValue(v reflect.Value) string {
+	switch v.Kind() {
+	case reflect.String:
+		return strconv.Quote(v.String())
+	case reflect.Bool:
+		return strconv.FormatBool(v.Bool())
+	default:
+		return strconv.FormatInt(v.Int(), 10)
+	}
+}
+
+// validateDumpedForward checks a forward of a dump as the store's other
+// writers check theirs, so a bad file fails before anything is written
+func validateDumpedForward(cfg PortForwardConfig) error {
+	if cfg.ID == "" {
+		return fmt.Errorf("forward ID must not be empty")
+	}
+	if strings.ContainsAny(cfg.ID, " \t\n") {
+		return fmt.Errorf("forward ID %q contains whitespace", cfg.ID)
+	}
+	checks := []error{
+		ValidateContextName(cfg.Context),
+		ValidateKubernetesName("namespace", cfg.Namespace),
+		ValidateKubernetesName(cfg.KindName(), cfg.Service),
+		ValidateTarget(cfg.TargetKind, cfg.PodSelector),
+		ValidatePortRange("local port", cfg.PortLocal, cfg.PortCount),
+		ValidatePortRange("remote port", cfg.PortRemote, cfg.PortCount),
+		ValidateTLSMode(cfg.TLSMode),
+		ValidateListen(cfg.Listen),
+		ValidateKubectlArgs(cfg.KubectlArgs),
+		ValidateTags(cfg.Tags),
+		ValidatePodSelector(cfg.PodSelector),
+		ValidateHook(cfg.StartHook),
+		ValidateHook(cfg.StopHook),
+		ValidateHealthPath(cfg.HealthPath),
+		ValidateOpenCommand(cfg.OpenCommand),
+	}
+	for _, err := range checks {
+		if err != nil {
+			return fmt.Errorf("%s: %w", cfg.ID, err)
+		}
+	}
+	return nil
+}
+
+// Import strategies: what ImportConfigDump does with a forward or project
+// that exists already and differs from the dump's
+const (
+	ImportMerge     = "merge"     // keep the forward; add the dump's forwards to the project
+	ImportOverwrite = "overwrite" // replace both with the dump's
+)
+
+// ImportResult reports what ImportConfigDump changed, by forward ID and
+// project name
+type ImportResult struct {
+	Added     []string
+	Updated   []string
+	Unchanged []string // defined as in the dump already
+	Kept      []string // differing from the dump, kept by ImportMerge
+	// ProjectsAdded and ProjectsUpdated are the projects created and those
+	// whose forwards changed
+	ProjectsAdded   []string
+	ProjectsUpdated []string
+	// SettingsAdded, SettingsUpdated and SettingsKept are the settings stored
+	// new, replaced, and differing but kept by ImportMerge, by key
+	SettingsAdded   []string
+	SettingsUpdated []string
+	SettingsKept    []string
+	// Commands are the commands the added and replaced forwards gain or
+	// change, and the stored local settings (SettingSpec.Local), which
+	// kprtfwd runs or trusts later without asking
+	Commands []ImportedCommand
+}
+
+// ImportedCommand is a command an import gives a forward, a hook, its open
+// command or its extra kubectl arguments, or a local setting it stores
+type ImportedCommand struct {
+	ID    string // the forward's; empty for a setting
+	Field string // its dump name, e.g. "start_hook", or the setting's key
+	Value string
+}
+
+// importedCommands returns the commands of cfg that cur, the forward it
+// replaces (the zero value for a new one), lacks or has otherwise
+func importedCommands(cfg, cur PortForwardConfig) []ImportedCommand {
+	fields := []struct{ name, value, cur string }{
+		{"kubectl_args", cfg.KubectlArgs, cur.KubectlArgs},
+		{"start_hook", cfg.StartHook, cur.StartHook},
+		{"stop_hook", cfg.StopHook, cur.StopHook},
+		{"open_command", cfg.OpenCommand, cur.OpenCommand},
+	}
+	var commands []ImportedCommand
+	for _, f := range fields {
+		if f.value != "" && f.value != f.cur {
+			commands = append(commands, ImportedCommand{ID: cfg.ID, Field: f.name, Value: f.value})
+		}
+	}
+	return commands
+}
+
+// ImportConfigDump adds the forwards, projects and settings of d in one
+// transaction, resolving those the store has already by strategy. Forwards,
+// projects and settings d does not name are left alone. The dump is checked in full first: a bad
+// forward, or a project listing a forward neither d nor the store has, fails
+// the import before anything changes. With dryRun nothing is written, and the
+// result tells what would change.
+func (cs *SQLiteConfigStore) ImportConfigDump(d ConfigDump, strategy string, dryRun bool) (ImportResult, error) {
+	var result ImportResult
+	if strategy != ImportMerge && strategy != ImportOverwrite {
+		return result, fmt.Errorf("unknown import strategy %q (use %s or %s)", strategy, ImportMerge, ImportOverwrite)
+	}
+
+	existing := cs.GetAll()
+	known := make(map[string]bool, len(existing)+len(d.Forwards))
+	for _, cfg := range existing {
+		known[cfg.ID] = true
+	}
+	var inserts, updates []PortForwardConfig
+	seen := make(map[string]bool, len(d.Forwards))
+	for _, f := range d.Forwards {
+		cfg := f.Config()
+		if err := validateDumpedForward(cfg); err != nil {
+			return result, err
+		}
+		if seen[cfg.ID] {
+			return result, fmt.Errorf("forward %s is defined twice", cfg.ID)
+		}
+		seen[cfg.ID] = true
+
+		cur, ok := cs.GetConfigByID(cfg.ID)
+		if !ok {
+			inserts = append(inserts, cfg)
+			result.Added = append(result.Added, cfg.ID)
+			result.Commands = append(result.Commands, importedCommands(cfg, PortForwardConfig{})...)
+			known[cfg.ID] = true
+			continue
+		}
+		// The machine-local fields stay as they are here
+		cfg.Kubeconfig, cfg.Template = cur.Kubeconfig, cur.Template
+		switch {
+		case cfg == cur:
+			result.Unchanged = append(result.Unchanged, cfg.ID)
+		case strategy == ImportMerge:
+			result.Kept = append(result.Kept, cfg.ID)
+		default:
+			updates = append(updates, cfg)
+			result.Updated = append(result.Updated, cfg.ID)
+			result.Commands = append(result.Commands, importedCommands(cfg, cur)...)
+		}
+	}
+
+	projects := cs.GetAllProjects()
+	memberships := make(map[string][]string) // project name -> its forwards after the import
+	names := make(map[string]bool, len(d.Projects))
+	for _, dp := range d.Projects {
+		if dp.Name == "" {
+			return result, fmt.Errorf("project name must not be empty")
+		}
+		if names[dp.Name] {
+			return result, fmt.Errorf("project %s is defined twice", dp.Name)
+		}
+		names[dp.Name] = true
+		for _, id := range dp.Forwards {
+			if !known[id] {
+				return result, fmt.Errorf("project %s lists %s, which neither the export nor this configuration defines", dp.Name, id)
+			}
+		}
+
+		i := slices.IndexFunc(projects, func(p Project) bool { return p.Name == dp.Name })
+		if i < 0 {
+			memberships[dp.Name] = uniqueIDs(nil, dp.Forwards)
+			result.ProjectsAdded = append(result.ProjectsAdded, dp.Name)
+			continue
+		}
+		want := uniqueIDs(nil, dp.Forwards)
+		if strategy == ImportMerge {
+			want = uniqueIDs(projects[i].Forwards, dp.Forwards)
+		}
+		if !sameIDs(want, projects[i].Forwards) {
+			memberships[dp.Name] = want
+			result.ProjectsUpdated = append(result.ProjectsUpdated, dp.Name)
+		}
+	}
+
+	stored := cs.GetStoredSettings()
+	settings := make(map[string]string) // settings to store
+	for _, key := range slices.Sorted(maps.Keys(d.Settings)) {
+		value := d.Settings[key]
+		if err := ValidateSetting(key, value); err != nil {
+			return result, err
+		}
+		cur, ok := stored[key]
+		switch {
+		case !ok:
+			settings[key] = value
+			result.SettingsAdded = append(result.SettingsAdded, key)
+		case cur == value:
+			continue
+		case strategy == ImportMerge:
+			result.SettingsKept = append(result.SettingsKept, key)
+			continue
+		default:
+			settings[key] = value
+			result.SettingsUpdated = append(result.SettingsUpdated, key)
+		}
+		if spec, _ := LookupSettingSpec(key); spec.Local {
+			result.Commands = append(result.Commands, ImportedCommand{Field: key, Value: value})
+		}
+	}
+	if dryRun {
+		return result, nil
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return result, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, cfg := range inserts {
+		query := `INSERT INTO port_forwards (` + portForwardColumns + `) VALUES (` + strings.Repeat("?, ", strings.Count(portForwardColumns, ",")) + `?)`
+		if _, err := tx.Exec(query, portForwardValues(cfg)...); err != nil {
+			return result, fmt.Errorf("failed to add port forward %s: %w", cfg.ID, err)
+		}
+	}
+	for _, cfg := range updates {
+		if err := updatePortForwardTx(tx, cfg.ID, cfg); err != nil {
+			return result, err
+		}
+	}
+	for name, ids := range memberships {
+		if _, err := tx.Exec("INSERT OR IGNORE INTO projects (name) VALUES (?)", name); err != nil {
+			return result, fmt.Errorf("failed to create project %s: %w", name, err)
+		}
+		var projectID int64
+		if err := tx.QueryRow("SELECT id FROM projects WHERE name = ?", name).Scan(&projectID); err != nil {
+			return result, fmt.Errorf("failed to find project %s: %w", name, err)
+		}
+		if _, err := tx.Exec("DELETE FROM project_port_forwards WHERE project_id = ?", projectID); err != nil {
+			return result, fmt.Errorf("failed to update project %s: %w", name, err)
+		}
+		for _, id := range ids {
+			if _, err := tx.Exec("INSERT INTO project_port_forwards (project_id, port_forward_id) VALUES (?, ?)", projectID, id); err != nil {
+				return result, fmt.Errorf("failed to add port forward to project %s: %w", name, err)
+			}
+		}
+	}
+
+	for key, value := range settings {
+		if _, err := tx.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value); err != nil {
+			return result, fmt.Errorf("failed to store setting %s: %w", key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return result, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	if cs.activeProject != nil {
+		if ids, ok := memberships[cs.activeProject.Name]; ok {
+			cs.activeProject.Forwards = append([]string{}, ids...)
+		}
+	}
+	logging.LogDebug("Imported %d forwards (%d added, %d updated), %d projects and %d settings", len(d.Forwards), len(inserts), len(updates), len(d.Projects), len(settings))
+	return result, cs.persist()
+}
+
+// uniqueIDs returns the IDs of a followed by those of b it lacks, without
+// duplicates
+func uniqueIDs(a, b []string) []string {
+	ids := []string{}
+	for _, id := range slices.Concat(a, b) {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// sameIDs reports whether a and b hold the same IDs, in any order
+func sameIDs(a, b []string) bool {
+	return len(a) == len(b) && !slices.ContainsFunc(a, func(id string) bool { return !slices.Contains(b, id) })
+}
@@ -0,0 +1,199 @@
+package config
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecodeConfigDump(t *testing.T) {
+	d := NewConfigDump([]PortForwardConfig{{ID: "a", Namespace: "ns", Service: "svc", PortRemote: 80, PortLocal: 8080, Kubeconfig: "/home/me/.kube/eu"}}, nil)
+	raw, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(raw), "/home/me") {
+		t.Errorf("the kubeconfig belongs to this machine and must stay out: %s", raw)
+	}
+	got, err := DecodeConfigDump(raw)
+	if err != nil || len(got.Forwards) != 1 || got.Forwards[0].Config().PortLocal != 8080 {
+		t.Fatalf("DecodeConfigDump = %+v, %v", got, err)
+	}
+
+	for _, bad := range []string{
+		`{"kprtfwd": 1, "forwards": [{"id": "a", "locl_port": 1}]}`, // misspelt field
+		`{"kprtfwd": 0, "forwards": []}`,
+		`{"kprtfwd": 99, "forwards": []}`,
+	} {
+		if _, err := DecodeConfigDump([]byte(bad)); err == nil {
+			t.Errorf("DecodeConfigDump(%s) must fail", bad)
+		}
+	}
+}
+
+func TestImportConfigDump(t *testing.T) {
+	store := newTestStore(t)
+	local := PortForwardConfig{ID: "a", Namespace: "ns", Service: "svc", PortRemote: 80, PortLocal: 8080, Kubeconfig: "/k", Template: "t"}
+	same := PortForwardConfig{ID: "b", Namespace: "ns", Service: "db", PortRemote: 5432, PortLocal: 5432}
+	for _, cfg := range []PortForwardConfig{local, same} {
+		if err := store.Add(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.CreateProject("team", []string{"b"}); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := local
+	changed.PortLocal = 9090
+	added := PortForwardConfig{ID: "c", Namespace: "ns", Service: "cache", PortRemote: 6379, PortLocal: 6379}
+	dump := NewConfigDump([]PortForwardConfig{changed, same, added}, []Project{{Name: "team", Forwards: []string{"a", "c"}}, {Name: "new", Forwards: []string{"c"}}})
+
+	plan, err := store.ImportConfigDump(dump, ImportMerge, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if _, ok := store.GetConfigByID("c"); ok {
+		t.Fatal("a dry run must change nothing")
+	}
+	if !slices.Equal(plan.Added, []string{"c"}) || !slices.Equal(plan.Kept, []string{"a"}) || !slices.Equal(plan.Unchanged, []string{"b"}) {
+		t.Fatalf("merge plan = %+v", plan)
+	}
+
+	if _, err := store.ImportConfigDump(dump, ImportMerge, false); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if cfg, _ := store.GetConfigByID("a"); cfg.PortLocal != 8080 {
+		t.Errorf("merge must keep a differing forward, got local port %d", cfg.PortLocal)
+	}
+	projects := store.GetAllProjects()
+	team := projects[slices.IndexFunc(projects, func(p Project) bool { return p.Name == "team" })]
+	if !sameIDs(team.Forwards, []string{"a", "b", "c"}) {
+		t.Errorf("merge must add the export's forwards to a project, got %v", team.Forwards)
+	}
+
+	result, err := store.ImportConfigDump(dump, ImportOverwrite, false)
+	if err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if !slices.Equal(result.Updated, []string{"a"}) || !slices.Equal(result.ProjectsUpdated, []string{"team"}) {
+		t.Fatalf("overwrite result = %+v", result)
+	}
+	cfg, _ := store.GetConfigByID("a")
+	if cfg.PortLocal != 9090 || cfg.Kubeconfig != "/k" || cfg.Template != "t" {
+		t.Errorf("overwrite must take the export's fields and keep the local ones, got %+v", cfg)
+	}
+	projects = store.GetAllProjects()
+	team = projects[slices.IndexFunc(projects, func(p Project) bool { return p.Name == "team" })]
+	if !sameIDs(team.Forwards, []string{"a", "c"}) {
+		t.Errorf("overwrite must give a project the export's forwards, got %v", team.Forwards)
+	}
+
+	dangling := NewConfigDump(nil, nil)
+	dangling.Projects = []DumpedProject{{Name: "x", Forwards: []string{"missing"}}}
+	if _, err := store.ImportConfigDump(dangling, ImportMerge, false); err == nil {
+		t.Error("a project listing an unknown forward must fail the import")
+	}
+	bad := NewConfigDump([]PortForwardConfig{{ID: "d", Namespace: "Not_Valid", Service: "s", PortRemote: 1, PortLocal: 1}}, nil)
+	if _, err := store.ImportConfigDump(bad, ImportMerge, false); err == nil {
+		t.Error("an invalid forward must fail the import")
+	}
+}
+
+func TestImportConfigDumpSettings(t *testing.T) {
+	store := newTestStore(t)
+	for key, value := range map[string]string{SettingTheme: ThemeMono, SettingKubectlRetries: "2"} {
+		if err := store.SetSetting(key, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dump := NewConfigDump(nil, nil)
+	dump.Settings = DumpSettings(map[string]string{SettingTheme: ThemeDefault, SettingKubectlRetries: "2", SettingKubectlTimeout: "45s", "no.such.key": "x"})
+	if _, ok := dump.Settings["no.such.key"]; ok {
+		t.Fatal("DumpSettings must leave out keys kprtfwd does not know")
+	}
+
+	result, err := store.ImportConfigDump(dump, ImportMerge, false)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if !slices.Equal(result.SettingsAdded, []string{SettingKubectlTimeout}) || !slices.Equal(result.SettingsKept, []string{SettingTheme}) {
+		t.Fatalf("merge result = %+v", result)
+	}
+	stored := store.GetStoredSettings()
+	if stored[SettingKubectlTimeout] != "45s" || stored[SettingTheme] != ThemeMono {
+		t.Errorf("after merge the settings are %v", stored)
+	}
+
+	if _, err := store.ImportConfigDump(dump, ImportOverwrite, false); err != nil {
+		t.Fatalf("overwrite: %v", err)
+	}
+	if theme := store.GetStoredSettings()[SettingTheme]; theme != ThemeDefault {
+		t.Errorf("overwrite must take the export's theme, got %q", theme)
+	}
+
+	dump.Settings[SettingKubectlRetries] = "-1"
+	if _, err := store.ImportConfigDump(dump, ImportOverwrite, false); err == nil {
+		t.Error("an invalid setting must fail the import")
+	}
+
+	yaml := DumpYAML(dump, nil)
+	if !strings.Contains(yaml, "settings:\n") || !strings.Contains(yaml, `  "ui.theme": "default"`) {
+		t.Errorf("DumpYAML lacks the settings:\n%s", yaml)
+	}
+}
+
+func TestImportConfigDumpCommands(t *testing.T) {
+	store := newTestStore(t)
+	hooked := PortForwardConfig{ID: "a", Namespace: "ns", Service: "svc", PortRemote: 80, PortLocal: 8080, StartHook: "make seed"}
+	if err := store.Add(hooked); err != nil {
+		t.Fatal(err)
+	}
+
+	changed := hooked
+	changed.StopHook = "make clean"
+	added := PortForwardConfig{ID: "b", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8081, OpenCommand: "firefox {url}", KubectlArgs: "--v=6"}
+	plain := PortForwardConfig{ID: "c", Namespace: "ns", Service: "db", PortRemote: 5432, PortLocal: 5432}
+	dump := NewConfigDump([]PortForwardConfig{changed, added, plain}, nil)
+
+	plan, err := store.ImportConfigDump(dump, ImportOverwrite, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportedCommand{
+		{ID: "a", Field: "stop_hook", Value: "make clean"},
+		{ID: "b", Field: "kubectl_args", Value: "--v=6"},
+		{ID: "b", Field: "open_command", Value: "firefox {url}"},
+	}
+	if !slices.Equal(plan.Commands, want) {
+		t.Errorf("overwrite commands = %+v, want the new and changed ones %+v", plan.Commands, want)
+	}
+
+	plan, err = store.ImportConfigDump(dump, ImportMerge, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan.Commands) != 2 || plan.Commands[0].ID != "b" || plan.Commands[1].ID != "b" {
+		t.Errorf("merge commands = %+v, want only the added forward's", plan.Commands)
+	}
+}
+
+func TestImportConfigDumpLocalSettings(t *testing.T) {
+	local := map[string]string{SettingKubectlPath: "/tmp/kubectl", SettingDigestCommand: "sh -c x", SettingTheme: ThemeMono}
+	if got := DumpSettings(local); len(got) != 1 || got[SettingTheme] != ThemeMono {
+		t.Fatalf("DumpSettings = %v, want the paths and commands of this machine left out", got)
+	}
+
+	store := newTestStore(t)
+	dump := NewConfigDump(nil, nil)
+	dump.Settings = local
+	plan, err := store.ImportConfigDump(dump, ImportMerge, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ImportedCommand{{Field: SettingDigestCommand, Value: "sh -c x"}, {Field: SettingKubectlPath, Value: "/tmp/kubectl"}}
+	if !slices.Equal(plan.Commands, want) {
+		t.Errorf("commands = %+v, want the local settings %+v", plan.Commands, want)
+	}
+}
@@ -0,0 +1,496 @@
+package config
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// EnvDBKey holds the database key as 64 hex digits, for machines without an
+// OS credential store (CI, containers). When set, the credential store is not
+// used at all.
+const EnvDBKey = "KPRTFWD_DB_KEY"
+
+// An encrypted database lives next to the plain path with this suffix. It
+// holds the database as SQL statements (see dumpSQL); while kprtfwd runs they
+// are replayed into an in-memory database, and every change is written back
+// encrypted.
+const encryptedSuffix = ".enc"
+
+// encryptedMagic starts every encrypted database file; the version byte allows
+// changing the format later.
+var encryptedMagic = []byte("KPRTFWD\x01")
+
+// Credential store entries holding the keys, one account per workspace (see
+// databaseKeyAccount)
+const (
+	keychainService = "kprtfwd"
+	keychainDBKey   = "database-key"
+)
+
+// ErrNotEncrypted and ErrAlreadyEncrypted are returned by DecryptDatabase and
+// EncryptDatabase when there is nothing to do.
+var (
+	ErrNotEncrypted     = errors.New("the database is not encrypted")
+	ErrAlreadyEncrypted = errors.New("the database is already encrypted")
+)
+
+// DatabasePath returns the plain database path: KPRTFWD_DB, else the current
+// workspace's (~/.kprtfwd/kprtfwd.db for the default workspace).
+func DatabasePath() (string, error) {
+	if dbPath := os.Getenv(EnvDB); dbPath != "" {
+		return dbPath, nil
+	}
+	return workspacePath(CurrentWorkspace())
+}
+
+// databaseKeyAccount is the credential store account of the current
+// workspace's key; each workspace is encrypted with its own
+func databaseKeyAccount() string {
+	if workspace := CurrentWorkspace(); workspace != DefaultWorkspace && os.Getenv(EnvDB) == "" {
+		return keychainDBKey + "." + workspace
+	}
+	return keychainDBKey
+}
+
+// DatabaseEncrypted reports whether the database at DatabasePath is stored
+// encrypted.
+func DatabaseEncrypted() (bool, error) {
+	dbPath, err := DatabasePath()
+	if err != nil {
+		return false, err
+	}
+	_, err = os.Stat(dbPath + encryptedSuffix)
+	return err == nil, nil
+}
+
+// EncryptDatabase encrypts the plain database with a new random key, stores
+// the key in the OS credential store (unless KPRTFWD_DB_KEY provides one) and
+// removes the plain file. No kprtfwd may have the database open meanwhile.
+func EncryptDatabase() error {
+	dbPath, err := DatabasePath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dbPath + encryptedSuffix); err == nil {
+		return ErrAlreadyEncrypted
+	}
+
+	// Opening the store first creates the schema for a database never
+	// opened before
+	store, err := NewSQLiteConfigStore()
+	if err != nil {
+		return err
+	}
+	plain, err := dumpSQL(store.db)
+	store.Close()
+	if err != nil {
+		return err
+	}
+
+	key, fromEnv, err := envKey()
+	if err != nil {
+		return err
+	}
+	if !fromEnv {
+		key = make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return fmt.Errorf("failed to generate a key: %w", err)
+		}
+		if err := keychainStore(databaseKeyAccount(), hex.EncodeToString(key)); err != nil {
+			return fmt.Errorf("failed to store the key in the credential store (or set %s): %w", EnvDBKey, err)
+		}
+	}
+	if err := writeEncrypted(dbPath+encryptedSuffix, key, plain); err != nil {
+		return err
+	}
+	for _, path := range []string{dbPath, dbPath + "-journal", dbPath + "-wal", dbPath + "-shm"} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("encrypted, but failed to remove %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+// DecryptDatabase turns the encrypted database back into a plain file and
+// removes the key from the OS credential store.
+func DecryptDatabase() error {
+	dbPath, err := DatabasePath()
+	if err != nil {
+		return err
+	}
+	encPath := dbPath + encryptedSuffix
+	data, err := os.ReadFile(encPath)
+	if os.IsNotExist(err) {
+		return ErrNotEncrypted
+	} else if err != nil {
+		return err
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return err
+	}
+	plain, err := decrypt(key, data)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(dbPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	db, err := sql.Open("sqlite", dbPath)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	_, err = db.Exec(string(plain))
+	db.Close()
+	if err != nil {
+		os.Remove(dbPath)
+		return fmt.Errorf("failed to restore the decrypted database: %w", err)
+	}
+	if err := os.Chmod(dbPath, 0600); err != nil {
+		return err
+	}
+	if err := os.Remove(encPath); err != nil {
+		return fmt.Errorf("decrypted, but failed to remove %s: %w", encPath, err)
+	}
+	if _, fromEnv, _ := envKey(); !fromEnv {
+		if err := keychainDelete(databaseKeyAccount()); err != nil {
+			return fmt.Errorf("decrypted, but failed to remove the key from the credential store: %w", err)
+		}
+	}
+	return nil
+}
+
+// openEncrypted decrypts the database at encPath into a private in-memory
+// SQLite database.
+func openEncrypted(encPath string) (*sql.DB, []byte, error) {
+	data, err := os.ReadFile(encPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read encrypted database: %w", err)
+	}
+	key, err := databaseKey()
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := openDecrypted(key, data)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db, key, nil
+}
+
+// openDecrypted decrypts data, as read from an encrypted database, into a
+// private in-memory SQLite database
+func openDecrypted(key, data []byte) (*sql.DB, error) {
+	plain, err := decrypt(key, data)
+	if err != nil {
+		return nil, err
+	}
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// Every connection to ":memory:" is a database of its own: keep one
+	db.SetMaxOpenConns(1)
+	db.SetMaxIdleConns(1)
+	if _, err := db.Exec(string(plain)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to load decrypted database: %w", err)
+	}
+	return db, nil
+}
+
+// dumpSQL returns the statements that recreate db: its tables, their rows and
+// indexes, and the AUTOINCREMENT counters.
+func dumpSQL(db *sql.DB) ([]byte, error) {
+	rows, err := db.Query(`SELECT type, name, sql FROM sqlite_master
+		WHERE sql IS NOT NULL AND name NOT LIKE 'sqlite_%' ORDER BY type != 'table', rowid`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	type object struct{ kind, name, sql string }
+	var objects []object
+	for rows.Next() {
+		var o object
+		if err := rows.Scan(&o.kind, &o.name, &o.sql); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+		objects = append(objects, o)
+	}
+	rows.Close()
+
+	var b bytes.Buffer
+	b.WriteString("BEGIN;\n")
+	for _, o := range objects {
+		b.WriteString(o.sql + ";\n")
+		if o.kind != "table" {
+			continue
+		}
+		if err := dumpRows(db, &b, o.name); err != nil {
+			return nil, err
+		}
+	}
+	var hasSequence bool
+	if err := db.QueryRow("SELECT count(*) > 0 FROM sqlite_master WHERE name = 'sqlite_sequence'").Scan(&hasSequence); err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	if hasSequence {
+		b.WriteString("DELETE FROM sqlite_sequence;\n")
+		if err := dumpRows(db, &b, "sqlite_sequence"); err != nil {
+			return nil, err
+		}
+	}
+	b.WriteString("COMMIT;\n")
+	return b.Bytes(), nil
+}
+
+// dumpRows writes an INSERT for every row of table, with SQLite quoting each
+// value
+func dumpRows(db *sql.DB, b *bytes.Buffer, table string) error {
+	quotedTable := `"` + strings.ReplaceAll(table, `"`, `""`) + `"`
+	cols, err := db.Query("SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return fmt.Errorf("failed to read columns of %s: %w", table, err)
+	}
+	var quoted []string
+	for cols.Next() {
+		var name string
+		if err := cols.Scan(&name); err != nil {
+			cols.Close()
+			return fmt.Errorf("failed to read columns of %s: %w", table, err)
+		}
+		quoted = append(quoted, `quote("`+strings.ReplaceAll(name, `"`, `""`)+`")`)
+	}
+	cols.Close()
+	if len(quoted) == 0 {
+		return nil
+	}
+
+	rows, err := db.Query("SELECT " + strings.Join(quoted, " || ',' || ") + " FROM " + quotedTable)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", table, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var values string
+		if err := rows.Scan(&values); err != nil {
+			return fmt.Errorf("failed to read %s: %w", table, err)
+		}
+		fmt.Fprintf(b, "INSERT INTO %s VALUES(%s);\n", quotedTable, values)
+	}
+	return rows.Err()
+}
+
+// persist writes an encrypted store back to disk after a change. Plain stores
+// are written by SQLite itself. The caller holds the write lock.
+//
+// The file is only replaced if no other kprtfwd wrote it since this store
+// read it; otherwise the store re-reads it, dropping the change, and returns
+// ErrChangedElsewhere for the caller to try again on the current data.
+func (cs *SQLiteConfigStore) persist() error {
+	if cs.encKey == nil {
+		return nil
+	}
+	encPath := cs.dbPath + encryptedSuffix
+	if fileStamp(encPath) != cs.seenStamp {
+		if err := cs.reloadLocked(); err != nil {
+			return err
+		}
+		return ErrChangedElsewhere
+	}
+	plain, err := dumpSQL(cs.db)
+	if err != nil {
+		return err
+	}
+	if err := writeEncrypted(encPath, cs.encKey, plain); err != nil {
+		return err
+	}
+	cs.seenStamp = fileStamp(encPath)
+	return nil
+}
+
+// reloadLocked replaces the in-memory copy of an encrypted database with the
+// file's current content. The caller holds the write lock.
+func (cs *SQLiteConfigStore) reloadLocked() error {
+	encPath := cs.dbPath + encryptedSuffix
+	stamp := fileStamp(encPath)
+	data, err := os.ReadFile(encPath)
+	if err != nil {
+		return fmt.Errorf("failed to read encrypted database: %w", err)
+	}
+	db, err := openDecrypted(cs.encKey, data)
+	if err != nil {
+		return err
+	}
+	cs.db.Close()
+	cs.db = db
+	cs.seenStamp = stamp
+	logging.LogDebug("Reloaded the encrypted database written by another kprtfwd")
+	return nil
+}
+
+// databaseKey returns the key from KPRTFWD_DB_KEY or the credential store
+func databaseKey() ([]byte, error) {
+	key, fromEnv, err := envKey()
+	if err != nil || fromEnv {
+		return key, err
+	}
+	stored, err := keychainLoad(databaseKeyAccount())
+	if err != nil {
+		return nil, fmt.Errorf("the database is encrypted and its key could not be read from the credential store (or set %s): %w", EnvDBKey, err)
+	}
+	key, err = hex.DecodeString(stored)
+	if err != nil || len(key) != 32 {
+		return nil, errors.New("the key in the credential store is not 64 hex digits")
+	}
+	return key, nil
+}
+
+// envKey returns the key in KPRTFWD_DB_KEY, if set
+func envKey() ([]byte, bool, error) {
+	value := os.Getenv(EnvDBKey)
+	if value == "" {
+		return nil, false, nil
+	}
+	key, err := hex.DecodeString(value)
+	if err != nil || len(key) != 32 {
+		return nil, true, fmt.Errorf("%s must be 64 hex digits (a 256-bit key)", EnvDBKey)
+	}
+	return key, true, nil
+}
+
+// writeEncrypted seals plain with AES-256-GCM and writes it to path
+func writeEncrypted(path string, key, plain []byte) error {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate a nonce: %w", err)
+	}
+	data := append(append(bytes.Clone(encryptedMagic), nonce...), gcm.Seal(nil, nonce, plain, encryptedMagic)...)
+	return writeFileAtomic(path, data)
+}
+
+// decrypt opens data written by writeEncrypted
+func decrypt(key, data []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if !bytes.HasPrefix(data, encryptedMagic) || len(data) < len(encryptedMagic)+gcm.NonceSize() {
+		return nil, errors.New("the encrypted database is not in a format this kprtfwd understands")
+	}
+	data = data[len(encryptedMagic):]
+	plain, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], encryptedMagic)
+	if err != nil {
+		return nil, errors.New("failed to decrypt the database: wrong key or damaged file")
+	}
+	return plain, nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic replaces path with data, readable by the owner only, so a
+// crash never leaves a half-written database behind.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := os.Chmod(tmp.Name(), 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// keychainLoad, keychainStore and keychainDelete keep the secret of account in
+// the OS credential store: the login keychain on macOS, the Secret Service
+// (GNOME Keyring, KWallet) through secret-tool on Linux.
+func keychainLoad(account string) (string, error) {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "find-generic-password", "-s", keychainService, "-a", account, "-w")
+	case "linux":
+		cmd = exec.Command("secret-tool", "lookup", "service", keychainService, "account", account)
+	default:
+		return "", fmt.Errorf("no supported credential store on %s", runtime.GOOS)
+	}
+	out, err := runCredentialTool(cmd)
+	return strings.TrimSpace(out), err
+}
+
+func keychainStore(account, secret string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// -w last and without a value makes security prompt for the secret,
+		// twice, so it never shows in the process list
+		cmd = exec.Command("security", "add-generic-password", "-U", "-s", keychainService, "-a", account, "-w")
+		cmd.Stdin = strings.NewReader(secret + "\n" + secret + "\n")
+		withoutTerminal(cmd)
+	case "linux":
+		cmd = exec.Command("secret-tool", "store", "--label", "kprtfwd database key", "service", keychainService, "account", account)
+		cmd.Stdin = strings.NewReader(secret)
+	default:
+		return fmt.Errorf("no supported credential store on %s", runtime.GOOS)
+	}
+	_, err := runCredentialTool(cmd)
+	return err
+}
+
+func keychainDelete(account string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("security", "delete-generic-password", "-s", keychainService, "-a", account)
+	case "linux":
+		cmd = exec.Command("secret-tool", "clear", "service", keychainService, "account", account)
+	default:
+		return fmt.Errorf("no supported credential store on %s", runtime.GOOS)
+	}
+	_, err := runCredentialTool(cmd)
+	return err
+}
+
+func runCredentialTool(cmd *exec.Cmd) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w (%s)", filepath.Base(cmd.Path), err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", filepath.Base(cmd.Path), err)
+	}
+	return stdout.String(), nil
+}
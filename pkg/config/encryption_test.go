@@ -0,0 +1,83 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestEncryptDatabaseRoundTrip(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvDBKey, strings.Repeat("ab", 32))
+	dbPath := filepath.Join(home, ".kprtfwd", "kprtfwd.db")
+
+	store, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := PortForwardConfig{ID: "prod.payments.web", Context: "prod", Namespace: "payments", Service: "web", PortRemote: 80, PortLocal: 8080}
+	if err := store.Add(web); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	if err := EncryptDatabase(); err != nil {
+		t.Fatalf("EncryptDatabase: %v", err)
+	}
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Fatalf("the plain database should be gone, stat = %v", err)
+	}
+	data, err := os.ReadFile(dbPath + encryptedSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("payments")) {
+		t.Fatal("the encrypted database contains a namespace in clear text")
+	}
+	if err := EncryptDatabase(); !errors.Is(err, ErrAlreadyEncrypted) {
+		t.Fatalf("encrypting twice = %v, want ErrAlreadyEncrypted", err)
+	}
+
+	// Changes to an encrypted store are written back encrypted
+	store, err = NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatalf("opening the encrypted database: %v", err)
+	}
+	if _, ok := store.GetConfigByID(web.ID); !ok {
+		t.Fatal("the forward did not survive encryption")
+	}
+	api := PortForwardConfig{ID: "prod.payments.api", Context: "prod", Namespace: "payments", Service: "api", PortRemote: 80, PortLocal: 8081}
+	if err := store.Add(api); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+	if _, err := os.Stat(dbPath); !os.IsNotExist(err) {
+		t.Fatal("an encrypted store must not write a plain database")
+	}
+
+	// The wrong key is refused
+	t.Setenv(EnvDBKey, strings.Repeat("cd", 32))
+	if _, err := NewSQLiteConfigStore(); err == nil || !strings.Contains(err.Error(), "wrong key") {
+		t.Fatalf("opening with the wrong key = %v", err)
+	}
+	t.Setenv(EnvDBKey, strings.Repeat("ab", 32))
+
+	if err := DecryptDatabase(); err != nil {
+		t.Fatalf("DecryptDatabase: %v", err)
+	}
+	if err := DecryptDatabase(); !errors.Is(err, ErrNotEncrypted) {
+		t.Fatalf("decrypting twice = %v, want ErrNotEncrypted", err)
+	}
+	store, err = NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if got := store.Len(); got != 2 {
+		t.Fatalf("decrypted database has %d forwards, want 2", got)
+	}
+}
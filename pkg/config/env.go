@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// EnvDB points kprtfwd at another database file than ~/.kprtfwd/kprtfwd.db.
+// It has no setting counterpart: settings live in the database.
+const EnvDB = "KPRTFWD_DB"
+
+// envPrefix starts the environment variable of every setting.
+const envPrefix = "KPRTFWD_"
+
+// SettingEnvVar returns the environment variable that overrides the setting
+// key: the spec's own name if it has one, else KPRTFWD_ and the key in upper
+// case with '.' and '-' turned into '_' (kubectl.retries is
+// KPRTFWD_KUBECTL_RETRIES). Per-context and per-command settings have none
+// and return "".
+func SettingEnvVar(key string) string {
+	for _, spec := range settingSpecs {
+		if spec.Key != key || strings.Contains(spec.Key, "<") {
+			continue
+		}
+		if spec.Env != "" {
+			return spec.Env
+		}
+		return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+	}
+	return ""
+}
+
+// EnvOverrides returns the settings set through environment variables, keyed
+// by setting key. They take precedence over stored settings. Invalid values
+// are logged and ignored, like invalid stored ones.
+func EnvOverrides() map[string]string {
+	overrides := make(map[string]string)
+	for _, spec := range settingSpecs {
+		name := SettingEnvVar(spec.Key)
+		if name == "" {
+			continue
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok || value == "" {
+			continue
+		}
+		if err := ValidateSetting(spec.Key, value); err != nil {
+			logging.LogError("Ignoring %s: %v", name, err)
+			continue
+		}
+		overrides[spec.Key] = value
+	}
+	return overrides
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettingEnvVar(t *testing.T) {
+	tests := map[string]string{
+		SettingKubectlRetries:               "KPRTFWD_KUBECTL_RETRIES",
+		SettingStopDrainTimeout:             "KPRTFWD_STOP_DRAIN_TIMEOUT",
+		SettingLogLevel:                     "KPRTFWD_LOG_LEVEL",
+		SettingKubectlPath:                  "KPRTFWD_KUBECTL",
+		SettingTheme:                        "KPRTFWD_THEME",
+		SettingLimitStartingPrefix + "prod": "", // per-context settings have none
+		"no.such.setting":                   "",
+	}
+	for key, want := range tests {
+		if got := SettingEnvVar(key); got != want {
+			t.Errorf("SettingEnvVar(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestEnvOverridesStoredSettings(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.SetSetting(SettingKubectlRetries, "3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.SetSetting(SettingStopDrainTimeout, "10s"); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KPRTFWD_KUBECTL_RETRIES", "0")
+	t.Setenv("KPRTFWD_STOP_DRAIN_TIMEOUT", "soon") // invalid: the stored value stays
+	t.Setenv("KPRTFWD_THEME", "mono")
+
+	settings := store.GetSettings()
+	if settings[SettingKubectlRetries] != "0" || settings[SettingStopDrainTimeout] != "10s" || settings[SettingTheme] != "mono" {
+		t.Fatalf("effective settings = %v", settings)
+	}
+	if value, ok := store.GetSetting(SettingKubectlRetries); !ok || value != "0" {
+		t.Fatalf("GetSetting = %q, %v; want the environment's 0", value, ok)
+	}
+	if stored := store.GetStoredSettings(); stored[SettingKubectlRetries] != "3" || len(stored) != 2 {
+		t.Fatalf("stored settings = %v", stored)
+	}
+}
+
+func TestEnvDB(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dbPath := filepath.Join(t.TempDir(), "ci", "kprtfwd.db")
+	t.Setenv(EnvDB, dbPath)
+
+	store, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatalf("NewSQLiteConfigStore: %v", err)
+	}
+	defer store.Close()
+	if _, err := os.Stat(dbPath); err != nil {
+		t.Fatalf("database not created at %s: %v", dbPath, err)
+	}
+}
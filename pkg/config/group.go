@@ -0,0 +1,231 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Group expression fields, the part before ':' in a term.
+const (
+	GroupFieldTag       = "tag"
+	GroupFieldContext   = "context"
+	GroupFieldNamespace = "namespace"
+	GroupFieldService   = "service"
+	GroupFieldID        = "id"
+)
+
+// groupFields lists the fields a term may test, in the order errors name them.
+var groupFields = []string{GroupFieldTag, GroupFieldContext, GroupFieldNamespace, GroupFieldService, GroupFieldID}
+
+// GroupExpr selects forwards for a user-defined group. Its text is made of
+// field:pattern terms, e.g. "tag:db OR namespace:payments", combined with
+// AND, OR, NOT and parentheses; AND binds tighter than OR, and terms next to
+// each other are ANDed. Patterns may use the * and ? wildcards; a tag: term
+// matches if any of the forward's tags does.
+type GroupExpr struct {
+	text  string
+	match func(cfg PortForwardConfig) bool
+}
+
+// ParseGroupExpr parses the text of a group expression.
+func ParseGroupExpr(text string) (*GroupExpr, error) {
+	p := &groupParser{tokens: tokenizeGroupExpr(text)}
+	if len(p.tokens) == 0 {
+		return nil, fmt.Errorf("group expression is empty")
+	}
+	match, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok, ok := p.peek(); ok {
+		return nil, fmt.Errorf("unexpected %q in group expression", tok)
+	}
+	return &GroupExpr{text: text, match: match}, nil
+}
+
+// String returns the expression's text as it was parsed.
+func (e *GroupExpr) String() string {
+	return e.text
+}
+
+// Match reports whether cfg belongs to the group.
+func (e *GroupExpr) Match(cfg PortForwardConfig) bool {
+	return e.match(cfg)
+}
+
+// CustomGroup is a user-defined group of forwards, from a ui.group.<name>
+// setting.
+type CustomGroup struct {
+	Name string
+	Expr *GroupExpr
+}
+
+// CustomGroups returns the groups defined in settings, sorted by name.
+// Settings whose expression does not parse are skipped; writes validate them,
+// so only a hand-edited database has any.
+func CustomGroups(settings map[string]string) []CustomGroup {
+	var groups []CustomGroup
+	for key, value := range settings {
+		name, ok := strings.CutPrefix(key, SettingGroupPrefix)
+		if !ok || name == "" {
+			continue
+		}
+		if expr, err := ParseGroupExpr(value); err == nil {
+			groups = append(groups, CustomGroup{Name: name, Expr: expr})
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Name < groups[j].Name })
+	return groups
+}
+
+func validateGroupExpr(value string) error {
+	_, err := ParseGroupExpr(value)
+	return err
+}
+
+// tokenizeGroupExpr splits text into parentheses and whitespace-separated words
+func tokenizeGroupExpr(text string) []string {
+	var tokens []string
+	var word strings.Builder
+	flush := func() {
+		if word.Len() > 0 {
+			tokens = append(tokens, word.String())
+			word.Reset()
+		}
+	}
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')':
+			flush()
+			tokens = append(tokens, string(r))
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			word.WriteRune(r)
+		}
+	}
+	flush()
+	return tokens
+}
+
+// groupParser is a recursive descent parser over the tokens of a group
+// expression:
+//
+//	or   = and { "OR" and }
+//	and  = not { ["AND"] not }
+//	not  = "NOT" not | "(" or ")" | field ":" pattern
+type groupParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *groupParser) peek() (string, bool) {
+	if p.pos >= len(p.tokens) {
+		return "", false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is the keyword kw, in any case
+func (p *groupParser) accept(kw string) bool {
+	if tok, ok := p.peek(); ok && strings.EqualFold(tok, kw) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *groupParser) parseOr() (func(PortForwardConfig) bool, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(cfg PortForwardConfig) bool { return l(cfg) || right(cfg) }
+	}
+	return left, nil
+}
+
+func (p *groupParser) parseAnd() (func(PortForwardConfig) bool, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if !p.accept("AND") {
+			// Adjacent terms are ANDed; OR and ')' end the conjunction
+			tok, ok := p.peek()
+			if !ok || tok == ")" || strings.EqualFold(tok, "OR") {
+				return left, nil
+			}
+		}
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		l := left
+		left = func(cfg PortForwardConfig) bool { return l(cfg) && right(cfg) }
+	}
+}
+
+func (p *groupParser) parseNot() (func(PortForwardConfig) bool, error) {
+	if p.accept("NOT") {
+		inner, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return func(cfg PortForwardConfig) bool { return !inner(cfg) }, nil
+	}
+	if p.accept("(") {
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing ) in group expression")
+		}
+		return inner, nil
+	}
+	tok, ok := p.peek()
+	if !ok {
+		return nil, fmt.Errorf("group expression ends early")
+	}
+	p.pos++
+	return parseGroupTerm(tok)
+}
+
+// parseGroupTerm parses one field:pattern term
+func parseGroupTerm(tok string) (func(PortForwardConfig) bool, error) {
+	field, pattern, ok := strings.Cut(tok, ":")
+	if !ok || pattern == "" {
+		return nil, fmt.Errorf("%q is not a field:pattern term (fields: %s)", tok, strings.Join(groupFields, ", "))
+	}
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, fmt.Errorf("bad pattern in %q: %w", tok, err)
+	}
+	matches := func(value string) bool {
+		ok, _ := path.Match(pattern, value)
+		return ok
+	}
+	switch strings.ToLower(field) {
+	case GroupFieldTag:
+		return func(cfg PortForwardConfig) bool { return slices.ContainsFunc(cfg.TagList(), matches) }, nil
+	case GroupFieldContext:
+		return func(cfg PortForwardConfig) bool { return matches(cfg.Context) }, nil
+	case GroupFieldNamespace:
+		return func(cfg PortForwardConfig) bool { return matches(cfg.Namespace) }, nil
+	case GroupFieldService:
+		return func(cfg PortForwardConfig) bool { return matches(cfg.Service) }, nil
+	case GroupFieldID:
+		return func(cfg PortForwardConfig) bool { return matches(cfg.ID) }, nil
+	}
+	return nil, fmt.Errorf("unknown field %q in %q (fields: %s)", field, tok, strings.Join(groupFields, ", "))
+}
@@ -0,0 +1,52 @@
+package config
+
+import "testing"
+
+func TestGroupExpr(t *testing.T) {
+	pg := PortForwardConfig{ID: "prod.data.pg", Context: "prod", Namespace: "data", Service: "pg", Tags: "db critical"}
+	web := PortForwardConfig{ID: "prod.payments.web", Context: "prod", Namespace: "payments", Service: "web"}
+	cache := PortForwardConfig{ID: "stg.payments.redis", Context: "stg", Namespace: "payments", Service: "redis", Tags: "db"}
+
+	tests := []struct {
+		expr string
+		want []bool // pg, web, cache
+	}{
+		{"tag:db", []bool{true, false, true}},
+		{"tag:db OR namespace:payments", []bool{true, true, true}},
+		{"tag:db namespace:payments", []bool{false, false, true}},
+		{"tag:db and not context:stg", []bool{true, false, false}},
+		{"NOT (tag:db OR service:web)", []bool{false, false, false}},
+		{"context:prod AND (tag:crit* OR service:w?b)", []bool{true, true, false}},
+		{"id:*.payments.*", []bool{false, true, true}},
+	}
+	for _, tt := range tests {
+		expr, err := ParseGroupExpr(tt.expr)
+		if err != nil {
+			t.Errorf("ParseGroupExpr(%q): %v", tt.expr, err)
+			continue
+		}
+		for i, cfg := range []PortForwardConfig{pg, web, cache} {
+			if got := expr.Match(cfg); got != tt.want[i] {
+				t.Errorf("%q.Match(%s) = %v, want %v", tt.expr, cfg.ID, got, tt.want[i])
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "db", "label:db", "tag:db OR", "(tag:db", "tag:db )", "tag:[", "NOT"} {
+		if _, err := ParseGroupExpr(bad); err == nil {
+			t.Errorf("ParseGroupExpr(%q) should fail", bad)
+		}
+	}
+}
+
+func TestCustomGroups(t *testing.T) {
+	groups := CustomGroups(map[string]string{
+		SettingGroupPrefix + "web": "service:web",
+		SettingGroupPrefix + "db":  "tag:db",
+		SettingGroupPrefix + "bad": "nonsense",
+		SettingTheme:               ThemeMono,
+	})
+	if len(groups) != 2 || groups[0].Name != "db" || groups[1].Name != "web" || groups[0].Expr.String() != "tag:db" {
+		t.Fatalf("CustomGroups = %+v, want db and web sorted by name", groups)
+	}
+}
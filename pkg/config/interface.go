@@ -15,6 +15,19 @@ type ConfigStoreInterface interface {
 	GetWithError(index int) (PortForwardConfig, error)
 	GetConfigByID(id string) (PortForwardConfig, bool)
 	GetIndexByID(id string) (int, bool)
+	// UpdatePortForward replaces the forward id with cfg in place, so its
+	// project memberships and discovery snapshot stay attached, also when
+	// cfg renames it
+	UpdatePortForward(id string, cfg PortForwardConfig) error
+	// ModifyPortForward applies change to the forward id as stored now
+	ModifyPortForward(id string, change func(cfg *PortForwardConfig) error) error
+
+	// Discovery Snapshots
+	SetServiceSnapshot(id string, snap ServiceSnapshot) error
+	GetServiceSnapshot(id string) (ServiceSnapshot, bool)
+
+	// Start/stop history
+	RecordForwardEvents(events []ForwardEvent) error
 
 	// Project Operations
 	CreateProject(name string, portForwardIDs []string) error
@@ -22,6 +35,12 @@ type ConfigStoreInterface interface {
 	GetAllProjects() []Project
 	DeleteProject(name string) error
 
+	// Settings Operations
+	GetSetting(key string) (string, bool)
+	GetSettings() map[string]string
+	SetSetting(key, value string) error
+	UnsetSetting(key string) error
+
 	// Active Project Management (in-memory state)
 	SetActiveProject(name string) error
 	GetActiveProject() *Project
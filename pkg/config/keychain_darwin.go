@@ -0,0 +1,15 @@
+//go:build darwin
+
+package config
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// withoutTerminal starts cmd in a session of its own, with no controlling
+// terminal: security then reads a password it prompts for from stdin
+// instead of asking on /dev/tty
+func withoutTerminal(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
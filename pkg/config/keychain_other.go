@@ -0,0 +1,8 @@
+//go:build !darwin
+
+package config
+
+import "os/exec"
+
+// withoutTerminal is only needed for the macOS security tool
+func withoutTerminal(cmd *exec.Cmd) {}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint rules, the Rule of a LintFinding.
+const (
+	LintLocalPortClash = "local-port-clash" // two forwards share a local port
+	LintMissingForward = "missing-forward"  // a project lists an ID that no longer exists
+	LintIDConvention   = "id-convention"    // an ID does not follow the naming convention
+	LintNoProject      = "no-project"       // a forward belongs to no project
+	LintUnknownContext = "unknown-context"  // a forward's context is not in the kubeconfig
+)
+
+// Severities of a LintFinding. Errors break starting forwards or projects;
+// warnings are housekeeping.
+const (
+	LintError   = "error"
+	LintWarning = "warning"
+)
+
+// LintFinding is one problem Lint found.
+type LintFinding struct {
+	Rule     string `json:"rule"`
+	Severity string `json:"severity"`
+	ID       string `json:"id,omitempty"`      // forward concerned, if any
+	Project  string `json:"project,omitempty"` // project concerned, if any
+	Message  string `json:"message"`
+}
+
+// Lint checks cfgs and projects for configuration problems, errors first.
+// IDs are checked against idPattern, or, when it is nil, against the scheme
+// discovery generates: "<context>.<namespace>." followed by the rest, each
+// part sanitized as by SanitizeIDPart.
+func Lint(cfgs []PortForwardConfig, projects []Project, idPattern *regexp.Regexp) []LintFinding {
+	var findings []LintFinding
+
+	// Local port clashes, reported once per pair
+	for i, a := range cfgs {
+		for _, b := range cfgs[i+1:] {
+			if a.OverlapsLocally(b) {
+				findings = append(findings, LintFinding{
+					Rule: LintLocalPortClash, Severity: LintError, ID: b.ID,
+					Message: fmt.Sprintf("local port %d clashes with %s (local port %d); only one of them can run", b.PortLocal, a.ID, a.PortLocal),
+				})
+			}
+		}
+	}
+
+	ids := make(map[string]bool, len(cfgs))
+	for _, cfg := range cfgs {
+		ids[cfg.ID] = true
+	}
+	inProject := make(map[string]bool)
+	for _, project := range projects {
+		for _, id := range project.Forwards {
+			inProject[id] = true
+			if !ids[id] {
+				findings = append(findings, LintFinding{
+					Rule: LintMissingForward, Severity: LintError, ID: id, Project: project.Name,
+					Message: fmt.Sprintf("project %s lists %s, which does not exist", project.Name, id),
+				})
+			}
+		}
+	}
+
+	for _, cfg := range cfgs {
+		if idPattern != nil {
+			if !idPattern.MatchString(cfg.ID) {
+				findings = append(findings, LintFinding{
+					Rule: LintIDConvention, Severity: LintWarning, ID: cfg.ID,
+					Message: fmt.Sprintf("ID does not match %s", idPattern),
+				})
+			}
+		} else if prefix := SanitizeIDPart(cfg.Context) + "." + SanitizeIDPart(cfg.Namespace) + "."; !strings.HasPrefix(cfg.ID, prefix) {
+			findings = append(findings, LintFinding{
+				Rule: LintIDConvention, Severity: LintWarning, ID: cfg.ID,
+				Message: fmt.Sprintf("ID does not start with %s (its context and namespace)", prefix),
+			})
+		}
+		if !inProject[cfg.ID] && len(projects) > 0 {
+			findings = append(findings, LintFinding{
+				Rule: LintNoProject, Severity: LintWarning, ID: cfg.ID,
+				Message: "forward is in no project",
+			})
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Severity == LintError && findings[j].Severity != LintError
+	})
+	return findings
+}
+
+// LintContexts reports the forwards of cfgs whose context is not among
+// contexts, those the kubeconfig lists: they cannot start until it has them
+// again. Unlike Lint it needs kubectl, so kprtfwd lint leaves it out.
+func LintContexts(cfgs []PortForwardConfig, contexts []string) []LintFinding {
+	known := make(map[string]bool, len(contexts))
+	for _, context := range contexts {
+		known[context] = true
+	}
+	var findings []LintFinding
+	for _, cfg := range cfgs {
+		if cfg.Context != "" && !known[cfg.Context] {
+			findings = append(findings, LintFinding{
+				Rule: LintUnknownContext, Severity: LintWarning, ID: cfg.ID,
+				Message: fmt.Sprintf("context %s is not in the kubeconfig", cfg.Context),
+			})
+		}
+	}
+	return findings
+}
+
+// SanitizeIDPart turns a context, namespace or service name into one part of
+// a forward ID the way discovery does: letters and digits are kept, runs of
+// '-', '_' and '.' become a single '-', anything else is dropped.
+func SanitizeIDPart(input string) string {
+	var b strings.Builder
+	for _, char := range input {
+		if (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || (char >= '0' && char <= '9') {
+			b.WriteRune(char)
+		} else if char == '-' || char == '_' || char == '.' {
+			if b.Len() > 0 && !strings.HasSuffix(b.String(), "-") {
+				b.WriteByte('-')
+			}
+		}
+	}
+	result := strings.TrimRight(b.String(), "-")
+	if result == "" {
+		return "unknown"
+	}
+	return result
+}
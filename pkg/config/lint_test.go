@@ -0,0 +1,77 @@
+package config
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestLint(t *testing.T) {
+	cfgs := []PortForwardConfig{
+		{ID: "prod.payments.web.web", Context: "prod", Namespace: "payments", PortLocal: 8080},
+		{ID: "prod.payments.api.api", Context: "prod", Namespace: "payments", PortLocal: 8079, PortCount: 2},
+		{ID: "legacy-db", Context: "gke_acme_db", Namespace: "data", PortLocal: 5432},
+	}
+	projects := []Project{{Name: "payments", Forwards: []string{"prod.payments.web.web", "prod.payments.api.api", "prod.payments.gone"}}}
+
+	got := make(map[string]LintFinding)
+	for _, f := range Lint(cfgs, projects, nil) {
+		got[f.Rule+" "+f.ID] = f
+	}
+	want := map[string]string{
+		LintLocalPortClash + " prod.payments.api.api": LintError,
+		LintMissingForward + " prod.payments.gone":    LintError,
+		LintIDConvention + " legacy-db":               LintWarning,
+		LintNoProject + " legacy-db":                  LintWarning,
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d findings, want %d: %v", len(got), len(want), got)
+	}
+	for key, severity := range want {
+		if f, ok := got[key]; !ok || f.Severity != severity {
+			t.Errorf("missing %s finding %q (got %+v)", severity, key, f)
+		}
+	}
+
+	findings := Lint(cfgs, projects, regexp.MustCompile(`^[a-z-]+$`))
+	if findings[0].Severity != LintError {
+		t.Errorf("errors should come first, got %+v", findings[0])
+	}
+	var convention []string
+	for _, f := range findings {
+		if f.Rule == LintIDConvention {
+			convention = append(convention, f.ID)
+		}
+	}
+	if len(convention) != 2 || convention[0] != "prod.payments.web.web" || convention[1] != "prod.payments.api.api" {
+		t.Errorf("with a custom pattern, flagged %v", convention)
+	}
+
+	if findings := Lint(cfgs[:1], nil, nil); len(findings) != 0 {
+		t.Errorf("a forward needs no project while there are none, got %+v", findings)
+	}
+}
+
+func TestLintContexts(t *testing.T) {
+	cfgs := []PortForwardConfig{
+		{ID: "prod.payments.web.web", Context: "prod"},
+		{ID: "old.data.db.db", Context: "old"},
+		{ID: "pasted", Context: ""},
+	}
+	findings := LintContexts(cfgs, []string{"prod", "staging"})
+	if len(findings) != 1 || findings[0].ID != "old.data.db.db" || findings[0].Rule != LintUnknownContext {
+		t.Errorf("LintContexts = %+v, want only old.data.db.db", findings)
+	}
+}
+
+func TestSanitizeIDPart(t *testing.T) {
+	for input, want := range map[string]string{
+		"gke_acme_europe-west1": "gke-acme-europe-west1",
+		"arn:aws:eks/prod":      "arnawseksprod",
+		"a..b":                  "a-b",
+		"--":                    "unknown",
+	} {
+		if got := SanitizeIDPart(input); got != want {
+			t.Errorf("SanitizeIDPart(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Defaults when the kernel does not say otherwise: Linux picks outgoing
+// ports from 32768-60999, macOS and Windows from the IANA range 49152-65535.
+const (
+	linuxEphemeralFirst = 32768
+	linuxEphemeralLast  = 60999
+	ianaEphemeralFirst  = 49152
+	ianaEphemeralLast   = 65535
+	privilegedPortLimit = 1024
+)
+
+var (
+	portLimitsOnce        sync.Once
+	unprivilegedPortStart int // lowest port a normal user may bind; 0 if any
+	ephemeralFirst        int
+	ephemeralLast         int
+)
+
+// loadPortLimits works out the OS port limits once, reading the Linux
+// sysctls where they exist
+func loadPortLimits() {
+	portLimitsOnce.Do(func() {
+		switch runtime.GOOS {
+		case "linux":
+			unprivilegedPortStart = privilegedPortLimit
+			if values := readProcInts("/proc/sys/net/ipv4/ip_unprivileged_port_start"); len(values) == 1 {
+				unprivilegedPortStart = values[0]
+			}
+			ephemeralFirst, ephemeralLast = linuxEphemeralFirst, linuxEphemeralLast
+			if values := readProcInts("/proc/sys/net/ipv4/ip_local_port_range"); len(values) == 2 && values[0] <= values[1] {
+				ephemeralFirst, ephemeralLast = values[0], values[1]
+			}
+		case "darwin", "windows":
+			// Both let anyone bind low ports on the loopback interface
+			ephemeralFirst, ephemeralLast = ianaEphemeralFirst, ianaEphemeralLast
+		default:
+			unprivilegedPortStart = privilegedPortLimit
+			ephemeralFirst, ephemeralLast = ianaEphemeralFirst, ianaEphemeralLast
+		}
+		if os.Geteuid() == 0 {
+			unprivilegedPortStart = 0
+		}
+	})
+}
+
+// readProcInts reads the whitespace-separated numbers of a /proc file
+func readProcInts(path string) []int {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var values []int
+	for _, field := range strings.Fields(string(data)) {
+		n, err := strconv.Atoi(field)
+		if err != nil {
+			return nil
+		}
+		values = append(values, n)
+	}
+	return values
+}
+
+// LocalPortWarning returns why the local ports first..first+count-1 are a
+// risky choice, or "" if they are not: a privileged port cannot be bound
+// without root, and a port in the ephemeral range may be taken by any
+// outgoing connection, so the forward fails to start at random.
+func LocalPortWarning(first, count int) string {
+	loadPortLimits()
+	if count < 1 {
+		count = 1
+	}
+	last := first + count - 1
+	if first < unprivilegedPortStart {
+		return fmt.Sprintf("port %d is privileged (below %d) and needs root to bind", first, unprivilegedPortStart)
+	}
+	if first <= ephemeralLast && last >= ephemeralFirst {
+		port := max(first, ephemeralFirst)
+		return fmt.Sprintf("port %d is in the OS ephemeral range (%d-%d) and may be taken by outgoing connections", port, ephemeralFirst, ephemeralLast)
+	}
+	return ""
+}
@@ -0,0 +1,40 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLocalPortWarning(t *testing.T) {
+	loadPortLimits()
+	saved := []int{unprivilegedPortStart, ephemeralFirst, ephemeralLast}
+	t.Cleanup(func() { unprivilegedPortStart, ephemeralFirst, ephemeralLast = saved[0], saved[1], saved[2] })
+	unprivilegedPortStart, ephemeralFirst, ephemeralLast = 1024, 32768, 60999
+
+	tests := []struct {
+		first, count int
+		want         string // substring of the warning; "" for none
+	}{
+		{8080, 1, ""},
+		{1024, 1, ""},
+		{80, 1, "port 80 is privileged"},
+		{1000, 50, "port 1000 is privileged"},
+		{32768, 1, "port 32768 is in the OS ephemeral range (32768-60999)"},
+		{60999, 1, "ephemeral"},
+		{61000, 1, ""},
+		{32760, 10, "port 32768 is in the OS ephemeral range"},
+		{32760, 8, ""},
+	}
+	for _, tt := range tests {
+		got := LocalPortWarning(tt.first, tt.count)
+		if (tt.want == "") != (got == "") || !strings.Contains(got, tt.want) {
+			t.Errorf("LocalPortWarning(%d, %d) = %q, want %q", tt.first, tt.count, got, tt.want)
+		}
+	}
+
+	// Root, or a lowered ip_unprivileged_port_start, may bind anything
+	unprivilegedPortStart = 0
+	if got := LocalPortWarning(80, 1); got != "" {
+		t.Errorf("without a privileged range, port 80 got %q", got)
+	}
+}
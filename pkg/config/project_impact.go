@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ProjectLoss is what removing some forwards does to one project
+type ProjectLoss struct {
+	Project   string
+	Removed   []string // IDs of the removed forwards the project lists
+	Remaining int      // forwards the project still lists afterwards
+}
+
+// Emptied reports whether the project lists no forwards any more
+func (l ProjectLoss) Emptied() bool {
+	return l.Remaining == 0
+}
+
+// String describes the loss, e.g. "backend (2 of 5)" or "api (1 of 1, left
+// empty)"
+func (l ProjectLoss) String() string {
+	text := fmt.Sprintf("%s (%d of %d", l.Project, len(l.Removed), len(l.Removed)+l.Remaining)
+	if l.Emptied() {
+		text += ", left empty"
+	}
+	return text + ")"
+}
+
+// ProjectsByForward maps each forward ID to the names of the projects that
+// list it
+func ProjectsByForward(projects []Project) map[string][]string {
+	byForward := make(map[string][]string)
+	for _, project := range projects {
+		for _, id := range project.Forwards {
+			byForward[id] = append(byForward[id], project.Name)
+		}
+	}
+	return byForward
+}
+
+// ProjectLosses returns, ordered by project name, what removing the forwards
+// ids would do to each project that lists any of them. Projects that are
+// empty already are not affected.
+func ProjectLosses(projects []Project, ids []string) []ProjectLoss {
+	var losses []ProjectLoss
+	for _, project := range projects {
+		loss := ProjectLoss{Project: project.Name}
+		for _, id := range project.Forwards {
+			if slices.Contains(ids, id) {
+				loss.Removed = append(loss.Removed, id)
+			} else {
+				loss.Remaining++
+			}
+		}
+		if len(loss.Removed) > 0 {
+			losses = append(losses, loss)
+		}
+	}
+	sort.Slice(losses, func(i, j int) bool { return losses[i].Project < losses[j].Project })
+	return losses
+}
+
+// EmptiedProjects returns the names of the projects among losses left
+// without forwards
+func EmptiedProjects(losses []ProjectLoss) []string {
+	var names []string
+	for _, loss := range losses {
+		if loss.Emptied() {
+			names = append(names, loss.Project)
+		}
+	}
+	return names
+}
+
+// JoinProjectLosses lists losses for a message, e.g. "backend (2 of 5), api
+// (1 of 1, left empty)"
+func JoinProjectLosses(losses []ProjectLoss) string {
+	parts := make([]string, len(losses))
+	for i, loss := range losses {
+		parts[i] = loss.String()
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,28 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestProjectLosses(t *testing.T) {
+	projects := []Project{
+		{Name: "web", Forwards: []string{"ctx.ns.web", "ctx.ns.api"}},
+		{Name: "db", Forwards: []string{"ctx.ns.db"}},
+		{Name: "other", Forwards: []string{"ctx.ns.cache"}},
+		{Name: "empty"},
+	}
+	losses := ProjectLosses(projects, []string{"ctx.ns.db", "ctx.ns.api"})
+	if len(losses) != 2 || losses[0].Project != "db" || losses[1].Project != "web" {
+		t.Fatalf("losses = %+v, want db and web by name", losses)
+	}
+	if got := JoinProjectLosses(losses); got != "db (1 of 1, left empty), web (1 of 2)" {
+		t.Errorf("JoinProjectLosses = %q", got)
+	}
+	if got := EmptiedProjects(losses); !slices.Equal(got, []string{"db"}) {
+		t.Errorf("EmptiedProjects = %v, want [db]", got)
+	}
+	if losses := ProjectLosses(projects, nil); len(losses) != 0 {
+		t.Errorf("removing nothing affects no project, got %+v", losses)
+	}
+}
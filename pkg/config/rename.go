@@ -0,0 +1,100 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// IDRename changes one forward's ID.
+type IDRename struct {
+	From string
+	To   string
+}
+
+// idPlaceholders are the fields an IDTemplate can use
+var idPlaceholders = map[string]func(PortForwardConfig) string{
+	"context":    func(c PortForwardConfig) string { return SanitizeIDPart(c.Context) },
+	"namespace":  func(c PortForwardConfig) string { return SanitizeIDPart(c.Namespace) },
+	"service":    func(c PortForwardConfig) string { return SanitizeIDPart(c.Service) },
+	"port":       func(c PortForwardConfig) string { return strconv.Itoa(c.PortRemote) },
+	"local_port": func(c PortForwardConfig) string { return strconv.Itoa(c.PortLocal) },
+}
+
+var idPlaceholderPattern = regexp.MustCompile(`\{\{\s*([a-z_]*)\s*\}\}`)
+
+// IDTemplate builds forward IDs from their fields, e.g.
+// "{{context}}.{{namespace}}.{{service}}". Create one with ParseIDTemplate.
+type IDTemplate struct {
+	text string
+}
+
+// ParseIDTemplate checks that template only uses known placeholders:
+// {{context}}, {{namespace}} and {{service}} (sanitized as by SanitizeIDPart),
+// {{port}} (the remote port) and {{local_port}}.
+func ParseIDTemplate(template string) (IDTemplate, error) {
+	matches := idPlaceholderPattern.FindAllStringSubmatch(template, -1)
+	if len(matches) == 0 {
+		return IDTemplate{}, fmt.Errorf("template %q uses no placeholder such as {{service}}", template)
+	}
+	for _, m := range matches {
+		if _, ok := idPlaceholders[m[1]]; !ok {
+			return IDTemplate{}, fmt.Errorf("unknown placeholder %s (use {{context}}, {{namespace}}, {{service}}, {{port}} or {{local_port}})", m[0])
+		}
+	}
+	if rest := idPlaceholderPattern.ReplaceAllString(template, ""); strings.ContainsAny(rest, "{} \t") {
+		return IDTemplate{}, fmt.Errorf("template %q has stray braces or spaces", template)
+	}
+	return IDTemplate{text: template}, nil
+}
+
+// String returns the template as given to ParseIDTemplate.
+func (t IDTemplate) String() string {
+	return t.text
+}
+
+// Render returns the ID the template gives cfg.
+func (t IDTemplate) Render(cfg PortForwardConfig) string {
+	return idPlaceholderPattern.ReplaceAllStringFunc(t.text, func(m string) string {
+		return idPlaceholders[idPlaceholderPattern.FindStringSubmatch(m)[1]](cfg)
+	})
+}
+
+// ProposeRenames returns the renames that give selected the IDs template
+// builds, skipping forwards whose ID already matches. Forwards the template
+// gives the same ID get their remote port appended ("-8080"); it is an error
+// if that still collides, with each other or with an ID in others, which keep
+// theirs.
+func ProposeRenames(selected, others []PortForwardConfig, template IDTemplate) ([]IDRename, error) {
+	proposed := make([]string, len(selected))
+	count := make(map[string]int)
+	for i, cfg := range selected {
+		proposed[i] = template.Render(cfg)
+		count[proposed[i]]++
+	}
+	for i, cfg := range selected {
+		if count[proposed[i]] > 1 {
+			proposed[i] += "-" + strconv.Itoa(cfg.PortRemote)
+		}
+	}
+
+	taken := make(map[string]string, len(others)+len(selected))
+	for _, cfg := range others {
+		taken[cfg.ID] = cfg.ID
+	}
+	var renames []IDRename
+	for i, cfg := range selected {
+		id := proposed[i]
+		if holder, ok := taken[id]; ok {
+			return nil, fmt.Errorf("%s and %s would both be named %s; add {{port}} or another placeholder to the template", holder, cfg.ID, id)
+		}
+		taken[id] = cfg.ID
+		if id != cfg.ID {
+			renames = append(renames, IDRename{From: cfg.ID, To: id})
+		}
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i].From < renames[j].From })
+	return renames, nil
+}
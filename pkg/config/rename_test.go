@@ -0,0 +1,44 @@
+package config
+
+import "testing"
+
+func TestIDTemplate(t *testing.T) {
+	tmpl, err := ParseIDTemplate("{{context}}.{{ namespace }}.{{service}}-{{port}}")
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := PortForwardConfig{Context: "gke_acme_prod", Namespace: "payments", Service: "api.v2", PortRemote: 443}
+	if got, want := tmpl.Render(cfg), "gke-acme-prod.payments.api-v2-443"; got != want {
+		t.Errorf("Render = %q, want %q", got, want)
+	}
+
+	for _, bad := range []string{"", "static-id", "{{pod}}.{{service}}", "{{service}} x", "{{service}"} {
+		if _, err := ParseIDTemplate(bad); err == nil {
+			t.Errorf("ParseIDTemplate(%q) should fail", bad)
+		}
+	}
+}
+
+func TestProposeRenames(t *testing.T) {
+	tmpl, _ := ParseIDTemplate("{{context}}.{{namespace}}.{{service}}")
+	http := PortForwardConfig{ID: "prod.payments.app.api", Context: "prod", Namespace: "payments", Service: "api", PortRemote: 80}
+	grpc := PortForwardConfig{ID: "prod.payments.app.api-grpc", Context: "prod", Namespace: "payments", Service: "api", PortRemote: 9090}
+	done := PortForwardConfig{ID: "prod.payments.web", Context: "prod", Namespace: "payments", Service: "web", PortRemote: 80}
+
+	got, err := ProposeRenames([]PortForwardConfig{http, grpc, done}, nil, tmpl)
+	if err != nil {
+		t.Fatalf("ProposeRenames: %v", err)
+	}
+	want := []IDRename{
+		{From: "prod.payments.app.api", To: "prod.payments.api-80"},
+		{From: "prod.payments.app.api-grpc", To: "prod.payments.api-9090"},
+	}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("ProposeRenames = %+v, want %+v", got, want)
+	}
+
+	other := PortForwardConfig{ID: "prod.payments.web"}
+	if _, err := ProposeRenames([]PortForwardConfig{http, {ID: "x", Context: "prod", Namespace: "payments", Service: "web"}}, []PortForwardConfig{other}, tmpl); err == nil {
+		t.Fatal("a proposal taking an unselected forward's ID must fail")
+	}
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// PortRewrite is a rule for moving many forwards' local ports at once, e.g.
+// out of a range that clashes with something else on the machine. Create one
+// with ParsePortRewrite.
+type PortRewrite struct {
+	offset int    // added to the port ("+10000", "-1000")
+	prefix string // digits put in front of the port ("prefix 1": 8080 → 18080)
+}
+
+// ParsePortRewrite parses a rewrite rule: "+N" or "-N" shifts ports by N,
+// "prefix D" puts the digits D in front of each port.
+func ParsePortRewrite(rule string) (PortRewrite, error) {
+	rule = strings.TrimSpace(rule)
+	if digits, ok := strings.CutPrefix(rule, "prefix"); ok {
+		digits = strings.TrimSpace(digits)
+		if _, err := strconv.ParseUint(digits, 10, 16); err != nil || strings.HasPrefix(digits, "0") {
+			return PortRewrite{}, fmt.Errorf("prefix %q must be digits not starting with 0", digits)
+		}
+		return PortRewrite{prefix: digits}, nil
+	}
+	if strings.HasPrefix(rule, "+") || strings.HasPrefix(rule, "-") {
+		offset, err := strconv.Atoi(rule)
+		if err != nil || offset == 0 {
+			return PortRewrite{}, fmt.Errorf("offset %q must be a non-zero number like +10000 or -1000", rule)
+		}
+		return PortRewrite{offset: offset}, nil
+	}
+	return PortRewrite{}, fmt.Errorf("unknown rewrite rule %q (use +N, -N or \"prefix D\")", rule)
+}
+
+// String returns the rule in the form ParsePortRewrite accepts.
+func (r PortRewrite) String() string {
+	if r.prefix != "" {
+		return "prefix " + r.prefix
+	}
+	return fmt.Sprintf("%+d", r.offset)
+}
+
+// Apply returns the rewritten port.
+func (r PortRewrite) Apply(port int) int {
+	if r.prefix != "" {
+		rewritten, err := strconv.Atoi(r.prefix + strconv.Itoa(port))
+		if err != nil {
+			return -1 // only on overflow; fails port validation
+		}
+		return rewritten
+	}
+	return port + r.offset
+}
+
+// RewriteLocalPorts applies rule to the local ports of selected and returns
+// the updated configs. A port range moves as a block: the rule decides where
+// its first port goes. Nothing is returned unless every rewritten forward is
+// valid and clashes neither with another rewritten forward nor with one of
+// others, the forwards left as they are.
+func RewriteLocalPorts(selected, others []PortForwardConfig, rule PortRewrite) ([]PortForwardConfig, error) {
+	rewritten := make([]PortForwardConfig, 0, len(selected))
+	for _, cfg := range selected {
+		updated := cfg
+		updated.PortLocal = rule.Apply(cfg.PortLocal)
+		if err := ValidatePortRange("local port", updated.PortLocal, updated.Ports()); err != nil {
+			return nil, fmt.Errorf("%s: %w", cfg.ID, err)
+		}
+		for _, other := range rewritten {
+			if updated.OverlapsLocally(other) {
+				return nil, fmt.Errorf("%s and %s would both use local port %d", other.ID, cfg.ID, max(updated.PortLocal, other.PortLocal))
+			}
+		}
+		rewritten = append(rewritten, updated)
+	}
+	for _, updated := range rewritten {
+		for _, other := range others {
+			if updated.OverlapsLocally(other) {
+				return nil, fmt.Errorf("%s would move onto local port %d, already used by %s", updated.ID, max(updated.PortLocal, other.PortLocal), other.ID)
+			}
+		}
+	}
+	return rewritten, nil
+}
+
+// NextFreeLocalPort returns the lowest local port above cfg's current one
+// where cfg's whole range fits without overlapping any of taken.
+func NextFreeLocalPort(cfg PortForwardConfig, taken []PortForwardConfig) (int, bool) {
+	candidate := cfg
+	for port := cfg.PortLocal + 1; port+cfg.Ports()-1 <= 65535; port++ {
+		candidate.PortLocal = port
+		free := true
+		for _, other := range taken {
+			if other.ID != cfg.ID && candidate.OverlapsLocally(other) {
+				free = false
+				break
+			}
+		}
+		if free {
+			return port, true
+		}
+	}
+	return 0, false
+}
+
+// DefaultLocalPort returns the local port a new forward of remotePort starts
+// with under strategy (see LocalPortStrategy). A random port is one the OS
+// reports free that none of taken uses; an offset that would run past 65535,
+// or a random pick that keeps failing, falls back to the remote port.
+func DefaultLocalPort(strategy string, remotePort int, taken []PortForwardConfig) int {
+	if strategy == LocalPortsRandom {
+		for range 10 {
+			l, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				break
+			}
+			port := l.Addr().(*net.TCPAddr).Port
+			l.Close()
+			candidate := PortForwardConfig{PortLocal: port}
+			used := false
+			for _, other := range taken {
+				if candidate.OverlapsLocally(other) {
+					used = true
+					break
+				}
+			}
+			if !used {
+				return port
+			}
+		}
+	}
+	if offset, ok := strings.CutPrefix(strategy, LocalPortsOffsetPrefix); ok {
+		if n, err := strconv.Atoi(offset); err == nil && remotePort+n <= 65535 {
+			return remotePort + n
+		}
+	}
+	return remotePort
+}
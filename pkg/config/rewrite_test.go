@@ -0,0 +1,93 @@
+package config
+
+import "testing"
+
+func TestParsePortRewrite(t *testing.T) {
+	tests := []struct {
+		rule string
+		port int
+		want int
+	}{
+		{"+10000", 8080, 18080},
+		{"-1000", 8080, 7080},
+		{"prefix 1", 8080, 18080},
+		{" prefix 2 ", 443, 2443},
+	}
+	for _, tt := range tests {
+		rule, err := ParsePortRewrite(tt.rule)
+		if err != nil {
+			t.Fatalf("ParsePortRewrite(%q): %v", tt.rule, err)
+		}
+		if got := rule.Apply(tt.port); got != tt.want {
+			t.Errorf("%q applied to %d = %d, want %d", tt.rule, tt.port, got, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "10000", "+0", "+x", "prefix", "prefix 0", "prefix a", "times 2"} {
+		if _, err := ParsePortRewrite(bad); err == nil {
+			t.Errorf("ParsePortRewrite(%q) should fail", bad)
+		}
+	}
+}
+
+func TestRewriteLocalPorts(t *testing.T) {
+	api := PortForwardConfig{ID: "api", PortLocal: 8080}
+	web := PortForwardConfig{ID: "web", PortLocal: 8081, PortCount: 3}
+	rule, _ := ParsePortRewrite("+10000")
+
+	got, err := RewriteLocalPorts([]PortForwardConfig{api, web}, nil, rule)
+	if err != nil {
+		t.Fatalf("RewriteLocalPorts: %v", err)
+	}
+	if got[0].PortLocal != 18080 || got[1].PortLocal != 18081 || got[1].PortCount != 3 {
+		t.Fatalf("unexpected rewrite: %+v", got)
+	}
+
+	// Moving onto a forward outside the selection is refused
+	taken := PortForwardConfig{ID: "taken", PortLocal: 18082}
+	if _, err := RewriteLocalPorts([]PortForwardConfig{api, web}, []PortForwardConfig{taken}, rule); err == nil {
+		t.Fatal("a rewrite into another forward's range must fail")
+	}
+
+	// Out of range for any forward fails the whole rewrite
+	high, _ := ParsePortRewrite("prefix 9")
+	if _, err := RewriteLocalPorts([]PortForwardConfig{api}, nil, high); err == nil {
+		t.Fatal("98080 is not a valid port")
+	}
+
+}
+
+func TestNextFreeLocalPort(t *testing.T) {
+	cfg := PortForwardConfig{ID: "web", PortLocal: 8080, PortCount: 2}
+	taken := []PortForwardConfig{
+		cfg, // itself does not count
+		{ID: "api", PortLocal: 8080},
+		{ID: "metrics", PortLocal: 8083},
+	}
+	// 8081-8082 is free; 8082-8083 would clash with metrics
+	if port, ok := NextFreeLocalPort(cfg, taken); !ok || port != 8081 {
+		t.Fatalf("NextFreeLocalPort = %d, %v; want 8081", port, ok)
+	}
+	if _, ok := NextFreeLocalPort(PortForwardConfig{PortLocal: 65535}, nil); ok {
+		t.Fatal("there is no port above 65535")
+	}
+}
+
+func TestDefaultLocalPort(t *testing.T) {
+	if got := DefaultLocalPort(LocalPortsSame, 8080, nil); got != 8080 {
+		t.Errorf("same = %d, want 8080", got)
+	}
+	if got := DefaultLocalPort("offset:10000", 8080, nil); got != 18080 {
+		t.Errorf("offset:10000 = %d, want 18080", got)
+	}
+	if got := DefaultLocalPort("offset:10000", 60000, nil); got != 60000 {
+		t.Errorf("offset past 65535 = %d, want the remote port", got)
+	}
+	taken := []PortForwardConfig{{ID: "web", PortLocal: 1024, PortCount: 64511}} // every port from 1024 up
+	if got := DefaultLocalPort(LocalPortsRandom, 8080, taken); got != 8080 {
+		t.Errorf("random with every port taken = %d, want the remote port", got)
+	}
+	if got := DefaultLocalPort(LocalPortsRandom, 8080, nil); got == 8080 || got < 1024 {
+		t.Errorf("random = %d, want a free unprivileged port", got)
+	}
+}
@@ -0,0 +1,557 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Setting keys persisted in the settings table. Keys containing a <placeholder>
+// are families: the placeholder stands for any non-empty text (context names
+// may themselves contain dots, so it is not limited to one segment).
+const (
+	SettingKubectlTimeout            = "kubectl.timeout"           // default timeout for every kubectl call
+	SettingKubectlTimeoutPrefix      = "kubectl.timeout."          // + command name, overrides the default
+	SettingKubectlRetries            = "kubectl.retries"           // retries after a transient failure
+	SettingKubectlCABundlePrefix     = "kubectl.ca_bundle."        // + context name, CA bundle for its API server
+	SettingStopDrainTimeout          = "stop.drain_timeout"        // how long a stop waits for open connections
+	SettingLimitStarting             = "limits.max_starting"       // concurrent kubectl establishes per context
+	SettingLimitStartingPrefix       = "limits.max_starting."      // + context name, overrides the default
+	SettingLimitForwards             = "limits.max_forwards"       // running forwards per context
+	SettingLimitForwardsPrefix       = "limits.max_forwards."      // + context name, overrides the default
+	settingKubectlTimeoutPerCommand  = "kubectl.timeout.<command>" // documentation form of the prefix
+	settingKubectlCABundlePerContext = "kubectl.ca_bundle.<context>"
+	settingLimitStartingPerContext   = "limits.max_starting.<context>"
+	settingLimitForwardsPerContext   = "limits.max_forwards.<context>"
+)
+
+// Discovery settings, in the same key scheme.
+const (
+	SettingDiscoveryNamespaces           = "discovery.namespace_filter"  // namespaces TUI discovery lists by default
+	SettingDiscoveryNamespacesPrefix     = "discovery.namespace_filter." // + context name, overrides the default
+	settingDiscoveryNamespacesPerContext = "discovery.namespace_filter.<context>"
+)
+
+// Local port settings, in the same key scheme.
+const (
+	SettingLocalPorts           = "ports.local"  // how new forwards' local ports are chosen
+	SettingLocalPortsPrefix     = "ports.local." // + context name, overrides the default
+	settingLocalPortsPerContext = "ports.local.<context>"
+)
+
+// General settings, in the same key scheme.
+const (
+	SettingLogLevel    = "log.level"     // debug, error or off
+	SettingKubectlPath = "kubectl.path"  // kubectl binary to run
+	SettingTheme       = "ui.theme"      // default or mono
+	SettingAccessible  = "ui.accessible" // screen-reader-friendly TUI output
+)
+
+// Group settings, in the same key scheme.
+const (
+	SettingGroupPrefix  = "ui.group." // + group name, expression selecting its forwards
+	settingGroupByName  = "ui.group.<name>"
+	SettingAutoCollapse = "ui.auto_collapse" // collapse context groups of clusters not used this session
+)
+
+// Network settings, in the same key scheme.
+const (
+	SettingNetworkRequirePrefix     = "network.require." // + context name, host:port that must be reachable
+	settingNetworkRequirePerContext = "network.require.<context>"
+)
+
+// Metrics settings, in the same key scheme.
+const (
+	SettingMetricsTextfile = "metrics.textfile" // file the TUI writes forward metrics to
+)
+
+// Security settings, in the same key scheme.
+const (
+	SettingConfirmExposed = "security.confirm_exposed" // confirm starting forwards bound outside loopback
+	SettingProtected      = "security.protected"       // expression selecting forwards into protected clusters
+)
+
+// Digest settings, in the same key scheme.
+const (
+	SettingDigestThreshold = "digest.threshold" // how long a protected forward runs before the digest lists it
+	SettingDigestWebhook   = "digest.webhook"   // URL kprtfwd digest posts to
+	SettingDigestCommand   = "digest.command"   // command kprtfwd digest runs with the digest on stdin
+)
+
+// DefaultDigestThreshold is SettingDigestThreshold when unset
+const DefaultDigestThreshold = 4 * time.Hour
+
+// kubectl resource settings, in the same key scheme.
+const (
+	SettingKubectlMemoryLimit   = "kubectl.memory_limit"   // memory a forward's kubectl may use before it is flagged
+	SettingKubectlMemoryRestart = "kubectl.memory_restart" // restart forwards whose kubectl exceeds the limit
+)
+
+// DefaultKubectlMemoryLimit is SettingKubectlMemoryLimit when unset: a
+// kubectl port-forward uses a few tens of MiB, so this only catches leaks.
+const DefaultKubectlMemoryLimit = 512 << 20
+
+// Pod settings, in the same key scheme.
+const (
+	SettingPodZone           = "pods.zone"  // zone or region whose pods a forward with a pod selector prefers
+	SettingPodZonePrefix     = "pods.zone." // + context name, overrides the default
+	settingPodZonePerContext = "pods.zone.<context>"
+)
+
+// Rollout settings, in the same key scheme.
+const (
+	SettingRolloutRestart = "rollout.restart" // restart forwards when their service's pods are replaced
+)
+
+// Values of SettingLogLevel and SettingTheme.
+const (
+	LogLevelDebug = "debug"
+	LogLevelError = "error"
+	LogLevelOff   = "off"
+	ThemeDefault  = "default"
+	ThemeMono     = "mono"
+)
+
+// Values of SettingLocalPorts: the remote port, a free port picked at random,
+// or the remote port plus a fixed offset ("offset:10000").
+const (
+	LocalPortsSame         = "same"
+	LocalPortsRandom       = "random"
+	LocalPortsOffsetPrefix = "offset:"
+)
+
+// SettingSpec documents a known setting and validates values written to it.
+type SettingSpec struct {
+	Key         string // exact key, or a pattern with a single <placeholder>
+	Description string
+	Validate    func(value string) error
+	Env         string // overrides the environment variable derived from Key
+	// Local is set for a path, URL or command of this machine, which kprtfwd
+	// runs, writes or trusts: exports leave it out, imports ask for it
+	Local bool
+}
+
+// settingSpecs lists every setting kprtfwd understands. Writes to unknown keys
+// are rejected so typos surface immediately instead of being silently ignored.
+var settingSpecs = []SettingSpec{
+	{
+		Key:         SettingKubectlTimeout,
+		Description: "Timeout for every kubectl call (e.g. 30s); overrides the built-in per-command defaults",
+		Validate:    validateDuration,
+	},
+	{
+		Key:         settingKubectlTimeoutPerCommand,
+		Description: "Timeout for one kubectl call: current-context, get-contexts, get-namespaces, get-services, get-endpoints, get-pods, get-nodes, describe, version",
+		Validate:    validateDuration,
+	},
+	{
+		Key:         SettingKubectlRetries,
+		Description: "Retries after a transient kubectl failure such as a timeout (default 1, 0 disables)",
+		Validate:    validateNonNegativeInt,
+	},
+	{
+		Key:         settingKubectlCABundlePerContext,
+		Description: "PEM file of CA certificates to trust for one context's API server, e.g. a corporate proxy's CA, instead of the kubeconfig's",
+		Validate:    validateNotEmpty,
+		Local:       true,
+	},
+	{
+		Key:         SettingStopDrainTimeout,
+		Description: "How long stopping a lazy or TLS forward waits for open connections to finish (default 30s, 0 stops immediately)",
+		Validate:    validateNonNegativeDuration,
+	},
+	{
+		Key:         SettingLimitStarting,
+		Description: "Forwards per context that may be connecting at once; more are queued (default 0, unlimited)",
+		Validate:    validateNonNegativeInt,
+	},
+	{
+		Key:         settingLimitStartingPerContext,
+		Description: "Connecting-at-once limit for one context, overriding limits.max_starting",
+		Validate:    validateNonNegativeInt,
+	},
+	{
+		Key:         SettingLimitForwards,
+		Description: "Forwards per context that may run at once; more are queued (default 0, unlimited)",
+		Validate:    validateNonNegativeInt,
+	},
+	{
+		Key:         settingLimitForwardsPerContext,
+		Description: "Running-at-once limit for one context, overriding limits.max_forwards",
+		Validate:    validateNonNegativeInt,
+	},
+	{
+		Key:         SettingDiscoveryNamespaces,
+		Description: "Namespace filter TUI discovery starts with, e.g. team-* (default *, every namespace)",
+		Validate:    validateNamespaceFilter,
+	},
+	{
+		Key:         settingDiscoveryNamespacesPerContext,
+		Description: "Namespace filter TUI discovery starts with for one context, overriding discovery.namespace_filter",
+		Validate:    validateNamespaceFilter,
+	},
+	{
+		Key:         settingNetworkRequirePerContext,
+		Description: "host:port that must accept connections before one context's forwards start, e.g. the VPN gateway or the API server's private address; several separated by commas",
+		Validate:    validateHostPorts,
+	},
+	{
+		Key:         SettingLocalPorts,
+		Description: "Local port discovery suggests for new forwards: same (the remote port, default), random (a free port) or offset:N (remote port + N)",
+		Validate:    validateLocalPorts,
+	},
+	{
+		Key:         settingLocalPortsPerContext,
+		Description: "Local port strategy for one context's new forwards, overriding ports.local",
+		Validate:    validateLocalPorts,
+	},
+	{
+		Key:         SettingLogLevel,
+		Description: "What goes to ~/.kprtfwd/logs/kprtfwd.log: debug, error or off (default error, debug if DEBUG is set)",
+		Validate:    oneOf(LogLevelDebug, LogLevelError, LogLevelOff),
+	},
+	{
+		Key:         SettingKubectlPath,
+		Description: "kubectl binary to run, a path or a name looked up in PATH (default kubectl)",
+		Validate:    validateNotEmpty,
+		Env:         "KPRTFWD_KUBECTL",
+		Local:       true,
+	},
+	{
+		Key:         SettingMetricsTextfile,
+		Description: "File the TUI keeps forward metrics in, in the Prometheus text format, for node_exporter's textfile collector (a *.prom file in its --collector.textfile.directory)",
+		Validate:    validatePromFile,
+		Local:       true,
+	},
+	{
+		Key:         SettingConfirmExposed,
+		Description: "Ask for confirmation before starting a forward bound outside loopback (e.g. --address=0.0.0.0), which exposes it to the local network: true or false (default true)",
+		Validate:    oneOf("true", "false"),
+	},
+	{
+		Key:         SettingProtected,
+		Description: "Forwards into protected clusters, for kprtfwd digest: an expression as ui.group.<name> takes it, e.g. context:prod-* OR tag:prod",
+		Validate:    validateGroupExpr,
+	},
+	{
+		Key:         SettingDigestThreshold,
+		Description: "How long a protected forward runs before kprtfwd digest lists it, e.g. 2h (default 4h)",
+		Validate:    validateDuration,
+	},
+	{
+		Key:         SettingDigestWebhook,
+		Description: "http(s) URL kprtfwd digest posts the digest to as JSON, e.g. a Slack or Teams incoming webhook",
+		Validate:    validateWebhookURL,
+		Local:       true,
+	},
+	{
+		Key:         SettingDigestCommand,
+		Description: "Command kprtfwd digest runs through the shell with the digest as text on stdin, e.g. mail -s 'kprtfwd digest' security@example.com",
+		Validate:    validateNotEmpty,
+		Local:       true,
+	},
+	{
+		Key:         SettingKubectlMemoryLimit,
+		Description: "Memory a forward's kubectl may use before the TUI flags it, e.g. 256MiB or 1GiB (default 512MiB, 0 never flags)",
+		Validate:    validateMemorySize,
+	},
+	{
+		Key:         SettingKubectlMemoryRestart,
+		Description: "Restart a forward as soon as its kubectl uses more than kubectl.memory_limit instead of only flagging it: true or false (default false)",
+		Validate:    oneOf("true", "false"),
+	},
+	{
+		Key:         SettingPodZone,
+		Description: "Zone or region, e.g. eu-west-1a, whose pods a forward with a pod selector goes to when several match, by the topology labels of their nodes (needs permission to list nodes); ready pods come first either way",
+		Validate:    validateNotEmpty,
+	},
+	{
+		Key:         settingPodZonePerContext,
+		Description: "Preferred zone or region for one context's pods, overriding pods.zone",
+		Validate:    validateNotEmpty,
+	},
+	{
+		Key:         SettingRolloutRestart,
+		Description: "Restart running forwards as soon as a rollout replaces their service's pods instead of waiting for them to fail: true or false (default false)",
+		Validate:    oneOf("true", "false"),
+	},
+	{
+		Key:         settingGroupByName,
+		Description: "Extra collapsible group in the TUI table listing the forwards an expression selects, e.g. tag:db OR namespace:payments (terms tag:, context:, namespace:, service:, id: with * wildcards; AND, OR, NOT, parentheses)",
+		Validate:    validateGroupExpr,
+	},
+	{
+		Key:         SettingAutoCollapse,
+		Description: "Start context groups collapsed while all their forwards are stopped, until a forward in them starts or the group is opened: true or false (default true)",
+		Validate:    oneOf("true", "false"),
+	},
+	{
+		Key:         SettingTheme,
+		Description: "TUI colors: default, or mono to draw without colors",
+		Validate:    oneOf(ThemeDefault, ThemeMono),
+		Env:         "KPRTFWD_THEME",
+	},
+	{
+		Key:         SettingAccessible,
+		Description: "Screen-reader-friendly TUI: a plain list instead of the table, no box borders, and each status change spelled out in the footer: true or false (default false)",
+		Validate:    oneOf("true", "false"),
+	},
+}
+
+// DiscoveryNamespaceFilter returns the namespace filter discovery should start
+// with for kubeContext: its own setting, else the general one, else "*".
+func DiscoveryNamespaceFilter(settings map[string]string, kubeContext string) string {
+	if filter, ok := settings[SettingDiscoveryNamespacesPrefix+kubeContext]; ok && kubeContext != "" {
+		return filter
+	}
+	if filter, ok := settings[SettingDiscoveryNamespaces]; ok {
+		return filter
+	}
+	return "*"
+}
+
+// LocalPortStrategy returns how new forwards in kubeContext get their local
+// port: its own setting, else the general one, else LocalPortsSame.
+func LocalPortStrategy(settings map[string]string, kubeContext string) string {
+	if strategy, ok := settings[SettingLocalPortsPrefix+kubeContext]; ok && kubeContext != "" {
+		return strategy
+	}
+	if strategy, ok := settings[SettingLocalPorts]; ok {
+		return strategy
+	}
+	return LocalPortsSame
+}
+
+// PodZone returns the zone or region whose pods forwards in kubeContext
+// prefer: its own setting, else the general one, else "" for none.
+func PodZone(settings map[string]string, kubeContext string) string {
+	if zone, ok := settings[SettingPodZonePrefix+kubeContext]; ok && kubeContext != "" {
+		return zone
+	}
+	return settings[SettingPodZone]
+}
+
+// PodZoneSettings returns the pods.zone settings among settings, for
+// PodZone
+func PodZoneSettings(settings map[string]string) map[string]string {
+	zones := make(map[string]string)
+	for key, value := range settings {
+		if key == SettingPodZone || strings.HasPrefix(key, SettingPodZonePrefix) {
+			zones[key] = value
+		}
+	}
+	return zones
+}
+
+// SettingSpecs returns the known settings sorted by key.
+func SettingSpecs() []SettingSpec {
+	specs := append([]SettingSpec{}, settingSpecs...)
+	sort.Slice(specs, func(i, j int) bool { return specs[i].Key < specs[j].Key })
+	return specs
+}
+
+// LookupSettingSpec returns the spec matching key, if any.
+func LookupSettingSpec(key string) (SettingSpec, bool) {
+	for _, spec := range settingSpecs {
+		if matchSettingKey(spec.Key, key) {
+			return spec, true
+		}
+	}
+	return SettingSpec{}, false
+}
+
+// ValidateSetting checks that key is a known setting and value is acceptable
+// for it.
+func ValidateSetting(key, value string) error {
+	spec, ok := LookupSettingSpec(key)
+	if !ok {
+		return fmt.Errorf("unknown setting %q", key)
+	}
+	if spec.Validate != nil {
+		if err := spec.Validate(value); err != nil {
+			return fmt.Errorf("invalid value for %s: %w", key, err)
+		}
+	}
+	return nil
+}
+
+// matchSettingKey reports whether key matches pattern, where pattern may hold
+// one <placeholder> matching any non-empty text.
+func matchSettingKey(pattern, key string) bool {
+	open := strings.Index(pattern, "<")
+	if open < 0 {
+		return pattern == key
+	}
+	end := strings.Index(pattern[open:], ">")
+	if end < 0 {
+		return pattern == key
+	}
+	prefix, suffix := pattern[:open], pattern[open+end+1:]
+	return len(key) > len(prefix)+len(suffix) &&
+		strings.HasPrefix(key, prefix) && strings.HasSuffix(key, suffix)
+}
+
+// oneOf returns a validator accepting exactly the given values.
+func oneOf(values ...string) func(string) error {
+	return func(value string) error {
+		if !slices.Contains(values, value) {
+			return fmt.Errorf("must be one of %s", strings.Join(values, ", "))
+		}
+		return nil
+	}
+}
+
+func validateNotEmpty(value string) error {
+	if strings.TrimSpace(value) == "" {
+		return fmt.Errorf("must not be empty")
+	}
+	return nil
+}
+
+func validateDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	return nil
+}
+
+func validateNonNegativeDuration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	}
+	if d < 0 {
+		return fmt.Errorf("duration must not be negative")
+	}
+	return nil
+}
+
+func validateNonNegativeInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fmt.Errorf("must be a whole number")
+	}
+	if n < 0 {
+		return fmt.Errorf("must not be negative")
+	}
+	return nil
+}
+
+// validateNamespaceFilter accepts the wildcard patterns discovery understands:
+// a namespace name with '*' at the start, the end or both, or '*' alone.
+func validateNamespaceFilter(value string) error {
+	if value == "*" {
+		return nil
+	}
+	name := strings.TrimSuffix(strings.TrimPrefix(value, "*"), "*")
+	if name == "" {
+		return fmt.Errorf("must be a namespace pattern like team-* or *-prod")
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return fmt.Errorf("%q is not a namespace pattern like team-* or *-prod", value)
+		}
+	}
+	return nil
+}
+
+// validateHostPorts accepts a comma-separated list of host:port addresses.
+func validateHostPorts(value string) error {
+	for _, address := range strings.Split(value, ",") {
+		host, port, err := net.SplitHostPort(strings.TrimSpace(address))
+		if err != nil || host == "" {
+			return fmt.Errorf("%q is not a host:port address like vpn.example.com:443", strings.TrimSpace(address))
+		}
+		if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+			return fmt.Errorf("%q has no valid port", strings.TrimSpace(address))
+		}
+	}
+	return nil
+}
+
+// validateWebhookURL accepts an absolute http or https URL.
+func validateWebhookURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL, e.g. https://hooks.example.com/kprtfwd")
+	}
+	return nil
+}
+
+// validatePromFile accepts a path ending in .prom, the only files the
+// textfile collector reads.
+func validatePromFile(value string) error {
+	if !strings.HasSuffix(value, ".prom") || len(value) == len(".prom") {
+		return fmt.Errorf("must be a path ending in .prom, e.g. /var/lib/node_exporter/textfile/kprtfwd.prom")
+	}
+	return nil
+}
+
+// memoryUnits are the suffixes ParseMemorySize accepts, by their size
+var memoryUnits = []struct {
+	suffix string
+	size   int64
+}{{"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}, {"Gi", 1 << 30}, {"Mi", 1 << 20}, {"Ki", 1 << 10}}
+
+// ParseMemorySize reads a memory size as the kubectl.memory_limit setting
+// takes it: a whole number of KiB, MiB or GiB (Kubernetes' Ki, Mi and Gi
+// also do), or 0.
+func ParseMemorySize(value string) (int64, error) {
+	if value == "0" {
+		return 0, nil
+	}
+	for _, unit := range memoryUnits {
+		if number, ok := strings.CutSuffix(value, unit.suffix); ok {
+			n, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+			if err != nil || n < 0 || n > 1<<40/unit.size {
+				break
+			}
+			return n * unit.size, nil
+		}
+	}
+	return 0, fmt.Errorf("%q is not a size like 512MiB or 1GiB", value)
+}
+
+func validateMemorySize(value string) error {
+	_, err := ParseMemorySize(value)
+	return err
+}
+
+// FormatMemorySize renders a byte count in the largest of KiB, MiB or GiB it
+// makes at least 1 of, with one decimal below 10
+func FormatMemorySize(bytes int64) string {
+	for _, unit := range memoryUnits[:3] {
+		if bytes >= unit.size {
+			value := float64(bytes) / float64(unit.size)
+			if value < 10 {
+				return strconv.FormatFloat(value, 'f', 1, 64) + " " + unit.suffix
+			}
+			return strconv.FormatFloat(value, 'f', 0, 64) + " " + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10) + " B"
+}
+
+// validateLocalPorts accepts the SettingLocalPorts values: same, random or
+// offset:N with N between 1 and 65534.
+func validateLocalPorts(value string) error {
+	if value == LocalPortsSame || value == LocalPortsRandom {
+		return nil
+	}
+	if offset, ok := strings.CutPrefix(value, LocalPortsOffsetPrefix); ok {
+		if n, err := strconv.Atoi(offset); err == nil && n >= 1 && n <= 65534 {
+			return nil
+		}
+		return fmt.Errorf("offset must be a number between 1 and 65534, e.g. offset:10000")
+	}
+	return fmt.Errorf("must be same, random or offset:N")
+}
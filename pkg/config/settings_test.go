@@ -0,0 +1,105 @@
+package config
+
+import "testing"
+
+func TestValidateSetting(t *testing.T) {
+	tests := []struct {
+		key, value string
+		wantErr    bool
+	}{
+		{"kubectl.timeout", "30s", false},
+		{"kubectl.timeout.get-services", "2m", false},
+		{"kubectl.retries", "0", false},
+		{"kubectl.timeout", "0s", true},
+		{"kubectl.timeout.get-services", "fast", true},
+		{"kubectl.retries", "-1", true},
+		{"kubectl.timeout.", "30s", true}, // placeholder must match something
+		{"kubectl.timeuot", "30s", true},
+		{"stop.drain_timeout", "0s", false},
+		{"stop.drain_timeout", "-5s", true},
+		{"limits.max_starting", "5", false},
+		{"limits.max_forwards.arn:aws:eks:eu-west-1:123:cluster/prod", "20", false},
+		{"limits.max_forwards.prod", "-1", true},
+		{"discovery.namespace_filter", "team-payments-*", false},
+		{"discovery.namespace_filter.prod", "*-prod", false},
+		{"discovery.namespace_filter", "*", false},
+		{"discovery.namespace_filter", "", true},
+		{"discovery.namespace_filter", "Team_*", true},
+		{"ports.local", "random", false},
+		{"ports.local.kind", "offset:10000", false},
+		{"ports.local", "offset:0", true},
+		{"ports.local", "offset:abc", true},
+		{"ports.local", "next", true},
+		{"log.level", "debug", false},
+		{"log.level", "verbose", true},
+		{"kubectl.path", "/opt/bin/kubectl", false},
+		{"kubectl.path", " ", true},
+		{"ui.theme", "mono", false},
+		{"ui.theme", "solarized", true},
+		{"ui.accessible", "true", false},
+		{"ui.accessible", "yes", true},
+		{"ui.group.databases", "tag:db OR namespace:payments", false},
+		{"ui.group.databases", "db", true},
+		{"metrics.textfile", "/var/lib/node_exporter/textfile/kprtfwd.prom", false},
+		{"metrics.textfile", "/tmp/kprtfwd.txt", true},
+		{"metrics.textfile", ".prom", true},
+		{"kubectl.memory_limit", "256MiB", false},
+		{"kubectl.memory_limit", "1Gi", false},
+		{"kubectl.memory_limit", "0", false},
+		{"kubectl.memory_limit", "512", true},
+		{"kubectl.memory_limit", "-1MiB", true},
+		{"kubectl.memory_restart", "true", false},
+	}
+	for _, tt := range tests {
+		err := ValidateSetting(tt.key, tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ValidateSetting(%q, %q) error = %v, wantErr %v", tt.key, tt.value, err, tt.wantErr)
+		}
+	}
+}
+
+func TestDiscoveryNamespaceFilter(t *testing.T) {
+	settings := map[string]string{
+		SettingDiscoveryNamespaces:                "team-*",
+		SettingDiscoveryNamespacesPrefix + "kind": "*",
+	}
+	if got := DiscoveryNamespaceFilter(settings, "prod"); got != "team-*" {
+		t.Errorf("prod filter = %q, want the general team-*", got)
+	}
+	if got := DiscoveryNamespaceFilter(settings, "kind"); got != "*" {
+		t.Errorf("kind filter = %q, want its own *", got)
+	}
+	if got := DiscoveryNamespaceFilter(nil, "prod"); got != "*" {
+		t.Errorf("filter without settings = %q, want *", got)
+	}
+}
+
+func TestLocalPortStrategy(t *testing.T) {
+	settings := map[string]string{
+		SettingLocalPorts:                "random",
+		SettingLocalPortsPrefix + "kind": "offset:10000",
+	}
+	if got := LocalPortStrategy(settings, "prod"); got != LocalPortsRandom {
+		t.Errorf("prod strategy = %q, want the general random", got)
+	}
+	if got := LocalPortStrategy(settings, "kind"); got != "offset:10000" {
+		t.Errorf("kind strategy = %q, want its own offset:10000", got)
+	}
+	if got := LocalPortStrategy(nil, "prod"); got != LocalPortsSame {
+		t.Errorf("strategy without settings = %q, want same", got)
+	}
+}
+
+func TestMemorySize(t *testing.T) {
+	if n, err := ParseMemorySize("512MiB"); err != nil || n != 512<<20 {
+		t.Fatalf("512MiB = %d, %v", n, err)
+	}
+	if n, err := ParseMemorySize("2Gi"); err != nil || n != 2<<30 {
+		t.Fatalf("2Gi = %d, %v", n, err)
+	}
+	for bytes, want := range map[int64]string{512: "512 B", 1536: "1.5 KiB", 48 << 20: "48 MiB", 3 << 29: "1.5 GiB"} {
+		if got := FormatMemorySize(bytes); got != want {
+			t.Errorf("FormatMemorySize(%d) = %q, want %q", bytes, got, want)
+		}
+	}
+}
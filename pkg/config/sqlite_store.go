@@ -2,10 +2,14 @@ package config
 
 import (
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/xlttj/kprtfwd/pkg/logging"
 
@@ -18,26 +22,40 @@ type SQLiteConfigStore struct {
 	activeProject *Project     // In-memory state only
 	mutex         sync.RWMutex // For thread-safe access
 	dbPath        string
+	encKey        []byte // key of an encrypted database, nil for a plain one
+	seenStamp     string // ChangeStamp of an encrypted database when last read or written
+
+	// Runs before a forward is deleted; see SetBeforeDelete
+	beforeDelete func(id string) error
 }
 
 // NewSQLiteConfigStore creates and initializes a new SQLite-based config store
+// at ~/.kprtfwd/kprtfwd.db, or at the path in KPRTFWD_DB. If the database was
+// encrypted (see EncryptDatabase) it is decrypted into memory.
 func NewSQLiteConfigStore() (*SQLiteConfigStore, error) {
 	// Determine database path
-	homeDir, err := os.UserHomeDir()
+	dbPath, err := DatabasePath()
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user home directory: %w", err)
+		return nil, err
 	}
-
-	configDir := filepath.Join(homeDir, ".kprtfwd")
-	dbPath := filepath.Join(configDir, "kprtfwd.db")
+	configDir := filepath.Dir(dbPath)
 
 	// Ensure config directory exists
 	if err := os.MkdirAll(configDir, 0700); err != nil {
 		return nil, fmt.Errorf("failed to create config directory: %w", err)
 	}
 
-	// Open SQLite database
-	db, err := sql.Open("sqlite", dbPath)
+	if _, err := os.Stat(dbPath + encryptedSuffix); err == nil {
+		db, key, err := openEncrypted(dbPath + encryptedSuffix)
+		if err != nil {
+			return nil, err
+		}
+		return newStore(db, dbPath, key)
+	}
+
+	// Open SQLite database, set up for other kprtfwd processes using it at
+	// the same time (see concurrency.go)
+	db, err := sql.Open("sqlite", plainDSN(dbPath))
 	// Attempt to set restrictive permissions on first creation
 	if _, statErr := os.Stat(dbPath); os.IsNotExist(statErr) {
 		// Create empty file with 0600, then reopen via sql if needed
@@ -55,10 +73,19 @@ func NewSQLiteConfigStore() (*SQLiteConfigStore, error) {
 		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
+	return newStore(db, dbPath, nil)
+}
 
+// newStore initializes the schema of an opened database and applies the
+// settings that take effect on opening
+func newStore(db *sql.DB, dbPath string, encKey []byte) (*SQLiteConfigStore, error) {
 	store := &SQLiteConfigStore{
 		db:     db,
 		dbPath: dbPath,
+		encKey: encKey,
+	}
+	if encKey != nil {
+		store.seenStamp = store.ChangeStamp()
 	}
 
 	// Initialize schema
@@ -66,6 +93,14 @@ func NewSQLiteConfigStore() (*SQLiteConfigStore, error) {
 		db.Close()
 		return nil, fmt.Errorf("failed to initialize database schema: %w", err)
 	}
+	if err := store.persist(); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// The log level is a setting, so it applies once the database is open
+	level, _ := store.GetSetting(SettingLogLevel)
+	logging.SetLevel(level)
 
 	logging.LogDebug("SQLite config store initialized at: %s", dbPath)
 	return store, nil
@@ -84,6 +119,17 @@ func (cs *SQLiteConfigStore) initializeSchema() error {
 		port_local INTEGER NOT NULL
 	);
 
+	-- Forwards defined once for every context matching a pattern (see
+	-- ExpandTemplate); the columns are those of port_forwards
+	CREATE TABLE IF NOT EXISTS forward_templates (
+		id TEXT PRIMARY KEY,
+		context TEXT NOT NULL,
+		namespace TEXT NOT NULL,
+		service TEXT NOT NULL,
+		port_remote INTEGER NOT NULL,
+		port_local INTEGER NOT NULL
+	);
+
 	-- Projects for grouping
 	CREATE TABLE IF NOT EXISTS projects (
 		id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -99,6 +145,40 @@ func (cs *SQLiteConfigStore) initializeSchema() error {
 		PRIMARY KEY (project_id, port_forward_id)
 	);
 
+	-- Service as seen by discovery when the forward was created
+	CREATE TABLE IF NOT EXISTS service_snapshots (
+		port_forward_id TEXT PRIMARY KEY,
+		service_type TEXT NOT NULL,
+		labels TEXT NOT NULL,
+		target_port TEXT NOT NULL,
+		port_name TEXT NOT NULL,
+		discovered_at INTEGER NOT NULL,
+		FOREIGN KEY (port_forward_id) REFERENCES port_forwards(id) ON DELETE CASCADE
+	);
+
+	-- Start/stop history of forwards, oldest first (see ForwardEvent)
+	CREATE TABLE IF NOT EXISTS forward_events (
+		seq INTEGER PRIMARY KEY AUTOINCREMENT,
+		port_forward_id TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		at INTEGER NOT NULL
+	);
+
+	-- Saved discovery selections, one row per port (see SelectionProfile)
+	CREATE TABLE IF NOT EXISTS selection_profiles (
+		name TEXT NOT NULL,
+		namespace TEXT NOT NULL,
+		service TEXT NOT NULL,
+		port_remote INTEGER NOT NULL,
+		PRIMARY KEY (name, namespace, service, port_remote)
+	);
+
+	-- Key/value settings (see settings.go for known keys)
+	CREATE TABLE IF NOT EXISTS settings (
+		key TEXT PRIMARY KEY,
+		value TEXT NOT NULL
+	);
+
 	-- Indexes for performance
 	CREATE INDEX IF NOT EXISTS idx_port_forwards_context ON port_forwards(context);
 	CREATE INDEX IF NOT EXISTS idx_port_forwards_namespace ON port_forwards(namespace);
@@ -110,9 +190,102 @@ func (cs *SQLiteConfigStore) initializeSchema() error {
 		return fmt.Errorf("failed to execute schema: %w", err)
 	}
 
+	return cs.migrateSchema()
+}
+
+// portForwardMigrations lists columns added to port_forwards after the initial
+// schema. Databases created by older versions get them via ALTER TABLE; the
+// defaults must reproduce the old behaviour.
+var portForwardMigrations = []struct {
+	column     string
+	definition string
+}{
+	{"lazy", "INTEGER NOT NULL DEFAULT 0"},
+	{"tls_mode", "TEXT NOT NULL DEFAULT ''"},
+	{"tls_server_name", "TEXT NOT NULL DEFAULT ''"},
+	{"port_count", "INTEGER NOT NULL DEFAULT 1"},
+	{"kubeconfig", "TEXT NOT NULL DEFAULT ''"},
+	{"kubectl_args", "TEXT NOT NULL DEFAULT ''"},
+	{"listen", "TEXT NOT NULL DEFAULT ''"},
+	{"tags", "TEXT NOT NULL DEFAULT ''"},
+	{"open_command", "TEXT NOT NULL DEFAULT ''"},
+	{"template", "TEXT NOT NULL DEFAULT ''"},
+	{"pod_selector", "TEXT NOT NULL DEFAULT ''"},
+	{"start_hook", "TEXT NOT NULL DEFAULT ''"},
+	{"stop_hook", "TEXT NOT NULL DEFAULT ''"},
+	{"health_path", "TEXT NOT NULL DEFAULT ''"},
+	{"target_kind", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrateSchema adds any missing port_forwards columns, to forward_templates
+// as well
+func (cs *SQLiteConfigStore) migrateSchema() error {
+	for _, table := range []string{"port_forwards", "forward_templates"} {
+		if err := cs.migrateForwardTable(table); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// migrateForwardTable adds the portForwardMigrations columns table lacks
+func (cs *SQLiteConfigStore) migrateForwardTable(table string) error {
+	rows, err := cs.db.Query(fmt.Sprintf("PRAGMA table_info(%s)", table))
+	if err != nil {
+		return fmt.Errorf("failed to inspect %s: %w", table, err)
+	}
+	existing := make(map[string]bool)
+	for rows.Next() {
+		var (
+			cid       int
+			name      string
+			colType   string
+			notNull   int
+			dfltValue sql.NullString
+			pk        int
+		)
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan %s column: %w", table, err)
+		}
+		existing[name] = true
+	}
+	rows.Close()
+
+	for _, m := range portForwardMigrations {
+		if existing[m.column] {
+			continue
+		}
+		stmt := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, m.column, m.definition)
+		if _, err := cs.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to add column %s to %s: %w", m.column, table, err)
+		}
+		logging.LogDebug("Migrated %s: added column %s", table, m.column)
+	}
 	return nil
 }
 
+// portForwardColumns is the column list every port_forwards SELECT uses, in
+// the order scanPortForward expects.
+const portForwardColumns = "id, context, namespace, service, port_remote, port_local, lazy, tls_mode, tls_server_name, port_count, kubeconfig, kubectl_args, listen, tags, open_command, template, pod_selector, start_hook, stop_hook, health_path, target_kind"
+
+// rowScanner is implemented by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanPortForward reads one port_forwards row selected with portForwardColumns
+func scanPortForward(row rowScanner) (PortForwardConfig, error) {
+	var cfg PortForwardConfig
+	err := row.Scan(&cfg.ID, &cfg.Context, &cfg.Namespace, &cfg.Service, &cfg.PortRemote, &cfg.PortLocal, &cfg.Lazy, &cfg.TLSMode, &cfg.TLSServerName, &cfg.PortCount, &cfg.Kubeconfig, &cfg.KubectlArgs, &cfg.Listen, &cfg.Tags, &cfg.OpenCommand, &cfg.Template, &cfg.PodSelector, &cfg.StartHook, &cfg.StopHook, &cfg.HealthPath, &cfg.TargetKind)
+	return cfg, err
+}
+
+// portForwardValues returns cfg's fields in the order of portForwardColumns
+func portForwardValues(cfg PortForwardConfig) []any {
+	return []any{cfg.ID, cfg.Context, cfg.Namespace, cfg.Service, cfg.PortRemote, cfg.PortLocal, cfg.Lazy, cfg.TLSMode, cfg.TLSServerName, cfg.PortCount, cfg.Kubeconfig, cfg.KubectlArgs, cfg.Listen, cfg.Tags, cfg.OpenCommand, cfg.Template, cfg.PodSelector, cfg.StartHook, cfg.StopHook, cfg.HealthPath, cfg.TargetKind}
+}
+
 // Close closes the database connection
 func (cs *SQLiteConfigStore) Close() error {
 	if cs.db != nil {
@@ -129,17 +302,17 @@ func (cs *SQLiteConfigStore) Add(cfg PortForwardConfig) error {
 	defer cs.mutex.Unlock()
 
 	query := `
-		INSERT INTO port_forwards (id, context, namespace, service, port_remote, port_local)
-		VALUES (?, ?, ?, ?, ?, ?)
+		INSERT INTO port_forwards (` + portForwardColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := cs.db.Exec(query, cfg.ID, cfg.Context, cfg.Namespace, cfg.Service, cfg.PortRemote, cfg.PortLocal)
+	_, err := cs.db.Exec(query, portForwardValues(cfg)...)
 	if err != nil {
 		return fmt.Errorf("failed to add port forward: %w", err)
 	}
 
 	logging.LogDebug("Added port forward: %s", cfg.ID)
-	return nil
+	return cs.persist()
 }
 
 // GetAll returns all port forward configurations
@@ -147,27 +320,7 @@ func (cs *SQLiteConfigStore) GetAll() []PortForwardConfig {
 	cs.mutex.RLock()
 	defer cs.mutex.RUnlock()
 
-	query := `SELECT id, context, namespace, service, port_remote, port_local FROM port_forwards ORDER BY context, namespace, service`
-
-	rows, err := cs.db.Query(query)
-	if err != nil {
-		logging.LogError("Failed to query port forwards: %v", err)
-		return []PortForwardConfig{}
-	}
-	defer rows.Close()
-
-	var configs []PortForwardConfig
-	for rows.Next() {
-		var cfg PortForwardConfig
-		err := rows.Scan(&cfg.ID, &cfg.Context, &cfg.Namespace, &cfg.Service, &cfg.PortRemote, &cfg.PortLocal)
-		if err != nil {
-			logging.LogError("Failed to scan port forward row: %v", err)
-			continue
-		}
-		configs = append(configs, cfg)
-	}
-
-	return configs
+	return cs.getAllUnsafe()
 }
 
 // Len returns the number of port forward configurations
@@ -208,19 +361,7 @@ func (cs *SQLiteConfigStore) GetConfigByID(id string) (PortForwardConfig, bool)
 	cs.mutex.RLock()
 	defer cs.mutex.RUnlock()
 
-	query := `SELECT id, context, namespace, service, port_remote, port_local FROM port_forwards WHERE id = ?`
-
-	var cfg PortForwardConfig
-	err := cs.db.QueryRow(query, id).Scan(&cfg.ID, &cfg.Context, &cfg.Namespace, &cfg.Service, &cfg.PortRemote, &cfg.PortLocal)
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return PortForwardConfig{}, false
-		}
-		logging.LogError("Failed to query port forward by ID: %v", err)
-		return PortForwardConfig{}, false
-	}
-
-	return cfg, true
+	return cs.getConfigByIDUnsafe(id)
 }
 
 // GetIndexByID returns the index of the port forward configuration with the given ID
@@ -234,8 +375,177 @@ func (cs *SQLiteConfigStore) GetIndexByID(id string) (int, bool) {
 	return -1, false
 }
 
+// UpdatePortForward replaces the configuration stored under id with cfg. If the
+// ID changes, project memberships follow the forward to its new ID.
+func (cs *SQLiteConfigStore) UpdatePortForward(id string, cfg PortForwardConfig) error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := updatePortForwardTx(tx, id, cfg); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logging.LogDebug("Updated port forward: %s", cfg.ID)
+	return cs.persist()
+}
+
+// UpdatePortForwards replaces several configurations, each stored under its
+// own ID, in one transaction: either all of them change or none does.
+func (cs *SQLiteConfigStore) UpdatePortForwards(cfgs []PortForwardConfig) error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, cfg := range cfgs {
+		if err := updatePortForwardTx(tx, cfg.ID, cfg); err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logging.LogDebug("Updated %d port forwards", len(cfgs))
+	return cs.persist()
+}
+
+// updatePortForwardTx is the body of UpdatePortForward, run inside tx
+func updatePortForwardTx(tx *sql.Tx, id string, cfg PortForwardConfig) error {
+	query := `
+		UPDATE port_forwards
+		SET id = ?, context = ?, namespace = ?, service = ?, port_remote = ?, port_local = ?,
+			lazy = ?, tls_mode = ?, tls_server_name = ?, port_count = ?, kubeconfig = ?, kubectl_args = ?, listen = ?, tags = ?, open_command = ?,
+			template = ?, pod_selector = ?, start_hook = ?, stop_hook = ?, health_path = ?, target_kind = ?
+		WHERE id = ?
+	`
+	result, err := tx.Exec(query, append(portForwardValues(cfg), id)...)
+	if err != nil {
+		return fmt.Errorf("failed to update port forward: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("port forward with ID '%s' not found", id)
+	}
+
+	if cfg.ID != id {
+		return moveReferencesTx(tx, id, cfg.ID)
+	}
+	return nil
+}
+
+// moveReferencesTx points project memberships, the service snapshot and the
+// start/stop history of forward from at to
+func moveReferencesTx(tx *sql.Tx, from, to string) error {
+	_, err := tx.Exec("UPDATE project_port_forwards SET port_forward_id = ? WHERE port_forward_id = ?", to, from)
+	if err != nil {
+		return fmt.Errorf("failed to update project associations: %w", err)
+	}
+	_, err = tx.Exec("UPDATE service_snapshots SET port_forward_id = ? WHERE port_forward_id = ?", to, from)
+	if err != nil {
+		return fmt.Errorf("failed to update service snapshot: %w", err)
+	}
+	_, err = tx.Exec("UPDATE forward_events SET port_forward_id = ? WHERE port_forward_id = ?", to, from)
+	if err != nil {
+		return fmt.Errorf("failed to update forward events: %w", err)
+	}
+	return nil
+}
+
+// RenamePortForwards gives forwards new IDs in one transaction, with their
+// project memberships, snapshots and history. Renames may reuse each other's
+// old IDs (a -> b, b -> a); a new ID held by a forward not being renamed fails.
+func (cs *SQLiteConfigStore) RenamePortForwards(renames []IDRename) error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Park every forward on a temporary ID first so that the order of the
+	// renames cannot make one collide with another's old ID
+	rename := func(from, to string) error {
+		result, err := tx.Exec("UPDATE port_forwards SET id = ? WHERE id = ?", to, from)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err != nil {
+			return err
+		} else if n == 0 {
+			return errors.New("not found")
+		}
+		return moveReferencesTx(tx, from, to)
+	}
+	for i, r := range renames {
+		if err := rename(r.From, fmt.Sprintf("\x00rename-%d", i)); err != nil {
+			return fmt.Errorf("failed to rename port forward '%s': %w", r.From, err)
+		}
+	}
+	for i, r := range renames {
+		var taken bool
+		if err := tx.QueryRow("SELECT count(*) > 0 FROM port_forwards WHERE id = ?", r.To).Scan(&taken); err != nil {
+			return fmt.Errorf("failed to check ID '%s': %w", r.To, err)
+		}
+		if taken {
+			return fmt.Errorf("cannot rename '%s': ID '%s' is already taken", r.From, r.To)
+		}
+		if err := rename(fmt.Sprintf("\x00rename-%d", i), r.To); err != nil {
+			return fmt.Errorf("failed to rename port forward '%s': %w", r.From, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logging.LogDebug("Renamed %d port forwards", len(renames))
+	return cs.persist()
+}
+
+// SetBeforeDelete registers fn to run before DeletePortForward removes a
+// forward. If fn fails the forward is kept. The TUI uses it to stop a
+// forward's kubectl process before its config goes away.
+func (cs *SQLiteConfigStore) SetBeforeDelete(fn func(id string) error) {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+	cs.beforeDelete = fn
+}
+
 // DeletePortForward removes a port forward configuration by ID
 func (cs *SQLiteConfigStore) DeletePortForward(id string) error {
+	// Run the hook outside the lock: stopping a forward may take a while
+	cs.mutex.RLock()
+	beforeDelete := cs.beforeDelete
+	cs.mutex.RUnlock()
+	if beforeDelete != nil {
+		if err := beforeDelete(id); err != nil {
+			return fmt.Errorf("failed to stop port forward '%s' before deleting it: %w", id, err)
+		}
+	}
+
 	cs.mutex.Lock()
 	defer cs.mutex.Unlock()
 
@@ -252,6 +562,16 @@ func (cs *SQLiteConfigStore) DeletePortForward(id string) error {
 		return fmt.Errorf("failed to remove project associations: %w", err)
 	}
 
+	_, err = tx.Exec("DELETE FROM service_snapshots WHERE port_forward_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to remove service snapshot: %w", err)
+	}
+
+	_, err = tx.Exec("DELETE FROM forward_events WHERE port_forward_id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to remove forward events: %w", err)
+	}
+
 	// Remove port forward
 	result, err := tx.Exec("DELETE FROM port_forwards WHERE id = ?", id)
 	if err != nil {
@@ -273,7 +593,202 @@ func (cs *SQLiteConfigStore) DeletePortForward(id string) error {
 	}
 
 	logging.LogDebug("Deleted port forward: %s", id)
-	return nil
+	return cs.persist()
+}
+
+// Discovery Snapshot Operations
+
+// SetServiceSnapshot stores the discovery snapshot for the forward with the
+// given ID, replacing any previous one
+func (cs *SQLiteConfigStore) SetServiceSnapshot(id string, snap ServiceSnapshot) error {
+	labels, err := json.Marshal(snap.Labels)
+	if err != nil {
+		return fmt.Errorf("failed to encode service labels: %w", err)
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	if _, exists := cs.getConfigByIDUnsafe(id); !exists {
+		return fmt.Errorf("port forward with ID '%s' not found", id)
+	}
+	_, err = cs.db.Exec(`
+		INSERT INTO service_snapshots (port_forward_id, service_type, labels, target_port, port_name, discovered_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(port_forward_id) DO UPDATE SET
+			service_type = excluded.service_type, labels = excluded.labels, target_port = excluded.target_port,
+			port_name = excluded.port_name, discovered_at = excluded.discovered_at
+	`, id, snap.Type, string(labels), snap.TargetPort, snap.PortName, snap.DiscoveredAt.Unix())
+	if err != nil {
+		return fmt.Errorf("failed to store service snapshot: %w", err)
+	}
+	return cs.persist()
+}
+
+// GetServiceSnapshot returns the discovery snapshot for the forward with the
+// given ID. Forwards added by hand or before snapshots existed have none.
+func (cs *SQLiteConfigStore) GetServiceSnapshot(id string) (ServiceSnapshot, bool) {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	var (
+		snap         ServiceSnapshot
+		labels       string
+		discoveredAt int64
+	)
+	err := cs.db.QueryRow(
+		"SELECT service_type, labels, target_port, port_name, discovered_at FROM service_snapshots WHERE port_forward_id = ?", id,
+	).Scan(&snap.Type, &labels, &snap.TargetPort, &snap.PortName, &discoveredAt)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logging.LogError("Failed to query service snapshot for %s: %v", id, err)
+		}
+		return ServiceSnapshot{}, false
+	}
+	if err := json.Unmarshal([]byte(labels), &snap.Labels); err != nil {
+		logging.LogError("Failed to decode service labels for %s: %v", id, err)
+	}
+	snap.DiscoveredAt = time.Unix(discoveredAt, 0)
+	return snap, true
+}
+
+// Forward Events
+
+// RecordForwardEvents appends events to the start/stop history
+func (cs *SQLiteConfigStore) RecordForwardEvents(events []ForwardEvent) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, e := range events {
+		_, err := tx.Exec("INSERT INTO forward_events (port_forward_id, kind, at) VALUES (?, ?, ?)", e.ForwardID, e.Kind, e.At.Unix())
+		if err != nil {
+			return fmt.Errorf("failed to record %s event for %s: %w", e.Kind, e.ForwardID, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	return cs.persist()
+}
+
+// ForwardEvents returns the recorded start/stop history, oldest first
+func (cs *SQLiteConfigStore) ForwardEvents() ([]ForwardEvent, error) {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	rows, err := cs.db.Query("SELECT port_forward_id, kind, at FROM forward_events ORDER BY seq")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query forward events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []ForwardEvent
+	for rows.Next() {
+		var (
+			e  ForwardEvent
+			at int64
+		)
+		if err := rows.Scan(&e.ForwardID, &e.Kind, &at); err != nil {
+			return nil, fmt.Errorf("failed to read forward event: %w", err)
+		}
+		e.At = time.Unix(at, 0)
+		events = append(events, e)
+	}
+	return events, rows.Err()
+}
+
+// Selection Profiles
+
+// SaveSelectionProfile stores profile, replacing any profile of the same name
+func (cs *SQLiteConfigStore) SaveSelectionProfile(profile SelectionProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("selection profile name cannot be empty")
+	}
+	if len(profile.Ports) == 0 {
+		return fmt.Errorf("selection profile '%s' has no ports", profile.Name)
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM selection_profiles WHERE name = ?", profile.Name); err != nil {
+		return fmt.Errorf("failed to replace selection profile: %w", err)
+	}
+	for _, p := range profile.Ports {
+		_, err := tx.Exec(
+			"INSERT OR IGNORE INTO selection_profiles (name, namespace, service, port_remote) VALUES (?, ?, ?, ?)",
+			profile.Name, p.Namespace, p.Service, p.Port,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to store selection profile: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logging.LogDebug("Saved selection profile: %s with %d ports", profile.Name, len(profile.Ports))
+	return cs.persist()
+}
+
+// GetSelectionProfiles returns the saved selection profiles sorted by name,
+// their ports sorted by namespace, service and port
+func (cs *SQLiteConfigStore) GetSelectionProfiles() []SelectionProfile {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	rows, err := cs.db.Query(
+		"SELECT name, namespace, service, port_remote FROM selection_profiles ORDER BY name, namespace, service, port_remote",
+	)
+	if err != nil {
+		logging.LogError("Failed to query selection profiles: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var profiles []SelectionProfile
+	for rows.Next() {
+		var (
+			name string
+			ref  ServicePortRef
+		)
+		if err := rows.Scan(&name, &ref.Namespace, &ref.Service, &ref.Port); err != nil {
+			logging.LogError("Failed to scan selection profile row: %v", err)
+			continue
+		}
+		if len(profiles) == 0 || profiles[len(profiles)-1].Name != name {
+			profiles = append(profiles, SelectionProfile{Name: name})
+		}
+		last := &profiles[len(profiles)-1]
+		last.Ports = append(last.Ports, ref)
+	}
+	return profiles
+}
+
+// GetSelectionProfile returns the saved selection profile with the given name
+func (cs *SQLiteConfigStore) GetSelectionProfile(name string) (SelectionProfile, bool) {
+	for _, profile := range cs.GetSelectionProfiles() {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return SelectionProfile{}, false
 }
 
 // Project Operations
@@ -315,7 +830,7 @@ func (cs *SQLiteConfigStore) CreateProject(name string, portForwardIDs []string)
 	}
 
 	logging.LogDebug("Created project: %s with %d port forwards", name, len(portForwardIDs))
-	return nil
+	return cs.persist()
 }
 
 // GetProjects returns all projects with their associated port forwards
@@ -398,7 +913,106 @@ func (cs *SQLiteConfigStore) DeleteProject(name string) error {
 	}
 
 	logging.LogDebug("Deleted project: %s", name)
-	return nil
+	return cs.persist()
+}
+
+// Settings Operations
+
+// GetSetting returns the value for key, its KPRTFWD_* environment override
+// before the stored one, and whether it is set
+func (cs *SQLiteConfigStore) GetSetting(key string) (string, bool) {
+	if value, ok := EnvOverrides()[key]; ok {
+		return value, true
+	}
+
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	var value string
+	err := cs.db.QueryRow("SELECT value FROM settings WHERE key = ?", key).Scan(&value)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			logging.LogError("Failed to read setting %s: %v", key, err)
+		}
+		return "", false
+	}
+	return value, true
+}
+
+// GetSettings returns the effective settings keyed by name: the stored ones
+// with the KPRTFWD_* environment overrides applied
+func (cs *SQLiteConfigStore) GetSettings() map[string]string {
+	settings := cs.GetStoredSettings()
+	for key, value := range EnvOverrides() {
+		settings[key] = value
+	}
+	return settings
+}
+
+// GetStoredSettings returns every stored setting keyed by name, ignoring
+// environment overrides
+func (cs *SQLiteConfigStore) GetStoredSettings() map[string]string {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	settings := make(map[string]string)
+	rows, err := cs.db.Query("SELECT key, value FROM settings")
+	if err != nil {
+		logging.LogError("Failed to query settings: %v", err)
+		return settings
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			logging.LogError("Failed to scan setting: %v", err)
+			continue
+		}
+		settings[key] = value
+	}
+	return settings
+}
+
+// SetSetting validates and stores a setting, replacing any previous value
+func (cs *SQLiteConfigStore) SetSetting(key, value string) error {
+	if err := ValidateSetting(key, value); err != nil {
+		return err
+	}
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	_, err := cs.db.Exec("INSERT OR REPLACE INTO settings (key, value) VALUES (?, ?)", key, value)
+	if err != nil {
+		return fmt.Errorf("failed to store setting: %w", err)
+	}
+
+	logging.LogDebug("Set setting %s = %s", key, value)
+	return cs.persist()
+}
+
+// UnsetSetting removes a setting so its built-in default applies again
+func (cs *SQLiteConfigStore) UnsetSetting(key string) error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	result, err := cs.db.Exec("DELETE FROM settings WHERE key = ?", key)
+	if err != nil {
+		return fmt.Errorf("failed to delete setting: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("setting '%s' is not set", key)
+	}
+
+	logging.LogDebug("Unset setting %s", key)
+	return cs.persist()
 }
 
 // In-Memory State Management
@@ -481,7 +1095,7 @@ func (cs *SQLiteConfigStore) GetActiveProjectForwards() []PortForwardConfig {
 // Helper methods (must be called with mutex already held)
 
 func (cs *SQLiteConfigStore) getAllUnsafe() []PortForwardConfig {
-	query := `SELECT id, context, namespace, service, port_remote, port_local FROM port_forwards ORDER BY context, namespace, service`
+	query := `SELECT ` + portForwardColumns + ` FROM port_forwards ORDER BY context, namespace, service`
 
 	rows, err := cs.db.Query(query)
 	if err != nil {
@@ -492,8 +1106,7 @@ func (cs *SQLiteConfigStore) getAllUnsafe() []PortForwardConfig {
 
 	var configs []PortForwardConfig
 	for rows.Next() {
-		var cfg PortForwardConfig
-		err := rows.Scan(&cfg.ID, &cfg.Context, &cfg.Namespace, &cfg.Service, &cfg.PortRemote, &cfg.PortLocal)
+		cfg, err := scanPortForward(rows)
 		if err != nil {
 			logging.LogError("Failed to scan port forward row: %v", err)
 			continue
@@ -505,10 +1118,9 @@ func (cs *SQLiteConfigStore) getAllUnsafe() []PortForwardConfig {
 }
 
 func (cs *SQLiteConfigStore) getConfigByIDUnsafe(id string) (PortForwardConfig, bool) {
-	query := `SELECT id, context, namespace, service, port_remote, port_local FROM port_forwards WHERE id = ?`
+	query := `SELECT ` + portForwardColumns + ` FROM port_forwards WHERE id = ?`
 
-	var cfg PortForwardConfig
-	err := cs.db.QueryRow(query, id).Scan(&cfg.ID, &cfg.Context, &cfg.Namespace, &cfg.Service, &cfg.PortRemote, &cfg.PortLocal)
+	cfg, err := scanPortForward(cs.db.QueryRow(query, id))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return PortForwardConfig{}, false
@@ -0,0 +1,296 @@
+package config
+
+import (
+	"database/sql"
+	"errors"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestStore opens a store in a throwaway HOME.
+func newTestStore(t *testing.T) *SQLiteConfigStore {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	store, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatalf("NewSQLiteConfigStore: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+	return store
+}
+
+// A database written by a version without the lazy column must open and
+// read its rows with the column's default.
+func TestMigrationAddsMissingColumns(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	dir := filepath.Join(home, ".kprtfwd")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	old, err := sql.Open("sqlite", filepath.Join(dir, "kprtfwd.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = old.Exec(`
+		CREATE TABLE port_forwards (
+			id TEXT PRIMARY KEY, context TEXT NOT NULL, namespace TEXT NOT NULL,
+			service TEXT NOT NULL, port_remote INTEGER NOT NULL, port_local INTEGER NOT NULL
+		);
+		INSERT INTO port_forwards VALUES ('ctx.ns.api', 'ctx', 'ns', 'api', 80, 8080);`)
+	old.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	store, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatalf("opening old database: %v", err)
+	}
+	defer store.Close()
+	cfg, ok := store.GetConfigByID("ctx.ns.api")
+	if !ok {
+		t.Fatal("existing row lost during migration")
+	}
+	if cfg.PortLocal != 8080 || cfg.Lazy {
+		t.Fatalf("unexpected migrated row: %+v", cfg)
+	}
+}
+
+func TestUpdatePortForwardKeepsProjectMembership(t *testing.T) {
+	store := newTestStore(t)
+	cfg := PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	if err := store.Add(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateProject("team", []string{cfg.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated := cfg
+	updated.PortLocal = 18080
+	updated.Lazy = true
+	updated.KubectlArgs = "--pod-running-timeout=2m"
+	updated.Listen = ListenDual
+	if err := store.UpdatePortForward(cfg.ID, updated); err != nil {
+		t.Fatalf("UpdatePortForward: %v", err)
+	}
+
+	got, _ := store.GetConfigByID(cfg.ID)
+	if got != updated {
+		t.Fatalf("stored %+v, want %+v", got, updated)
+	}
+	projects := store.GetProjects()
+	if len(projects) != 1 || len(projects[0].Forwards) != 1 || projects[0].Forwards[0] != cfg.ID {
+		t.Fatalf("project membership changed: %+v", projects)
+	}
+	if err := store.UpdatePortForward("missing", updated); err == nil {
+		t.Fatal("updating an unknown ID must fail")
+	}
+}
+
+func TestServiceSnapshotFollowsForward(t *testing.T) {
+	store := newTestStore(t)
+	cfg := PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	if err := store.Add(cfg); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.GetServiceSnapshot(cfg.ID); ok {
+		t.Fatal("a forward without a recorded snapshot must report none")
+	}
+
+	snap := ServiceSnapshot{
+		Type:         "ClusterIP",
+		Labels:       map[string]string{"app": "api", "tier": "backend"},
+		TargetPort:   "http",
+		PortName:     "web",
+		DiscoveredAt: time.Unix(1700000000, 0),
+	}
+	if err := store.SetServiceSnapshot(cfg.ID, snap); err != nil {
+		t.Fatalf("SetServiceSnapshot: %v", err)
+	}
+	if err := store.SetServiceSnapshot("missing", snap); err == nil {
+		t.Fatal("a snapshot for an unknown forward must be rejected")
+	}
+
+	renamed := cfg
+	renamed.ID = "ctx.ns.api-v2"
+	if err := store.UpdatePortForward(cfg.ID, renamed); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := store.GetServiceSnapshot(renamed.ID)
+	if !ok {
+		t.Fatal("snapshot lost when the forward's ID changed")
+	}
+	if got.Type != snap.Type || got.TargetPort != snap.TargetPort || got.PortName != snap.PortName ||
+		!got.DiscoveredAt.Equal(snap.DiscoveredAt) || !maps.Equal(got.Labels, snap.Labels) {
+		t.Fatalf("stored %+v, want %+v", got, snap)
+	}
+
+	if err := store.DeletePortForward(renamed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(renamed); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.GetServiceSnapshot(renamed.ID); ok {
+		t.Fatal("deleting a forward must delete its snapshot")
+	}
+}
+
+func TestSelectionProfiles(t *testing.T) {
+	store := newTestStore(t)
+	if err := store.SaveSelectionProfile(SelectionProfile{Name: "empty"}); err == nil {
+		t.Fatal("a profile without ports must be rejected")
+	}
+
+	api := ServicePortRef{Namespace: "ns", Service: "api", Port: 80}
+	db := ServicePortRef{Namespace: "data", Service: "pg", Port: 5432}
+	if err := store.SaveSelectionProfile(SelectionProfile{Name: "staging", Ports: []ServicePortRef{api, db, api}}); err != nil {
+		t.Fatalf("SaveSelectionProfile: %v", err)
+	}
+	if err := store.SaveSelectionProfile(SelectionProfile{Name: "base", Ports: []ServicePortRef{api}}); err != nil {
+		t.Fatal(err)
+	}
+	got, ok := store.GetSelectionProfile("staging")
+	if !ok || !slices.Equal(got.Ports, []ServicePortRef{db, api}) {
+		t.Fatalf("staging = %+v, %v; want data/pg and ns/api once each", got, ok)
+	}
+
+	// Saving under an existing name replaces the profile
+	if err := store.SaveSelectionProfile(SelectionProfile{Name: "staging", Ports: []ServicePortRef{db}}); err != nil {
+		t.Fatal(err)
+	}
+	profiles := store.GetSelectionProfiles()
+	if len(profiles) != 2 || profiles[0].Name != "base" || !slices.Equal(profiles[1].Ports, []ServicePortRef{db}) {
+		t.Fatalf("profiles = %+v", profiles)
+	}
+	if _, ok := store.GetSelectionProfile("missing"); ok {
+		t.Fatal("an unknown profile must not be found")
+	}
+}
+
+func TestUpdatePortForwardsIsAllOrNothing(t *testing.T) {
+	store := newTestStore(t)
+	api := PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	if err := store.Add(api); err != nil {
+		t.Fatal(err)
+	}
+
+	moved := api
+	moved.PortLocal = 18080
+	missing := PortForwardConfig{ID: "ctx.ns.gone", PortLocal: 18081}
+	if err := store.UpdatePortForwards([]PortForwardConfig{moved, missing}); err == nil {
+		t.Fatal("updating an unknown ID must fail")
+	}
+	if got, _ := store.GetConfigByID(api.ID); got.PortLocal != 8080 {
+		t.Fatalf("a failed bulk update left port %d behind", got.PortLocal)
+	}
+
+	if err := store.UpdatePortForwards([]PortForwardConfig{moved}); err != nil {
+		t.Fatalf("UpdatePortForwards: %v", err)
+	}
+	if got, _ := store.GetConfigByID(api.ID); got != moved {
+		t.Fatalf("stored %+v, want %+v", got, moved)
+	}
+}
+
+func TestRenamePortForwards(t *testing.T) {
+	store := newTestStore(t)
+	api := PortForwardConfig{ID: "api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	web := PortForwardConfig{ID: "web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8081}
+	for _, cfg := range []PortForwardConfig{api, web} {
+		if err := store.Add(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := store.CreateProject("team", []string{"api"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A swap only works if no rename sees the other's old ID
+	if err := store.RenamePortForwards([]IDRename{{From: "api", To: "web"}, {From: "web", To: "api"}}); err != nil {
+		t.Fatalf("RenamePortForwards: %v", err)
+	}
+	if got, _ := store.GetConfigByID("web"); got.Service != "api" {
+		t.Fatalf("web is now %+v, want the api forward", got)
+	}
+	if projects := store.GetProjects(); len(projects[0].Forwards) != 1 || projects[0].Forwards[0] != "web" {
+		t.Fatalf("project membership did not follow the rename: %+v", projects)
+	}
+
+	if err := store.RenamePortForwards([]IDRename{{From: "web", To: "ctx.ns.api"}, {From: "api", To: "ctx.ns.api"}}); err == nil {
+		t.Fatal("two forwards renamed to the same ID must fail")
+	}
+	if _, ok := store.GetConfigByID("web"); !ok {
+		t.Fatal("a failed rename must change nothing")
+	}
+}
+
+func TestBeforeDeleteRunsFirstAndCanVeto(t *testing.T) {
+	store := newTestStore(t)
+	api := PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	if err := store.Add(api); err != nil {
+		t.Fatal(err)
+	}
+
+	store.SetBeforeDelete(func(id string) error { return errors.New("still running") })
+	if err := store.DeletePortForward(api.ID); err == nil {
+		t.Fatal("a failing hook must keep the forward")
+	}
+	if _, ok := store.GetConfigByID(api.ID); !ok {
+		t.Fatal("forward deleted although the hook failed")
+	}
+
+	var stopped []string
+	store.SetBeforeDelete(func(id string) error {
+		stopped = append(stopped, id)
+		return nil
+	})
+	if err := store.DeletePortForward(api.ID); err != nil {
+		t.Fatal(err)
+	}
+	if len(stopped) != 1 || stopped[0] != api.ID {
+		t.Fatalf("hook saw %v, want [%s]", stopped, api.ID)
+	}
+}
+
+func TestForwardEventsFollowIDChangesAndDeletes(t *testing.T) {
+	store := newTestStore(t)
+	api := PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	if err := store.Add(api); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Unix(1_700_000_000, 0)
+	if err := store.RecordForwardEvents([]ForwardEvent{
+		{ForwardID: api.ID, Kind: ForwardEventStart, At: start},
+		{ForwardID: api.ID, Kind: ForwardEventStop, At: start.Add(time.Hour)},
+	}); err != nil {
+		t.Fatalf("RecordForwardEvents: %v", err)
+	}
+
+	renamed := api
+	renamed.ID = "ctx.ns.api-v2"
+	if err := store.UpdatePortForward(api.ID, renamed); err != nil {
+		t.Fatal(err)
+	}
+	events, err := store.ForwardEvents()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[0].ForwardID != renamed.ID || events[0].Kind != ForwardEventStart ||
+		!events[0].At.Equal(start) || !events[1].At.Equal(start.Add(time.Hour)) {
+		t.Fatalf("events after rename = %+v", events)
+	}
+
+	if err := store.DeletePortForward(renamed.ID); err != nil {
+		t.Fatal(err)
+	}
+	if events, _ := store.ForwardEvents(); len(events) != 0 {
+		t.Fatalf("deleting a forward left its history behind: %+v", events)
+	}
+}
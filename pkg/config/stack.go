@@ -0,0 +1,80 @@
+package config
+
+import (
+	"fmt"
+	"slices"
+)
+
+// StackTemplate is a built-in recipe for forwarding a well-known service:
+// the port it listens on, the local port developers expect it on and what
+// opening it runs. Unlike a forward template it is not stored; applying it
+// to a context and namespace creates an ordinary forward.
+type StackTemplate struct {
+	Name        string // also the service name the template assumes
+	Description string
+	PortRemote  int
+	PortLocal   int    // conventional local port, moved up when taken
+	OpenCommand string // empty to open the forward's URL in the browser
+	Tags        string
+}
+
+// StackTemplates are the built-in stack templates, in the order the add
+// flow offers them
+var StackTemplates = []StackTemplate{
+	{Name: "postgres", Description: "PostgreSQL", PortRemote: 5432, PortLocal: 5432, OpenCommand: "psql -h {{host}} -p {{port}}", Tags: "db"},
+	{Name: "redis", Description: "Redis", PortRemote: 6379, PortLocal: 6379, OpenCommand: "redis-cli -h {{host}} -p {{port}}", Tags: "db cache"},
+	{Name: "grafana", Description: "Grafana", PortRemote: 80, PortLocal: 3000, Tags: "monitoring"},
+}
+
+// FindStackTemplate returns the built-in stack template called name
+func FindStackTemplate(name string) (StackTemplate, bool) {
+	i := slices.IndexFunc(StackTemplates, func(t StackTemplate) bool { return t.Name == name })
+	if i < 0 {
+		return StackTemplate{}, false
+	}
+	return StackTemplates[i], true
+}
+
+// Summary describes the template in one line for the template picker
+func (t StackTemplate) Summary() string {
+	opens := "opens the browser"
+	if t.OpenCommand != "" {
+		opens = "opens " + t.OpenCommand
+	}
+	return fmt.Sprintf("%s: %s, %d → localhost:%d, %s", t.Name, t.Description, t.PortRemote, t.PortLocal, opens)
+}
+
+// Forward returns the forward the template defines for service in
+// kubeContext/namespace (service defaults to the template's name). It has no
+// ID. The local port is the conventional one, or the next one up that none
+// of taken uses, so the same stack can be forwarded from several namespaces.
+func (t StackTemplate) Forward(kubeContext, namespace, service string, taken []PortForwardConfig) (PortForwardConfig, error) {
+	if service == "" {
+		service = t.Name
+	}
+	cfg := PortForwardConfig{
+		Context:     kubeContext,
+		Namespace:   namespace,
+		Service:     service,
+		PortRemote:  t.PortRemote,
+		PortLocal:   t.PortLocal,
+		OpenCommand: t.OpenCommand,
+		Tags:        t.Tags,
+	}
+	if err := ValidateContextName(kubeContext); err != nil {
+		return cfg, err
+	}
+	if err := ValidateKubernetesName("namespace", namespace); err != nil {
+		return cfg, err
+	}
+	if err := ValidateKubernetesName("service", service); err != nil {
+		return cfg, err
+	}
+	for slices.ContainsFunc(taken, cfg.OverlapsLocally) {
+		if cfg.PortLocal == 65535 {
+			return cfg, fmt.Errorf("no free local port from %d up for %s", t.PortLocal, t.Name)
+		}
+		cfg.PortLocal++
+	}
+	return cfg, nil
+}
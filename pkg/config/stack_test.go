@@ -0,0 +1,39 @@
+package config
+
+import "testing"
+
+func TestStackTemplateForward(t *testing.T) {
+	postgres, ok := FindStackTemplate("postgres")
+	if !ok {
+		t.Fatal("postgres template missing")
+	}
+	cfg, err := postgres.Forward("dev", "data", "", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Service != "postgres" || cfg.PortRemote != 5432 || cfg.PortLocal != 5432 || cfg.OpenCommand != "psql -h {{host}} -p {{port}}" || cfg.Tags != "db" {
+		t.Errorf("forward = %+v", cfg)
+	}
+
+	// The conventional port is taken, by a single port and by a range
+	taken := []PortForwardConfig{{PortLocal: 5432}, {PortLocal: 5433, PortCount: 2}}
+	cfg, err = postgres.Forward("dev", "billing", "billing-db", taken)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Service != "billing-db" || cfg.PortLocal != 5435 {
+		t.Errorf("forward next to taken ports = %+v", cfg)
+	}
+
+	if _, err := postgres.Forward("dev", "Not_A_Namespace", "", nil); err == nil {
+		t.Error("an invalid namespace should be rejected")
+	}
+	if _, ok := FindStackTemplate("oracle"); ok {
+		t.Error("unknown template found")
+	}
+	for _, tmpl := range StackTemplates {
+		if err := ValidateOpenCommand(tmpl.OpenCommand); err != nil {
+			t.Errorf("%s: %v", tmpl.Name, err)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package config
+
+import "time"
+
+// ForwardUsage is what the start/stop history says about one forward.
+type ForwardUsage struct {
+	ID        string
+	Starts    int
+	Sessions  int           // finished sessions: a start followed by a stop
+	Uptime    time.Duration // total length of the finished sessions
+	LastStart time.Time     // zero if never started
+	Up        bool          // last event is a start: running now, or the TUI died before recording the stop
+}
+
+// AverageUptime returns the mean length of the forward's finished sessions.
+func (u ForwardUsage) AverageUptime() time.Duration {
+	if u.Sessions == 0 {
+		return 0
+	}
+	return u.Uptime / time.Duration(u.Sessions)
+}
+
+// SummarizeUsage folds events, oldest first, into one ForwardUsage per
+// forward in cfgs, in the same order. Events of other IDs are ignored. A
+// start with no stop before the next start (left behind by a TUI that did not
+// exit cleanly) counts as a start but not as a session.
+func SummarizeUsage(cfgs []PortForwardConfig, events []ForwardEvent) []ForwardUsage {
+	usage := make([]ForwardUsage, len(cfgs))
+	byID := make(map[string]*ForwardUsage, len(cfgs))
+	for i, cfg := range cfgs {
+		usage[i].ID = cfg.ID
+		byID[cfg.ID] = &usage[i]
+	}
+
+	for _, e := range events {
+		u, ok := byID[e.ForwardID]
+		if !ok {
+			continue
+		}
+		switch e.Kind {
+		case ForwardEventStart:
+			u.Starts++
+			u.LastStart = e.At
+			u.Up = true
+		case ForwardEventStop:
+			if u.Up {
+				u.Sessions++
+				u.Uptime += e.At.Sub(u.LastStart)
+			}
+			u.Up = false
+		}
+	}
+	return usage
+}
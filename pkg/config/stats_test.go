@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSummarizeUsage(t *testing.T) {
+	t0 := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return t0.Add(time.Duration(minutes) * time.Minute) }
+	cfgs := []PortForwardConfig{{ID: "api"}, {ID: "db"}, {ID: "idle"}}
+	events := []ForwardEvent{
+		{ForwardID: "api", Kind: ForwardEventStart, At: at(0)},
+		{ForwardID: "api", Kind: ForwardEventStop, At: at(10)},
+		{ForwardID: "gone", Kind: ForwardEventStart, At: at(15)}, // deleted forward
+		{ForwardID: "db", Kind: ForwardEventStart, At: at(20)},   // never stopped: TUI crashed
+		{ForwardID: "api", Kind: ForwardEventStart, At: at(30)},
+		{ForwardID: "api", Kind: ForwardEventStop, At: at(60)},
+		{ForwardID: "db", Kind: ForwardEventStart, At: at(90)}, // still running
+	}
+
+	usage := SummarizeUsage(cfgs, events)
+	if len(usage) != 3 || usage[0].ID != "api" || usage[1].ID != "db" || usage[2].ID != "idle" {
+		t.Fatalf("usage not in config order: %+v", usage)
+	}
+	if api := usage[0]; api.Starts != 2 || api.Sessions != 2 || api.AverageUptime() != 20*time.Minute || api.Up {
+		t.Fatalf("api = %+v, want 2 sessions averaging 20m", api)
+	}
+	if db := usage[1]; db.Starts != 2 || db.Sessions != 0 || !db.Up || !db.LastStart.Equal(at(90)) {
+		t.Fatalf("db = %+v, want 2 starts, no finished session, up since the last start", db)
+	}
+	if idle := usage[2]; idle.Starts != 0 || !idle.LastStart.IsZero() || idle.AverageUptime() != 0 {
+		t.Fatalf("idle = %+v, want no usage", idle)
+	}
+}
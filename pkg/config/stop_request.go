@@ -0,0 +1,125 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A panic stop reaches every running kprtfwd, whatever its workspace,
+// through two files in ~/.kprtfwd: `kprtfwd stop --all` touches stop-all,
+// each TUI polling it stops its forwards when its modification time is newer
+// than the last request it saw, and appends how many it stopped to
+// stop-all.done for the command to report.
+
+const (
+	stopAllFile     = "stop-all"
+	stopAllDoneFile = "stop-all.done"
+)
+
+// StopAllAnswer is a TUI's answer to a panic stop
+type StopAllAnswer struct {
+	PID       int
+	Workspace string
+	Stopped   int
+}
+
+// stopAllPath returns the path of name in ~/.kprtfwd
+func stopAllPath(name string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".kprtfwd", name), nil
+}
+
+// RequestStopAll asks every running kprtfwd to stop all its forwards and
+// returns the time of the request, for StopAllAnswers
+func RequestStopAll() (time.Time, error) {
+	path, err := stopAllPath(stopAllFile)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return time.Time{}, err
+	}
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now()
+	if err := os.Chtimes(path, now, now); err != nil {
+		return time.Time{}, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return info.ModTime(), nil
+}
+
+// StopAllRequestedAt returns the time of the last panic stop, or the zero
+// time if there was none. It costs a stat, so it can be polled.
+func StopAllRequestedAt() time.Time {
+	path, err := stopAllPath(stopAllFile)
+	if err != nil {
+		return time.Time{}
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// AnswerStopAll records that this process stopped n forwards for the panic
+// stop requested at at
+func AnswerStopAll(at time.Time, n int) error {
+	path, err := stopAllPath(stopAllDoneFile)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%d %d %d %s\n", at.UnixNano(), os.Getpid(), n, CurrentWorkspace())
+	return err
+}
+
+// StopAllAnswers returns the answers to the panic stop requested at at
+func StopAllAnswers(at time.Time) ([]StopAllAnswer, error) {
+	path, err := stopAllPath(stopAllDoneFile)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var answers []StopAllAnswer
+	want := strconv.FormatInt(at.UnixNano(), 10)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 4 || fields[0] != want {
+			continue // an older request's, or a torn line
+		}
+		pid, err1 := strconv.Atoi(fields[1])
+		stopped, err2 := strconv.Atoi(fields[2])
+		if err1 != nil || err2 != nil {
+			continue
+		}
+		answers = append(answers, StopAllAnswer{PID: pid, Workspace: fields[3], Stopped: stopped})
+	}
+	return answers, scanner.Err()
+}
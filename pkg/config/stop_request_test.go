@@ -0,0 +1,51 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStopAllRequestAndAnswers(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv(EnvWorkspace, "")
+
+	if at := StopAllRequestedAt(); !at.IsZero() {
+		t.Fatalf("StopAllRequestedAt = %v before any request, want zero", at)
+	}
+	first, err := RequestStopAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if at := StopAllRequestedAt(); !at.Equal(first) {
+		t.Fatalf("StopAllRequestedAt = %v, want %v", at, first)
+	}
+	if err := AnswerStopAll(first, 3); err != nil {
+		t.Fatal(err)
+	}
+
+	time.Sleep(10 * time.Millisecond) // a later modification time
+	second, err := RequestStopAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.After(first) {
+		t.Fatalf("second request at %v, not after the first at %v", second, first)
+	}
+	answers, err := StopAllAnswers(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 0 {
+		t.Fatalf("answers to the second request = %v, want none: the one there answered the first", answers)
+	}
+	if err := AnswerStopAll(second, 2); err != nil {
+		t.Fatal(err)
+	}
+	answers, err = StopAllAnswers(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 1 || answers[0].Stopped != 2 || answers[0].Workspace != DefaultWorkspace || answers[0].PID == 0 {
+		t.Fatalf("answers = %+v, want one from this process stopping 2 forwards", answers)
+	}
+}
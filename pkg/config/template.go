@@ -0,0 +1,205 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// A forward template is a forward whose Context is a path.Match pattern such
+// as "*-staging". It is never started itself; SyncForwardTemplates expands it
+// into one forward per kubectl context the pattern matches, so a fleet of
+// clusters gets the same forwards without copying them by hand.
+
+// ValidateForwardTemplate checks what is specific to a template: its ID,
+// which expanded forwards append to their context, and its context pattern
+func ValidateForwardTemplate(tmpl PortForwardConfig) error {
+	if tmpl.ID == "" {
+		return fmt.Errorf("template ID must not be empty")
+	}
+	if strings.ContainsAny(tmpl.ID, " \t") {
+		return fmt.Errorf("template ID %q contains whitespace", tmpl.ID)
+	}
+	if tmpl.Context == "" {
+		return fmt.Errorf("template %s needs a context pattern", tmpl.ID)
+	}
+	if _, err := path.Match(tmpl.Context, ""); err != nil {
+		return fmt.Errorf("bad context pattern %q: %w", tmpl.Context, err)
+	}
+	return ValidateContextName(tmpl.Context)
+}
+
+// ExpandForwardTemplate returns the forward tmpl defines in context: the
+// template with the context filled in, an ID of the sanitized context and the
+// template's ID (e.g. "eu-staging.payments-api") and Template set to it
+func ExpandForwardTemplate(tmpl PortForwardConfig, context string) PortForwardConfig {
+	cfg := tmpl
+	cfg.ID = SanitizeIDPart(context) + "." + tmpl.ID
+	cfg.Context = context
+	cfg.Template = tmpl.ID
+	// The template's kubeconfig belongs to whatever context it was made from
+	cfg.Kubeconfig = ""
+	return cfg
+}
+
+// TemplateSync reports what SyncForwardTemplates changed, by forward ID
+type TemplateSync struct {
+	Added   []string
+	Updated []string
+	// Skipped are forwards a template would add whose ID another forward
+	// already has
+	Skipped []string
+}
+
+// Changed reports whether the sync added or updated any forward
+func (s TemplateSync) Changed() bool {
+	return len(s.Added) > 0 || len(s.Updated) > 0
+}
+
+// Forward Templates
+
+// SetForwardTemplate stores tmpl, replacing the template with the same ID;
+// the next SyncForwardTemplates carries the change to its forwards
+func (cs *SQLiteConfigStore) SetForwardTemplate(tmpl PortForwardConfig) error {
+	if err := ValidateForwardTemplate(tmpl); err != nil {
+		return err
+	}
+	tmpl.Template = ""
+
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM forward_templates WHERE id = ?", tmpl.ID); err != nil {
+		return fmt.Errorf("failed to replace template: %w", err)
+	}
+	_, err = tx.Exec(`
+		INSERT INTO forward_templates (`+portForwardColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, portForwardValues(tmpl)...)
+	if err != nil {
+		return fmt.Errorf("failed to store template: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logging.LogDebug("Stored forward template: %s for contexts %s", tmpl.ID, tmpl.Context)
+	return cs.persist()
+}
+
+// ForwardTemplates returns the templates sorted by ID
+func (cs *SQLiteConfigStore) ForwardTemplates() []PortForwardConfig {
+	cs.mutex.RLock()
+	defer cs.mutex.RUnlock()
+
+	rows, err := cs.db.Query("SELECT " + portForwardColumns + " FROM forward_templates ORDER BY id")
+	if err != nil {
+		logging.LogError("Failed to query forward templates: %v", err)
+		return nil
+	}
+	defer rows.Close()
+
+	var templates []PortForwardConfig
+	for rows.Next() {
+		tmpl, err := scanPortForward(rows)
+		if err != nil {
+			logging.LogError("Failed to scan forward template row: %v", err)
+			continue
+		}
+		templates = append(templates, tmpl)
+	}
+	return templates
+}
+
+// DeleteForwardTemplate removes the template id. Forwards expanded from it
+// stay, as forwards of their own.
+func (cs *SQLiteConfigStore) DeleteForwardTemplate(id string) error {
+	cs.mutex.Lock()
+	defer cs.mutex.Unlock()
+
+	tx, err := cs.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec("DELETE FROM forward_templates WHERE id = ?", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete template: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	} else if n == 0 {
+		return fmt.Errorf("template '%s' does not exist", id)
+	}
+	if _, err := tx.Exec("UPDATE port_forwards SET template = '' WHERE template = ?", id); err != nil {
+		return fmt.Errorf("failed to detach forwards from template: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	logging.LogDebug("Deleted forward template: %s", id)
+	return cs.persist()
+}
+
+// SyncForwardTemplates expands every template across contexts. A context a
+// template matches gets its forward if it has none; a forward expanded
+// earlier is updated to the template's current fields, keeping its ID, local
+// port and kubeconfig so renames and port changes made since stick. Forwards
+// of contexts no longer listed are left alone.
+func (cs *SQLiteConfigStore) SyncForwardTemplates(contexts []string) (TemplateSync, error) {
+	var sync TemplateSync
+	templates := cs.ForwardTemplates()
+	if len(templates) == 0 {
+		return sync, nil
+	}
+
+	existing := cs.GetAll()
+	var updates []PortForwardConfig
+	for _, tmpl := range templates {
+		for _, context := range contexts {
+			if ok, _ := path.Match(tmpl.Context, context); !ok {
+				continue
+			}
+			want := ExpandForwardTemplate(tmpl, context)
+			i := slices.IndexFunc(existing, func(cfg PortForwardConfig) bool {
+				return cfg.Template == tmpl.ID && cfg.Context == context
+			})
+			if i >= 0 {
+				cur := existing[i]
+				want.ID, want.PortLocal, want.Kubeconfig = cur.ID, cur.PortLocal, cur.Kubeconfig
+				if want != cur {
+					updates = append(updates, want)
+					sync.Updated = append(sync.Updated, want.ID)
+				}
+				continue
+			}
+			if slices.ContainsFunc(existing, func(cfg PortForwardConfig) bool { return cfg.ID == want.ID }) {
+				logging.LogDebug("Template %s: %s already exists, not expanding into %s", tmpl.ID, want.ID, context)
+				sync.Skipped = append(sync.Skipped, want.ID)
+				continue
+			}
+			if err := cs.Add(want); err != nil {
+				return sync, fmt.Errorf("template %s in %s: %w", tmpl.ID, context, err)
+			}
+			existing = append(existing, want)
+			sync.Added = append(sync.Added, want.ID)
+		}
+	}
+	if len(updates) > 0 {
+		if err := cs.UpdatePortForwards(updates); err != nil {
+			return sync, err
+		}
+	}
+	return sync, nil
+}
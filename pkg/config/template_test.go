@@ -0,0 +1,65 @@
+package config
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSyncForwardTemplates(t *testing.T) {
+	store := newTestStore(t)
+	tmpl := PortForwardConfig{ID: "payments.api", Context: "*-staging", Namespace: "payments", Service: "api", PortRemote: 80, PortLocal: 8080, Kubeconfig: "/tmp/eu.yaml"}
+	if err := store.SetForwardTemplate(PortForwardConfig{ID: "bad", Context: "[-staging"}); err == nil {
+		t.Fatal("a malformed context pattern must be rejected")
+	}
+	if err := store.SetForwardTemplate(tmpl); err != nil {
+		t.Fatalf("SetForwardTemplate: %v", err)
+	}
+	// A forward defined on its own already has the ID us-staging would get
+	taken := PortForwardConfig{ID: "us-staging.payments.api", Context: "elsewhere", Namespace: "x", Service: "y", PortRemote: 1, PortLocal: 1}
+	if err := store.Add(taken); err != nil {
+		t.Fatal(err)
+	}
+
+	sync, err := store.SyncForwardTemplates([]string{"eu-staging", "prod", "us-staging"})
+	if err != nil {
+		t.Fatalf("SyncForwardTemplates: %v", err)
+	}
+	if !slices.Equal(sync.Added, []string{"eu-staging.payments.api"}) || !slices.Equal(sync.Skipped, []string{taken.ID}) {
+		t.Fatalf("first sync = %+v", sync)
+	}
+	got, ok := store.GetConfigByID("eu-staging.payments.api")
+	if !ok || got.Context != "eu-staging" || got.Template != tmpl.ID || got.Kubeconfig != "" || got.PortLocal != 8080 {
+		t.Fatalf("expanded forward = %+v", got)
+	}
+
+	// Renames and local ports made since stick; template changes propagate
+	got.ID, got.PortLocal = "eu.api", 18080
+	if err := store.UpdatePortForward("eu-staging.payments.api", got); err != nil {
+		t.Fatal(err)
+	}
+	tmpl.Lazy = true
+	if err := store.SetForwardTemplate(tmpl); err != nil {
+		t.Fatal(err)
+	}
+	sync, err = store.SyncForwardTemplates([]string{"eu-staging"})
+	if err != nil || !slices.Equal(sync.Updated, []string{"eu.api"}) || len(sync.Added) != 0 {
+		t.Fatalf("second sync = %+v, %v", sync, err)
+	}
+	if got, _ := store.GetConfigByID("eu.api"); !got.Lazy || got.PortLocal != 18080 {
+		t.Fatalf("updated forward = %+v", got)
+	}
+	if sync, _ := store.SyncForwardTemplates([]string{"eu-staging"}); sync.Changed() {
+		t.Fatalf("a sync without changes must change nothing, got %+v", sync)
+	}
+
+	// Removing the template leaves its forwards as forwards of their own
+	if err := store.DeleteForwardTemplate(tmpl.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := store.GetConfigByID("eu.api"); got.Template != "" {
+		t.Fatalf("forward still linked to a removed template: %+v", got)
+	}
+	if err := store.DeleteForwardTemplate(tmpl.ID); err == nil {
+		t.Fatal("removing an unknown template must fail")
+	}
+}
@@ -1,14 +1,288 @@
 package config
 
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
 // PortForwardConfig represents a port-forward configuration persisted in SQLite
 // Runtime status is managed in-memory by the PortForwarder
 type PortForwardConfig struct {
-	ID         string // Human-readable unique identifier
-	Context    string
-	Namespace  string
+	ID        string // Human-readable unique identifier
+	Context   string
+	Namespace string
+	// Service is the name of the resource forwarded to: a service unless
+	// TargetKind names another kind.
 	Service    string
 	PortRemote int
 	PortLocal  int
+	Lazy       bool // kprtfwd listens locally and starts kubectl on the first connection
+	// TLSMode makes kprtfwd terminate TLS on the local port or originate TLS
+	// towards the backend (see TLSMode* constants); empty for plain forwarding.
+	TLSMode       string
+	TLSServerName string // SNI for TLS origination; defaults to the service's cluster DNS name
+	// PortCount forwards a contiguous range: PortRemote..PortRemote+PortCount-1
+	// to PortLocal..PortLocal+PortCount-1. 0 and 1 both mean a single port.
+	PortCount int
+	// Kubeconfig is the file discovery found Context in; empty if unknown.
+	// It is passed to kubectl when KUBECONFIG no longer lists it.
+	Kubeconfig string
+	// KubectlArgs are extra flags appended to the kubectl port-forward
+	// command, separated by whitespace (e.g. "--pod-running-timeout=2m"),
+	// for cases the built-in options do not cover.
+	KubectlArgs string
+	// Listen selects the loopback addresses the local port is bound on (see
+	// Listen* constants); empty for IPv4 only.
+	Listen string
+	// Tags are free-form labels separated by whitespace (e.g. "db critical")
+	// that group expressions match with tag: terms.
+	Tags string
+	// OpenCommand is a shell command run instead of opening the browser,
+	// e.g. "psql -h {{host}} -p {{port}}" (see OpenCommandPlaceholders);
+	// empty to open the forward's URL.
+	OpenCommand string
+	// Template is the ID of the forward template this forward was expanded
+	// from (see ExpandTemplate); empty for forwards defined on their own.
+	Template string
+	// PodSelector sends the forward to one pod behind the service instead of
+	// letting the service pick: an ordinal ("2" for the pod named
+	// <something>-2, as a StatefulSet names them) or a label selector
+	// ("role=primary") narrowing the service's pods. It is resolved to a pod
+	// whenever the forward starts; empty forwards to the service.
+	PodSelector string
+	// StartHook and StopHook are shell commands run once the forward is up
+	// (e.g. "flyway migrate") and after it went down, with HookEnv in their
+	// environment; their output goes to the forward's log. Empty for none.
+	StartHook string
+	StopHook  string
+	// HealthPath is a path (e.g. "/healthz") the health check GETs over
+	// HTTP through the forward, so a backend answering with an error shows
+	// as degraded; empty checks only that the tunnel carries connections.
+	HealthPath string
+	// TargetKind is the kind of resource Service names (see TargetKind*
+	// constants): a pod or a workload, whose first pod kubectl picks, when
+	// no service exists. Empty for a service.
+	TargetKind string
+}
+
+// Target returns the resource kubectl port-forward forwards to, e.g.
+// "svc/api" or "deployment/api".
+func (c PortForwardConfig) Target() string {
+	switch c.TargetKind {
+	case TargetKindPod, TargetKindDeployment, TargetKindStatefulSet:
+		return c.TargetKind + "/" + c.Service
+	}
+	return "svc/" + c.Service
+}
+
+// KindName returns the kind of resource the forward goes to, for messages:
+// "service", "pod", ...
+func (c PortForwardConfig) KindName() string {
+	if c.TargetKind == TargetKindService {
+		return "service"
+	}
+	return c.TargetKind
+}
+
+// HasHooks reports whether the forward has a start or stop hook.
+func (c PortForwardConfig) HasHooks() bool {
+	return c.StartHook != "" || c.StopHook != ""
+}
+
+// Hook events, passed to hooks as KPRTFWD_EVENT
+const (
+	HookEventStart = "start"
+	HookEventStop  = "stop"
+)
+
+// HookEnv returns the environment variables a start or stop hook gets on
+// top of kprtfwd's own: the event and the forward's ID, context, namespace,
+// service, local address and ports.
+func (c PortForwardConfig) HookEnv(event string) []string {
+	return []string{
+		"KPRTFWD_EVENT=" + event,
+		"KPRTFWD_ID=" + c.ID,
+		"KPRTFWD_CONTEXT=" + c.Context,
+		"KPRTFWD_NAMESPACE=" + c.Namespace,
+		"KPRTFWD_SERVICE=" + c.Service,
+		"KPRTFWD_HOST=" + ListenHosts(c.Listen)[0],
+		"KPRTFWD_LOCAL_PORT=" + strconv.Itoa(c.PortLocal),
+		"KPRTFWD_REMOTE_PORT=" + strconv.Itoa(c.PortRemote),
+		"KPRTFWD_URL=" + c.LocalURL(),
+	}
+}
+
+// PodOrdinal returns the ordinal PodSelector names, if it is one rather than
+// a label selector.
+func (c PortForwardConfig) PodOrdinal() (int, bool) {
+	if c.PodSelector == "" || strings.Trim(c.PodSelector, "0123456789") != "" {
+		return 0, false
+	}
+	n, err := strconv.Atoi(c.PodSelector)
+	return n, err == nil
+}
+
+// Ports returns the number of ports the forward covers (at least 1).
+func (c PortForwardConfig) Ports() int {
+	if c.PortCount < 1 {
+		return 1
+	}
+	return c.PortCount
+}
+
+// KubectlArgList splits KubectlArgs into command-line arguments.
+func (c PortForwardConfig) KubectlArgList() []string {
+	return strings.Fields(c.KubectlArgs)
+}
+
+// TagList splits Tags into single tags.
+func (c PortForwardConfig) TagList() []string {
+	return strings.Fields(c.Tags)
+}
+
+// LocalURL is the local URL the forward serves on, as the browser opens it.
+func (c PortForwardConfig) LocalURL() string {
+	scheme := "http"
+	if c.TLSMode == TLSModeTerminate {
+		scheme = "https"
+	}
+	host := "localhost"
+	if c.Listen == ListenIPv6 {
+		// localhost may resolve to 127.0.0.1 only
+		host = "[::1]"
+	}
+	return fmt.Sprintf("%s://%s:%d", scheme, host, c.PortLocal)
+}
+
+// ExpandPlaceholders replaces the OpenCommandPlaceholders in s with the
+// forward's values.
+func (c PortForwardConfig) ExpandPlaceholders(s string) string {
+	return strings.NewReplacer(
+		"{{host}}", ListenHosts(c.Listen)[0],
+		"{{port}}", strconv.Itoa(c.PortLocal),
+		"{{url}}", c.LocalURL(),
+		"{{service}}", c.Service,
+		"{{namespace}}", c.Namespace,
+		"{{context}}", c.Context,
+		"{{id}}", c.ID,
+	).Replace(s)
+}
+
+// ExposedAddress returns the first address outside loopback the forward's
+// local port is bound on, through an --address in KubectlArgs (e.g. 0.0.0.0),
+// or "" if it is only reachable from this machine.
+func (c PortForwardConfig) ExposedAddress() string {
+	for _, arg := range c.KubectlArgList() {
+		value, ok := strings.CutPrefix(arg, "--address=")
+		if !ok {
+			continue
+		}
+		for _, host := range strings.Split(value, ",") {
+			if host == "localhost" {
+				continue
+			}
+			if ip := net.ParseIP(host); ip == nil || !ip.IsLoopback() {
+				return host
+			}
+		}
+	}
+	return ""
+}
+
+// OverlapsLocally reports whether c and other share a local port.
+func (c PortForwardConfig) OverlapsLocally(other PortForwardConfig) bool {
+	return c.PortLocal < other.PortLocal+other.Ports() && other.PortLocal < c.PortLocal+c.Ports()
+}
+
+// ServiceSnapshot records what a service looked like when discovery created a
+// forward for it. Prune uses it to tell a renamed service from a deleted one.
+type ServiceSnapshot struct {
+	Type         string            // ClusterIP, NodePort, LoadBalancer, ...
+	Labels       map[string]string // service labels
+	TargetPort   string            // target port of the forwarded service port (number or name)
+	PortName     string            // name of the forwarded service port, if any
+	DiscoveredAt time.Time
+}
+
+// LabelString renders the snapshot's labels as sorted key=value pairs
+func (s ServiceSnapshot) LabelString() string {
+	if len(s.Labels) == 0 {
+		return "(none)"
+	}
+	pairs := make([]string, 0, len(s.Labels))
+	for k, v := range s.Labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// SelectionProfile is a named set of service ports picked in a discovery
+// session. Applying it in another context selects the ports with the same
+// namespace, service and port there, so new clusters can mirror the forwards
+// of an existing one.
+type SelectionProfile struct {
+	Name  string
+	Ports []ServicePortRef
+}
+
+// ServicePortRef names a service port independently of its context
+type ServicePortRef struct {
+	Namespace string
+	Service   string
+	Port      int
+}
+
+// Kinds of ForwardEvent
+const (
+	ForwardEventStart = "start" // the forward came up (running or, if lazy, listening)
+	ForwardEventStop  = "stop"  // the forward went down, for whatever reason
+)
+
+// ForwardEvent records a forward coming up or going down. The TUI records
+// them; the stats command turns them into start counts and uptimes.
+type ForwardEvent struct {
+	ForwardID string
+	Kind      string // ForwardEventStart or ForwardEventStop
+	At        time.Time
+}
+
+// TLS modes for PortForwardConfig.TLSMode
+const (
+	TLSModeNone      = ""          // plain TCP, kubectl serves the local port
+	TLSModeTerminate = "terminate" // local port speaks TLS with a local dev certificate; plain TCP to the backend
+	TLSModeOriginate = "originate" // local port is plain TCP; kprtfwd speaks TLS to the backend
+)
+
+// Kinds of resource for PortForwardConfig.TargetKind
+const (
+	TargetKindService     = ""            // the service, which picks a pod for the connection
+	TargetKindPod         = "pod"         // one pod by name
+	TargetKindDeployment  = "deployment"  // a pod of the deployment, picked by kubectl when it starts
+	TargetKindStatefulSet = "statefulset" // a pod of the statefulset, picked by kubectl when it starts
+)
+
+// Local listen addresses for PortForwardConfig.Listen
+const (
+	ListenIPv4 = ""     // 127.0.0.1
+	ListenIPv6 = "ipv6" // ::1
+	ListenDual = "dual" // 127.0.0.1 and ::1; the port must be free on both
+)
+
+// ListenHosts returns the loopback addresses a forward with the given Listen
+// value is bound on; the first one is dialed to probe it.
+func ListenHosts(listen string) []string {
+	switch listen {
+	case ListenIPv6:
+		return []string{"::1"}
+	case ListenDual:
+		return []string{"127.0.0.1", "::1"}
+	}
+	return []string{"127.0.0.1"}
 }
 
 // Project represents a collection of port forwards that can be activated together
@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"regexp"
+	"slices"
 	"strings"
 )
 
@@ -57,3 +59,165 @@ func ValidatePort(kind string, port int) error {
 	}
 	return nil
 }
+
+// ValidatePortRange checks that count consecutive ports starting at first
+// are all in the valid TCP range.
+func ValidatePortRange(kind string, first, count int) error {
+	if err := ValidatePort(kind, first); err != nil {
+		return err
+	}
+	if count > 1 {
+		if err := ValidatePort(kind, first+count-1); err != nil {
+			return fmt.Errorf("%s range %d-%d: %w", kind, first, first+count-1, err)
+		}
+	}
+	return nil
+}
+
+// ValidateTLSMode checks that mode is one of the TLSMode* constants.
+func ValidateTLSMode(mode string) error {
+	switch mode {
+	case TLSModeNone, TLSModeTerminate, TLSModeOriginate:
+		return nil
+	}
+	return fmt.Errorf("TLS mode %q is not one of %q, %q or empty", mode, TLSModeTerminate, TLSModeOriginate)
+}
+
+// ValidateTargetKind checks that kind is one of the TargetKind* constants.
+func ValidateTargetKind(kind string) error {
+	switch kind {
+	case TargetKindService, TargetKindPod, TargetKindDeployment, TargetKindStatefulSet:
+		return nil
+	}
+	return fmt.Errorf("target kind %q is not one of %q, %q, %q or empty", kind, TargetKindPod, TargetKindDeployment, TargetKindStatefulSet)
+}
+
+// ValidateTarget checks that a forward's kind and pod selector go together:
+// a pod selector picks among a service's pods, so it needs a service.
+func ValidateTarget(kind, podSelector string) error {
+	if err := ValidateTargetKind(kind); err != nil {
+		return err
+	}
+	if kind != TargetKindService && podSelector != "" {
+		return fmt.Errorf("pod selector %q only applies to a service, not a %s", podSelector, kind)
+	}
+	return nil
+}
+
+// ValidateListen checks that listen is one of the Listen* constants.
+func ValidateListen(listen string) error {
+	switch listen {
+	case ListenIPv4, ListenIPv6, ListenDual:
+		return nil
+	}
+	return fmt.Errorf("listen address %q is not one of %q, %q or empty", listen, ListenIPv6, ListenDual)
+}
+
+// reservedKubectlFlags are set by kprtfwd itself from the forward's fields.
+var reservedKubectlFlags = []string{"namespace", "context", "kubeconfig"}
+
+// ValidateKubectlArgs checks a forward's extra kubectl arguments. Each one
+// must be a long flag ("--name" or "--name=value", never a separate value):
+// positional arguments would change what is forwarded, and the flags kprtfwd
+// sets itself must be changed through the forward's own fields.
+func ValidateKubectlArgs(args string) error {
+	for _, arg := range strings.Fields(args) {
+		name, _, _ := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+		if !strings.HasPrefix(arg, "--") || name == "" || strings.HasPrefix(name, "-") {
+			return fmt.Errorf("kubectl argument %q must be a --flag or --flag=value", arg)
+		}
+		for _, r := range arg {
+			if r < 0x20 || r == 0x7f {
+				return fmt.Errorf("kubectl argument %q contains control characters", arg)
+			}
+		}
+		if slices.Contains(reservedKubectlFlags, name) {
+			return fmt.Errorf("kubectl argument %q is set by kprtfwd; edit the forward instead", arg)
+		}
+	}
+	return nil
+}
+
+// ValidateTags checks a forward's whitespace-separated tags. A tag may not
+// contain ':' or parentheses, which group expressions use as syntax, nor
+// control characters.
+func ValidateTags(tags string) error {
+	for _, tag := range strings.Fields(tags) {
+		if strings.ContainsAny(tag, ":()") {
+			return fmt.Errorf("tag %q must not contain ':', '(' or ')'", tag)
+		}
+		for _, r := range tag {
+			if r < 0x20 || r == 0x7f {
+				return fmt.Errorf("tag %q contains control characters", tag)
+			}
+		}
+	}
+	return nil
+}
+
+// labelSelectorRegexp matches the label selectors a forward's PodSelector may
+// use: comma-separated key=value, key!=value, key or !key terms. Set-based
+// terms (key in (a,b)) are left out; one pod is picked anyway.
+var labelSelectorRegexp = regexp.MustCompile(`^!?[A-Za-z0-9][-A-Za-z0-9_./]*((==?|!=)[-A-Za-z0-9_.]*)?(,!?[A-Za-z0-9][-A-Za-z0-9_./]*((==?|!=)[-A-Za-z0-9_.]*)?)*$`)
+
+// ValidatePodSelector checks a forward's pod selector: empty, an ordinal or a
+// label selector.
+func ValidatePodSelector(selector string) error {
+	if selector == "" || strings.Trim(selector, "0123456789") == "" {
+		return nil
+	}
+	if !labelSelectorRegexp.MatchString(selector) {
+		return fmt.Errorf("pod selector %q is neither an ordinal (e.g. 2) nor a label selector (e.g. role=primary)", selector)
+	}
+	return nil
+}
+
+// OpenCommandPlaceholders are replaced in a forward's OpenCommand before it
+// runs: the local address and port, the forward's URL as the browser would
+// open it, and the forward's own fields.
+var OpenCommandPlaceholders = []string{"{{host}}", "{{port}}", "{{url}}", "{{service}}", "{{namespace}}", "{{context}}", "{{id}}"}
+
+// openCommandPlaceholder finds the {{...}} placeholders in an open command
+var openCommandPlaceholder = regexp.MustCompile(`\{\{[^}]*\}\}`)
+
+// ValidateOpenCommand checks a forward's open command: it may only use the
+// OpenCommandPlaceholders and may not contain control characters.
+func ValidateOpenCommand(command string) error {
+	for _, r := range command {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("open command contains control characters")
+		}
+	}
+	for _, placeholder := range openCommandPlaceholder.FindAllString(command, -1) {
+		if !slices.Contains(OpenCommandPlaceholders, placeholder) {
+			return fmt.Errorf("unknown placeholder %s in open command (use %s)", placeholder, strings.Join(OpenCommandPlaceholders, ", "))
+		}
+	}
+	return nil
+}
+
+// ValidateHealthPath checks a forward's health check path: empty, or an
+// absolute URL path with an optional query
+func ValidateHealthPath(path string) error {
+	if path == "" {
+		return nil
+	}
+	if !strings.HasPrefix(path, "/") {
+		return fmt.Errorf("health check path %q must start with /", path)
+	}
+	if u, err := url.ParseRequestURI(path); err != nil || u.Host != "" || strings.ContainsAny(path, " \t#") {
+		return fmt.Errorf("health check path %q is not a URL path", path)
+	}
+	return nil
+}
+
+// ValidateHook checks a forward's start or stop hook command, which may not
+// contain control characters
+func ValidateHook(command string) error {
+	for _, r := range command {
+		if r < 0x20 || r == 0x7f {
+			return fmt.Errorf("hook contains control characters")
+		}
+	}
+	return nil
+}
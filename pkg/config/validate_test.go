@@ -73,3 +73,134 @@ func TestValidatePort(t *testing.T) {
 		}
 	}
 }
+
+func TestValidateTLSMode(t *testing.T) {
+	for _, mode := range []string{TLSModeNone, TLSModeTerminate, TLSModeOriginate} {
+		if err := ValidateTLSMode(mode); err != nil {
+			t.Errorf("ValidateTLSMode(%q) = %v, want nil", mode, err)
+		}
+	}
+	if err := ValidateTLSMode("mtls"); err == nil {
+		t.Error("ValidateTLSMode(\"mtls\") = nil, want error")
+	}
+}
+
+func TestValidateListen(t *testing.T) {
+	for _, listen := range []string{ListenIPv4, ListenIPv6, ListenDual} {
+		if err := ValidateListen(listen); err != nil {
+			t.Errorf("ValidateListen(%q) = %v, want nil", listen, err)
+		}
+	}
+	if err := ValidateListen("0.0.0.0"); err == nil {
+		t.Error("ValidateListen(\"0.0.0.0\") = nil, want error")
+	}
+}
+
+func TestValidateKubectlArgs(t *testing.T) {
+	for _, args := range []string{"", "--pod-running-timeout=2m", "--request-timeout=30s", " --v=6  --address=0.0.0.0 "} {
+		if err := ValidateKubectlArgs(args); err != nil {
+			t.Errorf("ValidateKubectlArgs(%q) = %v, want nil", args, err)
+		}
+	}
+	for _, args := range []string{"-n", "svc/other", "--request-timeout 30s", "--", "---x", "--=1", "--namespace=kube-system", "--v=6 --context prod", "--kubeconfig=/tmp/x"} {
+		if err := ValidateKubectlArgs(args); err == nil {
+			t.Errorf("ValidateKubectlArgs(%q) = nil, want error", args)
+		}
+	}
+}
+
+func TestValidateTags(t *testing.T) {
+	for _, tags := range []string{"", "db", " db  critical team-a "} {
+		if err := ValidateTags(tags); err != nil {
+			t.Errorf("ValidateTags(%q) = %v, want nil", tags, err)
+		}
+	}
+	for _, tags := range []string{"tag:db", "db (old)", "a\x01b"} {
+		if err := ValidateTags(tags); err == nil {
+			t.Errorf("ValidateTags(%q) = nil, want error", tags)
+		}
+	}
+}
+
+func TestValidatePodSelector(t *testing.T) {
+	for _, selector := range []string{"", "0", "12", "role=primary", "app.kubernetes.io/name=pg,role!=replica", "canary", "!canary"} {
+		if err := ValidatePodSelector(selector); err != nil {
+			t.Errorf("ValidatePodSelector(%q) = %v, want nil", selector, err)
+		}
+	}
+	for _, selector := range []string{"-l", "role=primary --v=6", "env in (a,b)", "=x"} {
+		if err := ValidatePodSelector(selector); err == nil {
+			t.Errorf("ValidatePodSelector(%q) = nil, want error", selector)
+		}
+	}
+	if n, ok := (PortForwardConfig{PodSelector: "2"}).PodOrdinal(); !ok || n != 2 {
+		t.Errorf("PodOrdinal of \"2\" = %d, %v", n, ok)
+	}
+	if _, ok := (PortForwardConfig{PodSelector: "role=primary"}).PodOrdinal(); ok {
+		t.Error("a label selector is no ordinal")
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	for _, kind := range []string{TargetKindService, TargetKindPod, TargetKindDeployment, TargetKindStatefulSet} {
+		if err := ValidateTarget(kind, ""); err != nil {
+			t.Errorf("ValidateTarget(%q, \"\") = %v, want nil", kind, err)
+		}
+	}
+	if err := ValidateTarget(TargetKindService, "role=primary"); err != nil {
+		t.Errorf("a service with a pod selector: %v", err)
+	}
+	if err := ValidateTarget(TargetKindDeployment, "role=primary"); err == nil {
+		t.Error("a deployment with a pod selector must be rejected")
+	}
+	if err := ValidateTarget("replicaset", ""); err == nil {
+		t.Error("ValidateTarget(\"replicaset\") = nil, want error")
+	}
+	for kind, want := range map[string]string{TargetKindService: "svc/api", TargetKindPod: "pod/api", TargetKindDeployment: "deployment/api"} {
+		if got := (PortForwardConfig{Service: "api", TargetKind: kind}).Target(); got != want {
+			t.Errorf("Target of kind %q = %s, want %s", kind, got, want)
+		}
+	}
+}
+
+func TestValidateOpenCommand(t *testing.T) {
+	for _, command := range []string{"", "psql -h {{host}} -p {{port}}", "curl {{url}}/healthz", "echo {{id}} {{service}}.{{namespace}} in {{context}}"} {
+		if err := ValidateOpenCommand(command); err != nil {
+			t.Errorf("ValidateOpenCommand(%q) = %v, want nil", command, err)
+		}
+	}
+	for _, command := range []string{"psql -p {{local_port}}", "psql -p {{ port }}", "a\nb"} {
+		if err := ValidateOpenCommand(command); err == nil {
+			t.Errorf("ValidateOpenCommand(%q) = nil, want error", command)
+		}
+	}
+}
+
+func TestExpandPlaceholders(t *testing.T) {
+	cfg := PortForwardConfig{ID: "prod.data.pg", Context: "prod", Namespace: "data", Service: "pg", PortLocal: 15432}
+	want := "psql -h 127.0.0.1 -p 15432 # http://localhost:15432 pg.data@prod prod.data.pg"
+	if got := cfg.ExpandPlaceholders("psql -h {{host}} -p {{port}} # {{url}} {{service}}.{{namespace}}@{{context}} {{id}}"); got != want {
+		t.Errorf("ExpandPlaceholders = %q, want %q", got, want)
+	}
+	cfg.Listen = ListenIPv6
+	if got := cfg.ExpandPlaceholders("redis-cli -h {{host}} -p {{port}} # {{url}}"); got != "redis-cli -h ::1 -p 15432 # http://[::1]:15432" {
+		t.Errorf("ExpandPlaceholders for IPv6 = %q", got)
+	}
+}
+
+func TestExposedAddress(t *testing.T) {
+	tests := []struct{ args, want string }{
+		{"", ""},
+		{"--pod-running-timeout=2m", ""},
+		{"--address=localhost", ""},
+		{"--address=127.0.0.1,::1", ""},
+		{"--address=0.0.0.0", "0.0.0.0"},
+		{"--v=6 --address=127.0.0.1,192.168.1.20", "192.168.1.20"},
+	}
+	for _, tt := range tests {
+		cfg := PortForwardConfig{KubectlArgs: tt.args}
+		if got := cfg.ExposedAddress(); got != tt.want {
+			t.Errorf("ExposedAddress(%q) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// A workspace is a database of its own, so separate environments (one per
+// client, say) share no forwards, projects or settings. The default
+// workspace is ~/.kprtfwd/kprtfwd.db; every other one is
+// ~/.kprtfwd/workspaces/<name>.db and is created when first opened.
+
+// EnvWorkspace selects the workspace; the --workspace flag sets it for the
+// process. KPRTFWD_DB takes precedence over it.
+const EnvWorkspace = "KPRTFWD_WORKSPACE"
+
+// DefaultWorkspace names the database at ~/.kprtfwd/kprtfwd.db
+const DefaultWorkspace = "default"
+
+// workspacesDir holds the databases of the workspaces other than the default
+const workspacesDir = "workspaces"
+
+// ValidateWorkspaceName checks that name can be used as a workspace's file
+// name: lower-case letters, digits, '-' and '_'
+func ValidateWorkspaceName(name string) error {
+	if name == "" {
+		return fmt.Errorf("workspace name must not be empty")
+	}
+	if len(name) > 63 {
+		return fmt.Errorf("workspace name %q is longer than 63 characters", name)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+			return fmt.Errorf("workspace name %q may only contain a-z, 0-9, '-' and '_'", name)
+		}
+	}
+	return nil
+}
+
+// CurrentWorkspace returns the selected workspace, DefaultWorkspace unless
+// KPRTFWD_WORKSPACE names another
+func CurrentWorkspace() string {
+	if name := os.Getenv(EnvWorkspace); name != "" {
+		return name
+	}
+	return DefaultWorkspace
+}
+
+// SetWorkspace selects the workspace the next NewSQLiteConfigStore opens. It
+// fails when KPRTFWD_DB pins the database, as the workspace would be ignored.
+func SetWorkspace(name string) error {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return err
+	}
+	if os.Getenv(EnvDB) != "" {
+		return fmt.Errorf("%s is set, so workspaces cannot be used", EnvDB)
+	}
+	return os.Setenv(EnvWorkspace, name)
+}
+
+// workspacePath returns the plain database path of the workspace name
+func workspacePath(name string) (string, error) {
+	if err := ValidateWorkspaceName(name); err != nil {
+		return "", err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	if name == DefaultWorkspace {
+		return filepath.Join(homeDir, ".kprtfwd", "kprtfwd.db"), nil
+	}
+	return filepath.Join(homeDir, ".kprtfwd", workspacesDir, name+".db"), nil
+}
+
+// Workspaces lists the existing workspaces, the default one first and the
+// others sorted by name, plus the current one if it has no database yet
+func Workspaces() ([]string, error) {
+	dir, err := workspacePath(DefaultWorkspace)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(filepath.Join(filepath.Dir(dir), workspacesDir))
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to list workspaces: %w", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(strings.TrimSuffix(entry.Name(), encryptedSuffix), ".db")
+		if ok && !entry.IsDir() && ValidateWorkspaceName(name) == nil && name != DefaultWorkspace {
+			names = append(names, name)
+		}
+	}
+	if current := CurrentWorkspace(); current != DefaultWorkspace {
+		names = append(names, current)
+	}
+	slices.Sort(names)
+	return append([]string{DefaultWorkspace}, slices.Compact(names)...), nil
+}
@@ -0,0 +1,81 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestWorkspaceDatabases(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(EnvDB, "")
+	t.Setenv(EnvWorkspace, "")
+
+	store, err := NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Add(PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}); err != nil {
+		t.Fatal(err)
+	}
+	store.Close()
+
+	if err := SetWorkspace("client-a"); err != nil {
+		t.Fatal(err)
+	}
+	path, err := DatabasePath()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := filepath.Join(home, ".kprtfwd", "workspaces", "client-a.db"); path != want {
+		t.Fatalf("DatabasePath = %s, want %s", path, want)
+	}
+	store, err = NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(store.GetAll()); n != 0 {
+		t.Errorf("a new workspace should start empty, has %d forwards", n)
+	}
+	store.Close()
+
+	// A workspace created by a later process is listed too
+	if err := os.WriteFile(filepath.Join(home, ".kprtfwd", "workspaces", "b.db.enc"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	names, err := Workspaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{DefaultWorkspace, "b", "client-a"}; !slices.Equal(names, want) {
+		t.Errorf("Workspaces = %v, want %v", names, want)
+	}
+
+	if err := SetWorkspace(DefaultWorkspace); err != nil {
+		t.Fatal(err)
+	}
+	store, err = NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	if _, ok := store.GetConfigByID("ctx.ns.api"); !ok {
+		t.Error("the default workspace should keep its forwards")
+	}
+}
+
+func TestSetWorkspaceRejects(t *testing.T) {
+	t.Setenv(EnvWorkspace, "")
+	t.Setenv(EnvDB, "")
+	for _, name := range []string{"", "Client", "a/b", "../x"} {
+		if err := SetWorkspace(name); err == nil {
+			t.Errorf("SetWorkspace(%q) should fail", name)
+		}
+	}
+	t.Setenv(EnvDB, filepath.Join(t.TempDir(), "pinned.db"))
+	if err := SetWorkspace("client-a"); err == nil {
+		t.Error("workspaces must be refused while KPRTFWD_DB pins the database")
+	}
+}
@@ -0,0 +1,70 @@
+// Package devmode runs kprtfwd against a simulated cluster, for working on
+// the UI and for demos on machines without cluster access. Instead of
+// kubectl, kprtfwd runs itself: started with EnvKubectl set, the binary
+// answers kubectl's context, namespace, service, endpoint and pod lookups
+// from built-in fixtures, and its port-forwards serve a placeholder HTTP page
+// on the local port until they are stopped. The rest of kprtfwd — process
+// management, proxies, discovery — runs unchanged.
+package devmode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// EnvDev turns developer mode on for the TUI and every command; the TUI's
+// --dev flag does the same
+const EnvDev = "KPRTFWD_DEV"
+
+// EnvKubectl is set for the processes developer mode starts in place of
+// kubectl, so the binary knows to act as one
+const EnvKubectl = "KPRTFWD_DEV_KUBECTL"
+
+// devDatabase is the database developer mode uses, in ~/.kprtfwd, so demo
+// forwards never mix with real ones
+const devDatabase = "dev.db"
+
+// Enabled reports whether developer mode is on: KPRTFWD_DEV is set to
+// anything but "0" or "false", by the user or by Enable
+func Enabled() bool {
+	value := os.Getenv(EnvDev)
+	return value != "" && value != "0" && value != "false"
+}
+
+// IsKubectl reports whether this process was started in place of kubectl
+func IsKubectl() bool {
+	return os.Getenv(EnvKubectl) != ""
+}
+
+// Enable turns developer mode on for this process and the ones it starts:
+// kubectl is this binary acting as one, and unless KPRTFWD_DB names another,
+// the database is ~/.kprtfwd/dev.db. It must run before the config store is
+// opened.
+func Enable() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("cannot find the kprtfwd binary to simulate kubectl with: %w", err)
+	}
+	if os.Getenv(config.EnvDB) == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return fmt.Errorf("failed to get user home directory: %w", err)
+		}
+		if err := os.Setenv(config.EnvDB, filepath.Join(homeDir, ".kprtfwd", devDatabase)); err != nil {
+			return err
+		}
+	}
+	for name, value := range map[string]string{
+		EnvDev:     "1",
+		EnvKubectl: "1",
+		config.SettingEnvVar(config.SettingKubectlPath): exe,
+	} {
+		if err := os.Setenv(name, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
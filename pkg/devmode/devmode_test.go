@@ -0,0 +1,117 @@
+package devmode
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// runFake runs the simulated kubectl and returns its stdout, or fails the
+// test with its stderr
+func runFake(t *testing.T, args ...string) string {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	if code := RunKubectl(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("kubectl %s exited %d: %s", strings.Join(args, " "), code, stderr.String())
+	}
+	return stdout.String()
+}
+
+func TestFakeKubectlAnswersDiscovery(t *testing.T) {
+	if got := strings.TrimSpace(runFake(t, "config", "current-context")); got != "demo-dev" {
+		t.Errorf("current context = %q", got)
+	}
+	if got := strings.Fields(runFake(t, "config", "get-contexts", "-o", "name")); len(got) != 2 || got[1] != "demo-staging" {
+		t.Errorf("contexts = %v", got)
+	}
+	if got := runFake(t, "--context", "demo-staging", "get", "namespaces", "-o", "jsonpath={.items[*].metadata.name}"); got != "default shop data" {
+		t.Errorf("namespaces = %q", got)
+	}
+
+	var list discovery.K8sServiceList
+	if err := json.Unmarshal([]byte(runFake(t, "--context", "demo-dev", "get", "services", "--namespace", "shop", "-o", "json")), &list); err != nil {
+		t.Fatal(err)
+	}
+	if len(list.Items) != 4 || list.Items[0].Metadata.Name != "frontend" || list.Items[0].Spec.Ports[0].TargetPort != "http" {
+		t.Errorf("shop services = %+v", list.Items)
+	}
+	if err := json.Unmarshal([]byte(runFake(t, "get", "services", "--all-namespaces", "-o", "json")), &list); err != nil || len(list.Items) != 11 {
+		t.Errorf("all services: %d, %v", len(list.Items), err)
+	}
+
+	var pods struct {
+		Items []struct {
+			Metadata struct{ Name string } `json:"metadata"`
+		} `json:"items"`
+	}
+	out := runFake(t, "--context", "demo-dev", "get", "pods", "--namespace", "data", "--selector", "app=postgres,role=primary", "-o", "json")
+	if err := json.Unmarshal([]byte(out), &pods); err != nil || len(pods.Items) != 1 || pods.Items[0].Metadata.Name != "postgres-0" {
+		t.Errorf("pods = %s (%v)", out, err)
+	}
+}
+
+func TestFakeKubectlFailsLikeKubectl(t *testing.T) {
+	for _, tc := range []struct {
+		args []string
+		kind error
+	}{
+		{[]string{"--context", "prod", "get", "services", "-o", "json"}, kubectl.ErrContextNotFound},
+		{[]string{"--context", "demo-dev", "get", "endpoints", "gone", "--namespace", "shop", "-o", "json"}, nil},
+		{[]string{"--context", "demo-dev", "port-forward", "--namespace", "shop", "svc/gone", "8080:80"}, kubectl.ErrTargetMissing},
+		{[]string{"--context", "demo-dev", "port-forward", "--namespace", "shop", "pod/gone-0", "8080:80"}, kubectl.ErrTargetMissing},
+		{[]string{"--context", "demo-dev", "port-forward", "--namespace", "shop", "deploy/gone", "8080:80"}, kubectl.ErrTargetMissing},
+		{[]string{"--context", "demo-dev", "port-forward", "--namespace", "shop", "svc/legacy-billing", "8080:8080"}, nil},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := RunKubectl(tc.args, &stdout, &stderr); code == 0 {
+			t.Errorf("kubectl %v should fail", tc.args)
+			continue
+		}
+		if tc.kind != nil && kubectl.KindOf(stderr.String()) != tc.kind {
+			t.Errorf("kubectl %v: %q is not classified as %v", tc.args, stderr.String(), tc.kind)
+		}
+	}
+}
+
+func TestFakePortForwardServesLocalPort(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	inv := parseArgs([]string{"--context", "demo-dev", "port-forward", "--namespace", "monitoring", "svc/grafana", strconv.Itoa(port) + ":80", "--address", "127.0.0.1"})
+	cluster, _ := findCluster("demo-dev")
+	var stdout bytes.Buffer
+	listeners, err := inv.portForward(cluster, "monitoring", &stdout)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		for _, l := range listeners {
+			l.Close()
+		}
+	})
+	if want := "Forwarding from 127.0.0.1:" + strconv.Itoa(port) + " -> 80"; !strings.Contains(stdout.String(), want) {
+		t.Errorf("stdout = %q, want %q", stdout.String(), want)
+	}
+
+	resp, err := http.Get("http://127.0.0.1:" + strconv.Itoa(port) + "/login")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), "service/grafana in namespace monitoring, port 80") || !strings.Contains(string(body), "GET /login") {
+		t.Errorf("page = %q", body)
+	}
+}
@@ -0,0 +1,163 @@
+package devmode
+
+import (
+	"fmt"
+	"strings"
+)
+
+// currentContext is the simulated kubeconfig's current context
+const currentContext = "demo-dev"
+
+// fixtureCluster is a simulated context and what is deployed in it
+type fixtureCluster struct {
+	context    string
+	namespaces []string
+	services   []fixtureService
+}
+
+// fixtureService is a simulated service. Its pods are named <name>-0,
+// <name>-1, ... and carry the label app=<name>, which the service selects,
+// plus role=primary on the first and role=replica on the others.
+type fixtureService struct {
+	namespace string
+	name      string
+	kind      string // service type, ClusterIP unless set
+	labels    map[string]string
+	ports     []fixturePort
+	pods      int // none makes every port-forward to it fail
+}
+
+// fixturePort is a port of a simulated service. A named target port is
+// looked up among the pods' container ports, where it is containerPort.
+type fixturePort struct {
+	name          string
+	port          int
+	targetPort    any // int or string, as in a service spec
+	containerPort int // for a named targetPort
+}
+
+// clusters are the contexts developer mode simulates
+var clusters = []fixtureCluster{
+	{
+		context:    "demo-dev",
+		namespaces: []string{"default", "shop", "data", "monitoring", "kube-system"},
+		services: []fixtureService{
+			{namespace: "default", name: "kubernetes", labels: map[string]string{"component": "apiserver"}, ports: []fixturePort{{name: "https", port: 443, targetPort: 6443}}, pods: 1},
+			{namespace: "shop", name: "frontend", labels: map[string]string{"app": "frontend", "tier": "web"}, ports: []fixturePort{{name: "http", port: 80, targetPort: "http", containerPort: 3000}}, pods: 2},
+			{namespace: "shop", name: "api", labels: map[string]string{"app": "api", "tier": "backend"}, ports: []fixturePort{{name: "http", port: 8080, targetPort: 8080}, {name: "grpc", port: 9090, targetPort: 9090}}, pods: 3},
+			{namespace: "shop", name: "payments", labels: map[string]string{"app": "payments", "tier": "backend"}, ports: []fixturePort{{name: "https", port: 8443, targetPort: 8443}}, pods: 2},
+			{namespace: "shop", name: "legacy-billing", labels: map[string]string{"app": "legacy-billing"}, ports: []fixturePort{{name: "http", port: 8080, targetPort: 8080}}},
+			{namespace: "data", name: "postgres", labels: map[string]string{"app": "postgres", "app.kubernetes.io/name": "postgresql"}, ports: []fixturePort{{name: "postgres", port: 5432, targetPort: 5432}}, pods: 2},
+			{namespace: "data", name: "redis", labels: map[string]string{"app": "redis"}, ports: []fixturePort{{name: "redis", port: 6379, targetPort: 6379}}, pods: 1},
+			{namespace: "data", name: "rabbitmq", labels: map[string]string{"app": "rabbitmq"}, ports: []fixturePort{{name: "amqp", port: 5672, targetPort: 5672}, {name: "management", port: 15672, targetPort: 15672}}, pods: 1},
+			{namespace: "monitoring", name: "grafana", labels: map[string]string{"app": "grafana"}, ports: []fixturePort{{name: "http", port: 80, targetPort: 3000}}, pods: 1},
+			{namespace: "monitoring", name: "prometheus", labels: map[string]string{"app": "prometheus"}, ports: []fixturePort{{name: "web", port: 9090, targetPort: 9090}}, pods: 1},
+			{namespace: "kube-system", name: "kube-dns", labels: map[string]string{"k8s-app": "kube-dns"}, ports: []fixturePort{{name: "dns-tcp", port: 53, targetPort: 53}, {name: "metrics", port: 9153, targetPort: 9153}}, pods: 2},
+		},
+	},
+	{
+		context:    "demo-staging",
+		namespaces: []string{"default", "shop", "data"},
+		services: []fixtureService{
+			{namespace: "default", name: "kubernetes", labels: map[string]string{"component": "apiserver"}, ports: []fixturePort{{name: "https", port: 443, targetPort: 6443}}, pods: 1},
+			{namespace: "shop", name: "frontend", labels: map[string]string{"app": "frontend", "tier": "web"}, ports: []fixturePort{{name: "http", port: 80, targetPort: "http", containerPort: 3000}}, pods: 2},
+			{namespace: "shop", name: "api", labels: map[string]string{"app": "api", "tier": "backend"}, ports: []fixturePort{{name: "http", port: 8080, targetPort: 8080}}, pods: 2},
+			{namespace: "data", name: "postgres", labels: map[string]string{"app": "postgres", "app.kubernetes.io/name": "postgresql"}, ports: []fixturePort{{name: "postgres", port: 5432, targetPort: 5432}}, pods: 1},
+			{namespace: "data", name: "redis", kind: "NodePort", labels: map[string]string{"app": "redis"}, ports: []fixturePort{{name: "redis", port: 6379, targetPort: 6379}}, pods: 1},
+		},
+	},
+}
+
+// findCluster returns the simulated context named name, the current one if
+// name is empty
+func findCluster(name string) (*fixtureCluster, error) {
+	if name == "" {
+		name = currentContext
+	}
+	for i := range clusters {
+		if clusters[i].context == name {
+			return &clusters[i], nil
+		}
+	}
+	return nil, fmt.Errorf("context %q does not exist", name)
+}
+
+// service returns the simulated service namespace/name
+func (c *fixtureCluster) service(namespace, name string) (*fixtureService, bool) {
+	for i := range c.services {
+		if s := &c.services[i]; s.namespace == namespace && s.name == name {
+			return s, true
+		}
+	}
+	return nil, false
+}
+
+// podService returns the simulated service a pod of namespace belongs to
+func (c *fixtureCluster) podService(namespace, pod string) (*fixtureService, bool) {
+	for i := range c.services {
+		s := &c.services[i]
+		if s.namespace != namespace {
+			continue
+		}
+		for _, name := range s.podNames() {
+			if name == pod {
+				return s, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// podNames returns the names of the service's pods
+func (s *fixtureService) podNames() []string {
+	names := make([]string, s.pods)
+	for i := range names {
+		names[i] = fmt.Sprintf("%s-%d", s.name, i)
+	}
+	return names
+}
+
+// podLabels returns the labels of the service's pod number i
+func (s *fixtureService) podLabels(i int) map[string]string {
+	role := "replica"
+	if i == 0 {
+		role = "primary"
+	}
+	return map[string]string{"app": s.name, "role": role}
+}
+
+// targetPort returns the pod port the service sends port to
+func (s *fixtureService) targetPort(port int) (int, bool) {
+	for _, p := range s.ports {
+		if p.port != port {
+			continue
+		}
+		if n, ok := p.targetPort.(int); ok {
+			return n, true
+		}
+		return p.containerPort, true
+	}
+	return 0, false
+}
+
+// forwardFailure returns why a port-forward to the service fails, or ""
+func (s *fixtureService) forwardFailure() string {
+	if s.pods == 0 {
+		return fmt.Sprintf("error: unable to forward port because pod is not running. Current status=Pending (service %s has no ready pods)", s.name)
+	}
+	return ""
+}
+
+// matchesSelector reports whether labels has every key=value of selector
+func matchesSelector(labels map[string]string, selector string) bool {
+	for _, term := range strings.Split(selector, ",") {
+		if term == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(term, "=")
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
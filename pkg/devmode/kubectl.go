@@ -0,0 +1,315 @@
+package devmode
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// valueFlags are the kubectl flags kprtfwd passes that take a value
+var valueFlags = map[string]string{
+	"--context":               "context",
+	"--kubeconfig":            "kubeconfig",
+	"--certificate-authority": "certificate-authority",
+	"--namespace":             "namespace",
+	"-n":                      "namespace",
+	"--output":                "output",
+	"-o":                      "output",
+	"--selector":              "selector",
+	"-l":                      "selector",
+	"--address":               "address",
+}
+
+// invocation is a parsed kubectl command line
+type invocation struct {
+	words         []string          // the command and its arguments
+	flags         map[string]string // valueFlags by their long name
+	allNamespaces bool
+}
+
+// parseArgs splits a kubectl command line into words and the flags that
+// matter to the simulation; other flags are accepted and ignored
+func parseArgs(args []string) invocation {
+	inv := invocation{flags: make(map[string]string)}
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "-A" || arg == "--all-namespaces" {
+			inv.allNamespaces = true
+			continue
+		}
+		if !strings.HasPrefix(arg, "-") {
+			inv.words = append(inv.words, arg)
+			continue
+		}
+		name, value, hasValue := strings.Cut(arg, "=")
+		long, known := valueFlags[name]
+		switch {
+		case !known:
+		case hasValue:
+			inv.flags[long] = value
+		case i+1 < len(args):
+			inv.flags[long] = args[i+1]
+			i++
+		}
+	}
+	return inv
+}
+
+// RunKubectl acts as kubectl run with args, answering from the fixtures of
+// the simulated cluster, and returns the exit code. A port-forward that
+// starts serves until the process is killed, so it never returns.
+func RunKubectl(args []string, stdout, stderr io.Writer) int {
+	inv := parseArgs(args)
+	if err := inv.run(stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
+
+func (inv invocation) run(stdout io.Writer) error {
+	command := strings.Join(inv.words, " ")
+	switch {
+	case command == "config current-context":
+		fmt.Fprintln(stdout, currentContext)
+		return nil
+	case command == "config get-contexts":
+		for _, c := range clusters {
+			fmt.Fprintln(stdout, c.context)
+		}
+		return nil
+	case command == "config view":
+		return nil // no context sets a namespace: kprtfwd assumes "default"
+	case command == "version":
+		return writeJSON(stdout, map[string]any{"clientVersion": map[string]any{"gitVersion": "v1.30.0-kprtfwd-dev"}})
+	}
+
+	cluster, err := findCluster(inv.flags["context"])
+	if err != nil {
+		return fmt.Errorf("error: %w", err)
+	}
+	namespace := inv.flags["namespace"]
+	if namespace == "" {
+		namespace = "default"
+	}
+	if len(inv.words) < 2 {
+		return fmt.Errorf("error: %q is not simulated in developer mode", command)
+	}
+	name := ""
+	if len(inv.words) > 2 {
+		name = inv.words[2]
+	}
+
+	switch verb, resource := inv.words[0], inv.words[1]; {
+	case verb == "get" && (resource == "namespaces" || resource == "namespace" || resource == "ns"):
+		fmt.Fprint(stdout, strings.Join(cluster.namespaces, " "))
+		return nil
+	case verb == "get" && (resource == "services" || resource == "service" || resource == "svc"):
+		if name != "" {
+			svc, ok := cluster.service(namespace, name)
+			if !ok {
+				return fmt.Errorf("Error from server (NotFound): services %q not found", name)
+			}
+			return writeJSON(stdout, serviceJSON(svc))
+		}
+		var items []any
+		for i := range cluster.services {
+			if svc := &cluster.services[i]; inv.allNamespaces || svc.namespace == namespace {
+				items = append(items, serviceJSON(svc))
+			}
+		}
+		return writeJSON(stdout, listJSON(items))
+	case verb == "get" && (resource == "endpoints" || resource == "endpoint" || resource == "ep"):
+		svc, ok := cluster.service(namespace, name)
+		if !ok {
+			return fmt.Errorf("Error from server (NotFound): endpoints %q not found", name)
+		}
+		return writeJSON(stdout, endpointsJSON(svc))
+	case verb == "get" && (resource == "pods" || resource == "pod" || resource == "po"):
+		var items []any
+		for i := range cluster.services {
+			svc := &cluster.services[i]
+			if svc.namespace != namespace {
+				continue
+			}
+			for j, pod := range svc.podNames() {
+				if matchesSelector(svc.podLabels(j), inv.flags["selector"]) {
+					items = append(items, podJSON(svc, j, pod))
+				}
+			}
+		}
+		return writeJSON(stdout, listJSON(items))
+	case verb == "port-forward":
+		if _, err := inv.portForward(cluster, namespace, stdout); err != nil {
+			return err
+		}
+		select {} // until killed, like kubectl
+	}
+	return fmt.Errorf("error: %q is not simulated in developer mode", command)
+}
+
+// portForward listens on the local ports of a "port-forward TARGET
+// LOCAL:REMOTE..." invocation and serves a page naming the forward's target
+// on each, as if the pod answered. A deployment or statefulset is the
+// workload behind the service of its name. It fails like kubectl when the
+// target is missing, has no running pod or lacks the remote port.
+func (inv invocation) portForward(cluster *fixtureCluster, namespace string, stdout io.Writer) ([]net.Listener, error) {
+	if len(inv.words) < 3 {
+		return nil, fmt.Errorf("error: TYPE/NAME and list of ports are required for port-forward")
+	}
+	kind, name, _ := strings.Cut(inv.words[1], "/")
+	var svc *fixtureService
+	var ok bool
+	switch kind {
+	case "svc", "service", "services":
+		kind = "service"
+		if svc, ok = cluster.service(namespace, name); !ok {
+			return nil, fmt.Errorf("Error from server (NotFound): services %q not found", name)
+		}
+	case "pod", "pods", "po":
+		kind = "pod"
+		if svc, ok = cluster.podService(namespace, name); !ok {
+			return nil, fmt.Errorf("Error from server (NotFound): pods %q not found", name)
+		}
+	case "deploy", "deployment", "deployments", "sts", "statefulset", "statefulsets":
+		kind = "deployment"
+		if strings.HasPrefix(inv.words[1], "s") {
+			kind = "statefulset"
+		}
+		if svc, ok = cluster.service(namespace, name); !ok {
+			return nil, fmt.Errorf("Error from server (NotFound): %ss.apps %q not found", kind, name)
+		}
+	default:
+		return nil, fmt.Errorf("error: %q is not simulated in developer mode", inv.words[1])
+	}
+	if failure := svc.forwardFailure(); failure != "" {
+		return nil, fmt.Errorf("%s", failure)
+	}
+
+	hosts := []string{"127.0.0.1", "::1"}
+	if address := inv.flags["address"]; address != "" {
+		hosts = strings.Split(address, ",")
+	}
+	var listeners []net.Listener
+	fail := func(err error) ([]net.Listener, error) {
+		for _, l := range listeners {
+			l.Close()
+		}
+		return nil, err
+	}
+	for _, pair := range inv.words[2:] {
+		localText, remoteText, _ := strings.Cut(pair, ":")
+		local, err1 := strconv.Atoi(localText)
+		remote, err2 := strconv.Atoi(remoteText)
+		if err1 != nil || err2 != nil {
+			return fail(fmt.Errorf("error: invalid port pair %q", pair))
+		}
+		if kind != "service" && !svc.hasPodPort(remote) || kind == "service" && !slices.ContainsFunc(svc.ports, func(p fixturePort) bool { return p.port == remote }) {
+			return fail(fmt.Errorf("error: %s %s/%s does not have port %d", kind, namespace, name, remote))
+		}
+		page := fmt.Sprintf("kprtfwd developer mode\n\n%s/%s in namespace %s, port %d, context %s (simulated)\n", kind, name, namespace, remote, cluster.context)
+		for _, host := range hosts {
+			if host == "localhost" {
+				host = "127.0.0.1"
+			}
+			l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(local)))
+			if err != nil {
+				if host == "::1" && inv.flags["address"] == "" {
+					continue // no IPv6 loopback; kubectl carries on as well
+				}
+				return fail(fmt.Errorf("unable to listen on port %d: %w", local, err))
+			}
+			listeners = append(listeners, l)
+			go http.Serve(l, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+				fmt.Fprintf(w, "%sRequested: %s %s\n", page, r.Method, r.URL.Path)
+			}))
+			fmt.Fprintf(stdout, "Forwarding from %s -> %d\n", l.Addr(), remote)
+		}
+	}
+	return listeners, nil
+}
+
+// hasPodPort reports whether the service's pods listen on port
+func (s *fixtureService) hasPodPort(port int) bool {
+	for _, p := range s.ports {
+		if target, _ := s.targetPort(p.port); target == port {
+			return true
+		}
+	}
+	return false
+}
+
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	return enc.Encode(v)
+}
+
+func listJSON(items []any) map[string]any {
+	if items == nil {
+		items = []any{}
+	}
+	return map[string]any{"apiVersion": "v1", "kind": "List", "items": items}
+}
+
+func serviceJSON(s *fixtureService) map[string]any {
+	kind := s.kind
+	if kind == "" {
+		kind = "ClusterIP"
+	}
+	ports := make([]any, len(s.ports))
+	for i, p := range s.ports {
+		ports[i] = map[string]any{"name": p.name, "port": p.port, "protocol": "TCP", "targetPort": p.targetPort}
+	}
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Service",
+		"metadata":   map[string]any{"name": s.name, "namespace": s.namespace, "labels": s.labels},
+		"spec":       map[string]any{"type": kind, "ports": ports, "selector": map[string]string{"app": s.name}},
+	}
+}
+
+func endpointsJSON(s *fixtureService) map[string]any {
+	addresses := make([]any, 0, s.pods)
+	for i, pod := range s.podNames() {
+		addresses = append(addresses, map[string]any{
+			"ip":        fmt.Sprintf("10.244.0.%d", 10+i),
+			"targetRef": map[string]any{"kind": "Pod", "name": pod, "namespace": s.namespace},
+		})
+	}
+	subsets := []any{}
+	if len(addresses) > 0 {
+		subsets = append(subsets, map[string]any{"addresses": addresses})
+	}
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Endpoints",
+		"metadata":   map[string]any{"name": s.name, "namespace": s.namespace},
+		"subsets":    subsets,
+	}
+}
+
+func podJSON(s *fixtureService, i int, name string) map[string]any {
+	var containerPorts []any
+	for _, p := range s.ports {
+		target, _ := s.targetPort(p.port)
+		port := map[string]any{"containerPort": target, "protocol": "TCP"}
+		if name, ok := p.targetPort.(string); ok {
+			port["name"] = name
+		}
+		containerPorts = append(containerPorts, port)
+	}
+	return map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata":   map[string]any{"name": name, "namespace": s.namespace, "labels": s.podLabels(i)},
+		"spec":       map[string]any{"containers": []any{map[string]any{"name": s.name, "ports": containerPorts}}},
+		"status":     map[string]any{"phase": "Running"},
+	}
+}
@@ -0,0 +1,69 @@
+package discovery
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// installRBACKubectl puts a fake kubectl on PATH for a user who may list
+// services in team-a only: cluster-wide lists and team-b are forbidden.
+// namespaces is what 'get namespaces' prints, or forbidden if empty.
+func installRBACKubectl(t *testing.T, namespaces string) {
+	t.Helper()
+	listNamespaces := `echo 'Error from server (Forbidden): namespaces is forbidden: User "dev" cannot list resource "namespaces" in API group "" at the cluster scope' >&2; exit 1`
+	if namespaces != "" {
+		listNamespaces = "echo " + namespaces
+	}
+	script := `#!/bin/sh
+case "$*" in
+*"get namespaces"*) ` + listNamespaces + ` ;;
+*"config view"*) echo team-a ;;
+*"--all-namespaces"*|*"--namespace team-b"*)
+	echo 'Error from server (Forbidden): services is forbidden: User "dev" cannot list resource "services"' >&2; exit 1 ;;
+*"--namespace team-a"*)
+	echo '{"items":[{"metadata":{"name":"api","namespace":"team-a"},"spec":{"ports":[{"port":80}]}}]}' ;;
+*) exit 1 ;;
+esac
+`
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
+
+func TestDiscoverServicesFallsBackWhenClusterListForbidden(t *testing.T) {
+	installRBACKubectl(t, "team-a team-b")
+
+	result, err := DiscoverServices(Options{Context: "ctx", NamespaceFilter: "*"})
+	if err != nil {
+		t.Fatalf("discovery should fall back to per-namespace queries: %v", err)
+	}
+	if result.TotalCount != 1 || result.Services[0].ServiceInfo.Name != "api" {
+		t.Fatalf("expected the team-a service, got %+v", result.Services)
+	}
+	if len(result.Forbidden) != 1 || result.Forbidden[0] != "team-b" {
+		t.Fatalf("Forbidden = %v, want [team-b]", result.Forbidden)
+	}
+}
+
+func TestDiscoverServicesGuessesNamespaceWhenListingForbidden(t *testing.T) {
+	installRBACKubectl(t, "")
+
+	result, err := DiscoverServices(Options{Context: "ctx", NamespaceFilter: "team-*"})
+	if err != nil {
+		t.Fatalf("discovery should use the context's namespace: %v", err)
+	}
+	if result.TotalCount != 1 {
+		t.Fatalf("expected the team-a service, got %+v", result.Services)
+	}
+
+	_, err = DiscoverServices(Options{Context: "ctx", NamespaceFilter: "team-b"})
+	if !errors.Is(err, kubectl.ErrForbidden) {
+		t.Fatalf("a forbidden namespace should report ErrForbidden, got %v", err)
+	}
+}
@@ -1,15 +1,16 @@
 package discovery
 
 import (
-	"bytes"
-	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"slices"
+	"sort"
 	"strings"
-	"time"
+	"sync"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 )
 
@@ -57,6 +58,11 @@ func DiscoverServices(opts Options) (*DiscoveryResult, error) {
 
 	// Discover namespaces that match the filter
 	namespaces, err := discoverNamespaces(context, opts.NamespaceFilter)
+	if errors.Is(err, kubectl.ErrForbidden) {
+		// Users limited to some namespaces usually cannot list them either
+		logging.LogDebug("Discovery: listing namespaces in %s is forbidden, guessing the accessible ones", context)
+		namespaces, err = guessAccessibleNamespaces(context, opts.NamespaceFilter)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to discover namespaces: %w", err)
 	}
@@ -66,10 +72,32 @@ func DiscoverServices(opts Options) (*DiscoveryResult, error) {
 	}
 
 	// For efficiency with large clusters, get all services at once and filter by namespace
-	// This is much faster than making individual calls for each namespace
-	allServices, err := getAllServicesInContext(context)
+	// This is much faster than making individual calls for each namespace -
+	// unless a filter narrows it down to a handful of namespaces, where listing
+	// every service of a shared cluster would be the slow part.
+	var allServices []ServiceInfo
+	var forbidden []string
+	if opts.NamespaceFilter != "*" && len(namespaces) <= perNamespaceLookupMax {
+		allServices, forbidden, err = getServicesPerNamespace(context, namespaces)
+	} else {
+		allServices, err = getServices(context, "")
+		if errors.Is(err, kubectl.ErrForbidden) {
+			// RBAC-restricted users may list services only in the namespaces
+			// they were given; ask each one and keep what is allowed
+			logging.LogDebug("Discovery: listing services across %s is forbidden, querying %d namespaces one by one", context, len(namespaces))
+			allServices, forbidden, err = getServicesPerNamespace(context, namespaces)
+		} else if err != nil {
+			err = fmt.Errorf("failed to get services: %w", err)
+		}
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get services: %w", err)
+		return nil, err
+	}
+	if len(forbidden) > 0 {
+		logging.LogDebug("Discovery: services forbidden in %d namespaces of %s: %s", len(forbidden), context, strings.Join(forbidden, ", "))
+		if opts.Verbose {
+			fmt.Printf("🔒 Skipped %d namespace(s) you may not list services in\n", len(forbidden))
+		}
 	}
 
 	// Filter services to only include those in matching namespaces
@@ -104,6 +132,7 @@ func DiscoverServices(opts Options) (*DiscoveryResult, error) {
 			TotalCount:      0,
 			Context:         context,
 			NamespaceFilter: opts.NamespaceFilter,
+			Forbidden:       forbidden,
 		}, nil
 	}
 
@@ -131,68 +160,91 @@ func DiscoverServices(opts Options) (*DiscoveryResult, error) {
 		TotalCount:      len(discoveredServices),
 		Context:         context,
 		NamespaceFilter: opts.NamespaceFilter,
+		Forbidden:       forbidden,
 	}, nil
 }
 
 // CurrentContext gets the current kubectl context
 func CurrentContext() (string, error) {
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "kubectl", "config", "current-context")
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	out, err := kubectl.Run(kubectl.CmdCurrentContext, "config", "current-context")
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return "", fmt.Errorf("kubectl current-context timed out after 10 seconds")
-		}
-		return "", fmt.Errorf("kubectl current-context failed: %w (stderr: %s)", err, stderr.String())
+		return "", err
 	}
 
-	context := strings.TrimSpace(stdout.String())
+	context := strings.TrimSpace(string(out))
 	if context == "" {
-		return "", fmt.Errorf("no current context set")
+		return "", fmt.Errorf("no current context set: %w", kubectl.ErrContextNotFound)
 	}
 
 	return context, nil
 }
 
+// k8sEndpoints is the part of kubectl get endpoints output naming the pods
+// behind a service
+type k8sEndpoints struct {
+	Subsets []struct {
+		Addresses []struct {
+			TargetRef *struct {
+				Kind string `json:"kind"`
+				Name string `json:"name"`
+			} `json:"targetRef"`
+		} `json:"addresses"`
+	} `json:"subsets"`
+}
+
+// ServicePods returns the sorted names of the ready pods behind a service, as
+// listed by its endpoints. kubeconfig is the file a forward's context was
+// found in, if recorded (see kubectl.KubeconfigArgs).
+func ServicePods(kubeContext, kubeconfig, namespace, service string) ([]string, error) {
+	if err := config.ValidateContextName(kubeContext); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateKubernetesName("namespace", namespace); err != nil {
+		return nil, err
+	}
+	if err := config.ValidateKubernetesName("service", service); err != nil {
+		return nil, err
+	}
+
+	args := append(kubectl.KubeconfigArgs(kubeconfig), "get", "endpoints", service, "--namespace", namespace, "-o", "json")
+	args = append(kubectl.ContextArgs(kubeContext), args...)
+	out, err := kubectl.Run(kubectl.CmdGetEndpoints, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints k8sEndpoints
+	if err := json.Unmarshal(out, &endpoints); err != nil {
+		return nil, fmt.Errorf("failed to parse kubectl output: %w", err)
+	}
+	var pods []string
+	for _, subset := range endpoints.Subsets {
+		for _, address := range subset.Addresses {
+			if address.TargetRef != nil && address.TargetRef.Kind == "Pod" {
+				pods = append(pods, address.TargetRef.Name)
+			}
+		}
+	}
+	sort.Strings(pods)
+	return slices.Compact(pods), nil // a pod serving several ports is listed once per subset
+}
+
 // discoverNamespaces finds namespaces matching the given filter pattern
 func discoverNamespaces(kubeContext, filter string) ([]string, error) {
 	if err := config.ValidateContextName(kubeContext); err != nil {
 		return nil, err
 	}
 
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
 	// Get all namespaces
 	args := []string{"get", "namespaces", "-o", "jsonpath={.items[*].metadata.name}"}
-	if kubeContext != "" {
-		args = append([]string{"--context", kubeContext}, args...)
-	}
-
-	cmd := exec.CommandContext(ctx, "kubectl", args...)
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
+	args = append(kubectl.ContextArgs(kubeContext), args...)
 
-	err := cmd.Run()
+	out, err := kubectl.Run(kubectl.CmdGetNamespaces, args...)
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("kubectl get namespaces timed out after 30 seconds")
-		}
-		return nil, fmt.Errorf("kubectl get namespaces failed: %w (stderr: %s)", err, stderr.String())
+		return nil, err
 	}
 
-	allNamespaces := strings.Fields(stdout.String())
+	allNamespaces := strings.Fields(string(out))
 	if len(allNamespaces) == 0 {
 		return nil, fmt.Errorf("no namespaces found")
 	}
@@ -212,39 +264,101 @@ func discoverNamespaces(kubeContext, filter string) ([]string, error) {
 	return matchingNamespaces, nil
 }
 
-// getAllServicesInContext retrieves all services from all namespaces in a context
-// This is much more efficient than calling getServicesInNamespace for each namespace individually
-func getAllServicesInContext(kubeContext string) ([]ServiceInfo, error) {
-	if err := config.ValidateContextName(kubeContext); err != nil {
+// perNamespaceLookupMax is the most namespaces DiscoverServices queries one
+// by one; for more, one --all-namespaces call is cheaper.
+const perNamespaceLookupMax = 10
+
+// perNamespaceLookupWorkers is how many namespaces getServicesPerNamespace
+// queries at once
+const perNamespaceLookupWorkers = 8
+
+// getServicesPerNamespace retrieves the services of each namespace with its
+// own kubectl call. Namespaces the user may not list services in are skipped
+// and returned as forbidden; it fails only if every namespace is forbidden or
+// another error occurs.
+func getServicesPerNamespace(kubeContext string, namespaces []string) ([]ServiceInfo, []string, error) {
+	results := make([][]ServiceInfo, len(namespaces))
+	errs := make([]error, len(namespaces))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, perNamespaceLookupWorkers)
+	for i, namespace := range namespaces {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			results[i], errs[i] = getServices(kubeContext, namespace)
+		}()
+	}
+	wg.Wait()
+
+	var services []ServiceInfo
+	var forbidden []string
+	var firstForbidden error
+	for i, namespace := range namespaces {
+		switch err := errs[i]; {
+		case errors.Is(err, kubectl.ErrForbidden):
+			forbidden = append(forbidden, namespace)
+			if firstForbidden == nil {
+				firstForbidden = err
+			}
+		case err != nil:
+			return nil, nil, fmt.Errorf("failed to get services in namespace %s: %w", namespace, err)
+		default:
+			services = append(services, results[i]...)
+		}
+	}
+	if len(namespaces) > 0 && len(forbidden) == len(namespaces) {
+		return nil, forbidden, fmt.Errorf("not allowed to list services in any matching namespace: %w", firstForbidden)
+	}
+	return services, forbidden, nil
+}
+
+// guessAccessibleNamespaces stands in for discoverNamespaces when listing
+// namespaces is forbidden: a filter without wildcards names its namespace,
+// otherwise the context's default namespace is used if the filter matches it
+func guessAccessibleNamespaces(kubeContext, filter string) ([]string, error) {
+	if filter != "" && !strings.Contains(filter, "*") {
+		if err := config.ValidateKubernetesName("namespace", filter); err != nil {
+			return nil, err
+		}
+		return []string{filter}, nil
+	}
+	namespace, err := kubectl.ContextNamespace(kubeContext)
+	if err != nil {
 		return nil, err
 	}
+	if !MatchesWildcardPattern(namespace, filter) {
+		return nil, fmt.Errorf("not allowed to list namespaces and no namespace matching '%s' is known; name the namespace in the filter: %w", filter, kubectl.ErrForbidden)
+	}
+	return []string{namespace}, nil
+}
 
-	// Create context with timeout - use longer timeout since this gets all services
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+// getServices retrieves the services of one namespace in a context, or of all
+// namespaces if namespace is empty
+func getServices(kubeContext, namespace string) ([]ServiceInfo, error) {
+	if err := config.ValidateContextName(kubeContext); err != nil {
+		return nil, err
+	}
 
 	args := []string{"get", "services", "--all-namespaces", "-o", "json"}
-	if kubeContext != "" {
-		args = append([]string{"--context", kubeContext}, args...)
+	if namespace != "" {
+		if err := config.ValidateKubernetesName("namespace", namespace); err != nil {
+			return nil, err
+		}
+		args = []string{"get", "services", "--namespace", namespace, "-o", "json"}
 	}
+	args = append(kubectl.ContextArgs(kubeContext), args...)
 
-	cmd := exec.CommandContext(ctx, "kubectl", args...)
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	// Listing every service is the slowest call; see kubectl.CmdGetServices timeout
+	out, err := kubectl.Run(kubectl.CmdGetServices, args...)
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("kubectl get services --all-namespaces timed out after 60 seconds")
-		}
-		return nil, fmt.Errorf("kubectl get services --all-namespaces failed: %w (stderr: %s)", err, stderr.String())
+		return nil, err
 	}
 
 	// Parse JSON response
 	var serviceList K8sServiceList
-	err = json.Unmarshal(stdout.Bytes(), &serviceList)
+	err = json.Unmarshal(out, &serviceList)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse kubectl output: %w", err)
 	}
@@ -252,59 +366,65 @@ func getAllServicesInContext(kubeContext string) ([]ServiceInfo, error) {
 	// Convert to our ServiceInfo format
 	var services []ServiceInfo
 	for _, k8sService := range serviceList.Items {
-		// Trust boundary: names come from cluster output and end up persisted
-		// and on future kubectl command lines. Skip anything malformed.
-		if err := config.ValidateKubernetesName("namespace", k8sService.Metadata.Namespace); err != nil {
-			logging.LogError("Discovery: skipping service %q: %v", k8sService.Metadata.Name, err)
-			continue
-		}
-		if err := config.ValidateKubernetesName("service", k8sService.Metadata.Name); err != nil {
-			logging.LogError("Discovery: skipping service in namespace %q: %v", k8sService.Metadata.Namespace, err)
-			continue
+		if service, ok := serviceInfo(k8sService); ok {
+			services = append(services, service)
 		}
+	}
 
-		// Convert ports
-		var ports []ServicePort
-		for _, k8sPort := range k8sService.Spec.Ports {
-			targetPort := ""
-			if k8sPort.TargetPort != nil {
-				switch tp := k8sPort.TargetPort.(type) {
-				case float64:
-					targetPort = fmt.Sprintf("%.0f", tp)
-				case string:
-					targetPort = tp
-				default:
-					targetPort = fmt.Sprintf("%v", tp)
-				}
-			}
+	return services, nil
+}
 
-			port := ServicePort{
-				Name:       k8sPort.Name,
-				Port:       k8sPort.Port,
-				TargetPort: targetPort,
-				Protocol:   k8sPort.Protocol,
-			}
-			ports = append(ports, port)
-		}
+// serviceInfo converts a service as kubectl prints it to a ServiceInfo. It
+// reports false for services with malformed names or without ports.
+func serviceInfo(k8sService K8sService) (ServiceInfo, bool) {
+	// Trust boundary: names come from cluster output and end up persisted
+	// and on future kubectl command lines. Skip anything malformed.
+	if err := config.ValidateKubernetesName("namespace", k8sService.Metadata.Namespace); err != nil {
+		logging.LogError("Discovery: skipping service %q: %v", k8sService.Metadata.Name, err)
+		return ServiceInfo{}, false
+	}
+	if err := config.ValidateKubernetesName("service", k8sService.Metadata.Name); err != nil {
+		logging.LogError("Discovery: skipping service in namespace %q: %v", k8sService.Metadata.Namespace, err)
+		return ServiceInfo{}, false
+	}
 
-		// Skip services without ports
-		if len(ports) == 0 {
-			continue
+	// Convert ports
+	var ports []ServicePort
+	for _, k8sPort := range k8sService.Spec.Ports {
+		targetPort := ""
+		if k8sPort.TargetPort != nil {
+			switch tp := k8sPort.TargetPort.(type) {
+			case float64:
+				targetPort = fmt.Sprintf("%.0f", tp)
+			case string:
+				targetPort = tp
+			default:
+				targetPort = fmt.Sprintf("%v", tp)
+			}
 		}
 
-		service := ServiceInfo{
-			Name:        k8sService.Metadata.Name,
-			Namespace:   k8sService.Metadata.Namespace,
-			Ports:       ports,
-			Labels:      k8sService.Metadata.Labels,
-			Annotations: k8sService.Metadata.Annotations,
-			Type:        k8sService.Spec.Type,
+		port := ServicePort{
+			Name:       k8sPort.Name,
+			Port:       k8sPort.Port,
+			TargetPort: targetPort,
+			Protocol:   k8sPort.Protocol,
 		}
+		ports = append(ports, port)
+	}
 
-		services = append(services, service)
+	// Skip services without ports
+	if len(ports) == 0 {
+		return ServiceInfo{}, false
 	}
 
-	return services, nil
+	return ServiceInfo{
+		Name:        k8sService.Metadata.Name,
+		Namespace:   k8sService.Metadata.Namespace,
+		Ports:       ports,
+		Labels:      k8sService.Metadata.Labels,
+		Annotations: k8sService.Metadata.Annotations,
+		Type:        k8sService.Spec.Type,
+	}, true
 }
 
 // MatchesWildcardPattern checks if a string matches a wildcard pattern
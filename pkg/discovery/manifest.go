@@ -0,0 +1,118 @@
+package discovery
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.yaml.in/yaml/v3"
+)
+
+// ParseManifests finds the Services in rendered Kubernetes manifests, such as
+// the output of `helm template` or `kustomize build`, and returns them as a
+// discovery result for kubeContext with every service selected, so
+// GenerateConfig proposes a forward for each port. Services without a
+// namespace get namespace, as `kubectl apply -n` would give them.
+// ExternalName services are left out: they have no pods to forward to.
+//
+// data is multi-document YAML or JSON (a single object or a List).
+func ParseManifests(data []byte, kubeContext, namespace string) (*DiscoveryResult, error) {
+	var objects []any
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		dec := json.NewDecoder(bytes.NewReader(trimmed))
+		for {
+			var obj any
+			if err := dec.Decode(&obj); errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				return nil, fmt.Errorf("failed to parse JSON manifest: %w", err)
+			}
+			objects = append(objects, obj)
+		}
+	} else {
+		docs, err := parseYAMLDocuments(data)
+		if err != nil {
+			return nil, err
+		}
+		objects = docs
+	}
+
+	result := &DiscoveryResult{Context: kubeContext, NamespaceFilter: namespace}
+	for _, obj := range manifestItems(objects) {
+		raw, err := json.Marshal(obj)
+		if err != nil {
+			continue
+		}
+		var k8sService K8sService
+		if err := json.Unmarshal(raw, &k8sService); err != nil || k8sService.Kind != "Service" {
+			continue
+		}
+		if k8sService.Spec.Type == "ExternalName" {
+			continue
+		}
+		if k8sService.Metadata.Namespace == "" {
+			k8sService.Metadata.Namespace = namespace
+		}
+		if service, ok := serviceInfo(k8sService); ok {
+			result.Services = append(result.Services, DiscoveredService{ServiceInfo: service, Selected: true})
+		}
+	}
+	result.TotalCount = len(result.Services)
+	result.SelectedCount = len(result.Services)
+	return result, nil
+}
+
+// manifestItems flattens the items of List objects into the objects
+func manifestItems(objects []any) []any {
+	var items []any
+	for _, obj := range objects {
+		m, ok := obj.(map[string]any)
+		if !ok {
+			continue
+		}
+		if list, ok := m["items"].([]any); ok && strings.HasSuffix(fmt.Sprint(m["kind"]), "List") {
+			items = append(items, manifestItems(list)...)
+			continue
+		}
+		items = append(items, m)
+	}
+	return items
+}
+
+// ParseYAML parses a YAML stream into one value per document, as
+// encoding/json would decode the JSON form of each: mappings become
+// map[string]any and numbers float64. Empty documents are skipped.
+func ParseYAML(data []byte) ([]any, error) {
+	return parseYAMLDocuments(data)
+}
+
+// parseYAMLDocuments decodes the documents of a YAML stream and passes each
+// through its JSON form, so callers see what encoding/json would give them
+func parseYAMLDocuments(data []byte) ([]any, error) {
+	var docs []any
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc any
+		if err := dec.Decode(&doc); errors.Is(err, io.EOF) {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to parse YAML: %w", err)
+		}
+		if doc == nil {
+			continue
+		}
+		raw, err := json.Marshal(doc)
+		if err != nil {
+			return nil, fmt.Errorf("YAML document %d has no JSON form (keys must be strings): %w", len(docs)+1, err)
+		}
+		var value any
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, err
+		}
+		docs = append(docs, value)
+	}
+	return docs, nil
+}
@@ -0,0 +1,238 @@
+package discovery
+
+import (
+	"encoding/json"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+const helmOutput = `---
+# Source: shop/templates/api-service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: shop-api
+  labels:
+    app: api
+    helm.sh/chart: "shop-1.2.0"
+spec:
+  type: ClusterIP
+  ports:
+  - port: 80
+    targetPort: http # the container's named port
+    protocol: TCP
+    name: http
+  - name: grpc
+    port: 9090
+    targetPort: 9090
+  selector:
+    app: api
+---
+# Source: shop/templates/db.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: shop-db
+  namespace: data
+  annotations:
+    description: |
+      Primary database.
+      Not for reporting: use the replica # really
+spec:
+  ports:
+    - name: postgres
+      port: 5432
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: payments
+spec:
+  type: ExternalName
+  externalName: payments.example.com
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: shop-api
+spec:
+  template:
+    spec:
+      containers:
+        - name: api
+          ports:
+            - containerPort: 8080
+              name: http
+`
+
+func TestParseManifestsHelmOutput(t *testing.T) {
+	result, err := ParseManifests([]byte(helmOutput), "dev", "shop")
+	if err != nil {
+		t.Fatalf("ParseManifests: %v", err)
+	}
+	if len(result.Services) != 2 || result.SelectedCount != 2 {
+		t.Fatalf("want the two forwardable services, got %+v", result.Services)
+	}
+	api := result.Services[0].ServiceInfo
+	if api.Name != "shop-api" || api.Namespace != "shop" || api.Labels["helm.sh/chart"] != "shop-1.2.0" {
+		t.Errorf("api service = %+v", api)
+	}
+	if len(api.Ports) != 2 || api.Ports[0] != (ServicePort{Name: "http", Port: 80, TargetPort: "http", Protocol: "TCP"}) || api.Ports[1].TargetPort != "9090" {
+		t.Errorf("api ports = %+v", api.Ports)
+	}
+	db := result.Services[1].ServiceInfo
+	if db.Namespace != "data" || db.Annotations["description"] != "Primary database.\nNot for reporting: use the replica # really\n" {
+		t.Errorf("db service = %+v", db)
+	}
+
+	var ids []string
+	for _, cfg := range result.GenerateConfig() {
+		ids = append(ids, cfg.ID)
+	}
+	want := []string{"dev.shop.api.shop-api", "dev.shop.api.shop-api-grpc", "dev.data.service.shop-db-postgres"}
+	if !slices.Equal(ids, want) {
+		t.Errorf("proposed forwards = %v, want %v", ids, want)
+	}
+}
+
+// Flow collections and indented block scalars, as Helm and kustomize write
+// them, are read like their block forms
+func TestParseManifestsFlowStyle(t *testing.T) {
+	manifest := `apiVersion: v1
+kind: Service
+metadata: {name: cache, namespace: data, labels: {app: cache}}
+spec:
+  ports: [{port: 6379, name: redis}, {port: 9121, name: metrics}]
+  selector: {}
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: docs
+  annotations:
+    example: |
+      server {
+        listen 80;
+      }
+spec:
+  ports: [{port: 80}]
+`
+	result, err := ParseManifests([]byte(manifest), "dev", "shop")
+	if err != nil {
+		t.Fatalf("ParseManifests: %v", err)
+	}
+	if len(result.Services) != 2 {
+		t.Fatalf("want both services, got %+v", result.Services)
+	}
+	cache := result.Services[0].ServiceInfo
+	if cache.Name != "cache" || cache.Namespace != "data" || cache.Labels["app"] != "cache" || len(cache.Ports) != 2 || cache.Ports[1].Port != 9121 {
+		t.Errorf("cache service = %+v", cache)
+	}
+	docs := result.Services[1].ServiceInfo
+	if docs.Annotations["example"] != "server {\n  listen 80;\n}\n" || len(docs.Ports) != 1 {
+		t.Errorf("docs service = %+v", docs)
+	}
+}
+
+func TestParseManifestsJSONList(t *testing.T) {
+	list := `{"apiVersion":"v1","kind":"List","items":[
+		{"apiVersion":"v1","kind":"Service","metadata":{"name":"redis","namespace":"cache"},"spec":{"ports":[{"port":6379}]}},
+		{"apiVersion":"v1","kind":"ConfigMap","metadata":{"name":"settings"}}
+	]}`
+	result, err := ParseManifests([]byte(list), "dev", "default")
+	if err != nil {
+		t.Fatalf("ParseManifests: %v", err)
+	}
+	if len(result.Services) != 1 || result.Services[0].ServiceInfo.Name != "redis" || result.Services[0].ServiceInfo.Ports[0].Port != 6379 {
+		t.Fatalf("services = %+v", result.Services)
+	}
+}
+
+func TestParseManifestsRejectsBrokenYAML(t *testing.T) {
+	if _, err := ParseManifests([]byte("kind: Service\n  name: oops\n"), "dev", "default"); err == nil {
+		t.Error("a badly indented line must be an error")
+	}
+	if _, err := ParseManifests([]byte("kind: Service\nthis is not yaml\n"), "dev", "default"); err == nil {
+		t.Error("a line that is no key: value must be an error")
+	}
+}
+
+// What kprtfwd export writes as YAML, ParseYAML reads back as it was, quotes,
+// '#' and strings that look like numbers included
+func TestParseYAMLReadsConfigDumps(t *testing.T) {
+	want := config.NewConfigDump([]config.PortForwardConfig{
+		{ID: "prod.data.pg", Context: "arn:aws:eks:eu-west-1:1:cluster/prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432, PodSelector: "0", Tags: "true db"},
+		{ID: "prod.web.api", Namespace: "web", Service: "api", PortRemote: 80, PortLocal: 8080, PortCount: 2, Lazy: true, StartHook: `echo "up: #1" # it's up`, OpenCommand: "curl {{url}}"},
+	}, []config.Project{{Name: "backend", Forwards: []string{"prod.web.api", "prod.data.pg"}}, {Name: "elsewhere", Forwards: []string{"other"}}})
+
+	docs, err := ParseYAML([]byte(config.DumpYAML(want, []string{"exported"})))
+	if err != nil || len(docs) != 1 {
+		t.Fatalf("ParseYAML = %v, %v", docs, err)
+	}
+	raw, err := json.Marshal(docs[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := config.DecodeConfigDump(raw)
+	if err != nil {
+		t.Fatalf("DecodeConfigDump: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("round trip:\n got %+v\nwant %+v", got, want)
+	}
+	if len(got.Projects) != 1 {
+		t.Errorf("a project without exported forwards must be left out: %+v", got.Projects)
+	}
+}
+
+func TestParseYAMLReadsHandEditedDumps(t *testing.T) {
+	edited := `kprtfwd: 1
+forwards:
+  - {id: prod.data.pg, context: prod, namespace: data, service: pg, port_remote: 5432, port_local: 15432, tags: db}
+  - id: prod.web.api
+    namespace: web
+    service: api
+    port_remote: 80
+    port_local: 8080
+    start_hook: |
+      echo up
+projects: [{name: backend, forwards: [prod.web.api, prod.data.pg]}]
+settings: {ui.theme: mono}
+`
+	want := config.NewConfigDump([]config.PortForwardConfig{
+		{ID: "prod.data.pg", Context: "prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 15432, Tags: "db"},
+		{ID: "prod.web.api", Namespace: "web", Service: "api", PortRemote: 80, PortLocal: 8080, StartHook: "echo up\n"},
+	}, []config.Project{{Name: "backend", Forwards: []string{"prod.web.api", "prod.data.pg"}}})
+	want.Settings = map[string]string{"ui.theme": "mono"}
+
+	decode := func(data string) config.ConfigDump {
+		t.Helper()
+		docs, err := ParseYAML([]byte(data))
+		if err != nil || len(docs) != 1 {
+			t.Fatalf("ParseYAML = %v, %v", docs, err)
+		}
+		raw, err := json.Marshal(docs[0])
+		if err != nil {
+			t.Fatal(err)
+		}
+		dump, err := config.DecodeConfigDump(raw)
+		if err != nil {
+			t.Fatalf("DecodeConfigDump: %v", err)
+		}
+		return dump
+	}
+	got := decode(edited)
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("hand-edited dump:\n got %+v\nwant %+v", got, want)
+	}
+	if again := decode(config.DumpYAML(got, nil)); !reflect.DeepEqual(again, want) {
+		t.Errorf("exporting it again:\n got %+v\nwant %+v", again, want)
+	}
+
+	if _, err := ParseYAML([]byte("kprtfwd: 1\nforwards: [{id: a, port_local: 1}\n")); err == nil {
+		t.Error("an unclosed flow sequence should be an error")
+	}
+}
@@ -0,0 +1,85 @@
+package discovery
+
+import (
+	"maps"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// StaleForward is a configured forward whose service discovery no longer
+// finds.
+type StaleForward struct {
+	Config config.PortForwardConfig
+	// RenamedTo is the service the forward most likely points at now, if
+	// one matches what was recorded when the forward was discovered.
+	RenamedTo *ServiceInfo
+}
+
+// FindStaleForwards returns the forwards of result's context, in namespaces
+// matching its namespace filter, whose service result does not list.
+// Forwards to a pod or workload are never stale: discovery only lists
+// services.
+// snapshot returns what discovery recorded about a forward; it is used to
+// spot services that were renamed rather than deleted.
+func FindStaleForwards(configs []config.PortForwardConfig, result *DiscoveryResult, snapshot func(id string) (config.ServiceSnapshot, bool)) []StaleForward {
+	discovered := make(map[string]bool)
+	for _, svc := range result.Services {
+		discovered[svc.ServiceInfo.Namespace+"/"+svc.ServiceInfo.Name] = true
+	}
+	configured := make(map[string]bool)
+	for _, cfg := range configs {
+		if cfg.Context == result.Context {
+			configured[cfg.Namespace+"/"+cfg.Service] = true
+		}
+	}
+
+	var stale []StaleForward
+	for _, cfg := range configs {
+		if cfg.Context != result.Context || cfg.TargetKind != config.TargetKindService || !MatchesWildcardPattern(cfg.Namespace, result.NamespaceFilter) {
+			continue
+		}
+		if discovered[cfg.Namespace+"/"+cfg.Service] {
+			continue
+		}
+		s := StaleForward{Config: cfg}
+		if snap, ok := snapshot(cfg.ID); ok {
+			if svc, found := findRenamedService(cfg, snap, result.Services, configured); found {
+				s.RenamedTo = &svc
+			}
+		}
+		stale = append(stale, s)
+	}
+	return stale
+}
+
+// findRenamedService looks for the service a stale forward most likely points
+// at now: a service in the same namespace that no forward uses yet, with the
+// type and labels recorded at discovery time and a port matching the forward.
+// It only reports a match when exactly one service qualifies.
+func findRenamedService(cfg config.PortForwardConfig, snap config.ServiceSnapshot, services []DiscoveredService, configured map[string]bool) (ServiceInfo, bool) {
+	if len(snap.Labels) == 0 {
+		// Without labels any same-typed service would match
+		return ServiceInfo{}, false
+	}
+	var (
+		match   ServiceInfo
+		matches int
+	)
+	for _, candidate := range services {
+		svc := candidate.ServiceInfo
+		if svc.Namespace != cfg.Namespace || configured[svc.Namespace+"/"+svc.Name] {
+			continue
+		}
+		if svc.Type != snap.Type || !maps.Equal(svc.Labels, snap.Labels) {
+			continue
+		}
+		for _, port := range svc.Ports {
+			if int(port.Port) == cfg.PortRemote && port.TargetPort == snap.TargetPort {
+				match = svc
+				matches++
+				break
+			}
+		}
+	}
+	return match, matches == 1
+}
@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func service(namespace, name string, labels map[string]string, port int32, targetPort string) DiscoveredService {
+	return DiscoveredService{ServiceInfo: ServiceInfo{
+		Name: name, Namespace: namespace, Type: "ClusterIP", Labels: labels,
+		Ports: []ServicePort{{Port: port, TargetPort: targetPort}},
+	}}
+}
+
+func TestFindRenamedService(t *testing.T) {
+	labels := map[string]string{"app": "api"}
+	cfg := config.PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80}
+	snap := config.ServiceSnapshot{Type: "ClusterIP", Labels: labels, TargetPort: "8080"}
+
+	services := []DiscoveredService{
+		service("ns", "api-v2", labels, 80, "8080"),
+		service("other", "api", labels, 80, "8080"),                             // different namespace
+		service("ns", "worker", map[string]string{"app": "worker"}, 80, "8080"), // different labels
+	}
+	svc, ok := findRenamedService(cfg, snap, services, map[string]bool{})
+	if !ok || svc.Name != "api-v2" {
+		t.Fatalf("findRenamedService = %q, %v; want api-v2", svc.Name, ok)
+	}
+
+	// A service another forward already points at is not a rename target
+	if _, ok := findRenamedService(cfg, snap, services, map[string]bool{"ns/api-v2": true}); ok {
+		t.Fatal("a configured service must not be reported as the rename target")
+	}
+
+	// Two equally good candidates: too ambiguous to call it a rename
+	twins := append(services, service("ns", "api-v3", labels, 80, "8080"))
+	if _, ok := findRenamedService(cfg, snap, twins, map[string]bool{}); ok {
+		t.Fatal("an ambiguous match must be reported as deleted")
+	}
+
+	// Without labels there is nothing to identify the service by
+	if _, ok := findRenamedService(cfg, config.ServiceSnapshot{Type: "ClusterIP", TargetPort: "8080"}, services, map[string]bool{}); ok {
+		t.Fatal("a snapshot without labels must not match")
+	}
+}
+
+func TestFindStaleForwards(t *testing.T) {
+	labels := map[string]string{"app": "api"}
+	configs := []config.PortForwardConfig{
+		{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80},
+		{ID: "ctx.ns.db", Context: "ctx", Namespace: "ns", Service: "db", PortRemote: 5432},
+		{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80},
+		{ID: "ctx.other.web", Context: "ctx", Namespace: "other", Service: "web", PortRemote: 80},                                            // outside the filter
+		{ID: "prod.ns.api", Context: "prod", Namespace: "ns", Service: "api", PortRemote: 80},                                                // other context
+		{ID: "ctx.ns.worker", Context: "ctx", Namespace: "ns", Service: "worker", TargetKind: config.TargetKindDeployment, PortRemote: 9090}, // no service to miss
+	}
+	result := &DiscoveryResult{
+		Context:         "ctx",
+		NamespaceFilter: "n*",
+		Services:        []DiscoveredService{service("ns", "web", nil, 80, "80"), service("ns", "api-v2", labels, 80, "8080")},
+	}
+	snapshot := func(id string) (config.ServiceSnapshot, bool) {
+		if id == "ctx.ns.api" {
+			return config.ServiceSnapshot{Type: "ClusterIP", Labels: labels, TargetPort: "8080"}, true
+		}
+		return config.ServiceSnapshot{}, false
+	}
+
+	stale := FindStaleForwards(configs, result, snapshot)
+	if len(stale) != 2 || stale[0].Config.ID != "ctx.ns.api" || stale[1].Config.ID != "ctx.ns.db" {
+		t.Fatalf("stale forwards = %+v, want ctx.ns.api and ctx.ns.db", stale)
+	}
+	if stale[0].RenamedTo == nil || stale[0].RenamedTo.Name != "api-v2" {
+		t.Fatalf("ctx.ns.api should be reported as renamed to api-v2, got %+v", stale[0].RenamedTo)
+	}
+	if stale[1].RenamedTo != nil {
+		t.Fatal("ctx.ns.db has no snapshot and must be reported as deleted")
+	}
+}
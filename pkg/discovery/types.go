@@ -45,6 +45,7 @@ type DiscoveryResult struct {
 	TotalCount      int
 	Context         string
 	NamespaceFilter string
+	Forbidden       []string // Matching namespaces skipped because RBAC forbids listing their services
 }
 
 // GenerateConfig creates a list of PortForwardConfig from selected services
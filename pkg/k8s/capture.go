@@ -0,0 +1,348 @@
+package k8s
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// maxCaptureBytes bounds a capture file; a capture stops early once it is
+// reached, so a bulk transfer cannot fill the disk.
+const maxCaptureBytes = 100 << 20
+
+// Only a proxied forward's bytes pass through kprtfwd: a plain forward's
+// listener belongs to kubectl.
+var (
+	ErrCaptureNeedsProxy = errors.New("only lazy and TLS forwards can be captured: kubectl alone carries a plain forward's traffic")
+	ErrCaptureRunning    = errors.New("the forward's traffic is being captured already")
+)
+
+// pcap framing: the classic file format, with raw IPv4 packets
+const (
+	pcapMagic      = 0xa1b2c3d4
+	pcapLinkRaw    = 101
+	pcapSnapLen    = 65535
+	maxSegmentData = pcapSnapLen - ipv4HeaderLen - tcpHeaderLen
+	ipv4HeaderLen  = 20
+	tcpHeaderLen   = 20
+)
+
+// TCP flags of the synthesized segments
+const (
+	tcpFIN = 0x01
+	tcpSYN = 0x02
+	tcpPSH = 0x08
+	tcpACK = 0x10
+)
+
+// pcapCapture writes what passes through a proxied forward's connections to
+// a pcap file, as the TCP/IPv4 segments between client and forward that
+// carried it: kprtfwd sees the bytes, not the packets, so the handshakes,
+// segments and closes are made up from the connections' addresses. TLS
+// forwards are captured in the clear, between kprtfwd's TLS ends.
+type pcapCapture struct {
+	mu      sync.Mutex
+	file    *os.File
+	w       *bufio.Writer
+	written int64
+	closed  bool
+	timer   *time.Timer
+}
+
+// newPcapCapture creates path, which must not exist, and writes its header
+func newPcapCapture(path string) (*pcapCapture, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return nil, fmt.Errorf("cannot create capture directory: %w", err)
+	}
+	// The traffic may hold credentials: only the user may read it
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create capture file: %w", err)
+	}
+	c := &pcapCapture{file: file, w: bufio.NewWriter(file)}
+	header := make([]byte, 24)
+	binary.LittleEndian.PutUint32(header[0:], pcapMagic)
+	binary.LittleEndian.PutUint16(header[4:], 2) // version 2.4
+	binary.LittleEndian.PutUint16(header[6:], 4)
+	binary.LittleEndian.PutUint32(header[16:], pcapSnapLen)
+	binary.LittleEndian.PutUint32(header[20:], pcapLinkRaw)
+	if err := c.write(header); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// write appends b to the file, stopping the capture at maxCaptureBytes.
+// Caller must hold c.mu, or own c alone.
+func (c *pcapCapture) write(b []byte) error {
+	if c.written+int64(len(b)) > maxCaptureBytes {
+		logging.LogError("Capture %s reached %d bytes; stopped", c.file.Name(), maxCaptureBytes)
+		c.closeLocked()
+		return nil
+	}
+	c.written += int64(len(b))
+	if _, err := c.w.Write(b); err != nil {
+		logging.LogError("Failed to write capture %s: %v", c.file.Name(), err)
+		c.closeLocked()
+		return err
+	}
+	return nil
+}
+
+// close ends the capture; later traffic is not recorded
+func (c *pcapCapture) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closeLocked()
+}
+
+func (c *pcapCapture) closeLocked() {
+	if c.closed {
+		return
+	}
+	c.closed = true
+	if c.timer != nil {
+		c.timer.Stop()
+	}
+	if err := c.w.Flush(); err != nil {
+		logging.LogError("Failed to write capture %s: %v", c.file.Name(), err)
+	}
+	if err := c.file.Close(); err != nil {
+		logging.LogError("Failed to close capture %s: %v", c.file.Name(), err)
+	}
+}
+
+// captureFlow is one connection of a capture: a client and the forward's
+// local port, with the next sequence number each side sends
+type captureFlow struct {
+	c              *pcapCapture
+	client, server netip.AddrPort
+	seqClient      uint32
+	seqServer      uint32
+	opened         bool
+}
+
+// newCaptureFlow returns the flow of a connection from client to server,
+// the forward's listener
+func newCaptureFlow(c *pcapCapture, client, server net.Addr) *captureFlow {
+	return &captureFlow{c: c, client: flowAddr(client), server: flowAddr(server), seqClient: 1000, seqServer: 5000}
+}
+
+// flowAddr returns the IPv4 address and port of addr; the loopback address
+// for an IPv6 one, as the file holds IPv4 packets only
+func flowAddr(addr net.Addr) netip.AddrPort {
+	tcp, ok := addr.(*net.TCPAddr)
+	if !ok {
+		return netip.AddrPortFrom(netip.MustParseAddr("127.0.0.1"), 0)
+	}
+	ip := netip.MustParseAddr("127.0.0.1")
+	if v4 := tcp.IP.To4(); v4 != nil {
+		ip = netip.AddrFrom4([4]byte(v4))
+	}
+	return netip.AddrPortFrom(ip, uint16(tcp.Port))
+}
+
+// record writes data as sent by the client (fromClient) or by the forward,
+// opening the flow with a handshake first. It is a no-op once the capture
+// has ended.
+func (f *captureFlow) record(fromClient bool, data []byte) {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if f.c.closed {
+		return
+	}
+	f.openLocked()
+	for len(data) > 0 && !f.c.closed {
+		n := min(len(data), maxSegmentData)
+		f.segmentLocked(fromClient, tcpPSH|tcpACK, data[:n])
+		data = data[n:]
+	}
+}
+
+// finish writes the flow's close, if it was opened while capturing
+func (f *captureFlow) finish() {
+	f.c.mu.Lock()
+	defer f.c.mu.Unlock()
+	if f.c.closed || !f.opened {
+		return
+	}
+	f.segmentLocked(true, tcpFIN|tcpACK, nil)
+	f.segmentLocked(false, tcpFIN|tcpACK, nil)
+	f.segmentLocked(true, tcpACK, nil)
+}
+
+// openLocked writes the three-way handshake the first time
+func (f *captureFlow) openLocked() {
+	if f.opened {
+		return
+	}
+	f.opened = true
+	f.segmentLocked(true, tcpSYN, nil)
+	f.segmentLocked(false, tcpSYN|tcpACK, nil)
+	f.segmentLocked(true, tcpACK, nil)
+}
+
+// segmentLocked writes one segment with flags and payload and advances the
+// sender's sequence number. Caller must hold f.c.mu.
+func (f *captureFlow) segmentLocked(fromClient bool, flags byte, payload []byte) {
+	src, dst := f.client, f.server
+	seq, ack := &f.seqClient, &f.seqServer
+	if !fromClient {
+		src, dst = f.server, f.client
+		seq, ack = &f.seqServer, &f.seqClient
+	}
+	size := ipv4HeaderLen + tcpHeaderLen + len(payload)
+	packet := make([]byte, 16+size)
+	now := time.Now()
+	binary.LittleEndian.PutUint32(packet[0:], uint32(now.Unix()))
+	binary.LittleEndian.PutUint32(packet[4:], uint32(now.Nanosecond()/1000))
+	binary.LittleEndian.PutUint32(packet[8:], uint32(size))
+	binary.LittleEndian.PutUint32(packet[12:], uint32(size))
+
+	ip := packet[16:]
+	ip[0] = 0x45 // IPv4, 20 byte header
+	binary.BigEndian.PutUint16(ip[2:], uint16(size))
+	binary.BigEndian.PutUint16(ip[6:], 0x4000) // don't fragment
+	ip[8] = 64                                 // TTL
+	ip[9] = 6                                  // TCP
+	srcIP, dstIP := src.Addr().As4(), dst.Addr().As4()
+	copy(ip[12:16], srcIP[:])
+	copy(ip[16:20], dstIP[:])
+	binary.BigEndian.PutUint16(ip[10:], ipv4Checksum(ip[:ipv4HeaderLen]))
+
+	tcp := ip[ipv4HeaderLen:]
+	binary.BigEndian.PutUint16(tcp[0:], src.Port())
+	binary.BigEndian.PutUint16(tcp[2:], dst.Port())
+	binary.BigEndian.PutUint32(tcp[4:], *seq)
+	if flags&tcpACK != 0 { // a SYN alone has nothing to acknowledge yet
+		binary.BigEndian.PutUint32(tcp[8:], *ack)
+	}
+	tcp[12] = tcpHeaderLen / 4 << 4
+	tcp[13] = flags
+	binary.BigEndian.PutUint16(tcp[14:], 65535) // window
+	copy(tcp[tcpHeaderLen:], payload)
+
+	*seq += uint32(len(payload))
+	if flags&(tcpSYN|tcpFIN) != 0 {
+		*seq++ // SYN and FIN take a sequence number each
+	}
+	_ = f.c.write(packet)
+}
+
+// ipv4Checksum returns the checksum of an IPv4 header whose checksum field
+// is zero
+func ipv4Checksum(header []byte) uint16 {
+	var sum uint32
+	for i := 0; i < len(header); i += 2 {
+		sum += uint32(binary.BigEndian.Uint16(header[i:]))
+	}
+	for sum > 0xffff {
+		sum = sum>>16 + sum&0xffff
+	}
+	return ^uint16(sum)
+}
+
+// StartCapture records the traffic of the proxied forward id to a new pcap
+// file at path for d, for analysis in Wireshark: connections open at the
+// start, from their next byte, and those opened meanwhile. It stops early
+// when the forward stops or the file reaches maxCaptureBytes.
+func (pf *PortForwarder) StartCapture(id, path string, d time.Duration) error {
+	pf.Mutex.Lock()
+	p, ok := pf.proxies[id]
+	pf.Mutex.Unlock()
+	if !ok {
+		return ErrCaptureNeedsProxy
+	}
+	p.mu.Lock()
+	running := p.capture != nil && !p.capture.isClosed()
+	p.mu.Unlock()
+	if running {
+		return ErrCaptureRunning
+	}
+
+	c, err := newPcapCapture(path)
+	if err != nil {
+		return err
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed || (p.capture != nil && !p.capture.isClosed()) {
+		c.close()
+		os.Remove(path)
+		if p.closed {
+			return ErrCaptureNeedsProxy
+		}
+		return ErrCaptureRunning
+	}
+	c.mu.Lock()
+	c.timer = time.AfterFunc(d, c.close)
+	c.mu.Unlock()
+	p.capture = c
+	logging.LogDebug("Capturing the traffic of '%s' to %s for %s", id, path, d)
+	return nil
+}
+
+// Capturing reports whether the traffic of the forward id is being captured
+func (pf *PortForwarder) Capturing(id string) bool {
+	pf.Mutex.Lock()
+	p, ok := pf.proxies[id]
+	pf.Mutex.Unlock()
+	if !ok {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.capture != nil && !p.capture.isClosed()
+}
+
+func (c *pcapCapture) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// connCapture records one client connection of a proxied forward into the
+// forward's capture, if one runs while data passes
+type connCapture struct {
+	p      *proxyForward
+	client net.Conn
+	mu     sync.Mutex
+	flow   *captureFlow
+}
+
+// record writes data, sent by the client or to it, into the running capture
+func (cc *connCapture) record(fromClient bool, data []byte) {
+	cc.p.mu.Lock()
+	c := cc.p.capture
+	cc.p.mu.Unlock()
+	if c == nil {
+		return
+	}
+	cc.mu.Lock()
+	if cc.flow == nil || cc.flow.c != c {
+		cc.flow = newCaptureFlow(c, cc.client.RemoteAddr(), cc.client.LocalAddr())
+	}
+	flow := cc.flow
+	cc.mu.Unlock()
+	flow.record(fromClient, data)
+}
+
+// finish writes the close of the connection into the capture it went to
+func (cc *connCapture) finish() {
+	cc.mu.Lock()
+	flow := cc.flow
+	cc.mu.Unlock()
+	if flow != nil {
+		flow.finish()
+	}
+}
@@ -0,0 +1,103 @@
+package k8s
+
+import (
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// pcapPayloads returns the TCP payloads of the packets of a capture file,
+// checking its framing on the way
+func pcapPayloads(t *testing.T, path string) []string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) < 24 || binary.LittleEndian.Uint32(data) != pcapMagic || binary.LittleEndian.Uint32(data[20:]) != pcapLinkRaw {
+		t.Fatalf("%s has no pcap header", path)
+	}
+	var payloads []string
+	for rest := data[24:]; len(rest) > 0; {
+		size := int(binary.LittleEndian.Uint32(rest[8:]))
+		packet := rest[16 : 16+size]
+		if int(binary.BigEndian.Uint16(packet[2:])) != size || packet[9] != 6 {
+			t.Fatalf("packet of %d bytes is not a TCP/IPv4 packet of that size", size)
+		}
+		if ipv4Checksum(packet[:ipv4HeaderLen]) != 0 {
+			t.Fatal("bad IPv4 header checksum")
+		}
+		if payload := packet[ipv4HeaderLen+tcpHeaderLen:]; len(payload) > 0 {
+			payloads = append(payloads, string(payload))
+		}
+		rest = rest[16+size:]
+	}
+	return payloads
+}
+
+func TestCaptureProxiedForward(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+	path := filepath.Join(t.TempDir(), "captures", "lazy.pcap")
+
+	if err := pf.StartCapture(cfg.ID, path, time.Minute); !errors.Is(err, ErrCaptureNeedsProxy) {
+		t.Fatalf("StartCapture of a stopped forward = %v, want ErrCaptureNeedsProxy", err)
+	}
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := pf.StartCapture(cfg.ID, path, time.Minute); err != nil {
+		t.Fatalf("StartCapture: %v", err)
+	}
+	if err := pf.StartCapture(cfg.ID, path+".2", time.Minute); !errors.Is(err, ErrCaptureRunning) {
+		t.Errorf("second StartCapture = %v, want ErrCaptureRunning", err)
+	}
+	if !pf.Capturing(cfg.ID) {
+		t.Error("Capturing = false during a capture")
+	}
+
+	echoThrough(t, cfg.PortLocal, "ping")
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if pf.Capturing(cfg.ID) {
+		t.Error("stopping the forward must end its capture")
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o600 {
+		t.Fatalf("capture file = %v, %v; want one only the user can read", info, err)
+	}
+	got := pcapPayloads(t, path)
+	if len(got) != 2 || got[0] != "ping" || got[1] != "ping" {
+		t.Errorf("captured payloads = %q, want the request and the echo", got)
+	}
+}
+
+func TestCaptureEndsAfterItsDuration(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "short.pcap")
+	if err := pf.StartCapture(cfg.ID, path, 50*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for pf.Capturing(cfg.ID) && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if pf.Capturing(cfg.ID) {
+		t.Fatal("capture still running after its duration")
+	}
+	echoThrough(t, cfg.PortLocal, "late")
+	if got := pcapPayloads(t, path); len(got) != 0 {
+		t.Errorf("captured payloads = %q after the capture ended, want none", got)
+	}
+}
@@ -0,0 +1,94 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// portForwardArgs returns the kubectl arguments of the port-forward params
+// describes. A range is one kubectl process with a LOCAL:REMOTE pair per port.
+func portForwardArgs(params PortForwardParams) []string {
+	args := []string{"port-forward",
+		"--namespace", params.Namespace,
+		portForwardTarget(params),
+	}
+	for offset := 0; offset < max(params.PortCount, 1); offset++ {
+		args = append(args, fmt.Sprintf("%d:%d", params.PortLocal+offset, params.PortRemote+offset))
+	}
+	if params.Listen != config.ListenIPv4 {
+		// Without --address kubectl binds "localhost"
+		args = append(args, "--address", strings.Join(config.ListenHosts(params.Listen), ","))
+	}
+	args = append(args, strings.Fields(params.ExtraArgs)...)
+	args = append(kubectl.ContextArgs(params.Context), args...)
+	return append(kubectl.KubeconfigArgs(params.Kubeconfig), args...)
+}
+
+// portForwardTarget is the resource kubectl forwards to: the pod if one was
+// resolved, else the service, pod or workload the forward names
+func portForwardTarget(params PortForwardParams) string {
+	if params.Pod != "" {
+		return "pod/" + params.Pod
+	}
+	return config.PortForwardConfig{Service: params.Service, TargetKind: params.TargetKind}.Target()
+}
+
+// KubectlCommand returns the shell command that forwards cfg the way kprtfwd
+// runs kubectl for it, bound directly on the forward's local port. For lazy
+// and TLS forwards that is the tunnel alone: kprtfwd's listener in front of
+// it is not part of the command. A forward with a pod selector goes to the
+// pod in state (see ForwardState.Pod); while none is resolved, the command
+// forwards to the service.
+func KubectlCommand(cfg config.PortForwardConfig, state ForwardState) string {
+	params := PortForwardParams{
+		Context:    cfg.Context,
+		Namespace:  cfg.Namespace,
+		Service:    cfg.Service,
+		TargetKind: cfg.TargetKind,
+		PortRemote: cfg.PortRemote,
+		PortLocal:  cfg.PortLocal,
+		PortCount:  cfg.PortCount,
+		Kubeconfig: cfg.Kubeconfig,
+		Listen:     cfg.Listen,
+		ExtraArgs:  cfg.KubectlArgs,
+	}
+	if state.Pod != "" {
+		params.Pod = state.Pod
+		params.PortRemote = state.PodPort
+	}
+	args := portForwardArgs(params)
+	words := []string{shellQuote(kubectl.Binary())}
+	for _, arg := range args {
+		words = append(words, shellQuote(arg))
+	}
+	return strings.Join(words, " ")
+}
+
+// podShell starts bash where the image has it and sh otherwise
+const podShell = "command -v bash >/dev/null && exec bash || exec sh"
+
+// ExecShellArgs returns the kubectl arguments of an interactive shell in a
+// pod behind cfg: the pod its pod selector resolved to (see ForwardState.Pod)
+// if there is one, else the pod kubectl picks for the forward's target, which
+// is the one `kubectl port-forward` to it uses as well.
+func ExecShellArgs(cfg config.PortForwardConfig, state ForwardState) []string {
+	target := cfg.Target()
+	if state.Pod != "" {
+		target = "pod/" + state.Pod
+	}
+	args := []string{"exec", "-it", "--namespace", cfg.Namespace, target, "--", "sh", "-c", podShell}
+	args = append(kubectl.ContextArgs(cfg.Context), args...)
+	return append(kubectl.KubeconfigArgs(cfg.Kubeconfig), args...)
+}
+
+// shellQuote quotes s for a POSIX shell unless it only holds characters that
+// need no quoting
+func shellQuote(s string) string {
+	if s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789-_./:=,@%+") == "" {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
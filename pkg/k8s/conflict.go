@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// PortConflict describes another program holding a forward's local port.
+// The zero value means there is none.
+type PortConflict struct {
+	Port    int    // the first local port of the forward found taken
+	PID     int    // process listening on it; 0 if it could not be found
+	Process string // its command name, if known
+}
+
+// String describes the conflict for the status column: "port 5432 taken by
+// PID 4711 (postgres)"
+func (c PortConflict) String() string {
+	owner := "another process"
+	if c.PID != 0 {
+		owner = fmt.Sprintf("PID %d", c.PID)
+		if c.Process != "" {
+			owner += " (" + c.Process + ")"
+		}
+	}
+	return fmt.Sprintf("port %d taken by %s", c.Port, owner)
+}
+
+// ProbeConflicts checks the local ports of the failed forwards among cfgs
+// that kprtfwd does not hold any more, and records the ones another program
+// has bound meanwhile (see ForwardState.Conflict). A forward whose port is
+// free again loses its conflict. It reports whether any conflict changed.
+// Blocking: it listens on every port and may look up the owner; call from a
+// goroutine or tea.Cmd.
+func (pf *PortForwarder) ProbeConflicts(cfgs []config.PortForwardConfig) bool {
+	pf.Mutex.Lock()
+	var toProbe []config.PortForwardConfig
+	for _, cfg := range cfgs {
+		_, failed := pf.failedForwards[cfg.ID]
+		_, running := pf.RunningForwards[cfg.ID]
+		_, proxied := pf.proxies[cfg.ID]
+		if failed && !running && !proxied && pf.queuedIndexLocked(cfg.ID) < 0 {
+			toProbe = append(toProbe, cfg)
+		}
+	}
+	pf.Mutex.Unlock()
+
+	conflicts := make(map[string]PortConflict)
+	for _, cfg := range toProbe {
+		if c, ok := portConflict(cfg); ok {
+			conflicts[cfg.ID] = c
+		}
+	}
+
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	changed := !maps.Equal(pf.conflicts, conflicts)
+	pf.conflicts = conflicts
+	return changed
+}
+
+// portConflict reports whether one of cfg's local ports is bound, and by whom
+func portConflict(cfg config.PortForwardConfig) (PortConflict, bool) {
+	for port := cfg.PortLocal; port < cfg.PortLocal+cfg.Ports(); port++ {
+		for _, host := range config.ListenHosts(cfg.Listen) {
+			if isPortAvailable(host, port) {
+				continue
+			}
+			c := PortConflict{Port: port}
+			c.PID, c.Process = portOwner(port)
+			return c, true
+		}
+	}
+	return PortConflict{}, false
+}
@@ -0,0 +1,58 @@
+package k8s
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestProbeConflictsFindsOtherListener(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	cfg := config.PortForwardConfig{ID: "ctx.ns.db", Context: "ctx", Namespace: "ns", Service: "db", PortRemote: 5432, PortLocal: port}
+
+	pf := NewPortForwarder()
+	if pf.ProbeConflicts([]config.PortForwardConfig{cfg}) || pf.State(cfg.ID).Conflict.Port != 0 {
+		t.Error("a forward that did not fail has no conflict")
+	}
+
+	pf.Mutex.Lock()
+	pf.recordFailureLocked(cfg.ID, "kubectl exited")
+	pf.Mutex.Unlock()
+	if !pf.ProbeConflicts([]config.PortForwardConfig{cfg}) {
+		t.Fatal("the new conflict must be reported as a change")
+	}
+	c := pf.State(cfg.ID).Conflict
+	if c.Port != port {
+		t.Fatalf("conflict = %+v, want port %d", c, port)
+	}
+	if runtime.GOOS == "linux" && c.PID != os.Getpid() {
+		t.Errorf("conflict PID = %d, want this test's %d", c.PID, os.Getpid())
+	}
+
+	l.Close()
+	if !pf.ProbeConflicts([]config.PortForwardConfig{cfg}) || pf.State(cfg.ID).Conflict.Port != 0 {
+		t.Error("the conflict must go once the port is free again")
+	}
+}
+
+func TestPortConflictString(t *testing.T) {
+	for _, tc := range []struct {
+		c    PortConflict
+		want string
+	}{
+		{PortConflict{Port: 5432}, "port 5432 taken by another process"},
+		{PortConflict{Port: 5432, PID: 4711}, "port 5432 taken by PID 4711"},
+		{PortConflict{Port: 5432, PID: 4711, Process: "postgres"}, "port 5432 taken by PID 4711 (postgres)"},
+	} {
+		if got := tc.c.String(); got != tc.want {
+			t.Errorf("%+v.String() = %q, want %q", tc.c, got, tc.want)
+		}
+	}
+}
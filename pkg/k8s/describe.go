@@ -0,0 +1,28 @@
+package k8s
+
+import (
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// describeArgs returns the kubectl arguments describing what cfg forwards
+// to: a service together with its endpoints, which show the selector, the
+// ports and which pods are ready, else the pod or workload itself
+func describeArgs(cfg config.PortForwardConfig) []string {
+	args := []string{"describe", "--namespace", cfg.Namespace, cfg.Target()}
+	if cfg.TargetKind == config.TargetKindService {
+		args = append(args, "endpoints/"+cfg.Service)
+	}
+	args = append(kubectl.ContextArgs(cfg.Context), args...)
+	return append(kubectl.KubeconfigArgs(cfg.Kubeconfig), args...)
+}
+
+// Describe returns kubectl describe of what cfg forwards to (see
+// describeArgs). Blocking; call from a goroutine or tea.Cmd.
+func Describe(cfg config.PortForwardConfig) (string, error) {
+	out, err := kubectl.Run(kubectl.CmdDescribe, describeArgs(cfg)...)
+	if err != nil {
+		return "", err
+	}
+	return string(out), nil
+}
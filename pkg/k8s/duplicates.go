@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// ExternalForward is a kubectl port-forward that kprtfwd did not start,
+// such as one typed in another terminal or run by another kprtfwd, going to
+// the same target as a running forward on another local port
+type ExternalForward struct {
+	PID       int // the kubectl process
+	PortLocal int // the local port it listens on
+}
+
+// String describes the duplicate for the status column: "PID 4711 on :15432"
+func (d ExternalForward) String() string {
+	return fmt.Sprintf("PID %d on :%d", d.PID, d.PortLocal)
+}
+
+// ProbeDuplicates looks for kubectl port-forward processes that kprtfwd did
+// not start and that forward the target and remote port of one of the
+// running forwards among cfgs to another local port, and records them (see
+// ForwardState.Duplicate). A command without --context is taken to use
+// currentContext, the kubeconfig's current context; "" if unknown. It
+// reports whether any duplicate changed. Blocking: it lists the processes of
+// the machine; call from a goroutine or tea.Cmd.
+func (pf *PortForwarder) ProbeDuplicates(cfgs []config.PortForwardConfig, currentContext string) bool {
+	pf.Mutex.Lock()
+	var running []config.PortForwardConfig
+	for _, cfg := range cfgs {
+		_, active := pf.RunningForwards[cfg.ID]
+		_, proxied := pf.proxies[cfg.ID]
+		if active || proxied {
+			running = append(running, cfg)
+		}
+	}
+	own := make(map[int]bool)
+	for _, info := range pf.RunningForwards {
+		if info.cmd != nil && info.cmd.Process != nil {
+			own[info.cmd.Process.Pid] = true
+		}
+	}
+	for info := range pf.draining {
+		if info.cmd != nil && info.cmd.Process != nil {
+			own[info.cmd.Process.Pid] = true
+		}
+	}
+	pf.Mutex.Unlock()
+
+	var duplicates map[string][]ExternalForward
+	if len(running) > 0 {
+		duplicates = findDuplicates(running, commandLines(), own, currentContext)
+	}
+
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	changed := len(pf.duplicates) != len(duplicates)
+	for id, found := range duplicates {
+		if !slices.Equal(pf.duplicates[id], found) {
+			changed = true
+		}
+	}
+	pf.duplicates = duplicates
+	return changed
+}
+
+// findDuplicates returns the processes of lines, command lines keyed by PID,
+// that are not among own and forward the target of a forward in running to
+// another local port, by the forward's ID
+func findDuplicates(running []config.PortForwardConfig, lines map[int][]string, own map[int]bool, currentContext string) map[string][]ExternalForward {
+	duplicates := make(map[string][]ExternalForward)
+	for pid, words := range lines {
+		if own[pid] {
+			continue
+		}
+		other, err := parseKubectlWords(words)
+		if err != nil || other.PortLocal == 0 {
+			continue // not a port-forward, or one on a random port
+		}
+		if other.Context == "" {
+			other.Context = currentContext
+		}
+		for _, cfg := range running {
+			if sameTarget(cfg, other) {
+				duplicates[cfg.ID] = append(duplicates[cfg.ID], ExternalForward{PID: pid, PortLocal: other.PortLocal})
+			}
+		}
+	}
+	for id := range duplicates {
+		slices.SortFunc(duplicates[id], func(a, b ExternalForward) int { return a.PID - b.PID })
+	}
+	return duplicates
+}
+
+// sameTarget reports whether other, read from a kubectl command line,
+// forwards cfg's target and remote port to another local port
+func sameTarget(cfg, other config.PortForwardConfig) bool {
+	return other.Context != "" && other.Context == cfg.Context &&
+		other.Namespace == cfg.Namespace &&
+		other.Target() == cfg.Target() &&
+		other.PortRemote == cfg.PortRemote &&
+		other.PortLocal != cfg.PortLocal
+}
@@ -0,0 +1,38 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	db := config.PortForwardConfig{ID: "prod.shop.db", Context: "prod", Namespace: "shop", Service: "db", PortRemote: 5432, PortLocal: 5432}
+	api := config.PortForwardConfig{ID: "dev.shop.api", Context: "dev", Namespace: "shop", Service: "api", PortRemote: 8080, PortLocal: 8080}
+	lines := map[int][]string{
+		10: {"/usr/local/bin/kubectl", "--context", "prod", "port-forward", "-n", "shop", "svc/db", "15432:5432"},
+		11: {"kubectl", "port-forward", "--namespace=shop", "service/api", "18080:8080"},            // current context
+		12: {"kubectl", "--context", "prod", "port-forward", "-n", "shop", "svc/db", "5432:5432"},   // kprtfwd's own
+		13: {"kubectl", "--context", "prod", "port-forward", "-n", "shop", "svc/db", ":5432"},       // random local port
+		14: {"kubectl", "--context", "prod", "port-forward", "-n", "other", "svc/db", "25432:5432"}, // other namespace
+		15: {"kubectl", "--context", "prod", "port-forward", "-n", "shop", "pod/db", "35432:5432"},  // other target
+		16: {"kubectl", "--context", "prod", "get", "pods"},
+		17: {"/usr/sbin/sshd", "-D"},
+	}
+	own := map[int]bool{12: true}
+
+	got := findDuplicates([]config.PortForwardConfig{db, api}, lines, own, "dev")
+	if len(got) != 2 {
+		t.Fatalf("duplicates = %v, want db and api", got)
+	}
+	if d := got[db.ID]; len(d) != 1 || d[0] != (ExternalForward{PID: 10, PortLocal: 15432}) {
+		t.Errorf("db duplicates = %v, want PID 10 on :15432", d)
+	}
+	if d := got[api.ID]; len(d) != 1 || d[0].String() != "PID 11 on :18080" {
+		t.Errorf("api duplicates = %v, want PID 11 on :18080", d)
+	}
+
+	if got := findDuplicates([]config.PortForwardConfig{api}, lines, own, "prod"); len(got) != 0 {
+		t.Errorf("a command without --context runs in the current context, got %v", got)
+	}
+}
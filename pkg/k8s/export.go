@@ -0,0 +1,77 @@
+package k8s
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// shellScriptCleanup stops every kubectl the script started, once, however
+// the script ends
+const shellScriptCleanup = `pids=""
+cleanup() {
+	trap - INT TERM EXIT
+	if [ -n "$pids" ]; then
+		kill $pids 2>/dev/null
+	fi
+	wait
+}
+trap cleanup INT TERM EXIT
+`
+
+// ShellScript returns a POSIX shell script that runs cfgs the way kprtfwd
+// runs kubectl for them (see KubectlCommand), each in the background, and
+// stops them all when it is interrupted or terminated. Each forward is
+// introduced by a comment with its ID, noting what the script leaves out:
+// kprtfwd's own listener for lazy and TLS forwards, and pod selectors, which
+// it resolves at start. Header lines become comments at the top.
+func ShellScript(cfgs []config.PortForwardConfig, header []string) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	for _, line := range header {
+		b.WriteString("# " + line + "\n")
+	}
+	b.WriteString("# Needs only kubectl. Ctrl+C stops every forward.\n\n")
+	b.WriteString(shellScriptCleanup)
+
+	for _, cfg := range cfgs {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "# %s: %s in %s/%s, local %s\n", cfg.ID, cfg.Target(), cfg.Context, cfg.Namespace, portRange(cfg.PortLocal, cfg.Ports()))
+		for _, note := range scriptNotes(cfg) {
+			b.WriteString("# " + note + "\n")
+		}
+		b.WriteString(KubectlCommand(cfg, ForwardState{}) + " &\n")
+		b.WriteString(`pids="$pids $!"` + "\n")
+	}
+
+	fmt.Fprintf(&b, "\necho \"Forwarding %d port forward(s); Ctrl+C stops them\"\n", len(cfgs))
+	b.WriteString("wait\n")
+	return b.String()
+}
+
+// scriptNotes lists what of cfg a kubectl command alone does not do
+func scriptNotes(cfg config.PortForwardConfig) []string {
+	var notes []string
+	if cfg.Lazy {
+		notes = append(notes, "kprtfwd starts this one lazily, on the first connection; here it starts right away")
+	}
+	switch cfg.TLSMode {
+	case config.TLSModeTerminate:
+		notes = append(notes, "kprtfwd terminates TLS on the local port; here the port is plain")
+	case config.TLSModeOriginate:
+		notes = append(notes, "kprtfwd originates TLS to the backend; here clients must speak TLS themselves")
+	}
+	if cfg.PodSelector != "" {
+		notes = append(notes, fmt.Sprintf("kprtfwd picks the pod matching %q; here the service picks one", cfg.PodSelector))
+	}
+	return notes
+}
+
+// portRange formats a local port, or a range of count ports from it
+func portRange(port, count int) string {
+	if count > 1 {
+		return fmt.Sprintf("%d-%d", port, port+count-1)
+	}
+	return fmt.Sprint(port)
+}
@@ -0,0 +1,142 @@
+package k8s
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// Health is how a running forward answered its last health check
+type Health string
+
+const (
+	HealthUnknown  Health = ""         // not checked since it started
+	HealthHealthy  Health = "healthy"  // the tunnel carries connections and the HTTP check, if any, passed
+	HealthDegraded Health = "degraded" // the tunnel is up but the HTTP check failed: an error status or no answer
+	HealthDead     Health = "dead"     // kubectl runs but the local port refuses connections or the tunnel drops them
+)
+
+// healthCheckGrace is how long after its start a forward is left unchecked,
+// so a tunnel kubectl is still establishing is not reported dead
+const healthCheckGrace = 5 * time.Second
+
+// httpCheckTimeout bounds the HTTP GET of a forward's HealthPath
+const httpCheckTimeout = 2 * time.Second
+
+// healthRecord is the outcome of a forward's last health check
+type healthRecord struct {
+	health Health
+	detail string // why it is degraded or dead; empty when healthy
+	at     time.Time
+}
+
+// healthTarget is what a health check of one running forward dials
+type healthTarget struct {
+	host         string
+	local, count int
+	httpPath     string // config.PortForwardConfig.HealthPath, if the local port speaks plain HTTP
+}
+
+// checkHealth checks the forward at t: dead if its tunnel is broken (see
+// tunnelProblem), degraded if the HTTP check fails, healthy otherwise
+func checkHealth(t healthTarget) healthRecord {
+	if problem := tunnelProblem(t.host, t.local, t.count); problem != "" {
+		return healthRecord{health: HealthDead, detail: problem, at: time.Now()}
+	}
+	if t.httpPath != "" {
+		if problem := httpProblem(t.host, t.local, t.httpPath); problem != "" {
+			return healthRecord{health: HealthDegraded, detail: problem, at: time.Now()}
+		}
+	}
+	return healthRecord{health: HealthHealthy, at: time.Now()}
+}
+
+// httpProblem GETs path through the forward at host:port and returns why the
+// answer is unhealthy, or "" for a status below 500
+func httpProblem(host string, port int, path string) string {
+	url := "http://" + net.JoinHostPort(host, strconv.Itoa(port)) + path
+	client := &http.Client{
+		Timeout: httpCheckTimeout,
+		// A redirect (to a login page, say) is an answer: the backend is up
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Sprintf("GET %s got no answer", path)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Sprintf("GET %s answered %s", path, resp.Status)
+	}
+	return ""
+}
+
+// healthPathFor returns the path cfg's HTTP health check GETs on the port
+// kubectl listens on, or "" when there is none. kubectl carries TLS to the
+// backend of an originating forward, so it is not checked over HTTP.
+func healthPathFor(cfg config.PortForwardConfig) string {
+	if cfg.TLSMode == config.TLSModeOriginate {
+		return ""
+	}
+	return cfg.HealthPath
+}
+
+// CheckHealth checks every running forward concurrently and records how each
+// answered, for State and Snapshot to report. It returns why the tunnel of
+// each dead forward appears broken, by ID, for MarkBroken. Forwards started
+// within healthCheckGrace are skipped. Blocking; call from a goroutine or
+// tea.Cmd.
+func (pf *PortForwarder) CheckHealth() map[string]string {
+	pf.Mutex.Lock()
+	toCheck := make(map[string]healthTarget)
+	checked := make(map[string]*runningInfo)
+	for id, info := range pf.RunningForwards {
+		if time.Since(info.startedAt) < healthCheckGrace {
+			continue
+		}
+		toCheck[id] = healthTarget{info.probeHost(), info.localPort, max(info.portCount, 1), info.healthPath}
+		checked[id] = info
+	}
+	pf.Mutex.Unlock()
+
+	if len(toCheck) == 0 {
+		return nil
+	}
+
+	type result struct {
+		id     string
+		record healthRecord
+	}
+	ch := make(chan result, len(toCheck))
+	for id, t := range toCheck {
+		go func(i string, t healthTarget) {
+			ch <- result{i, checkHealth(t)}
+		}(id, t)
+	}
+
+	results := make([]result, 0, len(toCheck))
+	for range toCheck {
+		results = append(results, <-ch)
+	}
+
+	var dead map[string]string
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	for _, r := range results {
+		if pf.RunningForwards[r.id] != checked[r.id] {
+			continue // stopped, or restarted, while it was checked
+		}
+		pf.health[r.id] = r.record
+		if r.record.health == HealthDead {
+			if dead == nil {
+				dead = make(map[string]string)
+			}
+			dead[r.id] = r.record.detail
+		}
+	}
+	return dead
+}
@@ -0,0 +1,69 @@
+package k8s
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestCheckHealthTellsDegradedFromHealthyAndDead(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/broken" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer backend.Close()
+	port := backend.Listener.Addr().(*net.TCPAddr).Port
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	pf := NewPortForwarder()
+	markRunning(pf, "ctx.ns.plain", port)
+	markRunning(pf, "ctx.ns.ok", port)
+	markRunning(pf, "ctx.ns.broken", port)
+	markRunning(pf, "ctx.ns.gone", closedPort)
+	pf.Mutex.Lock()
+	pf.RunningForwards["ctx.ns.ok"].healthPath = "/healthz"
+	pf.RunningForwards["ctx.ns.broken"].healthPath = "/broken"
+	pf.Mutex.Unlock()
+
+	if s := pf.State("ctx.ns.ok"); s.Health != HealthUnknown {
+		t.Fatalf("health before any check = %q", s.Health)
+	}
+	dead := pf.CheckHealth()
+	if len(dead) != 1 || dead["ctx.ns.gone"] == "" {
+		t.Fatalf("dead = %v, want only ctx.ns.gone", dead)
+	}
+	for id, want := range map[string]Health{"ctx.ns.plain": HealthHealthy, "ctx.ns.ok": HealthHealthy, "ctx.ns.broken": HealthDegraded, "ctx.ns.gone": HealthDead} {
+		if got := pf.State(id).Health; got != want {
+			t.Errorf("%s health = %q, want %q", id, got, want)
+		}
+	}
+	if s := pf.State("ctx.ns.broken"); !s.Running || !strings.Contains(s.HealthDetail, "503") {
+		t.Fatalf("a degraded forward keeps running with the status as detail, got running %v, detail %q", s.Running, s.HealthDetail)
+	}
+
+	// A dead forward fails, and says the health check is why
+	pf.MarkBroken(dead)
+	if s := pf.State("ctx.ns.gone"); !s.Failed || s.Health != HealthDead {
+		t.Fatalf("after MarkBroken: failed %v, health %q", s.Failed, s.Health)
+	}
+}
+
+func TestHealthPathIsNotCheckedOverTLS(t *testing.T) {
+	if got := healthPathFor(config.PortForwardConfig{HealthPath: "/healthz", TLSMode: config.TLSModeOriginate}); got != "" {
+		t.Fatalf("an originating forward's backend speaks TLS, got path %q", got)
+	}
+	if got := healthPathFor(config.PortForwardConfig{HealthPath: "/healthz", TLSMode: config.TLSModeTerminate}); got != "/healthz" {
+		t.Fatalf("a terminating forward's backend speaks plain HTTP, got path %q", got)
+	}
+}
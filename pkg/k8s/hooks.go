@@ -0,0 +1,116 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// hookTimeout bounds one run of a start or stop hook; a migration may take a
+// while, a hung one must not pile up
+const hookTimeout = 5 * time.Minute
+
+// hookQuitWait is how long CleanupAll waits for hooks still running, the
+// stop hooks it triggers included, before kprtfwd exits
+const hookQuitWait = 10 * time.Second
+
+// trackHooksLocked remembers cfg as started, for its stop hook, if it has
+// hooks. The caller holds pf.Mutex.
+func (pf *PortForwarder) trackHooksLocked(cfg config.PortForwardConfig) {
+	if cfg.HasHooks() {
+		pf.hooked[cfg.ID] = cfg
+	} else {
+		delete(pf.hooked, cfg.ID)
+	}
+}
+
+// runStartHook runs the start hook of the forward id, if it has one
+func (pf *PortForwarder) runStartHook(id string) {
+	pf.Mutex.Lock()
+	cfg, ok := pf.hooked[id]
+	pf.Mutex.Unlock()
+	if ok && cfg.StartHook != "" {
+		pf.runHook(cfg, config.HookEventStart, cfg.StartHook)
+	}
+}
+
+// runStopHookLocked runs the stop hook of the forward id, which just went
+// down, if it has one. The caller holds pf.Mutex; the hook runs in the
+// background.
+func (pf *PortForwarder) runStopHookLocked(id string) {
+	cfg, ok := pf.hooked[id]
+	if !ok {
+		return
+	}
+	delete(pf.hooked, id)
+	if cfg.StopHook != "" {
+		pf.runHook(cfg, config.HookEventStop, cfg.StopHook)
+	}
+}
+
+// runHook runs command for event in the background
+func (pf *PortForwarder) runHook(cfg config.PortForwardConfig, event, command string) {
+	pf.hookRuns.Add(1)
+	go func() {
+		defer pf.hookRuns.Done()
+		if err := RunHook(cfg, event, command); err != nil {
+			logging.LogError("%s hook of '%s' failed: %v (see %s)", event, cfg.ID, err, logging.ForwardLogPath(cfg.ID))
+		}
+	}()
+}
+
+// waitForHooks waits up to timeout for the hooks still running
+func (pf *PortForwarder) waitForHooks(timeout time.Duration) {
+	done := make(chan struct{})
+	go func() {
+		pf.hookRuns.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		logging.LogError("Gave up waiting for forward hooks after %s", timeout)
+	}
+}
+
+// RunHook runs a start or stop hook of cfg through the shell, with
+// cfg.HookEnv(event) added to the environment, and appends what it prints to
+// the forward's log between a header and its exit status
+func RunHook(cfg config.PortForwardConfig, event, command string) error {
+	out, err := logging.OpenForwardLog(cfg.ID)
+	if err != nil {
+		return fmt.Errorf("cannot open the forward's log: %w", err)
+	}
+	defer out.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	cmd.Env = append(os.Environ(), cfg.HookEnv(event)...)
+	cmd.Stdout = out
+	cmd.Stderr = out
+
+	fmt.Fprintf(out, "%s %s hook: %s\n", time.Now().Format("2006-01-02 15:04:05"), event, command)
+	started := time.Now()
+	err = cmd.Run()
+	if ctx.Err() == context.DeadlineExceeded {
+		err = fmt.Errorf("timed out after %s", hookTimeout)
+	}
+	status := "done"
+	if err != nil {
+		status = err.Error()
+	}
+	fmt.Fprintf(out, "%s %s hook: %s (%s)\n", time.Now().Format("2006-01-02 15:04:05"), event, status, time.Since(started).Round(time.Millisecond))
+	return err
+}
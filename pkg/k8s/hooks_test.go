@@ -0,0 +1,58 @@
+package k8s
+
+import (
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+func TestHooksRunOnStartAndStop(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks run through sh here")
+	}
+	t.Setenv("HOME", t.TempDir())
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t) // the listener is up without kubectl
+	cfg.StartHook = `echo "migrating $KPRTFWD_ID on $KPRTFWD_HOST:$KPRTFWD_LOCAL_PORT ($KPRTFWD_EVENT)"`
+	cfg.StopHook = `echo "cleaning up $KPRTFWD_SERVICE in $KPRTFWD_NAMESPACE of $KPRTFWD_CONTEXT ($KPRTFWD_EVENT)"; exit 3`
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	pf.waitForHooks(5 * time.Second)
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	pf.waitForHooks(5 * time.Second)
+
+	data, err := os.ReadFile(logging.ForwardLogPath(cfg.ID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	for _, want := range []string{
+		"start hook: " + cfg.StartHook,
+		"migrating ctx.ns.lazy on 127.0.0.1:" + strconv.Itoa(cfg.PortLocal) + " (start)",
+		"start hook: done",
+		"cleaning up lazy in ns of ctx (stop)",
+		"stop hook: exit status 3",
+	} {
+		if !strings.Contains(log, want) {
+			t.Errorf("forward log lacks %q:\n%s", want, log)
+		}
+	}
+
+	// Stopping a forward that is not running runs no stop hook
+	before := len(data)
+	_ = pf.Stop(cfg.ID)
+	pf.waitForHooks(5 * time.Second)
+	if data, _ := os.ReadFile(logging.ForwardLogPath(cfg.ID)); len(data) != before {
+		t.Errorf("a second stop ran the stop hook again:\n%s", data)
+	}
+}
@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"time"
+)
+
+// latencyProbeTimeout bounds how long a latency probe waits for the backend to
+// answer. Anything slower is reported as LatencyTimeout.
+const latencyProbeTimeout = 2 * time.Second
+
+// LatencyTimeout is the value ProbeLatencies reports for a forward whose
+// backend did not answer within the probe timeout (tunnel up, pod degraded).
+const LatencyTimeout time.Duration = -1
+
+// latencyProbeRequest is written through the tunnel to provoke a reply. A TCP
+// connect alone only measures kubectl's local listener, which accepts
+// instantly; the round trip has to reach the pod. HTTP servers answer the HEAD,
+// and most other protocols either greet first (MySQL) or reject the garbage
+// with an error reply (PostgreSQL, Redis), which times the round trip just as
+// well.
+const latencyProbeRequest = "HEAD / HTTP/1.0\r\n\r\n"
+
+// measureLatency dials the local end of a forward, sends the probe request and
+// returns the time until the first byte comes back from the backend.
+func measureLatency(host string, localPort int) (time.Duration, error) {
+	address := net.JoinHostPort(host, strconv.Itoa(localPort))
+	conn, err := net.DialTimeout("tcp", address, latencyProbeTimeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	start := time.Now()
+	_ = conn.SetDeadline(start.Add(latencyProbeTimeout))
+	if _, err := conn.Write([]byte(latencyProbeRequest)); err != nil {
+		return 0, err
+	}
+	buf := make([]byte, 1)
+	if _, err := conn.Read(buf); err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// ProbeLatencies measures the round-trip latency through every running forward
+// concurrently. Forwards whose backend did not answer in time are reported as
+// LatencyTimeout; forwards that could not be probed at all (connection refused,
+// closed by the tunnel) are omitted and left to the tunnel health probe.
+// Blocking; call from a goroutine or tea.Cmd.
+func (pf *PortForwarder) ProbeLatencies() map[string]time.Duration {
+	pf.Mutex.Lock()
+	type target struct {
+		host string
+		port int
+	}
+	toProbe := make(map[string]target, len(pf.RunningForwards))
+	for id, info := range pf.RunningForwards {
+		toProbe[id] = target{info.probeHost(), info.localPort}
+	}
+	pf.Mutex.Unlock()
+
+	type result struct {
+		id      string
+		latency time.Duration
+		ok      bool
+	}
+	ch := make(chan result, len(toProbe))
+	for id, t := range toProbe {
+		go func(i string, t target) {
+			d, err := measureLatency(t.host, t.port)
+			if err != nil {
+				var netErr net.Error
+				if errors.As(err, &netErr) && netErr.Timeout() {
+					ch <- result{i, LatencyTimeout, true}
+					return
+				}
+				ch <- result{id: i}
+				return
+			}
+			ch <- result{i, d, true}
+		}(id, t)
+	}
+
+	latencies := make(map[string]time.Duration, len(toProbe))
+	for range toProbe {
+		r := <-ch
+		if r.ok {
+			latencies[r.id] = r.latency
+		}
+	}
+	return latencies
+}
@@ -0,0 +1,71 @@
+package k8s
+
+import (
+	"net"
+	"testing"
+)
+
+// serveLocal accepts connections on a fresh localhost listener and hands each to
+// handle. It returns the listening port; the listener closes with the test.
+func serveLocal(t *testing.T, handle func(net.Conn)) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go handle(conn)
+		}
+	}()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestProbeLatenciesMeasuresAnsweringBackend(t *testing.T) {
+	port := serveLocal(t, func(c net.Conn) {
+		defer c.Close()
+		buf := make([]byte, 64)
+		_, _ = c.Read(buf)
+		_, _ = c.Write([]byte("HTTP/1.0 200 OK\r\n\r\n"))
+	})
+	pf := NewPortForwarder()
+	markRunning(pf, "ctx.ns.web", port)
+
+	latencies := pf.ProbeLatencies()
+	d, ok := latencies["ctx.ns.web"]
+	if !ok {
+		t.Fatal("expected a latency for the answering forward")
+	}
+	if d <= 0 {
+		t.Fatalf("expected a positive round trip, got %v", d)
+	}
+}
+
+// A tunnel that accepts but never answers (pod degraded) must be reported as
+// a timeout, not omitted, so the column can flag it.
+func TestProbeLatenciesReportsSilentBackendAsTimeout(t *testing.T) {
+	port := serveLocal(t, func(c net.Conn) {
+		buf := make([]byte, 64)
+		_, _ = c.Read(buf) // swallow the probe and hold the connection open
+	})
+	pf := NewPortForwarder()
+	markRunning(pf, "ctx.ns.db", port)
+
+	if d := pf.ProbeLatencies()["ctx.ns.db"]; d != LatencyTimeout {
+		t.Fatalf("expected LatencyTimeout, got %v", d)
+	}
+}
+
+func TestProbeLatenciesOmitsUnreachablePort(t *testing.T) {
+	pf := NewPortForwarder()
+	markRunning(pf, "ctx.ns.gone", freeLocalPort(t))
+
+	if _, ok := pf.ProbeLatencies()["ctx.ns.gone"]; ok {
+		t.Fatal("a refused connection must be left to the tunnel health probe")
+	}
+}
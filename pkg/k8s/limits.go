@@ -0,0 +1,218 @@
+package k8s
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// contextLimits caps how hard kprtfwd hits one cluster's API server. Starting
+// many forwards at once (activating a large project) can get kubectl throttled,
+// EKS in particular; starts beyond a limit wait in a queue instead. 0 means
+// unlimited.
+type contextLimits struct {
+	maxStarting       int            // forwards whose kubectl is still connecting
+	maxForwards       int            // forwards running (or connecting)
+	startingByContext map[string]int // per-context overrides of maxStarting
+	forwardsByContext map[string]int // per-context overrides of maxForwards
+}
+
+// establishPollInterval is how often a connecting forward is checked for
+// kubectl having bound its local port.
+const establishPollInterval = 100 * time.Millisecond
+
+// parseLimits reads the limits.* settings. Invalid values are logged and
+// ignored.
+func parseLimits(values map[string]string) contextLimits {
+	limits := contextLimits{startingByContext: make(map[string]int), forwardsByContext: make(map[string]int)}
+	for key, value := range values {
+		isLimit := key == config.SettingLimitStarting || key == config.SettingLimitForwards ||
+			strings.HasPrefix(key, config.SettingLimitStartingPrefix) || strings.HasPrefix(key, config.SettingLimitForwardsPrefix)
+		if !isLimit {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			logging.LogError("Ignoring invalid %s setting %q", key, value)
+			continue
+		}
+		switch {
+		case key == config.SettingLimitStarting:
+			limits.maxStarting = n
+		case key == config.SettingLimitForwards:
+			limits.maxForwards = n
+		case strings.HasPrefix(key, config.SettingLimitStartingPrefix):
+			limits.startingByContext[strings.TrimPrefix(key, config.SettingLimitStartingPrefix)] = n
+		default:
+			limits.forwardsByContext[strings.TrimPrefix(key, config.SettingLimitForwardsPrefix)] = n
+		}
+	}
+	return limits
+}
+
+// forContext returns the effective limits for one context.
+func (l contextLimits) forContext(context string) (maxStarting, maxForwards int) {
+	maxStarting, maxForwards = l.maxStarting, l.maxForwards
+	if n, ok := l.startingByContext[context]; ok {
+		maxStarting = n
+	}
+	if n, ok := l.forwardsByContext[context]; ok {
+		maxForwards = n
+	}
+	return maxStarting, maxForwards
+}
+
+// hasCapacityLocked reports whether a forward in cfg's context may start now.
+// Caller must hold the mutex.
+func (pf *PortForwarder) hasCapacityLocked(cfg config.PortForwardConfig) bool {
+	maxStarting, maxForwards := pf.limits.forContext(cfg.Context)
+	if maxStarting == 0 && maxForwards == 0 {
+		return true
+	}
+	starting := 0
+	for _, ctx := range pf.establishing {
+		if ctx == cfg.Context {
+			starting++
+		}
+	}
+	if maxStarting > 0 && !usesProxy(cfg) && starting >= maxStarting {
+		return false
+	}
+	if maxForwards > 0 {
+		active := make(map[string]bool)
+		for id := range pf.establishing {
+			active[id] = true
+		}
+		for _, id := range pf.activeIDsLocked() {
+			active[id] = true
+		}
+		running := 0
+		for id := range active {
+			if pf.forwardContexts[id] == cfg.Context {
+				running++
+			}
+		}
+		if running >= maxForwards {
+			return false
+		}
+	}
+	return true
+}
+
+// queuedIndexLocked returns the position of id in the start queue, or -1.
+// Caller must hold the mutex.
+func (pf *PortForwarder) queuedIndexLocked(id string) int {
+	for i, cfg := range pf.queue {
+		if cfg.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// dequeueLocked removes id from the start queue and reports whether it was
+// queued. Caller must hold the mutex.
+func (pf *PortForwarder) dequeueLocked(id string) bool {
+	i := pf.queuedIndexLocked(id)
+	if i < 0 {
+		return false
+	}
+	pf.queue = append(pf.queue[:i], pf.queue[i+1:]...)
+	return true
+}
+
+// IsQueued reports whether the forward is waiting for its context's limits to
+// allow it to start.
+func (pf *PortForwarder) IsQueued(id string) bool {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	return pf.queuedIndexLocked(id) >= 0
+}
+
+// kickQueueLocked starts the queue dispatcher if forwards are waiting and it
+// is not already running. Caller must hold the mutex.
+func (pf *PortForwarder) kickQueueLocked() {
+	if len(pf.queue) == 0 || pf.dispatching {
+		return
+	}
+	pf.dispatching = true
+	go pf.dispatchQueue()
+}
+
+// kickQueue is kickQueueLocked for callers not holding the mutex.
+func (pf *PortForwarder) kickQueue() {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	pf.kickQueueLocked()
+}
+
+// dispatchQueue starts queued forwards, oldest first, while their contexts
+// have capacity. Forwards of a context at its limit don't hold up others.
+func (pf *PortForwarder) dispatchQueue() {
+	for {
+		pf.Mutex.Lock()
+		next := -1
+		for i, cfg := range pf.queue {
+			if pf.hasCapacityLocked(cfg) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			pf.dispatching = false
+			pf.Mutex.Unlock()
+			return
+		}
+		cfg := pf.queue[next]
+		pf.queue = append(pf.queue[:next], pf.queue[next+1:]...)
+		pf.Mutex.Unlock()
+
+		logging.LogDebug("Starting queued forward '%s'", cfg.ID)
+		if err := pf.Start(cfg); err != nil {
+			logging.LogError("Queued forward '%s' failed to start: %v", cfg.ID, err)
+		}
+	}
+}
+
+// awaitEstablished waits until kubectl has bound the forward's local port —
+// it connects to the API server before listening, so that is when the
+// establish is over — or exits, then frees the forward's starting slot. A
+// forward that did get established runs its start hook.
+func (pf *PortForwarder) awaitEstablished(id string, info *runningInfo) {
+	deadline := time.Now().Add(backendReadyTimeout)
+	for !portBound(info.probeHost(), info.localPort) && time.Now().Before(deadline) {
+		select {
+		case <-info.done:
+			pf.finishEstablishing(id)
+			return
+		case <-time.After(establishPollInterval):
+		}
+	}
+	pf.finishEstablishing(id)
+	if portBound(info.probeHost(), info.localPort) {
+		pf.runStartHook(id)
+	}
+}
+
+// finishEstablishing frees id's starting slot and lets the queue advance.
+func (pf *PortForwarder) finishEstablishing(id string) {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	delete(pf.establishing, id)
+	pf.kickQueueLocked()
+}
+
+// portBound reports whether something is listening on the local port. Unlike
+// dialing, it does not open a connection through kubectl to the pod.
+func portBound(host string, port int) bool {
+	l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		return true
+	}
+	l.Close()
+	return false
+}
@@ -0,0 +1,110 @@
+package k8s
+
+import (
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func limitedConfig(t *testing.T, service, context string) config.PortForwardConfig {
+	return config.PortForwardConfig{
+		ID: context + ".ns." + service, Context: context, Namespace: "ns", Service: service,
+		PortRemote: 80, PortLocal: freeLocalPort(t),
+	}
+}
+
+// waitFor polls cond until it holds or fails the test after a few seconds.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestForwardLimitQueuesAndDispatches(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	pf.ApplySettings(map[string]string{config.SettingLimitForwardsPrefix + "eks": "1"})
+	t.Cleanup(pf.CleanupAll)
+
+	first := limitedConfig(t, "first", "eks")
+	second := limitedConfig(t, "second", "eks")
+	other := limitedConfig(t, "other", "kind")
+
+	for _, cfg := range []config.PortForwardConfig{first, second, other} {
+		if err := pf.Start(cfg); err != nil {
+			t.Fatalf("Start(%s): %v", cfg.ID, err)
+		}
+	}
+	if !pf.IsRunning(first.ID) || !pf.IsRunning(other.ID) {
+		t.Fatal("forwards within their context's limit should start at once")
+	}
+	if pf.IsRunning(second.ID) || !pf.IsQueued(second.ID) {
+		t.Fatal("a forward over its context's limit should be queued")
+	}
+
+	if err := pf.Stop(first.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	waitFor(t, "the queued forward to start", func() bool { return pf.IsRunning(second.ID) })
+	if pf.IsQueued(second.ID) {
+		t.Fatal("a dispatched forward must leave the queue")
+	}
+}
+
+func TestStoppingQueuedForwardDropsIt(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	pf.ApplySettings(map[string]string{config.SettingLimitForwards: "1"})
+	t.Cleanup(pf.CleanupAll)
+
+	running := limitedConfig(t, "running", "eks")
+	queued := limitedConfig(t, "queued", "eks")
+	if err := pf.Start(running); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := pf.Start(queued); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := pf.Stop(queued.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if pf.IsQueued(queued.ID) {
+		t.Fatal("Stop must remove a queued forward")
+	}
+
+	if n := pf.StopAllRunning(); n != 1 {
+		t.Fatalf("StopAllRunning = %d, want 1", n)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if pf.IsRunning(queued.ID) {
+		t.Fatal("a dropped forward must not be started when room frees up")
+	}
+}
+
+func TestStartingLimitCountsConnectingForwards(t *testing.T) {
+	pf := NewPortForwarder()
+	pf.ApplySettings(map[string]string{config.SettingLimitStarting: "2"})
+	cfg := limitedConfig(t, "web", "eks")
+
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	pf.establishing["eks.ns.a"] = "eks"
+	pf.establishing["kind.ns.b"] = "kind"
+	if !pf.hasCapacityLocked(cfg) {
+		t.Fatal("one connecting forward in the context is below the limit of 2")
+	}
+	pf.establishing["eks.ns.c"] = "eks"
+	if pf.hasCapacityLocked(cfg) {
+		t.Fatal("two connecting forwards in the context reach the limit of 2")
+	}
+	cfg.Lazy = true
+	if !pf.hasCapacityLocked(cfg) {
+		t.Fatal("a lazy forward connects on demand and is not held back by the starting limit")
+	}
+}
@@ -0,0 +1,83 @@
+package k8s
+
+import (
+	"errors"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// listenLoopback listens on port on every host (see config.ListenHosts). With
+// more than one host the listeners are merged into one: Accept returns
+// connections from any of them and Close closes them all.
+func listenLoopback(hosts []string, port int) (net.Listener, error) {
+	var listeners []net.Listener
+	for _, host := range hosts {
+		l, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, err
+		}
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 1 {
+		return listeners[0], nil
+	}
+
+	ml := &multiListener{listeners: listeners, conns: make(chan net.Conn), closed: make(chan struct{})}
+	for _, l := range listeners {
+		go ml.serve(l)
+	}
+	return ml, nil
+}
+
+// multiListener merges several listeners on the same port
+type multiListener struct {
+	listeners []net.Listener
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+// serve hands l's connections to Accept until the listener is closed
+func (ml *multiListener) serve(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return
+		}
+		select {
+		case ml.conns <- conn:
+		case <-ml.closed:
+			conn.Close()
+			return
+		}
+	}
+}
+
+func (ml *multiListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-ml.conns:
+		return conn, nil
+	case <-ml.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (ml *multiListener) Close() error {
+	var errs []error
+	ml.closeOnce.Do(func() {
+		close(ml.closed)
+		for _, l := range ml.listeners {
+			errs = append(errs, l.Close())
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// Addr returns the address of the first listener
+func (ml *multiListener) Addr() net.Addr {
+	return ml.listeners[0].Addr()
+}
@@ -0,0 +1,128 @@
+package k8s
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// logTailLines is how many past lines of each pod a log stream starts with
+const logTailLines = 100
+
+// maxLogRequests caps the pods a service's log stream follows at once,
+// kubectl's --max-log-requests
+const maxLogRequests = 10
+
+// LogStream follows the logs of the pods behind a forward with kubectl logs
+// -f. Lines delivers them as they come; it is closed when kubectl exits,
+// after which Err says why.
+type LogStream struct {
+	cmd     *exec.Cmd
+	lines   chan string
+	err     error // set before lines is closed
+	stop    sync.Once
+	stopped atomic.Bool
+}
+
+// logsArgs returns the kubectl arguments that follow the logs of the pods
+// behind cfg: the pod its pod selector resolved to, every pod selector
+// selects for a service, else the pod kubectl picks for a workload. selector
+// is the service's pod selector; empty for other targets.
+func logsArgs(cfg config.PortForwardConfig, state ForwardState, selector string) []string {
+	args := []string{"logs", "--follow", fmt.Sprintf("--tail=%d", logTailLines), "--namespace", cfg.Namespace}
+	switch {
+	case state.Pod != "":
+		args = append(args, "pod/"+state.Pod, "--all-containers")
+	case selector != "":
+		args = append(args, "--selector", selector, "--all-containers", "--prefix", fmt.Sprintf("--max-log-requests=%d", maxLogRequests))
+	default:
+		args = append(args, cfg.Target(), "--all-containers")
+	}
+	args = append(kubectl.ContextArgs(cfg.Context), args...)
+	return append(kubectl.KubeconfigArgs(cfg.Kubeconfig), args...)
+}
+
+// StreamLogs starts following the logs of the pods behind cfg (see
+// logsArgs). For a service it first looks up the service's pod selector.
+// Blocking; call from a goroutine or tea.Cmd.
+func StreamLogs(cfg config.PortForwardConfig, state ForwardState) (*LogStream, error) {
+	selector := ""
+	if cfg.TargetKind == config.TargetKindService && state.Pod == "" {
+		svc, err := getService(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if len(svc.Spec.Selector) == 0 {
+			return nil, fmt.Errorf("service %s selects no pods (it has no selector), so it has no logs to follow", cfg.Service)
+		}
+		selector = strings.Join(svc.selectorTerms(), ",")
+	}
+
+	args := logsArgs(cfg, state, selector)
+	logging.LogDebug("Following logs of %s: %s %s", cfg.ID, kubectl.Binary(), strings.Join(args, " "))
+	cmd := exec.Command(kubectl.Binary(), args...)
+	setProcGroupAttrs(cmd)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("kubectl logs failed to start: %w", err)
+	}
+
+	s := &LogStream{cmd: cmd, lines: make(chan string, 256)}
+	go s.read(stdout, &stderr)
+	return s, nil
+}
+
+// read delivers stdout line by line until kubectl exits, then records why
+func (s *LogStream) read(stdout io.Reader, stderr *bytes.Buffer) {
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024) // long JSON log lines
+	for scanner.Scan() {
+		s.lines <- scanner.Text()
+	}
+	if err := s.cmd.Wait(); err != nil && !s.stopped.Load() {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			s.err = fmt.Errorf("%s", msg)
+		} else {
+			s.err = err
+		}
+	}
+	close(s.lines)
+}
+
+// Lines returns the channel the log lines arrive on
+func (s *LogStream) Lines() <-chan string {
+	return s.lines
+}
+
+// Err returns why kubectl stopped following the logs, once Lines is
+// closed: nil when it ended normally (the pods went away) or was stopped
+func (s *LogStream) Err() error {
+	return s.err
+}
+
+// Stop ends the stream. Lines is closed once kubectl has exited.
+func (s *LogStream) Stop() {
+	s.stop.Do(func() {
+		s.stopped.Store(true)
+		_ = killCmdGroup(s.cmd)
+		// Unblock read if nobody takes the remaining lines any more
+		go func() {
+			for range s.lines {
+			}
+		}()
+	})
+}
@@ -0,0 +1,125 @@
+package k8s
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// metricFamily is one metric of the textfile export with its samples
+type metricFamily struct {
+	name, kind, help string
+	samples          []string // formatted sample lines
+}
+
+func (f *metricFamily) add(labels string, value float64) {
+	f.samples = append(f.samples, fmt.Sprintf("%s%s %s", f.name, labels, strconv.FormatFloat(value, 'g', -1, 64)))
+}
+
+// TextfileMetrics renders the state of the forwards cfgs in the Prometheus
+// text format, as node_exporter's textfile collector reads it. Every forward
+// is labelled with its ID, context, namespace, service and local port.
+func (pf *PortForwarder) TextfileMetrics(cfgs []config.PortForwardConfig) []byte {
+	states := pf.Snapshot()
+	pf.Mutex.Lock()
+	failures := make(map[string]int, len(pf.failureCounts))
+	for id, n := range pf.failureCounts {
+		failures[id] = n
+	}
+	pf.Mutex.Unlock()
+
+	up := &metricFamily{name: "kprtfwd_forward_up", kind: "gauge", help: "Whether the forward is running (1) or not (0); a lazy forward on standby counts as running."}
+	failed := &metricFamily{name: "kprtfwd_forward_failed", kind: "gauge", help: "Whether the forward stopped on an error and was not restarted since."}
+	queued := &metricFamily{name: "kprtfwd_forward_queued", kind: "gauge", help: "Whether the forward waits for its context's start limits."}
+	failuresTotal := &metricFamily{name: "kprtfwd_forward_failures_total", kind: "counter", help: "Failures of the forward since kprtfwd started."}
+	activity := &metricFamily{name: "kprtfwd_forward_last_activity_timestamp_seconds", kind: "gauge", help: "When data last went through a lazy or TLS forward."}
+	memory := &metricFamily{name: "kprtfwd_forward_kubectl_resident_memory_bytes", kind: "gauge", help: "Resident memory of the forward's kubectl as last sampled."}
+	cpu := &metricFamily{name: "kprtfwd_forward_kubectl_cpu_seconds_total", kind: "counter", help: "CPU time the forward's kubectl used as last sampled."}
+	running := 0
+	for _, cfg := range cfgs {
+		state := states[cfg.ID]
+		labels := metricLabels(
+			"id", cfg.ID,
+			"context", cfg.Context,
+			"namespace", cfg.Namespace,
+			"service", cfg.Service,
+			"local_port", strconv.Itoa(cfg.PortLocal),
+		)
+		up.add(labels, boolMetric(state.Running))
+		failed.add(labels, boolMetric(state.Failed))
+		queued.add(labels, boolMetric(state.Queued))
+		failuresTotal.add(labels, float64(failures[cfg.ID]))
+		if !state.LastActivity.IsZero() {
+			activity.add(labels, float64(state.LastActivity.Unix()))
+		}
+		if !state.Usage.At.IsZero() {
+			memory.add(labels, float64(state.Usage.RSS))
+			cpu.add(labels, state.Usage.CPUTime.Seconds())
+		}
+		if state.Running {
+			running++
+		}
+	}
+	configured := &metricFamily{name: "kprtfwd_forwards", kind: "gauge", help: "Number of configured forwards."}
+	configured.add("", float64(len(cfgs)))
+	runningTotal := &metricFamily{name: "kprtfwd_forwards_running", kind: "gauge", help: "Number of running forwards."}
+	runningTotal.add("", float64(running))
+
+	var b bytes.Buffer
+	for _, f := range []*metricFamily{configured, runningTotal, up, failed, queued, failuresTotal, activity, memory, cpu} {
+		if len(f.samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", f.name, f.help, f.name, f.kind)
+		for _, sample := range f.samples {
+			b.WriteString(sample)
+			b.WriteByte('\n')
+		}
+	}
+	return b.Bytes()
+}
+
+// WriteTextfileMetrics writes TextfileMetrics to path. The file is replaced
+// in one rename, as the textfile collector may read it at any moment.
+func (pf *PortForwarder) WriteTextfileMetrics(path string, cfgs []config.PortForwardConfig) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(pf.TextfileMetrics(cfgs)); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	// Readable by the node_exporter user
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return fmt.Errorf("failed to write metrics: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// metricLabels formats name/value pairs as a label set, escaping values as
+// the text format requires
+func metricLabels(pairs ...string) string {
+	escape := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	parts := make([]string, 0, len(pairs)/2)
+	for i := 0; i+1 < len(pairs); i += 2 {
+		parts = append(parts, fmt.Sprintf(`%s="%s"`, pairs[i], escape.Replace(pairs[i+1])))
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+func boolMetric(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
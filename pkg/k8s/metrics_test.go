@@ -0,0 +1,64 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestTextfileMetricsReportsForwardStates(t *testing.T) {
+	pf := NewPortForwarder()
+	markRunning(pf, "ctx.ns.web", 8080)
+	pf.Mutex.Lock()
+	pf.recordFailureLocked("ctx.ns.db", "connection refused")
+	pf.recordFailureLocked("ctx.ns.db", "connection refused")
+	pf.Mutex.Unlock()
+
+	cfgs := []config.PortForwardConfig{
+		{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortLocal: 8080},
+		{ID: "ctx.ns.db", Context: "ctx", Namespace: "ns", Service: `d"b`, PortLocal: 5432},
+	}
+	out := string(pf.TextfileMetrics(cfgs))
+
+	for _, want := range []string{
+		"# TYPE kprtfwd_forwards gauge\nkprtfwd_forwards 2\n",
+		"kprtfwd_forwards_running 1\n",
+		`kprtfwd_forward_up{id="ctx.ns.web",context="ctx",namespace="ns",service="web",local_port="8080"} 1`,
+		`kprtfwd_forward_up{id="ctx.ns.db",context="ctx",namespace="ns",service="d\"b",local_port="5432"} 0`,
+		`kprtfwd_forward_failed{id="ctx.ns.db",context="ctx",namespace="ns",service="d\"b",local_port="5432"} 1`,
+		`kprtfwd_forward_failures_total{id="ctx.ns.db",context="ctx",namespace="ns",service="d\"b",local_port="5432"} 2`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("metrics lack %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "kprtfwd_forward_last_activity_timestamp_seconds") {
+		t.Errorf("no forward is proxied, so the activity metric must be left out:\n%s", out)
+	}
+}
+
+func TestWriteTextfileMetricsReplacesFile(t *testing.T) {
+	pf := NewPortForwarder()
+	path := filepath.Join(t.TempDir(), "kprtfwd.prom")
+	if err := os.WriteFile(path, []byte("stale\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := pf.WriteTextfileMetrics(path, nil); err != nil {
+		t.Fatalf("WriteTextfileMetrics: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "stale") || !strings.Contains(string(data), "kprtfwd_forwards 0\n") {
+		t.Errorf("file not replaced with the current metrics:\n%s", data)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("temporary files left behind: %v", entries)
+	}
+}
@@ -0,0 +1,105 @@
+package k8s
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// ErrNetworkUnreachable means an address a context requires (see
+// config.SettingNetworkRequirePrefix) did not accept a connection, typically
+// because the VPN is not connected.
+var ErrNetworkUnreachable = errors.New("required network not reachable")
+
+// networkCheckTimeout bounds each reachability probe; a VPN gateway answers
+// well within it when the tunnel is up.
+const networkCheckTimeout = 2 * time.Second
+
+// networkCheckTTL is how long a probe result is reused, so starting a whole
+// project probes each address once rather than once per forward.
+const networkCheckTTL = 5 * time.Second
+
+// networkCheck is a cached probe result.
+type networkCheck struct {
+	at  time.Time
+	err error
+}
+
+// networkChecker probes the addresses contexts require before their
+// forwards start.
+type networkChecker struct {
+	mu       sync.Mutex
+	required map[string][]string // context -> host:port addresses
+	checked  map[string]networkCheck
+	dial     func(address string) error
+}
+
+func newNetworkChecker() *networkChecker {
+	return &networkChecker{
+		checked: make(map[string]networkCheck),
+		dial: func(address string) error {
+			conn, err := net.DialTimeout("tcp", address, networkCheckTimeout)
+			if err != nil {
+				return err
+			}
+			return conn.Close()
+		},
+	}
+}
+
+// apply reads the network.require.<context> settings, dropping cached
+// results.
+func (c *networkChecker) apply(values map[string]string) {
+	required := make(map[string][]string)
+	for key, value := range values {
+		context, ok := strings.CutPrefix(key, config.SettingNetworkRequirePrefix)
+		if !ok || context == "" {
+			continue
+		}
+		for _, address := range strings.Split(value, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				required[context] = append(required[context], address)
+			}
+		}
+	}
+	c.mu.Lock()
+	c.required = required
+	c.checked = make(map[string]networkCheck)
+	c.mu.Unlock()
+}
+
+// check returns ErrNetworkUnreachable, naming the address, if one of the
+// addresses context requires does not accept a TCP connection.
+func (c *networkChecker) check(context string) error {
+	c.mu.Lock()
+	addresses := c.required[context]
+	c.mu.Unlock()
+	for _, address := range addresses {
+		if err := c.probe(address); err != nil {
+			return fmt.Errorf("%w: %s needs %s, which is unreachable (%v)", ErrNetworkUnreachable, context, address, err)
+		}
+	}
+	return nil
+}
+
+// probe dials address, or returns the result of a probe within
+// networkCheckTTL. Concurrent probes of one address may both dial; the
+// result is the same.
+func (c *networkChecker) probe(address string) error {
+	c.mu.Lock()
+	last, ok := c.checked[address]
+	c.mu.Unlock()
+	if ok && time.Since(last.at) < networkCheckTTL {
+		return last.err
+	}
+	err := c.dial(address)
+	c.mu.Lock()
+	c.checked[address] = networkCheck{at: time.Now(), err: err}
+	c.mu.Unlock()
+	return err
+}
@@ -0,0 +1,55 @@
+package k8s
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestStartChecksRequiredNetwork(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	l.Close() // now nothing listens there
+
+	pf := NewPortForwarder()
+	pf.ApplySettings(map[string]string{config.SettingNetworkRequirePrefix + "corp": address})
+
+	err = pf.Start(config.PortForwardConfig{ID: "corp.ns.api", Context: "corp", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 18080})
+	if !errors.Is(err, ErrNetworkUnreachable) {
+		t.Fatalf("expected ErrNetworkUnreachable, got %v", err)
+	}
+	if !strings.Contains(err.Error(), address) {
+		t.Errorf("the error should name the address, got %q", err)
+	}
+	if pf.IsRunning("corp.ns.api") || pf.IsQueued("corp.ns.api") {
+		t.Error("the forward must not start or queue")
+	}
+}
+
+func TestNetworkCheckerCachesProbes(t *testing.T) {
+	c := newNetworkChecker()
+	dials := 0
+	c.dial = func(string) error {
+		dials++
+		return nil
+	}
+	c.apply(map[string]string{config.SettingNetworkRequirePrefix + "corp": "vpn.example.com:443, 10.0.0.1:6443"})
+
+	for range 3 {
+		if err := c.check("corp"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if dials != 2 {
+		t.Errorf("expected one probe per address, got %d dials", dials)
+	}
+	if err := c.check("other"); err != nil || dials != 2 {
+		t.Errorf("contexts without requirements must not probe: %v, %d dials", err, dials)
+	}
+}
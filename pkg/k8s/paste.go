@@ -0,0 +1,355 @@
+package k8s
+
+import (
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// kubectlValueFlags are kubectl flags, other than those ParseForward maps to
+// fields, whose value may follow as a separate argument. They are kept as
+// extra kubectl arguments in the --flag=value form.
+var kubectlValueFlags = []string{
+	"pod-running-timeout", "request-timeout", "as", "as-group", "as-uid",
+	"user", "cluster", "server", "token", "cache-dir", "tls-server-name",
+	"certificate-authority", "client-certificate", "client-key", "v",
+}
+
+// ParseForward reads the forward in text pasted by the user: a `kubectl
+// port-forward` command, as found in shell history, or the URL of a service
+// (http://api.shop.svc.cluster.local:8080, api.shop:5432). The result has no
+// ID. Context is empty when text names none, meaning the current one, and
+// PortLocal is 0 when text leaves the local port open (a URL, or ":80"), for
+// the caller to fill in.
+func ParseForward(text string) (config.PortForwardConfig, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return config.PortForwardConfig{}, fmt.Errorf("nothing to parse")
+	}
+	if strings.ContainsAny(text, " \t\n") {
+		return ParseKubectlCommand(text)
+	}
+	return ParseServiceURL(text)
+}
+
+// ParseKubectlCommand reads a `kubectl port-forward TYPE/NAME LOCAL:REMOTE`
+// command, to a service, a pod (also a bare NAME, as kubectl takes it), a
+// deployment or a statefulset. Consecutive port pairs become a range; the flags kprtfwd sets
+// itself (--namespace, --context, --kubeconfig, --address) go to their
+// fields and other flags to KubectlArgs.
+func ParseKubectlCommand(command string) (config.PortForwardConfig, error) {
+	words, err := shellWords(command)
+	if err != nil {
+		return config.PortForwardConfig{}, err
+	}
+	return parseKubectlWords(words)
+}
+
+// parseKubectlWords reads a kubectl port-forward command split into words,
+// as ParseKubectlCommand does
+func parseKubectlWords(words []string) (config.PortForwardConfig, error) {
+	var cfg config.PortForwardConfig
+	var err error
+	// Skip a prompt and environment assignments in front of kubectl, except
+	// that KUBECONFIG names the file the context is in
+	for len(words) > 0 && (words[0] == "$" || words[0] == "%" || words[0] == "sudo" || strings.Contains(words[0], "=") && !strings.HasPrefix(words[0], "-")) {
+		if path, ok := strings.CutPrefix(words[0], "KUBECONFIG="); ok {
+			cfg.Kubeconfig = filepath.SplitList(path)[0]
+		}
+		words = words[1:]
+	}
+	if len(words) == 0 || !isKubectl(words[0]) {
+		return cfg, fmt.Errorf("not a kubectl command")
+	}
+
+	var positional, extra []string
+	address := ""
+	for i := 1; i < len(words); i++ {
+		word := words[i]
+		if !strings.HasPrefix(word, "-") || word == "-" {
+			positional = append(positional, word)
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(word, "-"), "=")
+		if !strings.HasPrefix(word, "--") && len(name) > 1 && !hasValue {
+			// Shorthand with the value attached: -nshop
+			name, value, hasValue = name[:1], name[1:], true
+		}
+		takesValue := slices.Contains([]string{"n", "namespace", "context", "kubeconfig", "address"}, name) || slices.Contains(kubectlValueFlags, name)
+		if takesValue && !hasValue {
+			if i+1 == len(words) {
+				return cfg, fmt.Errorf("flag %s needs a value", word)
+			}
+			i++
+			value = words[i]
+		}
+		switch name {
+		case "n", "namespace":
+			cfg.Namespace = value
+		case "context":
+			cfg.Context = value
+		case "kubeconfig":
+			cfg.Kubeconfig = value
+		case "address":
+			address = value
+		default:
+			if takesValue || hasValue {
+				extra = append(extra, "--"+name+"="+value)
+			} else {
+				extra = append(extra, "--"+name)
+			}
+		}
+	}
+
+	if len(positional) == 0 || positional[0] != "port-forward" {
+		return cfg, fmt.Errorf("not a kubectl port-forward command")
+	}
+	if len(positional) < 3 {
+		return cfg, fmt.Errorf("kubectl port-forward needs a target and a port")
+	}
+	if cfg.TargetKind, cfg.Service, err = ParseTarget(positional[1], config.TargetKindPod); err != nil {
+		return cfg, err
+	}
+
+	if err := parsePortPairs(&cfg, positional[2:]); err != nil {
+		return cfg, err
+	}
+	if cfg.Listen, err = listenFromAddress(address); err != nil {
+		return cfg, err
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = "default" // kubectl's default without the context's own
+	}
+	cfg.KubectlArgs = strings.Join(extra, " ")
+	return cfg, validateParsed(cfg)
+}
+
+// ParseTarget reads a TYPE/NAME target as kubectl takes it (svc/api,
+// deploy/worker, ...) into its config.TargetKind and name. A NAME alone is of
+// kind bare.
+func ParseTarget(text, bare string) (string, string, error) {
+	kind, name, found := strings.Cut(text, "/")
+	if !found {
+		return bare, text, nil
+	}
+	switch kind {
+	case "svc", "service", "services":
+		return config.TargetKindService, name, nil
+	case "pod", "pods", "po":
+		return config.TargetKindPod, name, nil
+	case "deploy", "deployment", "deployments":
+		return config.TargetKindDeployment, name, nil
+	case "sts", "statefulset", "statefulsets":
+		return config.TargetKindStatefulSet, name, nil
+	}
+	return "", "", fmt.Errorf("kprtfwd forwards to services, pods, deployments and statefulsets, not %s", text)
+}
+
+// parsePortPairs reads kubectl's LOCAL:REMOTE, REMOTE or :REMOTE port
+// arguments into cfg. Several are only accepted as a range: each one port
+// up from the previous on both sides.
+func parsePortPairs(cfg *config.PortForwardConfig, pairs []string) error {
+	for i, pair := range pairs {
+		localText, remoteText, found := strings.Cut(pair, ":")
+		if !found {
+			remoteText = localText
+		}
+		remote, err := strconv.Atoi(remoteText)
+		if err != nil {
+			return fmt.Errorf("port %q is not a number; kprtfwd forwards numbered service ports", remoteText)
+		}
+		local := 0
+		if localText != "" {
+			if local, err = strconv.Atoi(localText); err != nil {
+				return fmt.Errorf("local port %q is not a number", localText)
+			}
+		}
+		if i == 0 {
+			cfg.PortLocal, cfg.PortRemote = local, remote
+			continue
+		}
+		if remote != cfg.PortRemote+i || local != 0 && local != cfg.PortLocal+i || (local == 0) != (cfg.PortLocal == 0) {
+			return fmt.Errorf("ports %s are not a range; add each forward on its own", strings.Join(pairs, " "))
+		}
+	}
+	if len(pairs) > 1 {
+		cfg.PortCount = len(pairs)
+	}
+	return nil
+}
+
+// listenFromAddress maps kubectl's --address to a Listen value. Only
+// loopback addresses are accepted: kprtfwd never exposes a port beyond the
+// machine.
+func listenFromAddress(address string) (string, error) {
+	if address == "" {
+		return config.ListenIPv4, nil
+	}
+	var ipv4, ipv6 bool
+	for _, host := range strings.Split(address, ",") {
+		switch strings.TrimSpace(host) {
+		case "localhost", "127.0.0.1":
+			ipv4 = true
+		case "::1":
+			ipv6 = true
+		default:
+			return "", fmt.Errorf("address %s is not a loopback address; kprtfwd only listens on 127.0.0.1 and ::1", host)
+		}
+	}
+	switch {
+	case ipv4 && ipv6:
+		return config.ListenDual, nil
+	case ipv6:
+		return config.ListenIPv6, nil
+	}
+	return config.ListenIPv4, nil
+}
+
+// ParseServiceURL reads a service's cluster URL or host:port:
+// SERVICE[.NAMESPACE[.svc[.cluster.local]]][:PORT], optionally with a scheme
+// and path. Without a port, http means 80 and https 443; without a
+// namespace, "default" is assumed.
+func ParseServiceURL(text string) (config.PortForwardConfig, error) {
+	var cfg config.PortForwardConfig
+	if !strings.Contains(text, "://") {
+		text = "tcp://" + text
+	}
+	u, err := url.Parse(text)
+	if err != nil || u.Hostname() == "" {
+		return cfg, fmt.Errorf("%q is neither a kubectl port-forward command nor a service URL", strings.TrimPrefix(text, "tcp://"))
+	}
+
+	labels := strings.Split(strings.TrimSuffix(u.Hostname(), "."), ".")
+	if n := len(labels); n >= 2 && labels[n-2] == "cluster" && labels[n-1] == "local" {
+		labels = labels[:n-2]
+	}
+	if n := len(labels); n == 3 && labels[2] == "svc" {
+		labels = labels[:2]
+	}
+	switch len(labels) {
+	case 1:
+		cfg.Service, cfg.Namespace = labels[0], "default"
+	case 2:
+		cfg.Service, cfg.Namespace = labels[0], labels[1]
+	default:
+		return cfg, fmt.Errorf("%s is not a service's cluster name (service.namespace.svc.cluster.local)", u.Hostname())
+	}
+
+	switch port := u.Port(); {
+	case port != "":
+		if cfg.PortRemote, err = strconv.Atoi(port); err != nil {
+			return cfg, fmt.Errorf("port %q is not a number", port)
+		}
+	case u.Scheme == "http":
+		cfg.PortRemote = 80
+	case u.Scheme == "https":
+		cfg.PortRemote = 443
+	default:
+		return cfg, fmt.Errorf("%s names no port", u.Hostname())
+	}
+	return cfg, validateParsed(cfg)
+}
+
+// validateParsed checks what ParseForward read as the store would
+func validateParsed(cfg config.PortForwardConfig) error {
+	if err := config.ValidateContextName(cfg.Context); err != nil {
+		return err
+	}
+	if err := config.ValidateKubernetesName("namespace", cfg.Namespace); err != nil {
+		return err
+	}
+	if err := config.ValidateKubernetesName(cfg.KindName(), cfg.Service); err != nil {
+		return err
+	}
+	if err := config.ValidatePortRange("remote port", cfg.PortRemote, cfg.PortCount); err != nil {
+		return err
+	}
+	if cfg.PortLocal != 0 {
+		if err := config.ValidatePortRange("local port", cfg.PortLocal, cfg.PortCount); err != nil {
+			return err
+		}
+	}
+	return config.ValidateKubectlArgs(cfg.KubectlArgs)
+}
+
+// isKubectl reports whether word runs kubectl: its name or path, or the
+// common alias k
+func isKubectl(word string) bool {
+	name := strings.TrimSuffix(filepath.Base(strings.ReplaceAll(word, `\`, "/")), ".exe")
+	return name == "kubectl" || name == "k"
+}
+
+// shellWords splits a command line into words as a POSIX shell would for a
+// simple command: quotes group, backslashes escape, and a backslash before a
+// line break joins the lines. It stops at the first ;, |, & or # comment, so
+// a pasted pipeline yields its first command.
+func shellWords(line string) ([]string, error) {
+	var words []string
+	var word strings.Builder
+	inWord := false
+	quote := rune(0)
+	runes := []rune(line)
+	flush := func() {
+		if inWord {
+			words = append(words, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case quote == '"':
+			switch {
+			case r == '"':
+				quote = 0
+			case r == '\\' && i+1 < len(runes) && strings.ContainsRune("\"\\$`", runes[i+1]):
+				i++
+				word.WriteRune(runes[i])
+			default:
+				word.WriteRune(r)
+			}
+		case r == '\\':
+			if i+1 < len(runes) && unicode.IsSpace(runes[i+1]) {
+				// A line continuation, or what is left of one when the
+				// paste turned the line break into a space
+				flush()
+				i++
+				continue
+			}
+			if i+1 < len(runes) {
+				i++
+				word.WriteRune(runes[i])
+				inWord = true
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == ' ' || r == '\t' || r == '\n' || r == '\r':
+			flush()
+		case r == ';' || r == '|' || r == '&' || r == '#' && !inWord:
+			flush()
+			return words, nil
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	flush()
+	return words, nil
+}
@@ -0,0 +1,85 @@
+package k8s
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestParseForward(t *testing.T) {
+	tests := []struct {
+		text string
+		want config.PortForwardConfig
+	}{
+		{
+			text: "kubectl port-forward -n shop svc/api 8080:80 --context dev",
+			want: config.PortForwardConfig{Context: "dev", Namespace: "shop", Service: "api", PortLocal: 8080, PortRemote: 80},
+		},
+		{
+			// From shell history with a prompt, a continuation whose line
+			// break the paste turned into spaces, and quoting
+			text: `$ KUBECONFIG=/home/me/.kube/eks kubectl --context='arn:aws:eks:eu-west-1:1:cluster/prod' \   port-forward service/postgres --namespace=data :5432 --pod-running-timeout 2m --address localhost,::1`,
+			want: config.PortForwardConfig{Context: "arn:aws:eks:eu-west-1:1:cluster/prod", Namespace: "data", Service: "postgres", PortRemote: 5432,
+				Kubeconfig: "/home/me/.kube/eks", KubectlArgs: "--pod-running-timeout=2m", Listen: config.ListenDual},
+		},
+		{
+			text: "/usr/local/bin/kubectl port-forward svc/nats -nmessaging 4222:4222 4223:4223 4224:4224 | tee log",
+			want: config.PortForwardConfig{Namespace: "messaging", Service: "nats", PortLocal: 4222, PortRemote: 4222, PortCount: 3},
+		},
+		{
+			text: "kubectl port-forward api-7d9f-abcde 8080:80",
+			want: config.PortForwardConfig{Namespace: "default", Service: "api-7d9f-abcde", TargetKind: config.TargetKindPod, PortLocal: 8080, PortRemote: 80},
+		},
+		{
+			text: "kubectl -n data port-forward sts/postgres 5432",
+			want: config.PortForwardConfig{Namespace: "data", Service: "postgres", TargetKind: config.TargetKindStatefulSet, PortLocal: 5432, PortRemote: 5432},
+		},
+		{
+			text: "http://grafana.monitoring.svc.cluster.local/d/abc?orgId=1",
+			want: config.PortForwardConfig{Namespace: "monitoring", Service: "grafana", PortRemote: 80},
+		},
+		{
+			text: "redis.data.svc:6379",
+			want: config.PortForwardConfig{Namespace: "data", Service: "redis", PortRemote: 6379},
+		},
+		{
+			text: "https://api:8443",
+			want: config.PortForwardConfig{Namespace: "default", Service: "api", PortRemote: 8443},
+		},
+	}
+	for _, tt := range tests {
+		got, err := ParseForward(tt.text)
+		if err != nil {
+			t.Errorf("ParseForward(%q): %v", tt.text, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseForward(%q) =\n  %+v\nwant\n  %+v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestParseForwardRejects(t *testing.T) {
+	tests := []struct {
+		text, want string
+	}{
+		{"kubectl get pods", "not a kubectl port-forward command"},
+		{"kubectl port-forward rs/api-7d9f 8080:80", "not rs/api-7d9f"},
+		{"kubectl port-forward deploy/Api 8080:80", `deployment "Api" is not a valid`},
+		{"kubectl port-forward svc/api 8080:80 9090:9090", "not a range"},
+		{"kubectl port-forward svc/api 8080:http", "not a number"},
+		{"kubectl port-forward svc/api 8080:80 --address 0.0.0.0", "not a loopback address"},
+		{"kubectl port-forward 'svc/api 8080:80", "unterminated"},
+		{"helm upgrade api ./chart", "not a kubectl command"},
+		{"db.data.example.com:5432", "not a service's cluster name"},
+		{"redis.data", "names no port"},
+		{"Not_A_Service:80", "not a valid Kubernetes name"},
+	}
+	for _, tt := range tests {
+		_, err := ParseForward(tt.text)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseForward(%q) error = %v, want one mentioning %q", tt.text, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,290 @@
+package k8s
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// k8sService is the part of kubectl get service output that maps a service
+// port to the pods behind it
+type k8sService struct {
+	Spec struct {
+		Selector map[string]string `json:"selector"`
+		Ports    []struct {
+			Port       int `json:"port"`
+			TargetPort any `json:"targetPort"` // number or container port name
+		} `json:"ports"`
+	} `json:"spec"`
+}
+
+// getService fetches cfg's service
+func getService(cfg config.PortForwardConfig) (k8sService, error) {
+	var svc k8sService
+	args := append(kubectl.KubeconfigArgs(cfg.Kubeconfig), "get", "service", cfg.Service, "--namespace", cfg.Namespace, "-o", "json")
+	out, err := kubectl.Run(kubectl.CmdGetServices, append(kubectl.ContextArgs(cfg.Context), args...)...)
+	if err != nil {
+		return svc, err
+	}
+	if err := json.Unmarshal(out, &svc); err != nil {
+		return svc, fmt.Errorf("failed to parse kubectl output: %w", err)
+	}
+	return svc, nil
+}
+
+// selectorTerms returns the service's pod selector as sorted key=value terms
+func (s k8sService) selectorTerms() []string {
+	terms := make([]string, 0, len(s.Spec.Selector)+1)
+	for k, v := range s.Spec.Selector {
+		terms = append(terms, k+"="+v)
+	}
+	sort.Strings(terms)
+	return terms
+}
+
+// k8sPods is the part of kubectl get pods output a pod selector needs
+type k8sPods struct {
+	Items []struct {
+		Metadata struct {
+			Name              string  `json:"name"`
+			DeletionTimestamp *string `json:"deletionTimestamp"`
+		} `json:"metadata"`
+		Spec struct {
+			NodeName   string `json:"nodeName"`
+			Containers []struct {
+				Ports []struct {
+					Name          string `json:"name"`
+					ContainerPort int    `json:"containerPort"`
+				} `json:"ports"`
+			} `json:"containers"`
+		} `json:"spec"`
+		Status struct {
+			Phase      string `json:"phase"`
+			Conditions []struct {
+				Type   string `json:"type"`
+				Status string `json:"status"`
+			} `json:"conditions"`
+		} `json:"status"`
+	} `json:"items"`
+}
+
+// k8sNodes is the part of kubectl get nodes output that places a node in a
+// zone and region
+type k8sNodes struct {
+	Items []struct {
+		Metadata struct {
+			Name   string            `json:"name"`
+			Labels map[string]string `json:"labels"`
+		} `json:"metadata"`
+	} `json:"items"`
+}
+
+// Well-known node labels naming the zone and region a node runs in
+const (
+	labelZone   = "topology.kubernetes.io/zone"
+	labelRegion = "topology.kubernetes.io/region"
+)
+
+// PodCandidate is a running pod a forward's pod selector matches
+type PodCandidate struct {
+	Name    string
+	Port    int    // the pod's port the forward's remote port goes to
+	Ready   bool   // the pod reports Ready
+	Zone    string // zone of the pod's node, when pods.zone asks for one and nodes can be read
+	InZone  bool   // the node's zone or region is the one pods.zone prefers
+	Pinned  bool   // PinPod chose it
+	Current bool   // the forward's kubectl goes to it now
+}
+
+// resolvePod picks the pod cfg.PodSelector names among the running pods behind
+// cfg's service, and the pod port the service sends cfg.PortRemote to. A
+// label selector narrows the service's own selector. Of several matches the
+// pod pinned by name wins, then ready pods in zone, then ready pods, each by
+// name, so the choice is the same every time. It asks the cluster every
+// time, so a restart follows a pod that was replaced.
+func resolvePod(cfg config.PortForwardConfig, zone, pin string) (PodCandidate, error) {
+	candidates, err := podCandidates(cfg, zone)
+	if err != nil {
+		return PodCandidate{}, err
+	}
+	if len(candidates) == 0 {
+		return PodCandidate{}, fmt.Errorf("no running pod behind service %s matches pod selector %q", cfg.Service, cfg.PodSelector)
+	}
+	chosen := candidates[0]
+	if pin != "" {
+		if i := slices.IndexFunc(candidates, func(c PodCandidate) bool { return c.Name == pin }); i >= 0 {
+			chosen = candidates[i]
+			chosen.Pinned = true
+		} else {
+			logging.LogError("Pod %s '%s' was pinned to is gone; using %s", pin, cfg.ID, chosen.Name)
+		}
+	}
+	logging.LogDebug("Pod selector %q of '%s' resolved to pod %s, port %d", cfg.PodSelector, cfg.ID, chosen.Name, chosen.Port)
+	return chosen, nil
+}
+
+// podCandidates lists the running pods cfg.PodSelector matches in the order
+// resolvePod prefers them: ready before not ready, then those whose node is
+// in zone (a zone or region; "" for no preference), then by name
+func podCandidates(cfg config.PortForwardConfig, zone string) ([]PodCandidate, error) {
+	if err := config.ValidatePodSelector(cfg.PodSelector); err != nil {
+		return nil, err
+	}
+	svc, err := getService(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(svc.Spec.Selector) == 0 {
+		return nil, fmt.Errorf("service %s selects no pods (it has no selector), so pod selector %q cannot apply", cfg.Service, cfg.PodSelector)
+	}
+	terms := svc.selectorTerms()
+	if _, ok := cfg.PodOrdinal(); !ok {
+		terms = append(terms, cfg.PodSelector)
+	}
+
+	args := append(kubectl.KubeconfigArgs(cfg.Kubeconfig), "get", "pods", "--namespace", cfg.Namespace, "--selector", strings.Join(terms, ","), "-o", "json")
+	out, err := kubectl.Run(kubectl.CmdGetPods, append(kubectl.ContextArgs(cfg.Context), args...)...)
+	if err != nil {
+		return nil, err
+	}
+	var pods k8sPods
+	if err := json.Unmarshal(out, &pods); err != nil {
+		return nil, fmt.Errorf("failed to parse kubectl output: %w", err)
+	}
+	var zones, regions map[string]string
+	if zone != "" {
+		zones, regions = nodeTopology(cfg)
+	}
+
+	ordinal, isOrdinal := cfg.PodOrdinal()
+	var candidates []PodCandidate
+	for _, pod := range pods.Items {
+		if pod.Status.Phase != "Running" || pod.Metadata.DeletionTimestamp != nil {
+			continue
+		}
+		if isOrdinal && !strings.HasSuffix(pod.Metadata.Name, "-"+strconv.Itoa(ordinal)) {
+			continue
+		}
+
+		// The service's target port, looked up by name in the pod if need be
+		port := cfg.PortRemote
+		for _, sp := range svc.Spec.Ports {
+			if sp.Port != cfg.PortRemote {
+				continue
+			}
+			switch target := sp.TargetPort.(type) {
+			case float64:
+				port = int(target)
+			case string:
+				for _, c := range pod.Spec.Containers {
+					for _, cp := range c.Ports {
+						if cp.Name == target {
+							port = cp.ContainerPort
+						}
+					}
+				}
+			}
+		}
+		c := PodCandidate{Name: pod.Metadata.Name, Port: port, Zone: zones[pod.Spec.NodeName]}
+		for _, cond := range pod.Status.Conditions {
+			if cond.Type == "Ready" {
+				c.Ready = cond.Status == "True"
+			}
+		}
+		c.InZone = zone != "" && (c.Zone == zone || regions[pod.Spec.NodeName] == zone)
+		candidates = append(candidates, c)
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Ready != b.Ready {
+			return a.Ready
+		}
+		if a.InZone != b.InZone {
+			return a.InZone
+		}
+		return a.Name < b.Name
+	})
+	return candidates, nil
+}
+
+// nodeTopology returns the zone and region of each node of cfg's cluster, by
+// node name. Reading nodes is often not allowed to developers; the pods
+// are then taken in name order as without pods.zone.
+func nodeTopology(cfg config.PortForwardConfig) (zones, regions map[string]string) {
+	args := append(kubectl.KubeconfigArgs(cfg.Kubeconfig), "get", "nodes", "-o", "json")
+	out, err := kubectl.Run(kubectl.CmdGetNodes, append(kubectl.ContextArgs(cfg.Context), args...)...)
+	if err != nil {
+		logging.LogError("Cannot read the nodes of %s to prefer pods by zone: %v", cfg.Context, err)
+		return nil, nil
+	}
+	var nodes k8sNodes
+	if err := json.Unmarshal(out, &nodes); err != nil {
+		logging.LogError("Cannot read the nodes of %s to prefer pods by zone: %v", cfg.Context, err)
+		return nil, nil
+	}
+	zones, regions = make(map[string]string), make(map[string]string)
+	for _, node := range nodes.Items {
+		zones[node.Metadata.Name] = node.Metadata.Labels[labelZone]
+		regions[node.Metadata.Name] = node.Metadata.Labels[labelRegion]
+	}
+	return zones, regions
+}
+
+// withPod points params at the pod cfg.PodSelector resolves to, if it has
+// one, preferring the pod PinPod pinned and the zone of the pods.zone
+// setting.
+func (pf *PortForwarder) withPod(cfg config.PortForwardConfig, params PortForwardParams) (PortForwardParams, error) {
+	if cfg.PodSelector == "" {
+		return params, nil
+	}
+	pf.Mutex.Lock()
+	zone, pin := config.PodZone(pf.podZones, cfg.Context), pf.pins[cfg.ID]
+	pf.Mutex.Unlock()
+	pod, err := resolvePod(cfg, zone, pin)
+	if err != nil {
+		return params, err
+	}
+	params.Pod = pod.Name
+	params.PodZone = pod.Zone
+	params.PortRemote = pod.Port
+	return params, nil
+}
+
+// PodCandidates lists the running pods cfg's pod selector matches, in the
+// order a start picks them when none is pinned, marking the pinned one and
+// the one the forward's kubectl goes to now. Blocking: it runs kubectl.
+func (pf *PortForwarder) PodCandidates(cfg config.PortForwardConfig) ([]PodCandidate, error) {
+	pf.Mutex.Lock()
+	zone, pin := config.PodZone(pf.podZones, cfg.Context), pf.pins[cfg.ID]
+	current := ""
+	if info, ok := pf.RunningForwards[cfg.ID]; ok {
+		current = info.pod
+	}
+	pf.Mutex.Unlock()
+	candidates, err := podCandidates(cfg, zone)
+	for i := range candidates {
+		candidates[i].Pinned = candidates[i].Name == pin
+		candidates[i].Current = candidates[i].Name == current
+	}
+	return candidates, err
+}
+
+// PinPod makes the forward with the given ID go to pod from its next start
+// on, as long as its pod selector matches that pod; "" lets the selector
+// choose again. Restart the forward for it to take effect.
+func (pf *PortForwarder) PinPod(id, pod string) {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	if pod == "" {
+		delete(pf.pins, id)
+		return
+	}
+	pf.pins[id] = pod
+}
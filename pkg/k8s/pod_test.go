@@ -0,0 +1,123 @@
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// installPodKubectl puts a fake kubectl on PATH that answers get service with
+// a service selecting app=db and get pods with three pods of a StatefulSet,
+// one of them terminating. It returns the file the pods query is logged to.
+func installPodKubectl(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	dir := t.TempDir()
+	queryLog := filepath.Join(dir, "query")
+	service := `{"spec":{"selector":{"app":"db"},"ports":[{"port":5432,"targetPort":"pg"},{"port":80,"targetPort":8080}]}}`
+	pod := func(name, extra string) string {
+		return `{"metadata":{"name":"` + name + `"` + extra + `},"spec":{"containers":[{"ports":[{"name":"pg","containerPort":15432}]}]},"status":{"phase":"Running"}}`
+	}
+	pods := `{"items":[` + pod("db-2", "") + `,` + pod("db-0", "") + `,` + pod("db-1", `,"deletionTimestamp":"2026-01-01T00:00:00Z"`) + `]}`
+	script := "#!/bin/sh\ncase \"$*\" in\n" +
+		"*\" service \"*) echo '" + service + "' ;;\n" +
+		"*\" pods \"*) echo \"$*\" > " + queryLog + "; echo '" + pods + "' ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return queryLog
+}
+
+func TestResolvePod(t *testing.T) {
+	queryLog := installPodKubectl(t)
+	cfg := config.PortForwardConfig{ID: "ctx.data.db", Context: "ctx", Namespace: "data", Service: "db", PortRemote: 5432, PortLocal: 5432}
+
+	cfg.PodSelector = "2"
+	if pod, err := resolvePod(cfg, "", ""); err != nil || pod.Name != "db-2" || pod.Port != 15432 {
+		t.Errorf("ordinal 2 resolved to %s:%d (%v), want db-2:15432", pod.Name, pod.Port, err)
+	}
+	if _, err := resolvePod(config.PortForwardConfig{Context: "ctx", Namespace: "data", Service: "db", PodSelector: "1"}, "", ""); err == nil {
+		t.Error("a terminating pod must not be chosen")
+	}
+
+	cfg.PodSelector = "role=primary"
+	cfg.PortRemote = 80
+	if pod, err := resolvePod(cfg, "", ""); err != nil || pod.Name != "db-0" || pod.Port != 8080 {
+		t.Errorf("label selector resolved to %s:%d (%v), want the first pod by name, db-0:8080", pod.Name, pod.Port, err)
+	}
+	query, _ := os.ReadFile(queryLog)
+	if !strings.Contains(string(query), "--selector app=db,role=primary") {
+		t.Errorf("the label selector must narrow the service's, queried %s", query)
+	}
+
+	pf := NewPortForwarder()
+	params, err := pf.withPod(cfg, PortForwardParams{Service: "db", PortRemote: 80})
+	if err != nil || portForwardTarget(params) != "pod/db-0" || params.PortRemote != 8080 {
+		t.Errorf("withPod = %+v, %v", params, err)
+	}
+	cfg.PodSelector = ""
+	if params, _ := pf.withPod(cfg, PortForwardParams{Service: "db", PortRemote: 80}); portForwardTarget(params) != "svc/db" {
+		t.Errorf("without a pod selector the service is forwarded, got %s", portForwardTarget(params))
+	}
+}
+
+func TestResolvePodPrefersReadyPodsInZoneAndPins(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	dir := t.TempDir()
+	service := `{"spec":{"selector":{"app":"api"},"ports":[{"port":80,"targetPort":8080}]}}`
+	pod := func(name, node, ready string) string {
+		return `{"metadata":{"name":"` + name + `"},"spec":{"nodeName":"` + node + `"},"status":{"phase":"Running","conditions":[{"type":"Ready","status":"` + ready + `"}]}}`
+	}
+	pods := `{"items":[` + pod("api-a", "node-1", "False") + `,` + pod("api-b", "node-1", "True") + `,` + pod("api-c", "node-2", "True") + `]}`
+	nodes := `{"items":[{"metadata":{"name":"node-1","labels":{"topology.kubernetes.io/zone":"eu-1a","topology.kubernetes.io/region":"eu-1"}}},` +
+		`{"metadata":{"name":"node-2","labels":{"topology.kubernetes.io/zone":"eu-1b","topology.kubernetes.io/region":"eu-1"}}}]}`
+	script := "#!/bin/sh\ncase \"$*\" in\n" +
+		"*\" service \"*) echo '" + service + "' ;;\n" +
+		"*\" pods \"*) echo '" + pods + "' ;;\n" +
+		"*\" nodes \"*) echo '" + nodes + "' ;;\n" +
+		"esac\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	cfg := config.PortForwardConfig{ID: "ctx.web.api", Context: "ctx", Namespace: "web", Service: "api", PortRemote: 80, PortLocal: 8080, PodSelector: "app=api"}
+
+	for _, tc := range []struct {
+		zone, pin, want string
+	}{
+		{"", "", "api-b"},           // the first ready pod by name, not api-a
+		{"eu-1b", "", "api-c"},      // the ready pod in the zone
+		{"eu-1", "", "api-b"},       // a region takes in both nodes
+		{"eu-1b", "api-a", "api-a"}, // a pin wins, ready or not
+		{"", "api-gone", "api-b"},   // a pinned pod that is gone falls back
+	} {
+		pod, err := resolvePod(cfg, tc.zone, tc.pin)
+		if err != nil || pod.Name != tc.want {
+			t.Errorf("zone %q, pin %q: resolved to %s (%v), want %s", tc.zone, tc.pin, pod.Name, err, tc.want)
+		}
+	}
+
+	pf := NewPortForwarder()
+	pf.ApplySettings(map[string]string{config.SettingPodZonePrefix + "ctx": "eu-1b"})
+	pf.PinPod(cfg.ID, "api-a")
+	candidates, err := pf.PodCandidates(cfg)
+	if err != nil || len(candidates) != 3 {
+		t.Fatalf("candidates = %+v, %v", candidates, err)
+	}
+	if c := candidates[0]; c.Name != "api-c" || !c.InZone || c.Zone != "eu-1b" {
+		t.Errorf("the ready pod in the zone should come first, got %+v", c)
+	}
+	if c := candidates[2]; c.Name != "api-a" || !c.Pinned || c.Ready {
+		t.Errorf("the pod that is not ready should come last, pinned, got %+v", c)
+	}
+}
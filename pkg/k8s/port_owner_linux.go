@@ -0,0 +1,64 @@
+//go:build linux
+
+package k8s
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// tcpListen is the st column of a listening socket in /proc/net/tcp
+const tcpListen = "0A"
+
+// portOwner returns the PID and command name of the process listening on
+// the TCP port, from /proc. Sockets of other users' processes cannot be
+// traced, so a PID of 0 is normal for them.
+func portOwner(port int) (int, string) {
+	inodes := make(map[string]bool)
+	for _, table := range []string{"/proc/net/tcp", "/proc/net/tcp6"} {
+		listeningInodes(table, port, inodes)
+	}
+	if len(inodes) == 0 {
+		return 0, ""
+	}
+	fds, _ := filepath.Glob("/proc/[0-9]*/fd/*")
+	for _, fd := range fds {
+		link, err := os.Readlink(fd)
+		if err != nil || !strings.HasPrefix(link, "socket:[") {
+			continue
+		}
+		if !inodes[strings.TrimSuffix(strings.TrimPrefix(link, "socket:["), "]")] {
+			continue
+		}
+		pidDir := filepath.Dir(filepath.Dir(fd))
+		pid, _ := strconv.Atoi(filepath.Base(pidDir))
+		comm, _ := os.ReadFile(filepath.Join(pidDir, "comm"))
+		return pid, strings.TrimSpace(string(comm))
+	}
+	return 0, ""
+}
+
+// listeningInodes adds the inodes of the sockets listening on port in a
+// /proc/net/tcp style table to inodes
+func listeningInodes(table string, port int, inodes map[string]bool) {
+	f, err := os.Open(table)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+	suffix := fmt.Sprintf(":%04X", port)
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // header
+	for scanner.Scan() {
+		// sl local_address rem_address st tx_queue:rx_queue tr:tm->when retrnsmt uid timeout inode
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 10 || fields[3] != tcpListen || !strings.HasSuffix(fields[1], suffix) {
+			continue
+		}
+		inodes[fields[9]] = true
+	}
+}
@@ -0,0 +1,30 @@
+//go:build !linux
+
+package k8s
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// portOwner returns the PID and command name of the process listening on
+// the TCP port, asking lsof (0 and "" where it is not installed, e.g. on
+// Windows).
+func portOwner(port int) (int, string) {
+	out, err := exec.Command("lsof", "-nP", "-iTCP:"+strconv.Itoa(port), "-sTCP:LISTEN", "-Fpc").Output()
+	if err != nil {
+		return 0, ""
+	}
+	// -F output: one field per line, "p<pid>" then "c<command>"
+	pid, command := 0, ""
+	for _, line := range strings.Split(string(out), "\n") {
+		switch {
+		case strings.HasPrefix(line, "p") && pid == 0:
+			pid, _ = strconv.Atoi(line[1:])
+		case strings.HasPrefix(line, "c") && command == "":
+			command = line[1:]
+		}
+	}
+	return pid, command
+}
@@ -6,11 +6,15 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 )
 
@@ -29,19 +33,43 @@ const startupProbeDelay = 200 * time.Millisecond
 // PortForwardParams contains the essential parameters for starting a port-forward.
 type PortForwardParams struct {
 	Context    string
+	Kubeconfig string
 	Namespace  string
-	Service    string
-	PortRemote int // The target port on the service
-	PortLocal  int // The local port to forward to
+	Service    string // Name of the resource forwarded to, see TargetKind
+	TargetKind string // Kind of that resource, see config.PortForwardConfig.TargetKind
+	PortRemote int    // The target port on the service
+	PortLocal  int    // The local port to forward to
+	PortCount  int    // Consecutive ports forwarded from PortRemote/PortLocal; 0 or 1 for one
+	Listen     string // Loopback addresses to bind, see config.PortForwardConfig.Listen
+	Pod        string // Pod to forward to instead of the service; PortRemote is then the pod's port
+	PodZone    string // Zone of Pod's node, when pods.zone asked for one; only shown, not passed to kubectl
+
+	ExtraArgs string // Extra kubectl flags, see config.PortForwardConfig.KubectlArgs
+}
+
+// failuresKept is how many failures per forward RecentFailures remembers
+const failuresKept = 3
+
+// Failure is one recorded failure of a forward.
+type Failure struct {
+	At     time.Time
+	Reason string // as ErrorReason reported it
 }
 
 // runningInfo holds the command process and the local port being used.
 type runningInfo struct {
-	cmd       *exec.Cmd
-	localPort int
-	startedAt time.Time     // when the process was registered; used to grace-skip health probes
-	stopping  bool          // set (under PortForwarder.Mutex) before an intentional kill
-	done      chan struct{} // closed by the watcher once the process is reaped
+	cmd        *exec.Cmd
+	localPort  int
+	portCount  int           // ports reserved from localPort on (1 unless the forward is a range)
+	host       string        // loopback address health and latency probes dial; 127.0.0.1 if empty
+	startedAt  time.Time     // when the process was registered; used to grace-skip health probes
+	stopping   bool          // set (under PortForwarder.Mutex) before an intentional kill
+	proxied    bool          // backend of a proxied forward; restarted on demand, never auto-restarted
+	pod        string        // pod a PodSelector resolved to; empty when forwarding to the service
+	podPort    int           // port of pod the forward's remote port maps to
+	podZone    string        // zone of pod's node, if looked up
+	healthPath string        // path CheckHealth GETs over HTTP; empty for none
+	done       chan struct{} // closed by the watcher once the process is reaped
 }
 
 // Auto-restart policy for forwards that were running and then broke
@@ -77,10 +105,33 @@ func backoffDelay(priorAttempts int) time.Duration {
 // Forwards are keyed by config ID (stable across config list reordering),
 // never by list index: indices shift when configs are added/removed/edited.
 type PortForwarder struct {
-	RunningForwards  map[string]*runningInfo // Map of config ID to running info
-	activeLocalPorts map[int]string          // Map of active local port -> config ID
-	failedForwards   map[string]string       // ID -> human-readable reason it exited unexpectedly or failed to start
-	retrying         map[string]*retryInfo   // ID -> auto-restart backoff state (transient breaks only)
+	RunningForwards  map[string]*runningInfo             // Map of config ID to running info
+	activeLocalPorts map[int]string                      // Map of active local port -> config ID
+	failedForwards   map[string]string                   // ID -> human-readable reason it exited unexpectedly or failed to start
+	recentFailures   map[string][]Failure                // ID -> last failuresKept failures, oldest first; survives stops and restarts
+	failureCounts    map[string]int                      // ID -> failures since the PortForwarder was created
+	conflicts        map[string]PortConflict             // ID -> other program on a failed forward's port, as of the last ProbeConflicts
+	duplicates       map[string][]ExternalForward        // ID -> kubectl processes kprtfwd did not start forwarding the same target, as of the last ProbeDuplicates
+	retrying         map[string]*retryInfo               // ID -> auto-restart backoff state (transient breaks only)
+	proxies          map[string]*proxyForward            // ID -> kprtfwd-owned local listener (lazy/TLS forwards, see proxy.go)
+	draining         map[*runningInfo]bool               // backends of stopped proxied forwards kept alive for open connections
+	lazyIdleTimeout  time.Duration                       // how long a lazy backend outlives its last client
+	drainTimeout     time.Duration                       // how long Stop lets a proxied forward's open connections finish
+	limits           contextLimits                       // per-context start limits (see limits.go)
+	queue            []config.PortForwardConfig          // starts waiting for their context's limits, oldest first
+	establishing     map[string]string                   // ID -> context of forwards whose kubectl is still connecting
+	forwardContexts  map[string]string                   // ID -> context, recorded when a forward is admitted
+	dispatching      bool                                // a dispatchQueue goroutine is running
+	network          *networkChecker                     // per-context reachability preconditions (see network.go); locks itself
+	hooked           map[string]config.PortForwardConfig // ID -> started forwards with start/stop hooks (see hooks.go)
+	hookRuns         sync.WaitGroup                      // hooks still running
+	stopped          map[string]stopRecord               // ID -> why it last stopped, until it is started again (see stop_reason.go)
+	health           map[string]healthRecord             // ID -> last CheckHealth outcome, until it is started again (see health.go)
+	usage            map[string]usageRecord              // ID -> kubectl's CPU and memory as of the last SampleUsage (see usage.go)
+	memoryLimit      int64                               // kubectl RSS above which SampleUsage reports a forward; 0 for none
+	memoryRestart    bool                                // whether those forwards should be restarted
+	podZones         map[string]string                   // the pods.zone settings, for config.PodZone
+	pins             map[string]string                   // ID -> pod PinPod sent the forward to (see pod.go)
 	// Mutex protects the maps above. It must never be held across blocking
 	// calls (spawning kubectl, waiting on a process); only the non-blocking
 	// Kill signal may be sent while holding it.
@@ -93,7 +144,57 @@ func NewPortForwarder() *PortForwarder {
 		RunningForwards:  make(map[string]*runningInfo),
 		activeLocalPorts: make(map[int]string),
 		failedForwards:   make(map[string]string),
+		recentFailures:   make(map[string][]Failure),
+		failureCounts:    make(map[string]int),
+		conflicts:        make(map[string]PortConflict),
+		duplicates:       make(map[string][]ExternalForward),
+		pins:             make(map[string]string),
 		retrying:         make(map[string]*retryInfo),
+		proxies:          make(map[string]*proxyForward),
+		draining:         make(map[*runningInfo]bool),
+		establishing:     make(map[string]string),
+		forwardContexts:  make(map[string]string),
+		hooked:           make(map[string]config.PortForwardConfig),
+		stopped:          make(map[string]stopRecord),
+		health:           make(map[string]healthRecord),
+		usage:            make(map[string]usageRecord),
+		memoryLimit:      config.DefaultKubectlMemoryLimit,
+		lazyIdleTimeout:  defaultLazyIdleTimeout,
+		drainTimeout:     defaultDrainTimeout,
+		network:          newNetworkChecker(),
+	}
+}
+
+// ApplySettings configures the PortForwarder from stored settings (see
+// config.SettingStopDrainTimeout, the limits.*, network.require.*,
+// kubectl.memory_* and pods.zone settings). Invalid values are logged and ignored.
+func (pf *PortForwarder) ApplySettings(values map[string]string) {
+	drain := defaultDrainTimeout
+	if value, ok := values[config.SettingStopDrainTimeout]; ok {
+		if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+			drain = d
+		} else {
+			logging.LogError("Ignoring invalid %s setting %q", config.SettingStopDrainTimeout, value)
+		}
+	}
+	limits := parseLimits(values)
+	pf.network.apply(values)
+	pf.Mutex.Lock()
+	pf.drainTimeout = drain
+	pf.limits = limits
+	pf.applyUsageSettingsLocked(values)
+	pf.podZones = config.PodZoneSettings(values)
+	pf.kickQueueLocked() // a raised limit may let queued forwards start
+	pf.Mutex.Unlock()
+}
+
+// releasePortsLocked drops the reservations id holds on count local ports
+// starting at first. Caller must hold the mutex.
+func (pf *PortForwarder) releasePortsLocked(id string, first, count int) {
+	for port := first; port < first+max(count, 1); port++ {
+		if holder, reserved := pf.activeLocalPorts[port]; reserved && holder == id {
+			delete(pf.activeLocalPorts, port)
+		}
 	}
 }
 
@@ -114,9 +215,17 @@ func (pf *PortForwarder) clearRetryLocked(id string) {
 	delete(pf.retrying, id)
 }
 
-// isPortAvailable checks if a TCP port is available to listen on localhost.
-func isPortAvailable(port int) bool {
-	address := fmt.Sprintf("127.0.0.1:%d", port)
+// probeHost returns the loopback address the forward's local port is dialed on
+func (info *runningInfo) probeHost() string {
+	if info.host == "" {
+		return "127.0.0.1"
+	}
+	return info.host
+}
+
+// isPortAvailable checks if a TCP port is available to listen on host.
+func isPortAvailable(host string, port int) bool {
+	address := net.JoinHostPort(host, strconv.Itoa(port))
 	listener, err := net.Listen("tcp", address)
 	if err != nil {
 		// Port is likely already in use or another error occurred
@@ -147,13 +256,27 @@ func validateParams(params PortForwardParams) error {
 	if err := config.ValidateKubernetesName("namespace", params.Namespace); err != nil {
 		return err
 	}
-	if err := config.ValidateKubernetesName("service", params.Service); err != nil {
+	if err := config.ValidateKubernetesName(config.PortForwardConfig{TargetKind: params.TargetKind}.KindName(), params.Service); err != nil {
+		return err
+	}
+	if err := config.ValidateTargetKind(params.TargetKind); err != nil {
+		return err
+	}
+	if params.Pod != "" {
+		if err := config.ValidateKubernetesName("pod", params.Pod); err != nil {
+			return err
+		}
+	}
+	if err := config.ValidatePortRange("local port", params.PortLocal, params.PortCount); err != nil {
+		return err
+	}
+	if err := config.ValidatePortRange("remote port", params.PortRemote, params.PortCount); err != nil {
 		return err
 	}
-	if err := config.ValidatePort("local port", params.PortLocal); err != nil {
+	if err := config.ValidateListen(params.Listen); err != nil {
 		return err
 	}
-	return config.ValidatePort("remote port", params.PortRemote)
+	return config.ValidateKubectlArgs(params.ExtraArgs)
 }
 
 // StartPortForward starts a port-forward for a specific set of parameters.
@@ -163,25 +286,25 @@ func StartPortForward(params PortForwardParams) (*exec.Cmd, error) {
 		return nil, err
 	}
 
-	// *** Pre-check if local target port is available ***
-	if !isPortAvailable(params.PortLocal) {
-		// Return the specific sentinel error
-		logging.LogError("Pre-check failed: %v", ErrPortInUse)
-		return nil, ErrPortInUse
+	count := max(params.PortCount, 1)
+
+	// *** Pre-check if local target ports are available ***
+	hosts := config.ListenHosts(params.Listen)
+	for offset := 0; offset < count; offset++ {
+		for _, host := range hosts {
+			if !isPortAvailable(host, params.PortLocal+offset) {
+				// Return the specific sentinel error
+				logging.LogError("Pre-check failed for port %d on %s: %v", params.PortLocal+offset, host, ErrPortInUse)
+				return nil, ErrPortInUse
+			}
+		}
 	}
 	// *** End Pre-check ***
 
-	logging.LogDebug("Attempting port-forward: kubectl port-forward --namespace %s svc/%s %d:%d context=%s", params.Namespace, params.Service, params.PortRemote, params.PortLocal, params.Context)
+	logging.LogDebug("Attempting port-forward: kubectl port-forward --namespace %s %s %d:%d (%d port(s)) context=%s", params.Namespace, portForwardTarget(params), params.PortRemote, params.PortLocal, count, params.Context)
 
-	args := []string{"port-forward",
-		"--namespace", params.Namespace,
-		fmt.Sprintf("svc/%s", params.Service),
-		fmt.Sprintf("%d:%d", params.PortLocal, params.PortRemote),
-	}
-	if params.Context != "" {
-		args = append([]string{"--context", params.Context}, args...)
-	}
-	cmd := exec.Command("kubectl", args...)
+	args := portForwardArgs(params)
+	cmd := exec.Command(kubectl.Binary(), args...)
 
 	// Put kubectl in its own process group so that any child processes it
 	// spawns (SSO exec-credential plugins, browser launchers) can be killed as
@@ -257,7 +380,7 @@ func (pf *PortForwarder) watch(id string, info *runningInfo) {
 }
 
 // handleProcessExit deregisters a forward whose process exited on its own and
-// records it as errored so the UI can show an Error status.
+// records it as errored so the UI can show it as Failed.
 func (pf *PortForwarder) handleProcessExit(id string, info *runningInfo, waitErr error) {
 	pf.Mutex.Lock()
 	defer pf.Mutex.Unlock()
@@ -272,9 +395,8 @@ func (pf *PortForwarder) handleProcessExit(id string, info *runningInfo, waitErr
 		return
 	}
 	delete(pf.RunningForwards, id)
-	if holder, reserved := pf.activeLocalPorts[info.localPort]; reserved && holder == id {
-		delete(pf.activeLocalPorts, info.localPort)
-	}
+	pf.releasePortsLocked(id, info.localPort, info.portCount)
+	pf.kickQueueLocked()
 
 	// Safe to read only now: exec's copy goroutine finished when Wait returned.
 	stderrStr := drainStderr(info.cmd)
@@ -282,9 +404,17 @@ func (pf *PortForwarder) handleProcessExit(id string, info *runningInfo, waitErr
 	if reason == "" {
 		reason = fmt.Sprintf("kubectl exited unexpectedly (%v)", waitErr)
 	}
-	pf.failedForwards[id] = reason
+	pf.recordFailureLocked(id, reason)
+	pf.recordStopLocked(id, StopReasonFailed)
 	logging.LogError("Port-forward '%s' (port %d) exited unexpectedly: %v (stderr: %s)", id, info.localPort, waitErr, stderrStr)
 
+	// A proxied forward's listener is still up; the next client starts a new
+	// kubectl, so there is nothing to auto-restart.
+	if info.proxied {
+		return
+	}
+	pf.runStopHookLocked(id)
+
 	// Auto-restart only forwards that were genuinely running and then broke. A
 	// process that dies during the startup probe window is an initial-start
 	// failure (usually a misconfiguration), so it is left for manual Ctrl+R.
@@ -297,38 +427,107 @@ func (pf *PortForwarder) handleProcessExit(id string, info *runningInfo, waitErr
 func (pf *PortForwarder) Start(cfg config.PortForwardConfig) error {
 	id := cfg.ID
 	localPort := cfg.PortLocal // Get local port for checks
+	portCount := cfg.Ports()
+
+	// A context behind a VPN fails with connection errors that do not say
+	// so; probe what it requires first (outside the mutex, it dials)
+	if err := pf.network.check(cfg.Context); err != nil {
+		logging.LogError("Cannot start '%s': %v", id, err)
+		return err
+	}
 
 	pf.Mutex.Lock()
-	if _, exists := pf.RunningForwards[id]; exists {
+	_, running := pf.RunningForwards[id]
+	_, proxied := pf.proxies[id]
+	if running || proxied {
 		logging.LogDebug("Port-forward for '%s' already marked as running.", id)
 		pf.Mutex.Unlock()
 		return nil // Already running, not an error
 	}
 
+	if pf.queuedIndexLocked(id) >= 0 {
+		pf.Mutex.Unlock()
+		return nil // Already waiting for its turn
+	}
+
 	// *** Check internal reservation first ***
-	if conflictingID, reserved := pf.activeLocalPorts[localPort]; reserved {
-		logging.LogError("Cannot start '%s': %v (port %d reserved by '%s')", id, ErrLocalPortReserved, localPort, conflictingID)
+	for port := localPort; port < localPort+portCount; port++ {
+		if conflictingID, reserved := pf.activeLocalPorts[port]; reserved {
+			logging.LogError("Cannot start '%s': %v (port %d reserved by '%s')", id, ErrLocalPortReserved, port, conflictingID)
+			pf.Mutex.Unlock()
+			return ErrLocalPortReserved // Return specific error
+		}
+	}
+
+	delete(pf.stopped, id) // started again: the last stop no longer describes it
+	delete(pf.health, id)
+
+	// *** Respect the context's start limits; queue if they are reached ***
+	if !pf.hasCapacityLocked(cfg) {
+		pf.queue = append(pf.queue, cfg)
+		delete(pf.failedForwards, id)
+		pf.clearRetryLocked(id)
+		logging.LogDebug("Queued '%s': context '%s' is at its start limit (%d waiting)", id, cfg.Context, len(pf.queue))
 		pf.Mutex.Unlock()
-		return ErrLocalPortReserved // Return specific error
+		return nil
+	}
+	pf.forwardContexts[id] = cfg.Context
+	handedOff := false
+	if !usesProxy(cfg) {
+		// Hold a starting slot until kubectl has connected. Every failure
+		// below frees it; on success awaitEstablished takes it over.
+		pf.establishing[id] = cfg.Context
+		defer func() {
+			if !handedOff {
+				pf.finishEstablishing(id)
+			}
+		}()
 	}
 
-	// *** Reserve the port internally ***
-	pf.activeLocalPorts[localPort] = id
-	logging.LogDebug("Reserved local port %d for '%s'", localPort, id)
+	// *** Reserve the port(s) internally ***
+	for port := localPort; port < localPort+portCount; port++ {
+		pf.activeLocalPorts[port] = id
+	}
+	logging.LogDebug("Reserved %d local port(s) from %d for '%s'", portCount, localPort, id)
 	pf.Mutex.Unlock() // Unlock *before* calling potentially blocking StartPortForward helper
 
+	if usesProxy(cfg) {
+		err := pf.startProxy(cfg)
+		pf.Mutex.Lock()
+		if err != nil {
+			pf.releasePortsLocked(id, localPort, portCount)
+			pf.recordFailureLocked(id, err.Error())
+		} else {
+			pf.trackHooksLocked(cfg)
+		}
+		pf.Mutex.Unlock()
+		if err == nil {
+			pf.runStartHook(id) // the listener is up; clients start kubectl
+		}
+		return err
+	}
+
 	// Fallback: Check if port is actually available using net.Listen (done inside StartPortForward)
-	// Create params struct from config
-	params := PortForwardParams{
+	// Create params struct from config; a pod selector is resolved on every
+	// start, so a restart follows a replaced pod
+	params, err := pf.withPod(cfg, PortForwardParams{
 		Context:    cfg.Context,
 		Namespace:  cfg.Namespace,
 		Service:    cfg.Service,
+		TargetKind: cfg.TargetKind,
 		PortRemote: cfg.PortRemote,
 		PortLocal:  localPort,
-	}
+		PortCount:  portCount,
+		Kubeconfig: cfg.Kubeconfig,
+		Listen:     cfg.Listen,
+		ExtraArgs:  cfg.KubectlArgs,
+	})
 
 	// Call the helper function (which performs the net.Listen check)
-	cmd, err := StartPortForward(params)
+	var cmd *exec.Cmd
+	if err == nil {
+		cmd, err = StartPortForward(params)
+	}
 
 	// --- Handle outcome ---
 	pf.Mutex.Lock() // Re-acquire lock to update state
@@ -336,28 +535,31 @@ func (pf *PortForwarder) Start(cfg config.PortForwardConfig) error {
 	if err != nil || cmd == nil {
 		// Start failed, release the reservation and record error state
 		if currentHolder, ok := pf.activeLocalPorts[localPort]; ok && currentHolder == id {
-			delete(pf.activeLocalPorts, localPort)
+			pf.releasePortsLocked(id, localPort, portCount)
 			logging.LogDebug("Released local port %d reservation for '%s' due to start failure: %v", localPort, id, err)
 		} else {
 			// Log if reservation was already gone or held by someone else (shouldn't happen ideally)
 			logging.LogError("Could not release reservation for port %d ('%s') after start failure. Current holder: '%s', Exists: %t", localPort, id, currentHolder, ok)
 		}
 		if err != nil {
-			pf.failedForwards[id] = err.Error()
+			pf.recordFailureLocked(id, err.Error())
 			pf.Mutex.Unlock()
 			logging.LogError("Failed to start port-forward '%s': %v", id, err)
 			return err // Return the original error from StartPortForward
 		}
-		pf.failedForwards[id] = "kubectl did not start"
+		pf.recordFailureLocked(id, "kubectl did not start")
 		pf.Mutex.Unlock()
 		return fmt.Errorf("StartPortForward returned nil command without error for '%s'", id)
 	}
 
 	// Start succeeded — clear any previous error and register the forward.
 	delete(pf.failedForwards, id)
-	info := &runningInfo{cmd: cmd, localPort: localPort, startedAt: time.Now(), done: make(chan struct{})}
+	info := &runningInfo{cmd: cmd, localPort: localPort, portCount: portCount, host: config.ListenHosts(cfg.Listen)[0], startedAt: time.Now(), pod: params.Pod, podPort: params.PortRemote, podZone: params.PodZone, healthPath: healthPathFor(cfg), done: make(chan struct{})}
 	pf.RunningForwards[id] = info
+	pf.trackHooksLocked(cfg)
 	go pf.watch(id, info)
+	go pf.awaitEstablished(id, info)
+	handedOff = true
 	logging.LogDebug("Successfully started and registered port-forward for '%s' (PID: %d, Port: %d)", id, cmd.Process.Pid, localPort)
 	pf.Mutex.Unlock()
 
@@ -370,7 +572,7 @@ func (pf *PortForwarder) Start(cfg config.PortForwardConfig) error {
 	select {
 	case <-info.done:
 		if stderrStr := drainStderr(cmd); stderrStr != "" {
-			return fmt.Errorf("kubectl exited: %s", stderrStr)
+			return kubectl.Classify(fmt.Errorf("kubectl exited: %s", stderrStr), stderrStr)
 		}
 		return fmt.Errorf("kubectl exited immediately (check VPN / kube context / port conflicts)")
 	case <-time.After(startupProbeDelay):
@@ -383,9 +585,35 @@ func (pf *PortForwarder) Start(cfg config.PortForwardConfig) error {
 }
 
 // Stop attempts to stop the port-forward process for the given config ID.
+// A proxied forward stops accepting clients at once, but connections already
+// open are given up to the drain timeout to finish (see drainProxyLocked).
+// The stop is recorded as the user's; see StopFor for other reasons.
 func (pf *PortForwarder) Stop(id string) error {
+	return pf.stop(id, StopReasonUser)
+}
+
+// stop is Stop, recording reason as why the forward stopped
+func (pf *PortForwarder) stop(id string, reason StopReason) error {
 	pf.Mutex.Lock()
 
+	if pf.dequeueLocked(id) {
+		pf.recordStopLocked(id, reason)
+		pf.Mutex.Unlock()
+		logging.LogDebug("Stop: Removed queued forward '%s'", id)
+		return nil
+	}
+	defer pf.kickQueue() // the stop may free room for a queued forward
+
+	if _, proxied := pf.proxies[id]; proxied {
+		pf.drainProxyLocked(id)
+		delete(pf.failedForwards, id)
+		pf.clearRetryLocked(id)
+		pf.runStopHookLocked(id)
+		pf.recordStopLocked(id, reason)
+		pf.Mutex.Unlock()
+		return nil
+	}
+
 	info, exists := pf.RunningForwards[id]
 	if !exists {
 		// Not running (or not tracked). Still clear any error state, since an
@@ -407,7 +635,7 @@ func (pf *PortForwarder) Stop(id string) error {
 	// Release internal reservation first
 	if currentHolder, reserved := pf.activeLocalPorts[localPort]; reserved {
 		if currentHolder == id {
-			delete(pf.activeLocalPorts, localPort)
+			pf.releasePortsLocked(id, localPort, info.portCount)
 			logging.LogDebug("Stop: Released internal reservation for local port %d ('%s')", localPort, id)
 		} else {
 			// Log if reservation was held by someone else (indicates inconsistency)
@@ -424,6 +652,8 @@ func (pf *PortForwarder) Stop(id string) error {
 
 	// Remove from running map
 	delete(pf.RunningForwards, id)
+	pf.runStopHookLocked(id)
+	pf.recordStopLocked(id, reason)
 	pf.Mutex.Unlock()
 
 	// Kill outside the lock; the watcher goroutine reaps the process.
@@ -435,8 +665,16 @@ func (pf *PortForwarder) Stop(id string) error {
 	return err
 }
 
-// stopInternal stops a forward assuming the lock is already held.
-func (pf *PortForwarder) stopInternal(id string) error {
+// stopInternal stops a forward for reason assuming the lock is already held.
+func (pf *PortForwarder) stopInternal(id string, reason StopReason) error {
+	if _, proxied := pf.proxies[id]; proxied {
+		pf.drainProxyLocked(id)
+		delete(pf.failedForwards, id)
+		pf.clearRetryLocked(id)
+		pf.runStopHookLocked(id)
+		pf.recordStopLocked(id, reason)
+		return nil
+	}
 	info, exists := pf.RunningForwards[id]
 	if !exists {
 		delete(pf.failedForwards, id) // intentional stop clears error state
@@ -445,24 +683,39 @@ func (pf *PortForwarder) stopInternal(id string) error {
 	}
 	info.stopping = true
 	localPort := info.localPort
-	if currentHolder, reserved := pf.activeLocalPorts[localPort]; reserved && currentHolder == id {
-		delete(pf.activeLocalPorts, localPort)
-	}
+	pf.releasePortsLocked(id, localPort, info.portCount)
 	delete(pf.failedForwards, id) // intentional stop clears error state
 	pf.clearRetryLocked(id)
 	delete(pf.RunningForwards, id)
+	pf.runStopHookLocked(id)
+	pf.recordStopLocked(id, reason)
+	pf.kickQueueLocked()
 	// Kill is a non-blocking signal; the watcher goroutine reaps the process.
 	err := killProcess(info.cmd)
 	logging.LogDebug("stopInternal: Stopped '%s' (Port: %d)", id, localPort)
 	return err
 }
 
-// IsRunning checks if a port forward is currently running for the given config ID
+// IsRunning checks if a port forward is currently running for the given config
+// ID. A proxied forward counts as running while its local listener is up,
+// even if kubectl is idle (see IsStandby).
 func (pf *PortForwarder) IsRunning(id string) bool {
 	pf.Mutex.Lock()
 	defer pf.Mutex.Unlock()
 	_, exists := pf.RunningForwards[id]
-	return exists
+	_, proxied := pf.proxies[id]
+	return exists || proxied
+}
+
+// PID returns the process ID of the forward's kubectl, or 0 if none is running
+// (stopped, or a lazy forward waiting for its first connection).
+func (pf *PortForwarder) PID(id string) int {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	if info, exists := pf.RunningForwards[id]; exists && info.cmd.Process != nil {
+		return info.cmd.Process.Pid
+	}
+	return 0
 }
 
 // IsError reports whether the port-forward with the given ID is in an error
@@ -484,139 +737,164 @@ func (pf *PortForwarder) ErrorReason(id string) string {
 	return pf.failedForwards[id]
 }
 
-// StopAllRunning stops every currently running port-forward and returns how
-// many were stopped. Error state is cleared for each (intentional action).
-func (pf *PortForwarder) StopAllRunning() int {
+// RecentFailures returns the last few failures of the forward with the given
+// ID, oldest first. Unlike ErrorReason they outlive stops and clean restarts,
+// so a forward that keeps failing intermittently can still be diagnosed.
+func (pf *PortForwarder) RecentFailures(id string) []Failure {
 	pf.Mutex.Lock()
 	defer pf.Mutex.Unlock()
-	ids := make([]string, 0, len(pf.RunningForwards))
+	return slices.Clone(pf.recentFailures[id])
+}
+
+// recordFailureLocked puts the forward in the error state with reason and
+// adds it to its recent failures. Caller must hold the mutex.
+func (pf *PortForwarder) recordFailureLocked(id, reason string) {
+	pf.failedForwards[id] = reason
+	failures := append(pf.recentFailures[id], Failure{At: time.Now(), Reason: reason})
+	pf.recentFailures[id] = failures[max(len(failures)-failuresKept, 0):]
+	pf.failureCounts[id]++
+}
+
+// FailureKind classifies the reason the forward with the given ID last failed
+// as one of the kubectl sentinel errors (kubectl.ErrAuthExpired, ...), or
+// returns nil if it is not in an error state or the reason is unrecognised.
+func (pf *PortForwarder) FailureKind(id string) error {
+	return kubectl.KindOf(pf.ErrorReason(id))
+}
+
+// activeIDsLocked returns the IDs of running forwards, including proxied
+// forwards whose kubectl is idle. Caller must hold the mutex.
+func (pf *PortForwarder) activeIDsLocked() []string {
+	ids := make([]string, 0, len(pf.RunningForwards)+len(pf.proxies))
 	for id := range pf.RunningForwards {
 		ids = append(ids, id)
 	}
+	for id := range pf.proxies {
+		if _, running := pf.RunningForwards[id]; !running {
+			ids = append(ids, id)
+		}
+	}
+	return ids
+}
+
+// StopAllRunning stops every currently running port-forward, and drops every
+// queued start, and returns how many were stopped. Error state is cleared for
+// each (intentional action).
+func (pf *PortForwarder) StopAllRunning() int {
+	return pf.StopAllFor(StopReasonUser)
+}
+
+// StopAllFor is StopAllRunning, recording reason as why each forward stopped
+func (pf *PortForwarder) StopAllFor(reason StopReason) int {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	queued := len(pf.queue)
+	for _, cfg := range pf.queue {
+		pf.recordStopLocked(cfg.ID, reason)
+	}
+	pf.queue = nil
+	ids := pf.activeIDsLocked()
 	for _, id := range ids {
-		_ = pf.stopInternal(id)
+		_ = pf.stopInternal(id, reason)
 	}
-	return len(ids)
+	return len(ids) + queued
 }
 
 // CleanupAll stops all port-forwards
 func (pf *PortForwarder) CleanupAll() {
+	defer pf.waitForHooks(hookQuitWait) // after unlocking
 	pf.Mutex.Lock()
 	defer pf.Mutex.Unlock()
-	ids := make([]string, 0, len(pf.RunningForwards))
-	for id := range pf.RunningForwards {
-		ids = append(ids, id)
-	}
+	pf.queue = nil
+	ids := pf.activeIDsLocked()
 	for _, id := range ids {
 		logging.LogDebug("CleanupAll: Stopping '%s'", id)
-		_ = pf.stopInternal(id) // Call internal stop
+		_ = pf.stopInternal(id, StopReasonUser) // Call internal stop
+	}
+	for info := range pf.draining {
+		_ = killProcess(info.cmd)
 	}
 	pf.RunningForwards = make(map[string]*runningInfo)
 	pf.activeLocalPorts = make(map[int]string)
 	pf.failedForwards = make(map[string]string)
 	pf.retrying = make(map[string]*retryInfo)
+	pf.proxies = make(map[string]*proxyForward)
+	pf.draining = make(map[*runningInfo]bool)
+	pf.establishing = make(map[string]string)
 	logging.LogDebug("CleanupAll finished.")
 }
 
-// isPortForwardHealthy dials localhost:localPort and determines whether kubectl's
-// tunnel is live. A healthy tunnel: kubectl holds the connection open waiting to
-// forward data → our read times out. A broken tunnel (VPN down, pod gone): kubectl
-// closes the connection immediately → we get EOF. Connection refused means kubectl
-// is no longer listening (also unhealthy).
+// tunnelProblem dials localhost:localPort and determines whether kubectl's
+// tunnel is live; it returns why not, or "" if it is. A healthy tunnel: kubectl
+// holds the connection open waiting to forward data → our read times out. A
+// broken tunnel (VPN down, pod gone): kubectl closes the connection immediately
+// → we get EOF. Connection refused means nothing listens on the port anymore,
+// e.g. kubectl was suspended or lost the socket. The other ports of a range are
+// only checked for still being bound.
 //
 // Limitation: silent packet-drop black-holes (VPN route gone, no RST) cannot be
 // detected this way because kubectl still appears to hold the connection.
-func isPortForwardHealthy(localPort int) bool {
-	address := fmt.Sprintf("127.0.0.1:%d", localPort)
+func tunnelProblem(host string, localPort, portCount int) string {
+	notListening := "local port %d is no longer listening"
+	address := net.JoinHostPort(host, strconv.Itoa(localPort))
 	conn, err := net.DialTimeout("tcp", address, 200*time.Millisecond)
 	if err != nil {
-		return false
+		return fmt.Sprintf(notListening, localPort)
 	}
 	defer conn.Close()
 	_ = conn.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
 	buf := make([]byte, 1)
 	_, err = conn.Read(buf)
-	if err == nil {
-		return true // received data — definitely healthy
-	}
 	var netErr net.Error
-	if errors.As(err, &netErr) && netErr.Timeout() {
-		return true // held open — tunnel is live
+	if err != nil && !(errors.As(err, &netErr) && netErr.Timeout()) {
+		// EOF or other error — upstream unreachable
+		return fmt.Sprintf("tunnel health check failed on local port %d (VPN down or upstream unreachable)", localPort)
 	}
-	return false // EOF or other error — upstream unreachable
-}
-
-// ProbeAllTunnels checks every running forward's TCP tunnel health concurrently
-// and returns the IDs of forwards whose tunnel appears broken. Forwards started
-// within the grace period are skipped so a just-started tunnel isn't flagged
-// before kubectl has finished establishing it. Blocking; call from a goroutine
-// or tea.Cmd.
-func (pf *PortForwarder) ProbeAllTunnels() []string {
-	const probeGrace = 5 * time.Second // don't probe a forward that just started
+	// Received data or held open — the tunnel is live
 
-	pf.Mutex.Lock()
-	toProbe := make(map[string]int) // id → localPort
-	for id, info := range pf.RunningForwards {
-		if time.Since(info.startedAt) < probeGrace {
-			continue
+	for port := localPort + 1; port < localPort+portCount; port++ {
+		if isPortAvailable(host, port) {
+			return fmt.Sprintf(notListening, port)
 		}
-		toProbe[id] = info.localPort
 	}
-	pf.Mutex.Unlock()
-
-	if len(toProbe) == 0 {
-		return nil
-	}
-
-	type result struct {
-		id      string
-		healthy bool
-	}
-	ch := make(chan result, len(toProbe))
-	for id, port := range toProbe {
-		go func(i string, p int) {
-			ch <- result{i, isPortForwardHealthy(p)}
-		}(id, port)
-	}
-
-	var broken []string
-	for range toProbe {
-		r := <-ch
-		if !r.healthy {
-			broken = append(broken, r.id)
-		}
-	}
-	return broken
+	return ""
 }
 
-// MarkBroken kills and deregisters the forwards with the given IDs, marking each
-// as errored. Used to record tunnels that the TCP health probe found broken.
-// The killed process is reaped by its own watcher goroutine.
-func (pf *PortForwarder) MarkBroken(ids []string) {
-	if len(ids) == 0 {
+// MarkBroken kills and deregisters the forwards in broken, marking each as
+// errored with its reason. Used to record tunnels that the TCP health probe
+// found broken. The killed process is reaped by its own watcher goroutine.
+func (pf *PortForwarder) MarkBroken(broken map[string]string) {
+	if len(broken) == 0 {
 		return
 	}
 	pf.Mutex.Lock()
 	defer pf.Mutex.Unlock()
-	for _, id := range ids {
+	for id, reason := range broken {
 		info, ok := pf.RunningForwards[id]
 		if !ok {
 			continue
 		}
-		if holder, ok2 := pf.activeLocalPorts[info.localPort]; ok2 && holder == id {
-			delete(pf.activeLocalPorts, info.localPort)
-		}
+		pf.releasePortsLocked(id, info.localPort, info.portCount)
 		delete(pf.RunningForwards, id)
-		pf.failedForwards[id] = fmt.Sprintf("tunnel health check failed on local port %d (VPN down or upstream unreachable)", info.localPort)
+		pf.recordFailureLocked(id, reason)
+		pf.recordStopLocked(id, StopReasonFailed)
+		if info.proxied {
+			// The proxy listener stays up and starts a fresh kubectl for the
+			// next client; no auto-restart needed.
+			info.stopping = true
+			_ = killProcess(info.cmd)
+			continue
+		}
 		// A broken tunnel is a transient failure of a running forward, so it is
 		// eligible for auto-restart.
 		pf.markRetryEligibleLocked(id)
-		logging.LogError("MarkBroken: tunnel broken for '%s' (port %d); killing process", id, info.localPort)
+		logging.LogError("MarkBroken: tunnel broken for '%s': %s; killing process", id, reason)
 		// Non-blocking kill under the lock (allowed by the mutex contract);
 		// the forward's watcher owns Wait and will reap it, then see the entry
 		// is gone and leave the error state we just set in place.
 		_ = killProcess(info.cmd)
 	}
+	pf.kickQueueLocked()
 }
 
 // RetryStatus reports whether an auto-restart is scheduled for the given ID and
@@ -694,8 +972,17 @@ func (pf *PortForwarder) AutoRestart(configs []config.PortForwardConfig) []strin
 
 // RestartResult represents the outcome of a restart operation
 type RestartResult struct {
-	RestartedCount int              // Number of port forwards restarted
-	Errors         map[string]error // Errors by config ID
+	RestartedCount int                // Number of port forwards restarted
+	Errors         map[string]error   // Errors by config ID
+	Forwards       []RestartedForward // Every forward a restart was attempted for, sorted by ID
+}
+
+// RestartedForward is the outcome of restarting one forward
+type RestartedForward struct {
+	ID        string
+	LocalPort int
+	PID       int   // kubectl's process ID after the restart; 0 while a lazy forward waits for a connection
+	Err       error // nil if the forward is up again
 }
 
 // processReapTimeout bounds how long a restart waits for the killed kubectl
@@ -703,6 +990,39 @@ type RestartResult struct {
 // the replacement.
 const processReapTimeout = 5 * time.Second
 
+// Restart stops the forward with cfg's ID (if running) and starts it again
+// from cfg, so a changed configuration takes effect. For a forward in an error
+// state the stop is a cheap no-op that just clears the flag.
+func (pf *PortForwarder) Restart(cfg config.PortForwardConfig) error {
+	id := cfg.ID
+
+	// Grab the running info (nil for a purely-errored forward) so we can
+	// wait for the old process to be reaped after Stop; starting again
+	// while it still holds the local socket would trip the port pre-check.
+	pf.Mutex.Lock()
+	oldInfo := pf.RunningForwards[id]
+	pf.Mutex.Unlock()
+
+	if err := pf.Stop(id); err != nil {
+		return fmt.Errorf("failed to stop: %w", err)
+	}
+
+	// A proxied forward's kubectl runs on an internal port and may still be
+	// draining open connections; the replacement gets its own, so don't wait.
+	if oldInfo != nil && oldInfo.done != nil && !oldInfo.proxied {
+		select {
+		case <-oldInfo.done:
+		case <-time.After(processReapTimeout):
+			logging.LogError("Restart: Timed out waiting for '%s' to exit; attempting start anyway", id)
+		}
+	}
+
+	if err := pf.Start(cfg); err != nil {
+		return fmt.Errorf("failed to restart: %w", err)
+	}
+	return nil
+}
+
 // RestartForwards restarts every forward that is currently running OR in an
 // error state. Running forwards are stopped and started again (useful after a
 // VPN drop); errored forwards are simply (re)started so the user can recover a
@@ -734,52 +1054,63 @@ func (pf *PortForwarder) RestartForwards(configs []config.PortForwardConfig) *Re
 
 	logging.LogDebug("RestartForwards: Found %d port forwards to restart (running + errored)", len(targets))
 
+	ids := make([]string, 0, len(targets))
 	for id := range targets {
+		ids = append(ids, id)
+	}
+	pf.restartIDs(ids, configsByID, result)
+	return result
+}
+
+// RestartRunning restarts those of configs that are currently running, for
+// restarting a group or project without starting its stopped or failed
+// members. The result is reported as RestartForwards reports it.
+func (pf *PortForwarder) RestartRunning(configs []config.PortForwardConfig) *RestartResult {
+	result := &RestartResult{Errors: make(map[string]error)}
+	configsByID := make(map[string]config.PortForwardConfig, len(configs))
+	var ids []string
+	pf.Mutex.Lock()
+	for _, cfg := range configs {
+		_, running := pf.RunningForwards[cfg.ID]
+		_, proxied := pf.proxies[cfg.ID]
+		if running || proxied {
+			configsByID[cfg.ID] = cfg
+			ids = append(ids, cfg.ID)
+		}
+	}
+	pf.Mutex.Unlock()
+
+	logging.LogDebug("RestartRunning: Found %d of %d port forwards running", len(ids), len(configs))
+	pf.restartIDs(ids, configsByID, result)
+	return result
+}
+
+// restartIDs restarts the forwards ids in ID order, recording each outcome
+// in result
+func (pf *PortForwarder) restartIDs(ids []string, configsByID map[string]config.PortForwardConfig, result *RestartResult) {
+	sort.Strings(ids)
+	for _, id := range ids {
 		cfg, found := configsByID[id]
 		if !found {
 			logging.LogError("RestartForwards: Config '%s' no longer exists", id)
 			result.Errors[id] = fmt.Errorf("config '%s' no longer exists", id)
+			result.Forwards = append(result.Forwards, RestartedForward{ID: id, Err: result.Errors[id]})
 			continue
 		}
 
 		logging.LogDebug("RestartForwards: Restarting port forward '%s' (%s)", id, cfg.Service)
 
-		// Grab the running info (nil for a purely-errored forward) so we can
-		// wait for the old process to be reaped after Stop; starting again
-		// while it still holds the local socket would trip the port pre-check.
-		pf.Mutex.Lock()
-		oldInfo := pf.RunningForwards[id]
-		pf.Mutex.Unlock()
-
-		// Stop clears any error state and, if running, kills the process. For a
-		// purely-errored forward this is a cheap no-op that just clears the flag.
-		stopErr := pf.Stop(id)
-		if stopErr != nil {
-			logging.LogError("RestartForwards: Failed to stop port forward '%s': %v", id, stopErr)
-			result.Errors[id] = fmt.Errorf("failed to stop: %w", stopErr)
-			continue
-		}
-
-		if oldInfo != nil && oldInfo.done != nil {
-			select {
-			case <-oldInfo.done:
-			case <-time.After(processReapTimeout):
-				logging.LogError("RestartForwards: Timed out waiting for '%s' to exit; attempting start anyway", id)
-			}
-		}
-
-		// Start it again with the same config
-		startErr := pf.Start(cfg)
-		if startErr != nil {
-			logging.LogError("RestartForwards: Failed to start port forward '%s': %v", id, startErr)
-			result.Errors[id] = fmt.Errorf("failed to restart: %w", startErr)
+		if err := pf.Restart(cfg); err != nil {
+			logging.LogError("RestartForwards: Failed to restart port forward '%s': %v", id, err)
+			result.Errors[id] = err
+			result.Forwards = append(result.Forwards, RestartedForward{ID: id, LocalPort: cfg.PortLocal, Err: err})
 			continue
 		}
 
 		result.RestartedCount++
+		result.Forwards = append(result.Forwards, RestartedForward{ID: id, LocalPort: cfg.PortLocal, PID: pf.PID(id)})
 		logging.LogDebug("RestartForwards: Successfully restarted port forward '%s' (%s)", id, cfg.Service)
 	}
 
 	logging.LogDebug("RestartForwards: Complete - Restarted: %d, Errors: %d", result.RestartedCount, len(result.Errors))
-	return result
 }
@@ -8,6 +8,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 	"strings"
 	"testing"
 	"time"
@@ -282,9 +283,13 @@ func TestRestartForwardsReplacesProcess(t *testing.T) {
 	if !pf.IsRunning(cfg.ID) {
 		t.Fatal("forward should be running after restart")
 	}
-	if newPid := currentPid(t, pf, cfg.ID); newPid == oldPid {
+	newPid := currentPid(t, pf, cfg.ID)
+	if newPid == oldPid {
 		t.Fatal("restart must replace the process, but the PID is unchanged")
 	}
+	if len(result.Forwards) != 1 || result.Forwards[0].PID != newPid || result.Forwards[0].LocalPort != cfg.PortLocal {
+		t.Fatalf("report should list the new process, got %+v", result.Forwards)
+	}
 }
 
 // Configs carrying values kubectl would parse as flags must be rejected
@@ -307,6 +312,9 @@ func TestStartRejectsFlagInjectionValues(t *testing.T) {
 		{"remote port out of range", config.PortForwardConfig{
 			ID: "d", Context: "ctx", Namespace: "ns",
 			Service: "web", PortRemote: 0, PortLocal: 18080}},
+		{"positional kubectl argument", config.PortForwardConfig{
+			ID: "e", Context: "ctx", Namespace: "ns",
+			Service: "web", PortRemote: 80, PortLocal: 18080, KubectlArgs: "svc/other"}},
 	}
 
 	for _, tc := range cases {
@@ -328,6 +336,217 @@ func TestStartRejectsFlagInjectionValues(t *testing.T) {
 	}
 }
 
+func TestStartAppendsKubectlArgs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\nexec sleep 30\n", argsFile)
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web",
+		PortRemote: 80, PortLocal: freeLocalPort(t), KubectlArgs: "--pod-running-timeout=2m  --v=6"}
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	got, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("kubectl did not record its arguments: %v", err)
+	}
+	want := fmt.Sprintf("--context ctx port-forward --namespace ns svc/web %d:80 --pod-running-timeout=2m --v=6\n", cfg.PortLocal)
+	if string(got) != want {
+		t.Fatalf("kubectl arguments = %q, want %q", got, want)
+	}
+}
+
+func TestKubectlCommand(t *testing.T) {
+	cfg := config.PortForwardConfig{
+		ID: "prod.payments.api", Context: "prod", Namespace: "payments", Service: "api",
+		PortRemote: 8080, PortLocal: 18080, PortCount: 2, Listen: config.ListenDual,
+		KubectlArgs: "--pod-running-timeout=2m",
+	}
+	want := "kubectl --context prod port-forward --namespace payments svc/api 18080:8080 18081:8081 --address 127.0.0.1,::1 --pod-running-timeout=2m"
+	if got := KubectlCommand(cfg, ForwardState{}); got != want {
+		t.Errorf("KubectlCommand =\n  %s\nwant\n  %s", got, want)
+	}
+	cfg.PodSelector = "2"
+	want = "kubectl --context prod port-forward --namespace payments pod/api-2 18080:9090 18081:9091 --address 127.0.0.1,::1 --pod-running-timeout=2m"
+	if got := KubectlCommand(cfg, ForwardState{Running: true, Pod: "api-2", PodPort: 9090}); got != want {
+		t.Errorf("KubectlCommand with a resolved pod =\n  %s\nwant\n  %s", got, want)
+	}
+	cfg.PodSelector, cfg.TargetKind = "", config.TargetKindStatefulSet
+	want = "kubectl --context prod port-forward --namespace payments statefulset/api 18080:8080 18081:8081 --address 127.0.0.1,::1 --pod-running-timeout=2m"
+	if got := KubectlCommand(cfg, ForwardState{}); got != want {
+		t.Errorf("KubectlCommand to a statefulset =\n  %s\nwant\n  %s", got, want)
+	}
+
+	cfg = config.PortForwardConfig{Context: "arn:aws:eks:eu-west-1:1:cluster/my prod", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080}
+	if got := KubectlCommand(cfg, ForwardState{}); !strings.HasPrefix(got, "kubectl --context 'arn:aws:eks:eu-west-1:1:cluster/my prod' port-forward") {
+		t.Errorf("a context with a space must be quoted, got %s", got)
+	}
+}
+
+func TestExecShellArgs(t *testing.T) {
+	cfg := config.PortForwardConfig{Context: "prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432}
+	got := strings.Join(ExecShellArgs(cfg, ForwardState{}), " ")
+	if want := "--context prod exec -it --namespace data svc/pg -- sh -c " + podShell; got != want {
+		t.Errorf("ExecShellArgs =\n  %s\nwant\n  %s", got, want)
+	}
+	cfg.PodSelector = "role=primary"
+	if got := ExecShellArgs(cfg, ForwardState{Running: true, Pod: "pg-1"}); !slices.Contains(got, "pod/pg-1") {
+		t.Errorf("ExecShellArgs with a resolved pod = %v, want pod/pg-1", got)
+	}
+}
+
+func TestLogsArgs(t *testing.T) {
+	cfg := config.PortForwardConfig{Context: "prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432}
+	got := strings.Join(logsArgs(cfg, ForwardState{}, "app=pg,tier=db"), " ")
+	want := "--context prod logs --follow --tail=100 --namespace data --selector app=pg,tier=db --all-containers --prefix --max-log-requests=10"
+	if got != want {
+		t.Errorf("logsArgs =\n  %s\nwant\n  %s", got, want)
+	}
+	if got := logsArgs(cfg, ForwardState{Running: true, Pod: "pg-1"}, ""); !slices.Contains(got, "pod/pg-1") {
+		t.Errorf("logsArgs with a resolved pod = %v, want pod/pg-1", got)
+	}
+	cfg.TargetKind = config.TargetKindStatefulSet
+	if got := logsArgs(cfg, ForwardState{}, ""); !slices.Contains(got, "statefulset/pg") {
+		t.Errorf("logsArgs of a statefulset = %v, want statefulset/pg", got)
+	}
+}
+
+func TestDescribeArgs(t *testing.T) {
+	cfg := config.PortForwardConfig{Context: "prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432}
+	got := strings.Join(describeArgs(cfg), " ")
+	if want := "--context prod describe --namespace data svc/pg endpoints/pg"; got != want {
+		t.Errorf("describeArgs = %q, want %q", got, want)
+	}
+	cfg.TargetKind = config.TargetKindDeployment
+	got = strings.Join(describeArgs(cfg), " ")
+	if want := "--context prod describe --namespace data deployment/pg"; got != want {
+		t.Errorf("describeArgs of a deployment = %q, want %q", got, want)
+	}
+}
+
+func TestStreamLogsFollowsTheServicePods(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	dir := t.TempDir()
+	script := `#!/bin/sh
+case "$*" in
+*"get service"*) echo '{"spec":{"selector":{"app":"pg"}}}' ;;
+*) echo "$*"; echo second ;;
+esac
+`
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	cfg := config.PortForwardConfig{Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432}
+	stream, err := StreamLogs(cfg, ForwardState{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer stream.Stop()
+	var lines []string
+	for line := range stream.Lines() {
+		lines = append(lines, line)
+	}
+	if stream.Err() != nil {
+		t.Errorf("Err = %v, want nil", stream.Err())
+	}
+	if len(lines) != 2 || !strings.Contains(lines[0], "--selector app=pg") || lines[1] != "second" {
+		t.Errorf("lines = %q, want the logs of the pods selecting app=pg", lines)
+	}
+}
+
+func TestShellScript(t *testing.T) {
+	cfgs := []config.PortForwardConfig{
+		{ID: "prod.data.pg", Context: "prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432},
+		{ID: "prod.web.api", Context: "prod", Namespace: "web", Service: "api", PortRemote: 443, PortLocal: 8443, TLSMode: config.TLSModeTerminate, PodSelector: "0"},
+	}
+	script := ShellScript(cfgs, []string{"Exported from kprtfwd"})
+	for _, want := range []string{
+		"#!/bin/sh\n# Exported from kprtfwd\n",
+		"trap cleanup INT TERM EXIT",
+		"--context prod port-forward --namespace data svc/pg 5432:5432 &\n",
+		"# prod.web.api: svc/api in prod/web, local 8443\n",
+		"# kprtfwd terminates TLS on the local port",
+		`kprtfwd picks the pod matching "0"`,
+	} {
+		if !strings.Contains(script, want) {
+			t.Errorf("script lacks %q:\n%s", want, script)
+		}
+	}
+	if strings.Count(script, `pids="$pids $!"`) != 2 {
+		t.Errorf("script should track both kubectl PIDs:\n%s", script)
+	}
+	if runtime.GOOS != "windows" {
+		if out, err := exec.Command("sh", "-n", "-c", script).CombinedOutput(); err != nil {
+			t.Errorf("script is not valid shell: %v: %s", err, out)
+		}
+	}
+}
+
+func TestStartBindsDualStackAddresses(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	if l, err := net.Listen("tcp", "[::1]:0"); err != nil {
+		t.Skip("IPv6 loopback not available")
+	} else {
+		l.Close()
+	}
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := fmt.Sprintf("#!/bin/sh\necho \"$@\" > %s\nexec sleep 30\n", argsFile)
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web",
+		PortRemote: 80, PortLocal: freeLocalPort(t), Listen: config.ListenDual}
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	got, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatalf("kubectl did not record its arguments: %v", err)
+	}
+	want := fmt.Sprintf("--context ctx port-forward --namespace ns svc/web %d:80 --address 127.0.0.1,::1\n", cfg.PortLocal)
+	if string(got) != want {
+		t.Fatalf("kubectl arguments = %q, want %q", got, want)
+	}
+}
+
+func TestStartRejectsPortTakenOnIPv6(t *testing.T) {
+	l, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skip("IPv6 loopback not available")
+	}
+	defer l.Close()
+	port := l.Addr().(*net.TCPAddr).Port
+
+	pf := NewPortForwarder()
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web",
+		PortRemote: 80, PortLocal: port, Listen: config.ListenIPv6}
+	if err := pf.Start(cfg); !errors.Is(err, ErrPortInUse) {
+		t.Fatalf("Start = %v, want ErrPortInUse", err)
+	}
+}
+
 func TestRestartReportsDeletedConfigByID(t *testing.T) {
 	pf := NewPortForwarder()
 	markRunning(pf, "ctx.ns.deleted", 8080)
@@ -410,6 +629,29 @@ func TestStopClearsErrorState(t *testing.T) {
 	}
 }
 
+func TestRecentFailuresOutliveStopAndAreCapped(t *testing.T) {
+	pf := NewPortForwarder()
+	pf.Mutex.Lock()
+	for i := range failuresKept + 2 {
+		pf.recordFailureLocked("ctx.ns.web", fmt.Sprintf("failure %d", i))
+	}
+	pf.Mutex.Unlock()
+
+	if err := pf.Stop("ctx.ns.web"); err != nil {
+		t.Fatalf("Stop returned error: %v", err)
+	}
+	if pf.IsError("ctx.ns.web") {
+		t.Fatal("Stop must clear the Error state")
+	}
+	failures := pf.RecentFailures("ctx.ns.web")
+	if len(failures) != failuresKept {
+		t.Fatalf("kept %d failures, want %d", len(failures), failuresKept)
+	}
+	if first, last := failures[0].Reason, failures[len(failures)-1].Reason; first != "failure 2" || last != "failure 4" {
+		t.Fatalf("kept failures %q..%q, want the newest, oldest first", first, last)
+	}
+}
+
 func TestStopAllRunning(t *testing.T) {
 	pf := NewPortForwarder()
 	markRunning(pf, "ctx.ns.web", 8080)
@@ -433,7 +675,7 @@ func TestMarkBrokenDeregistersAndMarksError(t *testing.T) {
 	markRunning(pf, "ctx.ns.web", 8080)
 	markRunning(pf, "ctx.ns.api", 8081)
 
-	pf.MarkBroken([]string{"ctx.ns.web"})
+	pf.MarkBroken(map[string]string{"ctx.ns.web": "local port 8080 is no longer listening"})
 
 	if pf.IsRunning("ctx.ns.web") {
 		t.Fatal("broken forward must be deregistered")
@@ -441,6 +683,9 @@ func TestMarkBrokenDeregistersAndMarksError(t *testing.T) {
 	if !pf.IsError("ctx.ns.web") {
 		t.Fatal("broken forward must be in Error state")
 	}
+	if reason := pf.State("ctx.ns.web").StopReason; reason != StopReasonFailed {
+		t.Fatalf("broken forward's stop reason = %q, want %q", reason, StopReasonFailed)
+	}
 	if !pf.IsRunning("ctx.ns.api") {
 		t.Fatal("unrelated forward must stay running")
 	}
@@ -454,7 +699,7 @@ func TestMarkBrokenDeregistersAndMarksError(t *testing.T) {
 
 // Forwards still within the startup grace period must not be probed (kubectl
 // may not have finished establishing the tunnel yet).
-func TestProbeAllTunnelsSkipsRecentlyStarted(t *testing.T) {
+func TestCheckHealthSkipsRecentlyStarted(t *testing.T) {
 	pf := NewPortForwarder()
 	done := make(chan struct{})
 	close(done)
@@ -463,7 +708,7 @@ func TestProbeAllTunnelsSkipsRecentlyStarted(t *testing.T) {
 	pf.activeLocalPorts[8080] = "ctx.ns.web"
 	pf.Mutex.Unlock()
 
-	if broken := pf.ProbeAllTunnels(); broken != nil {
+	if broken := pf.CheckHealth(); broken != nil {
 		t.Fatalf("recently started forward must be skipped, got broken=%v", broken)
 	}
 }
@@ -506,6 +751,48 @@ func TestRestartForwardsRecoversErroredForward(t *testing.T) {
 	}
 }
 
+// A group restart touches only the members that are running: stopped and
+// errored ones stay as they are.
+func TestRestartRunningSkipsStoppedAndErroredForwards(t *testing.T) {
+	installFakeKubectl(t)
+
+	pf := NewPortForwarder()
+	defer pf.CleanupAll()
+
+	forward := func(name string) config.PortForwardConfig {
+		return config.PortForwardConfig{
+			ID: "ctx.ns." + name, Context: "ctx", Namespace: "ns",
+			Service: name, PortRemote: 80, PortLocal: freeLocalPort(t),
+		}
+	}
+	running, stopped, errored := forward("web"), forward("api"), forward("db")
+	if err := pf.Start(running); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	oldPid := currentPid(t, pf, running.ID)
+	pf.Mutex.Lock()
+	pf.failedForwards[errored.ID] = "kubectl exited: boom"
+	pf.Mutex.Unlock()
+
+	result := pf.RestartRunning([]config.PortForwardConfig{running, stopped, errored})
+
+	if len(result.Errors) != 0 || result.RestartedCount != 1 {
+		t.Fatalf("expected one clean restart, got count %d, errors %v", result.RestartedCount, result.Errors)
+	}
+	if len(result.Forwards) != 1 || result.Forwards[0].ID != running.ID {
+		t.Fatalf("report should list only the running forward, got %+v", result.Forwards)
+	}
+	if newPid := currentPid(t, pf, running.ID); newPid == oldPid {
+		t.Fatal("restart must replace the process, but the PID is unchanged")
+	}
+	if pf.IsRunning(stopped.ID) || pf.IsRunning(errored.ID) {
+		t.Fatal("stopped and errored forwards must not be started")
+	}
+	if !pf.IsError(errored.ID) {
+		t.Fatal("the errored forward should keep its error state")
+	}
+}
+
 // The failure reason is retained and exposed so the UI can show it.
 func TestErrorReasonExposesFailureDetail(t *testing.T) {
 	installFailingKubectl(t)
@@ -588,11 +875,58 @@ func TestStartupFailureDoesNotScheduleRetry(t *testing.T) {
 	}
 }
 
+// The probe tells a port that stopped listening apart from a broken tunnel and
+// checks every port of a range.
+func TestCheckHealthReportsPortsNoLongerListening(t *testing.T) {
+	// A tunnel kubectl holds open: accepts and waits for data
+	live, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer live.Close()
+	go func() {
+		for {
+			conn, err := live.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+	livePort := live.Addr().(*net.TCPAddr).Port
+
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closedPort := closed.Addr().(*net.TCPAddr).Port
+	closed.Close()
+
+	pf := NewPortForwarder()
+	markRunning(pf, "ctx.ns.live", livePort)
+	markRunning(pf, "ctx.ns.gone", closedPort)
+
+	broken := pf.CheckHealth()
+	if _, ok := broken["ctx.ns.live"]; ok || len(broken) != 1 {
+		t.Fatalf("only the closed port should be reported, got %v", broken)
+	}
+	if want := fmt.Sprintf("local port %d is no longer listening", closedPort); broken["ctx.ns.gone"] != want {
+		t.Fatalf("reason = %q, want %q", broken["ctx.ns.gone"], want)
+	}
+
+	if !isPortAvailable("127.0.0.1", livePort+1) {
+		t.Skipf("port %d is in use", livePort+1)
+	}
+	if got, want := tunnelProblem("127.0.0.1", livePort, 2), fmt.Sprintf("local port %d is no longer listening", livePort+1); got != want {
+		t.Fatalf("range probe = %q, want %q", got, want)
+	}
+}
+
 func TestMarkBrokenSchedulesRetry(t *testing.T) {
 	pf := NewPortForwarder()
 	markRunning(pf, "ctx.ns.web", 8080)
 
-	pf.MarkBroken([]string{"ctx.ns.web"})
+	pf.MarkBroken(map[string]string{"ctx.ns.web": "local port 8080 is no longer listening"})
 
 	if _, scheduled := pf.RetryStatus("ctx.ns.web"); !scheduled {
 		t.Fatal("a broken tunnel must schedule an auto-restart")
@@ -696,3 +1030,59 @@ func TestStopClearsRetrySchedule(t *testing.T) {
 		t.Fatal("an intentional stop must cancel any pending auto-restart")
 	}
 }
+
+// freeLocalPortRange returns the first of count consecutive free ports.
+func freeLocalPortRange(t *testing.T, count int) int {
+	t.Helper()
+	for attempt := 0; attempt < 50; attempt++ {
+		first := freeLocalPort(t)
+		free := first+count-1 <= 65535
+		for port := first + 1; free && port < first+count; port++ {
+			free = isPortAvailable("127.0.0.1", port)
+		}
+		if free {
+			return first
+		}
+	}
+	t.Fatalf("no %d consecutive free ports found", count)
+	return 0
+}
+
+func TestPortRangeForwardsEveryPort(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	first := freeLocalPortRange(t, 3)
+	cfg := config.PortForwardConfig{
+		ID: "ctx.ns.debug", Context: "ctx", Namespace: "ns", Service: "debug",
+		PortRemote: 9000, PortLocal: first, PortCount: 3,
+	}
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	for port := first; port < first+3; port++ {
+		if got := echoThrough(t, port, "ping"); got != "ping" {
+			t.Fatalf("echo through port %d = %q", port, got)
+		}
+	}
+
+	// A single-port forward inside the range must be refused.
+	overlap := config.PortForwardConfig{
+		ID: "ctx.ns.other", Context: "ctx", Namespace: "ns", Service: "other",
+		PortRemote: 80, PortLocal: first + 2,
+	}
+	if err := pf.Start(overlap); !errors.Is(err, ErrLocalPortReserved) {
+		t.Fatalf("Start inside a running range = %v, want ErrLocalPortReserved", err)
+	}
+
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	pf.Mutex.Lock()
+	reserved := len(pf.activeLocalPorts)
+	pf.Mutex.Unlock()
+	if reserved != 0 {
+		t.Fatalf("Stop left %d port reservation(s) behind", reserved)
+	}
+}
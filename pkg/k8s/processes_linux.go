@@ -0,0 +1,29 @@
+//go:build linux
+
+package k8s
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// commandLines returns the command line of every process whose
+// /proc/<pid>/cmdline can be read, split into its arguments, keyed by PID
+func commandLines() map[int][]string {
+	lines := make(map[int][]string)
+	paths, _ := filepath.Glob("/proc/[0-9]*/cmdline")
+	for _, path := range paths {
+		pid, err := strconv.Atoi(filepath.Base(filepath.Dir(path)))
+		if err != nil {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil || len(data) == 0 {
+			continue // gone meanwhile, or a kernel thread
+		}
+		lines[pid] = strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+	}
+	return lines
+}
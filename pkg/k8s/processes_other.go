@@ -0,0 +1,32 @@
+//go:build !linux && !windows
+
+package k8s
+
+import (
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// commandLines returns the command line of every process, asking ps (none
+// if ps cannot run), keyed by PID. ps joins the arguments with spaces, so one
+// holding a space comes back as several.
+func commandLines() map[int][]string {
+	lines := make(map[int][]string)
+	out, err := exec.Command("ps", "-axww", "-o", "pid=,command=").Output()
+	if err != nil {
+		return lines
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		pid, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		lines[pid] = fields[1:]
+	}
+	return lines
+}
@@ -0,0 +1,547 @@
+package k8s
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// defaultLazyIdleTimeout is how long a lazy forward keeps kubectl running
+// after its last client disconnects.
+const defaultLazyIdleTimeout = 5 * time.Minute
+
+// backendReadyTimeout bounds how long a client waits for kubectl to start
+// listening. Generous because an SSO credential plugin may need to refresh a
+// token first.
+const backendReadyTimeout = 30 * time.Second
+
+// defaultDrainTimeout is how long stopping a proxied forward waits for its
+// open connections (a long database dump, say) before killing kubectl.
+const defaultDrainTimeout = 30 * time.Second
+
+// ErrProxyPortRange is returned when a port range is configured as lazy or
+// TLS: kprtfwd's local listener serves a single port.
+var ErrProxyPortRange = errors.New("lazy and TLS forwards support a single port, not a range")
+
+// proxyForward is a forward whose local port is served by kprtfwd itself
+// rather than by kubectl: lazy forwards (kubectl starts on the first client)
+// and TLS forwards (kprtfwd terminates or originates TLS). kubectl runs on an
+// internal port, registered in RunningForwards under the same ID, and is
+// restarted on demand by the next client if it dies.
+type proxyForward struct {
+	cfg       config.PortForwardConfig
+	listener  net.Listener
+	tlsConfig *tls.Config // client side of TLS origination; nil unless cfg.TLSMode is originate
+
+	startMu sync.Mutex // serialises backend starts so concurrent clients share one kubectl
+
+	lastActivity atomic.Int64 // UnixNano of the last byte through the forward; 0 if none yet
+
+	mu        sync.Mutex // guards the fields below
+	active    int        // open client connections
+	idleTimer *time.Timer
+	closed    bool
+	drained   chan struct{} // closed when the last connection ends after the forward was closed
+	capture   *pcapCapture  // traffic capture started by StartCapture; nil if none
+}
+
+// usesProxy reports whether cfg needs kprtfwd to own the local listener.
+func usesProxy(cfg config.PortForwardConfig) bool {
+	return cfg.Lazy || cfg.TLSMode != config.TLSModeNone
+}
+
+// startProxy opens the local listener for a proxied forward. A lazy forward
+// waits for its first client before starting kubectl; any other is started
+// now so configuration errors surface immediately. Caller must have reserved
+// the local port in activeLocalPorts.
+func (pf *PortForwarder) startProxy(cfg config.PortForwardConfig) error {
+	if err := validateParams(paramsFor(cfg, cfg.PortLocal)); err != nil {
+		logging.LogError("Refusing to start proxied forward: %v", err)
+		return err
+	}
+	if err := config.ValidateTLSMode(cfg.TLSMode); err != nil {
+		return err
+	}
+	if err := config.ValidateListen(cfg.Listen); err != nil {
+		return err
+	}
+	if cfg.Ports() > 1 {
+		return ErrProxyPortRange
+	}
+	p := &proxyForward{cfg: cfg, drained: make(chan struct{})}
+
+	var serverTLS *tls.Config
+	switch cfg.TLSMode {
+	case config.TLSModeTerminate:
+		cert, err := localCertificate()
+		if err != nil {
+			return fmt.Errorf("cannot load local TLS certificate: %w", err)
+		}
+		serverTLS = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+	case config.TLSModeOriginate:
+		p.tlsConfig = originationConfig(cfg)
+	}
+
+	listener, err := listenLoopback(config.ListenHosts(cfg.Listen), cfg.PortLocal)
+	if err != nil {
+		logging.LogError("Proxied forward '%s' cannot listen on port %d: %v", cfg.ID, cfg.PortLocal, err)
+		return ErrPortInUse
+	}
+	if serverTLS != nil {
+		listener = tls.NewListener(listener, serverTLS)
+	}
+	p.listener = listener
+
+	pf.Mutex.Lock()
+	pf.proxies[cfg.ID] = p
+	delete(pf.failedForwards, cfg.ID)
+	pf.clearRetryLocked(cfg.ID)
+	pf.Mutex.Unlock()
+
+	if !cfg.Lazy {
+		if _, err := pf.ensureBackend(p); err != nil {
+			pf.Mutex.Lock()
+			pf.stopProxyLocked(cfg.ID)
+			pf.Mutex.Unlock()
+			return err
+		}
+	}
+
+	go pf.serveProxy(p)
+	logging.LogDebug("Proxied forward '%s' listening on port %d (lazy=%t, tls=%q)", cfg.ID, cfg.PortLocal, cfg.Lazy, cfg.TLSMode)
+	return nil
+}
+
+// stopProxyLocked closes a proxied forward's listener and stops its backend,
+// if running. Caller must hold the mutex; only non-blocking calls are made.
+func (pf *PortForwarder) stopProxyLocked(id string) {
+	p, ok := pf.proxies[id]
+	if !ok {
+		return
+	}
+	delete(pf.proxies, id)
+	if holder, reserved := pf.activeLocalPorts[p.cfg.PortLocal]; reserved && holder == id {
+		delete(pf.activeLocalPorts, p.cfg.PortLocal)
+	}
+
+	p.mu.Lock()
+	p.closed = true
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+	}
+	if p.capture != nil {
+		p.capture.close()
+	}
+	p.mu.Unlock()
+	_ = p.listener.Close()
+
+	pf.stopBackendLocked(id)
+	logging.LogDebug("Stopped proxied forward '%s'", id)
+}
+
+// drainProxyLocked stops a proxied forward gracefully: the listener closes at
+// once, but if clients are still connected the backend is detached and kept
+// running until they finish or the drain timeout passes. Caller must hold the
+// mutex.
+func (pf *PortForwarder) drainProxyLocked(id string) {
+	p, ok := pf.proxies[id]
+	if !ok {
+		return
+	}
+	// Close under p.mu so no connection can open after active is read, and
+	// the last one to finish reliably closes p.drained.
+	p.mu.Lock()
+	p.closed = true
+	active := p.active
+	p.mu.Unlock()
+
+	info, running := pf.RunningForwards[id]
+	if !running || active == 0 || pf.drainTimeout <= 0 {
+		pf.stopProxyLocked(id)
+		return
+	}
+	info.stopping = true
+	delete(pf.RunningForwards, id)
+	pf.draining[info] = true
+	pf.stopProxyLocked(id) // the backend is no longer registered, so it survives
+	logging.LogDebug("Draining '%s': waiting up to %s for %d open connection(s)", id, pf.drainTimeout, active)
+	go pf.awaitDrain(p, info, pf.drainTimeout)
+}
+
+// awaitDrain kills a detached backend once its clients are gone or the
+// timeout passes, whichever is first.
+func (pf *PortForwarder) awaitDrain(p *proxyForward, info *runningInfo, timeout time.Duration) {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-p.drained:
+		logging.LogDebug("Drained '%s'; stopping kubectl", p.cfg.ID)
+	case <-info.done:
+	case <-timer.C:
+		logging.LogError("Drain of '%s' timed out after %s; closing remaining connections", p.cfg.ID, timeout)
+	}
+
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	if pf.draining[info] {
+		delete(pf.draining, info)
+		_ = killProcess(info.cmd)
+	}
+}
+
+// ActiveConnections returns the number of client connections open through a
+// proxied forward. kubectl does not report its connections, so it is always 0
+// for a plain forward.
+func (pf *PortForwarder) ActiveConnections(id string) int {
+	pf.Mutex.Lock()
+	p, ok := pf.proxies[id]
+	pf.Mutex.Unlock()
+	if !ok {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.active
+}
+
+// DrainTimeout returns how long Stop lets a proxied forward's open connections
+// finish.
+func (pf *PortForwarder) DrainTimeout() time.Duration {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	return pf.drainTimeout
+}
+
+// stopBackendLocked kills the kubectl process behind a proxied forward,
+// leaving the listener up. Caller must hold the mutex.
+func (pf *PortForwarder) stopBackendLocked(id string) {
+	info, ok := pf.RunningForwards[id]
+	if !ok {
+		return
+	}
+	info.stopping = true
+	delete(pf.RunningForwards, id)
+	_ = killProcess(info.cmd)
+}
+
+// IsStandby reports whether kprtfwd is listening for the forward but no
+// kubectl process is running: a lazy forward waiting for its first client, or
+// a proxied forward whose kubectl exited and will restart on the next client.
+func (pf *PortForwarder) IsStandby(id string) bool {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	if _, proxied := pf.proxies[id]; !proxied {
+		return false
+	}
+	_, running := pf.RunningForwards[id]
+	return !running
+}
+
+// serveProxy accepts clients until the listener is closed.
+func (pf *PortForwarder) serveProxy(p *proxyForward) {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return // listener closed by Stop
+		}
+		go pf.handleProxyConn(p, conn)
+	}
+}
+
+// handleProxyConn starts the backend if needed and pipes one client through it.
+func (pf *PortForwarder) handleProxyConn(p *proxyForward, client net.Conn) {
+	defer client.Close()
+
+	if !p.connOpened() {
+		return
+	}
+	defer pf.proxyConnClosed(p)
+
+	port, err := pf.ensureBackend(p)
+	if err != nil {
+		logging.LogError("Proxied forward '%s': backend start failed: %v", p.cfg.ID, err)
+		return
+	}
+
+	upstream, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 5*time.Second)
+	if err != nil {
+		logging.LogError("Proxied forward '%s': cannot reach kubectl on port %d: %v", p.cfg.ID, port, err)
+		return
+	}
+	defer upstream.Close()
+
+	if p.tlsConfig != nil {
+		tlsConn := tls.Client(upstream, p.tlsConfig)
+		_ = tlsConn.SetDeadline(time.Now().Add(10 * time.Second))
+		if err := tlsConn.Handshake(); err != nil {
+			logging.LogError("Proxied forward '%s': TLS handshake with backend failed: %v", p.cfg.ID, err)
+			return
+		}
+		_ = tlsConn.SetDeadline(time.Time{})
+		upstream = tlsConn
+	}
+
+	captured := &connCapture{p: p, client: client}
+	defer captured.finish()
+	pipe(client, upstream, func(fromA bool, data []byte) {
+		p.lastActivity.Store(time.Now().UnixNano())
+		captured.record(fromA, data)
+	})
+}
+
+// pipe copies data both ways until both directions are done, calling touch
+// with whatever arrives from either side, and whether it came from a.
+func pipe(a, b net.Conn, touch func(fromA bool, data []byte)) {
+	done := make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(b, touchReader{a, func(data []byte) { touch(true, data) }})
+		closeWrite(b)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(a, touchReader{b, func(data []byte) { touch(false, data) }})
+		closeWrite(a)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+}
+
+// touchReader calls touch with the data of every read that returned some
+type touchReader struct {
+	r     io.Reader
+	touch func(data []byte)
+}
+
+func (t touchReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	if n > 0 {
+		t.touch(p[:n])
+	}
+	return n, err
+}
+
+// LastActivity returns when data last went through a proxied forward, in
+// either direction. ok is false for a plain forward, whose traffic kubectl
+// does not report; the time is zero while no data went through yet.
+func (pf *PortForwarder) LastActivity(id string) (last time.Time, ok bool) {
+	pf.Mutex.Lock()
+	p, ok := pf.proxies[id]
+	pf.Mutex.Unlock()
+	if !ok {
+		return time.Time{}, false
+	}
+	if nanos := p.lastActivity.Load(); nanos != 0 {
+		return time.Unix(0, nanos), true
+	}
+	return time.Time{}, true
+}
+
+// closeWrite half-closes a connection so the peer sees EOF while replies can
+// still flow the other way. Connections without half-close are closed fully.
+func closeWrite(c net.Conn) {
+	if cw, ok := c.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = c.Close()
+}
+
+// connOpened records a new client and cancels any pending idle stop. It
+// returns false if the forward has been stopped.
+func (p *proxyForward) connOpened() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.active++
+	if p.idleTimer != nil {
+		p.idleTimer.Stop()
+		p.idleTimer = nil
+	}
+	return true
+}
+
+// proxyConnClosed records a client leaving. When it was the last one, a
+// stopped forward's drain completes; for a running lazy forward the backend
+// is scheduled to stop after the idle timeout.
+func (pf *PortForwarder) proxyConnClosed(p *proxyForward) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.active--
+	if p.active > 0 {
+		return
+	}
+	if p.closed {
+		close(p.drained)
+		return
+	}
+	if !p.cfg.Lazy {
+		return
+	}
+	p.idleTimer = time.AfterFunc(pf.lazyIdleTimeout, func() { pf.idleStop(p) })
+}
+
+// idleStop stops the backend of a lazy forward that has had no clients for the
+// idle timeout.
+func (pf *PortForwarder) idleStop(p *proxyForward) {
+	p.mu.Lock()
+	idle := p.active == 0 && !p.closed
+	p.mu.Unlock()
+	if !idle {
+		return
+	}
+
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	if pf.proxies[p.cfg.ID] != p {
+		return // stopped or replaced meanwhile
+	}
+	if _, running := pf.RunningForwards[p.cfg.ID]; running {
+		logging.LogDebug("Lazy forward '%s' idle for %s; stopping kubectl", p.cfg.ID, pf.lazyIdleTimeout)
+		pf.stopBackendLocked(p.cfg.ID)
+		pf.recordStopLocked(p.cfg.ID, StopReasonIdle)
+	}
+}
+
+// ensureBackend returns the internal port of a running kubectl for the proxied
+// forward, starting one and waiting for it to listen if necessary.
+func (pf *PortForwarder) ensureBackend(p *proxyForward) (int, error) {
+	p.startMu.Lock()
+	defer p.startMu.Unlock()
+
+	id := p.cfg.ID
+	pf.Mutex.Lock()
+	if info, ok := pf.RunningForwards[id]; ok {
+		pf.Mutex.Unlock()
+		return info.localPort, nil
+	}
+	pf.Mutex.Unlock()
+
+	port, err := freeInternalPort()
+	if err != nil {
+		return 0, err
+	}
+	params, err := pf.withPod(p.cfg, paramsFor(p.cfg, port))
+	if err != nil {
+		pf.recordProxyFailure(id, err.Error())
+		return 0, err
+	}
+	cmd, err := StartPortForward(params)
+	if err != nil {
+		pf.recordProxyFailure(id, err.Error())
+		return 0, err
+	}
+
+	info := &runningInfo{cmd: cmd, localPort: port, startedAt: time.Now(), proxied: true, pod: params.Pod, podPort: params.PortRemote, podZone: params.PodZone, healthPath: healthPathFor(p.cfg), done: make(chan struct{})}
+	pf.Mutex.Lock()
+	if pf.proxies[id] != p {
+		// Stopped while kubectl was being spawned.
+		pf.Mutex.Unlock()
+		_ = killProcess(cmd)
+		go func() { _ = cmd.Wait() }()
+		return 0, fmt.Errorf("forward '%s' was stopped", id)
+	}
+	pf.RunningForwards[id] = info
+	delete(pf.health, id) // a fresh kubectl; the last one's health no longer applies
+	pf.Mutex.Unlock()
+	go pf.watch(id, info)
+
+	if err := waitForListener(port, info.done); err != nil {
+		pf.Mutex.Lock()
+		if pf.RunningForwards[id] == info {
+			pf.stopBackendLocked(id)
+		}
+		pf.Mutex.Unlock()
+		reason := err.Error()
+		if stderrStr := drainStderrAfter(info); stderrStr != "" {
+			reason = stderrStr
+			err = kubectl.Classify(fmt.Errorf("kubectl exited: %s", stderrStr), stderrStr)
+		}
+		pf.recordProxyFailure(id, reason)
+		return 0, err
+	}
+
+	pf.Mutex.Lock()
+	delete(pf.failedForwards, id)
+	pf.Mutex.Unlock()
+	logging.LogDebug("Proxied forward '%s': kubectl up on internal port %d (PID %d)", id, port, cmd.Process.Pid)
+	return port, nil
+}
+
+// recordProxyFailure notes why a backend could not start. The listener stays
+// up, so the next client simply tries again.
+func (pf *PortForwarder) recordProxyFailure(id, reason string) {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	if _, ok := pf.proxies[id]; ok {
+		pf.recordFailureLocked(id, reason)
+	}
+}
+
+// drainStderrAfter returns kubectl's stderr once its watcher has reaped it, or
+// "" if the process is still running.
+func drainStderrAfter(info *runningInfo) string {
+	select {
+	case <-info.done:
+		return drainStderr(info.cmd)
+	default:
+		return ""
+	}
+}
+
+// waitForListener polls until kubectl accepts connections on port, the process
+// exits (done closes) or backendReadyTimeout passes.
+func waitForListener(port int, done <-chan struct{}) error {
+	address := fmt.Sprintf("127.0.0.1:%d", port)
+	deadline := time.Now().Add(backendReadyTimeout)
+	for time.Now().Before(deadline) {
+		conn, err := net.DialTimeout("tcp", address, 200*time.Millisecond)
+		if err == nil {
+			conn.Close()
+			return nil
+		}
+		select {
+		case <-done:
+			return fmt.Errorf("kubectl exited before listening")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+	return fmt.Errorf("kubectl did not start listening within %s: %w", backendReadyTimeout, kubectl.ErrTimeout)
+}
+
+// freeInternalPort asks the OS for an unused localhost port for kubectl to
+// bind behind a proxied forward.
+func freeInternalPort() (int, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("no free internal port: %w", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+	return port, nil
+}
+
+// paramsFor builds kubectl parameters for cfg bound to the given local port.
+func paramsFor(cfg config.PortForwardConfig, localPort int) PortForwardParams {
+	return PortForwardParams{
+		Context:    cfg.Context,
+		Namespace:  cfg.Namespace,
+		Service:    cfg.Service,
+		TargetKind: cfg.TargetKind,
+		PortRemote: cfg.PortRemote,
+		PortLocal:  localPort,
+		Kubeconfig: cfg.Kubeconfig,
+		ExtraArgs:  cfg.KubectlArgs,
+	}
+}
@@ -0,0 +1,298 @@
+package k8s
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// TestHelperKubectl is not a real test. installEchoKubectl runs the test
+// binary in its place as a fake `kubectl port-forward` that serves an echo
+// server on each requested local port.
+func TestHelperKubectl(t *testing.T) {
+	if os.Getenv("KPRTFWD_HELPER_KUBECTL") != "1" {
+		return
+	}
+	portPair := regexp.MustCompile(`^(\d+):\d+$`)
+	var listeners []net.Listener
+	for _, arg := range os.Args {
+		if m := portPair.FindStringSubmatch(arg); m != nil {
+			l, err := net.Listen("tcp", "127.0.0.1:"+m[1])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(1)
+			}
+			listeners = append(listeners, l)
+		}
+	}
+	if len(listeners) == 0 {
+		fmt.Fprintln(os.Stderr, "no port pair in arguments")
+		os.Exit(1)
+	}
+	for _, l := range listeners {
+		go func(l net.Listener) {
+			for {
+				conn, err := l.Accept()
+				if err != nil {
+					os.Exit(1)
+				}
+				go func(c net.Conn) {
+					defer c.Close()
+					_, _ = io.Copy(c, c)
+				}(conn)
+			}
+		}(l)
+	}
+	select {}
+}
+
+// installEchoKubectl puts a fake kubectl on PATH that behaves like a working
+// port-forward to an echo server.
+func installEchoKubectl(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nKPRTFWD_HELPER_KUBECTL=1 exec %s -test.run='^TestHelperKubectl$' -- \"$@\"\n", os.Args[0])
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// echoThrough sends msg through the local port and returns the reply.
+func echoThrough(t *testing.T, port int, msg string) string {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte(msg)); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, len(msg))
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return string(buf)
+}
+
+func lazyConfig(t *testing.T) config.PortForwardConfig {
+	return config.PortForwardConfig{
+		ID: "ctx.ns.lazy", Context: "ctx", Namespace: "ns", Service: "lazy",
+		PortRemote: 80, PortLocal: freeLocalPort(t), Lazy: true,
+	}
+}
+
+func TestLazyForwardStartsKubectlOnFirstConnection(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if !pf.IsRunning(cfg.ID) || !pf.IsStandby(cfg.ID) {
+		t.Fatal("a started lazy forward must be running and in standby")
+	}
+
+	if got := echoThrough(t, cfg.PortLocal, "ping"); got != "ping" {
+		t.Fatalf("echo through lazy forward = %q", got)
+	}
+	if pf.IsStandby(cfg.ID) {
+		t.Fatal("kubectl should be running after the first connection")
+	}
+
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if pf.IsRunning(cfg.ID) {
+		t.Fatal("forward still running after Stop")
+	}
+	if !isPortAvailable("127.0.0.1", cfg.PortLocal) {
+		t.Fatal("Stop must release the local listener")
+	}
+}
+
+func TestProxiedForwardRecordsActivity(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+
+	if _, ok := pf.LastActivity(cfg.ID); ok {
+		t.Fatal("activity of a forward kprtfwd does not proxy cannot be known")
+	}
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if last, ok := pf.LastActivity(cfg.ID); !ok || !last.IsZero() {
+		t.Fatalf("LastActivity before any data = %v, %v; want zero, true", last, ok)
+	}
+
+	before := time.Now()
+	echoThrough(t, cfg.PortLocal, "ping")
+	if last, ok := pf.LastActivity(cfg.ID); !ok || last.Before(before) {
+		t.Fatalf("LastActivity after an echo = %v, %v; want after %v", last, ok, before)
+	}
+}
+
+func TestLazyForwardStopsKubectlWhenIdle(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	pf.lazyIdleTimeout = 100 * time.Millisecond
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	echoThrough(t, cfg.PortLocal, "ping")
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !pf.IsStandby(cfg.ID) {
+		if time.Now().After(deadline) {
+			t.Fatal("kubectl was not stopped after the idle timeout")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if s := pf.State(cfg.ID); s.StopReason != StopReasonIdle || s.StoppedAt.IsZero() {
+		t.Fatalf("standby after the idle timeout should say so, state %+v", s)
+	}
+
+	// The listener stays up and brings kubectl back for the next client.
+	if got := echoThrough(t, cfg.PortLocal, "again"); got != "again" {
+		t.Fatalf("echo after idle stop = %q", got)
+	}
+	if s := pf.State(cfg.ID); s.StopReason != StopReasonNone {
+		t.Fatalf("a running kubectl has no stop reason, state %+v", s)
+	}
+}
+
+func TestLazyForwardRecordsBackendFailure(t *testing.T) {
+	installFailingKubectl(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", cfg.PortLocal))
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(10 * time.Second))
+	_, _ = conn.Read(make([]byte, 1)) // closed once kubectl fails
+	conn.Close()
+
+	if pf.ErrorReason(cfg.ID) == "" {
+		t.Fatal("the kubectl failure should be recorded for the UI")
+	}
+	if !pf.IsStandby(cfg.ID) {
+		t.Fatal("the listener should stay up for the next client")
+	}
+}
+
+// openEcho connects to the local port and completes one echo round trip, so
+// the connection is known to be piped through to kubectl.
+func openEcho(t *testing.T, port int) net.Conn {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), time.Second)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if _, err := io.ReadFull(conn, make([]byte, 4)); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	return conn
+}
+
+// drainingCount returns how many detached backends are still draining.
+func drainingCount(pf *PortForwarder) int {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	return len(pf.draining)
+}
+
+func TestStopDrainsOpenConnections(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	conn := openEcho(t, cfg.PortLocal)
+	if n := pf.ActiveConnections(cfg.ID); n != 1 {
+		t.Fatalf("ActiveConnections = %d, want 1", n)
+	}
+
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if pf.IsRunning(cfg.ID) || !isPortAvailable("127.0.0.1", cfg.PortLocal) {
+		t.Fatal("Stop must release the local listener at once")
+	}
+
+	// The open connection keeps working while it drains.
+	if _, err := conn.Write([]byte("pong")); err != nil {
+		t.Fatalf("write while draining: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil || string(buf) != "pong" {
+		t.Fatalf("echo while draining = %q, %v", buf, err)
+	}
+
+	conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for drainingCount(pf) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("kubectl was not stopped once the last connection closed")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestStopDrainTimeoutClosesConnections(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	pf.ApplySettings(map[string]string{config.SettingStopDrainTimeout: "200ms"})
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t)
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	conn := openEcho(t, cfg.PortLocal)
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+
+	// Nothing more is sent, so the read only returns once kubectl is killed.
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Fatal("connection should be closed when the drain timeout passes")
+	}
+	if drainingCount(pf) != 0 {
+		t.Fatal("draining backend should be gone after the timeout")
+	}
+}
@@ -0,0 +1,108 @@
+package k8s
+
+import (
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// ForwardState is the runtime state of one forward as Snapshot and State
+// report it. The zero value is a stopped forward.
+type ForwardState struct {
+	Running      bool            // as IsRunning
+	Standby      bool            // as IsStandby
+	Queued       bool            // as IsQueued
+	Failed       bool            // as IsError
+	ErrorReason  string          // as ErrorReason
+	Proxied      bool            // kprtfwd owns the local listener, so LastActivity is known
+	LastActivity time.Time       // as LastActivity; zero while no data went through
+	Pod          string          // pod a pod selector resolved to while kubectl runs; empty otherwise
+	PodPort      int             // port of Pod the forward's remote port goes to
+	PodZone      string          // zone of Pod's node, when the pods.zone setting made kprtfwd look it up
+	PodPinned    bool            // Pod is the one PinPod chose
+	Conflict     PortConflict    // set while Failed and another program holds the local port
+	Duplicate    ExternalForward // while it runs, a kubectl kprtfwd did not start forwarding its target elsewhere, the one of lowest PID
+	StopReason   StopReason      // why the forward, or a standby forward's kubectl, last stopped; StopReasonNone if it runs
+	StoppedAt    time.Time       // when it stopped for StopReason
+	Health       Health          // last CheckHealth outcome while kubectl runs, or HealthDead if that is why it failed
+	HealthDetail string          // why it is degraded or dead
+	CheckedAt    time.Time       // when Health was checked
+	Usage        ProcessUsage    // kubectl's CPU and memory as of the last SampleUsage while it runs
+	OverMemory   bool            // Usage is above the kubectl.memory_limit setting
+}
+
+// FailureKind classifies ErrorReason as PortForwarder.FailureKind does.
+func (s ForwardState) FailureKind() error {
+	return kubectl.KindOf(s.ErrorReason)
+}
+
+// Snapshot returns the state of every forward that is not simply stopped,
+// keyed by config ID, taken in one locked pass. Forwards missing from the map
+// are stopped; State tells why. A table refresh reads it once instead of locking for each
+// row's IsRunning, IsError, ... call.
+func (pf *PortForwarder) Snapshot() map[string]ForwardState {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	states := make(map[string]ForwardState, len(pf.RunningForwards)+len(pf.proxies)+len(pf.failedForwards)+len(pf.queue))
+	for id := range pf.RunningForwards {
+		states[id] = pf.stateLocked(id)
+	}
+	for id := range pf.proxies {
+		states[id] = pf.stateLocked(id)
+	}
+	for id := range pf.failedForwards {
+		states[id] = pf.stateLocked(id)
+	}
+	for _, cfg := range pf.queue {
+		states[cfg.ID] = pf.stateLocked(cfg.ID)
+	}
+	return states
+}
+
+// State returns the state of the forward with the given ID, as one entry of
+// Snapshot.
+func (pf *PortForwarder) State(id string) ForwardState {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	return pf.stateLocked(id)
+}
+
+// stateLocked gathers the state of one forward. Caller must hold the mutex.
+func (pf *PortForwarder) stateLocked(id string) ForwardState {
+	info, running := pf.RunningForwards[id]
+	p, proxied := pf.proxies[id]
+	reason, failed := pf.failedForwards[id]
+	s := ForwardState{
+		Running:     running || proxied,
+		Standby:     proxied && !running,
+		Queued:      pf.queuedIndexLocked(id) >= 0,
+		Failed:      failed,
+		ErrorReason: reason,
+		Proxied:     proxied,
+	}
+	if c, ok := pf.conflicts[id]; ok && failed && !running && !proxied {
+		s.Conflict = c
+	}
+	if d := pf.duplicates[id]; len(d) > 0 && (running || proxied) {
+		s.Duplicate = d[0]
+	}
+	if running && info.pod != "" {
+		s.Pod, s.PodPort, s.PodZone = info.pod, info.podPort, info.podZone
+		s.PodPinned = pf.pins[id] == info.pod
+	}
+	if proxied {
+		if nanos := p.lastActivity.Load(); nanos != 0 {
+			s.LastActivity = time.Unix(0, nanos)
+		}
+	}
+	if u, ok := pf.usage[id]; ok && running && u.info == info {
+		s.Usage, s.OverMemory = u.usage, pf.overLimitLocked(u.usage)
+	}
+	if h, ok := pf.health[id]; ok && (running || failed && h.health == HealthDead) {
+		s.Health, s.HealthDetail, s.CheckedAt = h.health, h.detail, h.at
+	}
+	if stop, ok := pf.stopped[id]; ok && !running {
+		s.StopReason, s.StoppedAt = stop.reason, stop.at
+	}
+	return s
+}
@@ -0,0 +1,47 @@
+package k8s
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestSnapshotMatchesPerForwardQueries(t *testing.T) {
+	installEchoKubectl(t)
+	pf := NewPortForwarder()
+	pf.ApplySettings(map[string]string{config.SettingLimitForwardsPrefix + "eks": "1"})
+	t.Cleanup(pf.CleanupAll)
+
+	running := limitedConfig(t, "running", "eks")
+	queued := limitedConfig(t, "queued", "eks")
+	lazy := lazyConfig(t)
+	for _, cfg := range []config.PortForwardConfig{running, queued, lazy} {
+		if err := pf.Start(cfg); err != nil {
+			t.Fatalf("Start(%s): %v", cfg.ID, err)
+		}
+	}
+	pf.Mutex.Lock()
+	pf.recordFailureLocked("ctx.ns.failed", "connection refused")
+	pf.Mutex.Unlock()
+
+	states := pf.Snapshot()
+	if len(states) != 4 {
+		t.Fatalf("Snapshot has %d entries, want 4: %+v", len(states), states)
+	}
+	for _, id := range []string{running.ID, queued.ID, lazy.ID, "ctx.ns.failed", "ctx.ns.stopped"} {
+		s := states[id]
+		if s != pf.State(id) {
+			t.Errorf("%s: Snapshot %+v differs from State %+v", id, s, pf.State(id))
+		}
+		if s.Running != pf.IsRunning(id) || s.Standby != pf.IsStandby(id) || s.Queued != pf.IsQueued(id) ||
+			s.Failed != pf.IsError(id) || s.ErrorReason != pf.ErrorReason(id) {
+			t.Errorf("%s: state %+v disagrees with the per-forward queries", id, s)
+		}
+	}
+	if !states[lazy.ID].Proxied || states[running.ID].Proxied {
+		t.Error("only the lazy forward is proxied")
+	}
+	if states["ctx.ns.stopped"] != (ForwardState{}) {
+		t.Error("a stopped forward must have the zero state")
+	}
+}
@@ -0,0 +1,54 @@
+package k8s
+
+import "time"
+
+// StopReason says why a forward, or a lazy forward's kubectl, last stopped
+type StopReason string
+
+const (
+	StopReasonNone          StopReason = ""               // not stopped since it was last started
+	StopReasonUser          StopReason = "user"           // stopped on purpose
+	StopReasonFailed        StopReason = "failed"         // kubectl exited on its own or the tunnel broke
+	StopReasonIdle          StopReason = "idle"           // a lazy forward's kubectl, after the idle timeout
+	StopReasonProjectSwitch StopReason = "project-switch" // stopped for another project to be activated
+	StopReasonPanic         StopReason = "panic-stop"     // stopped with everything else by a panic stop
+	StopReasonExpired       StopReason = "expired"        // stopped when the time it was started for ran out
+)
+
+// Describe completes "stopped ..." with the reason: "by you", "after
+// failing", ...
+func (r StopReason) Describe() string {
+	switch r {
+	case StopReasonUser:
+		return "by you"
+	case StopReasonFailed:
+		return "after failing"
+	case StopReasonIdle:
+		return "after idling"
+	case StopReasonProjectSwitch:
+		return "by a project switch"
+	case StopReasonPanic:
+		return "by a panic stop"
+	case StopReasonExpired:
+		return "when its time limit ran out"
+	}
+	return ""
+}
+
+// stopRecord is why and when a forward last stopped
+type stopRecord struct {
+	reason StopReason
+	at     time.Time
+}
+
+// recordStopLocked notes that the forward id stopped for reason. Caller must
+// hold the mutex.
+func (pf *PortForwarder) recordStopLocked(id string, reason StopReason) {
+	pf.stopped[id] = stopRecord{reason: reason, at: time.Now()}
+}
+
+// StopFor stops the forward id as Stop does, recording reason as why it
+// stopped.
+func (pf *PortForwarder) StopFor(id string, reason StopReason) error {
+	return pf.stop(id, reason)
+}
@@ -0,0 +1,43 @@
+package k8s
+
+import "testing"
+
+func TestStopReasonRecordsWhyAForwardStopped(t *testing.T) {
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := lazyConfig(t) // the listener is up without kubectl
+
+	if s := pf.State(cfg.ID); s.StopReason != StopReasonNone {
+		t.Fatalf("a forward never started has no stop reason, got %q", s.StopReason)
+	}
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if s := pf.State(cfg.ID); s.StopReason != StopReasonUser || s.StoppedAt.IsZero() {
+		t.Fatalf("Stop should be recorded as the user's, state %+v", s)
+	}
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if s := pf.State(cfg.ID); s.StopReason != StopReasonNone {
+		t.Fatalf("starting again should clear the stop reason, got %q", s.StopReason)
+	}
+	if n := pf.StopAllFor(StopReasonProjectSwitch); n != 1 {
+		t.Fatalf("StopAllFor stopped %d forwards, want 1", n)
+	}
+	if s := pf.State(cfg.ID); s.StopReason != StopReasonProjectSwitch {
+		t.Fatalf("stop reason = %q, want %q", s.StopReason, StopReasonProjectSwitch)
+	}
+
+	// Stopping a stopped forward keeps the reason it stopped for
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if s := pf.State(cfg.ID); s.StopReason != StopReasonProjectSwitch {
+		t.Fatalf("a second stop replaced the reason with %q", s.StopReason)
+	}
+}
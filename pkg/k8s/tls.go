@@ -0,0 +1,276 @@
+package k8s
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// Files under ~/.kprtfwd/tls. The leaf certificate serves every TLS-terminating
+// forward; the CA is only created when no mkcert CA is installed.
+const (
+	tlsCertFile  = "localhost.pem"
+	tlsKeyFile   = "localhost-key.pem"
+	tlsCAFile    = "ca.pem"
+	tlsCAKeyFile = "ca-key.pem"
+)
+
+// leafValidity is how long a generated localhost certificate is valid. It is
+// reissued once it is within leafRenewBefore of expiring.
+const (
+	leafValidity    = 365 * 24 * time.Hour
+	leafRenewBefore = 7 * 24 * time.Hour
+)
+
+// certMu serialises certificate generation so two forwards starting at once
+// don't both write the files.
+var certMu sync.Mutex
+
+// TLSDir returns the directory holding kprtfwd's local TLS material.
+func TLSDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get user home directory: %w", err)
+	}
+	return filepath.Join(home, ".kprtfwd", "tls"), nil
+}
+
+// localCertificate returns the certificate served by TLS-terminating forwards,
+// valid for localhost, 127.0.0.1 and ::1. A user-supplied pair in TLSDir (for
+// example from `mkcert localhost 127.0.0.1 ::1`) is used as-is; otherwise one
+// is issued from mkcert's CA when installed — so browsers already trust it —
+// or from a kprtfwd CA the user can choose to trust.
+func localCertificate() (tls.Certificate, error) {
+	certMu.Lock()
+	defer certMu.Unlock()
+
+	dir, err := TLSDir()
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	certPath := filepath.Join(dir, tlsCertFile)
+	keyPath := filepath.Join(dir, tlsKeyFile)
+
+	if cert, err := tls.LoadX509KeyPair(certPath, keyPath); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil && time.Until(leaf.NotAfter) > leafRenewBefore {
+			return cert, nil
+		}
+		logging.LogDebug("Local TLS certificate %s expires soon; reissuing", certPath)
+	}
+
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return tls.Certificate{}, fmt.Errorf("failed to create TLS directory: %w", err)
+	}
+	caCert, caKey, err := loadOrCreateCA(dir)
+	if err != nil {
+		return tls.Certificate{}, err
+	}
+	if err := issueLeaf(caCert, caKey, certPath, keyPath); err != nil {
+		return tls.Certificate{}, err
+	}
+	return tls.LoadX509KeyPair(certPath, keyPath)
+}
+
+// loadOrCreateCA returns mkcert's CA if installed, else kprtfwd's own CA,
+// creating it on first use.
+func loadOrCreateCA(dir string) (*x509.Certificate, crypto.Signer, error) {
+	if root := mkcertCARoot(); root != "" {
+		cert, key, err := loadCA(filepath.Join(root, "rootCA.pem"), filepath.Join(root, "rootCA-key.pem"))
+		if err == nil {
+			logging.LogDebug("Issuing local TLS certificate from mkcert CA in %s", root)
+			return cert, key, nil
+		}
+		if !errors.Is(err, os.ErrNotExist) {
+			logging.LogError("Ignoring unusable mkcert CA in %s: %v", root, err)
+		}
+	}
+
+	caPath := filepath.Join(dir, tlsCAFile)
+	caKeyPath := filepath.Join(dir, tlsCAKeyFile)
+	cert, key, err := loadCA(caPath, caKeyPath)
+	if err == nil {
+		return cert, key, nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to load kprtfwd CA: %w", err)
+	}
+
+	key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate CA key: %w", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          randomSerial(),
+		Subject:               pkix.Name{Organization: []string{"kprtfwd development CA"}, CommonName: "kprtfwd local CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		MaxPathLenZero:        true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, key.Public(), key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create CA certificate: %w", err)
+	}
+	if err := writePEMFiles(caPath, der, caKeyPath, key); err != nil {
+		return nil, nil, err
+	}
+	logging.LogDebug("Created kprtfwd local CA at %s; trust it to avoid browser warnings", caPath)
+	cert, err = x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// issueLeaf signs a localhost certificate with the CA and writes it to disk.
+func issueLeaf(caCert *x509.Certificate, caKey crypto.Signer, certPath, keyPath string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate key: %w", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: randomSerial(),
+		Subject:      pkix.Name{Organization: []string{"kprtfwd development certificate"}},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1"), net.ParseIP("::1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(leafValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, caCert, key.Public(), caKey)
+	if err != nil {
+		return fmt.Errorf("failed to sign local certificate: %w", err)
+	}
+	return writePEMFiles(certPath, der, keyPath, key)
+}
+
+// loadCA reads a PEM certificate and private key pair usable for signing.
+func loadCA(certPath, keyPath string) (*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := os.ReadFile(certPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	certBlock, _ := pem.Decode(certPEM)
+	keyBlock, _ := pem.Decode(keyPEM)
+	if certBlock == nil || keyBlock == nil {
+		return nil, nil, fmt.Errorf("invalid PEM in %s or %s", certPath, keyPath)
+	}
+	cert, err := x509.ParseCertificate(certBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := parsePrivateKey(keyBlock.Bytes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return cert, key, nil
+}
+
+// parsePrivateKey accepts PKCS#8 (mkcert, kprtfwd), PKCS#1 RSA and SEC 1 EC keys.
+func parsePrivateKey(der []byte) (crypto.Signer, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		if signer, ok := key.(crypto.Signer); ok {
+			return signer, nil
+		}
+		return nil, fmt.Errorf("unsupported private key type %T", key)
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(der); err == nil {
+		return key, nil
+	}
+	if key, err := x509.ParseECPrivateKey(der); err == nil {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unrecognised private key format")
+}
+
+// writePEMFiles writes a certificate and its key with owner-only permissions.
+func writePEMFiles(certPath string, certDER []byte, keyPath string, key crypto.Signer) error {
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode private key: %w", err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", keyPath, err)
+	}
+	if err := os.WriteFile(certPath, certPEM, 0600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", certPath, err)
+	}
+	return nil
+}
+
+func randomSerial() *big.Int {
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return big.NewInt(time.Now().UnixNano())
+	}
+	return serial
+}
+
+// mkcertCARoot returns the directory mkcert keeps its CA in, following
+// mkcert's own lookup ($CAROOT, then the per-OS data directory).
+func mkcertCARoot() string {
+	if root := os.Getenv("CAROOT"); root != "" {
+		return root
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	switch runtime.GOOS {
+	case "darwin":
+		return filepath.Join(home, "Library", "Application Support", "mkcert")
+	case "windows":
+		if dir := os.Getenv("LocalAppData"); dir != "" {
+			return filepath.Join(dir, "mkcert")
+		}
+		return ""
+	default:
+		if dir := os.Getenv("XDG_DATA_HOME"); dir != "" {
+			return filepath.Join(dir, "mkcert")
+		}
+		return filepath.Join(home, ".local", "share", "mkcert")
+	}
+}
+
+// originationConfig is the client TLS configuration kprtfwd uses towards the
+// backend of an originate-mode forward. The SNI defaults to the service's
+// cluster DNS name, which is what in-cluster certificates are issued for.
+func originationConfig(cfg config.PortForwardConfig) *tls.Config {
+	serverName := cfg.TLSServerName
+	if serverName == "" {
+		serverName = fmt.Sprintf("%s.%s.svc", cfg.Service, cfg.Namespace)
+	}
+	return &tls.Config{
+		ServerName: serverName,
+		// In-cluster certificates are almost always issued by a private CA
+		// (service mesh, cert-manager) the workstation does not trust. The
+		// connection already runs through kubectl's authenticated tunnel to
+		// the chosen service, so origination only satisfies the backend's TLS
+		// requirement; it does not need to establish the backend's identity.
+		InsecureSkipVerify: true,
+		MinVersion:         tls.VersionTLS12,
+	}
+}
@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// useTempTLSDir points the TLS directory and mkcert lookup at empty temporary
+// directories so tests never touch (or trust) the user's real certificates.
+func useTempTLSDir(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("CAROOT", t.TempDir())
+	return filepath.Join(home, ".kprtfwd", "tls")
+}
+
+func TestTerminateForwardServesLocalCertificate(t *testing.T) {
+	installEchoKubectl(t)
+	dir := useTempTLSDir(t)
+	pf := NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	cfg := config.PortForwardConfig{
+		ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web",
+		PortRemote: 443, PortLocal: freeLocalPort(t), TLSMode: config.TLSModeTerminate,
+	}
+
+	if err := pf.Start(cfg); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if pf.IsStandby(cfg.ID) {
+		t.Fatal("a non-lazy TLS forward should start kubectl immediately")
+	}
+
+	caPEM, err := os.ReadFile(filepath.Join(dir, tlsCAFile))
+	if err != nil {
+		t.Fatalf("kprtfwd CA was not written: %v", err)
+	}
+	roots := x509.NewCertPool()
+	if !roots.AppendCertsFromPEM(caPEM) {
+		t.Fatal("failed to parse kprtfwd CA")
+	}
+	conn, err := tls.Dial("tcp", fmt.Sprintf("localhost:%d", cfg.PortLocal), &tls.Config{RootCAs: roots})
+	if err != nil {
+		t.Fatalf("TLS dial: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	buf := make([]byte, 4)
+	if _, err := io.ReadFull(conn, buf); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if string(buf) != "ping" {
+		t.Fatalf("echo through TLS forward = %q", buf)
+	}
+}
+
+func TestLocalCertificateIsReused(t *testing.T) {
+	useTempTLSDir(t)
+
+	first, err := localCertificate()
+	if err != nil {
+		t.Fatalf("localCertificate: %v", err)
+	}
+	second, err := localCertificate()
+	if err != nil {
+		t.Fatalf("localCertificate: %v", err)
+	}
+	if !bytes.Equal(first.Certificate[0], second.Certificate[0]) {
+		t.Fatal("a valid certificate on disk must be reused, not reissued")
+	}
+}
+
+func TestOriginationConfigServerName(t *testing.T) {
+	cfg := config.PortForwardConfig{Namespace: "payments", Service: "api"}
+	if got := originationConfig(cfg).ServerName; got != "api.payments.svc" {
+		t.Fatalf("default SNI = %q, want api.payments.svc", got)
+	}
+	cfg.TLSServerName = "api.example.com"
+	if got := originationConfig(cfg).ServerName; got != "api.example.com" {
+		t.Fatalf("SNI = %q, want the configured override", got)
+	}
+}
@@ -0,0 +1,106 @@
+package k8s
+
+import (
+	"sort"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// ProcessUsage is the CPU and memory a forward's kubectl used as of one
+// SampleUsage
+type ProcessUsage struct {
+	RSS        int64         // resident memory in bytes
+	CPUTime    time.Duration // CPU time used since kubectl started
+	CPUPercent float64       // share of one core used since the previous sample; 0 at the first
+	At         time.Time     // when it was sampled; zero if never
+}
+
+// usageRecord is the last sample of the kubectl info runs
+type usageRecord struct {
+	info  *runningInfo
+	usage ProcessUsage
+}
+
+// applyUsageSettingsLocked reads the kubectl.memory_* settings. Caller must
+// hold the mutex.
+func (pf *PortForwarder) applyUsageSettingsLocked(values map[string]string) {
+	pf.memoryLimit = config.DefaultKubectlMemoryLimit
+	if value, ok := values[config.SettingKubectlMemoryLimit]; ok {
+		if limit, err := config.ParseMemorySize(value); err == nil {
+			pf.memoryLimit = limit
+		} else {
+			logging.LogError("Ignoring invalid %s setting %q", config.SettingKubectlMemoryLimit, value)
+		}
+	}
+	pf.memoryRestart = values[config.SettingKubectlMemoryRestart] == "true"
+}
+
+// MemoryRestart reports whether forwards whose kubectl exceeds the memory
+// limit should be restarted rather than only flagged
+func (pf *PortForwarder) MemoryRestart() bool {
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	return pf.memoryRestart
+}
+
+// overLimitLocked reports whether u exceeds the memory limit. Caller must
+// hold the mutex.
+func (pf *PortForwarder) overLimitLocked(u ProcessUsage) bool {
+	return pf.memoryLimit > 0 && u.RSS > pf.memoryLimit
+}
+
+// SampleUsage measures the CPU and memory of every running kubectl, for
+// State and Snapshot to report, and returns the IDs of the forwards whose
+// kubectl uses more memory than the kubectl.memory_limit setting, sorted.
+// Where the platform does not tell (no ps on Windows), usage stays unknown.
+// Blocking; call from a goroutine or tea.Cmd.
+func (pf *PortForwarder) SampleUsage() []string {
+	pf.Mutex.Lock()
+	pids := make(map[string]int)
+	infos := make(map[string]*runningInfo)
+	for id, info := range pf.RunningForwards {
+		if info.cmd == nil || info.cmd.Process == nil {
+			continue
+		}
+		pids[id] = info.cmd.Process.Pid
+		infos[id] = info
+	}
+	pf.Mutex.Unlock()
+
+	samples := make(map[string]ProcessUsage, len(pids))
+	for id, pid := range pids {
+		usage, err := processUsage(pid)
+		if err != nil {
+			logging.LogDebug("No resource usage for '%s' (PID %d): %v", id, pid, err)
+			continue
+		}
+		usage.At = time.Now()
+		samples[id] = usage
+	}
+
+	pf.Mutex.Lock()
+	defer pf.Mutex.Unlock()
+	var over []string
+	usage := make(map[string]usageRecord, len(samples))
+	for id, u := range samples {
+		info := infos[id]
+		if pf.RunningForwards[id] != info {
+			continue // stopped, or restarted, while it was sampled
+		}
+		if last, ok := pf.usage[id]; ok && last.info == info {
+			if elapsed := u.At.Sub(last.usage.At); elapsed > 0 {
+				u.CPUPercent = 100 * float64(u.CPUTime-last.usage.CPUTime) / float64(elapsed)
+			}
+		}
+		usage[id] = usageRecord{info: info, usage: u}
+		if pf.overLimitLocked(u) {
+			over = append(over, id)
+			logging.LogError("kubectl of '%s' uses %s, above the %s limit", id, config.FormatMemorySize(u.RSS), config.FormatMemorySize(pf.memoryLimit))
+		}
+	}
+	pf.usage = usage
+	sort.Strings(over)
+	return over
+}
@@ -0,0 +1,56 @@
+//go:build linux
+
+package k8s
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// userHZ is the unit of the CPU times in /proc/<pid>/stat, 100 per second on
+// every Linux architecture kprtfwd runs on
+const userHZ = 100
+
+// processUsage reads the CPU time and resident memory of the process pid
+// from /proc
+func processUsage(pid int) (ProcessUsage, error) {
+	var u ProcessUsage
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return u, err
+	}
+	// pid (comm) state ppid ...: comm may contain spaces and parentheses,
+	// so the fields are counted from the last ')'. utime and stime are the
+	// 14th and 15th fields, the 12th and 13th after it.
+	end := strings.LastIndexByte(string(stat), ')')
+	fields := strings.Fields(string(stat[end+1:]))
+	if end < 0 || len(fields) < 13 {
+		return u, fmt.Errorf("unexpected /proc/%d/stat", pid)
+	}
+	for _, field := range fields[11:13] {
+		ticks, err := strconv.ParseInt(field, 10, 64)
+		if err != nil {
+			return u, fmt.Errorf("unexpected /proc/%d/stat: %w", pid, err)
+		}
+		u.CPUTime += time.Duration(ticks) * time.Second / userHZ
+	}
+
+	statm, err := os.ReadFile(fmt.Sprintf("/proc/%d/statm", pid))
+	if err != nil {
+		return u, err
+	}
+	// size resident shared ...: in pages
+	pages := strings.Fields(string(statm))
+	if len(pages) < 2 {
+		return u, fmt.Errorf("unexpected /proc/%d/statm", pid)
+	}
+	resident, err := strconv.ParseInt(pages[1], 10, 64)
+	if err != nil {
+		return u, fmt.Errorf("unexpected /proc/%d/statm: %w", pid, err)
+	}
+	u.RSS = resident * int64(os.Getpagesize())
+	return u, nil
+}
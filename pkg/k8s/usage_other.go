@@ -0,0 +1,63 @@
+//go:build !linux
+
+package k8s
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// processUsage asks ps for the CPU time and resident memory of the process
+// pid (an error where there is no ps, e.g. on Windows)
+func processUsage(pid int) (ProcessUsage, error) {
+	var u ProcessUsage
+	out, err := exec.Command("ps", "-o", "rss=,time=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return u, err
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) != 2 {
+		return u, fmt.Errorf("unexpected ps output %q", out)
+	}
+	kib, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return u, fmt.Errorf("unexpected ps output %q", out)
+	}
+	u.RSS = kib << 10
+	if u.CPUTime, err = parseCPUTime(fields[1]); err != nil {
+		return u, fmt.Errorf("unexpected ps output %q", out)
+	}
+	return u, nil
+}
+
+// parseCPUTime reads ps' TIME column: [[dd-]hh:]mm:ss, with a fraction of a
+// second on macOS (0:01.25)
+func parseCPUTime(text string) (time.Duration, error) {
+	var total time.Duration
+	if days, rest, ok := strings.Cut(text, "-"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, err
+		}
+		total, text = time.Duration(n)*24*time.Hour, rest
+	}
+	parts := strings.Split(text, ":")
+	seconds, err := strconv.ParseFloat(parts[len(parts)-1], 64)
+	if err != nil {
+		return 0, err
+	}
+	total += time.Duration(seconds * float64(time.Second))
+	unit := time.Minute
+	for i := len(parts) - 2; i >= 0; i-- {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil {
+			return 0, err
+		}
+		total += time.Duration(n) * unit
+		unit *= 60
+	}
+	return total, nil
+}
@@ -0,0 +1,46 @@
+package k8s
+
+import (
+	"os"
+	"os/exec"
+	"slices"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestSampleUsageFlagsKubectlAboveTheMemoryLimit(t *testing.T) {
+	pf := NewPortForwarder()
+	markRunning(pf, "ctx.ns.web", 8080)
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	pf.Mutex.Lock()
+	pf.RunningForwards["ctx.ns.web"].cmd = &exec.Cmd{Process: self} // the test stands in for kubectl
+	pf.Mutex.Unlock()
+
+	if over := pf.SampleUsage(); len(over) != 0 {
+		t.Fatalf("nothing uses 512MiB, got %v over the limit", over)
+	}
+	s := pf.State("ctx.ns.web")
+	if s.Usage.At.IsZero() {
+		t.Skip("this platform does not tell a process' usage")
+	}
+	if s.Usage.RSS <= 0 || s.OverMemory {
+		t.Fatalf("usage = %+v, over %v", s.Usage, s.OverMemory)
+	}
+
+	pf.ApplySettings(map[string]string{config.SettingKubectlMemoryLimit: "1KiB", config.SettingKubectlMemoryRestart: "true"})
+	if over := pf.SampleUsage(); !slices.Equal(over, []string{"ctx.ns.web"}) || !pf.State("ctx.ns.web").OverMemory {
+		t.Fatalf("over the limit = %v", over)
+	}
+	if !pf.MemoryRestart() {
+		t.Fatal("kubectl.memory_restart was not applied")
+	}
+
+	pf.ApplySettings(map[string]string{config.SettingKubectlMemoryLimit: "0"})
+	if over := pf.SampleUsage(); len(over) != 0 || pf.MemoryRestart() {
+		t.Fatalf("a limit of 0 flags nothing, got %v", over)
+	}
+}
@@ -0,0 +1,303 @@
+// Package kprtfwd is the programmatic API of kprtfwd. It bundles the config
+// store, the port forwarder and service discovery behind a Manager so other
+// tools can manage forwards without driving the TUI:
+//
+//	m, err := kprtfwd.Open()
+//	if err != nil {
+//		return err
+//	}
+//	defer m.Close()
+//	if err := m.Start("prod.payments.api.payments-api"); err != nil {
+//		return err
+//	}
+//
+// A Manager works on the same database as the kprtfwd binary
+// (~/.kprtfwd/kprtfwd.db, or $KPRTFWD_DB) and honors the same settings.
+// Forwards it starts belong to the calling process: they are not visible to a
+// TUI running elsewhere and stop when the Manager is closed.
+package kprtfwd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// Forward is a configured port-forward.
+type Forward = config.PortForwardConfig
+
+// Project is a named set of forward IDs.
+type Project = config.Project
+
+// Status is the runtime state of a forward.
+type Status string
+
+// Forward statuses, as shown in the TUI's STATUS column
+const (
+	StatusStopped Status = "stopped"
+	StatusRunning Status = "running"
+	StatusStandby Status = "standby" // lazy or TLS forward listening, kubectl not started yet
+	StatusQueued  Status = "queued"  // waiting for the context's start limits
+	StatusFailed  Status = "failed"  // the last start failed or the tunnel broke
+)
+
+// Manager manages the configured forwards of one kprtfwd database.
+type Manager struct {
+	store     *config.SQLiteConfigStore
+	forwarder *k8s.PortForwarder
+}
+
+// Open opens the kprtfwd database and returns a Manager for it, with the
+// stored settings (kubectl binary and timeouts, start limits, ...) applied.
+// Close it when done.
+func Open() (*Manager, error) {
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to open config store: %w", err)
+	}
+	return New(store), nil
+}
+
+// New returns a Manager for an already opened store and applies its
+// settings. Closing the Manager closes the store.
+func New(store *config.SQLiteConfigStore) *Manager {
+	settings := store.GetSettings()
+	kubectl.ApplySettings(settings)
+	forwarder := k8s.NewPortForwarder()
+	forwarder.ApplySettings(settings)
+	// A deleted forward's process would otherwise run on
+	store.SetBeforeDelete(forwarder.Stop)
+	return &Manager{store: store, forwarder: forwarder}
+}
+
+// Close stops every forward the Manager started and closes the store.
+func (m *Manager) Close() error {
+	m.forwarder.CleanupAll()
+	return m.store.Close()
+}
+
+// Store returns the underlying config store, for what the Manager does not
+// cover (settings, service snapshots, start/stop history).
+func (m *Manager) Store() *config.SQLiteConfigStore {
+	return m.store
+}
+
+// Forwarder returns the underlying port forwarder.
+func (m *Manager) Forwarder() *k8s.PortForwarder {
+	return m.forwarder
+}
+
+// Forwards returns all configured forwards, ordered by context, namespace
+// and service.
+func (m *Manager) Forwards() []Forward {
+	return m.store.GetAll()
+}
+
+// Forward returns the forward with the given ID.
+func (m *Manager) Forward(id string) (Forward, bool) {
+	return m.store.GetConfigByID(id)
+}
+
+// Projects returns all projects.
+func (m *Manager) Projects() []Project {
+	return m.store.GetAllProjects()
+}
+
+// Add validates cfg and stores it as a new forward.
+func (m *Manager) Add(cfg Forward) error {
+	if err := validate(cfg); err != nil {
+		return err
+	}
+	return m.store.Add(cfg)
+}
+
+// Update validates cfg and stores it in place of the forward id (cfg.ID may
+// differ to rename it). A running forward is restarted with the new config.
+func (m *Manager) Update(id string, cfg Forward) error {
+	if err := validate(cfg); err != nil {
+		return err
+	}
+	if err := m.store.UpdatePortForward(id, cfg); err != nil {
+		return err
+	}
+	if m.forwarder.IsRunning(id) {
+		if err := m.forwarder.Restart(cfg); err != nil {
+			return fmt.Errorf("updated %s, but restart failed: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// Delete stops the forward id if it runs and removes it, including from
+// every project.
+func (m *Manager) Delete(id string) error {
+	return m.store.DeletePortForward(id)
+}
+
+// Start starts the forward id. It returns once kubectl is up (or, for lazy
+// and TLS forwards, once the local port listens); a start held back by the
+// start limits returns nil and the forward shows StatusQueued.
+func (m *Manager) Start(id string) error {
+	cfg, ok := m.store.GetConfigByID(id)
+	if !ok {
+		return fmt.Errorf("no forward with ID %q", id)
+	}
+	return m.forwarder.Start(cfg)
+}
+
+// Stop stops the forward id. Stopping a stopped forward is a no-op.
+func (m *Manager) Stop(id string) error {
+	return m.forwarder.Stop(id)
+}
+
+// Restart stops and starts the forward id.
+func (m *Manager) Restart(id string) error {
+	cfg, ok := m.store.GetConfigByID(id)
+	if !ok {
+		return fmt.Errorf("no forward with ID %q", id)
+	}
+	return m.forwarder.Restart(cfg)
+}
+
+// StartProject starts every forward of the named project and returns the
+// first error; the other forwards are started regardless.
+func (m *Manager) StartProject(name string) error {
+	var project *Project
+	for _, p := range m.store.GetAllProjects() {
+		if p.Name == name {
+			project = &p
+			break
+		}
+	}
+	if project == nil {
+		return fmt.Errorf("no project named %q", name)
+	}
+	var firstErr error
+	for _, id := range project.Forwards {
+		if err := m.Start(id); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("%s: %w", id, err)
+		}
+	}
+	return firstErr
+}
+
+// StopAll stops every running forward and returns how many there were.
+func (m *Manager) StopAll() int {
+	return m.forwarder.StopAllRunning()
+}
+
+// Status returns the runtime state of the forward id.
+func (m *Manager) Status(id string) Status {
+	switch {
+	case m.forwarder.IsQueued(id):
+		return StatusQueued
+	case m.forwarder.IsStandby(id):
+		return StatusStandby
+	case m.forwarder.IsRunning(id):
+		return StatusRunning
+	case m.forwarder.IsError(id):
+		return StatusFailed
+	default:
+		return StatusStopped
+	}
+}
+
+// ErrorReason returns why the forward id is in StatusFailed, or "".
+func (m *Manager) ErrorReason(id string) string {
+	return m.forwarder.ErrorReason(id)
+}
+
+// CheckHealth checks every running forward as the TUI does every 2 seconds:
+// forwards whose tunnel is dead are stopped and marked failed, and Health
+// reports how each answered. Blocking.
+func (m *Manager) CheckHealth() {
+	m.forwarder.MarkBroken(m.forwarder.CheckHealth())
+}
+
+// Health returns how the forward id answered the last CheckHealth while it
+// ran, or k8s.HealthDead if that check is why it is in StatusFailed.
+func (m *Manager) Health(id string) k8s.Health {
+	return m.forwarder.State(id).Health
+}
+
+// Discover lists the services of kubeContext (the current context if empty)
+// in the namespaces matching namespaceFilter ("*" for all, wildcards
+// allowed). Nothing is stored: mark the services to keep as Selected and
+// pass the result to AddDiscovered.
+func (m *Manager) Discover(kubeContext, namespaceFilter string) (*discovery.DiscoveryResult, error) {
+	return discovery.DiscoverServices(discovery.Options{Context: kubeContext, NamespaceFilter: namespaceFilter})
+}
+
+// AddDiscovered stores a forward for each port of the selected services in
+// result, skipping IDs that already exist, and returns the forwards added.
+// Local ports follow the context's ports.local setting.
+func (m *Manager) AddDiscovered(result *discovery.DiscoveryResult) ([]Forward, error) {
+	strategy := config.LocalPortStrategy(m.store.GetSettings(), result.Context)
+	var added []Forward
+	for _, cfg := range result.GenerateConfig() {
+		if _, exists := m.store.GetConfigByID(cfg.ID); exists {
+			continue
+		}
+		cfg.PortLocal = config.DefaultLocalPort(strategy, cfg.PortRemote, m.store.GetAll())
+		if err := m.Add(cfg); err != nil {
+			return added, fmt.Errorf("%s: %w", cfg.ID, err)
+		}
+		added = append(added, cfg)
+	}
+	return added, nil
+}
+
+// validate checks what the forwarder would refuse to start, so a bad config
+// fails when stored rather than on its first start.
+func validate(cfg Forward) error {
+	if cfg.ID == "" {
+		return errors.New("forward ID must not be empty")
+	}
+	if err := config.ValidateContextName(cfg.Context); err != nil {
+		return err
+	}
+	if err := config.ValidateKubernetesName("namespace", cfg.Namespace); err != nil {
+		return err
+	}
+	if err := config.ValidateKubernetesName(cfg.KindName(), cfg.Service); err != nil {
+		return err
+	}
+	if err := config.ValidateTarget(cfg.TargetKind, cfg.PodSelector); err != nil {
+		return err
+	}
+	if err := config.ValidatePortRange("local port", cfg.PortLocal, cfg.PortCount); err != nil {
+		return err
+	}
+	if err := config.ValidatePortRange("remote port", cfg.PortRemote, cfg.PortCount); err != nil {
+		return err
+	}
+	if err := config.ValidateTLSMode(cfg.TLSMode); err != nil {
+		return err
+	}
+	if err := config.ValidateListen(cfg.Listen); err != nil {
+		return err
+	}
+	if err := config.ValidateKubectlArgs(cfg.KubectlArgs); err != nil {
+		return err
+	}
+	if err := config.ValidateTags(cfg.Tags); err != nil {
+		return err
+	}
+	if err := config.ValidatePodSelector(cfg.PodSelector); err != nil {
+		return err
+	}
+	if err := config.ValidateHook(cfg.StartHook); err != nil {
+		return err
+	}
+	if err := config.ValidateHook(cfg.StopHook); err != nil {
+		return err
+	}
+	if err := config.ValidateHealthPath(cfg.HealthPath); err != nil {
+		return err
+	}
+	return config.ValidateOpenCommand(cfg.OpenCommand)
+}
@@ -0,0 +1,108 @@
+package kprtfwd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// openTestManager opens a Manager on an empty database in a temporary HOME,
+// with a fake long-running kubectl on PATH
+func openTestManager(t *testing.T) *Manager {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep binary not available")
+	}
+	t.Setenv("HOME", t.TempDir())
+	t.Setenv("KPRTFWD_DB", "")
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nexec %s 30\n", sleepPath)
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir)
+
+	m, err := Open()
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { _ = m.Close() })
+	return m
+}
+
+// freeLocalPort returns a TCP port that is currently free on localhost
+func freeLocalPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+func TestManagerLifecycle(t *testing.T) {
+	m := openTestManager(t)
+	cfg := Forward{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: freeLocalPort(t)}
+	if err := m.Add(cfg); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if err := m.Store().CreateProject("team", []string{cfg.ID}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.StartProject("team"); err != nil {
+		t.Fatalf("StartProject: %v", err)
+	}
+	if got := m.Status(cfg.ID); got != StatusRunning {
+		t.Fatalf("status after start = %s, want %s", got, StatusRunning)
+	}
+
+	updated := cfg
+	updated.PortLocal = freeLocalPort(t)
+	if err := m.Update(cfg.ID, updated); err != nil {
+		t.Fatalf("Update: %v", err)
+	}
+	if got, _ := m.Forward(cfg.ID); got != updated || m.Status(cfg.ID) != StatusRunning {
+		t.Fatalf("after update: %+v, %s", got, m.Status(cfg.ID))
+	}
+
+	if err := m.Delete(cfg.ID); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if m.Status(cfg.ID) != StatusStopped || len(m.Forwards()) != 0 {
+		t.Fatal("a deleted forward must be stopped and gone")
+	}
+	if err := m.Start(cfg.ID); err == nil {
+		t.Fatal("starting a deleted forward must fail")
+	}
+}
+
+func TestManagerRejectsInvalidForwards(t *testing.T) {
+	m := openTestManager(t)
+	valid := Forward{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080}
+	for name, mutate := range map[string]func(*Forward){
+		"empty ID":        func(f *Forward) { f.ID = "" },
+		"flag as service": func(f *Forward) { f.Service = "--kubeconfig=/tmp/x" },
+		"port":            func(f *Forward) { f.PortLocal = 0 },
+		"TLS mode":        func(f *Forward) { f.TLSMode = "mtls" },
+		"kubectl args":    func(f *Forward) { f.KubectlArgs = "svc/other" },
+	} {
+		cfg := valid
+		mutate(&cfg)
+		if err := m.Add(cfg); err == nil {
+			t.Errorf("%s: Add accepted %+v", name, cfg)
+		}
+	}
+	if len(m.Forwards()) != 0 {
+		t.Fatal("rejected forwards must not be stored")
+	}
+}
@@ -0,0 +1,155 @@
+package kubectl
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Sentinel errors for the kubectl failures kprtfwd knows how to explain. Match
+// them with errors.Is; the error text itself stays kubectl's original message.
+var (
+	ErrContextNotFound = errors.New("kubernetes context not found")
+	ErrAuthExpired     = errors.New("kubernetes credentials expired or rejected")
+	ErrTargetMissing   = errors.New("forward target not found in cluster")
+	ErrTimeout         = errors.New("kubectl timed out")
+	ErrForbidden       = errors.New("not permitted by the cluster's RBAC rules")
+)
+
+// errorSignatures maps each sentinel to stderr fragments that identify it.
+// Checked in order; the first match wins.
+var errorSignatures = []struct {
+	kind      error
+	fragments []string
+}{
+	{ErrContextNotFound, []string{
+		"context was not found for specified context",
+		"no context exists with the name",
+		"does not exist", // error: context "foo" does not exist
+	}},
+	{ErrAuthExpired, []string{
+		"You must be logged in to the server",
+		"Unauthorized",
+		"the server has asked for the client to provide credentials",
+		"token is expired",
+		"token has expired",
+		"invalid_grant",
+		"getting credentials: exec",
+	}},
+	{ErrForbidden, []string{
+		"(Forbidden)", // Error from server (Forbidden): services is forbidden: User "x" cannot list ...
+		"is forbidden:",
+	}},
+	{ErrTargetMissing, []string{
+		`" not found`, // Error from server (NotFound): services "api" not found
+	}},
+	{ErrTimeout, []string{
+		"i/o timeout",
+		"TLS handshake timeout",
+		"Client.Timeout exceeded",
+		"context deadline exceeded",
+		"request timed out",
+		"timed out waiting",
+	}},
+}
+
+// classifiedError keeps the original message while letting errors.Is match
+// both the sentinel and the underlying error.
+type classifiedError struct {
+	kind error
+	err  error
+}
+
+func (e *classifiedError) Error() string   { return e.err.Error() }
+func (e *classifiedError) Unwrap() []error { return []error{e.kind, e.err} }
+
+// KindOf returns the sentinel error describing a kubectl message (usually its
+// stderr), or nil if the failure is not one kprtfwd recognises.
+func KindOf(message string) error {
+	for _, sig := range errorSignatures {
+		for _, fragment := range sig.fragments {
+			if !strings.Contains(message, fragment) {
+				continue
+			}
+			// Any kind of target can be missing: services, pods, and
+			// workloads such as deployments.apps
+			if sig.kind == ErrTargetMissing && !notFoundPattern.MatchString(message) {
+				continue
+			}
+			// "does not exist" is only a context error when it names one.
+			if sig.kind == ErrContextNotFound && fragment == "does not exist" && !strings.Contains(message, "context") {
+				continue
+			}
+			return sig.kind
+		}
+	}
+	return nil
+}
+
+// notFoundPattern matches kubectl's NotFound message for a resource of any
+// kind, e.g. `pods "api-0" not found` or `deployments.apps "web" not found`
+var notFoundPattern = regexp.MustCompile(`\b[a-z]+(?:\.[a-z0-9.-]+)? "[^"]+" not found`)
+
+// Classify wraps err with the sentinel matching stderr, if any. Unrecognised
+// failures and nil errors are returned unchanged.
+func Classify(err error, stderr string) error {
+	if err == nil {
+		return nil
+	}
+	kind := KindOf(stderr)
+	if kind == nil || errors.Is(err, kind) {
+		return err
+	}
+	return &classifiedError{kind: kind, err: err}
+}
+
+// Permission is an RBAC permission the API server reported missing
+type Permission struct {
+	User      string
+	Verb      string // e.g. "create"
+	Resource  string // with subresource and API group, e.g. "pods/portforward" or "deployments.apps"
+	Namespace string // empty at the cluster scope
+}
+
+// forbiddenPattern matches the reason of a Forbidden response, e.g.
+// User "dev" cannot create resource "pods/portforward" in API group "" in the namespace "team-b"
+var forbiddenPattern = regexp.MustCompile(`User "([^"]*)" cannot (\S+) resource "([^"]+)" in API group "([^"]*)"(?: in the namespace "([^"]+)")?`)
+
+// MissingPermission extracts the permission a Forbidden kubectl message says
+// the user lacks; ok is false if the message names none.
+func MissingPermission(message string) (perm Permission, ok bool) {
+	m := forbiddenPattern.FindStringSubmatch(message)
+	if m == nil {
+		return Permission{}, false
+	}
+	perm = Permission{User: m[1], Verb: m[2], Resource: m[3], Namespace: m[5]}
+	if group := m[4]; group != "" {
+		resource, subresource, found := strings.Cut(perm.Resource, "/")
+		perm.Resource = resource + "." + group
+		if found {
+			perm.Resource += "/" + subresource
+		}
+	}
+	return perm, true
+}
+
+// String describes the permission, e.g. "create pods/portforward in
+// namespace team-b"
+func (p Permission) String() string {
+	if p.Namespace == "" {
+		return fmt.Sprintf("%s %s at the cluster scope", p.Verb, p.Resource)
+	}
+	return fmt.Sprintf("%s %s in namespace %s", p.Verb, p.Resource, p.Namespace)
+}
+
+// SuggestedRole names the smallest built-in ClusterRole granting p: "view"
+// covers reading (get, list, watch), "edit" everything else kprtfwd does,
+// including creating pods/portforward
+func (p Permission) SuggestedRole() string {
+	switch p.Verb {
+	case "get", "list", "watch":
+		return "view"
+	}
+	return "edit"
+}
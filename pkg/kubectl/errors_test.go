@@ -0,0 +1,87 @@
+package kubectl
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		stderr string
+		want   error
+	}{
+		{`error: context "staging" does not exist`, ErrContextNotFound},
+		{`error: no context exists with the name: "staging"`, ErrContextNotFound},
+		{"error: You must be logged in to the server (Unauthorized)", ErrAuthExpired},
+		{"getting credentials: exec: executable aws failed with exit code 255", ErrAuthExpired},
+		{`Error from server (NotFound): services "api" not found`, ErrTargetMissing},
+		{`Error from server (NotFound): pods "api-0" not found`, ErrTargetMissing},
+		{`Error from server (NotFound): deployments.apps "web" not found`, ErrTargetMissing},
+		{`Error from server (Forbidden): services "api" is forbidden`, ErrForbidden},
+		{`Error from server (Forbidden): services is forbidden: User "dev" cannot list resource "services" in API group "" at the cluster scope`, ErrForbidden},
+		{"Unable to connect to the server: dial tcp 10.0.0.1:443: i/o timeout", ErrTimeout},
+		{`error: namespace "x" does not exist`, nil},
+		{"something unexpected", nil},
+	}
+	for _, tt := range tests {
+		if got := KindOf(tt.stderr); got != tt.want {
+			t.Errorf("KindOf(%q) = %v, want %v", tt.stderr, got, tt.want)
+		}
+	}
+}
+
+func TestClassifyKeepsMessageAndCause(t *testing.T) {
+	cause := errors.New("exit status 1")
+	err := Classify(cause, "error: You must be logged in to the server (Unauthorized)")
+	if !errors.Is(err, ErrAuthExpired) {
+		t.Fatalf("expected ErrAuthExpired, got %v", err)
+	}
+	if !errors.Is(err, cause) {
+		t.Fatal("the original error must stay reachable")
+	}
+	if err.Error() != cause.Error() {
+		t.Fatalf("message changed to %q", err.Error())
+	}
+	if got := Classify(cause, "unknown failure"); got != cause {
+		t.Fatalf("unrecognised failures must pass through unchanged, got %v", got)
+	}
+}
+
+func TestMissingPermission(t *testing.T) {
+	tests := []struct {
+		message string
+		want    Permission
+		role    string
+	}{
+		{
+			`error: error upgrading connection: pods "api-7d9" is forbidden: User "dev" cannot create resource "pods/portforward" in API group "" in the namespace "team-b"`,
+			Permission{User: "dev", Verb: "create", Resource: "pods/portforward", Namespace: "team-b"},
+			"edit",
+		},
+		{
+			`Error from server (Forbidden): services is forbidden: User "dev" cannot list resource "services" in API group "" at the cluster scope`,
+			Permission{User: "dev", Verb: "list", Resource: "services"},
+			"view",
+		},
+		{
+			`Error from server (Forbidden): deployments.apps "api" is forbidden: User "dev" cannot get resource "deployments/scale" in API group "apps" in the namespace "ns"`,
+			Permission{User: "dev", Verb: "get", Resource: "deployments.apps/scale", Namespace: "ns"},
+			"view",
+		},
+	}
+	for _, tt := range tests {
+		got, ok := MissingPermission(tt.message)
+		if !ok || got != tt.want {
+			t.Errorf("MissingPermission(%q) = %+v, %v, want %+v", tt.message, got, ok, tt.want)
+		}
+		if role := got.SuggestedRole(); role != tt.role {
+			t.Errorf("SuggestedRole of %+v = %q, want %q", got, role, tt.role)
+		}
+	}
+	if got := (Permission{Verb: "create", Resource: "pods/portforward", Namespace: "team-b"}).String(); got != "create pods/portforward in namespace team-b" {
+		t.Errorf("String() = %q", got)
+	}
+	if _, ok := MissingPermission(`Error from server (Forbidden): services "api" is forbidden`); ok {
+		t.Error("a message without a reason must not yield a permission")
+	}
+}
@@ -0,0 +1,130 @@
+package kubectl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// KubeconfigPaths returns the kubeconfig files kubectl reads: the entries of
+// $KUBECONFIG if set, otherwise ~/.kube/config.
+func KubeconfigPaths() []string {
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		var paths []string
+		for _, path := range filepath.SplitList(env) {
+			if path != "" {
+				paths = append(paths, path)
+			}
+		}
+		return paths
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil
+	}
+	return []string{filepath.Join(home, ".kube", "config")}
+}
+
+// KubeconfigStamp returns a fingerprint of the kubeconfig files' modification
+// times and sizes. It changes whenever kubectl rewrites them — `kubectl config
+// use-context`, a new cloud login — and costs only a stat per file, so it can
+// be polled.
+func KubeconfigStamp() string {
+	var b strings.Builder
+	for _, path := range KubeconfigPaths() {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintf(&b, "%s:-;", path)
+			continue
+		}
+		fmt.Fprintf(&b, "%s:%d:%d;", path, info.ModTime().UnixNano(), info.Size())
+	}
+	return b.String()
+}
+
+// Contexts lists the context names of the merged kubeconfig
+func Contexts() ([]string, error) {
+	out, err := Run(CmdGetContexts, "config", "get-contexts", "-o", "name")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// ContextNamespace returns the namespace a context defaults to, "default"
+// when its kubeconfig entry sets none
+func ContextNamespace(context string) (string, error) {
+	args := append(ContextArgs(context), "config", "view", "--minify", "-o", "jsonpath={..namespace}")
+	out, err := Run(CmdGetContexts, args...)
+	if err != nil {
+		return "", err
+	}
+	if namespace := strings.TrimSpace(string(out)); namespace != "" {
+		return namespace, nil
+	}
+	return "default", nil
+}
+
+// ContextSources maps each of contexts to the kubeconfig file it comes from.
+// Like kubectl's merge, the first file in KubeconfigPaths defining a context
+// wins. With a single file no kubectl call is needed; otherwise each file is
+// listed on its own, and a file that cannot be read is skipped.
+func ContextSources(contexts []string) map[string]string {
+	sources := make(map[string]string, len(contexts))
+	paths := KubeconfigPaths()
+	if len(paths) == 1 {
+		for _, context := range contexts {
+			sources[context] = paths[0]
+		}
+		return sources
+	}
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		out, err := Run(CmdGetContexts, "--kubeconfig", path, "config", "get-contexts", "-o", "name")
+		if err != nil {
+			logging.LogDebug("Listing contexts of %s failed: %v", path, err)
+			continue
+		}
+		for _, context := range strings.Fields(string(out)) {
+			if _, seen := sources[context]; !seen && slices.Contains(contexts, context) {
+				sources[context] = path
+			}
+		}
+	}
+	return sources
+}
+
+// KubeconfigArgs returns the kubectl flags that make a forward whose context
+// was found in the kubeconfig file recorded keep working: none while that
+// file is still among KubeconfigPaths (kubectl merges it anyway) or gone,
+// else --kubeconfig pointing at it, for a KUBECONFIG that no longer lists it.
+func KubeconfigArgs(recorded string) []string {
+	if recorded == "" {
+		return nil
+	}
+	for _, path := range KubeconfigPaths() {
+		if samePath(path, recorded) {
+			return nil
+		}
+	}
+	if _, err := os.Stat(recorded); err != nil {
+		return nil
+	}
+	return []string{"--kubeconfig", recorded}
+}
+
+// samePath reports whether a and b name the same file once made absolute
+func samePath(a, b string) bool {
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return absA == absB
+}
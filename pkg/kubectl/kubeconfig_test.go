@@ -0,0 +1,89 @@
+package kubectl
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestKubeconfigStampChangesWhenFileIsRewritten(t *testing.T) {
+	dir := t.TempDir()
+	primary := filepath.Join(dir, "config")
+	extra := filepath.Join(dir, "eks")
+	t.Setenv("KUBECONFIG", primary+string(os.PathListSeparator)+extra)
+
+	if err := os.WriteFile(primary, []byte("current-context: a\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	before := KubeconfigStamp()
+	if before != KubeconfigStamp() {
+		t.Fatal("stamp must be stable while nothing changes")
+	}
+
+	// A missing file appearing counts as a change too.
+	if err := os.WriteFile(extra, []byte("contexts: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	afterCreate := KubeconfigStamp()
+	if afterCreate == before {
+		t.Fatal("creating a kubeconfig file should change the stamp")
+	}
+
+	later := time.Now().Add(time.Second)
+	if err := os.WriteFile(primary, []byte("current-context: b\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(primary, later, later) // coarse filesystem clocks
+	if KubeconfigStamp() == afterCreate {
+		t.Fatal("rewriting the kubeconfig should change the stamp")
+	}
+}
+
+func TestContextSourcesFirstFileWins(t *testing.T) {
+	// The fake kubectl lists the "contexts" written one per line in the file
+	// passed with --kubeconfig
+	installScriptKubectl(t, `cat "$2"`)
+	useSettings(t, nil)
+	dir := t.TempDir()
+	work, shared, missing := filepath.Join(dir, "work"), filepath.Join(dir, "shared"), filepath.Join(dir, "missing")
+	if err := os.WriteFile(work, []byte("prod\nstaging\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shared, []byte("staging\nkind\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KUBECONFIG", strings.Join([]string{missing, work, shared}, string(os.PathListSeparator)))
+
+	sources := ContextSources([]string{"prod", "staging", "kind"})
+	want := map[string]string{"prod": work, "staging": work, "kind": shared}
+	if !maps.Equal(sources, want) {
+		t.Fatalf("ContextSources = %v, want %v", sources, want)
+	}
+}
+
+func TestKubeconfigArgs(t *testing.T) {
+	dir := t.TempDir()
+	listed, dropped := filepath.Join(dir, "listed"), filepath.Join(dir, "dropped")
+	for _, path := range []string{listed, dropped} {
+		if err := os.WriteFile(path, nil, 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Setenv("KUBECONFIG", listed)
+
+	if args := KubeconfigArgs(listed); args != nil {
+		t.Errorf("a file KUBECONFIG lists needs no flag, got %v", args)
+	}
+	if args := KubeconfigArgs(""); args != nil {
+		t.Errorf("no recorded file needs no flag, got %v", args)
+	}
+	if args := KubeconfigArgs(filepath.Join(dir, "moved")); args != nil {
+		t.Errorf("a file that is gone must not be passed, got %v", args)
+	}
+	if args := KubeconfigArgs(dropped); len(args) != 2 || args[0] != "--kubeconfig" || args[1] != dropped {
+		t.Errorf("a file KUBECONFIG no longer lists should be passed, got %v", args)
+	}
+}
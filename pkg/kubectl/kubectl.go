@@ -0,0 +1,248 @@
+// Package kubectl runs short-lived kubectl commands (context and service
+// lookups) with configurable timeouts and a retry on transient failures.
+// Long-running port-forward processes are managed by pkg/k8s instead.
+package kubectl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// Command names identify kubectl invocations; they are the <command> part of
+// the kubectl.timeout.<command> setting.
+const (
+	CmdCurrentContext = "current-context"
+	CmdGetContexts    = "get-contexts"
+	CmdGetNamespaces  = "get-namespaces"
+	CmdGetServices    = "get-services"
+	CmdGetEndpoints   = "get-endpoints"
+	CmdGetPods        = "get-pods"
+	CmdGetNodes       = "get-nodes"
+	CmdDescribe       = "describe"
+	CmdVersion        = "version"
+)
+
+// defaultTimeouts are used when no setting overrides them. Listing every
+// service in a large cluster takes far longer than reading the kubeconfig.
+var defaultTimeouts = map[string]time.Duration{
+	CmdCurrentContext: 10 * time.Second,
+	CmdGetContexts:    10 * time.Second,
+	CmdGetNamespaces:  30 * time.Second,
+	CmdGetServices:    60 * time.Second,
+	CmdGetEndpoints:   10 * time.Second,
+	CmdGetPods:        10 * time.Second,
+	CmdGetNodes:       10 * time.Second,
+	CmdDescribe:       20 * time.Second,
+	CmdVersion:        10 * time.Second,
+}
+
+// fallbackTimeout applies to commands without a default of their own.
+const fallbackTimeout = 30 * time.Second
+
+// defaultRetries is how many times a transient failure is retried.
+const defaultRetries = 1
+
+// transientErrors are stderr fragments of failures worth retrying: the API
+// server was momentarily unreachable rather than the request being wrong.
+var transientErrors = []string{
+	"i/o timeout",
+	"TLS handshake timeout",
+	"connection reset by peer",
+	"connection refused",
+	"Unable to connect to the server",
+	"net/http: request canceled",
+	"the server is currently unable to handle the request",
+	"etcdserver: request timed out",
+}
+
+// runSettings holds the effective configuration, replaced wholesale by
+// ApplySettings.
+type runSettings struct {
+	timeout    time.Duration            // overrides defaultTimeouts when non-zero
+	perCommand map[string]time.Duration // overrides everything for one command
+	retries    int
+	binary     string            // kubectl to run; "kubectl" from PATH when empty
+	caBundles  map[string]string // context -> CA bundle overriding the kubeconfig's
+}
+
+var (
+	settingsMu sync.RWMutex
+	current    = runSettings{retries: defaultRetries}
+)
+
+// ApplySettings configures timeouts, retries and the binary from settings (see
+// config.SettingKubectlTimeout and friends). Unparseable values are logged and
+// ignored so a bad setting never prevents kprtfwd from starting.
+func ApplySettings(values map[string]string) {
+	s := runSettings{retries: defaultRetries, perCommand: make(map[string]time.Duration), caBundles: make(map[string]string)}
+	for key, value := range values {
+		switch {
+		case key == config.SettingKubectlTimeout:
+			if d, ok := parseTimeout(key, value); ok {
+				s.timeout = d
+			}
+		case strings.HasPrefix(key, config.SettingKubectlTimeoutPrefix):
+			if d, ok := parseTimeout(key, value); ok {
+				s.perCommand[strings.TrimPrefix(key, config.SettingKubectlTimeoutPrefix)] = d
+			}
+		case key == config.SettingKubectlPath:
+			s.binary = value
+		case strings.HasPrefix(key, config.SettingKubectlCABundlePrefix):
+			s.caBundles[strings.TrimPrefix(key, config.SettingKubectlCABundlePrefix)] = value
+		case key == config.SettingKubectlRetries:
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				logging.LogError("Ignoring invalid %s setting %q", key, value)
+				continue
+			}
+			s.retries = n
+		}
+	}
+
+	settingsMu.Lock()
+	current = s
+	settingsMu.Unlock()
+}
+
+func parseTimeout(key, value string) (time.Duration, bool) {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		logging.LogError("Ignoring invalid %s setting %q", key, value)
+		return 0, false
+	}
+	return d, true
+}
+
+// Timeout returns the effective timeout for a command: its own setting, then
+// the global setting, then the built-in default.
+func Timeout(command string) time.Duration {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+
+	if d, ok := current.perCommand[command]; ok {
+		return d
+	}
+	if current.timeout > 0 {
+		return current.timeout
+	}
+	if d, ok := defaultTimeouts[command]; ok {
+		return d
+	}
+	return fallbackTimeout
+}
+
+// Binary returns the kubectl to run: the kubectl.path setting, else "kubectl"
+// looked up in PATH. pkg/k8s uses it for port-forward processes too.
+func Binary() string {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	if current.binary != "" {
+		return current.binary
+	}
+	return "kubectl"
+}
+
+// ContextArgs returns the flags selecting kubeContext: --context, plus
+// --certificate-authority when a kubectl.ca_bundle setting overrides the CA
+// the kubeconfig trusts for it. None for the current context (empty).
+func ContextArgs(kubeContext string) []string {
+	if kubeContext == "" {
+		return nil
+	}
+	args := []string{"--context", kubeContext}
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	if bundle, ok := current.caBundles[kubeContext]; ok {
+		args = append(args, "--certificate-authority", bundle)
+	}
+	return args
+}
+
+func retries() int {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return current.retries
+}
+
+// Run executes kubectl with args under the timeout configured for command and
+// returns its stdout. Transient failures (timeouts, connection resets) are
+// retried up to the configured number of times; other failures return
+// immediately.
+func Run(command string, args ...string) ([]byte, error) {
+	attempts := retries() + 1
+	var lastErr error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		out, transient, err := runOnce(command, args)
+		if err == nil {
+			return out, nil
+		}
+		lastErr = err
+		if !transient || attempt == attempts {
+			break
+		}
+		logging.LogDebug("kubectl %s failed transiently (attempt %d/%d), retrying: %v", command, attempt, attempts, err)
+	}
+	return nil, lastErr
+}
+
+// ClientVersion returns the version of the kubectl binary, e.g. "v1.30.1".
+// It does not contact a cluster.
+func ClientVersion() (string, error) {
+	out, err := Run(CmdVersion, "version", "--client", "-o", "json")
+	if err != nil {
+		return "", err
+	}
+	var version struct {
+		ClientVersion struct {
+			GitVersion string `json:"gitVersion"`
+		} `json:"clientVersion"`
+	}
+	if err := json.Unmarshal(out, &version); err != nil {
+		return "", fmt.Errorf("failed to parse kubectl version: %w", err)
+	}
+	if version.ClientVersion.GitVersion == "" {
+		return "", fmt.Errorf("kubectl version did not report a client version")
+	}
+	return version.ClientVersion.GitVersion, nil
+}
+
+// runOnce makes a single attempt and reports whether a failure is transient.
+func runOnce(command string, args []string) ([]byte, bool, error) {
+	timeout := Timeout(command)
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, Binary(), args...)
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return nil, true, &classifiedError{kind: ErrTimeout, err: fmt.Errorf("kubectl %s timed out after %s", command, timeout)}
+		}
+		err = fmt.Errorf("kubectl %s failed: %w (stderr: %s)", command, err, stderr.String())
+		return nil, isTransient(stderr.String()), Classify(err, stderr.String())
+	}
+	return stdout.Bytes(), false, nil
+}
+
+func isTransient(stderr string) bool {
+	for _, fragment := range transientErrors {
+		if strings.Contains(stderr, fragment) {
+			return true
+		}
+	}
+	return false
+}
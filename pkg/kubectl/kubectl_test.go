@@ -0,0 +1,165 @@
+package kubectl
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+// installScriptKubectl puts a fake kubectl running body on PATH. Each
+// invocation appends a line to the returned file so tests can count attempts.
+func installScriptKubectl(t *testing.T, body string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	dir := t.TempDir()
+	calls := filepath.Join(dir, "calls")
+	script := "#!/bin/sh\necho call >> " + calls + "\n" + body + "\n"
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatalf("failed to write fake kubectl: %v", err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return calls
+}
+
+func countCalls(t *testing.T, calls string) int {
+	t.Helper()
+	data, err := os.ReadFile(calls)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(data), "call")
+}
+
+// useSettings applies settings for one test and restores the defaults after.
+func useSettings(t *testing.T, values map[string]string) {
+	t.Helper()
+	ApplySettings(values)
+	t.Cleanup(func() { ApplySettings(nil) })
+}
+
+func TestTimeoutPrecedence(t *testing.T) {
+	useSettings(t, nil)
+	if got := Timeout(CmdGetServices); got != 60*time.Second {
+		t.Fatalf("default get-services timeout = %v, want 60s", got)
+	}
+
+	useSettings(t, map[string]string{
+		"kubectl.timeout":                 "20s",
+		"kubectl.timeout.current-context": "3s",
+	})
+	if got := Timeout(CmdGetServices); got != 20*time.Second {
+		t.Errorf("global setting should override default, got %v", got)
+	}
+	if got := Timeout(CmdCurrentContext); got != 3*time.Second {
+		t.Errorf("per-command setting should win, got %v", got)
+	}
+}
+
+func TestApplySettingsIgnoresInvalidValues(t *testing.T) {
+	useSettings(t, map[string]string{
+		"kubectl.timeout.get-namespaces": "soon",
+		"kubectl.retries":                "-1",
+	})
+	if got := Timeout(CmdGetNamespaces); got != 30*time.Second {
+		t.Errorf("invalid timeout should fall back to default, got %v", got)
+	}
+	if got := retries(); got != defaultRetries {
+		t.Errorf("invalid retries should fall back to %d, got %d", defaultRetries, got)
+	}
+}
+
+func TestRunRetriesTransientFailureOnce(t *testing.T) {
+	calls := installScriptKubectl(t, "echo 'Unable to connect to the server: dial tcp: i/o timeout' >&2\nexit 1")
+	useSettings(t, nil)
+
+	if _, err := Run(CmdGetNamespaces, "get", "namespaces"); err == nil {
+		t.Fatal("expected an error from the failing kubectl")
+	}
+	if got := countCalls(t, calls); got != 2 {
+		t.Fatalf("expected 1 attempt + 1 retry, got %d calls", got)
+	}
+}
+
+func TestRunDoesNotRetryPermanentFailure(t *testing.T) {
+	calls := installScriptKubectl(t, "echo 'error: context \"nope\" does not exist' >&2\nexit 1")
+	useSettings(t, nil)
+
+	_, err := Run(CmdGetNamespaces, "--context", "nope", "get", "namespaces")
+	if !errors.Is(err, ErrContextNotFound) {
+		t.Fatalf("expected ErrContextNotFound, got %v", err)
+	}
+	if got := countCalls(t, calls); got != 1 {
+		t.Fatalf("a permanent failure must not be retried, got %d calls", got)
+	}
+}
+
+func TestRunTimesOutAndHonoursRetrySetting(t *testing.T) {
+	calls := installScriptKubectl(t, "exec sleep 5")
+	useSettings(t, map[string]string{
+		"kubectl.timeout.get-services": "200ms",
+		"kubectl.retries":              "0",
+	})
+
+	start := time.Now()
+	_, err := Run(CmdGetServices, "get", "services")
+	if err == nil || !strings.Contains(err.Error(), "timed out after 200ms") {
+		t.Fatalf("expected a timeout error, got %v", err)
+	}
+	if !errors.Is(err, ErrTimeout) {
+		t.Errorf("a timeout must match ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 3*time.Second {
+		t.Fatalf("configured timeout not applied, took %v", elapsed)
+	}
+	if got := countCalls(t, calls); got != 1 {
+		t.Fatalf("retries disabled, expected 1 call, got %d", got)
+	}
+}
+
+func TestContextArgs(t *testing.T) {
+	useSettings(t, map[string]string{"kubectl.ca_bundle.prod": "/etc/corp/ca.pem"})
+
+	if got := ContextArgs(""); got != nil {
+		t.Errorf("ContextArgs(\"\") = %q, want none for the current context", got)
+	}
+	if got := ContextArgs("staging"); !slices.Equal(got, []string{"--context", "staging"}) {
+		t.Errorf("ContextArgs(staging) = %q", got)
+	}
+	want := []string{"--context", "prod", "--certificate-authority", "/etc/corp/ca.pem"}
+	if got := ContextArgs("prod"); !slices.Equal(got, want) {
+		t.Errorf("ContextArgs(prod) = %q, want %q", got, want)
+	}
+}
+
+func TestRunUsesConfiguredBinary(t *testing.T) {
+	calls := installScriptKubectl(t, "echo ok")
+	// Move the fake off PATH so only the configured path can find it
+	binary := filepath.Join(t.TempDir(), "my-kubectl")
+	if err := os.Rename(filepath.Join(filepath.Dir(calls), "kubectl"), binary); err != nil {
+		t.Fatal(err)
+	}
+	useSettings(t, map[string]string{"kubectl.path": binary})
+
+	if out, err := Run(CmdCurrentContext, "config", "current-context"); err != nil || strings.TrimSpace(string(out)) != "ok" {
+		t.Fatalf("Run = %q, %v; want the configured binary's output", out, err)
+	}
+	if got := countCalls(t, calls); got != 1 {
+		t.Fatalf("configured binary ran %d times, want 1", got)
+	}
+}
+
+func TestClientVersion(t *testing.T) {
+	installScriptKubectl(t, `echo '{"clientVersion":{"major":"1","minor":"30","gitVersion":"v1.30.1"},"kustomizeVersion":"v5.0.4"}'`)
+	useSettings(t, nil)
+
+	if got, err := ClientVersion(); err != nil || got != "v1.30.1" {
+		t.Fatalf("ClientVersion = %q, %v; want v1.30.1", got, err)
+	}
+}
@@ -0,0 +1,44 @@
+package logging
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// forwardLogMaxSize is the size past which a forward's log is rotated
+const forwardLogMaxSize = 1024 * 1024
+
+// ForwardLogPath returns the file a forward's hook output is written to,
+// ~/.kprtfwd/logs/forwards/<id>.log, or "" if the home directory is unknown
+func ForwardLogPath(id string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, id)
+	return filepath.Join(home, ".kprtfwd", "logs", "forwards", name+".log")
+}
+
+// OpenForwardLog opens the forward's log for appending, rotating it to .1
+// first once it outgrew forwardLogMaxSize
+func OpenForwardLog(id string) (*os.File, error) {
+	path := ForwardLogPath(id)
+	if path == "" {
+		return nil, os.ErrNotExist
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	if fi, err := os.Stat(path); err == nil && fi.Size() > forwardLogMaxSize {
+		_ = rotateOnce(path)
+	}
+	return os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, fs.FileMode(0600))
+}
@@ -5,18 +5,55 @@ import (
 	"io/fs"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 )
 
+// Levels, from least to most verbose
+const (
+	levelOff = iota
+	levelError
+	levelDebug
+)
+
 var (
-	logFile   *os.File
-	logMutex  sync.Mutex
-	debugMode bool
+	logFile  *os.File
+	logPath  string
+	logMutex sync.Mutex
+	level    = defaultLevel()
 )
 
+// defaultLevel logs errors, and debug messages too if DEBUG is set
+func defaultLevel() int {
+	if os.Getenv("DEBUG") != "" {
+		return levelDebug
+	}
+	return levelError
+}
+
+// SetLevel sets what is logged: "debug", "error" or "off" (see the log.level
+// setting). An empty name restores the default; unknown names are ignored.
+func SetLevel(name string) {
+	l := defaultLevel()
+	switch name {
+	case "":
+	case "debug":
+		l = levelDebug
+	case "error":
+		l = levelError
+	case "off":
+		l = levelOff
+	default:
+		LogError("Ignoring unknown log level %q", name)
+		return
+	}
+	logMutex.Lock()
+	level = l
+	logMutex.Unlock()
+}
+
 func init() {
-	debugMode = os.Getenv("DEBUG") != ""
 	// Prepare private log directory
 	home, err := os.UserHomeDir()
 	if err != nil {
@@ -25,7 +62,7 @@ func init() {
 	}
 	logDir := filepath.Join(home, ".kprtfwd", "logs")
 	_ = os.MkdirAll(logDir, 0700)
-	logPath := filepath.Join(logDir, "kprtfwd.log")
+	logPath = filepath.Join(logDir, "kprtfwd.log")
 
 	// Simple size-based rotation: if file > ~5MB, rotate to .1
 	if fi, err := os.Stat(logPath); err == nil {
@@ -46,24 +83,51 @@ func rotateOnce(path string) error {
 	return os.Rename(path, path+".1")
 }
 
-func log(level, msg string) {
+func log(minLevel int, label, msg string) {
 	if logFile == nil {
 		return
 	}
 	logMutex.Lock()
 	defer logMutex.Unlock()
+	if level < minLevel {
+		return
+	}
 	timestamp := time.Now().Format("2006-01-02 15:04:05")
-	fmt.Fprintf(logFile, "%s [%s] %s\n", timestamp, level, msg)
+	fmt.Fprintf(logFile, "%s [%s] %s\n", timestamp, label, msg)
 	_ = logFile.Sync()
 }
 
 func LogDebug(format string, args ...interface{}) {
-	if !debugMode {
+	logMutex.Lock()
+	enabled := level >= levelDebug
+	logMutex.Unlock()
+	if !enabled {
 		return
 	}
-	log("DEBUG", fmt.Sprintf(format, args...))
+	log(levelDebug, "DEBUG", fmt.Sprintf(format, args...))
 }
 
 func LogError(format string, args ...interface{}) {
-	log("ERROR", fmt.Sprintf(format, args...))
+	log(levelError, "ERROR", fmt.Sprintf(format, args...))
+}
+
+// RecentErrors returns the last n error lines of the log, oldest first,
+// including those of the file rotated away most recently.
+func RecentErrors(n int) []string {
+	if logPath == "" || n <= 0 {
+		return nil
+	}
+	var errors []string
+	for _, path := range []string{logPath + ".1", logPath} {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		for _, line := range strings.Split(string(data), "\n") {
+			if strings.Contains(line, " [ERROR] ") {
+				errors = append(errors, line)
+			}
+		}
+	}
+	return errors[max(0, len(errors)-n):]
 }
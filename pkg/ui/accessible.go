@@ -0,0 +1,146 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// accessibleMode is set by the ui.accessible setting or the --accessible
+// flag. The port forwards are then listed one sentence per line instead of
+// in table columns, boxes lose their borders, and status changes are spelled
+// out in the footer, for screen readers.
+var accessibleMode bool
+
+// EnableAccessibleMode turns on accessible mode for this run, whatever the
+// ui.accessible setting says.
+func EnableAccessibleMode() {
+	accessibleMode = true
+}
+
+// AccessibleMode reports whether the TUI renders for screen readers.
+func AccessibleMode() bool {
+	return accessibleMode
+}
+
+// applyAccessible turns on accessible mode if the ui.accessible setting asks
+// for it. The setting cannot turn off what the flag turned on.
+func applyAccessible(value string) {
+	if value == "true" {
+		accessibleMode = true
+	}
+}
+
+// boxStyle returns the style of a framed box (filter input, detail pane,
+// ...) with a border in borderColor, or a plain style in accessible mode,
+// where box-drawing characters would be read out.
+func boxStyle(borderColor string) lipgloss.Style {
+	if accessibleMode {
+		return lipgloss.NewStyle()
+	}
+	return lipgloss.NewStyle().
+		Border(lipgloss.NormalBorder()).
+		BorderForeground(lipgloss.Color(borderColor)).
+		Padding(0, 1)
+}
+
+// renderAccessibleList renders the table's rows as one sentence each, the
+// selected one marked with "> ", scrolled like the table to keep the cursor
+// visible.
+func (m *Model) renderAccessibleList() string {
+	rows := len(m.portForwardsTable.Rows())
+	if rows == 0 {
+		return "No port forwards listed."
+	}
+	cursor := m.portForwardsTable.Cursor()
+	height := m.portForwardsTable.Height()
+	if height <= 0 {
+		height = rows // not laid out yet: list every row
+	}
+	start := min(max(cursor-height/2, 0), max(rows-height, 0))
+
+	lines := make([]string, 0, height+1)
+	lines = append(lines, fmt.Sprintf("%d rows, row %d selected.", rows, cursor+1))
+	states := m.portForwarder.Snapshot()
+	for i := start; i < min(start+height, rows); i++ {
+		marker := "  "
+		if i == cursor {
+			marker = "> "
+		}
+		lines = append(lines, marker+m.accessibleRowText(i, states))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// accessibleRowText describes the table row at index i in words
+func (m *Model) accessibleRowText(i int, states map[string]k8s.ForwardState) string {
+	var cfg config.PortForwardConfig
+	if m.groupingEnabled {
+		if i >= len(m.tableRows) {
+			return ""
+		}
+		row := m.tableRows[i]
+		if row.Type == RowTypeGroup {
+			state := m.groupStates[row.GroupName]
+			expanded := "collapsed"
+			if state.Expanded {
+				expanded = "expanded"
+			}
+			name := strings.TrimPrefix(row.GroupName, VirtualGroupMarker)
+			return fmt.Sprintf("Group %s, %s, %d total, %d active.", name, expanded, state.Count, state.Active)
+		}
+		c, err := m.configStore.GetWithError(row.ConfigIndex)
+		if err != nil {
+			return ""
+		}
+		cfg = c
+	} else {
+		listed := m.listedConfigs()
+		if i >= len(listed) {
+			return ""
+		}
+		cfg = listed[i]
+	}
+
+	state := states[cfg.ID]
+	text := fmt.Sprintf("%s in namespace %s, context %s, local port %s to remote port %s: %s.",
+		cfg.Service, cfg.Namespace, cfg.Context,
+		formatPorts(cfg.PortLocal, cfg.Ports()), formatPorts(cfg.PortRemote, cfg.Ports()),
+		m.statusDescription(cfg.ID, m.statusOf(cfg.ID, state), state))
+	if m.showLatency {
+		text += " Latency " + m.formatLatency(cfg.ID, state) + "."
+	}
+	if m.showIDs {
+		text += " ID " + cfg.ID + "."
+	}
+	return text
+}
+
+// announceStatusChanges puts a sentence for every forward whose status
+// changed since the last call in the footer, e.g. "db in namespace data is
+// now Failed: connection refused". Only done in accessible mode, where the
+// table's colors are not seen; the first call just takes note.
+func (m *Model) announceStatusChanges() {
+	if !accessibleMode || m.configStore == nil {
+		return
+	}
+	states := m.portForwarder.Snapshot()
+	current := make(map[string]string)
+	var changes []string
+	for _, cfg := range m.configStore.GetAll() {
+		state := states[cfg.ID]
+		status := m.statusDescription(cfg.ID, m.statusOf(cfg.ID, state), state)
+		current[cfg.ID] = status
+		if previous, ok := m.announcedStatus[cfg.ID]; ok && previous != status {
+			changes = append(changes, fmt.Sprintf("%s in namespace %s is now %s", cfg.Service, cfg.Namespace, status))
+		}
+	}
+	m.announcedStatus = current
+	if len(changes) > 0 {
+		m.statusMsg = strings.Join(changes, ". ") + "."
+	}
+}
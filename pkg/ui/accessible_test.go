@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestAccessibleModeListsForwardsAsSentences(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no kubectl: every start fails
+	accessibleMode = true
+	t.Cleanup(func() { accessibleMode = false })
+
+	cfg := config.PortForwardConfig{ID: "dev.ns.api", Context: "dev", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	m, pf := newTestModel(t, cfg)
+	m.groupingEnabled = true
+	m.applyColumnLayout()
+	m.refreshTable()
+
+	// Before the first window size the table has no height; every row is listed
+	if view := m.viewPortForwards(); !strings.Contains(view, "  api in namespace ns") {
+		t.Errorf("an unsized table should list every row:\n%s", view)
+	}
+	m.resizePortForwards()
+
+	view := m.viewPortForwards()
+	for _, want := range []string{
+		"2 rows, row 1 selected.",
+		"> Group dev, expanded, 1 total, 0 active.",
+		"  api in namespace ns, context dev, local port 8080 to remote port 80: Stopped.",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("accessible view lacks %q:\n%s", want, view)
+		}
+	}
+	if strings.ContainsAny(view, "─│┌┐└┘") {
+		t.Errorf("accessible view must not draw boxes:\n%s", view)
+	}
+
+	// The first pass takes note, a change is announced in the footer
+	m.announceStatusChanges()
+	if m.statusMsg != "" {
+		t.Fatalf("nothing changed yet, announced %q", m.statusMsg)
+	}
+	if err := pf.Start(cfg); err == nil {
+		t.Fatal("start without kubectl should fail")
+	}
+	m.announceStatusChanges()
+	if !strings.HasPrefix(m.statusMsg, "api in namespace ns is now Failed: ") {
+		t.Errorf("announcement = %q", m.statusMsg)
+	}
+}
@@ -0,0 +1,381 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// rowAction is one entry of the action menu. key, if set, is the main-view
+// key the entry stands for and is replayed there, so the entry behaves
+// exactly like the key (stop confirmations included); otherwise run is
+// called with the forward the menu was opened on.
+type rowAction struct {
+	label string
+	key   string
+	run   func(m *Model, cfg config.PortForwardConfig)
+}
+
+// enterActionMenu opens the action menu on the selected row, or the group
+// actions on a group header
+func (m *Model) enterActionMenu() (tea.Model, tea.Cmd) {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	if m.isGroupHeaderSelected() {
+		groupName := m.getSelectedGroupName()
+		m.actionMenuID = ""
+		m.openActionMenu(strings.TrimPrefix(groupName, VirtualGroupMarker), m.groupActions(groupName))
+		return m, nil
+	}
+	selectedIdx, err := m.getConfigIndexFromTableRow()
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("No actions available: %v", err)
+		return m, nil
+	}
+	cfg, err := m.configStore.GetWithError(selectedIdx)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("No actions available: %v", err)
+		return m, nil
+	}
+	m.actionMenuID = cfg.ID
+	m.openActionMenu(cfg.ID, m.rowActions(cfg))
+	return m, nil
+}
+
+// openActionMenu shows items under title with the cursor on the first one
+func (m *Model) openActionMenu(title string, items []rowAction) {
+	m.uiState = StateActionMenu
+	m.actionMenuTitle = title
+	m.actionMenuItems = items
+	m.actionMenuCursor = 0
+	m.portForwardsTable.SetHeight(m.portForwardsTableHeight())
+}
+
+// closeActionMenu returns to the main view
+func (m *Model) closeActionMenu() {
+	m.uiState = StatePortForwards
+	m.actionMenuItems = nil
+	m.portForwardsTable.SetHeight(m.portForwardsTableHeight())
+}
+
+// rowActions lists what can be done with cfg in its current state
+func (m *Model) rowActions(cfg config.PortForwardConfig) []rowAction {
+	var actions []rowAction
+	if m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID) {
+		actions = append(actions, rowAction{label: "Stop", key: " "})
+	} else {
+		actions = append(actions, rowAction{label: "Start", key: " "})
+	}
+	if status := m.statusFor(cfg.ID); status != StatusStopped && status != StatusSnoozed {
+		actions = append(actions, rowAction{label: "Restart", run: (*Model).restartForward})
+	}
+	if m.portForwarder.IsRunning(cfg.ID) {
+		actions = append(actions, rowAction{label: "Snooze...", run: func(m *Model, cfg config.PortForwardConfig) {
+			m.openActionMenu("Snooze "+cfg.ID+" for", snoozeActions())
+		}})
+	}
+	actions = append(actions, rowAction{label: "Run for...", run: func(m *Model, cfg config.PortForwardConfig) {
+		m.openActionMenu("Run "+cfg.ID+" for", expiryActions())
+	}})
+	if _, limited := m.expiresAt[cfg.ID]; limited {
+		actions = append(actions, rowAction{label: "Remove time limit", run: (*Model).clearExpiry})
+	}
+	actions = append(actions, rowAction{label: "Edit local port", key: "e"})
+	actions = append(actions, rowAction{label: "Edit kubectl arguments", run: (*Model).startArgsEdit})
+	if cfg.TargetKind == config.TargetKindService {
+		actions = append(actions, rowAction{label: "Edit pod selector", run: (*Model).startPodEdit})
+		if cfg.PodSelector != "" {
+			actions = append(actions, rowAction{label: "Pin to another pod...", key: "p"})
+		}
+	}
+	actions = append(actions, rowAction{label: "Edit tags", run: (*Model).startTagsEdit})
+	actions = append(actions, rowAction{label: "Edit open command", run: (*Model).startOpenEdit})
+	actions = append(actions, rowAction{label: "Edit start hook", run: func(m *Model, cfg config.PortForwardConfig) {
+		m.startHookEdit(cfg, config.HookEventStart)
+	}})
+	actions = append(actions, rowAction{label: "Edit stop hook", run: func(m *Model, cfg config.PortForwardConfig) {
+		m.startHookEdit(cfg, config.HookEventStop)
+	}})
+	actions = append(actions, rowAction{label: "Edit health check", run: (*Model).startHealthEdit})
+	if m.portForwarder.IsRunning(cfg.ID) {
+		if cfg.OpenCommand != "" {
+			actions = append(actions, rowAction{label: "Run open command", key: "o"})
+		} else {
+			actions = append(actions, rowAction{label: "Open in browser", key: "o"})
+		}
+	}
+	if m.portForwarder.State(cfg.ID).Proxied && !m.portForwarder.Capturing(cfg.ID) {
+		actions = append(actions, rowAction{label: "Capture traffic...", run: func(m *Model, cfg config.PortForwardConfig) {
+			m.openActionMenu("Capture "+cfg.ID+" to a pcap file for", captureActions())
+		}})
+	}
+	actions = append(actions, rowAction{label: "Copy URL", run: (*Model).copyForwardURL})
+	actions = append(actions, rowAction{label: "Copy kubectl command", run: (*Model).copyKubectlCommand})
+	actions = append(actions, rowAction{label: "Open shell in pod", key: "x"})
+	actions = append(actions, rowAction{label: "Follow pod logs", key: "l"})
+	actions = append(actions, rowAction{label: "Describe", key: "d"})
+	if len(m.projectsWithout(cfg.ID)) > 0 {
+		actions = append(actions, rowAction{label: "Add to project...", run: func(m *Model, cfg config.PortForwardConfig) {
+			m.openActionMenu("Add "+cfg.ID+" to project", m.projectActions(cfg))
+		}})
+	}
+	actions = append(actions, rowAction{label: "Delete...", run: (*Model).confirmDelete})
+	return actions
+}
+
+// confirmDelete asks whether to delete cfg, saying what else goes with it:
+// the running forward and its place in projects. Cancel comes first, so a
+// stray Enter keeps it.
+func (m *Model) confirmDelete(cfg config.PortForwardConfig) {
+	title := "Delete " + cfg.ID + "?"
+	if m.isForwardActive(cfg.ID) {
+		title += " It is stopped first."
+	}
+	var in []string
+	for _, project := range m.configStore.GetAllProjects() {
+		if slices.Contains(project.Forwards, cfg.ID) {
+			in = append(in, project.Name)
+		}
+	}
+	if len(in) > 0 {
+		title += " It leaves project " + strings.Join(in, ", ") + "."
+	}
+	m.actionMenuID = cfg.ID
+	m.openActionMenu(title, []rowAction{
+		{label: "Cancel", run: func(*Model, config.PortForwardConfig) {}},
+		{label: "Delete", run: (*Model).deleteForward},
+	})
+}
+
+// groupActions lists what can be done with the group groupName. Collapsing
+// comes first, so Enter twice still folds the group as it used to.
+func (m *Model) groupActions(groupName string) []rowAction {
+	toggle := "Collapse"
+	if state, exists := m.groupStates[groupName]; exists && !state.Expanded {
+		toggle = "Expand"
+	}
+	actions := []rowAction{{label: toggle, key: " "}}
+	members := m.groupConfigs(groupName)
+	if active := m.groupRunning(members); len(members) > 0 && active == len(members) {
+		actions = append(actions, rowAction{label: fmt.Sprintf("Stop all (%d)", active), key: "s"})
+	} else if len(members) > 0 {
+		actions = append(actions, rowAction{label: fmt.Sprintf("Start stopped (%d)", len(members)-active), key: "s"})
+	}
+	running := 0
+	for _, cfg := range members {
+		if m.portForwarder.IsRunning(cfg.ID) {
+			running++
+		}
+	}
+	if running > 0 {
+		name := strings.TrimPrefix(groupName, VirtualGroupMarker)
+		actions = append(actions, rowAction{label: fmt.Sprintf("Restart running (%d)", running), run: func(m *Model, _ config.PortForwardConfig) {
+			m.restartRunning(name, members)
+		}})
+	}
+	return actions
+}
+
+// groupConfigs returns the listed forwards under the group header groupName
+func (m *Model) groupConfigs(groupName string) []config.PortForwardConfig {
+	var custom *config.CustomGroup
+	for i := range m.customGroups {
+		if VirtualGroupMarker+m.customGroups[i].Name == groupName {
+			custom = &m.customGroups[i]
+		}
+	}
+	var members []config.PortForwardConfig
+	for _, cfg := range m.listedConfigs() {
+		switch {
+		case custom != nil:
+			if custom.Expr.Match(cfg) {
+				members = append(members, cfg)
+			}
+		case cfg.Context == groupName, cfg.Context == "" && groupName == "(no context)":
+			members = append(members, cfg)
+		}
+	}
+	return members
+}
+
+// projectActions lists one entry per project cfg could be added to
+func (m *Model) projectActions(cfg config.PortForwardConfig) []rowAction {
+	var actions []rowAction
+	for _, project := range m.projectsWithout(cfg.ID) {
+		actions = append(actions, rowAction{label: project.Name, run: func(m *Model, cfg config.PortForwardConfig) {
+			m.addForwardToProject(project, cfg)
+		}})
+	}
+	return actions
+}
+
+// projectsWithout returns the projects that do not list the forward id
+func (m *Model) projectsWithout(id string) []config.Project {
+	var projects []config.Project
+	for _, project := range m.configStore.GetAllProjects() {
+		if !slices.Contains(project.Forwards, id) {
+			projects = append(projects, project)
+		}
+	}
+	return projects
+}
+
+// updateActionMenu handles keys in the action menu
+func (m *Model) updateActionMenu(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.closeActionMenu()
+		return m, nil
+	case "up", "k":
+		m.actionMenuCursor = max(m.actionMenuCursor-1, 0)
+		return m, nil
+	case "down", "j":
+		m.actionMenuCursor = min(m.actionMenuCursor+1, max(len(m.actionMenuItems)-1, 0))
+		return m, nil
+	case "enter":
+		if m.actionMenuCursor >= len(m.actionMenuItems) {
+			return m, nil
+		}
+		return m.runRowAction(m.actionMenuItems[m.actionMenuCursor])
+	}
+	return m, nil
+}
+
+// runRowAction runs action on the forward the menu was opened on. Entries
+// that lead to another menu (projects, delete confirmation) reopen it.
+func (m *Model) runRowAction(action rowAction) (tea.Model, tea.Cmd) {
+	m.closeActionMenu()
+	if m.actionMenuID == "" {
+		// A menu that is not about a forward, like the template picker or
+		// the group actions
+		if action.key != "" {
+			return m.replayKey(action.key)
+		}
+		action.run(m, config.PortForwardConfig{})
+		return m, nil
+	}
+	cfg, ok := m.configStore.GetConfigByID(m.actionMenuID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.actionMenuID)
+		return m, nil
+	}
+	if action.key != "" {
+		return m.replayKey(action.key)
+	}
+	action.run(m, cfg)
+	return m, nil
+}
+
+// replayKey handles key as if it had been pressed in the main view
+func (m *Model) replayKey(key string) (tea.Model, tea.Cmd) {
+	msg := tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(key)}
+	if key == " " {
+		msg = tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(key)}
+	}
+	return m.updatePortForwards(msg)
+}
+
+// restartForward stops and starts cfg again
+func (m *Model) restartForward(cfg config.PortForwardConfig) {
+	if err := m.portForwarder.Restart(cfg); err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot restart %s: %s", cfg.Service, friendlyError(err))
+	} else {
+		m.statusMsg = fmt.Sprintf("Restarted %s", cfg.Service)
+	}
+	m.refreshTable()
+}
+
+// copyForwardURL puts the forward's local URL on the clipboard
+func (m *Model) copyForwardURL(cfg config.PortForwardConfig) {
+	url := cfg.LocalURL()
+	if err := copyToClipboard(url); err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot copy %s: %v", url, err)
+	} else {
+		m.statusMsg = fmt.Sprintf("Copied %s to the clipboard", url)
+	}
+}
+
+// copyKubectlCommand copies the kubectl port-forward command equivalent to
+// cfg, to reproduce it by hand or share it with someone not using kprtfwd
+func (m *Model) copyKubectlCommand(cfg config.PortForwardConfig) {
+	command := k8s.KubectlCommand(cfg, m.portForwarder.State(cfg.ID))
+	if err := copyToClipboard(command); err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot copy the kubectl command: %v", err)
+		return
+	}
+	m.statusMsg = "Copied: " + command
+	if cfg.Lazy || cfg.TLSMode != config.TLSModeNone {
+		m.statusMsg += " (the tunnel only: lazy start and TLS are done by kprtfwd)"
+	}
+}
+
+// addForwardToProject adds cfg to project, keeping it active if it was
+func (m *Model) addForwardToProject(project config.Project, cfg config.PortForwardConfig) {
+	wasActive := m.configStore.GetActiveProjectName() == project.Name
+	forwards := append(slices.Clone(project.Forwards), cfg.ID)
+
+	// Delete and recreate project (since we don't have an update method)
+	if err := m.configStore.DeleteProject(project.Name); err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to add %s to %s: %v", cfg.Service, project.Name, err)
+		return
+	}
+	if err := m.configStore.CreateProject(project.Name, forwards); err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to add %s to %s: %v", cfg.Service, project.Name, err)
+		return
+	}
+	if wasActive {
+		if err := m.configStore.SetActiveProject(project.Name); err != nil {
+			m.errorMsg = fmt.Sprintf("Added %s to %s but could not reactivate it: %v", cfg.Service, project.Name, err)
+			return
+		}
+	}
+	m.statusMsg = fmt.Sprintf("Added %s to project %s", cfg.Service, project.Name)
+}
+
+// deleteForward stops cfg (through the store's delete hook) and deletes it
+func (m *Model) deleteForward(cfg config.PortForwardConfig) {
+	sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+	if !ok {
+		m.errorMsg = "Delete not supported with current config store"
+		return
+	}
+	if err := sqliteStore.DeletePortForward(cfg.ID); err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot delete %s: %v", cfg.ID, err)
+		return
+	}
+	m.refreshTable()
+	m.statusMsg = fmt.Sprintf("Deleted %s", cfg.ID)
+}
+
+// actionMenuHeight is the number of lines the action menu takes, border
+// included
+func (m *Model) actionMenuHeight() int {
+	return len(m.actionMenuItems) + 4 // title, help line and border
+}
+
+// renderActionMenu renders the action menu shown below the table
+func (m *Model) renderActionMenu() string {
+	style := boxStyle(ColorTitle)
+	titleStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorTitle)).Bold(true)
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	selected := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorSelectedFg)).
+		Background(lipgloss.Color(ColorSelectedBg)).Reverse(monoTheme)
+
+	lines := []string{titleStyle.Render(m.actionMenuTitle)}
+	for i, action := range m.actionMenuItems {
+		if i == m.actionMenuCursor {
+			lines = append(lines, "▶ "+selected.Render(action.label))
+		} else {
+			lines = append(lines, "  "+action.label)
+		}
+	}
+	lines = append(lines, helpStyle.Render(ActionActionMenu))
+	return style.Render(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,194 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// selectAction moves the action menu cursor to label and runs it
+func selectAction(t *testing.T, m *Model, label string) {
+	t.Helper()
+	idx := slices.Index(labels(m.actionMenuItems), label)
+	if idx < 0 {
+		t.Fatalf("no %q in the action menu %v", label, labels(m.actionMenuItems))
+	}
+	for range idx {
+		m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+}
+
+// labels returns the labels of actions
+func labels(actions []rowAction) []string {
+	names := make([]string, len(actions))
+	for i, action := range actions {
+		names[i] = action.label
+	}
+	return names
+}
+
+func TestActionMenu(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no kubectl
+
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080}
+	m, _ := newTestModel(t, cfg)
+	store := m.configStore
+	if err := store.CreateProject("team", nil); err != nil {
+		t.Fatal(err)
+	}
+	m.groupingEnabled = true
+	m.applyColumnLayout()
+
+	// Enter on a group header opens the group actions, collapsing first;
+	// with nothing running there is nothing to restart
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got := labels(m.actionMenuItems); m.uiState != StateActionMenu || !slices.Equal(got, []string{"Collapse", "Start stopped (1)"}) {
+		t.Fatalf("group actions = %v, state %d", got, m.uiState)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.uiState != StatePortForwards || m.groupStates["ctx"].Expanded {
+		t.Fatalf("Enter twice on a group header should collapse it, state %d", m.uiState)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Expand")
+	m.portForwardsTable.SetCursor(1)
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.uiState != StateActionMenu {
+		t.Fatal("Enter on a forward should open the action menu")
+	}
+	want := []string{"Start", "Run for...", "Edit local port", "Edit kubectl arguments", "Edit pod selector", "Edit tags", "Edit open command", "Edit start hook", "Edit stop hook", "Edit health check", "Copy URL", "Copy kubectl command", "Open shell in pod", "Follow pod logs", "Describe", "Add to project...", "Delete..."}
+	if got := labels(m.actionMenuItems); !slices.Equal(got, want) {
+		t.Fatalf("actions for a stopped forward = %v, want %v", got, want)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.uiState != StatePortForwards {
+		t.Fatal("Esc should close the action menu")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Edit kubectl arguments")
+	if !m.argsEditMode {
+		t.Fatal("the action should open the kubectl arguments input")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("--namespace=x")})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(cfg.ID); got.KubectlArgs != "" || m.errorMsg == "" {
+		t.Fatalf("reserved flag must be rejected, got args %q", got.KubectlArgs)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Edit kubectl arguments")
+	m.argsEditInput.SetValue(" --pod-running-timeout=2m   --v=6 ")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(cfg.ID); got.KubectlArgs != "--pod-running-timeout=2m --v=6" {
+		t.Fatalf("kubectl arguments = %q (error %q)", got.KubectlArgs, m.errorMsg)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Edit stop hook")
+	if !m.hookEditMode || m.hookEditEvent != config.HookEventStop {
+		t.Fatal("the action should open the stop hook input")
+	}
+	m.hookEditInput.SetValue(" ./cleanup.sh $KPRTFWD_ID ")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(cfg.ID); got.StopHook != "./cleanup.sh $KPRTFWD_ID" || got.StartHook != "" {
+		t.Fatalf("hooks = %q, %q (error %q)", got.StartHook, got.StopHook, m.errorMsg)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Edit health check")
+	m.healthEditInput.SetValue("healthz")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(cfg.ID); got.HealthPath != "" || m.errorMsg == "" {
+		t.Fatalf("a path without a leading / must be rejected, got %q", got.HealthPath)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Edit health check")
+	m.healthEditInput.SetValue(" /healthz?full=1 ")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(cfg.ID); got.HealthPath != "/healthz?full=1" {
+		t.Fatalf("health check path = %q (error %q)", got.HealthPath, m.errorMsg)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Add to project...")
+	selectAction(t, m, "team")
+	if projects := store.GetAllProjects(); len(projects) != 1 || !slices.Equal(projects[0].Forwards, []string{cfg.ID}) {
+		t.Fatalf("projects after adding = %+v", projects)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if slices.Contains(labels(m.actionMenuItems), "Add to project...") {
+		t.Fatal("no project is left to add the forward to")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m.Update(tea.KeyMsg{Type: tea.KeyBackspace})
+	if m.uiState != StatePortForwards {
+		t.Fatal("Backspace must not offer to delete the forward")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyDelete})
+	if m.uiState != StateActionMenu || !strings.Contains(m.actionMenuTitle, "leaves project team") {
+		t.Fatalf("Delete should ask for confirmation naming the project, got %q in state %d", m.actionMenuTitle, m.uiState)
+	}
+	selectAction(t, m, "Cancel")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Delete...")
+	selectAction(t, m, "Cancel")
+	if _, ok := store.GetConfigByID(cfg.ID); !ok || m.uiState != StatePortForwards {
+		t.Fatal("cancelling must keep the forward and close the menu")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Delete...")
+	selectAction(t, m, "Delete")
+	if _, ok := store.GetConfigByID(cfg.ID); ok {
+		t.Fatal("the forward should be deleted")
+	}
+	if m.statusMsg != "Deleted ctx.ns.web" {
+		t.Fatalf("unexpected status: %q (error %q)", m.statusMsg, m.errorMsg)
+	}
+}
+
+func TestExposedForwardNeedsConfirmation(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no kubectl: a start attempt fails
+
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080,
+		KubectlArgs: "--address=0.0.0.0"}
+	m, pf := newTestModel(t, cfg)
+	store := m.configStore
+	space := tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")}
+
+	m.Update(space)
+	if !strings.Contains(m.errorMsg, "Security warning") || pf.IsError(cfg.ID) {
+		t.Fatalf("the first Space should only warn, got %q", m.errorMsg)
+	}
+	m.Update(space)
+	if !pf.IsError(cfg.ID) {
+		t.Fatalf("the second Space should start the forward, got %q", m.errorMsg)
+	}
+
+	// Any other key in between cancels the confirmation
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(space)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("i")})
+	m.Update(space)
+	if pf.IsError(cfg.ID) || !strings.Contains(m.errorMsg, "Security warning") {
+		t.Fatalf("Space after another key should warn again, got %q", m.errorMsg)
+	}
+
+	// With the setting off there is nothing to confirm
+	if err := store.SetSetting(config.SettingConfirmExposed, "false"); err != nil {
+		t.Fatal(err)
+	}
+	m.confirmStartID = ""
+	m.Update(space)
+	if !pf.IsError(cfg.ID) {
+		t.Fatalf("with security.confirm_exposed off Space should start at once, got %q", m.errorMsg)
+	}
+}
@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// Fields of the add forward form, in the order Tab walks them
+const (
+	formContext = iota
+	formNamespace
+	formService
+	formRemotePort
+	formLocalPort
+	formID
+	formFields
+)
+
+// formLabels are the labels of the form's fields
+var formLabels = [formFields]string{"Context", "Namespace", "Service", "Remote port", "Local port", "ID"}
+
+// addForwardForm is the screen a forward is typed into field by field (a),
+// for clusters and services discovery cannot list
+type addForwardForm struct {
+	m      *Model
+	inputs [formFields]textinput.Model
+	focus  int
+	err    string // why the last Enter did not add the forward; "" otherwise
+}
+
+// enterAddForm opens the add forward form, with the current context filled in
+func (m *Model) enterAddForm() {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	f := addForwardForm{m: m}
+	placeholders := [formFields]string{
+		"kubeconfig context",
+		"default",
+		"api, or pod/NAME, deploy/NAME, sts/NAME",
+		"8080, or 8080-8082",
+		"from the ports.local setting",
+		"from context, namespace and service",
+	}
+	for i := range f.inputs {
+		input := textinput.New()
+		input.Placeholder = placeholders[i]
+		input.CharLimit = 253
+		input.Width = 40
+		f.inputs[i] = input
+	}
+	f.inputs[formContext].SetValue(m.currentContext)
+	f.focusField(formContext)
+	if m.currentContext != "" {
+		f.focusField(formNamespace)
+	}
+	m.addForm = f
+	m.uiState = StateAddForward
+}
+
+// focusField moves the cursor to field i
+func (f *addForwardForm) focusField(i int) {
+	f.inputs[f.focus].Blur()
+	f.focus = (i + formFields) % formFields
+	f.inputs[f.focus].Focus()
+}
+
+// Update handles keys in the add forward form
+func (f *addForwardForm) Update(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m := f.m
+	switch msg.String() {
+	case "esc":
+		m.uiState = StatePortForwards
+		return m, nil
+	case "tab", "down":
+		f.focusField(f.focus + 1)
+		return m, nil
+	case "shift+tab", "up":
+		f.focusField(f.focus - 1)
+		return m, nil
+	case "enter":
+		cfg, err := f.forward()
+		if err != nil {
+			f.err = err.Error()
+			return m, nil
+		}
+		m.uiState = StatePortForwards
+		m.storeNewForward(cfg)
+		return m, nil
+	}
+	var cmd tea.Cmd
+	f.inputs[f.focus], cmd = f.inputs[f.focus].Update(msg)
+	return m, cmd
+}
+
+// Resize has nothing to lay out: the form is as wide as its inputs
+func (f *addForwardForm) Resize() {}
+
+// value returns the trimmed text of field i
+func (f *addForwardForm) value(i int) string {
+	return strings.TrimSpace(f.inputs[i].Value())
+}
+
+// forward builds the forward the form describes, checked as the store's
+// other writers check theirs. A local port or ID left empty is chosen as for
+// a pasted command; one typed in must not be taken by another forward.
+func (f *addForwardForm) forward() (config.PortForwardConfig, error) {
+	cfg := config.PortForwardConfig{
+		Context:   f.value(formContext),
+		Namespace: f.value(formNamespace),
+		ID:        f.value(formID),
+		Listen:    config.ListenIPv4,
+	}
+	if cfg.Context == "" {
+		return cfg, fmt.Errorf("context must not be empty")
+	}
+	if cfg.Namespace == "" {
+		cfg.Namespace = "default"
+	}
+	var err error
+	if cfg.TargetKind, cfg.Service, err = k8s.ParseTarget(f.value(formService), config.TargetKindService); err != nil {
+		return cfg, err
+	}
+	remote := f.value(formRemotePort)
+	if remote == "" {
+		return cfg, fmt.Errorf("remote port must not be empty")
+	}
+	if cfg.PortRemote, cfg.PortCount, err = parseLocalPorts(remote, 1); err != nil {
+		return cfg, fmt.Errorf("remote port: %w", err)
+	}
+	if cfg.PortCount == 1 {
+		cfg.PortCount = 0
+	}
+	checks := []error{
+		config.ValidateContextName(cfg.Context),
+		config.ValidateKubernetesName("namespace", cfg.Namespace),
+		config.ValidateKubernetesName(cfg.KindName(), cfg.Service),
+		config.ValidatePortRange("remote port", cfg.PortRemote, cfg.PortCount),
+	}
+	for _, err := range checks {
+		if err != nil {
+			return cfg, err
+		}
+	}
+
+	all := f.m.configStore.GetAll()
+	if i := slices.IndexFunc(all, func(c config.PortForwardConfig) bool {
+		return c.Context == cfg.Context && c.Namespace == cfg.Namespace && c.Target() == cfg.Target() && c.PortRemote == cfg.PortRemote
+	}); i >= 0 {
+		return cfg, fmt.Errorf("%s/%s:%d is already forwarded by %s", cfg.Namespace, cfg.Service, cfg.PortRemote, all[i].ID)
+	}
+	if local := f.value(formLocalPort); local != "" {
+		if cfg.PortLocal, err = strconv.Atoi(local); err != nil {
+			return cfg, fmt.Errorf("local port %q is not a number", local)
+		}
+		if err := config.ValidatePortRange("local port", cfg.PortLocal, cfg.PortCount); err != nil {
+			return cfg, err
+		}
+		if i := slices.IndexFunc(all, cfg.OverlapsLocally); i >= 0 {
+			return cfg, fmt.Errorf("local port %s is taken by %s; pick another or leave it empty", formatPorts(all[i].PortLocal, all[i].Ports()), all[i].ID)
+		}
+	} else {
+		strategy := config.LocalPortStrategy(f.m.configStore.GetSettings(), cfg.Context)
+		cfg.PortLocal = config.DefaultLocalPort(strategy, cfg.PortRemote, all)
+	}
+	if cfg.ID == "" {
+		cfg.ID = uniqueForwardID(generateServicePortID(cfg.Context, discovery.ServiceInfo{Name: cfg.Service, Namespace: cfg.Namespace},
+			discovery.ServicePort{Port: int32(cfg.PortRemote)}), all)
+	} else {
+		if strings.ContainsAny(cfg.ID, " \t\n") {
+			return cfg, fmt.Errorf("ID %q contains whitespace", cfg.ID)
+		}
+		if slices.ContainsFunc(all, func(c config.PortForwardConfig) bool { return c.ID == cfg.ID }) {
+			return cfg, fmt.Errorf("ID %s is taken by another forward", cfg.ID)
+		}
+	}
+	return cfg, nil
+}
+
+// View renders the add forward form
+func (f *addForwardForm) View() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	b.WriteString(titleStyle.Render("➕ Add Port Forward"))
+	b.WriteString("\n\n")
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	b.WriteString(helpStyle.Render("For services discovery cannot list. Empty fields take the value shown."))
+	b.WriteString("\n\n")
+
+	labelStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("6")).Width(13) // Cyan
+	for i, input := range f.inputs {
+		b.WriteString(labelStyle.Render(formLabels[i] + ":"))
+		b.WriteString(input.View())
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	if f.err != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorError)).
+			Bold(true)
+		b.WriteString(errorStyle.Render("Error: " + f.err))
+	}
+	b.WriteString("\n")
+
+	b.WriteString(helpStyle.Render(ActionAddForm))
+	b.WriteString("\n")
+
+	return b.String()
+}
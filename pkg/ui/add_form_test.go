@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAddForwardForm(t *testing.T) {
+	m, _ := newTestModel(t, config.PortForwardConfig{ID: "db", Context: "dev", Namespace: "shop", Service: "db", PortRemote: 5432, PortLocal: 5432})
+	store := m.configStore
+	m.groupingEnabled, m.currentContext = true, "dev"
+	m.applyColumnLayout()
+
+	submit := func(fields map[int]string) {
+		t.Helper()
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("a")})
+		if m.uiState != StateAddForward {
+			t.Fatal("a should open the add form")
+		}
+		if got := m.addForm.value(formContext); got != "dev" {
+			t.Errorf("context = %q, want the current context", got)
+		}
+		for i, value := range fields {
+			m.addForm.inputs[i].SetValue(value)
+		}
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	submit(map[int]string{formNamespace: "shop", formService: "legacy", formRemotePort: "5432", formLocalPort: "5432"})
+	if m.uiState != StateAddForward || !strings.Contains(m.addForm.err, "5432 is taken by db") {
+		t.Errorf("a taken local port must keep the form open with an error, got %q", m.addForm.err)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	submit(map[int]string{formNamespace: "shop", formService: "legacy", formRemotePort: "5432", formLocalPort: "15432", formID: "db"})
+	if !strings.Contains(m.addForm.err, "ID db is taken") {
+		t.Errorf("a taken ID must be refused, got %q", m.addForm.err)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	submit(map[int]string{formNamespace: "shop", formService: "deploy/legacy", formRemotePort: "5432", formLocalPort: "15432", formID: "legacy-db"})
+	if m.uiState != StatePortForwards {
+		t.Fatalf("the form should close once the forward is added (error %q)", m.addForm.err)
+	}
+	cfg, ok := store.GetConfigByID("legacy-db")
+	if !ok || cfg.TargetKind != config.TargetKindDeployment || cfg.Service != "legacy" || cfg.PortLocal != 15432 || cfg.Context != "dev" {
+		t.Errorf("added %+v (found %v)", cfg, ok)
+	}
+}
@@ -0,0 +1,120 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+)
+
+// startAddForward opens the input a kubectl port-forward command or service
+// URL is pasted into
+func (m *Model) startAddForward() {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	m.addMode = true
+	m.addTemplate = ""
+	m.addInput.SetValue("")
+	m.addInput.Focus()
+	m.portForwardsTable.Blur()
+}
+
+// openTemplatePicker swaps the add input for a menu of the stack templates;
+// picking one asks for the namespace to apply it to
+func (m *Model) openTemplatePicker() {
+	m.addMode = false
+	m.addInput.Blur()
+	items := make([]rowAction, 0, len(config.StackTemplates))
+	for _, tmpl := range config.StackTemplates {
+		items = append(items, rowAction{label: tmpl.Summary(), run: func(m *Model, _ config.PortForwardConfig) {
+			m.startAddForward()
+			m.addTemplate = tmpl.Name
+		}})
+	}
+	m.actionMenuID = ""
+	m.openActionMenu("Add from template", items)
+}
+
+// commitAddForward adds the forward the pasted text, or the picked template,
+// describes. What the text leaves open is filled in as discovery would: the
+// current context, a local port from the ports.local strategy and an ID from
+// the service's name.
+func (m *Model) commitAddForward() {
+	m.addMode = false
+	m.addInput.Blur()
+	m.portForwardsTable.Focus()
+	tmplName := m.addTemplate
+	m.addTemplate = ""
+
+	text := strings.TrimSpace(m.addInput.Value())
+	if text == "" {
+		return
+	}
+	all := m.configStore.GetAll()
+	var cfg config.PortForwardConfig
+	var err error
+	if tmpl, ok := config.FindStackTemplate(tmplName); ok {
+		if m.currentContext == "" {
+			m.errorMsg = "Cannot add forward: no current kubectl context to apply the template in"
+			return
+		}
+		namespace, service, _ := strings.Cut(text, "/")
+		cfg, err = tmpl.Forward(m.currentContext, namespace, service, all)
+	} else {
+		cfg, err = k8s.ParseForward(text)
+	}
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot add forward: %v", err)
+		return
+	}
+	if cfg.Context == "" {
+		cfg.Context = m.currentContext
+	}
+
+	if i := slices.IndexFunc(all, func(c config.PortForwardConfig) bool {
+		return c.Context == cfg.Context && c.Namespace == cfg.Namespace && c.Service == cfg.Service && c.PortRemote == cfg.PortRemote
+	}); i >= 0 {
+		m.errorMsg = fmt.Sprintf("%s/%s:%d is already forwarded by %s", cfg.Namespace, cfg.Service, cfg.PortRemote, all[i].ID)
+		return
+	}
+	if cfg.PortLocal == 0 {
+		strategy := config.LocalPortStrategy(m.configStore.GetSettings(), cfg.Context)
+		cfg.PortLocal = config.DefaultLocalPort(strategy, cfg.PortRemote, all)
+	}
+	cfg.ID = uniqueForwardID(generateServicePortID(cfg.Context, discovery.ServiceInfo{Name: cfg.Service, Namespace: cfg.Namespace},
+		discovery.ServicePort{Port: int32(cfg.PortRemote)}), all)
+	m.storeNewForward(cfg)
+}
+
+// storeNewForward adds cfg to the store and selects it, unless a project
+// is active, which cfg is not in yet
+func (m *Model) storeNewForward(cfg config.PortForwardConfig) {
+	if err := m.configStore.Add(cfg); err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot add forward: %v", err)
+		return
+	}
+	m.refreshTable()
+	m.statusMsg = fmt.Sprintf("Added %s: %s/%s %s→%s", cfg.ID, cfg.Namespace, cfg.Service,
+		formatPorts(cfg.PortLocal, cfg.Ports()), formatPorts(cfg.PortRemote, cfg.Ports()))
+	if m.configStore.GetActiveProjectName() != "" {
+		m.statusMsg += " (not in the active project)"
+		return
+	}
+	m.jumpToForward(cfg.ID)
+}
+
+// uniqueForwardID returns id, or id with the lowest numeric suffix no
+// configured forward has
+func uniqueForwardID(id string, configs []config.PortForwardConfig) string {
+	taken := func(candidate string) bool {
+		return slices.ContainsFunc(configs, func(c config.PortForwardConfig) bool { return c.ID == candidate })
+	}
+	candidate := id
+	for n := 2; taken(candidate); n++ {
+		candidate = fmt.Sprintf("%s-%d", id, n)
+	}
+	return candidate
+}
@@ -0,0 +1,85 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAddForwardFromPastedCommand(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+	m, _ := newTestModel(t)
+	store := m.configStore
+	if err := store.SetSetting(config.SettingLocalPorts, "offset:10000"); err != nil {
+		t.Fatal(err)
+	}
+	m.groupingEnabled, m.currentContext = true, "dev"
+	m.applyColumnLayout()
+
+	paste := func(text string) {
+		t.Helper()
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+		if !m.addMode {
+			t.Fatal("+ should open the add input")
+		}
+		m.addInput.SetValue(text)
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	paste("kubectl port-forward -n shop svc/api 8080:80 --context staging --pod-running-timeout=1m")
+	cfg, ok := store.GetConfigByID("staging.shop.api.api-80")
+	if !ok {
+		t.Fatalf("the pasted command should be added (error %q), have %+v", m.errorMsg, store.GetAll())
+	}
+	if cfg.PortLocal != 8080 || cfg.PortRemote != 80 || cfg.KubectlArgs != "--pod-running-timeout=1m" {
+		t.Errorf("added %+v", cfg)
+	}
+	if m.statusMsg != "Added staging.shop.api.api-80: shop/api 8080→80" {
+		t.Errorf("status = %q", m.statusMsg)
+	}
+
+	// A URL leaves the context and local port to kprtfwd
+	paste("redis.data.svc.cluster.local:6379")
+	if cfg, ok := store.GetConfigByID("dev.data.redis.redis-6379"); !ok || cfg.Context != "dev" || cfg.PortLocal != 16379 {
+		t.Errorf("URL forward = %+v (error %q)", cfg, m.errorMsg)
+	}
+
+	paste("kubectl -n shop port-forward svc/api 9000:80 --context staging")
+	if !strings.Contains(m.errorMsg, "already forwarded by staging.shop.api.api-80") || len(store.GetAll()) != 2 {
+		t.Errorf("a service port that is forwarded already must not be added twice: %q", m.errorMsg)
+	}
+	paste("kubectl port-forward pod/api-0 8081:80 -n shop")
+	if i := slices.IndexFunc(store.GetAll(), func(c config.PortForwardConfig) bool { return c.Service == "api-0" }); i < 0 {
+		t.Errorf("a pod forward should be added (error %q)", m.errorMsg)
+	} else if cfg := store.GetAll()[i]; cfg.TargetKind != config.TargetKindPod || cfg.PortLocal != 8081 {
+		t.Errorf("pod forward = %+v", cfg)
+	}
+	paste("kubectl port-forward job/migrate 8082:80")
+	if !strings.HasPrefix(m.errorMsg, "Cannot add forward: kprtfwd forwards to services, pods, deployments and statefulsets, not job/migrate") {
+		t.Errorf("error = %q", m.errorMsg)
+	}
+
+	// A stack template, picked with Tab, takes the namespace (and service)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("+")})
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	if m.uiState != StateActionMenu || len(m.actionMenuItems) != len(config.StackTemplates) {
+		t.Fatalf("Tab should open the template picker, state %d with %d items", m.uiState, len(m.actionMenuItems))
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter}) // postgres
+	if !m.addMode || m.addTemplate != "postgres" {
+		t.Fatalf("picking a template should ask for its namespace (template %q)", m.addTemplate)
+	}
+	m.addInput.SetValue("billing/billing-db")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	cfg, ok = store.GetConfigByID("dev.billing.service.billing-db-5432")
+	if !ok {
+		t.Fatalf("the template forward should be added (error %q), have %+v", m.errorMsg, store.GetAll())
+	}
+	if cfg.PortLocal != 5432 || cfg.OpenCommand != "psql -h {{host}} -p {{port}}" || cfg.Tags != "db" {
+		t.Errorf("template forward = %+v", cfg)
+	}
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestAutoCollapseInactiveContextGroups(t *testing.T) {
+	useSleepingKubectl(t)
+	dev, prod := testForward(t, "dev", "api"), testForward(t, "prod", "api")
+	m, pf := newTestModel(t, dev, prod)
+	if err := pf.Start(dev); err != nil {
+		t.Fatal(err)
+	}
+	m.groupingEnabled, m.autoCollapse = true, true
+	m.applyColumnLayout()
+
+	if !m.groupStates["dev"].Expanded {
+		t.Error("a group with a running forward should stay expanded")
+	}
+	if m.groupStates["prod"].Expanded {
+		t.Fatal("a group with only stopped forwards should be collapsed")
+	}
+
+	// Stopping the last forward does not collapse a group in use
+	if err := pf.Stop(dev.ID); err != nil {
+		t.Fatal(err)
+	}
+	m.refreshTable()
+	if !m.groupStates["dev"].Expanded {
+		t.Error("dev was used this session and should stay expanded")
+	}
+
+	// Expanding the collapsed group by hand sticks
+	for i, row := range m.tableRows {
+		if row.Type == RowTypeGroup && row.GroupName == "prod" {
+			m.portForwardsTable.SetCursor(i)
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune{' '}})
+	m.refreshTable()
+	if !m.groupStates["prod"].Expanded {
+		t.Error("a group the user expanded should stay expanded")
+	}
+}
+
+func TestAutoCollapseDisabled(t *testing.T) {
+	m, _ := newTestModel(t, config.PortForwardConfig{ID: "prod.ns.api", Context: "prod", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080})
+	m.groupingEnabled = true
+	m.applyColumnLayout()
+	if !m.groupStates["prod"].Expanded {
+		t.Error("without auto-collapse groups start expanded")
+	}
+}
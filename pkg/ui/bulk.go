@@ -0,0 +1,168 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// startListed starts every listed forward that is not running or queued (S):
+// those the filter and the active project leave in the table, collapsed
+// groups included. Exposed forwards are left to Space, which confirms them.
+func (m *Model) startListed() {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	listed := m.listedConfigs()
+	if len(listed) == 0 {
+		m.statusMsg = "No port forwards listed to start"
+		return
+	}
+	m.startAll(listed, "listed forward(s)")
+}
+
+// stopListed stops every listed forward that runs or is queued (K), and
+// ends the snoozes and time limits of the listed ones. Open connections
+// need a second K, as they need a second Space; confirmed is whether this
+// press is that second one.
+func (m *Model) stopListed(confirmed bool) {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	listed := m.listedConfigs()
+	if len(listed) == 0 {
+		m.statusMsg = "No port forwards listed to stop"
+		return
+	}
+	if !confirmed && m.askBulkStop(listed, "K") {
+		return
+	}
+	m.stopAll(listed, "listed forward(s)")
+}
+
+// toggleGroup starts the forwards of the selected group header that are not
+// running (s), or stops them all when every one already runs, as Space does
+// for one forward. The header's active count follows; confirmed is as for
+// stopListed.
+func (m *Model) toggleGroup(confirmed bool) {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	if !m.groupingEnabled || !m.isGroupHeaderSelected() {
+		m.errorMsg = "s starts or stops a whole group; select its header (g groups the table)"
+		return
+	}
+	groupName := m.getSelectedGroupName()
+	members := m.groupConfigs(groupName)
+	if len(members) == 0 {
+		m.statusMsg = "No port forwards listed in this group"
+		return
+	}
+	what := "forward(s) of " + strings.TrimPrefix(groupName, VirtualGroupMarker)
+	if m.groupRunning(members) == len(members) {
+		if !confirmed && m.askBulkStop(members, "s") {
+			return
+		}
+		m.stopAll(members, what)
+	} else {
+		m.startAll(members, what)
+	}
+}
+
+// groupRunning counts the forwards of members that run or are queued
+func (m *Model) groupRunning(members []config.PortForwardConfig) int {
+	running := 0
+	for _, cfg := range members {
+		if m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID) {
+			running++
+		}
+	}
+	return running
+}
+
+// askBulkStop asks for a second press of key before stopping cfgs when some
+// of them have open connections, and reports whether it asked
+func (m *Model) askBulkStop(cfgs []config.PortForwardConfig, key string) bool {
+	busy, open := 0, 0
+	for _, cfg := range cfgs {
+		if n := m.portForwarder.ActiveConnections(cfg.ID); n > 0 {
+			busy++
+			open += n
+		}
+	}
+	if open == 0 {
+		return false
+	}
+	m.confirmBulkStop = key
+	m.statusMsg = fmt.Sprintf("%d forward(s) have %d open connection(s); press %s again to stop (they get %s to finish)",
+		busy, open, key, m.portForwarder.DrainTimeout())
+	return true
+}
+
+// startAll starts the forwards of cfgs that are not running or queued,
+// except exposed ones, and sums up the outcome in the footer; what names
+// cfgs there, e.g. "listed forward(s)"
+func (m *Model) startAll(cfgs []config.PortForwardConfig, what string) {
+	started, already := 0, 0
+	var failed, exposed []string
+	for _, cfg := range cfgs {
+		if m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID) {
+			already++
+			continue
+		}
+		if m.exposureWarning(cfg) != "" {
+			exposed = append(exposed, cfg.ID)
+			continue
+		}
+		delete(m.snoozedUntil, cfg.ID) // starting early ends a snooze
+		if err := m.portForwarder.Start(cfg); err != nil {
+			logging.LogError("Failed to start '%s' among the %s: %v", cfg.ID, what, err)
+			failed = append(failed, cfg.ID)
+			continue
+		}
+		started++
+	}
+
+	summary := fmt.Sprintf("Started %d of %d %s", started, len(cfgs), what)
+	if already > 0 {
+		summary += fmt.Sprintf(", %d already running", already)
+	}
+	switch {
+	case len(failed) > 0:
+		m.errorMsg = fmt.Sprintf("%s; %d failed: %s", summary, len(failed), strings.Join(failed, ", "))
+	case len(exposed) > 0:
+		m.errorMsg = fmt.Sprintf("%s; not started because they listen outside loopback (start them with Space to confirm): %s",
+			summary, strings.Join(exposed, ", "))
+	default:
+		m.statusMsg = summary
+	}
+	m.refreshTable()
+}
+
+// stopAll stops the forwards of cfgs that run or are queued, ends the
+// snoozes and time limits of all of them, and sums up the outcome in the
+// footer; what names cfgs there, as for startAll
+func (m *Model) stopAll(cfgs []config.PortForwardConfig, what string) {
+	stopped := 0
+	var failed []string
+	for _, cfg := range cfgs {
+		delete(m.snoozedUntil, cfg.ID)
+		delete(m.expiresAt, cfg.ID)
+		if !m.portForwarder.IsRunning(cfg.ID) && !m.portForwarder.IsQueued(cfg.ID) {
+			continue
+		}
+		if err := m.portForwarder.Stop(cfg.ID); err != nil {
+			logging.LogError("Error stopping port-forward '%s' among the %s: %v", cfg.ID, what, err)
+			failed = append(failed, cfg.ID)
+			continue
+		}
+		stopped++
+	}
+
+	summary := fmt.Sprintf("Stopped %d of %d %s", stopped, len(cfgs), what)
+	if len(failed) > 0 {
+		m.errorMsg = fmt.Sprintf("%s; %d failed: %s", summary, len(failed), strings.Join(failed, ", "))
+	} else {
+		m.statusMsg = summary
+	}
+	m.refreshTable()
+}
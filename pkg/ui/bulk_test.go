@@ -0,0 +1,144 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newBulkTestModel returns a grouped model holding a forward for each of
+// services, "context/service", with a kubectl that just sleeps
+func newBulkTestModel(t *testing.T, services ...string) (*Model, *k8s.PortForwarder, []config.PortForwardConfig) {
+	t.Helper()
+	useSleepingKubectl(t)
+	var cfgs []config.PortForwardConfig
+	for _, name := range services {
+		context, service, _ := strings.Cut(name, "/")
+		cfgs = append(cfgs, testForward(t, context, service))
+	}
+	m, pf := newTestModel(t, cfgs...)
+	m.groupingEnabled = true
+	m.applyColumnLayout()
+	return m, pf, cfgs
+}
+
+func TestStartAndStopListedForwards(t *testing.T) {
+	m, pf, cfgs := newBulkTestModel(t, "ctx/api", "ctx/web", "ctx/db")
+
+	if err := pf.Start(cfgs[1]); err != nil {
+		t.Fatal(err)
+	}
+	m.filterInput.SetValue("api")
+	m.applyFilter()
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	if !pf.IsRunning(cfgs[0].ID) || pf.IsRunning(cfgs[2].ID) {
+		t.Fatalf("S must start the listed forward only (error %q)", m.errorMsg)
+	}
+	if m.statusMsg != "Started 1 of 1 listed forward(s)" {
+		t.Errorf("status = %q", m.statusMsg)
+	}
+
+	m.filterInput.SetValue("")
+	m.applyFilter()
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("S")})
+	if m.statusMsg != "Started 1 of 3 listed forward(s), 2 already running" {
+		t.Errorf("status = %q (error %q)", m.statusMsg, m.errorMsg)
+	}
+
+	m.filterInput.SetValue("web")
+	m.applyFilter()
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if pf.IsRunning(cfgs[1].ID) || !pf.IsRunning(cfgs[0].ID) || !pf.IsRunning(cfgs[2].ID) {
+		t.Error("K must stop the listed forward only")
+	}
+	if m.statusMsg != "Stopped 1 of 1 listed forward(s)" {
+		t.Errorf("status = %q", m.statusMsg)
+	}
+}
+
+func TestStartAndStopGroup(t *testing.T) {
+	m, pf, cfgs := newBulkTestModel(t, "dev/api", "dev/web", "prod/db")
+
+	m.portForwardsTable.SetCursor(0) // the dev header
+	if err := pf.Start(cfgs[0]); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if !pf.IsRunning(cfgs[1].ID) || pf.IsRunning(cfgs[2].ID) {
+		t.Fatalf("s must start the group's stopped forwards only (error %q)", m.errorMsg)
+	}
+	if m.statusMsg != "Started 1 of 2 forward(s) of dev, 1 already running" {
+		t.Errorf("status = %q", m.statusMsg)
+	}
+	if m.groupStates["dev"].Active != 2 {
+		t.Errorf("the header should count 2 active, got %d", m.groupStates["dev"].Active)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if pf.IsRunning(cfgs[0].ID) || pf.IsRunning(cfgs[1].ID) {
+		t.Fatal("s must stop the group once all of it runs")
+	}
+	if m.statusMsg != "Stopped 2 of 2 forward(s) of dev" || m.groupStates["dev"].Active != 0 {
+		t.Errorf("status = %q, active = %d", m.statusMsg, m.groupStates["dev"].Active)
+	}
+
+	m.portForwardsTable.SetCursor(1) // a forward
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+	if m.errorMsg == "" || pf.IsRunning(cfgs[0].ID) {
+		t.Error("s on a forward row should point to the group header")
+	}
+}
+
+func TestStopListedConfirmsOpenConnections(t *testing.T) {
+	m, pf, cfgs := newBulkTestModel(t, "ctx/api", "ctx/web")
+
+	// A lazy forward counts its client as soon as it connects; the sleeping
+	// kubectl never gets ready, so the connection stays open
+	lazy := cfgs[0]
+	lazy.Lazy = true
+	if err := pf.Start(lazy); err != nil {
+		t.Fatal(err)
+	}
+	if err := pf.Start(cfgs[1]); err != nil {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("tcp", fmt.Sprintf("127.0.0.1:%d", lazy.PortLocal))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	deadline := time.Now().Add(5 * time.Second)
+	for pf.ActiveConnections(lazy.ID) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("the client was never counted")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if !pf.IsRunning(lazy.ID) || !pf.IsRunning(cfgs[1].ID) {
+		t.Fatal("K must ask before cutting off open connections")
+	}
+	if !strings.Contains(m.statusMsg, "1 open connection(s); press K again") {
+		t.Errorf("status = %q", m.statusMsg)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if pf.IsRunning(lazy.ID) || pf.IsRunning(cfgs[1].ID) {
+		t.Fatal("a second K must stop them")
+	}
+
+	if err := pf.Start(cfgs[1]); err != nil {
+		t.Fatal(err)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("K")})
+	if pf.IsRunning(cfgs[1].ID) {
+		t.Error("without open connections K stops at once")
+	}
+}
@@ -0,0 +1,49 @@
+package ui
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// captureChoices are the entries of the Capture traffic... action
+var captureChoices = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"30 seconds", 30 * time.Second},
+	{"1 minute", time.Minute},
+	{"5 minutes", 5 * time.Minute},
+}
+
+// captureActions lists one entry per capture duration
+func captureActions() []rowAction {
+	var actions []rowAction
+	for _, choice := range captureChoices {
+		actions = append(actions, rowAction{label: choice.label, run: func(m *Model, cfg config.PortForwardConfig) {
+			m.captureTraffic(cfg, choice.duration)
+		}})
+	}
+	return actions
+}
+
+// captureTraffic records what passes through the proxied forward cfg for d
+// to a new pcap file in ~/.kprtfwd/captures, for Wireshark
+func (m *Model) captureTraffic(cfg config.PortForwardConfig, d time.Duration) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot capture %s: %v", cfg.Service, err)
+		return
+	}
+	path := filepath.Join(home, ".kprtfwd", "captures", cfg.ID+"-"+time.Now().Format("20060102-150405")+".pcap")
+	if err := m.portForwarder.StartCapture(cfg.ID, path, d); err != nil {
+		logging.LogError("Failed to capture the traffic of '%s': %v", cfg.ID, err)
+		m.errorMsg = fmt.Sprintf("Cannot capture %s: %v", cfg.Service, err)
+		return
+	}
+	m.statusMsg = fmt.Sprintf("Capturing %s for %s to %s", cfg.Service, d, path)
+}
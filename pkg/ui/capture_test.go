@@ -0,0 +1,47 @@
+package ui
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestCaptureTrafficOfLazyForward(t *testing.T) {
+	useSleepingKubectl(t)
+	lazy := testForward(t, "dev", "api")
+	lazy.Lazy = true
+	plain := testForward(t, "dev", "web")
+	m, pf := newTestModel(t, lazy, plain)
+	for _, cfg := range []config.PortForwardConfig{lazy, plain} {
+		if err := pf.Start(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hasCapture := func(cfg config.PortForwardConfig) bool {
+		return slices.ContainsFunc(m.rowActions(cfg), func(a rowAction) bool { return a.label == "Capture traffic..." })
+	}
+	if hasCapture(plain) {
+		t.Error("a plain forward offers a capture kubectl's traffic cannot give")
+	}
+	if !hasCapture(lazy) {
+		t.Fatal("a lazy forward offers no capture")
+	}
+
+	m.captureTraffic(lazy, time.Minute)
+	if !pf.Capturing(lazy.ID) {
+		t.Fatalf("not capturing; error %q", m.errorMsg)
+	}
+	files, _ := filepath.Glob(filepath.Join(os.Getenv("HOME"), ".kprtfwd", "captures", lazy.ID+"-*.pcap"))
+	if len(files) != 1 || !strings.Contains(m.statusMsg, files[0]) {
+		t.Errorf("capture files %v, status %q; want one, named in the status", files, m.statusMsg)
+	}
+	if hasCapture(lazy) {
+		t.Error("a second capture is offered while one runs")
+	}
+}
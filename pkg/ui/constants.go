@@ -8,12 +8,23 @@ const (
 	ColPortRemote = "REMOTE"
 	ColPortLocal  = "LOCAL"
 	ColStatus     = "STATUS"
+	ColLatency    = "LATENCY" // optional, toggled with 'L'
+	ColID         = "ID"      // optional, toggled with 'I'
 )
 
 // Action Lines / Key Hints
 const (
-	ActionPortForwardNav  = "↑/↓: Navigate | space: Toggle/Expand | e: Edit Port | g: Toggle Grouping | S: Stop All | ctrl+d: Discover | ctrl+p: Projects | ctrl+r: Restart | q: Quit"
-	ActionProjectSelector = "↑/↓: Navigate | Enter: Select Project | M: Manage Projects | Esc: Back"
+	ActionPortForwardNav  = "↑/↓: Navigate | space: Toggle/Expand | e: Edit Port | g: Toggle Grouping | S/K: Start/Stop Listed | ctrl+d: Discover | ctrl+p: Projects | ctrl+r: Restart | q: Quit"
+	ActionProjectSelector = "↑/↓: Navigate | Enter: Select Project | R: Restart Running | M: Manage Projects | Esc: Back"
+	ActionRestartReport   = "↑/↓: Navigate | R: Retry Selected | Esc: Back"
+	ActionFinder          = "↑/↓: Navigate | Enter: Jump to / Activate Project | Ctrl+P: Show Project | Esc: Back"
+	ActionActionMenu      = "↑/↓: Navigate | Enter: Run | Esc: Back"
+	ActionPrune           = "↑/↓: Navigate | Space: Delete/Keep/Rename | x: Delete Emptied Projects | Enter: Apply | Esc: Cancel"
+	ActionWorkspaces      = "↑/↓: Navigate | Enter: Switch | N: New Workspace | Esc: Back"
+	ActionLogs            = "↑/↓/PgUp/PgDn: Scroll | g: Top | G: Follow | Esc: Back"
+	ActionDescribe        = "↑/↓/PgUp/PgDn: Scroll | r: Refresh | Esc: Back"
+	ActionAddForm         = "Tab/↓: Next Field | Shift+Tab/↑: Previous Field | Enter: Add | Esc: Cancel"
+	ActionProblems        = "↑/↓: Navigate | Enter: Select Forward | Esc: Back"
 	ActionExit            = "ctrl+x: Exit"
 )
 
@@ -23,20 +34,32 @@ const (
 	ShortcutRestartForwards = "ctrl+r"
 	ShortcutProjects        = "ctrl+p"
 	ShortcutDiscovery       = "ctrl+d"
+	ShortcutFinder          = "ctrl+f"
+	ShortcutPrune           = "ctrl+u"
+	ShortcutPanicStop       = "ctrl+s"
 )
 
 // Numeric Constants for Layout/Indexing
 const (
-	HeaderHeightEstimate   = 3 // Estimated lines used by the header section
-	MinTableHeight         = 4 // Minimum height for tables after calculation
-	PortForwardsViewOffset = 8 // Estimated non-table lines in PortForwards view for height calc (including filter line)
+	HeaderHeightEstimate   = 3  // Estimated lines used by the header section
+	MinTableHeight         = 4  // Minimum height for tables after calculation
+	PortForwardsViewOffset = 8  // Estimated non-table lines in PortForwards view for height calc (including filter line)
+	DetailPaneHeight       = 12 // Lines taken by the forward detail pane, border included
+	FinderViewOffset       = 9  // Non-result lines in the finder view
 )
 
 // Status Strings - these are display-only, not stored in config
 const (
-	StatusStopped = "Stopped"
-	StatusRunning = "Running"
-	StatusError   = "Error  " // padded to the same width as "Running"/"Stopped" to keep column alignment
+	StatusStopped  = "Stopped"
+	StatusRunning  = "Running"
+	StatusFailed   = "Failed "  // padded to the same width as "Running"/"Stopped" to keep column alignment
+	StatusStandby  = "Standby"  // lazy forward listening, kubectl not started yet
+	StatusQueued   = "Queued "  // waiting for the context's start limits
+	StatusSnoozed  = "Snoozed"  // stopped for a while, starts again by itself
+	StatusActive   = "Active "  // shown instead of Running while data flows (proxied forwards only)
+	StatusConflict = "Conflict" // failed, and another program now holds the local port
+	StatusDegraded = "Degraded" // running, but the HTTP health check fails
+	StatusDead     = "Dead   "  // failed: kubectl ran but the health check found its tunnel dead
 )
 
 // ASCII Visual Indicators - Compatible across all terminals
@@ -52,6 +75,9 @@ const (
 	// Group expansion indicators
 	ExpanderCollapsed = "[-]"
 	ExpanderExpanded  = "[+]"
+
+	// Marks user-defined groups (ui.group.<name> settings) in the table
+	VirtualGroupMarker = "◆ "
 )
 
 // Lipgloss Colors
@@ -62,9 +88,14 @@ const (
 	ColorTitle      = "14"  // Cyan for titles
 	ColorHelp       = "245" // Grey for help text
 	ColorError      = "9"   // Red for errors
+	ColorWarning    = "11"  // Yellow for warnings
 
 	// Status column colors
-	ColorStatusRunning = "2"   // Green
-	ColorStatusStopped = "240" // Dim grey
-	ColorStatusError   = "9"   // Red
+	ColorStatusRunning  = "2"   // Green
+	ColorStatusStopped  = "240" // Dim grey
+	ColorStatusError    = "9"   // Red
+	ColorStatusStandby  = "3"   // Yellow
+	ColorStatusQueued   = "6"   // Cyan
+	ColorStatusSnoozed  = "5"   // Magenta
+	ColorStatusDegraded = "11"  // Bright yellow
 )
@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// handleControlRequests starts and stops the forwards `kprtfwd start` and
+// `kprtfwd stop` asked this TUI to. Exposed forwards are not started: they
+// need the confirmation only the TUI can ask for.
+func (m *Model) handleControlRequests() {
+	reqs, err := config.TakeControlRequests()
+	if err != nil {
+		logging.LogError("Failed to read start and stop requests: %v", err)
+		return
+	}
+	var done []string
+	for _, req := range reqs {
+		cfg, exists := m.configStore.GetConfigByID(req.ID)
+		if !exists {
+			continue
+		}
+		switch req.Action {
+		case config.ControlStart:
+			if m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID) {
+				continue
+			}
+			if warning := m.exposureWarning(cfg); warning != "" {
+				m.errorMsg = warning + "; start it here with Space to confirm"
+				continue
+			}
+			delete(m.snoozedUntil, cfg.ID)
+			if err := m.portForwarder.Start(cfg); err != nil {
+				logging.LogError("Failed to start '%s' for kprtfwd start: %v", cfg.ID, err)
+				m.errorMsg = fmt.Sprintf("Cannot start %s: %s", cfg.ID, friendlyError(err))
+				continue
+			}
+			done = append(done, "started "+cfg.ID)
+		case config.ControlStop:
+			delete(m.snoozedUntil, cfg.ID)
+			delete(m.expiresAt, cfg.ID)
+			if !m.portForwarder.IsRunning(cfg.ID) && !m.portForwarder.IsQueued(cfg.ID) {
+				continue
+			}
+			if err := m.portForwarder.Stop(cfg.ID); err != nil {
+				logging.LogError("Failed to stop '%s' for kprtfwd stop: %v", cfg.ID, err)
+			}
+			done = append(done, "stopped "+cfg.ID)
+		}
+	}
+	if len(done) > 0 {
+		m.statusMsg = fmt.Sprintf("From the command line: %s", strings.Join(done, ", "))
+		m.refreshTable()
+	}
+}
+
+// publishHostState publishes the state of every forward this TUI does not
+// leave stopped, for `kprtfwd list`, `start` and `stop`
+func (m *Model) publishHostState() {
+	host := config.HostState{Mode: config.HostModeTUI}
+	states := m.portForwarder.Snapshot()
+	for _, cfg := range m.configStore.GetAll() {
+		state := states[cfg.ID]
+		status := m.statusOf(cfg.ID, state)
+		if status == StatusStopped {
+			continue
+		}
+		detail := state.ErrorReason
+		if detail == "" {
+			detail = state.HealthDetail
+		}
+		host.Forwards = append(host.Forwards, config.HostForward{
+			ID:     cfg.ID,
+			Status: strings.ToLower(strings.TrimSpace(status)),
+			Detail: detail,
+		})
+	}
+	if err := config.PublishHostState(host); err != nil {
+		logging.LogError("Failed to publish the state of the forwards: %v", err)
+	}
+}
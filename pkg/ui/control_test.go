@@ -0,0 +1,34 @@
+package ui
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestControlStartFailureIsNotReportedAsStarted(t *testing.T) {
+	useSleepingKubectl(t)
+	api := testForward(t, "dev", "api")
+	web := testForward(t, "dev", "web")
+	web.PortLocal = api.PortLocal
+	m, pf := newTestModel(t, api, web)
+	if err := pf.Start(api); err != nil {
+		t.Fatal(err)
+	}
+
+	m.publishHostState()
+	reqs := []config.ControlRequest{{Action: config.ControlStart, ID: web.ID}}
+	if err := config.SendControlRequests(os.Getpid(), reqs); err != nil {
+		t.Fatal(err)
+	}
+	m.handleControlRequests()
+
+	if strings.Contains(m.statusMsg, "started") {
+		t.Errorf("statusMsg = %q, want no start reported", m.statusMsg)
+	}
+	if !strings.Contains(m.errorMsg, web.ID) {
+		t.Errorf("errorMsg = %q, want the failure to start %s", m.errorMsg, web.ID)
+	}
+}
@@ -0,0 +1,76 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestCustomGroupsListTaggedForwards(t *testing.T) {
+	pg := config.PortForwardConfig{ID: "prod.data.pg", Context: "prod", Namespace: "data", Service: "pg", PortRemote: 5432, PortLocal: 5432}
+	web := config.PortForwardConfig{ID: "prod.payments.web", Context: "prod", Namespace: "payments", Service: "web", PortRemote: 80, PortLocal: 8080}
+	expr, err := config.ParseGroupExpr("tag:db OR namespace:payments")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m, _ := newTestModel(t, pg, web)
+	store := m.configStore
+	m.groupingEnabled = true
+	m.customGroups = []config.CustomGroup{{Name: "stateful", Expr: expr}}
+	m.applyColumnLayout()
+
+	// groupMembers returns the services listed under each group header
+	groupMembers := func() map[string][]string {
+		members := make(map[string][]string)
+		for _, row := range m.tableRows {
+			if row.Type == RowTypeItem {
+				members[row.GroupName] = append(members[row.GroupName], strings.TrimSpace(row.Data[2]))
+			}
+		}
+		return members
+	}
+
+	virtual := VirtualGroupMarker + "stateful"
+	if first := m.tableRows[0]; first.Type != RowTypeGroup || first.GroupName != virtual {
+		t.Fatalf("user-defined groups should come first, got %+v", first)
+	}
+	if got := groupMembers(); strings.Join(got[virtual], ",") != "web" || len(got["prod"]) != 2 {
+		t.Fatalf("before tagging, groups = %v", got)
+	}
+
+	// Tag pg through the action menu: it joins the group and stays under prod
+	m.portForwardsTable.SetCursor(len(m.tableRows) - 2)
+	if idx, _ := m.getConfigIndexFromTableRow(); store.GetAll()[idx].ID != pg.ID {
+		t.Fatalf("cursor is not on %s", pg.ID)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	selectAction(t, m, "Edit tags")
+	if !m.tagsEditMode {
+		t.Fatal("the action should open the tags input")
+	}
+	m.tagsEditInput.SetValue("tag:x")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(pg.ID); got.Tags != "" || m.errorMsg == "" {
+		t.Fatalf("a tag with ':' must be rejected, got tags %q", got.Tags)
+	}
+	m.startTagsEdit(pg)
+	m.tagsEditInput.SetValue("  db   critical ")
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(pg.ID); got.Tags != "db critical" {
+		t.Fatalf("tags = %q (error %q)", got.Tags, m.errorMsg)
+	}
+	if got := groupMembers(); len(got[virtual]) != 2 || len(got["prod"]) != 2 {
+		t.Fatalf("after tagging, groups = %v", got)
+	}
+
+	// Collapsing the group hides its rows only
+	m.portForwardsTable.SetCursor(0)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	if got := groupMembers(); len(got[virtual]) != 0 || len(got["prod"]) != 2 {
+		t.Fatalf("after collapsing, groups = %v", got)
+	}
+}
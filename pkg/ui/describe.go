@@ -0,0 +1,145 @@
+package ui
+
+import (
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// describePane is the screen showing kubectl describe of what a forward
+// goes to (d): a service with its endpoints, or the pod or workload
+type describePane struct {
+	m        *Model
+	id       string
+	target   string // cfg.Target() plus endpoints for a service, for the title
+	loading  bool
+	err      string // why kubectl describe failed; "" otherwise
+	viewport viewport.Model
+}
+
+// describedMsg carries kubectl describe output for the forward id
+type describedMsg struct {
+	id  string
+	out string
+	err error
+}
+
+// enterDescribe shows the describe pane for cfg and runs kubectl describe
+func (m *Model) enterDescribe(cfg config.PortForwardConfig) tea.Cmd {
+	target := cfg.Target()
+	if cfg.TargetKind == config.TargetKindService {
+		target += " and endpoints/" + cfg.Service
+	}
+	m.describe = describePane{m: m, id: cfg.ID, target: target}
+	m.describe.viewport = viewport.New(m.width, m.describe.viewportHeight())
+	m.uiState = StateDescribe
+	return m.describe.load()
+}
+
+// load runs kubectl describe off the event loop
+func (p *describePane) load() tea.Cmd {
+	cfg, exists := p.m.configStore.GetConfigByID(p.id)
+	if !exists {
+		p.err = p.id + " no longer exists"
+		return nil
+	}
+	p.loading = true
+	p.err = ""
+	return func() tea.Msg {
+		out, err := k8s.Describe(cfg)
+		return describedMsg{id: cfg.ID, out: out, err: err}
+	}
+}
+
+// handleDescribed shows the output, unless the pane moved on meanwhile
+func (m *Model) handleDescribed(msg describedMsg) (tea.Model, tea.Cmd) {
+	p := &m.describe
+	if p.id != msg.id {
+		return m, nil
+	}
+	p.loading = false
+	if msg.err != nil {
+		logging.LogError("Describing %s failed: %v", msg.id, msg.err)
+		p.err = friendlyError(msg.err)
+		return m, nil
+	}
+	p.viewport.SetContent(strings.TrimRight(msg.out, "\n"))
+	return m, nil
+}
+
+// viewportHeight returns the viewport height that fits the window under the
+// title and above the status and help lines
+func (p *describePane) viewportHeight() int {
+	return max(p.m.height-6, 3)
+}
+
+// Update handles keys in the describe pane
+func (p *describePane) Update(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m := p.m
+	switch msg.String() {
+	case "esc", "q":
+		m.uiState = StatePortForwards
+		m.refreshTable()
+		return m, nil
+	case "r":
+		if p.loading {
+			return m, nil
+		}
+		return m, p.load()
+	case "g", "home":
+		p.viewport.GotoTop()
+		return m, nil
+	case "G", "end":
+		p.viewport.GotoBottom()
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		p.viewport, cmd = p.viewport.Update(msg)
+		return m, cmd
+	}
+}
+
+// Resize fits the viewport to the window
+func (p *describePane) Resize() {
+	if p.m == nil {
+		return // never shown
+	}
+	p.viewport.Width = p.m.width
+	p.viewport.Height = p.viewportHeight()
+}
+
+// View renders the describe pane
+func (p *describePane) View() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	b.WriteString(titleStyle.Render("🔎 Describe " + p.target))
+	b.WriteString("\n\n")
+
+	b.WriteString(p.viewport.View())
+	b.WriteString("\n")
+
+	switch {
+	case p.loading:
+		b.WriteString("Running kubectl describe...")
+	case p.err != "":
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorError))
+		b.WriteString(errorStyle.Render("Error: " + p.err))
+	}
+	b.WriteString("\n")
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	b.WriteString(helpStyle.Render(ActionDescribe))
+	b.WriteString("\n")
+
+	return b.String()
+}
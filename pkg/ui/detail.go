@@ -0,0 +1,206 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// toggleDetail shows or hides the detail pane below the port-forwards table
+func (m *Model) toggleDetail() {
+	m.showDetail = !m.showDetail
+	m.portForwardsTable.SetHeight(m.portForwardsTableHeight())
+}
+
+// portForwardsTableHeight returns the table height that fits the window,
+// leaving room for the detail pane when it is shown
+func (m *Model) portForwardsTableHeight() int {
+	height := m.height - PortForwardsViewOffset
+	if m.showDetail {
+		height -= DetailPaneHeight
+	}
+	if m.uiState == StateActionMenu {
+		height -= m.actionMenuHeight()
+	}
+	if height < MinTableHeight {
+		height = MinTableHeight
+	}
+	return height
+}
+
+// renderForwardDetail renders the detail pane for the selected forward: its
+// configuration, runtime status and what discovery saw when it was created.
+func (m *Model) renderForwardDetail() string {
+	style := boxStyle(ColorBorder)
+
+	lines := []string{"Select a port forward to see its details"}
+	if !m.isGroupHeaderSelected() {
+		if idx, err := m.getConfigIndexFromTableRow(); err == nil {
+			if cfg, err := m.configStore.GetWithError(idx); err == nil {
+				lines = m.forwardDetailLines(cfg)
+			}
+		}
+	}
+	for len(lines) < DetailPaneHeight-2 {
+		lines = append(lines, "")
+	}
+	return style.Render(strings.Join(lines, "\n"))
+}
+
+// sinceText renders an elapsed time coarsely: "12s", "4m", "3h"
+func sinceText(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	}
+}
+
+// forwardDetailLines returns the detail pane content for cfg, one line per
+// field. The pane has a fixed height, so the count must stay at
+// DetailPaneHeight-2 (the border takes the other two).
+func (m *Model) forwardDetailLines(cfg config.PortForwardConfig) []string {
+	label := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp)).Render
+
+	mode := "plain"
+	if cfg.Lazy {
+		mode = "lazy"
+	}
+	if cfg.TLSMode != config.TLSModeNone {
+		mode += ", TLS " + cfg.TLSMode
+	}
+	if cfg.Listen != config.ListenIPv4 {
+		mode += ", listen " + strings.Join(config.ListenHosts(cfg.Listen), "+")
+	}
+	if cfg.KubectlArgs != "" {
+		mode += ", kubectl " + cfg.KubectlArgs
+	}
+
+	state := m.portForwarder.State(cfg.ID)
+	status := strings.TrimSpace(m.statusOf(cfg.ID, state))
+	if reason := state.ErrorReason; reason != "" {
+		if friendly, ok := friendlyReason(reason); ok {
+			status += fmt.Sprintf(": %s (%s)", friendly, oneLine(reason))
+		} else {
+			status += ": " + oneLine(reason)
+		}
+	}
+	if state.Conflict.Port != 0 {
+		status += "; now " + state.Conflict.String()
+	}
+	if state.Duplicate.PID != 0 {
+		status += "; also forwarded by a kubectl outside kprtfwd, " + state.Duplicate.String()
+	}
+	switch {
+	case state.Health == k8s.HealthDegraded:
+		status += ": " + state.HealthDetail + ", checked at " + state.CheckedAt.Format("15:04:05")
+	case state.Health == k8s.HealthHealthy && cfg.HealthPath != "":
+		status += ", healthy at " + state.CheckedAt.Format("15:04:05") + " (GET " + cfg.HealthPath + ")"
+	case state.Health == k8s.HealthHealthy:
+		status += ", healthy at " + state.CheckedAt.Format("15:04:05")
+	}
+	if usage := usageText(state); usage != "" {
+		status += "; " + usage
+	}
+	if until, ok := m.expiresAt[cfg.ID]; ok {
+		status += ", stops itself at " + until.Format("15:04")
+	}
+	// A plain Stopped says nothing about whether it was meant to be
+	if reason := state.StopReason.Describe(); reason != "" && !state.Failed {
+		switch {
+		case state.Standby:
+			status += ", kubectl stopped " + reason + " at " + state.StoppedAt.Format("15:04:05")
+		case !state.Running && !state.Queued:
+			status += " " + reason + " at " + state.StoppedAt.Format("15:04:05")
+		}
+	}
+
+	if state.Proxied {
+		if state.LastActivity.IsZero() {
+			status += ", no data yet"
+		} else {
+			status += ", last data " + sinceText(time.Since(state.LastActivity)) + " ago"
+		}
+		if m.portForwarder.Capturing(cfg.ID) {
+			status += ", capturing traffic"
+		}
+	}
+
+	// Earlier failures, newest first; the current one is on the Status line
+	failures := m.portForwarder.RecentFailures(cfg.ID)
+	if state.Failed && len(failures) > 0 {
+		failures = failures[:len(failures)-1]
+	}
+	history := "none"
+	if len(failures) > 0 {
+		var entries []string
+		for _, f := range slices.Backward(failures) {
+			entries = append(entries, f.At.Format("15:04:05")+" "+oneLine(f.Reason))
+		}
+		history = strings.Join(entries, " | ")
+	}
+
+	name := cfg.Service
+	if cfg.TargetKind != config.TargetKindService {
+		name = cfg.Target() // deployment/api
+	}
+	target := fmt.Sprintf("%s/%s/%s:%s", cfg.Context, cfg.Namespace, name, formatPorts(cfg.PortRemote, cfg.Ports()))
+	if cfg.PodSelector != "" {
+		target += ", pod " + cfg.PodSelector
+		if state.Pod != "" {
+			pod := state.Pod
+			if state.PodZone != "" {
+				pod += ", " + state.PodZone
+			}
+			if state.PodPinned {
+				pod += ", pinned"
+			}
+			target += " (" + pod + ")"
+		}
+	}
+
+	hooks := "none"
+	if cfg.HasHooks() {
+		var entries []string
+		if cfg.StartHook != "" {
+			entries = append(entries, "start: "+cfg.StartHook)
+		}
+		if cfg.StopHook != "" {
+			entries = append(entries, "stop: "+cfg.StopHook)
+		}
+		hooks = strings.Join(entries, " | ") + " (output in " + logging.ForwardLogPath(cfg.ID) + ")"
+	}
+
+	discovered := "not recorded (added before snapshots were kept)"
+	labels := "-"
+	if snap, ok := m.configStore.GetServiceSnapshot(cfg.ID); ok {
+		discovered = fmt.Sprintf("%s, %s, target port %s", snap.DiscoveredAt.Format("2006-01-02 15:04"), snap.Type, snap.TargetPort)
+		if snap.PortName != "" {
+			discovered += fmt.Sprintf(" (%s)", snap.PortName)
+		}
+		labels = snap.LabelString()
+	}
+
+	return []string{
+		label("Forward:    ") + cfg.ID,
+		label("Target:     ") + target,
+		label("Local:      ") + "localhost:" + formatPorts(cfg.PortLocal, cfg.Ports()),
+		label("Mode:       ") + mode,
+		label("kubectl:    ") + k8s.KubectlCommand(cfg, state),
+		label("Status:     ") + status,
+		label("Failed:     ") + history,
+		label("Hooks:      ") + hooks,
+		label("Discovered: ") + discovered,
+		label("Labels:     ") + labels,
+	}
+}
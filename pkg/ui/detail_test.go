@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+)
+
+func TestFailedForwardShowsReason(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no kubectl: every start fails
+
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 18080}
+	m, pf := newTestModel(t, cfg)
+
+	if err := pf.Start(cfg); err == nil {
+		t.Fatal("start without kubectl should fail")
+	}
+	reason := pf.ErrorReason(cfg.ID)
+	if cell := m.statusCell(cfg.ID, m.statusFor(cfg.ID), pf.State(cfg.ID)); !strings.Contains(cell, "Failed: ") {
+		t.Fatalf("STATUS cell of a failed forward = %q, want the reason after Failed", cell)
+	}
+	lines := m.forwardDetailLines(cfg)
+	if len(lines) != DetailPaneHeight-2 {
+		t.Fatalf("detail pane has %d lines, want %d", len(lines), DetailPaneHeight-2)
+	}
+	if !strings.Contains(lines[5], oneLine(reason)) || !strings.HasSuffix(lines[6], "none") {
+		t.Fatalf("the detail pane should show the full error and no earlier ones:\n%s", strings.Join(lines, "\n"))
+	}
+
+	// Stopping clears the failure, but it stays in the history
+	if err := pf.Stop(cfg.ID); err != nil {
+		t.Fatal(err)
+	}
+	if got := m.statusFor(cfg.ID); got != StatusStopped {
+		t.Fatalf("status after stop = %q", got)
+	}
+	if lines := m.forwardDetailLines(cfg); !strings.Contains(lines[6], oneLine(reason)) {
+		t.Fatalf("the failure should stay in the detail pane's history:\n%s", strings.Join(lines, "\n"))
+	}
+}
+
+func TestStoppedForwardShowsWhy(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	// Lazy: the listener is up without kubectl
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 18091, Lazy: true}
+	m, pf := newTestModel(t, cfg)
+
+	if lines := m.forwardDetailLines(cfg); strings.Contains(lines[5], " at ") {
+		t.Fatalf("a forward never started has no stop to explain: %s", lines[5])
+	}
+	if err := pf.Start(cfg); err != nil {
+		t.Fatal(err)
+	}
+	m.stopAllRunningPortForwards()
+	if lines := m.forwardDetailLines(cfg); !strings.Contains(lines[5], "Stopped by a project switch at ") {
+		t.Fatalf("the detail pane should say the project switch stopped it: %s", lines[5])
+	}
+}
+
+func TestHealthShowsInStatus(t *testing.T) {
+	m := &Model{}
+	degraded := k8s.ForwardState{Running: true, Health: k8s.HealthDegraded, HealthDetail: "GET /healthz answered 503 Service Unavailable"}
+	if got := m.statusOf("ctx.ns.api", degraded); got != StatusDegraded {
+		t.Fatalf("status of a running forward failing its HTTP check = %q", got)
+	}
+	if got := m.statusDescription("ctx.ns.api", StatusDegraded, degraded); got != "Degraded: GET /healthz answered 503 Service Unavailable" {
+		t.Fatalf("description = %q", got)
+	}
+	if got := m.statusOf("ctx.ns.api", k8s.ForwardState{Running: true, Health: k8s.HealthHealthy}); got != StatusRunning {
+		t.Fatalf("status of a healthy forward = %q", got)
+	}
+
+	// A tunnel the check found dead is told apart from kubectl failing
+	dead := k8s.ForwardState{Failed: true, ErrorReason: "local port 8080 is no longer listening", Health: k8s.HealthDead}
+	if got := m.statusDescription("ctx.ns.api", m.statusOf("ctx.ns.api", dead), dead); got != "Dead: local port 8080 is no longer listening" {
+		t.Fatalf("description of a dead forward = %q", got)
+	}
+	if got := m.statusOf("ctx.ns.api", k8s.ForwardState{Failed: true, ErrorReason: "kubectl exited"}); got != StatusFailed {
+		t.Fatalf("status of a forward whose kubectl failed = %q", got)
+	}
+}
+
+func TestCycleListen(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 18080}
+	m, _ := newTestModel(t, cfg)
+
+	for _, want := range []string{config.ListenIPv6, config.ListenDual, config.ListenIPv4} {
+		m.cycleListen(cfg)
+		var err error
+		if cfg, err = m.configStore.GetWithError(0); err != nil {
+			t.Fatal(err)
+		}
+		if cfg.Listen != want {
+			t.Fatalf("Listen after cycling = %q, want %q (%s)", cfg.Listen, want, m.errorMsg)
+		}
+		if want == config.ListenIPv6 && cfg.LocalURL() != "http://[::1]:18080" {
+			t.Errorf("forwardURL of an IPv6 forward = %q", cfg.LocalURL())
+		}
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	for _, tc := range []struct {
+		in    string
+		width int
+		want  string
+	}{
+		{"Failed", 10, "Failed"},
+		{"Failed: auth expired", 10, "Failed: a…"},
+		{"Failed", 0, ""},
+	} {
+		if got := truncate(tc.in, tc.width); got != tc.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tc.in, tc.width, got, tc.want)
+		}
+	}
+}
+
+func TestSinceText(t *testing.T) {
+	for d, want := range map[time.Duration]string{
+		12 * time.Second:               "12s",
+		4*time.Minute + 50*time.Second: "4m",
+		26 * time.Hour:                 "26h",
+	} {
+		if got := sinceText(d); got != want {
+			t.Errorf("sinceText(%v) = %q, want %q", d, got, want)
+		}
+	}
+}
@@ -2,12 +2,15 @@ package ui
 
 import (
 	"fmt"
+	"slices"
 
+	"github.com/xlttj/kprtfwd/pkg/config"
 	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // This file keeps all the kubectl-backed work in service discovery OFF the
@@ -19,17 +22,28 @@ import (
 // clustersLoadedMsg is delivered when the async kubectl context lookup finishes.
 type clustersLoadedMsg struct {
 	clusters []string
+	sources  map[string]string // kubeconfig file per cluster
 	current  string
 	err      error
 }
 
 // servicesDiscoveredMsg is delivered when async service discovery for a cluster finishes.
 type servicesDiscoveredMsg struct {
+	run     int // discoveryRun when it started
 	cluster string
 	result  *discovery.DiscoveryResult
 	err     error
 }
 
+// kubeconfigChangedMsg is delivered after the kubeconfig was (re)read: at
+// startup and whenever its files change on disk.
+type kubeconfigChangedMsg struct {
+	clusters []string
+	sources  map[string]string // kubeconfig file per cluster
+	current  string
+	err      error
+}
+
 // loadClustersCmd fetches the available kubectl contexts without blocking the UI.
 func loadClustersCmd() tea.Cmd {
 	return func() tea.Msg {
@@ -39,25 +53,50 @@ func loadClustersCmd() tea.Cmd {
 		}
 		// Current context is best-effort; failing to read it is non-fatal.
 		current, _ := discovery.CurrentContext()
-		return clustersLoadedMsg{clusters: clusters, current: current}
+		return clustersLoadedMsg{clusters: clusters, sources: kubectl.ContextSources(clusters), current: current}
+	}
+}
+
+// kubeconfigChangedCmd re-reads the contexts and current context from the
+// kubeconfig without blocking the UI.
+func kubeconfigChangedCmd() tea.Cmd {
+	return func() tea.Msg {
+		current, err := discovery.CurrentContext()
+		if err != nil {
+			return kubeconfigChangedMsg{err: err}
+		}
+		clusters, err := getAvailableClusters()
+		if err != nil {
+			return kubeconfigChangedMsg{current: current, err: err}
+		}
+		return kubeconfigChangedMsg{clusters: clusters, sources: kubectl.ContextSources(clusters), current: current}
 	}
 }
 
-// discoverServicesCmd runs service discovery for a cluster without blocking the UI.
-func discoverServicesCmd(cluster string) tea.Cmd {
+// discoverServicesCmd runs service discovery for the namespaces of a cluster
+// matching namespaceFilter without blocking the UI. run tags the result.
+func discoverServicesCmd(cluster, namespaceFilter string, run int) tea.Cmd {
 	return func() tea.Msg {
 		opts := discovery.Options{
 			Context:         cluster,
-			NamespaceFilter: "*", // Discover all namespaces
+			NamespaceFilter: namespaceFilter,
 			Verbose:         false,
 		}
 		result, err := discovery.DiscoverServices(opts)
-		return servicesDiscoveredMsg{cluster: cluster, result: result, err: err}
+		return servicesDiscoveredMsg{run: run, cluster: cluster, result: result, err: err}
 	}
 }
 
-// handleClustersLoaded builds the cluster-selection table from async results.
+// handleClustersLoaded builds the cluster-selection table from async results,
+// or updates the one built from the known contexts.
 func (m *Model) handleClustersLoaded(msg clustersLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err == nil {
+		m.syncForwardTemplates(msg.clusters)
+	}
+	if !m.clustersPending {
+		return m.refreshClusters(msg)
+	}
+	m.clustersPending = false
 	m.discoveryLoading = false
 
 	// The user may have pressed Esc while loading; don't yank them back.
@@ -66,7 +105,7 @@ func (m *Model) handleClustersLoaded(msg clustersLoadedMsg) (tea.Model, tea.Cmd)
 	}
 
 	if msg.err != nil {
-		m.errorMsg = fmt.Sprintf("Failed to get clusters: %v", msg.err)
+		m.errorMsg = fmt.Sprintf("Failed to get clusters: %s", friendlyError(msg.err))
 		m.statusMsg = ""
 		m.uiState = StatePortForwards
 		return m, nil
@@ -79,7 +118,89 @@ func (m *Model) handleClustersLoaded(msg clustersLoadedMsg) (tea.Model, tea.Cmd)
 	}
 
 	m.statusMsg = ""
-	m.buildClusterTable(msg.clusters, msg.current)
+	if msg.current != "" {
+		m.currentContext = msg.current
+	}
+	m.knownClusters, m.knownClusterSources = msg.clusters, msg.sources
+	m.updateProblems()
+	m.discoveryClusterSources = msg.sources
+	m.buildClusterTable(msg.clusters, msg.current, msg.current)
+	return m, nil
+}
+
+// syncForwardTemplates expands the forward templates across the listed
+// contexts, so a context that appeared since gets its forwards
+func (m *Model) syncForwardTemplates(contexts []string) {
+	sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+	if !ok || len(contexts) == 0 {
+		return
+	}
+	sync, err := sqliteStore.SyncForwardTemplates(contexts)
+	if err != nil {
+		logging.LogError("Expanding forward templates failed: %v", err)
+		m.errorMsg = fmt.Sprintf("Expanding forward templates failed: %v", err)
+	}
+	if !sync.Changed() {
+		return
+	}
+	logging.LogDebug("Forward templates: added %v, updated %v", sync.Added, sync.Updated)
+	m.statusMsg = fmt.Sprintf("Templates: %d forwards added, %d updated", len(sync.Added), len(sync.Updated))
+	if !m.tableLoading {
+		m.refreshTable()
+	}
+}
+
+// refreshClusters applies a background context listing: it updates the known
+// contexts and, if the cluster list is open, rebuilds it while keeping the
+// highlighted cluster.
+func (m *Model) refreshClusters(msg clustersLoadedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil || len(msg.clusters) == 0 {
+		logging.LogDebug("Refreshing kubectl contexts failed: %v", msg.err)
+		return m, nil // keep showing the known contexts
+	}
+	if msg.current != "" {
+		m.currentContext = msg.current
+	}
+	m.knownClusters, m.knownClusterSources = msg.clusters, msg.sources
+	m.updateProblems()
+
+	if m.uiState != StateServiceDiscovery || m.discoveryPhase != PhaseClusterSelection || m.discoveryLoading {
+		return m, nil
+	}
+	selected := m.currentContext
+	if idx := m.discoveryTable.Cursor(); idx >= 0 && idx < len(m.discoveryClusters) {
+		selected = m.discoveryClusters[idx]
+	}
+	m.discoveryClusterSources = msg.sources
+	m.buildClusterTable(msg.clusters, m.currentContext, selected)
+	return m, nil
+}
+
+// handleKubeconfigChanged records the kubeconfig's current context, warns when
+// it was switched outside kprtfwd, and refreshes an open cluster list so its
+// CURRENT marker and default selection follow the change.
+func (m *Model) handleKubeconfigChanged(msg kubeconfigChangedMsg) (tea.Model, tea.Cmd) {
+	if msg.err != nil {
+		logging.LogDebug("Re-reading kubeconfig failed: %v", msg.err)
+		return m, nil
+	}
+	if len(msg.clusters) > 0 {
+		m.knownClusters, m.knownClusterSources = msg.clusters, msg.sources
+		m.syncForwardTemplates(msg.clusters)
+		m.updateProblems()
+	}
+	previous := m.currentContext
+	m.currentContext = msg.current
+	if previous == "" || previous == msg.current {
+		return m, nil
+	}
+	logging.LogDebug("kubectl current context changed externally: %s -> %s", previous, msg.current)
+	m.statusMsg = fmt.Sprintf("kubectl context switched outside kprtfwd: %s → %s", previous, msg.current)
+
+	if m.uiState == StateServiceDiscovery && m.discoveryPhase == PhaseClusterSelection && !m.discoveryLoading && len(msg.clusters) > 0 {
+		m.discoveryClusterSources = msg.sources
+		m.buildClusterTable(msg.clusters, msg.current, msg.current)
+	}
 	return m, nil
 }
 
@@ -87,6 +208,11 @@ func (m *Model) handleClustersLoaded(msg clustersLoadedMsg) (tea.Model, tea.Cmd)
 // The conversion logic (pre-existing service detection, local-port defaulting) is
 // pure given the configStore, which makes it unit-testable without kubectl.
 func (m *Model) handleServicesDiscovered(msg servicesDiscoveredMsg) (tea.Model, tea.Cmd) {
+	// Ignore late results of a run cancelled with Esc: discovery may have
+	// been opened again meanwhile, on another cluster
+	if msg.run != m.discoveryRun {
+		return m, nil
+	}
 	m.discoveryLoading = false
 
 	// Ignore late results if the user navigated away while we were discovering.
@@ -95,7 +221,7 @@ func (m *Model) handleServicesDiscovered(msg servicesDiscoveredMsg) (tea.Model,
 	}
 
 	if msg.err != nil {
-		m.errorMsg = fmt.Sprintf("Service discovery failed: %v", msg.err)
+		m.errorMsg = fmt.Sprintf("Service discovery failed: %s", friendlyError(msg.err))
 		m.statusMsg = ""
 		return m, nil
 	}
@@ -104,6 +230,9 @@ func (m *Model) handleServicesDiscovered(msg servicesDiscoveredMsg) (tea.Model,
 	result := msg.result
 	if result == nil || result.TotalCount == 0 {
 		m.errorMsg = fmt.Sprintf("No services found in cluster '%s'", selectedCluster)
+		if m.discoveryNamespaceFilter != "*" {
+			m.errorMsg += fmt.Sprintf(" in namespaces '%s' (a: all namespaces)", m.discoveryNamespaceFilter)
+		}
 		m.statusMsg = ""
 		return m, nil
 	}
@@ -119,18 +248,21 @@ func (m *Model) handleServicesDiscovered(msg servicesDiscoveredMsg) (tea.Model,
 	}
 	m.discoveryExistingServices = existingServiceMap
 
-	// Convert discovered services to individual port selections
+	// Convert discovered services to individual port selections. New ports get
+	// their local port from the context's strategy; taken grows with each pick
+	// so random ports stay distinct.
+	strategy := config.LocalPortStrategy(m.configStore.GetSettings(), selectedCluster)
+	taken := slices.Clone(existingConfigs)
 	var portSelections []PortSelection
 	for _, discoveredService := range result.Services {
 		for _, port := range discoveredService.ServiceInfo.Ports {
 			generatedID := generateServicePortID(selectedCluster, discoveredService.ServiceInfo, port)
 
-			// Default local port to remote port
-			localPort := int(port.Port)
-
 			// Check if this specific port already exists in config
+			localPort := 0
 			alreadyExists := false
 			existingConfigIndex := -1
+			existingConfigID := ""
 			for i, cfg := range existingConfigs {
 				if cfg.Context == selectedCluster &&
 					cfg.Namespace == discoveredService.ServiceInfo.Namespace &&
@@ -138,11 +270,16 @@ func (m *Model) handleServicesDiscovered(msg servicesDiscoveredMsg) (tea.Model,
 					cfg.PortRemote == int(port.Port) {
 					alreadyExists = true
 					existingConfigIndex = i
+					existingConfigID = cfg.ID
 					// Use the existing local port, not the remote port
 					localPort = cfg.PortLocal
 					break
 				}
 			}
+			if !alreadyExists {
+				localPort = config.DefaultLocalPort(strategy, int(port.Port), taken)
+				taken = append(taken, config.PortForwardConfig{PortLocal: localPort})
+			}
 
 			portSelections = append(portSelections, PortSelection{
 				ServiceName:      discoveredService.ServiceInfo.Name,
@@ -159,15 +296,21 @@ func (m *Model) handleServicesDiscovered(msg servicesDiscoveredMsg) (tea.Model,
 				LocalPort:           localPort,
 				GeneratedID:         generatedID,
 				ExistingConfigIndex: existingConfigIndex, // Config index or -1 if new
+				ExistingConfigID:    existingConfigID,
 			})
 		}
 	}
 
 	m.discoveryPorts = portSelections
+	m.recentDiscovery = &msg
+	m.touchGroup(selectedCluster)
 
 	// Move to service selection phase
 	m.discoveryPhase = PhaseServiceSelection
 	m.statusMsg = fmt.Sprintf("Found %d ports in cluster '%s'", len(m.discoveryPorts), selectedCluster)
+	if len(result.Forbidden) > 0 {
+		m.statusMsg += fmt.Sprintf(" (%d namespaces skipped: no permission to list services)", len(result.Forbidden))
+	}
 	m.refreshDiscoveryTable()
 
 	return m, nil
@@ -175,12 +318,14 @@ func (m *Model) handleServicesDiscovered(msg servicesDiscoveredMsg) (tea.Model,
 
 // buildClusterTable constructs the cluster-selection table from already-fetched
 // data. It performs no network I/O, so it is safe to call from the event loop
-// (e.g. when navigating back from service selection).
-func (m *Model) buildClusterTable(clusters []string, current string) {
+// (e.g. when navigating back from service selection). The CURRENT column marks
+// the kubeconfig's current context and KUBECONFIG the file each context comes
+// from; the cursor starts on selected.
+func (m *Model) buildClusterTable(clusters []string, current, selected string) {
 	m.discoveryClusters = clusters
 	m.discoverySelectedCluster = 0
 	for i, cluster := range clusters {
-		if cluster == current {
+		if cluster == selected {
 			m.discoverySelectedCluster = i
 			break
 		}
@@ -189,31 +334,14 @@ func (m *Model) buildClusterTable(clusters []string, current string) {
 	rows := make([]table.Row, len(clusters))
 	for i, cluster := range clusters {
 		status := IndicatorUnselected
-		if i == m.discoverySelectedCluster {
+		if cluster == current {
 			status = IndicatorSelected
 		}
-		rows[i] = table.Row{cluster, status}
+		rows[i] = table.Row{cluster, displayPath(m.discoveryClusterSources[cluster]), status}
 	}
 
 	columns := m.calculateClusterSelectionColumns()
 
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(ColorBorder)).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color(ColorSelectedFg)).
-		Background(lipgloss.Color(ColorSelectedBg)).
-		Bold(false)
-
-	m.discoveryTable = table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(min(len(rows)+2, m.height-6)),
-		table.WithKeyMap(navTableKeyMap()),
-		table.WithStyles(s),
-	)
+	m.discoveryTable = newNavTable(columns, rows, min(len(rows)+2, m.height-6))
+	m.discoveryTable.SetCursor(m.discoverySelectedCluster)
 }
@@ -1,6 +1,8 @@
 package ui
 
 import (
+	"fmt"
+	"strings"
 	"testing"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
@@ -11,7 +13,8 @@ import (
 // Only the read methods used by the discovery handlers carry real behaviour;
 // the rest satisfy the interface as no-ops.
 type fakeConfigStore struct {
-	configs []config.PortForwardConfig
+	configs  []config.PortForwardConfig
+	settings map[string]string
 }
 
 func (f *fakeConfigStore) Add(cfg config.PortForwardConfig) error { return nil }
@@ -32,11 +35,27 @@ func (f *fakeConfigStore) GetWithError(index int) (config.PortForwardConfig, err
 func (f *fakeConfigStore) GetConfigByID(id string) (config.PortForwardConfig, bool) {
 	return config.PortForwardConfig{}, false
 }
-func (f *fakeConfigStore) GetIndexByID(id string) (int, bool)            { return 0, false }
+func (f *fakeConfigStore) GetIndexByID(id string) (int, bool) { return 0, false }
+func (f *fakeConfigStore) UpdatePortForward(id string, cfg config.PortForwardConfig) error {
+	return nil
+}
+func (f *fakeConfigStore) ModifyPortForward(id string, change func(cfg *config.PortForwardConfig) error) error {
+	return nil
+}
+func (f *fakeConfigStore) SetServiceSnapshot(id string, snap config.ServiceSnapshot) error {
+	return nil
+}
+func (f *fakeConfigStore) GetServiceSnapshot(id string) (config.ServiceSnapshot, bool) {
+	return config.ServiceSnapshot{}, false
+}
 func (f *fakeConfigStore) CreateProject(name string, ids []string) error { return nil }
 func (f *fakeConfigStore) GetProjects() []config.Project                 { return nil }
 func (f *fakeConfigStore) GetAllProjects() []config.Project              { return nil }
 func (f *fakeConfigStore) DeleteProject(name string) error               { return nil }
+func (f *fakeConfigStore) GetSetting(key string) (string, bool)          { return "", false }
+func (f *fakeConfigStore) GetSettings() map[string]string                { return f.settings }
+func (f *fakeConfigStore) SetSetting(key, value string) error            { return nil }
+func (f *fakeConfigStore) UnsetSetting(key string) error                 { return nil }
 func (f *fakeConfigStore) SetActiveProject(name string) error            { return nil }
 func (f *fakeConfigStore) GetActiveProject() *config.Project             { return nil }
 func (f *fakeConfigStore) ClearActiveProject()                           {}
@@ -47,6 +66,8 @@ func (f *fakeConfigStore) GetActiveProjectForwards() []config.PortForwardConfig
 func (f *fakeConfigStore) Load() error { return nil }
 func (f *fakeConfigStore) Save() error { return nil }
 
+func (f *fakeConfigStore) RecordForwardEvents(events []config.ForwardEvent) error { return nil }
+
 // newDiscoveryResult builds a single-service discovery result with the given ports.
 func newDiscoveryResult(cluster, namespace, service string, ports ...discovery.ServicePort) *discovery.DiscoveryResult {
 	return &discovery.DiscoveryResult{
@@ -130,6 +151,34 @@ func TestHandleServicesDiscovered_BuildsPortSelections(t *testing.T) {
 	}
 }
 
+func TestHandleServicesDiscovered_UsesLocalPortStrategy(t *testing.T) {
+	store := &fakeConfigStore{
+		configs: []config.PortForwardConfig{
+			{Context: "ctx1", Namespace: "default", Service: "api", PortRemote: 8080, PortLocal: 8080},
+		},
+		settings: map[string]string{
+			config.SettingLocalPorts:                "random",
+			config.SettingLocalPortsPrefix + "ctx1": "offset:10000",
+		},
+	}
+	m := &Model{configStore: store, uiState: StateServiceDiscovery, discoveryLoading: true}
+
+	result := newDiscoveryResult("ctx1", "default", "api",
+		discovery.ServicePort{Port: 8080, Protocol: "TCP"},
+		discovery.ServicePort{Port: 9090, Protocol: "TCP"},
+	)
+	m.handleServicesDiscovered(servicesDiscoveredMsg{cluster: "ctx1", result: result})
+
+	// The context's own offset wins over the general setting; existing
+	// forwards keep their port
+	if got := m.discoveryPorts[0].LocalPort; got != 8080 {
+		t.Errorf("existing forward's local port = %d, want 8080", got)
+	}
+	if got := m.discoveryPorts[1].LocalPort; got != 19090 {
+		t.Errorf("new forward's local port = %d, want 19090", got)
+	}
+}
+
 func TestHandleServicesDiscovered_IgnoredWhenNavigatedAway(t *testing.T) {
 	store := &fakeConfigStore{}
 	m := &Model{
@@ -154,6 +203,28 @@ func TestHandleServicesDiscovered_IgnoredWhenNavigatedAway(t *testing.T) {
 	}
 }
 
+func TestHandleServicesDiscovered_IgnoredAfterCancel(t *testing.T) {
+	store := &fakeConfigStore{}
+	m := &Model{
+		configStore:      store,
+		uiState:          StateServiceDiscovery,
+		discoveryLoading: true, // a second run, on another cluster
+		discoveryRun:     2,
+		discoveryPhase:   PhaseClusterSelection,
+	}
+
+	result := newDiscoveryResult("ctx1", "default", "api",
+		discovery.ServicePort{Port: 8080, Protocol: "TCP"})
+	m.handleServicesDiscovered(servicesDiscoveredMsg{run: 1, cluster: "ctx1", result: result})
+
+	if !m.discoveryLoading {
+		t.Error("the cancelled run's result ended the loading of the current one")
+	}
+	if len(m.discoveryPorts) != 0 {
+		t.Error("expected no port selections from a cancelled run")
+	}
+}
+
 func TestHandleServicesDiscovered_Error(t *testing.T) {
 	store := &fakeConfigStore{}
 	m := &Model{
@@ -180,7 +251,7 @@ func TestHandleServicesDiscovered_Error(t *testing.T) {
 }
 
 func TestHandleClustersLoaded_SelectsCurrentContext(t *testing.T) {
-	m := &Model{uiState: StateServiceDiscovery, discoveryLoading: true}
+	m := &Model{uiState: StateServiceDiscovery, discoveryLoading: true, clustersPending: true}
 
 	m.handleClustersLoaded(clustersLoadedMsg{
 		clusters: []string{"ctx-a", "ctx-b", "ctx-c"},
@@ -198,8 +269,31 @@ func TestHandleClustersLoaded_SelectsCurrentContext(t *testing.T) {
 	}
 }
 
+func TestHandleClustersLoaded_ExpandsForwardTemplates(t *testing.T) {
+	m, _ := newTestModel(t)
+	store := m.configStore.(*config.SQLiteConfigStore)
+	tmpl := config.PortForwardConfig{ID: "payments.api", Context: "*-staging", Namespace: "payments", Service: "api", PortRemote: 80, PortLocal: 8080}
+	if err := store.SetForwardTemplate(tmpl); err != nil {
+		t.Fatal(err)
+	}
+
+	// A background listing (startup, or a new kubeconfig) expands templates
+	m.handleClustersLoaded(clustersLoadedMsg{clusters: []string{"eu-staging", "prod"}})
+	if _, ok := store.GetConfigByID("eu-staging.payments.api"); !ok || store.Len() != 1 {
+		t.Fatalf("expected only eu-staging.payments.api, got %v", store.GetAll())
+	}
+	if len(m.portForwardsTable.Rows()) != 1 || !strings.Contains(m.statusMsg, "1 forwards added") {
+		t.Fatalf("table has %d rows, status %q", len(m.portForwardsTable.Rows()), m.statusMsg)
+	}
+
+	m.handleKubeconfigChanged(kubeconfigChangedMsg{clusters: []string{"eu-staging", "us-staging"}})
+	if _, ok := store.GetConfigByID("us-staging.payments.api"); !ok {
+		t.Fatal("a context added to the kubeconfig should get the template's forward")
+	}
+}
+
 func TestHandleClustersLoaded_EmptyReturnsToMain(t *testing.T) {
-	m := &Model{uiState: StateServiceDiscovery, discoveryLoading: true}
+	m := &Model{uiState: StateServiceDiscovery, discoveryLoading: true, clustersPending: true}
 
 	m.handleClustersLoaded(clustersLoadedMsg{clusters: nil})
 
@@ -210,3 +304,96 @@ func TestHandleClustersLoaded_EmptyReturnsToMain(t *testing.T) {
 		t.Error("expected an error message when no clusters are found")
 	}
 }
+
+func TestEnterServiceDiscovery_ShowsKnownClustersWhileRefreshing(t *testing.T) {
+	m := &Model{configStore: &fakeConfigStore{}, height: 24, currentContext: "ctx-b"}
+	m.handleKubeconfigChanged(kubeconfigChangedMsg{clusters: []string{"ctx-a", "ctx-b"}, current: "ctx-b"})
+
+	_, cmd := m.enterServiceDiscovery()
+	if cmd == nil {
+		t.Fatal("expected a background refresh of the contexts")
+	}
+	if m.discoveryLoading || len(m.discoveryClusters) != 2 || m.discoverySelectedCluster != 1 {
+		t.Fatalf("expected the known contexts right away with ctx-b selected, got %v / %d (loading %v)",
+			m.discoveryClusters, m.discoverySelectedCluster, m.discoveryLoading)
+	}
+
+	// The refresh adds a context and keeps the highlighted one
+	m.discoveryTable.SetCursor(0)
+	m.handleClustersLoaded(clustersLoadedMsg{clusters: []string{"ctx-0", "ctx-a", "ctx-b"}, current: "ctx-b"})
+	if len(m.discoveryClusters) != 3 || m.discoveryClusters[m.discoveryTable.Cursor()] != "ctx-a" {
+		t.Fatalf("expected the refreshed list with ctx-a still highlighted, got %v / %d", m.discoveryClusters, m.discoveryTable.Cursor())
+	}
+
+	// A refresh arriving once service discovery started leaves it alone
+	m.discoveryLoading = true
+	m.handleClustersLoaded(clustersLoadedMsg{clusters: []string{"ctx-a"}, current: "ctx-a"})
+	if !m.discoveryLoading || len(m.discoveryClusters) != 3 {
+		t.Fatal("a background refresh must not interrupt service discovery")
+	}
+}
+
+func TestHandleKubeconfigChanged_WarnsAndRefreshesClusterList(t *testing.T) {
+	m := &Model{uiState: StateServiceDiscovery, discoveryPhase: PhaseClusterSelection}
+	m.handleClustersLoaded(clustersLoadedMsg{clusters: []string{"ctx-a", "ctx-b"}, current: "ctx-a"})
+	m.statusMsg = ""
+
+	m.handleKubeconfigChanged(kubeconfigChangedMsg{clusters: []string{"ctx-a", "ctx-b", "ctx-c"}, current: "ctx-c"})
+
+	if m.currentContext != "ctx-c" {
+		t.Errorf("expected current context ctx-c, got %q", m.currentContext)
+	}
+	if !strings.Contains(m.statusMsg, "ctx-a → ctx-c") {
+		t.Errorf("expected a context-switch warning, got %q", m.statusMsg)
+	}
+	if len(m.discoveryClusters) != 3 || m.discoverySelectedCluster != 2 {
+		t.Errorf("expected refreshed list with ctx-c selected, got %v / %d", m.discoveryClusters, m.discoverySelectedCluster)
+	}
+}
+
+func TestHandleKubeconfigChanged_QuietOnFirstReadAndUnchangedContext(t *testing.T) {
+	m := &Model{uiState: StatePortForwards}
+
+	m.handleKubeconfigChanged(kubeconfigChangedMsg{current: "ctx-a"})
+	m.handleKubeconfigChanged(kubeconfigChangedMsg{current: "ctx-a"}) // file rewritten, same context
+
+	if m.statusMsg != "" {
+		t.Errorf("expected no warning, got %q", m.statusMsg)
+	}
+	if m.currentContext != "ctx-a" {
+		t.Errorf("expected current context ctx-a, got %q", m.currentContext)
+	}
+}
+
+// Deselecting several existing ports removes each of them, stopping the
+// running ones first; positions in the store shift as configs are removed.
+func TestServiceSelectionConfirm_RemovesDeselectedByID(t *testing.T) {
+	var cfgs []config.PortForwardConfig
+	for _, port := range []int{8080, 9090} {
+		cfgs = append(cfgs, config.PortForwardConfig{ID: fmt.Sprintf("ctx1.default.api-%d", port), Context: "ctx1", Namespace: "default", Service: "api", PortRemote: port, PortLocal: port})
+	}
+	m, _ := newTestModel(t, cfgs...)
+	store := m.configStore.(*config.SQLiteConfigStore)
+	var stopped []string
+	store.SetBeforeDelete(func(id string) error {
+		stopped = append(stopped, id)
+		return nil
+	})
+	m.uiState, m.discoveryClusters = StateServiceDiscovery, []string{"ctx1"}
+	result := newDiscoveryResult("ctx1", "default", "api",
+		discovery.ServicePort{Port: 8080, Protocol: "TCP"},
+		discovery.ServicePort{Port: 9090, Protocol: "TCP"},
+	)
+	m.handleServicesDiscovered(servicesDiscoveredMsg{cluster: "ctx1", result: result})
+	for i := range m.discoveryPorts {
+		m.discoveryPorts[i].Selected = false
+	}
+	m.handleServiceSelectionConfirm()
+
+	if left := store.GetAll(); len(left) != 0 {
+		t.Fatalf("expected both forwards removed, %d left", len(left))
+	}
+	if len(stopped) != 2 {
+		t.Fatalf("expected the store to stop both forwards first, got %v", stopped)
+	}
+}
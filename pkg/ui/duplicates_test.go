@@ -0,0 +1,26 @@
+package ui
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDuplicateProbeCadence(t *testing.T) {
+	m, _ := newTestModel(t)
+	now := time.Now()
+	if !m.startDuplicateProbe(now) {
+		t.Fatal("the first scan is not started")
+	}
+	later := now.Add(duplicateProbeInterval)
+	if m.startDuplicateProbe(later) {
+		t.Error("a scan is started while one is in flight")
+	}
+
+	m.Update(duplicateProbeMsg(false))
+	if m.startDuplicateProbe(now.Add(statusRefreshInterval)) {
+		t.Error("a scan is started before duplicateProbeInterval has passed")
+	}
+	if !m.startDuplicateProbe(later) {
+		t.Error("no scan is started once duplicateProbeInterval has passed")
+	}
+}
@@ -28,6 +28,9 @@ func TestCommitPortEditWithActiveFilterUpdatesList(t *testing.T) {
 	if err := store.Add(cfg); err != nil {
 		t.Fatalf("failed to add config: %v", err)
 	}
+	if err := store.CreateProject("shop", []string{cfg.ID}); err != nil {
+		t.Fatal(err)
+	}
 
 	filterInput := textinput.New()
 	filterInput.SetValue("web") // filter on the service name, not the port
@@ -36,12 +39,12 @@ func TestCommitPortEditWithActiveFilterUpdatesList(t *testing.T) {
 	editInput.SetValue("9090")
 
 	m := &Model{
-		configStore:     store,
-		portForwarder:   k8s.NewPortForwarder(),
-		filterInput:     filterInput,
-		editInput:       editInput,
-		editMode:        true,
-		editConfigIndex: 0,
+		configStore:   store,
+		portForwarder: k8s.NewPortForwarder(),
+		filterInput:   filterInput,
+		editInput:     editInput,
+		editMode:      true,
+		editConfigID:  cfg.ID,
 	}
 	m.applyFilter() // populate the (soon-to-be-stale) cache, as typing would
 
@@ -53,6 +56,11 @@ func TestCommitPortEditWithActiveFilterUpdatesList(t *testing.T) {
 		t.Fatalf("store should have port 9090, got ok=%v port=%d", ok, updated.PortLocal)
 	}
 
+	// ...updated in place, so its project still lists it...
+	if projects := store.GetAllProjects(); len(projects) != 1 || len(projects[0].Forwards) != 1 || projects[0].Forwards[0] != cfg.ID {
+		t.Fatalf("the edit must keep the forward in its project, projects = %+v", projects)
+	}
+
 	// ...and the filtered cache that drives the visible list must reflect it.
 	if len(m.filteredConfigs) != 1 {
 		t.Fatalf("expected 1 filtered config, got %d", len(m.filteredConfigs))
@@ -61,3 +69,56 @@ func TestCommitPortEditWithActiveFilterUpdatesList(t *testing.T) {
 		t.Fatalf("filtered list still shows stale port %d, want 9090", m.filteredConfigs[0].PortLocal)
 	}
 }
+
+// A forward added while the port is typed (by `kprtfwd import` in another
+// terminal, say) shifts the list; the edit must still reach the forward it
+// was started on.
+func TestCommitPortEditAfterListChanged(t *testing.T) {
+	web := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080}
+	m, _ := newTestModel(t, web)
+	store := m.configStore
+	m.editInput.SetValue("9090")
+	m.editMode, m.editConfigID = true, web.ID
+
+	api := config.PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8081}
+	if err := store.Add(api); err != nil { // sorts before web
+		t.Fatal(err)
+	}
+	_, _ = m.commitPortEdit()
+
+	if got, _ := store.GetConfigByID(web.ID); got.PortLocal != 9090 {
+		t.Errorf("edited forward has port %d, want 9090", got.PortLocal)
+	}
+	if got, _ := store.GetConfigByID(api.ID); got.PortLocal != 8081 {
+		t.Errorf("the forward added meanwhile was edited: port %d", got.PortLocal)
+	}
+}
+
+func TestParseLocalPorts(t *testing.T) {
+	tests := []struct {
+		input        string
+		currentCount int
+		wantFirst    int
+		wantCount    int
+		wantErr      bool
+	}{
+		{"9090", 1, 9090, 1, false},
+		{"19000", 6, 19000, 6, false}, // a range moves as a whole
+		{"9000-9005", 1, 9000, 6, false},
+		{"9000-9000", 6, 9000, 1, false},
+		{"9005-9000", 1, 0, 0, true},
+		{"65535", 2, 0, 0, true}, // range would run past 65535
+		{"0", 1, 0, 0, true},
+		{"web", 1, 0, 0, true},
+	}
+	for _, tt := range tests {
+		first, count, err := parseLocalPorts(tt.input, tt.currentCount)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseLocalPorts(%q, %d) error = %v, wantErr %v", tt.input, tt.currentCount, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && (first != tt.wantFirst || count != tt.wantCount) {
+			t.Errorf("parseLocalPorts(%q, %d) = %d, %d; want %d, %d", tt.input, tt.currentCount, first, count, tt.wantFirst, tt.wantCount)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+// errorAdvice pairs each failure kprtfwd recognises with a short explanation
+// and the action most likely to fix it. Checked in order.
+var errorAdvice = []struct {
+	kind    error
+	message string
+}{
+	{kubectl.ErrAuthExpired, "credentials expired or rejected - log in to the cluster again (e.g. refresh your SSO/cloud token), then Ctrl+R"},
+	{kubectl.ErrContextNotFound, "kube context not found - check 'kubectl config get-contexts' and your KUBECONFIG"},
+	{kubectl.ErrTargetMissing, "service, pod or workload no longer exists in the cluster - delete the forward, or run 'kprtfwd prune' to remove stale services"},
+	{kubectl.ErrForbidden, "not permitted by the cluster's RBAC rules - ask the cluster admin for access (see the log for kubectl's message)"},
+	{kubectl.ErrTimeout, "kubectl timed out - check VPN/connectivity, or raise it with 'kprtfwd settings set kubectl.timeout 60s'"},
+	{k8s.ErrNetworkUnreachable, "VPN not connected - an address this context requires (network.require setting) is unreachable; connect, then retry"},
+	{k8s.ErrPortInUse, "local port already in use - press e to pick another port or stop the other process"},
+	{k8s.ErrLocalPortReserved, "local port is used by another forward - press e to pick another port"},
+}
+
+// friendlyError turns err into a user-facing message with a suggested action
+// when it is a known failure, falling back to the raw error text. The raw text
+// is always in the log file.
+func friendlyError(err error) string {
+	if errors.Is(err, kubectl.ErrForbidden) {
+		if perm, ok := kubectl.MissingPermission(err.Error()); ok {
+			return rbacAdvice(perm)
+		}
+	}
+	for _, advice := range errorAdvice {
+		if errors.Is(err, advice.kind) {
+			return advice.message
+		}
+	}
+	return err.Error()
+}
+
+// friendlyReason is friendlyError for a failure recorded as text, such as a
+// forward's error reason; ok is false if the failure is not recognised
+func friendlyReason(reason string) (message string, ok bool) {
+	if kubectl.KindOf(reason) == nil {
+		return "", false
+	}
+	return friendlyError(kubectl.Classify(errors.New(reason), reason)), true
+}
+
+// rbacAdvice names the permission a Forbidden error lacks and the built-in
+// role that grants it
+func rbacAdvice(perm kubectl.Permission) string {
+	where := "in namespace " + perm.Namespace
+	binding := "a RoleBinding"
+	if perm.Namespace == "" {
+		where = "cluster-wide"
+		binding = "a ClusterRoleBinding"
+	}
+	return fmt.Sprintf("missing permission %s - ask for the '%s' role %s (%s for user %s)", perm, perm.SuggestedRole(), where, binding, perm.User)
+}
@@ -0,0 +1,37 @@
+package ui
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+)
+
+func TestFriendlyErrorExplainsMissingPermission(t *testing.T) {
+	stderr := `error: error upgrading connection: pods "api-7d9" is forbidden: User "dev" cannot create resource "pods/portforward" in API group "" in the namespace "team-b"`
+	err := kubectl.Classify(errors.New("kubectl exited: "+stderr), stderr)
+
+	got := friendlyError(err)
+	for _, want := range []string{"missing permission create pods/portforward in namespace team-b", "'edit' role", "user dev"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("friendlyError = %q, want it to contain %q", got, want)
+		}
+	}
+
+	reason, ok := friendlyReason(stderr)
+	if !ok || reason != got {
+		t.Errorf("friendlyReason = %q, %v, want %q", reason, ok, got)
+	}
+	if _, ok := friendlyReason("something unexpected"); ok {
+		t.Error("unrecognised reasons must be reported as such")
+	}
+}
+
+func TestFriendlyErrorForbiddenWithoutReason(t *testing.T) {
+	stderr := `Error from server (Forbidden): services "api" is forbidden`
+	got := friendlyError(kubectl.Classify(errors.New(stderr), stderr))
+	if !strings.Contains(got, "RBAC") {
+		t.Errorf("friendlyError = %q, want the generic RBAC advice", got)
+	}
+}
@@ -0,0 +1,114 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// expiryChoices are the entries of the Run for... action
+var expiryChoices = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"30 minutes", 30 * time.Minute},
+	{"1 hour", time.Hour},
+	{"2 hours", 2 * time.Hour},
+	{"4 hours", 4 * time.Hour},
+	{"8 hours", 8 * time.Hour},
+}
+
+// expiryActions lists one entry per time limit
+func expiryActions() []rowAction {
+	var actions []rowAction
+	for _, choice := range expiryChoices {
+		actions = append(actions, rowAction{label: choice.label, run: func(m *Model, cfg config.PortForwardConfig) {
+			m.runForwardFor(cfg, choice.duration)
+		}})
+	}
+	return actions
+}
+
+// runForwardFor starts cfg, unless it runs already, and stops it again after
+// d. Stopping it by hand ends the time limit; setting another replaces it.
+func (m *Model) runForwardFor(cfg config.PortForwardConfig, d time.Duration) {
+	if !m.isForwardActive(cfg.ID) {
+		// Exposing a service needs the second Space of a normal start
+		if warning := m.exposureWarning(cfg); warning != "" {
+			m.errorMsg = warning + "; start it with Space first, then set the time limit"
+			return
+		}
+		delete(m.snoozedUntil, cfg.ID)
+		if err := m.portForwarder.Start(cfg); err != nil {
+			m.errorMsg = fmt.Sprintf("Cannot start %s: %s", cfg.Service, friendlyError(err))
+			m.refreshTable()
+			return
+		}
+	}
+	if m.expiresAt == nil {
+		m.expiresAt = make(map[string]time.Time)
+	}
+	until := time.Now().Add(d)
+	m.expiresAt[cfg.ID] = until
+	m.statusMsg = fmt.Sprintf("%s stops itself at %s", cfg.Service, until.Format("15:04"))
+	m.refreshTable()
+}
+
+// clearExpiry lets cfg run on without a time limit
+func (m *Model) clearExpiry(cfg config.PortForwardConfig) {
+	delete(m.expiresAt, cfg.ID)
+	m.statusMsg = fmt.Sprintf("%s runs on until you stop it", cfg.Service)
+	m.refreshTable()
+}
+
+// expireForwards stops the forwards whose time limit is over at now. A
+// failed forward keeps its limit, as auto-restart may bring it back, and so
+// does a snoozed one; one deleted or stopped by other means is forgotten.
+func (m *Model) expireForwards(now time.Time) {
+	for id, until := range m.expiresAt {
+		cfg, ok := m.configStore.GetConfigByID(id)
+		s := m.portForwarder.State(id)
+		if !ok || !(s.Running || s.Standby || s.Queued || s.Failed || !m.snoozedUntil[id].IsZero()) {
+			delete(m.expiresAt, id)
+			continue
+		}
+		if now.Before(until) {
+			continue
+		}
+		delete(m.expiresAt, id)
+		delete(m.snoozedUntil, id)
+		if err := m.portForwarder.StopFor(id, k8s.StopReasonExpired); err != nil {
+			logging.LogError("Error stopping port-forward '%s' at the end of its time limit: %v", id, err)
+			m.errorMsg = fmt.Sprintf("Cannot stop %s at the end of its time limit: %v", cfg.Service, err)
+			continue
+		}
+		m.statusMsg = fmt.Sprintf("Time limit of %s is over; stopped it", cfg.Service)
+	}
+}
+
+// timeLeft renders how long a forward with a time limit has left at now,
+// "" for one without
+func (m *Model) timeLeft(id string, now time.Time) string {
+	until, ok := m.expiresAt[id]
+	if !ok {
+		return ""
+	}
+	return leftText(until.Sub(now))
+}
+
+// leftText renders a remaining time for the STATUS column: "45s", "12m",
+// "1h05m"
+func leftText(d time.Duration) string {
+	d = max(d, 0)
+	switch {
+	case d < time.Minute:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+	}
+}
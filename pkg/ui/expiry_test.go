@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+)
+
+func TestRunForwardForStopsItAtTheEnd(t *testing.T) {
+	useSleepingKubectl(t)
+	cfg := testForward(t, "ctx", "api")
+	m, pf := newTestModel(t, cfg)
+
+	m.runForwardFor(cfg, 2*time.Hour)
+	if !pf.IsRunning(cfg.ID) {
+		t.Fatalf("a stopped forward must be started: %s", m.errorMsg)
+	}
+	until := m.expiresAt[cfg.ID]
+	if cell := m.statusCell(cfg.ID, StatusRunning, pf.State(cfg.ID)); !strings.Contains(cell, "left") {
+		t.Errorf("STATUS cell = %q, want the time left", cell)
+	}
+
+	m.expireForwards(until.Add(-time.Second))
+	if !pf.IsRunning(cfg.ID) {
+		t.Fatal("the forward stopped before its time was up")
+	}
+	m.expireForwards(until)
+	if pf.IsRunning(cfg.ID) {
+		t.Fatal("the forward did not stop at the end of its time limit")
+	}
+	if s := pf.State(cfg.ID); s.StopReason != k8s.StopReasonExpired {
+		t.Errorf("stop reason = %q, want %q", s.StopReason, k8s.StopReasonExpired)
+	}
+	if _, ok := m.expiresAt[cfg.ID]; ok {
+		t.Error("the time limit outlived the forward")
+	}
+}
+
+func TestLeftText(t *testing.T) {
+	tests := []struct {
+		d    time.Duration
+		want string
+	}{
+		{-time.Second, "0s"},
+		{45 * time.Second, "45s"},
+		{12*time.Minute + 30*time.Second, "12m"},
+		{time.Hour + 5*time.Minute, "1h05m"},
+		{2 * time.Hour, "2h00m"},
+	}
+	for _, tt := range tests {
+		if got := leftText(tt.d); got != tt.want {
+			t.Errorf("leftText(%s) = %q, want %q", tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Typing in filter mode filters once the keystrokes pause, and only when the
+// text changed in a way the filter sees
+func TestFilterTypingIsDebounced(t *testing.T) {
+	m, _ := newTestModel(t,
+		config.PortForwardConfig{ID: "dev.ns.api", Context: "dev", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080},
+		config.PortForwardConfig{ID: "dev.ns.web", Context: "dev", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8081},
+	)
+	m.refreshTable()
+
+	typeKey := func(key tea.KeyMsg) {
+		t.Helper()
+		m.Update(key)
+	}
+	typeKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("/")})
+	for _, r := range "api" {
+		typeKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	if m.filteredConfigs != nil || len(m.portForwardsTable.Rows()) != 2 {
+		t.Fatalf("nothing should be filtered before the debounce, have %d rows", len(m.portForwardsTable.Rows()))
+	}
+
+	m.Update(filterDebounceMsg(1)) // the first keystroke's, overtaken by the others
+	if m.filteredConfigs != nil {
+		t.Fatal("a stale debounce must not filter")
+	}
+	m.Update(filterDebounceMsg(m.filterSeq))
+	if len(m.filteredConfigs) != 1 || len(m.portForwardsTable.Rows()) != 1 {
+		t.Fatalf("the last debounce should filter to api, have %+v", m.filteredConfigs)
+	}
+
+	// A trailing space changes nothing the filter matches
+	seq := m.filterSeq
+	typeKey(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if m.filterSeq != seq {
+		t.Error("a keystroke that leaves the filter as it is should not schedule another pass")
+	}
+
+	// Enter applies what is still pending right away
+	typeKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	typeKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	typeKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	typeKey(tea.KeyMsg{Type: tea.KeyBackspace})
+	typeKey(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	typeKey(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.filterMode || len(m.filteredConfigs) != 1 || m.filteredConfigs[0].ID != "dev.ns.web" {
+		t.Errorf("Enter should apply the filter, have %+v", m.filteredConfigs)
+	}
+}
@@ -0,0 +1,378 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// finderKind is what a finder result points at; the order is the tie-break
+// between equally good matches.
+type finderKind int
+
+const (
+	finderForward finderKind = iota
+	finderProject
+	finderDiscovered
+)
+
+// finderKindNames are the tags shown in front of finder results
+var finderKindNames = map[finderKind]string{
+	finderForward:    "forward",
+	finderProject:    "project",
+	finderDiscovered: "discovered",
+}
+
+// finderItem is one searchable entry of the global finder
+type finderItem struct {
+	kind   finderKind
+	id     string // forward ID, project name or discovered port's GeneratedID
+	label  string // the text the query is matched against
+	detail string // shown after the label, not searched
+	score  int
+}
+
+// fuzzyScore matches query against text case-insensitively: every
+// space-separated word of query must appear in text as a subsequence.
+// Consecutive characters and characters at the start of a word ("/", "-",
+// ".", ":" or a space before them) score higher.
+func fuzzyScore(query, text string) (int, bool) {
+	t := []rune(strings.ToLower(text))
+	total := 0
+	for _, word := range strings.Fields(strings.ToLower(query)) {
+		q := []rune(word)
+		score, qi, prev := 0, 0, -2
+		for ti, r := range t {
+			if qi == len(q) {
+				break
+			}
+			if r != q[qi] {
+				continue
+			}
+			score++
+			if ti == prev+1 {
+				score += 5
+			}
+			if ti == 0 || strings.ContainsRune("/-.: ", t[ti-1]) {
+				score += 3
+			}
+			prev = ti
+			qi++
+		}
+		if qi < len(q) {
+			return 0, false
+		}
+		total += score
+	}
+	return total, true
+}
+
+// finderItems returns everything the finder searches: the stored forwards,
+// the projects and the ports of the last discovery run.
+func (m *Model) finderItems() []finderItem {
+	var items []finderItem
+	for _, cfg := range m.configStore.GetAll() {
+		items = append(items, finderItem{
+			kind:   finderForward,
+			id:     cfg.ID,
+			label:  fmt.Sprintf("%s/%s/%s:%s", cfg.Context, cfg.Namespace, cfg.Service, formatPorts(cfg.PortRemote, cfg.Ports())),
+			detail: "localhost:" + formatPorts(cfg.PortLocal, cfg.Ports()),
+		})
+	}
+	// Enter activates a project, so each tells what that would start and
+	// stop; "All Projects" goes back to every forward
+	if m.configStore.GetActiveProjectName() != "" {
+		items = append(items, finderItem{
+			kind:   finderProject,
+			label:  "All Projects",
+			detail: m.projectSwitchPreview(nil),
+		})
+	}
+	for _, project := range m.configStore.GetAllProjects() {
+		items = append(items, finderItem{
+			kind:   finderProject,
+			id:     project.Name,
+			label:  project.Name,
+			detail: fmt.Sprintf("%d forward(s), %s", len(project.Forwards), m.projectSwitchPreview(&project)),
+		})
+	}
+	if recent := m.recentDiscovery; recent != nil && recent.result != nil {
+		for _, svc := range recent.result.Services {
+			for _, port := range svc.ServiceInfo.Ports {
+				items = append(items, finderItem{
+					kind:   finderDiscovered,
+					id:     generateServicePortID(recent.cluster, svc.ServiceInfo, port),
+					label:  fmt.Sprintf("%s/%s/%s:%d", recent.cluster, svc.ServiceInfo.Namespace, svc.ServiceInfo.Name, port.Port),
+					detail: svc.ServiceInfo.Type,
+				})
+			}
+		}
+	}
+	return items
+}
+
+// projectSwitchPreview tells what activating project would do to the
+// forwards, or going back to all of them for nil: activation stops every
+// running forward, then starts the project's, except those listening
+// outside loopback, which need a confirmation each.
+func (m *Model) projectSwitchPreview(project *config.Project) string {
+	if project != nil && project.Name == m.configStore.GetActiveProjectName() {
+		return "active"
+	}
+	members := make(map[string]bool)
+	if project != nil {
+		for _, id := range project.Forwards {
+			members[id] = true
+		}
+	}
+	start, restart, stop := 0, 0, 0
+	for _, cfg := range m.configStore.GetAll() {
+		up := m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID)
+		switch {
+		case members[cfg.ID] && up:
+			restart++
+		case members[cfg.ID] && m.exposureWarning(cfg) == "":
+			start++
+		case up:
+			stop++
+		}
+	}
+	preview := fmt.Sprintf("stops %d", stop)
+	if project != nil {
+		preview = fmt.Sprintf("starts %d, stops %d", start, stop)
+	}
+	if restart > 0 {
+		preview += fmt.Sprintf(", restarts %d", restart)
+	}
+	return preview
+}
+
+// enterFinder opens the global finder (Ctrl+F)
+func (m *Model) enterFinder() (tea.Model, tea.Cmd) {
+	m.finderInput = textinput.New()
+	m.finderInput.Placeholder = "Search forwards, projects and discovered services..."
+	m.finderInput.CharLimit = 156
+	m.finderInput.Width = max(m.width-14, 20)
+	m.finderInput.Focus()
+	m.errorMsg = ""
+	m.statusMsg = ""
+	m.uiState = StateFinder
+	m.refreshFinder()
+	return m, textinput.Blink
+}
+
+// refreshFinder re-runs the query, best matches first
+func (m *Model) refreshFinder() {
+	query := m.finderInput.Value()
+	m.finderResults = nil
+	for _, item := range m.finderItems() {
+		if score, ok := fuzzyScore(query, item.label); ok {
+			item.score = score
+			m.finderResults = append(m.finderResults, item)
+		}
+	}
+	sort.SliceStable(m.finderResults, func(i, j int) bool {
+		a, b := m.finderResults[i], m.finderResults[j]
+		if a.score != b.score {
+			return a.score > b.score
+		}
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		return utf8.RuneCountInString(a.label) < utf8.RuneCountInString(b.label)
+	})
+	m.finderCursor = 0
+}
+
+// updateFinder handles keys in the global finder
+func (m *Model) updateFinder(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.uiState = StatePortForwards
+		return m, nil
+	case "enter":
+		if m.finderCursor < len(m.finderResults) {
+			m.runFinderResult(m.finderResults[m.finderCursor])
+		}
+		return m, nil
+	case "ctrl+p":
+		// A project in the project selector instead of activating it
+		if m.finderCursor < len(m.finderResults) && m.finderResults[m.finderCursor].kind == finderProject {
+			m.jumpToFinderResult(m.finderResults[m.finderCursor])
+		}
+		return m, nil
+	case "up", "ctrl+k":
+		m.finderCursor = max(m.finderCursor-1, 0)
+		return m, nil
+	case "down", "ctrl+j":
+		m.finderCursor = min(m.finderCursor+1, max(len(m.finderResults)-1, 0))
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		m.finderInput, cmd = m.finderInput.Update(msg)
+		m.refreshFinder()
+		return m, cmd
+	}
+}
+
+// runFinderResult activates a project result and jumps to any other
+func (m *Model) runFinderResult(item finderItem) {
+	if item.kind != finderProject {
+		m.jumpToFinderResult(item)
+		return
+	}
+	switch {
+	case item.id == "":
+		m.stopAllRunningPortForwards()
+		m.configStore.ClearActiveProject()
+		m.statusMsg = "Showing all port forwards (all running forwards stopped)"
+		m.refreshTable()
+		m.uiState = StatePortForwards
+	case item.id == m.configStore.GetActiveProjectName():
+		m.statusMsg = fmt.Sprintf("Project '%s' is active already", item.id)
+		m.uiState = StatePortForwards
+	default:
+		for _, project := range m.configStore.GetAllProjects() {
+			if project.Name == item.id {
+				m.beginProjectActivation(project)
+				return
+			}
+		}
+		m.errorMsg = fmt.Sprintf("Project '%s' no longer exists", item.id)
+		m.uiState = StatePortForwards
+	}
+}
+
+// jumpToFinderResult opens the view that lists item with the cursor on it
+func (m *Model) jumpToFinderResult(item finderItem) {
+	switch item.kind {
+	case finderForward:
+		m.jumpToForward(item.id)
+	case finderProject:
+		m.enterProjectSelector() // "All Projects", the empty ID, is row 0
+		for i, project := range m.configStore.GetAllProjects() {
+			if project.Name == item.id {
+				m.projectSelector.SetCursor(i + 1) // row 0 is "All Projects"
+			}
+		}
+	case finderDiscovered:
+		m.jumpToDiscovered(item.id)
+	}
+}
+
+// jumpToForward selects the forward in the main table, clearing a filter
+// that hides it and expanding its group. Forwards outside the active project
+// are not listed at all; switching projects would stop forwards, so that is
+// left to the user.
+func (m *Model) jumpToForward(id string) {
+	m.uiState = StatePortForwards
+	isTarget := func(c config.PortForwardConfig) bool { return c.ID == id }
+
+	if !slices.ContainsFunc(m.configStore.GetActiveProjectForwards(), isTarget) {
+		m.errorMsg = fmt.Sprintf("%s is not in the active project '%s' (Ctrl+P: All Projects)", id, m.configStore.GetActiveProjectName())
+		return
+	}
+	if !slices.ContainsFunc(m.listedConfigs(), isTarget) {
+		m.filterInput.SetValue("")
+		m.filteredConfigs = nil
+	}
+
+	all := m.configStore.GetAll()
+	idx := slices.IndexFunc(all, isTarget)
+	if m.groupingEnabled {
+		group := all[idx].Context
+		if group == "" {
+			group = "(no context)"
+		}
+		if state, exists := m.groupStates[group]; exists {
+			m.touchGroup(group)
+			state.Expanded = true
+		}
+	}
+	m.refreshTable()
+
+	if m.groupingEnabled {
+		for i, row := range m.tableRows {
+			if row.Type == RowTypeItem && row.ConfigIndex == idx {
+				m.portForwardsTable.SetCursor(i)
+			}
+		}
+	} else {
+		m.portForwardsTable.SetCursor(slices.IndexFunc(m.listedConfigs(), isTarget))
+	}
+}
+
+// jumpToDiscovered reopens the last discovery results, rechecked against
+// the current configuration, with the cursor on the port with the given ID
+func (m *Model) jumpToDiscovered(id string) {
+	recent := *m.recentDiscovery
+	m.uiState = StateServiceDiscovery
+	m.errorMsg = ""
+	m.statusMsg = ""
+	m.initDiscoveryInputs()
+
+	m.discoverySelectedCluster = slices.Index(m.discoveryClusters, recent.cluster)
+	if m.discoverySelectedCluster == -1 {
+		m.discoveryClusters = append(m.discoveryClusters, recent.cluster)
+		m.discoverySelectedCluster = len(m.discoveryClusters) - 1
+	}
+	m.handleServicesDiscovered(recent)
+
+	for i, port := range m.discoveryPorts {
+		if port.GeneratedID == id {
+			m.discoveryTable.SetCursor(i)
+		}
+	}
+}
+
+// renderFinder renders the global finder
+func (m *Model) renderFinder() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	b.WriteString(titleStyle.Render("🔎 Find"))
+	b.WriteString("\n\n")
+
+	inputStyle := boxStyle(ColorBorder)
+	b.WriteString(inputStyle.Render("Find: " + m.finderInput.View()))
+	b.WriteString("\n\n")
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	selected := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorSelectedFg)).
+		Background(lipgloss.Color(ColorSelectedBg)).Reverse(monoTheme)
+
+	// Keep the cursor in the visible window of results
+	visible := max(m.height-FinderViewOffset, MinTableHeight)
+	start := max(m.finderCursor-visible+1, 0)
+	end := min(start+visible, len(m.finderResults))
+	if len(m.finderResults) == 0 {
+		b.WriteString(helpStyle.Render("No matches"))
+		b.WriteString("\n")
+	}
+	for i := start; i < end; i++ {
+		item := m.finderResults[i]
+		line := fmt.Sprintf("%-10s  %s", finderKindNames[item.kind], item.label)
+		if i == m.finderCursor {
+			b.WriteString("▶ " + selected.Render(line) + "  " + helpStyle.Render(item.detail))
+		} else {
+			b.WriteString("  " + line + "  " + helpStyle.Render(item.detail))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	b.WriteString(helpStyle.Render(fmt.Sprintf("%d match(es) | %s", len(m.finderResults), ActionFinder)))
+	b.WriteString("\n")
+	return b.String()
+}
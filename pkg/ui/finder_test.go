@@ -0,0 +1,101 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	if _, ok := fuzzyScore("pmt api", "prod/payments/api:8080"); !ok {
+		t.Error("every query word should match as a subsequence")
+	}
+	if _, ok := fuzzyScore("api pmt x", "prod/payments/api:8080"); ok {
+		t.Error("a word without a match should reject the text")
+	}
+	if score, ok := fuzzyScore("", "anything"); !ok || score != 0 {
+		t.Errorf("an empty query should match everything with score 0, got %d, %v", score, ok)
+	}
+	consecutive, _ := fuzzyScore("web", "ctx/ns/web:80")
+	scattered, _ := fuzzyScore("web", "ctx/wide-eb:80")
+	if consecutive <= scattered {
+		t.Errorf("consecutive match scored %d, scattered %d", consecutive, scattered)
+	}
+}
+
+func TestFinderJumpsToResults(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	m, _ := newTestModel(t,
+		config.PortForwardConfig{ID: "a.ns.db", Context: "a", Namespace: "ns", Service: "db", PortRemote: 5432, PortLocal: 5432},
+		config.PortForwardConfig{ID: "b.ns.web", Context: "b", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080},
+	)
+	store := m.configStore
+	if err := store.CreateProject("backend", []string{"a.ns.db"}); err != nil {
+		t.Fatal(err)
+	}
+	m.groupingEnabled = true
+	m.recentDiscovery = &servicesDiscoveredMsg{
+		cluster: "b",
+		result:  newDiscoveryResult("b", "ns", "cache", discovery.ServicePort{Port: 6379, Protocol: "TCP"}),
+	}
+
+	// A filter hides the forward and its group is collapsed
+	m.filterInput.SetValue("db")
+	m.applyFilter()
+	m.groupStates["b"] = &GroupState{Expanded: false}
+	m.refreshTable()
+
+	find := func(query string) {
+		m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+		if m.uiState != StateFinder {
+			t.Fatalf("Ctrl+F should open the finder, uiState = %d", m.uiState)
+		}
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(query)})
+		if len(m.finderResults) == 0 {
+			t.Fatalf("no results for %q", query)
+		}
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	find("b web")
+	if m.uiState != StatePortForwards || m.filterInput.Value() != "" {
+		t.Fatalf("jumping to a hidden forward should clear the filter, state %d, filter %q", m.uiState, m.filterInput.Value())
+	}
+	if idx, err := m.getConfigIndexFromTableRow(); err != nil || store.GetAll()[idx].ID != "b.ns.web" {
+		t.Fatalf("cursor should be on b.ns.web, got index %d, %v", idx, err)
+	}
+
+	// A project previews its activation, Ctrl+P shows it in the selector
+	// and Enter activates it
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlF})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("backend")})
+	if got := m.finderResults[0]; got.kind != finderProject || got.detail != "1 forward(s), starts 1, stops 0" {
+		t.Fatalf("top result = %+v", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyCtrlP})
+	if m.uiState != StateProjectSelector || m.projectSelector.Cursor() != 1 {
+		t.Fatalf("expected the project selector on backend, state %d, cursor %d", m.uiState, m.projectSelector.Cursor())
+	}
+	m.uiState = StatePortForwards
+	find("backend")
+	if m.uiState != StatePortForwards || store.GetActiveProjectName() != "backend" {
+		t.Fatalf("Enter should activate backend, state %d, active %q", m.uiState, store.GetActiveProjectName())
+	}
+	find("all proj")
+	if store.GetActiveProjectName() != "" {
+		t.Fatalf("All Projects should clear the active project, got %q", store.GetActiveProjectName())
+	}
+
+	m.uiState = StatePortForwards
+	find("cache")
+	if m.uiState != StateServiceDiscovery || m.discoveryPhase != PhaseServiceSelection {
+		t.Fatalf("expected the service selection of the last discovery, state %d", m.uiState)
+	}
+	if port := m.discoveryPorts[m.discoveryTable.Cursor()]; port.ServiceName != "cache" {
+		t.Fatalf("cursor should be on cache, got %s", port.ServiceName)
+	}
+}
@@ -0,0 +1,68 @@
+package ui
+
+import "strings"
+
+// helpAllKeys and helpAllKeysNarrow list every main-view key; "?" shows them
+// in the footer instead of the hints for the selected row. A bare letter
+// names the unshifted key and Shift+ the shifted one, in both lists.
+const (
+	helpAllKeys       = "Enter: Actions | Space: Toggle/Expand | E: Edit Port | Shift+E: Rewrite Ports | S: Start/Stop Group | Shift+S/Shift+K: Start/Stop Listed | G: Group Mode | O: Open URL | I: Details | Shift+I: IDs | Y: Copy ID | X: Shell | L: Logs | D: Describe | P: Pin Pod | Del: Delete | Shift+L: Latency | Z: Lazy | Shift+T: TLS | Shift+A: IPv4/IPv6 | /: Filter | 1-4: Quick Filters | Ctrl+F: Find | Ctrl+U: Prune | Ctrl+S: Panic Stop | +: Add | A: Add Form | Ctrl+P: Projects | Shift+W: Workspaces | !: Problems | Q: Quit | ?: Hints"
+	helpAllKeysNarrow = "Enter:Actions | Space:Toggle | E:Edit | Shift+E:Rewrite | S:Group | Shift+S/Shift+K:Start/Stop | G:Group | O:Open | I:Details | Shift+I:IDs | Y:Copy ID | X:Shell | L:Logs | D:Describe | P:Pin | Del:Delete | Shift+L:Latency | Z:Lazy | Shift+T:TLS | Shift+A:IPv6 | /:Filter | 1-4:Quick | Ctrl+F:Find | Ctrl+U:Prune | Ctrl+S:Panic | +:Add | A:Form | Ctrl+P:Projects | Shift+W:Workspaces | !:Problems | Q:Quit | ?:Hints"
+)
+
+// footerHelp returns the footer's key help: every key after "?", otherwise
+// the keys that make sense for the selected row
+func (m *Model) footerHelp() string {
+	if m.showAllKeys {
+		if m.width < 80 {
+			return helpAllKeysNarrow
+		}
+		return helpAllKeys
+	}
+	return strings.Join(append(m.selectionHints(), "?: All Keys"), " | ")
+}
+
+// selectionHints returns the keys worth pressing on the selected row, in its
+// current state: Space starts a stopped forward but stops a running one, o
+// only opens a running one, and so on
+func (m *Model) selectionHints() []string {
+	if len(m.portForwardsTable.Rows()) == 0 {
+		if m.filtering() {
+			return []string{"Esc: Clear Filter", "/: Edit Filter"}
+		}
+		return []string{"+: Add", "a: Add Form", "Ctrl+D: Discover", "Ctrl+P: Projects"}
+	}
+	if m.isGroupHeaderSelected() {
+		return []string{"Space: Expand/Collapse", "s: Start/Stop Group", "Enter: Group Actions", "g: Ungroup", "/: Filter"}
+	}
+	idx, err := m.getConfigIndexFromTableRow()
+	if err != nil {
+		return []string{"/: Filter"}
+	}
+	cfg, err := m.configStore.GetWithError(idx)
+	if err != nil {
+		return []string{"/: Filter"}
+	}
+
+	open := "o: Open"
+	if cfg.OpenCommand != "" {
+		open = "o: Run Open Command"
+	}
+	switch m.statusFor(cfg.ID) {
+	case StatusRunning:
+		return []string{open, "Space: Stop", "Enter: Restart & More", "y: Copy ID"}
+	case StatusDegraded:
+		return []string{"i: Details", "l: Logs", "x: Shell", "Space: Stop", "Enter: Restart & More"}
+	case StatusStandby:
+		return []string{open + " (starts kubectl)", "Space: Stop", "Enter: More"}
+	case StatusQueued:
+		return []string{"Space: Cancel Start", "Enter: More"}
+	case StatusFailed, StatusDead:
+		return []string{"Space: Retry", "i: Details", "x: Shell", "Enter: Restart & More"}
+	case StatusConflict:
+		return []string{"e: Edit Port", "Space: Retry", "i: Details"}
+	case StatusSnoozed:
+		return []string{"Space: Start Now", "Enter: More"}
+	}
+	return []string{"Space: Start", "e: Edit Port", "Enter: More"}
+}
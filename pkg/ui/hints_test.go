@@ -0,0 +1,41 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestFooterHintsFollowTheSelection(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no kubectl: every start fails
+	cfg := config.PortForwardConfig{ID: "dev.ns.api", Context: "dev", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	m, _ := newTestModel(t, cfg)
+	m.refreshTable()
+
+	if got := m.footerHelp(); got != "Space: Start | e: Edit Port | Enter: More | ?: All Keys" {
+		t.Errorf("stopped forward: footer = %q", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(" ")})
+	if got := m.footerHelp(); !strings.HasPrefix(got, "Space: Retry | i: Details") {
+		t.Errorf("failed forward: footer = %q", got)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if got := m.footerHelp(); got != helpAllKeys {
+		t.Errorf("after ?: footer = %q", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	if got := m.footerHelp(); strings.Contains(got, "Quit") {
+		t.Errorf("a second ? should bring the hints back: %q", got)
+	}
+
+	m.filterInput.SetValue("nothing matches")
+	m.applyFilter()
+	m.refreshTable()
+	if got := m.footerHelp(); !strings.HasPrefix(got, "Esc: Clear Filter") {
+		t.Errorf("empty filter result: footer = %q", got)
+	}
+}
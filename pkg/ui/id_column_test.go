@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestIDColumnToggleAndCopy(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no clipboard tool
+
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080}
+	m, _ := newTestModel(t, cfg)
+	m.groupingEnabled = true
+	m.applyColumnLayout()
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	columns := m.portForwardsTable.Columns()
+	if last := columns[len(columns)-1]; last.Title != ColID || last.Width != len(cfg.ID) {
+		t.Fatalf("last column = %+v, want an ID column as wide as %q", last, cfg.ID)
+	}
+	rows := m.portForwardsTable.Rows()
+	if len(rows) != 2 || rows[0][len(columns)-1] != "" || rows[1][len(columns)-1] != cfg.ID {
+		t.Fatalf("expected an empty ID cell for the group and the ID for the forward, got %v", rows)
+	}
+
+	// Copying a group header is refused; copying without a clipboard tool
+	// reports which ID it could not copy
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+	if m.errorMsg == "" {
+		t.Fatal("copying a group header should be refused")
+	}
+	if runtime.GOOS == "linux" {
+		m.portForwardsTable.SetCursor(1)
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("y")})
+		if m.errorMsg != "Cannot copy ctx.ns.web: no clipboard tool found (install xclip, xsel or wl-clipboard)" {
+			t.Fatalf("unexpected error: %q", m.errorMsg)
+		}
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("I")})
+	if columns := m.portForwardsTable.Columns(); columns[len(columns)-1].Title == ColID {
+		t.Fatal("a second I should hide the ID column")
+	}
+}
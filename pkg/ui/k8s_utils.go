@@ -1,38 +1,35 @@
 package ui
 
 import (
-	"bytes"
-	"context"
 	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
 	"strings"
-	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
 )
 
 // getAvailableClusters returns a list of available Kubernetes contexts
 func getAvailableClusters() ([]string, error) {
-	// Create context with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "kubectl", "config", "get-contexts", "-o", "name")
-	var stdout bytes.Buffer
-	var stderr bytes.Buffer
-	cmd.Stdout = &stdout
-	cmd.Stderr = &stderr
-
-	err := cmd.Run()
+	contexts, err := kubectl.Contexts()
 	if err != nil {
-		if ctx.Err() == context.DeadlineExceeded {
-			return nil, fmt.Errorf("kubectl get-contexts timed out after 10 seconds")
-		}
-		return nil, fmt.Errorf("kubectl get-contexts failed: %w (stderr: %s)", err, stderr.String())
+		return nil, err
 	}
-
-	contexts := strings.Fields(stdout.String())
 	if len(contexts) == 0 {
 		return nil, fmt.Errorf("no Kubernetes contexts found")
 	}
 
 	return contexts, nil
 }
+
+// displayPath shortens a path under the home directory to ~/...
+func displayPath(path string) string {
+	home, err := os.UserHomeDir()
+	if err != nil || home == "" {
+		return path
+	}
+	if rel, err := filepath.Rel(home, path); err == nil && !strings.HasPrefix(rel, "..") && !filepath.IsAbs(rel) {
+		return filepath.Join("~", rel)
+	}
+	return path
+}
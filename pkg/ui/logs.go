@@ -0,0 +1,224 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// maxLogLines is how many log lines the pane keeps; older ones scroll away
+const maxLogLines = 5000
+
+// maxLogBatch caps the lines one logLinesMsg carries, so a burst of output
+// is drawn in a few frames rather than one per line
+const maxLogBatch = 500
+
+// logPane is the screen following the logs of the pods behind a forward
+// (l). Like restartReport it keeps its state to itself.
+type logPane struct {
+	m        *Model
+	id       string         // forward whose pods are followed
+	stream   *k8s.LogStream // nil until kubectl logs started
+	lines    []string
+	follow   bool   // keep the newest line in view
+	ended    string // why the stream stopped; "" while it runs
+	viewport viewport.Model
+}
+
+// logStreamStartedMsg reports the outcome of starting the log stream of id
+type logStreamStartedMsg struct {
+	id     string
+	stream *k8s.LogStream
+	err    error
+}
+
+// logLinesMsg carries the lines stream delivered since the last one
+type logLinesMsg struct {
+	stream *k8s.LogStream
+	lines  []string
+	done   bool // kubectl exited; stream.Err says why
+}
+
+// enterLogs shows the log pane and starts following the logs of the pods
+// behind cfg
+func (m *Model) enterLogs(cfg config.PortForwardConfig) tea.Cmd {
+	m.logs.stop()
+	m.logs = logPane{m: m, id: cfg.ID, follow: true}
+	m.logs.viewport = viewport.New(m.width, m.logs.viewportHeight())
+	m.logs.viewport.SetContent("Connecting to the pods of " + cfg.Service + "...")
+	m.uiState = StateLogs
+
+	state := m.portForwarder.State(cfg.ID)
+	return func() tea.Msg {
+		stream, err := k8s.StreamLogs(cfg, state)
+		return logStreamStartedMsg{id: cfg.ID, stream: stream, err: err}
+	}
+}
+
+// waitForLogLines delivers the next lines of stream: blocks for the first,
+// then takes whatever else already arrived
+func waitForLogLines(stream *k8s.LogStream) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-stream.Lines()
+		if !ok {
+			return logLinesMsg{stream: stream, done: true}
+		}
+		lines := []string{line}
+		for len(lines) < maxLogBatch {
+			select {
+			case line, ok := <-stream.Lines():
+				if !ok {
+					return logLinesMsg{stream: stream, lines: lines, done: true}
+				}
+				lines = append(lines, line)
+			default:
+				return logLinesMsg{stream: stream, lines: lines}
+			}
+		}
+		return logLinesMsg{stream: stream, lines: lines}
+	}
+}
+
+// handleLogStreamStarted starts reading the new stream, or stops it when the
+// pane was left, or opened on another forward, in the meantime
+func (m *Model) handleLogStreamStarted(msg logStreamStartedMsg) (tea.Model, tea.Cmd) {
+	p := &m.logs
+	if msg.err != nil {
+		logging.LogError("Following logs of %s failed: %v", msg.id, msg.err)
+		if p.id == msg.id && p.stream == nil {
+			p.ended = friendlyError(msg.err)
+			p.viewport.SetContent("")
+		}
+		return m, nil
+	}
+	if m.uiState != StateLogs || p.id != msg.id || p.stream != nil {
+		msg.stream.Stop()
+		return m, nil
+	}
+	p.stream = msg.stream
+	p.viewport.SetContent("")
+	return m, waitForLogLines(msg.stream)
+}
+
+// handleLogLines appends the lines to the pane and waits for more
+func (m *Model) handleLogLines(msg logLinesMsg) (tea.Model, tea.Cmd) {
+	p := &m.logs
+	if msg.stream != p.stream {
+		return m, nil // from a stream the pane already stopped
+	}
+	p.lines = append(p.lines, msg.lines...)
+	if len(p.lines) > maxLogLines {
+		p.lines = append([]string(nil), p.lines[len(p.lines)-maxLogLines:]...)
+	}
+	p.viewport.SetContent(strings.Join(p.lines, "\n"))
+	if p.follow {
+		p.viewport.GotoBottom()
+	}
+	if msg.done {
+		p.ended = "kubectl logs exited"
+		if err := p.stream.Err(); err != nil {
+			logging.LogError("Following logs of %s stopped: %v", p.id, err)
+			p.ended = friendlyError(err)
+		}
+		return m, nil
+	}
+	return m, waitForLogLines(p.stream)
+}
+
+// stop ends the log stream, if one runs
+func (p *logPane) stop() {
+	if p.stream != nil {
+		p.stream.Stop()
+		p.stream = nil
+	}
+}
+
+// viewportHeight returns the log viewport height that fits the window under
+// the title and above the status and help lines
+func (p *logPane) viewportHeight() int {
+	return max(p.m.height-6, 3)
+}
+
+// Update handles keys in the log pane
+func (p *logPane) Update(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m := p.m
+	switch msg.String() {
+	case "esc", "q":
+		p.stop()
+		m.uiState = StatePortForwards
+		m.refreshTable()
+		return m, nil
+	case "G", "end":
+		p.viewport.GotoBottom()
+		p.follow = true
+		return m, nil
+	case "g", "home":
+		p.viewport.GotoTop()
+		p.follow = false
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		p.viewport, cmd = p.viewport.Update(msg)
+		p.follow = p.viewport.AtBottom()
+		return m, cmd
+	}
+}
+
+// Resize fits the log viewport to the window
+func (p *logPane) Resize() {
+	if p.m == nil {
+		return // never shown
+	}
+	p.viewport.Width = p.m.width
+	p.viewport.Height = p.viewportHeight()
+	if p.follow {
+		p.viewport.GotoBottom()
+	}
+}
+
+// View renders the log pane
+func (p *logPane) View() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	title := p.id
+	if cfg, exists := p.m.configStore.GetConfigByID(p.id); exists {
+		title = cfg.Service
+		if cfg.TargetKind != config.TargetKindService {
+			title = cfg.Target()
+		}
+	}
+	b.WriteString(titleStyle.Render("📜 Logs of " + title))
+	b.WriteString("\n\n")
+
+	b.WriteString(p.viewport.View())
+	b.WriteString("\n")
+
+	if p.ended != "" {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorError))
+		b.WriteString(errorStyle.Render("Stopped: " + p.ended))
+	} else if p.stream != nil {
+		state := "following"
+		if !p.follow {
+			state = "paused, G to follow"
+		}
+		b.WriteString(fmt.Sprintf("%d line(s), %s", len(p.lines), state))
+	}
+	b.WriteString("\n")
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	b.WriteString(helpStyle.Render(ActionLogs))
+	b.WriteString("\n")
+
+	return b.String()
+}
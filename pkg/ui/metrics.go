@@ -0,0 +1,28 @@
+package ui
+
+import (
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// metricsWriteInterval is how often the metrics textfile is rewritten; hosts
+// are rarely scraped more often than every 15s.
+const metricsWriteInterval = 10 * time.Second
+
+// writeMetrics rewrites the metrics.textfile file, if one is set, once
+// metricsWriteInterval has passed since the last write, or now with force.
+// Failures are logged: monitoring must never get in the way of the TUI.
+func (m *Model) writeMetrics(force bool) {
+	if m.metricsTextfile == "" || m.configStore == nil {
+		return
+	}
+	now := time.Now()
+	if !force && now.Sub(m.metricsWrittenAt) < metricsWriteInterval {
+		return
+	}
+	m.metricsWrittenAt = now
+	if err := m.portForwarder.WriteTextfileMetrics(m.metricsTextfile, m.configStore.GetAll()); err != nil {
+		logging.LogError("Failed to write metrics to %s: %v", m.metricsTextfile, err)
+	}
+}
@@ -2,6 +2,7 @@ package ui
 
 import (
 	"fmt"
+	"os"
 	"os/exec"
 	"runtime"
 	"strings"
@@ -9,12 +10,15 @@ import (
 
 	"github.com/xlttj/kprtfwd/pkg/config"
 	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
+	"github.com/muesli/termenv"
 )
 
 // // Render method for minimalDelegate - MOVED to types.go
@@ -39,21 +43,103 @@ type Model struct {
 
 	// Port forwards table
 	portForwardsTable table.Model
+	tableLoading      bool // True until the first rows are built, after the first frame
 
 	// Grouping state
 	groupStates     map[string]*GroupState // Map of group name to state
 	tableRows       []TableRow             // Enhanced rows with metadata
 	groupingEnabled bool                   // Whether grouping is enabled
+	customGroups    []config.CustomGroup   // User-defined groups from ui.group.<name> settings
+	autoCollapse    bool                   // Collapse context groups not used this session (ui.auto_collapse)
+	touchedGroups   map[string]bool        // Context groups used this session, left as the user sets them
+
+	// Metrics textfile export (metrics.textfile)
+	metricsTextfile  string    // Path written to, empty when not exporting
+	metricsWrittenAt time.Time // Last write
+
+	// Optional LATENCY column
+	showLatency    bool                     // Whether the LATENCY column is shown
+	latencyProbing bool                     // Whether a latency probe/tick chain is in flight
+	memoryWarned   map[string]bool          // IDs whose kubectl was above the memory limit at the last usage sample
+	latencies      map[string]time.Duration // Last probed round trip per config ID
+
+	// Optional ID column
+	showIDs bool // Whether the ID column is shown
+
+	// Scan for kubectl processes duplicating running forwards
+	duplicateProbing  bool      // Whether a scan is in flight
+	duplicateProbedAt time.Time // When the last scan started
+
+	// Start/stop history for `kprtfwd stats`
+	activeIDs map[string]bool // Forwards seen running or on standby at the last record
+
+	// Detail pane for the selected forward
+	showDetail bool // Whether the detail pane is shown below the table
+
+	// Footer help
+	showAllKeys bool // Whether the footer lists every key instead of hints for the selected row
+
+	// Stop confirmation for forwards with open connections
+	confirmStopID string // Forward whose stop awaits a second Space
+	// Start confirmation for forwards exposed to the local network
+	confirmStartID string // Forward whose start awaits a second Space
+	// Bulk stop confirmation for listed or grouped forwards with open connections
+	confirmBulkStop string // Key (K, or s on a group) whose stop awaits a second press
+	// Panic stop confirmation, and the last `kprtfwd stop --all` carried out
+	confirmPanicStop bool      // Whether the next Ctrl+S stops every forward
+	stopAllSeen      time.Time // Time of the last panic stop request seen
+
+	// Snoozed forwards and when they start again
+	snoozedUntil map[string]time.Time
+	// Forwards started for a while and when they stop themselves
+	expiresAt map[string]time.Time
+
+	// Status of each forward as last announced in accessible mode
+	announcedStatus map[string]string
 
 	// Filter state
-	filterMode      bool                       // Whether filtering is active
-	filterInput     textinput.Model            // The search input component
-	filteredConfigs []config.PortForwardConfig // Cached filtered results
+	filterMode         bool                       // Whether filtering is active
+	filterInput        textinput.Model            // The search input component
+	filteredConfigs    []config.PortForwardConfig // Cached filtered results
+	filteredText       string                     // Filter text filteredConfigs was computed for
+	filterSeq          int                        // Bumped per keystroke; only the last one's debounce applies
+	activeQuickFilters map[string]bool            // Keys of the quick filters turned on
 
 	// Inline editing state for local ports in main view
-	editMode        bool            // Whether we're in inline edit mode
-	editConfigIndex int             // Config index being edited
-	editInput       textinput.Model // Text input for editing local port
+	editMode     bool            // Whether we're in inline edit mode
+	editConfigID string          // Forward being edited, by ID: its index shifts when the list changes
+	editInput    textinput.Model // Text input for editing local port
+
+	// Bulk rewrite of the listed forwards' local ports
+	bulkEditMode  bool            // Whether the rewrite rule input is active
+	bulkEditInput textinput.Model // Rewrite rule ("+10000", "prefix 1")
+
+	// Extra kubectl arguments of one forward, edited from the action menu
+	argsEditMode    bool            // Whether the kubectl arguments input is active
+	argsEditID      string          // ID of the forward being edited
+	argsEditInput   textinput.Model // Whitespace-separated --flags
+	tagsEditMode    bool            // Whether the tags input is active
+	tagsEditID      string          // ID of the forward whose tags are edited
+	tagsEditInput   textinput.Model // Whitespace-separated tags
+	openEditMode    bool            // Whether the open command input is active
+	openEditID      string          // ID of the forward whose open command is edited
+	openEditInput   textinput.Model // Command template run by 'o'
+	podEditMode     bool            // Whether the pod selector input is active
+	podEditID       string          // ID of the forward whose pod selector is edited
+	podEditInput    textinput.Model // Ordinal or label selector
+	hookEditMode    bool            // Whether a hook input is active
+	hookEditID      string          // ID of the forward whose hook is edited
+	hookEditEvent   string          // config.HookEventStart or config.HookEventStop
+	hookEditInput   textinput.Model // Shell command
+	healthEditMode  bool            // Whether the health check path input is active
+	healthEditID    string          // ID of the forward whose health check path is edited
+	healthEditInput textinput.Model // URL path, e.g. /healthz
+
+	// A new forward from a pasted kubectl command or service URL (+), or
+	// from a stack template picked with Tab
+	addMode     bool
+	addInput    textinput.Model
+	addTemplate string // Stack template the input takes a namespace for; empty for a paste
 
 	// Project management state
 	projectSelector        table.Model     // Project selection table
@@ -62,21 +148,91 @@ type Model struct {
 	projectServiceTable    table.Model     // Service selection for project editing
 	currentProject         *config.Project // Project being edited
 
+	// Workspace switcher state
+	workspaceSelector  table.Model     // Workspace selection table
+	workspaceNameInput textinput.Model // Name of a new workspace
+	workspaceCreating  bool            // Whether the new workspace input is active
+
+	// Project activation waiting on local port conflict resolution
+	activation *projectActivation
+
+	// Report of the last Ctrl+R restart
+	restart restartReport
+
+	// Logs of the pods behind a forward (l)
+	logs logPane
+
+	// kubectl describe of a forward's target (d)
+	describe describePane
+
+	// Form adding a forward field by field (a)
+	addForm addForwardForm
+
+	// Configuration problems for the footer badge, and their panel (!)
+	problems      []config.LintFinding
+	problemsPanel problemsPanel
+
+	screenSet map[UIState]screen // see screens()
+
+	// Pods last seen behind the services of running forwards, to notice rollouts
+	servicePods map[serviceRef][]string
+
+	// Interactive prune (Ctrl+U) of the selected row's context
+	pruneContext string     // context scanned; "" until discovery resolves the current one
+	pruneLoading bool       // discovery still running
+	pruneRows    []pruneRow // stale forwards and what to do with them
+	pruneTable   table.Model
+	// Delete the projects the prune leaves without forwards
+	pruneDeleteEmpty bool
+
+	// Global finder (Ctrl+F); the last discovery run stays searchable
+	finderInput     textinput.Model
+	finderResults   []finderItem
+	finderCursor    int
+	recentDiscovery *servicesDiscoveredMsg
+
+	// Action menu on the selected forward (Enter)
+	actionMenuTitle  string
+	actionMenuItems  []rowAction
+	actionMenuCursor int
+	actionMenuID     string // Forward the menu was opened on
+
 	// Service discovery state
 	discoveryPhase            DiscoveryPhase
 	discoveryClusters         []string
+	discoveryClusterSources   map[string]string // Kubeconfig file per cluster
 	discoverySelectedCluster  int
+	discoveryNamespaceFilter  string
 	discoveryPorts            []PortSelection // Changed from services to individual ports
 	discoveryTable            table.Model
 	discoveryFilterInput      textinput.Model
 	discoveryFilterMode       bool
 	discoveryExistingServices map[string]bool
 	discoveryLoading          bool // True while an async kubectl discovery operation is in flight
+	discoverySpinner          spinner.Model
+	discoveryStartedAt        time.Time // When the operation in flight started
+	discoveryRun              int       // Counts discovery runs, so a cancelled one's result is dropped
+
+	// kubectl contexts as last listed, so discovery opens without waiting on
+	// kubectl; refreshed at startup, on kubeconfig changes and on each open
+	knownClusters       []string
+	knownClusterSources map[string]string
+	clustersPending     bool // discovery waits for its first context listing
 
 	// Inline editing state for local ports in discovery
 	discoveryEditMode  bool            // Whether we're in inline edit mode
 	discoveryEditIndex int             // Index of the port being edited
 	discoveryEditInput textinput.Model // Text input for editing local port
+
+	// Name prompt for saving or applying a discovery selection profile
+	discoveryProfilePrompt profilePrompt
+	discoveryProfileInput  textinput.Model
+	discoveryProfileNames  []string // saved profiles, as of opening the prompt
+
+	// Kubeconfig watching: kubectl may switch context outside kprtfwd
+	kubeconfigStamp string // kubectl.KubeconfigStamp() as last seen
+	storeStamp      string // ChangeStamp of the database as last seen (see syncStore)
+	currentContext  string // kubeconfig current-context as last read; "" until known
 }
 
 // calculateProjectSelectorColumns returns columns for project selector with dynamic widths
@@ -130,9 +286,10 @@ func (m *Model) calculateServiceSelectionColumns() []table.Column {
 	// Fixed minimums for some columns
 	minInProject := 10 // "IN PROJECT"
 	minPorts := 12     // "PORTS" (for "1234→5678")
+	minStatus := 7     // "STATUS"
 
 	// Remaining width distributed among SERVICE, NAMESPACE, CONTEXT
-	remainingWidth := availableWidth - minInProject - minPorts
+	remainingWidth := availableWidth - minInProject - minPorts - minStatus
 	serviceWidth := remainingWidth * 40 / 100
 	namespaceWidth := remainingWidth * 30 / 100
 	contextWidth := remainingWidth - serviceWidth - namespaceWidth
@@ -148,6 +305,7 @@ func (m *Model) calculateServiceSelectionColumns() []table.Column {
 		{Title: "NAMESPACE", Width: namespaceWidth},
 		{Title: "CONTEXT", Width: contextWidth},
 		{Title: "PORTS", Width: minPorts},
+		{Title: ColStatus, Width: minStatus},
 	}
 }
 
@@ -157,13 +315,16 @@ func (m *Model) calculateClusterSelectionColumns() []table.Column {
 	availableWidth := m.width - 8
 	availableWidth = max(availableWidth, 30) // Minimum total width
 
-	// CURRENT column gets fixed small width, CLUSTER gets the rest
+	// CURRENT column gets fixed small width, CLUSTER and KUBECONFIG share
+	// the rest
 	minCurrent := 8 // "CURRENT"
-	clusterWidth := availableWidth - minCurrent
+	clusterWidth := (availableWidth - minCurrent) * 55 / 100
 	clusterWidth = max(clusterWidth, 15)
+	kubeconfigWidth := max(availableWidth-minCurrent-clusterWidth, 10)
 
 	return []table.Column{
 		{Title: "CLUSTER", Width: clusterWidth},
+		{Title: "KUBECONFIG", Width: kubeconfigWidth},
 		{Title: "CURRENT", Width: minCurrent},
 	}
 }
@@ -200,6 +361,10 @@ func (m *Model) calculateDiscoveryServiceColumns() []table.Column {
 	}
 }
 
+// maxIDColumnWidth is the widest the optional ID column gets; longer IDs are
+// cut off
+const maxIDColumnWidth = 40
+
 // calculateColumnWidths returns column widths based on terminal width
 func (m *Model) calculateColumnWidths() []table.Column {
 	// Minimum widths for each column
@@ -212,8 +377,19 @@ func (m *Model) calculateColumnWidths() []table.Column {
 		ColStatus:     7, // "STATUS"
 	}
 
+	// The ID column is as wide as the longest ID (capped) and is taken off
+	// the top, so the other columns keep their proportions
+	idWidth := 0
+	if m.showIDs {
+		idWidth = len(ColID)
+		for _, cfg := range m.configStore.GetAll() {
+			idWidth = max(idWidth, len(cfg.ID))
+		}
+		idWidth = min(idWidth, maxIDColumnWidth)
+	}
+
 	// Calculate available width (standardized padding for borders)
-	availableWidth := m.width - 8
+	availableWidth := m.width - 8 - idWidth
 	availableWidth = max(availableWidth, 60) // Minimum total width
 
 	// Calculate total minimum width needed
@@ -274,7 +450,7 @@ func (m *Model) calculateColumnWidths() []table.Column {
 	}
 
 	// Return columns with calculated widths (without ID column)
-	return []table.Column{
+	columns := []table.Column{
 		{Title: ColContext, Width: finalWidths[ColContext]},
 		{Title: ColNamespace, Width: finalWidths[ColNamespace]},
 		{Title: ColService, Width: finalWidths[ColService]},
@@ -282,6 +458,13 @@ func (m *Model) calculateColumnWidths() []table.Column {
 		{Title: ColPortLocal, Width: finalWidths[ColPortLocal]},
 		{Title: ColStatus, Width: finalWidths[ColStatus]},
 	}
+	if m.showLatency {
+		columns = append(columns, table.Column{Title: ColLatency, Width: finalWidths[ColLatency]})
+	}
+	if m.showIDs {
+		columns = append(columns, table.Column{Title: ColID, Width: idWidth})
+	}
+	return columns
 }
 
 func NewModel() *Model {
@@ -304,29 +487,17 @@ func NewModel() *Model {
 		initialError = "Critical error: Config store failed to initialize."
 		return nil // Can't proceed without a config store
 	}
+	kubectl.ApplySettings(cfgStore.GetSettings())
+	applyTheme(cfgStore.GetSettings()[config.SettingTheme])
+	applyAccessible(cfgStore.GetSettings()[config.SettingAccessible])
 
 	// --- Initialize PortForwarder ---
 	pf := k8s.NewPortForwarder()
+	pf.ApplySettings(cfgStore.GetSettings())
+	// A deleted forward's process would otherwise run on, no longer listed
+	cfgStore.SetBeforeDelete(pf.Stop)
 
-	// Get initial configs slice
-	initialCfgs := cfgStore.GetAll()
-
-	logging.LogDebug("NewModel: Configs loaded before UI init:")
-	for i, cfg := range initialCfgs {
-		logging.LogDebug("  Index %d: %s/%s", i, cfg.Namespace, cfg.Service)
-	}
-
-	// --- Initialize Tables --- (Define styles first)
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(ColorBorder)).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color(ColorSelectedFg)).
-		Background(lipgloss.Color(ColorSelectedBg)).
-		Bold(false)
+	logging.LogDebug("NewModel: %d configs loaded before UI init", len(cfgStore.GetAll()))
 
 	// --- Create Model --- (Initialize with all components)
 	ti := textinput.New()
@@ -337,9 +508,57 @@ func NewModel() *Model {
 
 	// Initialize edit input for local port editing
 	ei := textinput.New()
-	ei.Placeholder = "Port"
-	ei.CharLimit = 5
-	ei.Width = 8
+	ei.Placeholder = "Port or range"
+	ei.CharLimit = 11 // "65530-65535"
+	ei.Width = 14
+
+	// Initialize rule input for bulk local port rewrites
+	bei := textinput.New()
+	bei.Placeholder = "+10000 or prefix 1"
+	bei.CharLimit = 16
+	bei.Width = 20
+
+	// Initialize kubectl arguments input
+	aei := textinput.New()
+	aei.Placeholder = "--pod-running-timeout=2m"
+	aei.CharLimit = 256
+	aei.Width = 40
+
+	// Initialize tags input
+	tei := textinput.New()
+	tei.Placeholder = "db critical"
+	tei.CharLimit = 256
+	tei.Width = 40
+
+	// Initialize open command input
+	oei := textinput.New()
+	oei.Placeholder = "psql -h {{host}} -p {{port}}"
+	oei.CharLimit = 256
+	oei.Width = 40
+
+	// Initialize pod selector input
+	pei := textinput.New()
+	pei.Placeholder = "2 or role=primary"
+	pei.CharLimit = 256
+	pei.Width = 40
+
+	// Initialize start/stop hook input
+	hei := textinput.New()
+	hei.Placeholder = "flyway -url=jdbc:postgresql://localhost:$KPRTFWD_LOCAL_PORT/app migrate"
+	hei.CharLimit = 512
+	hei.Width = 50
+
+	// Initialize health check path input
+	hci := textinput.New()
+	hci.Placeholder = "/healthz"
+	hci.CharLimit = 256
+	hci.Width = 40
+
+	// Initialize the input a forward is pasted into
+	adi := textinput.New()
+	adi.Placeholder = "kubectl port-forward -n shop svc/api 8080:80 --context dev, or http://api.shop:8080"
+	adi.CharLimit = 1024
+	adi.Width = 60
 
 	// Initialize project name input
 	pni := textinput.New()
@@ -358,27 +577,85 @@ func NewModel() *Model {
 		groupingEnabled:  true, // Enable grouping by default
 		filterInput:      ti,
 		editInput:        ei,
+		bulkEditInput:    bei,
+		argsEditInput:    aei,
+		tagsEditInput:    tei,
+		openEditInput:    oei,
+		podEditInput:     pei,
+		hookEditInput:    hei,
+		healthEditInput:  hci,
+		addInput:         adi,
 		projectNameInput: pni,
+		stopAllSeen:      config.StopAllRequestedAt(), // an older request is not repeated
+		kubeconfigStamp:  kubectl.KubeconfigStamp(),
+		storeStamp:       cfgStore.ChangeStamp(),
+		tableLoading:     true,
+		customGroups:     config.CustomGroups(cfgStore.GetSettings()),
+		autoCollapse:     cfgStore.GetSettings()[config.SettingAutoCollapse] != "false",
+		metricsTextfile:  cfgStore.GetSettings()[config.SettingMetricsTextfile],
 	}
 
-	// Initialize Port Forwards Table with dynamic columns
+	// Initialize Port Forwards Table with dynamic columns. Its rows are built
+	// by loadTableCmd from Init, so a big configuration does not hold up the
+	// first frame.
 	pfCols := m.calculateColumnWidths()
-	pfTable := table.New(
-		table.WithColumns(pfCols),
-		table.WithRows(m.generateGroupedRows(initialCfgs)), // Use grouped rows
-		table.WithFocused(true),
-		table.WithHeight(10),
-		table.WithKeyMap(navTableKeyMap()),
-		table.WithStyles(s),
-	)
-	m.portForwardsTable = pfTable
+	m.portForwardsTable = newNavTable(pfCols, nil, 10)
 
 	return m
 }
 
 func (m *Model) Cleanup() {
 	if m.portForwarder != nil {
+		m.recordForwardEvents(true)
 		m.portForwarder.CleanupAll()
+		m.writeMetrics(true) // everything down, rather than the last state forever
+	}
+	config.RemoveHostState()
+}
+
+// recordForwardEvents appends a start event for each forward that became
+// active since the last call and a stop event for each that went inactive.
+// With stopAll every active forward is recorded as stopped, as on exit.
+func (m *Model) recordForwardEvents(stopAll bool) {
+	if m.configStore == nil {
+		return
+	}
+	if m.activeIDs == nil {
+		m.activeIDs = make(map[string]bool)
+	}
+	now := time.Now()
+	var events []config.ForwardEvent
+	for _, cfg := range m.configStore.GetAll() {
+		active := !stopAll && (m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsStandby(cfg.ID))
+		if active == m.activeIDs[cfg.ID] {
+			continue
+		}
+		kind := config.ForwardEventStop
+		if active {
+			kind = config.ForwardEventStart
+		}
+		events = append(events, config.ForwardEvent{ForwardID: cfg.ID, Kind: kind, At: now})
+		if active {
+			m.activeIDs[cfg.ID] = true
+		} else {
+			delete(m.activeIDs, cfg.ID)
+		}
+	}
+	if err := m.configStore.RecordForwardEvents(events); err != nil {
+		logging.LogError("Failed to record forward events: %v", err)
+	}
+}
+
+// monoTheme is set by the mono theme; selected rows are then shown in
+// reverse video, as their colors are gone.
+var monoTheme bool
+
+// applyTheme sets up the colors for the ui.theme setting: mono drops every
+// color, other values keep the default palette.
+func applyTheme(name string) {
+	monoTheme = name == config.ThemeMono
+	if monoTheme {
+		lipgloss.SetColorProfile(termenv.Ascii)
 	}
 }
 
@@ -390,25 +667,78 @@ const statusRefreshInterval = 2 * time.Second
 // statusTickMsg drives the periodic runtime-status refresh.
 type statusTickMsg time.Time
 
-// tunnelProbeMsg carries the config IDs whose TCP tunnel a background probe
-// found broken (e.g. VPN dropped without killing kubectl).
-type tunnelProbeMsg []string
+// tunnelProbeMsg carries the config IDs whose tunnel the background health
+// check found dead (e.g. VPN dropped without killing kubectl, or the local
+// port no longer listening), with the reason for each.
+type tunnelProbeMsg map[string]string
+
+// conflictProbeMsg reports whether a background probe found a failed
+// forward's local port newly taken by, or freed from, another program.
+type conflictProbeMsg bool
+
+// duplicateProbeMsg reports whether a background probe found a kubectl
+// port-forward that kprtfwd did not start duplicating a running forward, or
+// one such gone.
+type duplicateProbeMsg bool
+
+// duplicateProbeInterval is how often the processes of the machine are
+// scanned for kubectl port-forwards duplicating running forwards; listing
+// them is too costly for every status tick.
+const duplicateProbeInterval = 30 * time.Second
 
 // autoRestartMsg carries the config IDs that a background auto-restart attempt
 // successfully brought back up.
 type autoRestartMsg []string
 
+// latencyRefreshInterval is how often round-trip latency is re-probed while
+// the LATENCY column is visible.
+const latencyRefreshInterval = 10 * time.Second
+
+// latencyTickMsg drives the periodic latency probe while the column is shown.
+type latencyTickMsg time.Time
+
+// latencyProbeMsg carries the round-trip latency per config ID measured by a
+// background probe.
+type latencyProbeMsg map[string]time.Duration
+
 func statusTickCmd() tea.Cmd {
 	return tea.Tick(statusRefreshInterval, func(t time.Time) tea.Msg {
 		return statusTickMsg(t)
 	})
 }
 
-// probeTunnelsCmd runs the (blocking) tunnel health probe off the event loop.
+// probeTunnelsCmd runs the (blocking) health check off the event loop.
 func probeTunnelsCmd(pf *k8s.PortForwarder) tea.Cmd {
 	return func() tea.Msg {
-		return tunnelProbeMsg(pf.ProbeAllTunnels())
+		return tunnelProbeMsg(pf.CheckHealth())
+	}
+}
+
+// probeConflictsCmd runs the (blocking) port conflict probe off the event loop.
+func probeConflictsCmd(pf *k8s.PortForwarder, configs []config.PortForwardConfig) tea.Cmd {
+	return func() tea.Msg {
+		return conflictProbeMsg(pf.ProbeConflicts(configs))
+	}
+}
+
+// probeDuplicatesCmd runs the (blocking) scan for kubectl processes
+// duplicating running forwards off the event loop.
+func probeDuplicatesCmd(pf *k8s.PortForwarder, configs []config.PortForwardConfig, currentContext string) tea.Cmd {
+	return func() tea.Msg {
+		return duplicateProbeMsg(pf.ProbeDuplicates(configs, currentContext))
+	}
+}
+
+// startDuplicateProbe reports whether a scan for duplicating kubectl
+// processes is due at now, and if so records it as started: none is while
+// one is in flight or within duplicateProbeInterval of the last.
+func (m *Model) startDuplicateProbe(now time.Time) bool {
+	if m.duplicateProbing || now.Sub(m.duplicateProbedAt) < duplicateProbeInterval {
+		return false
 	}
+	m.duplicateProbing = true
+	m.duplicateProbedAt = now
+	return true
 }
 
 // autoRestartCmd runs the (blocking) auto-restart pass off the event loop,
@@ -419,8 +749,30 @@ func autoRestartCmd(pf *k8s.PortForwarder, configs []config.PortForwardConfig) t
 	}
 }
 
+func latencyTickCmd() tea.Cmd {
+	return tea.Tick(latencyRefreshInterval, func(t time.Time) tea.Msg {
+		return latencyTickMsg(t)
+	})
+}
+
+// probeLatencyCmd runs the (blocking) latency probe off the event loop.
+func probeLatencyCmd(pf *k8s.PortForwarder) tea.Cmd {
+	return func() tea.Msg {
+		return latencyProbeMsg(pf.ProbeLatencies())
+	}
+}
+
+// tableLoadedMsg asks for the first build of the port forwards table.
+type tableLoadedMsg struct{}
+
+// loadTableCmd delivers tableLoadedMsg once the program runs, after the
+// loading frame was drawn.
+func loadTableCmd() tea.Cmd {
+	return func() tea.Msg { return tableLoadedMsg{} }
+}
+
 func (m *Model) Init() tea.Cmd {
-	return statusTickCmd()
+	return tea.Batch(loadTableCmd(), statusTickCmd(), kubeconfigChangedCmd(), rolloutTickCmd(), usageTickCmd())
 }
 
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -429,19 +781,54 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		// Sync displayed status with actual process state; the PortForwarder
 		// watcher goroutines deregister forwards whose process exited. Also
 		// kick off a tunnel health probe to catch VPN drops that leave kubectl
-		// running but the tunnel dead, and an auto-restart pass to recover
-		// transiently-broken forwards whose backoff has elapsed.
+		// running but the tunnel dead, a probe for other programs that took a
+		// failed forward's port, an auto-restart pass to recover
+		// transiently-broken forwards whose backoff has elapsed and, every
+		// duplicateProbeInterval, a scan for kubectl processes forwarding a
+		// running forward's target elsewhere.
+		m.answerStopAllRequest()  // a `kprtfwd stop --all` in another terminal
+		m.handleControlRequests() // `kprtfwd start` and `stop`
+		m.resumeSnoozed(time.Now())
+		m.expireForwards(time.Now())
+		m.syncStore() // picks up `kprtfwd import`, `prune`, ... or a second TUI
 		m.refreshTable()
+		m.announceStatusChanges()
+		m.recordForwardEvents(false)
+		m.writeMetrics(false)
+		m.publishHostState()
 		configs := m.configStore.GetAll()
-		return m, tea.Batch(
+		cmds := []tea.Cmd{
 			statusTickCmd(),
 			probeTunnelsCmd(m.portForwarder),
+			probeConflictsCmd(m.portForwarder, configs),
 			autoRestartCmd(m.portForwarder, configs),
-		)
+		}
+		if m.startDuplicateProbe(time.Time(msg)) {
+			cmds = append(cmds, probeDuplicatesCmd(m.portForwarder, configs, m.currentContext))
+		}
+		// Picks up `kubectl config use-context` run in another terminal.
+		if stamp := kubectl.KubeconfigStamp(); stamp != m.kubeconfigStamp {
+			m.kubeconfigStamp = stamp
+			cmds = append(cmds, kubeconfigChangedCmd())
+		}
+		return m, tea.Batch(cmds...)
 
 	case tunnelProbeMsg:
-		if len(msg) > 0 {
-			m.portForwarder.MarkBroken([]string(msg))
+		// Redrawn even with none dead: the check also recorded which
+		// forwards are degraded or healthy again
+		m.portForwarder.MarkBroken(msg)
+		m.refreshTable()
+		return m, nil
+
+	case conflictProbeMsg:
+		if msg {
+			m.refreshTable()
+		}
+		return m, nil
+
+	case duplicateProbeMsg:
+		m.duplicateProbing = false
+		if msg {
 			m.refreshTable()
 		}
 		return m, nil
@@ -457,91 +844,106 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		return m, nil
 
+	case latencyTickMsg:
+		if !m.showLatency {
+			m.latencyProbing = false // column hidden; let the chain die
+			return m, nil
+		}
+		return m, probeLatencyCmd(m.portForwarder)
+
+	case latencyProbeMsg:
+		if !m.showLatency {
+			m.latencyProbing = false
+			return m, nil
+		}
+		m.latencies = map[string]time.Duration(msg)
+		m.refreshTable()
+		return m, latencyTickCmd()
+
+	case usageTickMsg:
+		return m, sampleUsageCmd(m.portForwarder, m.configStore.GetAll())
+	case usageSampledMsg:
+		return m.handleUsageSampled(msg)
+
+	case rolloutTickMsg:
+		return m.handleRolloutTick()
+	case rolloutCheckedMsg:
+		return m.handleRolloutChecked(msg)
+	case rolloutRestartedMsg:
+		return m.handleRolloutRestarted(msg)
+
 	// Async service-discovery results (run off the event loop so the UI never freezes)
 	case clustersLoadedMsg:
 		return m.handleClustersLoaded(msg)
+	case tableLoadedMsg:
+		m.refreshTable()
+		return m, nil
+
+	case filterDebounceMsg:
+		if int(msg) == m.filterSeq && !m.filterUpToDate() {
+			m.applyFilter()
+			m.refreshTable()
+		}
+		return m, nil
+
+	case openCommandDoneMsg:
+		m.handleOpenCommandDone(msg)
+		return m, nil
+	case shellDoneMsg:
+		m.handleShellDone(msg)
+		return m, nil
+	case logStreamStartedMsg:
+		return m.handleLogStreamStarted(msg)
+	case logLinesMsg:
+		return m.handleLogLines(msg)
+	case describedMsg:
+		return m.handleDescribed(msg)
+
+	case podCandidatesMsg:
+		return m.handlePodCandidates(msg)
+
+	case kubeconfigChangedMsg:
+		return m.handleKubeconfigChanged(msg)
 	case servicesDiscoveredMsg:
 		return m.handleServicesDiscovered(msg)
+	case spinner.TickMsg:
+		if !m.discoveryLoading {
+			return m, nil // the spinner stops with the operation
+		}
+		var cmd tea.Cmd
+		m.discoverySpinner, cmd = m.discoverySpinner.Update(msg)
+		return m, cmd
+	case pruneScannedMsg:
+		return m.handlePruneScanned(msg)
 
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
-		// Update table dimensions based on new window size
-		pfTableHeight := m.height - PortForwardsViewOffset
-		if pfTableHeight < MinTableHeight {
-			pfTableHeight = MinTableHeight
+		for _, s := range m.screens() {
+			s.Resize()
 		}
-		m.portForwardsTable.SetHeight(pfTableHeight)
-
-		// Recalculate and update column widths based on new terminal width
-		newCols := m.calculateColumnWidths()
-		m.portForwardsTable.SetColumns(newCols)
-
-		// Update other table column widths as well
-		if m.projectSelector.Rows() != nil {
-			m.projectSelector.SetColumns(m.calculateProjectSelectorColumns())
-			// Update height too
-			m.projectSelector.SetHeight(min(len(m.projectSelector.Rows())+2, m.height-6))
-		}
-		if m.projectManagementTable.Rows() != nil {
-			m.projectManagementTable.SetColumns(m.calculateProjectManagementColumns())
-			// Update height too
-			m.projectManagementTable.SetHeight(min(len(m.projectManagementTable.Rows())+2, m.height-8))
-		}
-		if m.projectServiceTable.Rows() != nil {
-			m.projectServiceTable.SetColumns(m.calculateServiceSelectionColumns())
-			// Update height too
-			m.projectServiceTable.SetHeight(min(len(m.projectServiceTable.Rows())+2, m.height-10))
-		}
-		if m.discoveryTable.Rows() != nil {
-			if m.discoveryPhase == PhaseClusterSelection {
-				m.discoveryTable.SetColumns(m.calculateClusterSelectionColumns())
-				// Update height for cluster selection
-				m.discoveryTable.SetHeight(min(len(m.discoveryTable.Rows())+2, m.height-6))
-			} else if m.discoveryPhase == PhaseServiceSelection {
-				m.discoveryTable.SetColumns(m.calculateDiscoveryServiceColumns())
-				// Update height for service selection with proper calculation
-				availableHeight := m.height - 9
-				if availableHeight < 4 {
-					availableHeight = 4
-				}
-				m.discoveryTable.SetHeight(min(len(m.discoveryTable.Rows())+2, availableHeight))
-			}
-		}
-
-		// Update filter input widths to match terminal width (with some padding)
-		filterWidth := m.width - 4 // Leave some padding
-		if filterWidth < 20 {
-			filterWidth = 20
-		}
-		m.filterInput.Width = filterWidth
-		m.discoveryFilterInput.Width = filterWidth
-
 		return m, nil
 
 	case tea.KeyMsg:
 		keyStr := msg.String()
 
+		// The panic stop confirmation only applies to the key press right
+		// after it
+		confirmPanicStop := m.confirmPanicStop
+		m.confirmPanicStop = false
+
 		// Global shortcuts that work in any state
 		switch keyStr {
 		case "ctrl+c", ShortcutExit: // ctrl+x
+			m.logs.stop() // kubectl logs runs in its own process group
 			return m, tea.Quit
+		case ShortcutPanicStop: // ctrl+s
+			return m.handlePanicStopKey(confirmPanicStop)
 		}
 
-		// Delegate to state-specific handlers
-		switch m.uiState {
-		case StatePortForwards:
-			return m.updatePortForwards(msg)
-		case StateProjectSelector:
-			return m.updateProjectSelector(msg)
-		case StateServiceDiscovery:
-			return m.updateServiceDiscovery(msg)
-		case StateProjectManagement:
-			return m.updateProjectManagement(msg)
-		case StateProjectCreation:
-			return m.updateProjectCreation(msg)
-		case StateProjectServiceSelection:
-			return m.updateProjectServiceSelection(msg)
+		// Delegate to the current view
+		if s, ok := m.screens()[m.uiState]; ok {
+			return s.Update(msg)
 		}
 
 	// Handle messages specific to certain operations/states
@@ -555,13 +957,58 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// filterDebounce is how long filter mode waits after a keystroke before it
+// filters, so typing a word filters once rather than once per letter
+const filterDebounce = 75 * time.Millisecond
+
+// filterDebounceMsg applies the filter typed up to keystroke seq, unless
+// another keystroke followed it
+type filterDebounceMsg int
+
+func filterDebounceCmd(seq int) tea.Cmd {
+	return tea.Tick(filterDebounce, func(time.Time) tea.Msg {
+		return filterDebounceMsg(seq)
+	})
+}
+
+// normalizedFilterText returns the filter text as applyFilter matches it
+func (m *Model) normalizedFilterText() string {
+	return strings.ToLower(strings.TrimSpace(m.filterInput.Value()))
+}
+
+// filterUpToDate reports whether the cached filter result already matches
+// the filter text, as after moving the cursor or typing a trailing space
+func (m *Model) filterUpToDate() bool {
+	return m.filteredConfigs != nil && m.filteredText == m.normalizedFilterText()
+}
+
+// scheduleFilter arranges for the filter to be applied once typing pauses
+func (m *Model) scheduleFilter() tea.Cmd {
+	if m.filterUpToDate() {
+		return nil
+	}
+	m.filterSeq++
+	return filterDebounceCmd(m.filterSeq)
+}
+
+// flushFilter applies a filter still waiting for its debounce
+func (m *Model) flushFilter() {
+	if m.filterUpToDate() {
+		return
+	}
+	m.filterSeq++ // the pending debounce has nothing left to do
+	m.applyFilter()
+	m.refreshTable()
+}
+
 // applyFilter filters configs based on the current filter text
 func (m *Model) applyFilter() {
-	filterText := strings.ToLower(strings.TrimSpace(m.filterInput.Value()))
+	filterText := m.normalizedFilterText()
+	m.filteredText = filterText
 	// Use base configs that respect active project filtering
 	baseConfigs := m.configStore.GetActiveProjectForwards()
 
-	if filterText == "" {
+	if filterText == "" && len(m.activeQuickFilters) == 0 {
 		// No filter, show base configs (which respect active project)
 		m.filteredConfigs = baseConfigs
 		return
@@ -569,13 +1016,22 @@ func (m *Model) applyFilter() {
 
 	// Filter configs by searching across visible fields (excluding ID)
 	m.filteredConfigs = []config.PortForwardConfig{}
+	states := m.portForwarder.Snapshot()
 	for _, cfg := range baseConfigs {
+		if !m.matchesQuickFilters(cfg, states) {
+			continue
+		}
+		if filterText == "" {
+			m.filteredConfigs = append(m.filteredConfigs, cfg)
+			continue
+		}
+
 		// Convert search fields to lowercase for case-insensitive matching
 		context := strings.ToLower(cfg.Context)
 		namespace := strings.ToLower(cfg.Namespace)
 		service := strings.ToLower(cfg.Service)
-		portRemote := fmt.Sprintf("%d", cfg.PortRemote)
-		portLocal := fmt.Sprintf("%d", cfg.PortLocal)
+		portRemote := formatPorts(cfg.PortRemote, cfg.Ports())
+		portLocal := formatPorts(cfg.PortLocal, cfg.Ports())
 
 		// Check if filter text is found in any of the visible fields
 		if strings.Contains(context, filterText) ||
@@ -610,9 +1066,33 @@ func (m *Model) handlePortForwardsRestart() (tea.Model, tea.Cmd) {
 		m.statusMsg = m.formatRestartSummary(result)
 	}
 
+	// The report view lists every forward with its new PID or its error in
+	// full, so the squeezed summary line is only kept when it has nothing
+	// to show.
+	if len(result.Forwards) > 0 {
+		m.errorMsg = ""
+		m.statusMsg = ""
+		m.enterRestartReport(result.Forwards)
+	}
+
 	return m, nil
 }
 
+// restartRunning restarts those of configs that are running, the members of
+// the group or project called name, and shows the outcome in the restart
+// report. With none running the current view stays, saying so.
+func (m *Model) restartRunning(name string, configs []config.PortForwardConfig) {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	result := m.portForwarder.RestartRunning(configs)
+	m.refreshTable()
+	if len(result.Forwards) == 0 {
+		m.statusMsg = fmt.Sprintf("%s: no forward is running, nothing to restart", name)
+		return
+	}
+	m.enterRestartReport(result.Forwards)
+}
+
 // formatRestartSummary creates user-friendly restart summary
 func (m *Model) formatRestartSummary(result *k8s.RestartResult) string {
 	if len(result.Errors) > 0 {
@@ -620,9 +1100,9 @@ func (m *Model) formatRestartSummary(result *k8s.RestartResult) string {
 		errorMsgs := []string{}
 		for id, err := range result.Errors {
 			if cfg, exists := m.configStore.GetConfigByID(id); exists {
-				errorMsgs = append(errorMsgs, fmt.Sprintf("%s: %v", cfg.Service, err))
+				errorMsgs = append(errorMsgs, fmt.Sprintf("%s: %s", cfg.Service, friendlyError(err)))
 			} else {
-				errorMsgs = append(errorMsgs, fmt.Sprintf("%s: %v", id, err))
+				errorMsgs = append(errorMsgs, fmt.Sprintf("%s: %s", id, friendlyError(err)))
 			}
 		}
 		return fmt.Sprintf("Restart errors: %s", strings.Join(errorMsgs, "; "))
@@ -638,7 +1118,7 @@ func (m *Model) formatRestartSummary(result *k8s.RestartResult) string {
 
 // openInBrowser opens the HTTP URL for the given port forward configuration
 func (m *Model) openInBrowser(cfg config.PortForwardConfig) error {
-	url := fmt.Sprintf("http://localhost:%d", cfg.PortLocal)
+	url := cfg.LocalURL()
 	logging.LogDebug("Opening URL in browser: %s", url)
 
 	var cmd *exec.Cmd
@@ -655,3 +1135,37 @@ func (m *Model) openInBrowser(cfg config.PortForwardConfig) error {
 
 	return cmd.Run()
 }
+
+// copyToClipboard puts text on the system clipboard using the platform's
+// clipboard tool
+func copyToClipboard(text string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("pbcopy")
+	case "windows":
+		cmd = exec.Command("clip")
+	default:
+		// Wayland first, then the common X11 tools
+		candidates := [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+		if os.Getenv("WAYLAND_DISPLAY") == "" {
+			candidates = candidates[1:]
+		}
+		for _, c := range candidates {
+			if _, err := exec.LookPath(c[0]); err == nil {
+				cmd = exec.Command(c[0], c[1:]...)
+				break
+			}
+		}
+		if cmd == nil {
+			return fmt.Errorf("no clipboard tool found (install xclip, xsel or wl-clipboard)")
+		}
+	}
+
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
@@ -3,6 +3,9 @@ package ui
 import (
 	"fmt"
 	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
 	"github.com/xlttj/kprtfwd/pkg/k8s"
@@ -12,53 +15,248 @@ import (
 	"github.com/charmbracelet/lipgloss"
 )
 
-// styleStatusText colors the status text by state so Running/Stopped/Error are
+// styleStatusText colors the status text by state so Running/Stopped/Failed are
 // distinguishable at a glance. The status strings are padded to equal width
 // (see constants) so the STATUS column stays aligned regardless of value.
 func styleStatusText(status string) string {
 	switch status {
 	case StatusRunning:
 		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusRunning)).Render(status)
-	case StatusError:
+	case StatusFailed, StatusConflict, StatusDead:
 		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusError)).Render(status)
+	case StatusDegraded:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusDegraded)).Render(status)
+	case StatusStandby:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusStandby)).Render(status)
+	case StatusQueued:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusQueued)).Render(status)
+	case StatusSnoozed:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusSnoozed)).Render(status)
 	default: // StatusStopped
 		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusStopped)).Render(status)
 	}
 }
 
+// statusFor returns the STATUS cell text for a forward from the
+// PortForwarder's runtime state.
+func (m *Model) statusFor(id string) string {
+	return m.statusOf(id, m.portForwarder.State(id))
+}
+
+// statusOf returns the STATUS cell text for the forward with the given ID in
+// runtime state s, as taken from PortForwarder.State or Snapshot.
+func (m *Model) statusOf(id string, s k8s.ForwardState) string {
+	switch {
+	case s.Queued:
+		return StatusQueued
+	case s.Standby:
+		return StatusStandby
+	case s.Running && s.Health == k8s.HealthDegraded:
+		return StatusDegraded
+	case s.Running:
+		return StatusRunning
+	case s.Failed && s.Conflict.Port != 0:
+		return StatusConflict
+	case s.Failed && s.Health == k8s.HealthDead:
+		return StatusDead
+	case s.Failed:
+		return StatusFailed
+	case !m.snoozedUntil[id].IsZero():
+		return StatusSnoozed
+	default:
+		return StatusStopped
+	}
+}
+
+// statusCell renders the STATUS cell of a forward whose status is status,
+// computed from its runtime state s, cut to the column's width; the detail
+// pane shows it in full. A forward with a time limit shows how long it has
+// left; a running one that a kubectl outside kprtfwd forwards to another
+// port as well is marked as duplicated, as which port is live is unclear.
+func (m *Model) statusCell(id, status string, s k8s.ForwardState) string {
+	if s.Duplicate.PID != 0 && (status == StatusRunning || status == StatusStandby) {
+		text := truncate(strings.TrimSpace(status)+", duplicate "+s.Duplicate.String(), max(m.columnWidth(ColStatus), len(status)))
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusDegraded)).Render(text)
+	}
+	switch status {
+	case StatusSnoozed:
+		text := truncate(m.statusDescription(id, status, s), max(m.columnWidth(ColStatus), len(StatusSnoozed)))
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusSnoozed)).Render(text)
+	case StatusRunning:
+		if s.OverMemory {
+			text := truncate(StatusRunning+", kubectl "+config.FormatMemorySize(s.Usage.RSS), max(m.columnWidth(ColStatus), len(status)))
+			return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusDegraded)).Render(text)
+		}
+		style, text := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusRunning)), StatusRunning
+		if recentlyActive(s) {
+			style, text = style.Bold(true), StatusActive
+		}
+		if left := m.timeLeft(id, time.Now()); left != "" {
+			text = truncate(strings.TrimSpace(text)+", "+left+" left", max(m.columnWidth(ColStatus), len(status)))
+		}
+		return style.Render(text)
+	case StatusStandby:
+		if left := m.timeLeft(id, time.Now()); left != "" {
+			text := truncate(StatusStandby+", "+left+" left", max(m.columnWidth(ColStatus), len(status)))
+			return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusStandby)).Render(text)
+		}
+	case StatusFailed, StatusConflict, StatusDead:
+		text := truncate(m.statusDescription(id, status, s), max(m.columnWidth(ColStatus), len(status)))
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusError)).Render(text)
+	case StatusDegraded:
+		text := truncate(m.statusDescription(id, status, s), max(m.columnWidth(ColStatus), len(status)))
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusDegraded)).Render(text)
+	}
+	return styleStatusText(status)
+}
+
+// statusDescription spells out status for a forward in runtime state s: a
+// failed or dead forward gets a short form of the reason after its status, a
+// degraded one what its health check got, a conflicting one the program
+// holding its port, a snoozed one the time it starts again.
+func (m *Model) statusDescription(id, status string, s k8s.ForwardState) string {
+	switch status {
+	case StatusSnoozed:
+		return "Snoozed until " + m.snoozedUntil[id].Format("15:04")
+	case StatusConflict:
+		return StatusConflict + ": " + s.Conflict.String()
+	case StatusDegraded:
+		return StatusDegraded + ": " + s.HealthDetail
+	case StatusFailed, StatusDead:
+		reason := s.ErrorReason
+		if friendly, ok := friendlyReason(reason); ok {
+			reason = friendly
+		}
+		text := strings.TrimSpace(status)
+		if reason = oneLine(reason); reason != "" {
+			text += ": " + reason
+		}
+		return text
+	}
+	return strings.TrimSpace(status)
+}
+
+// activityWindow is how recent the last data through a forward must be for
+// its row to show it as active
+const activityWindow = 5 * time.Second
+
+// recentlyActive reports whether data went through the forward in state s
+// within activityWindow. Only known for lazy and TLS forwards, whose local
+// port kprtfwd serves itself.
+func recentlyActive(s k8s.ForwardState) bool {
+	return s.Proxied && !s.LastActivity.IsZero() && time.Since(s.LastActivity) < activityWindow
+}
+
+// columnWidth returns the width of the main table's column titled title, or
+// 0 if it is not shown
+func (m *Model) columnWidth(title string) int {
+	for _, col := range m.portForwardsTable.Columns() {
+		if col.Title == title {
+			return col.Width
+		}
+	}
+	return 0
+}
+
+// oneLine collapses s (kubectl's stderr, say) onto a single line
+func oneLine(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// truncate cuts s to width characters, marking the cut with an ellipsis
+func truncate(s string, width int) string {
+	runes := []rune(s)
+	if len(runes) <= width {
+		return s
+	}
+	if width < 1 {
+		return ""
+	}
+	return string(runes[:width-1]) + "…"
+}
+
+// isForwardActive reports whether the forward has a process, a listener or a
+// queue slot that stopping it would release
+func (m *Model) isForwardActive(id string) bool {
+	s := m.portForwarder.State(id)
+	return s.Running || s.Standby || s.Queued
+}
+
+// formatPorts renders a PORT cell: "8080" for a single port, "9000-9005" for
+// a range.
+func formatPorts(first, count int) string {
+	if count <= 1 {
+		return strconv.Itoa(first)
+	}
+	return fmt.Sprintf("%d-%d", first, first+count-1)
+}
+
+// formatLatency renders the last probed round trip for the LATENCY column:
+// "-" until a probe result exists (or while stopped), "timeout" when the
+// backend did not answer in time. s is the forward's runtime state.
+func (m *Model) formatLatency(id string, s k8s.ForwardState) string {
+	if !s.Running {
+		return "-"
+	}
+	d, ok := m.latencies[id]
+	switch {
+	case !ok:
+		return "-"
+	case d == k8s.LatencyTimeout:
+		return lipgloss.NewStyle().Foreground(lipgloss.Color(ColorStatusError)).Render("timeout")
+	case d < time.Millisecond:
+		return "<1ms"
+	case d < time.Second:
+		return fmt.Sprintf("%dms", d.Milliseconds())
+	default:
+		return fmt.Sprintf("%.1fs", d.Seconds())
+	}
+}
+
 // generatePortForwardRows converts config slice to table.Row slice (ungrouped)
 func (m *Model) generatePortForwardRows(configs []config.PortForwardConfig) []table.Row {
 	// If no text filtering is active, respect active project filtering
 	actualConfigs := configs
-	if !(m.filterMode || m.filterInput.Value() != "") {
+	if !m.filtering() {
 		actualConfigs = m.configStore.GetActiveProjectForwards()
 	}
 
 	rows := make([]table.Row, 0, len(actualConfigs))
+	states := m.portForwarder.Snapshot()
 
 	for _, cfg := range actualConfigs {
-		// Determine actual runtime status by checking the PortForwarder.
-		var statusText string
-		if m.portForwarder.IsRunning(cfg.ID) {
-			statusText = StatusRunning
-		} else if m.portForwarder.IsError(cfg.ID) {
-			statusText = StatusError
-		} else {
-			statusText = StatusStopped
-		}
+		// Determine actual runtime status from the PortForwarder's snapshot.
+		state := states[cfg.ID]
+		statusText := m.statusOf(cfg.ID, state)
 
-		rows = append(rows, table.Row{
+		row := table.Row{
 			cfg.Context,
 			cfg.Namespace,
 			cfg.Service,
-			fmt.Sprintf("%d", cfg.PortRemote),
-			fmt.Sprintf("%d", cfg.PortLocal),
-			styleStatusText(statusText),
-		})
+			formatPorts(cfg.PortRemote, cfg.Ports()),
+			formatPorts(cfg.PortLocal, cfg.Ports()),
+			m.statusCell(cfg.ID, statusText, state),
+		}
+		if m.showLatency {
+			row = append(row, m.formatLatency(cfg.ID, state))
+		}
+		if m.showIDs {
+			row = append(row, cfg.ID)
+		}
+		rows = append(rows, row)
 	}
 	return rows
 }
 
+// touchGroup marks a context group as used this session, so auto-collapse
+// leaves it as it is from now on
+func (m *Model) touchGroup(groupName string) {
+	if m.touchedGroups == nil {
+		m.touchedGroups = make(map[string]bool)
+	}
+	m.touchedGroups[groupName] = true
+}
+
 // generateGroupedRows creates grouped table rows with collapsible sections
 func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.Row {
 	if !m.groupingEnabled {
@@ -67,7 +265,7 @@ func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.
 
 	// If no text filtering is active, respect active project filtering
 	actualConfigs := configs
-	if !(m.filterMode || m.filterInput.Value() != "") {
+	if !m.filtering() {
 		actualConfigs = m.configStore.GetActiveProjectForwards()
 	}
 
@@ -77,8 +275,13 @@ func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.
 		index  int
 	})
 
-	// Always get all configs for index mapping
+	// Always get all configs for index mapping, looked up by ID once per
+	// refresh rather than scanned for every row
 	allConfigs := m.configStore.GetAll()
+	indexByID := make(map[string]int, len(allConfigs))
+	for j, origCfg := range allConfigs {
+		indexByID[origCfg.ID] = j
+	}
 
 	for _, cfg := range actualConfigs {
 		groupKey := cfg.Context
@@ -86,31 +289,39 @@ func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.
 			groupKey = "(no context)"
 		}
 		// Find the original index in the full config store using ID
-		originalIndex := -1
-		for j, origCfg := range allConfigs {
-			if origCfg.ID == cfg.ID {
-				originalIndex = j
-				break
-			}
-		}
-
-		if originalIndex == -1 {
+		originalIndex, ok := indexByID[cfg.ID]
+		if !ok {
 			logging.LogDebug("Warning: Could not find original index for config ID %s", cfg.ID)
 			continue // Skip this config if we can't find its index
 		}
 
-		groups[groupKey] = append(groups[groupKey], struct {
+		item := struct {
 			config config.PortForwardConfig
 			index  int
-		}{cfg, originalIndex})
+		}{cfg, originalIndex}
+		groups[groupKey] = append(groups[groupKey], item)
+		// User-defined groups are views: their forwards stay listed under
+		// their context too
+		for _, custom := range m.customGroups {
+			if custom.Expr.Match(cfg) {
+				name := VirtualGroupMarker + custom.Name
+				groups[name] = append(groups[name], item)
+			}
+		}
 	}
 
-	// Sort group names
+	// Sort group names, user-defined groups first
 	groupNames := make([]string, 0, len(groups))
 	for groupName := range groups {
 		groupNames = append(groupNames, groupName)
 	}
-	sort.Strings(groupNames)
+	sort.Slice(groupNames, func(i, j int) bool {
+		iVirtual, jVirtual := isVirtualGroup(groupNames[i]), isVirtualGroup(groupNames[j])
+		if iVirtual != jVirtual {
+			return iVirtual
+		}
+		return groupNames[i] < groupNames[j]
+	})
 
 	// Initialize group states for new groups
 	for _, groupName := range groupNames {
@@ -123,16 +334,31 @@ func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.
 		}
 	}
 
-	// Update counts and calculate active counts based on runtime state
+	// Update counts and calculate active counts based on runtime state, read
+	// once for all rows
+	states := m.portForwarder.Snapshot()
+	filtering := m.filtering()
 	for groupName, items := range groups {
 		state := m.groupStates[groupName]
 		state.Count = len(items)
 		state.Active = 0
+		inUse := false
 		for _, item := range items {
 			// Check actual runtime state instead of config file status
-			if m.portForwarder.IsRunning(item.config.ID) {
+			forward, listed := states[item.config.ID]
+			if forward.Running {
 				state.Active++
 			}
+			inUse = inUse || listed // running, queued, failed, ...
+		}
+		// A cluster not used this session keeps its group collapsed; the
+		// first forward to start in it makes it one the user is working with.
+		// Filter matches are never hidden.
+		if m.autoCollapse && !isVirtualGroup(groupName) && !m.touchedGroups[groupName] {
+			if inUse {
+				m.touchGroup(groupName)
+			}
+			state.Expanded = inUse || filtering
 		}
 	}
 
@@ -154,7 +380,13 @@ func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.
 		groupHeader := table.Row{
 			fmt.Sprintf("%s %s", expandIcon, groupName),
 			groupStatus,
-			"", "", "", "", // Empty cells for other columns (no ID column)
+			"", "", "", "", // Empty cells for the other columns
+		}
+		if m.showLatency {
+			groupHeader = append(groupHeader, "")
+		}
+		if m.showIDs {
+			groupHeader = append(groupHeader, "")
 		}
 		tableRows = append(tableRows, groupHeader)
 		m.tableRows = append(m.tableRows, TableRow{
@@ -170,17 +402,10 @@ func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.
 				cfg := item.config
 				index := item.index
 
-				// Determine actual runtime status by checking the PortForwarder.
-				isRunning := m.portForwarder.IsRunning(cfg.ID)
-				var statusText string
-				if isRunning {
-					statusText = StatusRunning
-				} else if m.portForwarder.IsError(cfg.ID) {
-					statusText = StatusError
-				} else {
-					statusText = StatusStopped
-				}
-				logging.LogDebug("UI Refresh: Config %d (%s) - IsRunning=%t, Status='%s'", index, cfg.ID, isRunning, statusText)
+				// Determine actual runtime status from the PortForwarder's snapshot.
+				state := states[cfg.ID]
+				statusText := m.statusOf(cfg.ID, state)
+				logging.LogDebug("UI Refresh: Config %d (%s) - Status='%s'", index, cfg.ID, statusText)
 
 				// Indent service name to show hierarchy
 				indentedService := "  " + cfg.Service
@@ -189,9 +414,15 @@ func (m *Model) generateGroupedRows(configs []config.PortForwardConfig) []table.
 					"", // Empty context since it's shown in group header
 					cfg.Namespace,
 					indentedService,
-					fmt.Sprintf("%d", cfg.PortRemote),
-					fmt.Sprintf("%d", cfg.PortLocal),
-					styleStatusText(statusText),
+					formatPorts(cfg.PortRemote, cfg.Ports()),
+					formatPorts(cfg.PortLocal, cfg.Ports()),
+					m.statusCell(cfg.ID, statusText, state),
+				}
+				if m.showLatency {
+					itemRow = append(itemRow, m.formatLatency(cfg.ID, state))
+				}
+				if m.showIDs {
+					itemRow = append(itemRow, cfg.ID)
 				}
 				tableRows = append(tableRows, itemRow)
 				m.tableRows = append(m.tableRows, TableRow{
@@ -214,7 +445,7 @@ func (m *Model) getConfigIndexFromTableRow() (int, error) {
 	// In ungrouped mode, handle filtered vs unfiltered data
 	if !m.groupingEnabled {
 		var configs []config.PortForwardConfig
-		if (m.filterMode || m.filterInput.Value() != "") && m.filteredConfigs != nil {
+		if m.filtering() && m.filteredConfigs != nil {
 			configs = m.filteredConfigs
 		} else {
 			configs = m.configStore.GetAll()
@@ -225,7 +456,7 @@ func (m *Model) getConfigIndexFromTableRow() (int, error) {
 		}
 
 		// If filtering is active, find the original index
-		if (m.filterMode || m.filterInput.Value() != "") && m.filteredConfigs != nil {
+		if m.filtering() && m.filteredConfigs != nil {
 			selectedCfg := configs[selectedIdx]
 			allConfigs := m.configStore.GetAll()
 			for i, origCfg := range allConfigs {
@@ -256,7 +487,7 @@ func (m *Model) getConfigIndexFromTableRow() (int, error) {
 // currently selected port-forward is in an error state, or "" if the selection
 // is not an errored forward. This lets the user read the failure detail
 // (kubectl stderr, a broken-tunnel notice, ...) by moving the cursor onto a red
-// "Error" row, complementing the full record written to the log file.
+// "Failed" row, complementing the full record written to the log file.
 func (m *Model) selectedErrorReason() string {
 	idx, err := m.getConfigIndexFromTableRow()
 	if err != nil {
@@ -270,6 +501,9 @@ func (m *Model) selectedErrorReason() string {
 	if reason == "" {
 		return ""
 	}
+	if friendly, ok := friendlyReason(reason); ok {
+		reason = friendly
+	}
 	// If an auto-restart is scheduled for this forward, show the progress so the
 	// user knows it will recover on its own (transient breaks only).
 	if attempts, scheduled := m.portForwarder.RetryStatus(cfg.ID); scheduled {
@@ -278,6 +512,21 @@ func (m *Model) selectedErrorReason() string {
 	return fmt.Sprintf("%s: %s", cfg.Service, reason)
 }
 
+// listedConfigs returns the forwards the table lists: the filter result while
+// a filter is set, otherwise the active project's forwards (or all of them).
+func (m *Model) listedConfigs() []config.PortForwardConfig {
+	if m.filtering() && m.filteredConfigs != nil {
+		return m.filteredConfigs
+	}
+	return m.configStore.GetActiveProjectForwards()
+}
+
+// isVirtualGroup reports whether groupName is a user-defined group from a
+// ui.group.<name> setting rather than a context
+func isVirtualGroup(groupName string) bool {
+	return strings.HasPrefix(groupName, VirtualGroupMarker)
+}
+
 // isGroupHeaderSelected returns true if a group header is currently selected
 func (m *Model) isGroupHeaderSelected() bool {
 	selectedIdx := m.portForwardsTable.Cursor()
@@ -296,12 +545,28 @@ func (m *Model) getSelectedGroupName() string {
 	return m.tableRows[selectedIdx].GroupName
 }
 
+// applyColumnLayout re-applies the main table columns after the set of
+// visible columns changed. The table renders one cell per row value, so rows
+// wider than the column list would index past it: rows are cleared before the
+// columns change and rebuilt afterwards, keeping the cursor where it was.
+func (m *Model) applyColumnLayout() {
+	cursor := m.portForwardsTable.Cursor()
+	m.portForwardsTable.SetRows(nil)
+	m.portForwardsTable.SetColumns(m.calculateColumnWidths())
+	m.refreshTable()
+	m.portForwardsTable.SetCursor(cursor)
+}
+
 // refreshTable refreshes the table based on current grouping mode and filter state
 func (m *Model) refreshTable() {
 	var configs []config.PortForwardConfig
 
-	// Use filtered configs if filtering is active and we have filtered results
-	if (m.filterMode || m.filterInput.Value() != "") && m.filteredConfigs != nil {
+	// Use filtered configs if filtering is active and we have filtered results.
+	// Quick filters match runtime state, so their result is redone each time.
+	if len(m.activeQuickFilters) > 0 {
+		m.applyFilter()
+	}
+	if m.filtering() && m.filteredConfigs != nil {
 		configs = m.filteredConfigs
 	} else {
 		// Use all configs for proper index mapping, but we'll filter later if needed
@@ -313,4 +578,6 @@ func (m *Model) refreshTable() {
 	} else {
 		m.portForwardsTable.SetRows(m.generatePortForwardRows(configs))
 	}
+	m.tableLoading = false
+	m.updateProblems()
 }
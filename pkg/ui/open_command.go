@@ -0,0 +1,46 @@
+package ui
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// openCommandDoneMsg reports that a forward's open command exited
+type openCommandDoneMsg struct {
+	id      string
+	command string // as run, placeholders replaced
+	err     error
+}
+
+// runOpenCommand runs cfg's open command through the shell. The TUI hands
+// the terminal over until it exits, so interactive clients such as psql or
+// redis-cli work; what it prints stays on the terminal meanwhile.
+func runOpenCommand(cfg config.PortForwardConfig) tea.Cmd {
+	line := cfg.ExpandPlaceholders(cfg.OpenCommand)
+	logging.LogDebug("Running open command for %s: %s", cfg.ID, line)
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", line)
+	} else {
+		cmd = exec.Command("sh", "-c", line)
+	}
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return openCommandDoneMsg{id: cfg.ID, command: line, err: err}
+	})
+}
+
+// handleOpenCommandDone reports how an open command ended
+func (m *Model) handleOpenCommandDone(msg openCommandDoneMsg) {
+	if msg.err != nil {
+		logging.LogError("Open command for %s (%s) failed: %v", msg.id, msg.command, msg.err)
+		m.errorMsg = fmt.Sprintf("%s: %v", msg.command, msg.err)
+		return
+	}
+	m.statusMsg = fmt.Sprintf("%s finished", msg.command)
+}
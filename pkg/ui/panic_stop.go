@@ -0,0 +1,53 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// handlePanicStopKey asks for confirmation on the first press of the panic
+// stop shortcut and stops every forward on the second, if it came right
+// after
+func (m *Model) handlePanicStopKey(confirmed bool) (tea.Model, tea.Cmd) {
+	if !confirmed {
+		m.confirmPanicStop = true
+		m.errorMsg = "Stop every forward in every project and context? Press Ctrl+S again to confirm"
+		return m, nil
+	}
+	m.errorMsg = ""
+	n := m.panicStop()
+	m.statusMsg = fmt.Sprintf("Panic stop: stopped %d port forward(s)", n)
+	return m, nil
+}
+
+// panicStop stops every running, idle and queued forward, whatever its
+// project or context, and cancels every snooze and time limit, so nothing
+// starts again by itself. It returns how many forwards it stopped.
+func (m *Model) panicStop() int {
+	n := m.portForwarder.StopAllFor(k8s.StopReasonPanic)
+	m.snoozedUntil = nil
+	m.expiresAt = nil
+	m.refreshTable()
+	logging.LogDebug("Panic stop: stopped %d port forward(s)", n)
+	return n
+}
+
+// answerStopAllRequest carries out a `kprtfwd stop --all` run since the last
+// one this TUI saw, and answers it with how many forwards it stopped
+func (m *Model) answerStopAllRequest() {
+	at := config.StopAllRequestedAt()
+	if !at.After(m.stopAllSeen) {
+		return
+	}
+	m.stopAllSeen = at
+	n := m.panicStop()
+	m.statusMsg = fmt.Sprintf("Panic stop from the command line at %s: stopped %d port forward(s)", at.Format(time.TimeOnly), n)
+	if err := config.AnswerStopAll(at, n); err != nil {
+		logging.LogError("Failed to answer the panic stop: %v", err)
+	}
+}
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+)
+
+func TestPanicStop(t *testing.T) {
+	useSleepingKubectl(t)
+	cfgs := []config.PortForwardConfig{testForward(t, "dev", "api"), testForward(t, "prod", "api")}
+	m, pf := newTestModel(t, cfgs...)
+	startAll := func() {
+		t.Helper()
+		for _, cfg := range cfgs {
+			if err := pf.Start(cfg); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	startAll()
+	m.snoozedUntil = map[string]time.Time{"gone.ns.db": time.Now().Add(time.Hour)}
+
+	ctrlS := tea.KeyMsg{Type: tea.KeyCtrlS}
+	m.Update(ctrlS)
+	if !pf.IsRunning(cfgs[0].ID) || !m.confirmPanicStop {
+		t.Fatal("the first Ctrl+S must only ask for confirmation")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("?")})
+	m.Update(ctrlS)
+	if !pf.IsRunning(cfgs[0].ID) {
+		t.Fatal("a Ctrl+S after another key must ask again, not stop")
+	}
+	m.Update(ctrlS)
+	for _, cfg := range cfgs {
+		if pf.IsRunning(cfg.ID) {
+			t.Fatalf("%s still runs after the panic stop", cfg.ID)
+		}
+		if s := pf.State(cfg.ID); s.StopReason != k8s.StopReasonPanic {
+			t.Errorf("%s stop reason = %q, want %q", cfg.ID, s.StopReason, k8s.StopReasonPanic)
+		}
+	}
+	if len(m.snoozedUntil) != 0 {
+		t.Error("a panic stop must cancel the snoozes")
+	}
+
+	// From the command line
+	startAll()
+	m.stopAllSeen = config.StopAllRequestedAt()
+	m.answerStopAllRequest()
+	if !pf.IsRunning(cfgs[0].ID) {
+		t.Fatal("no new request: nothing must stop")
+	}
+	time.Sleep(10 * time.Millisecond)
+	at, err := config.RequestStopAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.answerStopAllRequest()
+	if pf.IsRunning(cfgs[0].ID) || pf.IsRunning(cfgs[1].ID) {
+		t.Fatal("stop --all must stop every forward")
+	}
+	answers, err := config.StopAllAnswers(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(answers) != 1 || answers[0].Stopped != 2 {
+		t.Fatalf("answers = %+v, want one stopping 2 forwards", answers)
+	}
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// podCandidatesMsg carries the pods a forward's pod selector matches, for
+// the pin menu
+type podCandidatesMsg struct {
+	id         string
+	candidates []k8s.PodCandidate
+	err        error
+}
+
+// listPodsCmd lists the pods cfg's pod selector matches off the event loop
+func listPodsCmd(pf *k8s.PortForwarder, cfg config.PortForwardConfig) tea.Cmd {
+	return func() tea.Msg {
+		candidates, err := pf.PodCandidates(cfg)
+		return podCandidatesMsg{id: cfg.ID, candidates: candidates, err: err}
+	}
+}
+
+// startPinPod looks up the pods behind cfg to pin it to one (p). Only a
+// forward with a pod selector goes to a pod kprtfwd picks; others go to
+// whichever pod kubectl picks behind the service.
+func (m *Model) startPinPod(cfg config.PortForwardConfig) tea.Cmd {
+	if cfg.PodSelector == "" {
+		m.errorMsg = fmt.Sprintf("%s has no pod selector, so kubectl picks its pod; set one with Enter, Edit pod selector (e.g. app=%s)", cfg.ID, cfg.Service)
+		return nil
+	}
+	m.statusMsg = fmt.Sprintf("Listing the pods behind %s...", cfg.Service)
+	return listPodsCmd(m.portForwarder, cfg)
+}
+
+// handlePodCandidates opens the pin menu on the pods in msg, unless the
+// user moved on meanwhile. The first entry lets the selector choose again.
+func (m *Model) handlePodCandidates(msg podCandidatesMsg) (tea.Model, tea.Cmd) {
+	if m.uiState != StatePortForwards {
+		return m, nil
+	}
+	m.statusMsg = ""
+	if msg.err != nil {
+		logging.LogError("Listing the pods of %s failed: %v", msg.id, msg.err)
+		m.errorMsg = fmt.Sprintf("Cannot list the pods of %s: %s", msg.id, friendlyError(msg.err))
+		return m, nil
+	}
+	if len(msg.candidates) == 0 {
+		m.errorMsg = fmt.Sprintf("No running pod matches the pod selector of %s", msg.id)
+		return m, nil
+	}
+	items := []rowAction{{label: "Automatic: ready pods in pods.zone first, then by name", run: func(m *Model, cfg config.PortForwardConfig) {
+		m.pinPod(cfg, "")
+	}}}
+	for _, c := range msg.candidates {
+		items = append(items, rowAction{label: podLabel(c), run: func(m *Model, cfg config.PortForwardConfig) {
+			m.pinPod(cfg, c.Name)
+		}})
+	}
+	m.actionMenuID = msg.id
+	m.openActionMenu("Pin "+msg.id+" to pod", items)
+	return m, nil
+}
+
+// podLabel describes a pod of the pin menu: "db-1  ready, eu-west-1a, current"
+func podLabel(c k8s.PodCandidate) string {
+	var notes []string
+	if c.Ready {
+		notes = append(notes, "ready")
+	} else {
+		notes = append(notes, "not ready")
+	}
+	if c.Zone != "" {
+		notes = append(notes, c.Zone)
+	}
+	if c.Current {
+		notes = append(notes, "current")
+	}
+	if c.Pinned {
+		notes = append(notes, "pinned")
+	}
+	return c.Name + "  " + strings.Join(notes, ", ")
+}
+
+// pinPod sends cfg to pod ("" to let its pod selector choose) and restarts
+// it if it runs, so the change takes effect now
+func (m *Model) pinPod(cfg config.PortForwardConfig, pod string) {
+	m.portForwarder.PinPod(cfg.ID, pod)
+	what := "pinned to " + pod
+	if pod == "" {
+		what = "no longer pinned to a pod"
+	}
+	if !m.portForwarder.IsRunning(cfg.ID) {
+		m.statusMsg = fmt.Sprintf("%s %s from its next start", cfg.Service, what)
+		return
+	}
+	if err := m.portForwarder.Restart(cfg); err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot restart %s: %s", cfg.Service, friendlyError(err))
+	} else {
+		m.statusMsg = fmt.Sprintf("Restarted %s, %s", cfg.Service, what)
+	}
+	m.refreshTable()
+}
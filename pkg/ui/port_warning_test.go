@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// 50000 is ephemeral on Linux, macOS and Windows alike; 8080 on none
+func TestDiscoveryWarnsAboutEphemeralLocalPorts(t *testing.T) {
+	m := &Model{configStore: &fakeConfigStore{}, uiState: StateServiceDiscovery, width: 120, height: 30}
+	m.initDiscoveryInputs()
+	m.handleServicesDiscovered(servicesDiscoveredMsg{
+		cluster: "ctx",
+		result:  newDiscoveryResult("ctx", "default", "api", discovery.ServicePort{Port: 50000}, discovery.ServicePort{Port: 8080}),
+	})
+
+	rows := m.discoveryTable.Rows()
+	if !strings.HasSuffix(rows[0][5], "⚠") || strings.Contains(rows[1][5], "⚠") {
+		t.Fatalf("LOCAL cells = %q, %q; only 50000 should be marked", rows[0][5], rows[1][5])
+	}
+	if view := m.renderServiceSelectionView(); !strings.Contains(view, "ephemeral range") {
+		t.Fatal("the highlighted row's warning should be shown")
+	}
+
+	// Editing 8080 to an ephemeral port warns while typing
+	m.discoveryTable.SetCursor(1)
+	if view := m.renderServiceSelectionView(); strings.Contains(view, "ephemeral range") {
+		t.Fatal("8080 should not warn")
+	}
+	m.handleDiscoveryEditStart()
+	m.discoveryEditInput.SetValue("50001")
+	if view := m.renderServiceSelectionView(); !strings.Contains(view, "port 50001 is in the OS ephemeral range") {
+		t.Fatal("the port being typed should be checked")
+	}
+}
+
+func TestLocalPortEditWarnsWhileTyping(t *testing.T) {
+	cfg := config.PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080}
+	m, _ := newTestModel(t, cfg)
+	store := m.configStore
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	if !m.editMode || strings.Contains(m.View(), "ephemeral") {
+		t.Fatal("8080 should open the editor without a warning")
+	}
+	m.editInput.SetValue("50000")
+	if !strings.Contains(m.View(), "port 50000 is in the OS ephemeral range") {
+		t.Fatal("the typed port should be checked")
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if got, _ := store.GetConfigByID(cfg.ID); got.PortLocal != 50000 || !strings.Contains(m.statusMsg, "⚠") {
+		t.Fatalf("a risky port is allowed but flagged: port %d, status %q", got.PortLocal, m.statusMsg)
+	}
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// problemsPanel is the screen listing what is wrong with the configuration
+// without stopping kprtfwd (!): local ports two forwards share, forwards a
+// project lists that no longer exist, and contexts the kubeconfig lacks
+type problemsPanel struct {
+	m     *Model
+	table table.Model
+}
+
+// updateProblems rechecks the configuration for the footer's problem count
+// and an open problems panel. The ID convention and forwards outside any
+// project are left to kprtfwd lint: they are housekeeping, not problems.
+func (m *Model) updateProblems() {
+	if m.configStore == nil {
+		return // contexts listed before the store is opened
+	}
+	var problems []config.LintFinding
+	for _, f := range config.Lint(m.configStore.GetAll(), m.configStore.GetAllProjects(), nil) {
+		if f.Rule != config.LintIDConvention && f.Rule != config.LintNoProject {
+			problems = append(problems, f)
+		}
+	}
+	if len(m.knownClusters) > 0 { // unknown until the contexts are listed
+		problems = append(problems, config.LintContexts(m.configStore.GetAll(), m.knownClusters)...)
+	}
+	m.problems = problems
+	if m.uiState == StateProblems {
+		m.problemsPanel.refresh()
+	}
+}
+
+// problemsBadge returns the footer's problem count, "" when there are none
+func (m *Model) problemsBadge() string {
+	if len(m.problems) == 0 {
+		return ""
+	}
+	style := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorWarning))
+	return style.Render(fmt.Sprintf("⚠ %d problem(s) (!)", len(m.problems)))
+}
+
+// enterProblems opens the problems panel
+func (m *Model) enterProblems() {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	if len(m.problems) == 0 {
+		m.statusMsg = "No problems found"
+		return
+	}
+	m.problemsPanel = problemsPanel{m: m}
+	p := &m.problemsPanel
+	p.table = newNavTable(p.columns(), nil, p.tableHeight())
+	p.refresh()
+	m.uiState = StateProblems
+}
+
+// tableHeight returns the problems table height that fits the window
+func (p *problemsPanel) tableHeight() int {
+	return max(min(len(p.m.problems)+2, p.m.height-8), MinTableHeight)
+}
+
+// columns returns columns for the problems table with dynamic widths
+func (p *problemsPanel) columns() []table.Column {
+	availableWidth := p.m.width - 8
+	availableWidth = max(availableWidth, 60) // Minimum total width
+
+	ruleWidth := 16 // "local-port-clash"
+	remaining := availableWidth - ruleWidth
+	subjectWidth := max(remaining*35/100, 12)
+	problemWidth := max(remaining-subjectWidth, 15)
+
+	return []table.Column{
+		{Title: "RULE", Width: ruleWidth},
+		{Title: "FORWARD", Width: subjectWidth},
+		{Title: "PROBLEM", Width: problemWidth},
+	}
+}
+
+// refresh rebuilds the problems table rows from m.problems
+func (p *problemsPanel) refresh() {
+	rows := make([]table.Row, 0, len(p.m.problems))
+	for _, f := range p.m.problems {
+		subject := f.ID
+		if subject == "" {
+			subject = f.Project
+		}
+		rows = append(rows, table.Row{f.Rule, subject, f.Message})
+	}
+	p.table.SetRows(rows)
+	if p.table.Cursor() >= len(rows) {
+		p.table.SetCursor(max(len(rows)-1, 0))
+	}
+}
+
+// Update handles keys in the problems panel
+func (p *problemsPanel) Update(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m := p.m
+	switch msg.String() {
+	case "esc", "q", "!":
+		m.uiState = StatePortForwards
+		m.refreshTable()
+		return m, nil
+	case "enter":
+		idx := p.table.Cursor()
+		if idx < 0 || idx >= len(m.problems) {
+			return m, nil
+		}
+		f := m.problems[idx]
+		if _, exists := m.configStore.GetConfigByID(f.ID); !exists {
+			m.errorMsg = fmt.Sprintf("%s does not exist; remove it from project %s (Ctrl+P, M)", f.ID, f.Project)
+			return m, nil
+		}
+		m.jumpToForward(f.ID)
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		p.table, cmd = p.table.Update(msg)
+		return m, cmd
+	}
+}
+
+// Resize fits the problems table to the window
+func (p *problemsPanel) Resize() {
+	if p.m == nil {
+		return // never shown
+	}
+	p.table.SetColumns(p.columns())
+	p.table.SetHeight(p.tableHeight())
+}
+
+// View renders the problems panel
+func (p *problemsPanel) View() string {
+	m := p.m
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	b.WriteString(titleStyle.Render("⚠ Problems"))
+	b.WriteString("\n\n")
+
+	if len(m.problems) == 0 {
+		b.WriteString("No problems left\n\n")
+	} else {
+		b.WriteString(fmt.Sprintf("%d problem(s) in the configuration; Enter selects the forward concerned\n\n", len(m.problems)))
+		b.WriteString(p.table.View())
+		b.WriteString("\n\n")
+	}
+
+	// The PROBLEM cell truncates long messages; show the selected one in full
+	if idx := p.table.Cursor(); idx >= 0 && idx < len(m.problems) {
+		b.WriteString(m.problems[idx].Message)
+		b.WriteString("\n")
+	}
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	b.WriteString(helpStyle.Render(ActionProblems))
+	b.WriteString("\n")
+
+	if m.errorMsg != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorError)).
+			Bold(true)
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %s", m.errorMsg)))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
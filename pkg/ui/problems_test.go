@@ -0,0 +1,66 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestProblemsPanel(t *testing.T) {
+	cfgs := []config.PortForwardConfig{
+		{ID: "prod.shop.api", Context: "prod", Namespace: "shop", Service: "api", PortRemote: 80, PortLocal: 18080},
+		{ID: "prod.shop.web", Context: "prod", Namespace: "shop", Service: "web", PortRemote: 80, PortLocal: 18080},
+		{ID: "old.data.db", Context: "old", Namespace: "data", Service: "db", PortRemote: 5432, PortLocal: 15432},
+	}
+	m, _ := newTestModel(t, cfgs...)
+	store := m.configStore.(*config.SQLiteConfigStore)
+	if err := store.CreateProject("shop", []string{"prod.shop.api", "prod.shop.gone"}); err != nil {
+		t.Fatal(err)
+	}
+
+	m.refreshTable()
+	if len(m.problems) != 2 {
+		t.Fatalf("before the contexts are listed, problems = %+v, want the clash and the missing forward", m.problems)
+	}
+	m.refreshClusters(clustersLoadedMsg{clusters: []string{"prod"}, current: "prod"})
+	if len(m.problems) != 3 || m.problems[2].ID != "old.data.db" {
+		t.Fatalf("problems = %+v, want old.data.db's context as well", m.problems)
+	}
+	if badge := m.problemsBadge(); !strings.Contains(badge, "3 problem(s)") {
+		t.Errorf("badge = %q", badge)
+	}
+
+	m.updatePortForwards(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("!")})
+	if m.uiState != StateProblems {
+		t.Fatalf("! should open the problems panel, state = %d", m.uiState)
+	}
+	for i, f := range m.problems {
+		if f.Rule == config.LintMissingForward {
+			m.problemsPanel.table.SetCursor(i)
+		}
+	}
+	m.problemsPanel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.uiState != StateProblems || !strings.Contains(m.errorMsg, "prod.shop.gone") {
+		t.Errorf("Enter on a missing forward should explain, state = %d, error = %q", m.uiState, m.errorMsg)
+	}
+
+	m.problemsPanel.table.SetCursor(2)
+	m.problemsPanel.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.uiState != StatePortForwards {
+		t.Fatalf("Enter should select the forward, state = %d", m.uiState)
+	}
+	if idx, err := m.getConfigIndexFromTableRow(); err != nil || store.GetAll()[idx].ID != "old.data.db" {
+		t.Errorf("selected row %d (%v), want old.data.db", idx, err)
+	}
+
+	if err := store.DeletePortForward("old.data.db"); err != nil {
+		t.Fatal(err)
+	}
+	m.refreshTable()
+	if len(m.problems) != 2 {
+		t.Errorf("deleting the forward should drop its problem, got %+v", m.problems)
+	}
+}
@@ -0,0 +1,204 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// projectActivation holds a project activation that is waiting for the user
+// to resolve local port conflicts between the project's forwards. Nothing is
+// stopped, started or stored until every conflict is resolved.
+type projectActivation struct {
+	project  config.Project
+	forwards []config.PortForwardConfig // the project's forwards, remaps applied
+	skipped  map[string]bool            // forwards not to start this time
+	remapped map[string]int             // forward ID → new local port, stored on activation
+	target   int                        // which forward of the current conflict (0 or 1) the next action applies to
+}
+
+// findPortConflicts returns the first pair of forwards in cfgs, ignoring the
+// skipped ones, that want a common local port.
+func findPortConflicts(cfgs []config.PortForwardConfig, skipped map[string]bool) ([2]config.PortForwardConfig, int) {
+	var first [2]config.PortForwardConfig
+	count := 0
+	for i, a := range cfgs {
+		if skipped[a.ID] {
+			continue
+		}
+		for _, b := range cfgs[i+1:] {
+			if skipped[b.ID] || !a.OverlapsLocally(b) {
+				continue
+			}
+			if count == 0 {
+				first = [2]config.PortForwardConfig{a, b}
+			}
+			count++
+		}
+	}
+	return first, count
+}
+
+// projectForwards returns the stored configs of the project's forwards
+func (m *Model) projectForwards(project config.Project) []config.PortForwardConfig {
+	var cfgs []config.PortForwardConfig
+	for _, id := range project.Forwards {
+		if cfg, found := m.configStore.GetConfigByID(id); found {
+			cfgs = append(cfgs, cfg)
+		}
+	}
+	return cfgs
+}
+
+// updateProjectConflicts handles keys in the port conflict dialog
+func (m *Model) updateProjectConflicts(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	a := m.activation
+	pair, count := findPortConflicts(a.forwards, a.skipped)
+	if count == 0 {
+		return m.finishProjectActivation()
+	}
+	target := pair[a.target]
+
+	switch msg.String() {
+	case "esc", "c":
+		m.activation = nil
+		m.uiState = StateProjectSelector
+		m.errorMsg = ""
+		m.statusMsg = fmt.Sprintf("Activation of '%s' cancelled", a.project.Name)
+		return m, nil
+
+	case "tab", "left", "right", "h", "l":
+		a.target = 1 - a.target
+		return m, nil
+
+	case "s":
+		a.skipped[target.ID] = true
+		logging.LogDebug("Project '%s': skipping '%s' to resolve a port conflict", a.project.Name, target.ID)
+
+	case "r":
+		port, ok := config.NextFreeLocalPort(target, m.allForwardsWith(a.forwards))
+		if !ok {
+			m.errorMsg = fmt.Sprintf("No free local port above %d for %s", target.PortLocal, target.Service)
+			return m, nil
+		}
+		for i := range a.forwards {
+			if a.forwards[i].ID == target.ID {
+				a.forwards[i].PortLocal = port
+			}
+		}
+		a.remapped[target.ID] = port
+		logging.LogDebug("Project '%s': remapping '%s' to local port %d", a.project.Name, target.ID, port)
+
+	default:
+		return m, nil
+	}
+
+	m.errorMsg = ""
+	a.target = 1
+	if _, left := findPortConflicts(a.forwards, a.skipped); left == 0 {
+		return m.finishProjectActivation()
+	}
+	return m, nil
+}
+
+// allForwardsWith returns every stored forward, with the project's pending
+// remaps applied, so a remap never lands on a port another forward uses.
+func (m *Model) allForwardsWith(pending []config.PortForwardConfig) []config.PortForwardConfig {
+	byID := make(map[string]config.PortForwardConfig, len(pending))
+	for _, cfg := range pending {
+		byID[cfg.ID] = cfg
+	}
+	var all []config.PortForwardConfig
+	for _, cfg := range m.configStore.GetAll() {
+		if p, ok := byID[cfg.ID]; ok {
+			cfg = p
+		}
+		all = append(all, cfg)
+	}
+	return all
+}
+
+// finishProjectActivation stores the chosen remaps and activates the project
+// without the skipped forwards
+func (m *Model) finishProjectActivation() (tea.Model, tea.Cmd) {
+	a := m.activation
+	m.activation = nil
+
+	if len(a.remapped) > 0 {
+		sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+		if !ok {
+			m.errorMsg = "Update not supported with current config store"
+			m.uiState = StateProjectSelector
+			return m, nil
+		}
+		var updates []config.PortForwardConfig
+		for _, cfg := range a.forwards {
+			if _, ok := a.remapped[cfg.ID]; ok {
+				updates = append(updates, cfg)
+			}
+		}
+		if err := sqliteStore.UpdatePortForwards(updates); err != nil {
+			m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+			m.uiState = StateProjectSelector
+			return m, nil
+		}
+	}
+
+	m.activateProject(a.project, a.skipped)
+	if m.errorMsg == "" && (len(a.remapped) > 0 || len(a.skipped) > 0) {
+		m.statusMsg += fmt.Sprintf(" (%d remapped, %d skipped)", len(a.remapped), len(a.skipped))
+	}
+	return m, nil
+}
+
+// renderProjectConflicts renders the port conflict dialog
+func (m *Model) renderProjectConflicts() string {
+	var b strings.Builder
+	a := m.activation
+	pair, count := findPortConflicts(a.forwards, a.skipped)
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	b.WriteString(titleStyle.Render(fmt.Sprintf("⚠️  Local Port Conflict - Project: %s", a.project.Name)))
+	b.WriteString("\n\n")
+
+	port := max(pair[0].PortLocal, pair[1].PortLocal)
+	b.WriteString(fmt.Sprintf("These forwards both want local port %d (%d conflict(s) left):\n\n", port, count))
+	selected := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorSelectedFg)).
+		Background(lipgloss.Color(ColorSelectedBg)).Reverse(monoTheme)
+	for i, cfg := range pair {
+		line := fmt.Sprintf("%s/%s/%s  localhost:%s", cfg.Context, cfg.Namespace, cfg.Service, formatPorts(cfg.PortLocal, cfg.Ports()))
+		if i == a.target {
+			b.WriteString("▶ " + selected.Render(line))
+		} else {
+			b.WriteString("  " + line)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	target := pair[a.target]
+	actions := fmt.Sprintf("Tab: Switch | R: Remap %s | S: Skip %s | Esc: Cancel activation", target.Service, target.Service)
+	if port, ok := config.NextFreeLocalPort(target, m.allForwardsWith(a.forwards)); ok {
+		actions = fmt.Sprintf("Tab: Switch | R: Remap %s to %d | S: Skip %s | Esc: Cancel activation", target.Service, port, target.Service)
+	}
+	b.WriteString(lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp)).Render(actions))
+	b.WriteString("\n")
+
+	if m.errorMsg != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorError)).
+			Bold(true)
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %s", m.errorMsg)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// newConflictModel returns a model whose only project has two forwards on
+// local port 8080, with its selector cursor on that project.
+func newConflictModel(t *testing.T) (*Model, config.ConfigStoreInterface) {
+	t.Helper()
+	t.Setenv("PATH", t.TempDir()) // no kubectl: starts fail instead of forwarding
+
+	var cfgs []config.PortForwardConfig
+	for _, service := range []string{"api", "web"} {
+		cfgs = append(cfgs, config.PortForwardConfig{
+			ID: "ctx.ns." + service, Context: "ctx", Namespace: "ns",
+			Service: service, PortRemote: 80, PortLocal: 8080,
+		})
+	}
+	m, _ := newTestModel(t, cfgs...)
+	if err := m.configStore.CreateProject("team", []string{"ctx.ns.api", "ctx.ns.web"}); err != nil {
+		t.Fatal(err)
+	}
+	m.initializeProjectSelector()
+	m.projectSelector.SetCursor(1)
+	return m, m.configStore
+}
+func TestProjectActivationAsksAboutPortConflicts(t *testing.T) {
+	m, store := newConflictModel(t)
+
+	m.handleProjectSelection()
+	if m.uiState != StateProjectConflicts {
+		t.Fatalf("uiState = %d, want the conflict dialog", m.uiState)
+	}
+	if store.GetActiveProjectName() != "" {
+		t.Fatal("the project must not be activated before the conflict is resolved")
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if m.uiState != StatePortForwards || store.GetActiveProjectName() != "team" {
+		t.Fatalf("resolving the only conflict should activate the project (state %d)", m.uiState)
+	}
+	web, _ := store.GetConfigByID("ctx.ns.web")
+	api, _ := store.GetConfigByID("ctx.ns.api")
+	if web.PortLocal != 8081 || api.PortLocal != 8080 {
+		t.Fatalf("remap stored api:%d web:%d, want api:8080 web:8081", api.PortLocal, web.PortLocal)
+	}
+}
+
+func TestCancellingConflictDialogChangesNothing(t *testing.T) {
+	m, store := newConflictModel(t)
+
+	m.handleProjectSelection()
+	m.Update(tea.KeyMsg{Type: tea.KeyTab})
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+
+	if m.uiState != StateProjectSelector || m.activation != nil {
+		t.Fatalf("Esc should return to the project selector (state %d)", m.uiState)
+	}
+	if store.GetActiveProjectName() != "" {
+		t.Fatal("a cancelled activation must not activate the project")
+	}
+	for _, cfg := range store.GetAll() {
+		if cfg.PortLocal != 8080 {
+			t.Fatalf("a cancelled activation changed %s to port %d", cfg.ID, cfg.PortLocal)
+		}
+	}
+}
+
+func TestSkippingConflictingForward(t *testing.T) {
+	m, _ := newConflictModel(t)
+
+	m.handleProjectSelection()
+	m.Update(tea.KeyMsg{Type: tea.KeyTab}) // act on api instead of web
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("s")})
+
+	if m.uiState != StatePortForwards {
+		t.Fatalf("skipping one side should resolve the conflict (state %d)", m.uiState)
+	}
+	if m.portForwarder.IsError("ctx.ns.api") || m.portForwarder.IsRunning("ctx.ns.api") {
+		t.Fatal("a skipped forward must not be started")
+	}
+}
+
+func TestStartupFlags(t *testing.T) {
+	m, store := newConflictModel(t)
+
+	if err := m.Startup("nope", false); err == nil {
+		t.Fatal("an unknown project should be an error")
+	}
+
+	if err := m.Startup("team", true); err != nil {
+		t.Fatal(err)
+	}
+	if m.uiState != StateProjectConflicts {
+		t.Fatalf("uiState = %d, want the conflict dialog for --project", m.uiState)
+	}
+	if store.GetActiveProjectName() != "" || m.statusMsg != "" || m.errorMsg != "" {
+		t.Fatal("nothing may be started while the conflict dialog is open")
+	}
+
+	// Without a conflicting project, --start-all tries every forward; with no
+	// kubectl on PATH they all fail and are reported
+	m, _ = newConflictModel(t)
+	if err := m.Startup("", true); err != nil {
+		t.Fatal(err)
+	}
+	if m.errorMsg != "Started 0 forwards, 2 failed: ctx.ns.api, ctx.ns.web" {
+		t.Fatalf("errorMsg = %q", m.errorMsg)
+	}
+}
@@ -0,0 +1,23 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestProjectServiceSelectionShowsStatus(t *testing.T) {
+	cfg := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080}
+	m, _ := newTestModel(t, cfg)
+	m.enterProjectServiceSelection(config.Project{Name: "backend"})
+
+	columns := m.projectServiceTable.Columns()
+	if columns[len(columns)-1].Title != ColStatus {
+		t.Fatalf("last column = %q, want %s", columns[len(columns)-1].Title, ColStatus)
+	}
+	row := m.projectServiceTable.Rows()[0]
+	if len(row) != len(columns) || !strings.Contains(row[len(row)-1], StatusStopped) {
+		t.Fatalf("expected a Stopped status cell, got %v", row)
+	}
+}
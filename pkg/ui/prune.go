@@ -0,0 +1,370 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// What the prune view does with a stale forward when applied
+const (
+	pruneDelete   = "delete"
+	pruneKeep     = "keep"
+	pruneRetarget = "rename" // point the forward at the service's new name
+)
+
+// pruneRow is one stale forward in the prune view and what to do with it
+type pruneRow struct {
+	stale  discovery.StaleForward
+	action string
+}
+
+// pruneScannedMsg is delivered when the prune view's discovery run finishes
+type pruneScannedMsg struct {
+	context string
+	result  *discovery.DiscoveryResult
+	err     error
+}
+
+// pruneScanCmd discovers the services of kubeContext without blocking the UI
+func pruneScanCmd(kubeContext string) tea.Cmd {
+	return func() tea.Msg {
+		result, err := discovery.DiscoverServices(discovery.Options{Context: kubeContext, NamespaceFilter: "*"})
+		return pruneScannedMsg{context: kubeContext, result: result, err: err}
+	}
+}
+
+// enterPrune opens the prune view for the context of the selected row (the
+// current context if nothing is selected) and starts looking for stale
+// forwards in it.
+func (m *Model) enterPrune() (tea.Model, tea.Cmd) {
+	m.errorMsg = ""
+	m.statusMsg = ""
+	kubeContext := ""
+	if m.isGroupHeaderSelected() {
+		if name := m.getSelectedGroupName(); name != "(no context)" && !isVirtualGroup(name) {
+			kubeContext = name
+		}
+	} else if idx, err := m.getConfigIndexFromTableRow(); err == nil {
+		if cfg, err := m.configStore.GetWithError(idx); err == nil {
+			kubeContext = cfg.Context
+		}
+	}
+
+	m.uiState = StatePrune
+	m.pruneContext = kubeContext
+	m.pruneLoading = true
+	m.pruneRows = nil
+	m.pruneDeleteEmpty = false
+	return m, pruneScanCmd(kubeContext)
+}
+
+// handlePruneScanned turns the discovery result into the prune table
+func (m *Model) handlePruneScanned(msg pruneScannedMsg) (tea.Model, tea.Cmd) {
+	if m.uiState != StatePrune || !m.pruneLoading || msg.context != m.pruneContext {
+		return m, nil // cancelled, or superseded by another scan
+	}
+	m.pruneLoading = false
+	if msg.err != nil {
+		logging.LogError("Prune scan of context '%s' failed: %v", msg.context, msg.err)
+		m.errorMsg = fmt.Sprintf("Cannot list services: %s", friendlyError(msg.err))
+		return m, nil
+	}
+	m.pruneContext = msg.result.Context
+
+	m.pruneRows = nil
+	for _, s := range discovery.FindStaleForwards(m.configStore.GetAll(), msg.result, m.configStore.GetServiceSnapshot) {
+		action := pruneDelete
+		if s.RenamedTo != nil {
+			action = pruneRetarget
+		}
+		m.pruneRows = append(m.pruneRows, pruneRow{stale: s, action: action})
+	}
+
+	m.pruneTable = newNavTable(m.calculatePruneColumns(), nil, m.pruneTableHeight())
+	m.refreshPruneTable()
+	return m, nil
+}
+
+// pruneTableHeight returns the prune table height that fits the window
+func (m *Model) pruneTableHeight() int {
+	return max(min(len(m.pruneRows)+2, m.height-10), MinTableHeight)
+}
+
+// resizePrune fits the prune table to the window
+func (m *Model) resizePrune() {
+	if m.pruneTable.Rows() == nil {
+		return
+	}
+	m.pruneTable.SetColumns(m.calculatePruneColumns())
+	m.pruneTable.SetHeight(m.pruneTableHeight())
+}
+
+// calculatePruneColumns returns columns for the prune table with dynamic widths
+func (m *Model) calculatePruneColumns() []table.Column {
+	availableWidth := m.width - 10
+	availableWidth = max(availableWidth, 70) // Minimum total width
+
+	actionWidth := 8
+	remaining := availableWidth - actionWidth
+	forwardWidth := max(remaining*30/100, 12)
+	serviceWidth := max(remaining*25/100, 12)
+	findingWidth := max(remaining*25/100, 12)
+	projectsWidth := max(remaining-forwardWidth-serviceWidth-findingWidth, 10)
+
+	return []table.Column{
+		{Title: "ACTION", Width: actionWidth},
+		{Title: "FORWARD", Width: forwardWidth},
+		{Title: "SERVICE", Width: serviceWidth},
+		{Title: "FINDING", Width: findingWidth},
+		{Title: "PROJECTS", Width: projectsWidth},
+	}
+}
+
+// refreshPruneTable rebuilds the prune table rows from m.pruneRows
+func (m *Model) refreshPruneTable() {
+	projects := m.projectsByForward()
+	rows := make([]table.Row, 0, len(m.pruneRows))
+	for _, r := range m.pruneRows {
+		cfg := r.stale.Config
+		finding := "deleted"
+		if r.stale.RenamedTo != nil {
+			finding = "renamed to " + r.stale.RenamedTo.Name
+		}
+		inProjects := "-"
+		if names := projects[cfg.ID]; len(names) > 0 {
+			inProjects = strings.Join(names, ", ")
+		}
+		rows = append(rows, table.Row{
+			r.action,
+			cfg.ID,
+			fmt.Sprintf("%s/%s:%s", cfg.Namespace, cfg.Service, formatPorts(cfg.PortRemote, cfg.Ports())),
+			finding,
+			inProjects,
+		})
+	}
+	m.pruneTable.SetRows(rows)
+}
+
+// projectsByForward maps each forward ID to the projects that list it
+func (m *Model) projectsByForward() map[string][]string {
+	return config.ProjectsByForward(m.configStore.GetAllProjects())
+}
+
+// updatePrune handles keys in the prune view
+func (m *Model) updatePrune(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc", "q":
+		m.uiState = StatePortForwards
+		m.pruneRows = nil
+		m.pruneLoading = false
+		m.errorMsg = ""
+		m.statusMsg = "Prune cancelled, nothing changed"
+		return m, nil
+	}
+	if m.pruneLoading || len(m.pruneRows) == 0 {
+		return m, nil
+	}
+
+	switch msg.String() {
+	case " ":
+		idx := m.pruneTable.Cursor()
+		if idx < 0 || idx >= len(m.pruneRows) {
+			return m, nil
+		}
+		r := &m.pruneRows[idx]
+		switch r.action {
+		case pruneRetarget:
+			r.action = pruneDelete
+		case pruneDelete:
+			r.action = pruneKeep
+		default:
+			r.action = pruneDelete
+			if r.stale.RenamedTo != nil {
+				r.action = pruneRetarget
+			}
+		}
+		m.refreshPruneTable()
+		return m, nil
+	case "x":
+		if len(config.EmptiedProjects(m.pruneProjectLosses())) > 0 {
+			m.pruneDeleteEmpty = !m.pruneDeleteEmpty
+		}
+		return m, nil
+	case "enter":
+		m.applyPrune()
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		m.pruneTable, cmd = m.pruneTable.Update(msg)
+		return m, cmd
+	}
+}
+
+// applyPrune carries out the chosen action for every row and returns to the
+// main view
+func (m *Model) applyPrune() {
+	// Only projects these deletes empty are cleaned up, not ones that were
+	// empty before
+	var emptied []string
+	if m.pruneDeleteEmpty {
+		emptied = config.EmptiedProjects(m.pruneProjectLosses())
+	}
+
+	m.uiState = StatePortForwards
+	rows := m.pruneRows
+	m.pruneRows = nil
+	m.errorMsg = ""
+
+	sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+	if !ok {
+		m.errorMsg = "Prune not supported with current config store"
+		return
+	}
+
+	var deleted, retargeted, kept int
+	var failed []string
+	for _, r := range rows {
+		cfg := r.stale.Config
+		switch r.action {
+		case pruneDelete:
+			// The store's delete hook stops the forward first
+			if err := sqliteStore.DeletePortForward(cfg.ID); err != nil {
+				logging.LogError("Prune: deleting '%s' failed: %v", cfg.ID, err)
+				failed = append(failed, cfg.ID)
+				continue
+			}
+			deleted++
+		case pruneRetarget:
+			updated := cfg
+			updated.Service = r.stale.RenamedTo.Name
+			if err := sqliteStore.UpdatePortForward(cfg.ID, updated); err != nil {
+				logging.LogError("Prune: retargeting '%s' failed: %v", cfg.ID, err)
+				failed = append(failed, cfg.ID)
+				continue
+			}
+			if m.portForwarder.IsRunning(cfg.ID) {
+				if err := m.portForwarder.Restart(updated); err != nil {
+					logging.LogError("Prune: restarting '%s' failed: %v", cfg.ID, err)
+				}
+			}
+			retargeted++
+		default:
+			kept++
+		}
+	}
+
+	removedProjects := 0
+	for _, project := range m.configStore.GetAllProjects() {
+		// A failed delete leaves its projects with a forward, so they stay
+		if len(project.Forwards) > 0 || !slices.Contains(emptied, project.Name) {
+			continue
+		}
+		if err := sqliteStore.DeleteProject(project.Name); err != nil {
+			logging.LogError("Prune: deleting empty project '%s' failed: %v", project.Name, err)
+			failed = append(failed, "project "+project.Name)
+			continue
+		}
+		removedProjects++
+	}
+
+	m.statusMsg = fmt.Sprintf("Pruned %s: %d deleted, %d renamed, %d kept", m.pruneContext, deleted, retargeted, kept)
+	if removedProjects > 0 {
+		m.statusMsg += fmt.Sprintf(", %d empty project(s) removed", removedProjects)
+	}
+	if len(failed) > 0 {
+		m.errorMsg = fmt.Sprintf("Could not prune %s", strings.Join(failed, ", "))
+	}
+	if m.filtering() {
+		m.applyFilter()
+	}
+	m.refreshTable()
+}
+
+// pruneProjectLosses returns what deleting the rows marked for deletion
+// would do to the projects
+func (m *Model) pruneProjectLosses() []config.ProjectLoss {
+	var ids []string
+	for _, r := range m.pruneRows {
+		if r.action == pruneDelete {
+			ids = append(ids, r.stale.Config.ID)
+		}
+	}
+	return config.ProjectLosses(m.configStore.GetAllProjects(), ids)
+}
+
+// pruneSummary describes what applying the prune view would do, including
+// the projects that would lose forwards or be left empty
+func (m *Model) pruneSummary() string {
+	counts := make(map[string]int)
+	for _, r := range m.pruneRows {
+		counts[r.action]++
+	}
+	summary := fmt.Sprintf("%d to delete, %d to rename, %d to keep", counts[pruneDelete], counts[pruneRetarget], counts[pruneKeep])
+	losses := m.pruneProjectLosses()
+	if len(losses) == 0 {
+		return summary
+	}
+	summary += "\nDeleting removes forwards from projects: " + config.JoinProjectLosses(losses)
+	if emptied := config.EmptiedProjects(losses); len(emptied) > 0 {
+		verdict := "kept (x: delete them)"
+		if m.pruneDeleteEmpty {
+			verdict = "deleted too (x: keep them)"
+		}
+		summary += fmt.Sprintf("\nProjects left empty (%s) will be %s", strings.Join(emptied, ", "), verdict)
+	}
+	return summary
+}
+
+// renderPrune renders the prune view
+func (m *Model) renderPrune() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	kubeContext := m.pruneContext
+	if kubeContext == "" {
+		kubeContext = "(current context)" // until discovery resolves it
+	}
+	b.WriteString(titleStyle.Render(fmt.Sprintf("🧹 Prune - Context: %s", kubeContext)))
+	b.WriteString("\n\n")
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	switch {
+	case m.pruneLoading:
+		b.WriteString("Looking for forwards whose service no longer exists...\n\n")
+		b.WriteString(helpStyle.Render("Esc: Cancel"))
+	case m.errorMsg != "":
+		b.WriteString(helpStyle.Render("Esc: Back"))
+	case len(m.pruneRows) == 0:
+		b.WriteString("✅ No stale forwards: every configured service still exists.\n\n")
+		b.WriteString(helpStyle.Render("Esc: Back"))
+	default:
+		b.WriteString(fmt.Sprintf("%d forward(s) point at services that no longer exist:\n\n", len(m.pruneRows)))
+		b.WriteString(m.pruneTable.View())
+		b.WriteString("\n\n")
+		b.WriteString(m.pruneSummary())
+		b.WriteString("\n\n")
+		b.WriteString(helpStyle.Render(ActionPrune))
+	}
+	b.WriteString("\n")
+
+	if m.errorMsg != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorError)).
+			Bold(true)
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %s", m.errorMsg)))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
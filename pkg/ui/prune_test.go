@@ -0,0 +1,112 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestPruneView(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no kubectl
+
+	labels := map[string]string{"app": "api"}
+	m, _ := newTestModel(t,
+		config.PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080},
+		config.PortForwardConfig{ID: "ctx.ns.db", Context: "ctx", Namespace: "ns", Service: "db", PortRemote: 5432, PortLocal: 5432},
+		config.PortForwardConfig{ID: "ctx.ns.gone", Context: "ctx", Namespace: "ns", Service: "gone", PortRemote: 80, PortLocal: 8081},
+		config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8082},
+	)
+	store := m.configStore
+	if err := store.SetServiceSnapshot("ctx.ns.api", config.ServiceSnapshot{Type: "ClusterIP", Labels: labels, TargetPort: "8080"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateProject("team", []string{"ctx.ns.db", "ctx.ns.web"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CreateProject("legacy", []string{"ctx.ns.gone"}); err != nil {
+		t.Fatal(err)
+	}
+	m.groupingEnabled = true
+	m.applyColumnLayout()
+
+	m.portForwardsTable.SetCursor(1)
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlU}); m.uiState != StatePrune || cmd == nil {
+		t.Fatal("Ctrl+U should open the prune view and start a scan")
+	}
+	if m.pruneContext != "ctx" {
+		t.Fatalf("prune should scan the selected forward's context, got %q", m.pruneContext)
+	}
+
+	svc := func(name string, labels map[string]string, targetPort string) discovery.DiscoveredService {
+		return discovery.DiscoveredService{ServiceInfo: discovery.ServiceInfo{
+			Name: name, Namespace: "ns", Type: "ClusterIP", Labels: labels,
+			Ports: []discovery.ServicePort{{Port: 80, TargetPort: targetPort}},
+		}}
+	}
+	m.Update(pruneScannedMsg{context: "ctx", result: &discovery.DiscoveryResult{
+		Context: "ctx", NamespaceFilter: "*",
+		Services: []discovery.DiscoveredService{svc("web", nil, "80"), svc("api-v2", labels, "8080")},
+	}})
+	var actions []string
+	for _, r := range m.pruneRows {
+		actions = append(actions, r.stale.Config.ID+"="+r.action)
+	}
+	if got := strings.Join(actions, " "); got != "ctx.ns.api=rename ctx.ns.db=delete ctx.ns.gone=delete" {
+		t.Fatalf("prune rows = %s", got)
+	}
+	for _, want := range []string{"legacy (1 of 1, left empty), team (1 of 2)", "Projects left empty (legacy) will be kept"} {
+		if !strings.Contains(m.renderPrune(), want) {
+			t.Fatalf("the view should show %q:\n%s", want, m.renderPrune())
+		}
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("x")})
+	if !m.pruneDeleteEmpty || !strings.Contains(m.pruneSummary(), "will be deleted too") {
+		t.Fatalf("x should choose to delete the emptied project:\n%s", m.pruneSummary())
+	}
+
+	// Keep the db forward: delete → keep
+	m.pruneTable.SetCursor(1)
+	m.Update(tea.KeyMsg{Type: tea.KeySpace, Runes: []rune(" ")})
+	if m.pruneRows[1].action != pruneKeep {
+		t.Fatalf("space should toggle delete to keep, got %s", m.pruneRows[1].action)
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if m.uiState != StatePortForwards {
+		t.Fatal("applying should return to the main view")
+	}
+	if got, ok := store.GetConfigByID("ctx.ns.api"); !ok || got.Service != "api-v2" {
+		t.Fatalf("the renamed service's forward should point at api-v2, got %+v", got)
+	}
+	if _, ok := store.GetConfigByID("ctx.ns.db"); !ok {
+		t.Fatal("a kept forward must not be deleted")
+	}
+	if _, ok := store.GetConfigByID("ctx.ns.gone"); ok {
+		t.Fatal("the deleted service's forward should be gone")
+	}
+	var projects []string
+	for _, project := range store.GetAllProjects() {
+		projects = append(projects, project.Name)
+	}
+	if strings.Join(projects, ",") != "team" {
+		t.Fatalf("only the project the prune emptied should be deleted, left %v", projects)
+	}
+	if m.statusMsg != "Pruned ctx: 1 deleted, 1 renamed, 1 kept, 1 empty project(s) removed" {
+		t.Fatalf("unexpected status %q (error %q)", m.statusMsg, m.errorMsg)
+	}
+}
+
+func TestPruneViewIgnoresScanAfterCancel(t *testing.T) {
+	m, _ := newTestModel(t)
+
+	m.enterPrune()
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	m.Update(pruneScannedMsg{result: &discovery.DiscoveryResult{Context: "ctx", NamespaceFilter: "*"}})
+	if m.uiState != StatePortForwards || m.pruneRows != nil {
+		t.Fatal("a scan finishing after Esc must not reopen the prune view")
+	}
+}
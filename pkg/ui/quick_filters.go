@@ -0,0 +1,99 @@
+package ui
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	"github.com/charmbracelet/lipgloss"
+)
+
+// FavoriteTag marks a forward as a favorite for the favorites quick filter
+const FavoriteTag = "favorite"
+
+// quickFilter is a predicate toggled with one key in the main view and shown
+// as a chip next to the filter box. Active quick filters combine with each
+// other and with the text filter.
+type quickFilter struct {
+	key   string
+	label string
+	match func(m *Model, cfg config.PortForwardConfig, state k8s.ForwardState) bool
+}
+
+// quickFilters lists the quick filters in chip order
+var quickFilters = []quickFilter{
+	{key: "1", label: "running", match: func(_ *Model, _ config.PortForwardConfig, state k8s.ForwardState) bool {
+		return state.Running
+	}},
+	{key: "2", label: "failed", match: func(_ *Model, _ config.PortForwardConfig, state k8s.ForwardState) bool {
+		return state.Failed
+	}},
+	{key: "3", label: "current context", match: func(m *Model, cfg config.PortForwardConfig, _ k8s.ForwardState) bool {
+		return m.currentContext != "" && cfg.Context == m.currentContext
+	}},
+	{key: "4", label: "favorites", match: func(_ *Model, cfg config.PortForwardConfig, _ k8s.ForwardState) bool {
+		return slices.Contains(cfg.TagList(), FavoriteTag)
+	}},
+}
+
+// quickFilterForKey returns the quick filter toggled by key, if any
+func quickFilterForKey(key string) (quickFilter, bool) {
+	for _, qf := range quickFilters {
+		if qf.key == key {
+			return qf, true
+		}
+	}
+	return quickFilter{}, false
+}
+
+// toggleQuickFilter turns the quick filter on or off and re-filters the table
+func (m *Model) toggleQuickFilter(qf quickFilter) {
+	if m.activeQuickFilters == nil {
+		m.activeQuickFilters = make(map[string]bool)
+	}
+	if m.activeQuickFilters[qf.key] {
+		delete(m.activeQuickFilters, qf.key)
+	} else {
+		m.activeQuickFilters[qf.key] = true
+	}
+	m.applyFilter()
+	m.refreshTable()
+	m.portForwardsTable.SetCursor(0)
+}
+
+// filtering reports whether the table lists a filter result rather than the
+// active project's forwards: while the filter is typed or set, or a quick
+// filter is on.
+func (m *Model) filtering() bool {
+	return m.filterMode || m.filterInput.Value() != "" || len(m.activeQuickFilters) > 0
+}
+
+// matchesQuickFilters reports whether cfg passes every active quick filter
+func (m *Model) matchesQuickFilters(cfg config.PortForwardConfig, states map[string]k8s.ForwardState) bool {
+	for _, qf := range quickFilters {
+		if m.activeQuickFilters[qf.key] && !qf.match(m, cfg, states[cfg.ID]) {
+			return false
+		}
+	}
+	return true
+}
+
+// renderQuickFilterChips renders one chip per quick filter with its key,
+// highlighting the active ones
+func (m *Model) renderQuickFilterChips() string {
+	active := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorSelectedFg)).
+		Background(lipgloss.Color(ColorSelectedBg)).Reverse(monoTheme)
+	inactive := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	chips := make([]string, 0, len(quickFilters))
+	for _, qf := range quickFilters {
+		style := inactive
+		if m.activeQuickFilters[qf.key] {
+			style = active
+		}
+		chips = append(chips, style.Render("["+qf.key+"] "+qf.label))
+	}
+	return strings.Join(chips, " ")
+}
@@ -0,0 +1,57 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestQuickFiltersCombineWithTextFilter(t *testing.T) {
+	m, _ := newTestModel(t,
+		config.PortForwardConfig{ID: "dev.ns.api", Context: "dev", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080, Tags: "favorite"},
+		config.PortForwardConfig{ID: "dev.ns.db", Context: "dev", Namespace: "ns", Service: "db", PortRemote: 5432, PortLocal: 5432},
+		config.PortForwardConfig{ID: "prod.ns.api", Context: "prod", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 18080, Tags: "favorite db"},
+	)
+	m.groupingEnabled, m.currentContext = true, "dev"
+	m.applyColumnLayout()
+
+	listed := func() []string {
+		var ids []string
+		for _, cfg := range m.listedConfigs() {
+			ids = append(ids, cfg.ID)
+		}
+		return ids
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("4")})
+	if got := listed(); !slices.Equal(got, []string{"dev.ns.api", "prod.ns.api"}) {
+		t.Fatalf("favorites = %v", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")})
+	if got := listed(); !slices.Equal(got, []string{"dev.ns.api"}) {
+		t.Fatalf("favorites in the current context = %v", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("3")})
+	m.filterInput.SetValue("prod")
+	m.applyFilter()
+	if got := listed(); !slices.Equal(got, []string{"prod.ns.api"}) {
+		t.Fatalf("favorites matching the text filter = %v", got)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	if got := listed(); len(got) != 0 {
+		t.Fatalf("nothing runs, got %v", got)
+	}
+
+	// Esc clears the text filter first, then the quick filters
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if len(m.activeQuickFilters) != 2 {
+		t.Fatalf("the first Esc must keep the quick filters, got %v", m.activeQuickFilters)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.filtering() || len(listed()) != 3 {
+		t.Fatalf("the second Esc must clear the quick filters, listed %v", listed())
+	}
+}
@@ -0,0 +1,181 @@
+package ui
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// restartReport is the screen showing the outcome of a Ctrl+R restart, one
+// row per forward. Unlike the older views it keeps its state to itself.
+type restartReport struct {
+	m       *Model
+	results []k8s.RestartedForward
+	table   table.Model
+}
+
+// enterRestartReport shows the outcome of a Ctrl+R restart, one row per forward
+func (m *Model) enterRestartReport(results []k8s.RestartedForward) {
+	m.restart = restartReport{m: m, results: results}
+	r := &m.restart
+	r.table = newNavTable(r.columns(), nil, r.tableHeight())
+	r.refresh()
+	m.uiState = StateRestartReport
+}
+
+// tableHeight returns the report table height that fits the window
+func (r *restartReport) tableHeight() int {
+	return min(len(r.results)+2, r.m.height-8)
+}
+
+// columns returns columns for the restart report with dynamic widths
+func (r *restartReport) columns() []table.Column {
+	availableWidth := r.m.width - 8
+	availableWidth = max(availableWidth, 60) // Minimum total width
+
+	localWidth := 11 // "65530-65535"
+	pidWidth := 8
+	remaining := availableWidth - localWidth - pidWidth
+	serviceWidth := max(remaining*35/100, 12)
+	resultWidth := max(remaining-serviceWidth, 15)
+
+	return []table.Column{
+		{Title: "FORWARD", Width: serviceWidth},
+		{Title: "LOCAL", Width: localWidth},
+		{Title: "PID", Width: pidWidth},
+		{Title: "RESULT", Width: resultWidth},
+	}
+}
+
+// refresh rebuilds the report rows from r.results
+func (r *restartReport) refresh() {
+	rows := make([]table.Row, 0, len(r.results))
+	for _, res := range r.results {
+		name, local := res.ID, "-"
+		if cfg, exists := r.m.configStore.GetConfigByID(res.ID); exists {
+			name = cfg.Service
+			local = formatPorts(cfg.PortLocal, cfg.Ports())
+		}
+		pid := "-"
+		if res.PID != 0 {
+			pid = strconv.Itoa(res.PID)
+		} else if res.Err == nil {
+			pid = "lazy"
+		}
+		result := "restarted"
+		if res.Err != nil {
+			result = friendlyError(res.Err)
+		}
+		rows = append(rows, table.Row{name, local, pid, result})
+	}
+	r.table.SetRows(rows)
+}
+
+// Update handles keys in the restart report view
+func (r *restartReport) Update(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	m := r.m
+	switch msg.String() {
+	case "esc", "enter", "q":
+		m.uiState = StatePortForwards
+		m.refreshTable()
+		return m, nil
+	case "r":
+		r.retry(r.table.Cursor())
+		return m, nil
+	default:
+		var cmd tea.Cmd
+		r.table, cmd = r.table.Update(msg)
+		return m, cmd
+	}
+}
+
+// Resize fits the report table to the window
+func (r *restartReport) Resize() {
+	if r.m == nil {
+		return // never shown
+	}
+	r.table.SetColumns(r.columns())
+	r.table.SetHeight(r.tableHeight())
+}
+
+// retry restarts the forward in report row idx again and records the new
+// outcome in its row
+func (r *restartReport) retry(idx int) {
+	if idx < 0 || idx >= len(r.results) {
+		return
+	}
+	m := r.m
+	res := &r.results[idx]
+	m.errorMsg = ""
+	m.statusMsg = ""
+
+	cfg, exists := m.configStore.GetConfigByID(res.ID)
+	if !exists {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", res.ID)
+		return
+	}
+	if err := m.portForwarder.Restart(cfg); err != nil {
+		logging.LogError("Retrying restart of '%s' failed: %v", cfg.ID, err)
+		*res = k8s.RestartedForward{ID: cfg.ID, LocalPort: cfg.PortLocal, Err: err}
+		m.errorMsg = fmt.Sprintf("%s: %s", cfg.Service, friendlyError(err))
+	} else {
+		*res = k8s.RestartedForward{ID: cfg.ID, LocalPort: cfg.PortLocal, PID: m.portForwarder.PID(cfg.ID)}
+		m.statusMsg = fmt.Sprintf("Restarted %s", cfg.Service)
+	}
+	r.refresh()
+}
+
+// View renders the restart report view
+func (r *restartReport) View() string {
+	m := r.m
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	b.WriteString(titleStyle.Render("🔁 Restart Report"))
+	b.WriteString("\n\n")
+
+	failed := 0
+	for _, res := range r.results {
+		if res.Err != nil {
+			failed++
+		}
+	}
+	b.WriteString(fmt.Sprintf("Restarted %d of %d port forward(s)\n\n", len(r.results)-failed, len(r.results)))
+
+	b.WriteString(r.table.View())
+	b.WriteString("\n\n")
+
+	// The RESULT cell truncates long errors; show the selected one in full
+	if idx := r.table.Cursor(); idx >= 0 && idx < len(r.results) && r.results[idx].Err != nil {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorError))
+		b.WriteString(errorStyle.Render(r.results[idx].Err.Error()))
+		b.WriteString("\n")
+	}
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	b.WriteString(helpStyle.Render(ActionRestartReport))
+	b.WriteString("\n")
+
+	if m.errorMsg != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorError)).
+			Bold(true)
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %s", m.errorMsg)))
+		b.WriteString("\n")
+	} else if m.statusMsg != "" {
+		b.WriteString(m.statusMsg)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
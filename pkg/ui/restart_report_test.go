@@ -0,0 +1,51 @@
+package ui
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestRestartReportListsEachForward(t *testing.T) {
+	t.Setenv("PATH", t.TempDir()) // no kubectl: the retry fails again
+
+	web := config.PortForwardConfig{ID: "ctx.ns.web", Context: "ctx", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080}
+	m, _ := newTestModel(t, web)
+	m.enterRestartReport([]k8s.RestartedForward{
+		{ID: "ctx.ns.gone", Err: errors.New("config 'ctx.ns.gone' no longer exists")},
+		{ID: web.ID, LocalPort: 8080, PID: 4242},
+	})
+
+	if m.uiState != StateRestartReport {
+		t.Fatalf("uiState = %d, want the restart report", m.uiState)
+	}
+	rows := m.restart.table.Rows()
+	if len(rows) != 2 {
+		t.Fatalf("got %d rows, want one per forward", len(rows))
+	}
+	if rows[1][0] != "web" || rows[1][1] != "8080" || rows[1][2] != "4242" || rows[1][3] != "restarted" {
+		t.Fatalf("unexpected row for the restarted forward: %v", rows[1])
+	}
+
+	// Retrying a forward whose config is gone reports it instead of crashing
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if m.errorMsg == "" {
+		t.Fatal("retrying a deleted forward should report an error")
+	}
+
+	// Retrying a failing start records the new error in the row
+	m.restart.table.SetCursor(1)
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	if m.restart.results[1].Err == nil || m.restart.table.Rows()[1][2] != "-" {
+		t.Fatalf("a failed retry should replace the row's outcome, got %+v", m.restart.results[1])
+	}
+
+	m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if m.uiState != StatePortForwards {
+		t.Fatal("Esc should return to the port forwards view")
+	}
+}
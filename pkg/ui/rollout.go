@@ -0,0 +1,196 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// rolloutCheckInterval is how often the pods behind the services of running
+// forwards are listed to notice rollouts. A forward attached to a replaced
+// pod breaks; noticing the rollout lets us say why, or restart it early.
+const rolloutCheckInterval = 15 * time.Second
+
+// serviceRef identifies the service behind one or more forwards
+type serviceRef struct {
+	context    string
+	kubeconfig string
+	namespace  string
+	service    string
+}
+
+func (s serviceRef) String() string {
+	return s.namespace + "/" + s.service
+}
+
+// rolloutTickMsg starts a rollout check
+type rolloutTickMsg time.Time
+
+// rolloutCheckedMsg carries the pods now behind each checked service; services
+// whose lookup failed are missing
+type rolloutCheckedMsg map[serviceRef][]string
+
+// rolloutRestartedMsg reports the forwards restarted after a rollout
+type rolloutRestartedMsg struct {
+	restarted int
+	failed    []string
+}
+
+func rolloutTickCmd() tea.Cmd {
+	return tea.Tick(rolloutCheckInterval, func(t time.Time) tea.Msg {
+		return rolloutTickMsg(t)
+	})
+}
+
+// checkRolloutsCmd lists the pods behind services without blocking the UI
+func checkRolloutsCmd(services []serviceRef) tea.Cmd {
+	return func() tea.Msg {
+		var mu sync.Mutex
+		var wg sync.WaitGroup
+		checked := make(rolloutCheckedMsg)
+		for _, ref := range services {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				pods, err := discovery.ServicePods(ref.context, ref.kubeconfig, ref.namespace, ref.service)
+				if err != nil {
+					logging.LogDebug("Rollout check of %s failed: %v", ref, err)
+					return
+				}
+				mu.Lock()
+				checked[ref] = pods
+				mu.Unlock()
+			}()
+		}
+		wg.Wait()
+		return checked
+	}
+}
+
+// restartAfterRolloutCmd restarts forwards whose pods were replaced without
+// blocking the UI
+func restartAfterRolloutCmd(pf *k8s.PortForwarder, cfgs []config.PortForwardConfig) tea.Cmd {
+	return func() tea.Msg {
+		var msg rolloutRestartedMsg
+		for _, cfg := range cfgs {
+			if err := pf.Restart(cfg); err != nil {
+				logging.LogError("Restarting '%s' after a rollout failed: %v", cfg.ID, err)
+				msg.failed = append(msg.failed, cfg.ID)
+				continue
+			}
+			msg.restarted++
+		}
+		return msg
+	}
+}
+
+// runningServices returns the services behind running forwards, with their
+// forwards. Lazy forwards in standby have no tunnel to break; forwards to a
+// pod or workload have no service to look up pods through.
+func (m *Model) runningServices() map[serviceRef][]config.PortForwardConfig {
+	services := make(map[serviceRef][]config.PortForwardConfig)
+	for _, cfg := range m.configStore.GetAll() {
+		if !m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsStandby(cfg.ID) || cfg.TargetKind != config.TargetKindService {
+			continue
+		}
+		ref := serviceRef{cfg.Context, cfg.Kubeconfig, cfg.Namespace, cfg.Service}
+		services[ref] = append(services[ref], cfg)
+	}
+	return services
+}
+
+// handleRolloutTick checks the services of running forwards, if any
+func (m *Model) handleRolloutTick() (tea.Model, tea.Cmd) {
+	services := m.runningServices()
+	if len(services) == 0 {
+		m.servicePods = nil
+		return m, rolloutTickCmd()
+	}
+	refs := make([]serviceRef, 0, len(services))
+	for ref := range services {
+		refs = append(refs, ref)
+	}
+	return m, checkRolloutsCmd(refs)
+}
+
+// handleRolloutChecked compares the pods behind each service with the last
+// check and reports services whose pods a rollout replaced, restarting their
+// forwards if SettingRolloutRestart is on
+func (m *Model) handleRolloutChecked(msg rolloutCheckedMsg) (tea.Model, tea.Cmd) {
+	services := m.runningServices()
+	restart := m.configStore.GetSettings()[config.SettingRolloutRestart] == "true"
+
+	next := make(map[serviceRef][]string)
+	var notes []string
+	var toRestart []config.PortForwardConfig
+	for ref, cfgs := range services {
+		pods, checked := msg[ref]
+		previous, seen := m.servicePods[ref]
+		if !checked {
+			if seen {
+				next[ref] = previous // lookup failed; compare with the last good one
+			}
+			continue
+		}
+		next[ref] = pods
+		if !seen || !podsReplaced(previous, pods) {
+			continue
+		}
+		logging.LogDebug("Rollout of %s: pods %v replaced by %v", ref, previous, pods)
+		notes = append(notes, fmt.Sprintf("%s (%d forward(s))", ref, len(cfgs)))
+		toRestart = append(toRestart, cfgs...)
+	}
+	m.servicePods = next
+
+	cmds := []tea.Cmd{rolloutTickCmd()}
+	if len(notes) > 0 {
+		sort.Strings(notes)
+		m.statusMsg = "Rollout replaced the pods of " + strings.Join(notes, ", ")
+		if restart {
+			m.statusMsg += ", restarting"
+			cmds = append(cmds, restartAfterRolloutCmd(m.portForwarder, toRestart))
+		}
+	}
+	return m, tea.Batch(cmds...)
+}
+
+// handleRolloutRestarted reports the outcome of restarts after a rollout
+func (m *Model) handleRolloutRestarted(msg rolloutRestartedMsg) (tea.Model, tea.Cmd) {
+	m.refreshTable()
+	if len(msg.failed) > 0 {
+		m.errorMsg = fmt.Sprintf("Restart after rollout failed for %s", strings.Join(msg.failed, ", "))
+		return m, nil
+	}
+	m.statusMsg = fmt.Sprintf("Restarted %d forward(s) after a rollout", msg.restarted)
+	return m, nil
+}
+
+// podsReplaced reports whether pods were replaced between two sorted lists:
+// some earlier pod is gone and a new one took over. Scaling up or down alone
+// does not count.
+func podsReplaced(before, after []string) bool {
+	gone, added := false, false
+	for _, pod := range before {
+		if !slices.Contains(after, pod) {
+			gone = true
+			break
+		}
+	}
+	for _, pod := range after {
+		if !slices.Contains(before, pod) {
+			added = true
+			break
+		}
+	}
+	return gone && added
+}
@@ -0,0 +1,60 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestPodsReplaced(t *testing.T) {
+	tests := []struct {
+		before, after []string
+		want          bool
+	}{
+		{[]string{"api-1", "api-2"}, []string{"api-1", "api-2"}, false},
+		{[]string{"api-1"}, []string{"api-1", "api-2"}, false}, // scaled up
+		{[]string{"api-1", "api-2"}, []string{"api-1"}, false}, // scaled down
+		{[]string{"api-1", "api-2"}, []string{"api-2", "api-3"}, true},
+		{[]string{"api-1"}, []string{"api-9"}, true},
+		{[]string{"api-1"}, nil, false}, // no pods left: the forward fails on its own
+	}
+	for _, tt := range tests {
+		if got := podsReplaced(tt.before, tt.after); got != tt.want {
+			t.Errorf("podsReplaced(%v, %v) = %v, want %v", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestRolloutOfRunningForwardIsReported(t *testing.T) {
+	useSleepingKubectl(t)
+	cfg := testForward(t, "ctx", "api")
+	m, pf := newTestModel(t, cfg)
+	if err := pf.Start(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	ref := serviceRef{context: "ctx", namespace: "ns", service: "api"}
+	m.handleRolloutChecked(rolloutCheckedMsg{ref: {"api-1", "api-2"}})
+	m.handleRolloutChecked(rolloutCheckedMsg{}) // lookup failed: keep the last pods
+	m.handleRolloutChecked(rolloutCheckedMsg{ref: {"api-1", "api-2", "api-3"}})
+	if m.statusMsg != "" {
+		t.Fatalf("a first look and a scale-up are no rollout, got %q", m.statusMsg)
+	}
+
+	m.handleRolloutChecked(rolloutCheckedMsg{ref: {"api-4", "api-5"}})
+	if !strings.Contains(m.statusMsg, "Rollout replaced the pods of ns/api (1 forward(s))") {
+		t.Fatalf("statusMsg = %q, want a rollout notice", m.statusMsg)
+	}
+	if strings.Contains(m.statusMsg, "restarting") {
+		t.Fatal("forwards must only be restarted with rollout.restart on")
+	}
+
+	if err := m.configStore.SetSetting(config.SettingRolloutRestart, "true"); err != nil {
+		t.Fatal(err)
+	}
+	m.handleRolloutChecked(rolloutCheckedMsg{ref: {"api-6"}})
+	if !strings.HasSuffix(m.statusMsg, ", restarting") {
+		t.Fatalf("statusMsg = %q, want the forwards restarted", m.statusMsg)
+	}
+}
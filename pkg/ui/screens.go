@@ -0,0 +1,68 @@
+package ui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// screen is one view of the TUI. Model.Update routes key presses to the
+// screen of the current UIState, Model.View renders it and every screen is
+// told when the window size changes, so a new view implements screen and
+// registers in screens instead of adding branches to each of them.
+type screen interface {
+	// Update handles a key press while the screen is shown
+	Update(msg tea.KeyMsg) (tea.Model, tea.Cmd)
+	// View renders the screen
+	View() string
+	// Resize lays the screen out for the new m.width and m.height
+	Resize()
+}
+
+// modelScreen is a screen written as Model methods, which is how the views
+// that keep their state on Model are built
+type modelScreen struct {
+	m      *Model
+	update func(*Model, tea.KeyMsg) (tea.Model, tea.Cmd)
+	view   func(*Model) string
+	resize func(*Model) // nil when there is nothing to lay out
+}
+
+func (s modelScreen) Update(msg tea.KeyMsg) (tea.Model, tea.Cmd) { return s.update(s.m, msg) }
+func (s modelScreen) View() string                               { return s.view(s.m) }
+
+func (s modelScreen) Resize() {
+	if s.resize != nil {
+		s.resize(s.m)
+	}
+}
+
+// screens returns the screen of every UIState
+func (m *Model) screens() map[UIState]screen {
+	if m.screenSet != nil {
+		return m.screenSet
+	}
+	m.screenSet = map[UIState]screen{
+		StatePortForwards:            modelScreen{m, updatePortForwardKeys, (*Model).viewPortForwards, (*Model).resizePortForwards},
+		StateProjectSelector:         modelScreen{m, (*Model).updateProjectSelector, (*Model).renderProjectSelector, (*Model).resizeProjectSelector},
+		StateServiceDiscovery:        modelScreen{m, (*Model).updateServiceDiscovery, (*Model).renderServiceDiscoveryView, (*Model).resizeServiceDiscovery},
+		StateProjectManagement:       modelScreen{m, (*Model).updateProjectManagement, (*Model).renderProjectManagement, (*Model).resizeProjectManagement},
+		StateProjectCreation:         modelScreen{m, (*Model).updateProjectCreation, (*Model).renderProjectCreation, nil},
+		StateProjectServiceSelection: modelScreen{m, (*Model).updateProjectServiceSelection, (*Model).renderProjectServiceSelection, (*Model).resizeProjectServiceSelection},
+		StateProjectConflicts:        modelScreen{m, (*Model).updateProjectConflicts, (*Model).renderProjectConflicts, nil},
+		StateRestartReport:           &m.restart,
+		StateFinder:                  modelScreen{m, (*Model).updateFinder, (*Model).renderFinder, nil},
+		StateActionMenu:              modelScreen{m, (*Model).updateActionMenu, (*Model).viewPortForwards, nil}, // drawn over the main view
+		StatePrune:                   modelScreen{m, (*Model).updatePrune, (*Model).renderPrune, (*Model).resizePrune},
+		StateWorkspaceSelector:       modelScreen{m, (*Model).updateWorkspaceSelector, (*Model).renderWorkspaceSelector, (*Model).resizeWorkspaceSelector},
+		StateLogs:                    &m.logs,
+		StateDescribe:                &m.describe,
+		StateAddForward:              &m.addForm,
+		StateProblems:                &m.problemsPanel,
+	}
+	return m.screenSet
+}
+
+// updatePortForwardKeys adapts updatePortForwards, which also takes other
+// messages, to modelScreen
+func updatePortForwardKeys(m *Model, msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	return m.updatePortForwards(msg)
+}
@@ -0,0 +1,30 @@
+package ui
+
+import (
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestEveryStateHasAScreen(t *testing.T) {
+	m := &Model{}
+	for state := StatePortForwards; state < stateCount; state++ {
+		if _, ok := m.screens()[state]; !ok {
+			t.Errorf("UIState %d has no screen", state)
+		}
+	}
+}
+
+func TestResizeReachesRestartReport(t *testing.T) {
+	m, _ := newConflictModel(t)
+	m.enterRestartReport([]k8s.RestartedForward{{ID: "a"}, {ID: "b"}, {ID: "c"}})
+	before := m.restart.table.Height()
+	m.Update(tea.WindowSizeMsg{Width: 120, Height: 12})
+
+	// Three rows fit in 24 lines but need scrolling in 12
+	if got := m.restart.table.Height(); got >= before {
+		t.Fatalf("report table height = %d after shrinking the window, was %d", got, before)
+	}
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// profilePrompt says what the discovery name prompt does on Enter
+type profilePrompt int
+
+const (
+	profilePromptNone  profilePrompt = iota // no prompt open
+	profilePromptSave                       // save the selected ports under the name
+	profilePromptApply                      // select the ports of the named profile
+)
+
+// startProfilePrompt opens the selection profile name prompt
+func (m *Model) startProfilePrompt(prompt profilePrompt) (tea.Model, tea.Cmd) {
+	sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+	if !ok {
+		m.errorMsg = "Selection profiles are not supported by this config store"
+		return m, nil
+	}
+	m.discoveryProfileNames = selectionProfileNames(sqliteStore)
+	if prompt == profilePromptApply && len(m.discoveryProfileNames) == 0 {
+		m.errorMsg = "No saved selection profiles (w: save the current selection)"
+		return m, nil
+	}
+
+	m.discoveryProfilePrompt = prompt
+	m.discoveryProfileInput.SetValue("")
+	m.discoveryProfileInput.Focus()
+	m.discoveryTable.Blur()
+	m.errorMsg = ""
+	m.statusMsg = ""
+	return m, textinput.Blink
+}
+
+// handleProfilePromptKeys handles input while the profile name prompt is open
+func (m *Model) handleProfilePromptKeys(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		m.closeProfilePrompt()
+		m.errorMsg = ""
+		return m, nil
+
+	case "enter":
+		name := strings.TrimSpace(m.discoveryProfileInput.Value())
+		if name == "" {
+			m.errorMsg = "Profile name cannot be empty"
+			return m, nil
+		}
+		if m.discoveryProfilePrompt == profilePromptSave {
+			m.saveSelectionProfile(name)
+		} else {
+			m.applySelectionProfile(name)
+		}
+		return m, nil
+
+	default:
+		var cmd tea.Cmd
+		m.discoveryProfileInput, cmd = m.discoveryProfileInput.Update(msg)
+		return m, cmd
+	}
+}
+
+func (m *Model) closeProfilePrompt() {
+	m.discoveryProfilePrompt = profilePromptNone
+	m.discoveryProfileInput.Blur()
+	m.discoveryTable.Focus()
+}
+
+// saveSelectionProfile stores the selected ports, new and already configured,
+// as the profile name
+func (m *Model) saveSelectionProfile(name string) {
+	sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+	if !ok {
+		m.errorMsg = "Selection profiles are not supported by this config store"
+		return
+	}
+
+	profile := config.SelectionProfile{Name: name}
+	for _, port := range m.discoveryPorts {
+		if port.Selected {
+			profile.Ports = append(profile.Ports, config.ServicePortRef{
+				Namespace: port.ServiceNamespace,
+				Service:   port.ServiceName,
+				Port:      int(port.Port.Port),
+			})
+		}
+	}
+	if len(profile.Ports) == 0 {
+		m.errorMsg = "Nothing selected to save"
+		return
+	}
+	if err := sqliteStore.SaveSelectionProfile(profile); err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to save selection profile: %v", err)
+		return
+	}
+
+	m.closeProfilePrompt()
+	m.errorMsg = ""
+	m.statusMsg = fmt.Sprintf("Saved %d ports as selection profile '%s'", len(profile.Ports), name)
+}
+
+// applySelectionProfile selects the discovered ports that the profile name
+// lists. Ports selected already stay selected; profile ports this context
+// does not have are reported and otherwise ignored.
+func (m *Model) applySelectionProfile(name string) {
+	sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+	if !ok {
+		m.errorMsg = "Selection profiles are not supported by this config store"
+		return
+	}
+	profile, ok := sqliteStore.GetSelectionProfile(name)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("No selection profile named '%s' (saved: %s)", name, strings.Join(m.discoveryProfileNames, ", "))
+		return
+	}
+
+	selected := 0
+	var missing []string
+	for _, ref := range profile.Ports {
+		found := false
+		for i := range m.discoveryPorts {
+			port := &m.discoveryPorts[i]
+			if port.ServiceNamespace == ref.Namespace && port.ServiceName == ref.Service && int(port.Port.Port) == ref.Port {
+				found = true
+				if !port.Selected {
+					port.Selected = true
+					selected++
+				}
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, fmt.Sprintf("%s/%s:%d", ref.Namespace, ref.Service, ref.Port))
+		}
+	}
+
+	m.closeProfilePrompt()
+	cursor := m.discoveryTable.Cursor()
+	m.refreshDiscoveryTable()
+	m.discoveryTable.SetCursor(cursor)
+
+	m.errorMsg = ""
+	m.statusMsg = fmt.Sprintf("Profile '%s': selected %d more ports", name, selected)
+	if len(missing) > 0 {
+		logging.LogDebug("Selection profile %s: not found in this context: %s", name, strings.Join(missing, ", "))
+		m.statusMsg += fmt.Sprintf(", %d not found in this context", len(missing))
+		if m.discoveryNamespaceFilter != "*" {
+			m.statusMsg += " (a: all namespaces)"
+		}
+	}
+}
+
+// selectionProfileNames lists the names of the saved selection profiles
+func selectionProfileNames(store *config.SQLiteConfigStore) []string {
+	var names []string
+	for _, profile := range store.GetSelectionProfiles() {
+		names = append(names, profile.Name)
+	}
+	return names
+}
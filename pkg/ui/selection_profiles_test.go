@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/discovery"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSelectionProfileMirrorsAnotherContext(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatalf("failed to create store: %v", err)
+	}
+	defer store.Close()
+
+	m := &Model{configStore: store, uiState: StateServiceDiscovery, width: 120, height: 30}
+	m.initDiscoveryInputs()
+	discover := func(cluster string, ports ...discovery.ServicePort) {
+		m.handleServicesDiscovered(servicesDiscoveredMsg{
+			cluster: cluster,
+			result:  newDiscoveryResult(cluster, "default", "api", ports...),
+		})
+	}
+	typeLine := func(s string) {
+		m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune(s)})
+		m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	}
+
+	// Select 8080 in prod and save it as "mirror"
+	discover("prod", discovery.ServicePort{Port: 8080}, discovery.ServicePort{Port: 9090})
+	m.discoveryPorts[0].Selected = true
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("w")})
+	if m.discoveryProfilePrompt != profilePromptSave {
+		t.Fatal("w should open the save prompt")
+	}
+	typeLine("mirror")
+	if m.discoveryProfilePrompt != profilePromptNone || m.errorMsg != "" {
+		t.Fatalf("saving should close the prompt (error %q)", m.errorMsg)
+	}
+
+	// A new cluster with the same service and one more port
+	discover("stage", discovery.ServicePort{Port: 8080}, discovery.ServicePort{Port: 9090}, discovery.ServicePort{Port: 7070})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	typeLine("nope")
+	if !strings.Contains(m.errorMsg, "mirror") || m.discoveryProfilePrompt != profilePromptApply {
+		t.Fatalf("an unknown name should list the saved profiles and keep the prompt, got %q", m.errorMsg)
+	}
+	m.discoveryProfileInput.SetValue("")
+	typeLine("mirror")
+	for _, port := range m.discoveryPorts {
+		if port.Selected != (port.Port.Port == 8080) {
+			t.Errorf("port %d selected = %v", port.Port.Port, port.Selected)
+		}
+	}
+
+	// Ports the context lacks are reported, not fatal
+	discover("dev", discovery.ServicePort{Port: 9090})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	typeLine("mirror")
+	if m.discoveryPorts[0].Selected || !strings.Contains(m.statusMsg, "1 not found") {
+		t.Fatalf("status = %q", m.statusMsg)
+	}
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// shellDoneMsg reports that a shell opened with x exited
+type shellDoneMsg struct {
+	id     string
+	err    error
+	stderr string // what kubectl itself printed; the shell's output goes through the terminal
+}
+
+// openShell opens an interactive shell in a pod behind cfg with kubectl
+// exec. The TUI hands the terminal over until the shell exits; forwards keep
+// running meanwhile.
+func (m *Model) openShell(cfg config.PortForwardConfig) tea.Cmd {
+	args := k8s.ExecShellArgs(cfg, m.portForwarder.State(cfg.ID))
+	logging.LogDebug("Opening a shell for %s: %s %s", cfg.ID, kubectl.Binary(), strings.Join(args, " "))
+	cmd := exec.Command(kubectl.Binary(), args...)
+	// With a terminal the shell's errors reach stdout, so stderr only holds
+	// kubectl's own: the pod is gone, exec is forbidden, ...
+	var stderr bytes.Buffer
+	cmd.Stderr = io.MultiWriter(os.Stderr, &stderr)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return shellDoneMsg{id: cfg.ID, err: err, stderr: strings.TrimSpace(stderr.String())}
+	})
+}
+
+// handleShellDone reports how a shell ended. A shell leaves with the status
+// of its last command, so an exit status is only an error when kubectl
+// complained.
+func (m *Model) handleShellDone(msg shellDoneMsg) {
+	var exitErr *exec.ExitError
+	switch {
+	case msg.err == nil:
+		m.statusMsg = fmt.Sprintf("Shell for %s closed", msg.id)
+	case errors.As(msg.err, &exitErr) && msg.stderr == "":
+		m.statusMsg = fmt.Sprintf("Shell for %s closed (exit status %d)", msg.id, exitErr.ExitCode())
+	default:
+		reason := msg.err.Error()
+		if msg.stderr != "" {
+			lines := strings.Split(msg.stderr, "\n")
+			reason = oneLine(lines[len(lines)-1])
+		}
+		logging.LogError("Shell for %s failed: %v: %s", msg.id, msg.err, msg.stderr)
+		m.errorMsg = fmt.Sprintf("Cannot open a shell for %s: %s", msg.id, reason)
+	}
+}
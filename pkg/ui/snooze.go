@@ -0,0 +1,68 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// snoozeChoices are the entries of the Snooze... action
+var snoozeChoices = []struct {
+	label    string
+	duration time.Duration
+}{
+	{"5 minutes", 5 * time.Minute},
+	{"15 minutes", 15 * time.Minute},
+	{"30 minutes", 30 * time.Minute},
+	{"1 hour", time.Hour},
+}
+
+// snoozeActions lists one entry per snooze choice
+func snoozeActions() []rowAction {
+	var actions []rowAction
+	for _, choice := range snoozeChoices {
+		actions = append(actions, rowAction{label: choice.label, run: func(m *Model, cfg config.PortForwardConfig) {
+			m.snoozeForward(cfg, choice.duration)
+		}})
+	}
+	return actions
+}
+
+// snoozeForward stops cfg, freeing its local port for something else, and
+// starts it again after d. Starting or stopping it by hand ends the snooze.
+func (m *Model) snoozeForward(cfg config.PortForwardConfig, d time.Duration) {
+	if err := m.portForwarder.Stop(cfg.ID); err != nil {
+		logging.LogError("Error stopping port-forward '%s' to snooze it: %v", cfg.ID, err)
+		m.errorMsg = fmt.Sprintf("Cannot snooze %s: %v", cfg.Service, err)
+		return
+	}
+	if m.snoozedUntil == nil {
+		m.snoozedUntil = make(map[string]time.Time)
+	}
+	until := time.Now().Add(d)
+	m.snoozedUntil[cfg.ID] = until
+	m.statusMsg = fmt.Sprintf("Snoozed %s until %s; local port %d is free meanwhile", cfg.Service, until.Format("15:04"), cfg.PortLocal)
+	m.refreshTable()
+}
+
+// resumeSnoozed starts the forwards whose snooze is over at now. One deleted
+// or started by other means meanwhile is just forgotten.
+func (m *Model) resumeSnoozed(now time.Time) {
+	for id, until := range m.snoozedUntil {
+		if now.Before(until) {
+			continue
+		}
+		delete(m.snoozedUntil, id)
+		cfg, ok := m.configStore.GetConfigByID(id)
+		if !ok || m.portForwarder.IsRunning(id) || m.portForwarder.IsQueued(id) {
+			continue
+		}
+		if err := m.portForwarder.Start(cfg); err != nil {
+			m.errorMsg = fmt.Sprintf("Cannot resume snoozed %s: %s", cfg.Service, friendlyError(err))
+			continue
+		}
+		m.statusMsg = fmt.Sprintf("Snooze of %s is over; started it again", cfg.Service)
+	}
+}
@@ -0,0 +1,40 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSnoozeStopsAndResumesForward(t *testing.T) {
+	useSleepingKubectl(t)
+	cfg := testForward(t, "ctx", "api")
+	m, pf := newTestModel(t, cfg)
+	if err := pf.Start(cfg); err != nil {
+		t.Fatal(err)
+	}
+
+	m.snoozeForward(cfg, 15*time.Minute)
+	if pf.IsRunning(cfg.ID) {
+		t.Fatal("a snoozed forward must be stopped")
+	}
+	if got := m.statusFor(cfg.ID); got != StatusSnoozed {
+		t.Fatalf("status = %q, want %q", got, StatusSnoozed)
+	}
+	until := m.snoozedUntil[cfg.ID]
+	if cell := m.statusCell(cfg.ID, StatusSnoozed, pf.State(cfg.ID)); !strings.Contains(cell, "until "+until.Format("15:04")) {
+		t.Errorf("STATUS cell = %q, want the resume time", cell)
+	}
+
+	m.resumeSnoozed(until.Add(-time.Second))
+	if pf.IsRunning(cfg.ID) {
+		t.Fatal("the forward resumed before its snooze was over")
+	}
+	m.resumeSnoozed(until)
+	if !pf.IsRunning(cfg.ID) {
+		t.Fatalf("the forward did not resume: %s", m.errorMsg)
+	}
+	if got := m.statusFor(cfg.ID); got != StatusRunning {
+		t.Fatalf("status after the snooze = %q", got)
+	}
+}
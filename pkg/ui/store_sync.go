@@ -0,0 +1,80 @@
+package ui
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/kubectl"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+)
+
+// sharedStore is a store other kprtfwd processes may write while the TUI
+// runs (see config.SQLiteConfigStore.ChangeStamp)
+type sharedStore interface {
+	ChangeStamp() string
+	Reload() error
+}
+
+// syncStore catches up with what other kprtfwd processes — `kprtfwd import`,
+// `prune`, `ids rename`, `templates add` or `settings set` in another
+// terminal, or a second TUI — wrote to the database since the last call:
+// settings apply again, forwards whose config went away are stopped and a
+// filter is redone; the caller rebuilds the table. It costs a stat while
+// nothing changed.
+func (m *Model) syncStore() {
+	store, ok := m.configStore.(sharedStore)
+	if !ok {
+		return
+	}
+	stamp := store.ChangeStamp()
+	if stamp == m.storeStamp {
+		return
+	}
+	m.storeStamp = stamp
+	if err := store.Reload(); err != nil {
+		logging.LogError("Failed to reload the database: %v", err)
+		return
+	}
+	m.applySettings(m.configStore.GetSettings())
+	m.stopVanishedForwards()
+	if m.filtering() {
+		m.applyFilter()
+	}
+}
+
+// stopVanishedForwards stops the forwards running, on standby or queued here
+// whose config another process deleted or renamed away (`kprtfwd prune`,
+// `ids rename`, ...); nothing would show or stop them otherwise.
+func (m *Model) stopVanishedForwards() {
+	var stopped []string
+	for id, state := range m.portForwarder.Snapshot() {
+		if !state.Running && !state.Standby && !state.Queued {
+			continue
+		}
+		if _, exists := m.configStore.GetConfigByID(id); exists {
+			continue
+		}
+		if err := m.portForwarder.Stop(id); err != nil {
+			logging.LogError("Failed to stop '%s', removed from the database elsewhere: %v", id, err)
+			continue
+		}
+		delete(m.expiresAt, id)
+		stopped = append(stopped, id)
+	}
+	if len(stopped) > 0 {
+		slices.Sort(stopped)
+		m.statusMsg = fmt.Sprintf("Stopped %s: removed from the configuration elsewhere", strings.Join(stopped, ", "))
+	}
+}
+
+// applySettings applies the stored settings that take effect while the TUI
+// runs
+func (m *Model) applySettings(settings map[string]string) {
+	kubectl.ApplySettings(settings)
+	m.portForwarder.ApplySettings(settings)
+	m.customGroups = config.CustomGroups(settings)
+	m.autoCollapse = settings[config.SettingAutoCollapse] != "false"
+	m.metricsTextfile = settings[config.SettingMetricsTextfile]
+}
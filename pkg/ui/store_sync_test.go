@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+// A forward pruned by another process must not keep running here, out of
+// sight of the table
+func TestSyncStoreStopsForwardsDeletedElsewhere(t *testing.T) {
+	useSleepingKubectl(t)
+	api := testForward(t, "dev", "api")
+	web := testForward(t, "dev", "web")
+	m, pf := newTestModel(t, api, web)
+	for _, cfg := range []config.PortForwardConfig{api, web} {
+		if err := pf.Start(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m.syncStore()
+
+	other, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	if err := other.DeletePortForward(api.ID); err != nil {
+		t.Fatal(err)
+	}
+	m.syncStore()
+
+	if pf.IsRunning(api.ID) {
+		t.Errorf("%s still runs after another process deleted it", api.ID)
+	}
+	if !pf.IsRunning(web.ID) {
+		t.Errorf("%s was stopped, but its config still exists", web.ID)
+	}
+	if !strings.Contains(m.statusMsg, api.ID) {
+		t.Errorf("statusMsg = %q, want it to name %s", m.statusMsg, api.ID)
+	}
+}
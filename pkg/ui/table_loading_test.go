@@ -0,0 +1,35 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+)
+
+func TestTableLoadsAfterFirstFrame(t *testing.T) {
+	m, _ := newTestModel(t,
+		config.PortForwardConfig{ID: "b.ns.web", Context: "b", Namespace: "ns", Service: "web", PortRemote: 80, PortLocal: 8080},
+		config.PortForwardConfig{ID: "a.ns.api", Context: "a", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8081},
+	)
+	store := m.configStore
+	m.groupingEnabled, m.tableLoading = true, true
+	m.portForwardsTable = newNavTable(m.calculateColumnWidths(), nil, 10)
+
+	if view := m.View(); !strings.Contains(view, "Loading 2 port forwards") {
+		t.Fatalf("first frame should say the table is loading, got:\n%s", view)
+	}
+
+	m.Update(tableLoadedMsg{})
+	if m.tableLoading {
+		t.Fatal("table still loading after tableLoadedMsg")
+	}
+	if rows := m.portForwardsTable.Rows(); len(rows) != 4 {
+		t.Fatalf("expected two groups with one forward each, got %v", rows)
+	}
+	// Group "a" sorts first; its row must point at a.ns.api in the store
+	row := m.tableRows[1]
+	if row.Type != RowTypeItem || row.ConfigIndex < 0 || store.GetAll()[row.ConfigIndex].ID != "a.ns.api" {
+		t.Errorf("first forward row = %+v, want the index of a.ns.api", row)
+	}
+}
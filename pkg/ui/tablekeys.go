@@ -3,6 +3,7 @@ package ui
 import (
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/lipgloss"
 )
 
 // navTableKeyMap is a trimmed key map for the list tables. The bubbles table
@@ -22,3 +23,33 @@ func navTableKeyMap() table.KeyMap {
 		// HalfPageUp / HalfPageDown intentionally left unbound.
 	}
 }
+
+// navTableStyles returns the header and selected-row styles shared by all
+// list tables
+func navTableStyles() table.Styles {
+	s := table.DefaultStyles()
+	s.Header = s.Header.Bold(false)
+	if !accessibleMode {
+		s.Header = s.Header.
+			BorderStyle(lipgloss.NormalBorder()).
+			BorderForeground(lipgloss.Color(ColorBorder)).
+			BorderBottom(true)
+	}
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color(ColorSelectedFg)).
+		Background(lipgloss.Color(ColorSelectedBg)).Reverse(monoTheme).
+		Bold(false)
+	return s
+}
+
+// newNavTable returns a focused list table with the shared key map and styles
+func newNavTable(columns []table.Column, rows []table.Row, height int) table.Model {
+	return table.New(
+		table.WithColumns(columns),
+		table.WithRows(rows),
+		table.WithFocused(true),
+		table.WithHeight(height),
+		table.WithKeyMap(navTableKeyMap()),
+		table.WithStyles(navTableStyles()),
+	)
+}
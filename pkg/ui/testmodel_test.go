@@ -0,0 +1,84 @@
+package ui
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	"github.com/charmbracelet/bubbles/textinput"
+)
+
+// newTestModel returns a 200x30 model over a fresh store, in a temporary
+// HOME, holding cfgs, and its port forwarder, cleaned up when t ends. The
+// table is laid out; a test that changes the layout (groupingEnabled,
+// width) lays it out again with applyColumnLayout.
+func newTestModel(t *testing.T, cfgs ...config.PortForwardConfig) (*Model, *k8s.PortForwarder) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	for _, cfg := range cfgs {
+		if err := store.Add(cfg); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pf := k8s.NewPortForwarder()
+	t.Cleanup(pf.CleanupAll)
+	store.SetBeforeDelete(pf.Stop)
+
+	m := &Model{configStore: store, portForwarder: pf, groupStates: make(map[string]*GroupState),
+		filterInput: textinput.New(), editInput: textinput.New(), addInput: textinput.New(), tagsEditInput: textinput.New(),
+		argsEditInput: textinput.New(), hookEditInput: textinput.New(), healthEditInput: textinput.New(),
+		width: 200, height: 30}
+	m.applyColumnLayout()
+	return m, pf
+}
+
+// testForward returns a forward to port 80 of service in namespace ns of
+// context, from a free local port
+func testForward(t *testing.T, context, service string) config.PortForwardConfig {
+	t.Helper()
+	return config.PortForwardConfig{ID: context + ".ns." + service, Context: context, Namespace: "ns",
+		Service: service, PortRemote: 80, PortLocal: freeLocalPort(t)}
+}
+
+// freeLocalPort returns a loopback port nothing listens on
+func freeLocalPort(t *testing.T) int {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+	return l.Addr().(*net.TCPAddr).Port
+}
+
+// useSleepingKubectl makes kubectl, alone on PATH, a script that just
+// sleeps, so forwards start and keep running without a cluster. It skips t
+// where no such script can run.
+func useSleepingKubectl(t *testing.T) {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake kubectl shell script requires a Unix-like OS")
+	}
+	sleepPath, err := exec.LookPath("sleep")
+	if err != nil {
+		t.Skip("sleep binary not available")
+	}
+	dir := t.TempDir()
+	script := fmt.Sprintf("#!/bin/sh\nexec %s 30\n", sleepPath)
+	if err := os.WriteFile(filepath.Join(dir, "kubectl"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+}
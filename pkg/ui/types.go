@@ -10,6 +10,18 @@ const (
 	StateProjectManagement                      // Project management view
 	StateProjectCreation                        // Project creation form
 	StateProjectServiceSelection                // Add/remove services to/from project
+	StateProjectConflicts                       // Resolve local port conflicts before activating a project
+	StateRestartReport                          // Per-forward outcome of a Ctrl+R restart
+	StateFinder                                 // Global finder (Ctrl+F)
+	StateActionMenu                             // Action menu on the selected forward (Enter)
+	StatePrune                                  // Review and apply stale forward removal (Ctrl+U)
+	StateWorkspaceSelector                      // Switch to another workspace (W)
+	StateLogs                                   // Logs of the pods behind the selected forward (l)
+	StateDescribe                               // kubectl describe of the selected forward's target (d)
+	StateAddForward                             // Form adding a forward field by field (a)
+	StateProblems                               // Non-fatal configuration problems (!)
+
+	stateCount // Number of states; keep it last
 )
 
 // GroupState represents whether a group is expanded or collapsed
@@ -60,7 +72,8 @@ type PortSelection struct {
 	Selected            bool
 	LocalPort           int
 	GeneratedID         string
-	ExistingConfigIndex int // Index in config store if port already exists, -1 if new
+	ExistingConfigIndex int    // Index in config store if port already exists, -1 if new
+	ExistingConfigID    string // ID of that config; the index shifts as configs are removed
 }
 
 // DiscoveredServiceWithPorts wraps discovery.DiscoveredService with additional UI state
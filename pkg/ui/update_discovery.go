@@ -2,12 +2,15 @@ package ui
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
 	"github.com/xlttj/kprtfwd/pkg/discovery"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/table"
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
@@ -24,6 +27,7 @@ func (m *Model) updateServiceDiscovery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 	if m.discoveryLoading {
 		if keyStr == "esc" {
 			m.discoveryLoading = false
+			m.clustersPending = false
 			m.uiState = StatePortForwards
 			m.statusMsg = ""
 			m.errorMsg = ""
@@ -36,6 +40,11 @@ func (m *Model) updateServiceDiscovery(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		return m.handleDiscoveryEditMode(msg)
 	}
 
+	// Handle the selection profile name prompt
+	if m.discoveryPhase == PhaseServiceSelection && m.discoveryProfilePrompt != profilePromptNone {
+		return m.handleProfilePromptKeys(msg)
+	}
+
 	// Handle filter mode for service selection phase
 	if m.discoveryPhase == PhaseServiceSelection && m.discoveryFilterMode {
 		switch keyStr {
@@ -111,11 +120,11 @@ func (m *Model) handleServiceSelectionKeys(keyStr string, msg tea.KeyMsg) (tea.M
 		// the table locally (no kubectl call, no freeze) and keep the prior
 		// selection highlighted.
 		m.discoveryPhase = PhaseClusterSelection
-		current := ""
+		selected := ""
 		if m.discoverySelectedCluster >= 0 && m.discoverySelectedCluster < len(m.discoveryClusters) {
-			current = m.discoveryClusters[m.discoverySelectedCluster]
+			selected = m.discoveryClusters[m.discoverySelectedCluster]
 		}
-		m.buildClusterTable(m.discoveryClusters, current)
+		m.buildClusterTable(m.discoveryClusters, m.currentContext, selected)
 		return m, nil
 
 	case "enter":
@@ -126,6 +135,10 @@ func (m *Model) handleServiceSelectionKeys(keyStr string, msg tea.KeyMsg) (tea.M
 		// Toggle service selection
 		return m.handleServiceToggle()
 
+	case "a":
+		// Switch between all namespaces and the default namespace filter
+		return m.toggleAllNamespaces()
+
 	case "/":
 		// Enter filter mode
 		m.errorMsg = ""
@@ -135,6 +148,14 @@ func (m *Model) handleServiceSelectionKeys(keyStr string, msg tea.KeyMsg) (tea.M
 		m.discoveryTable.Blur()
 		return m, nil
 
+	case "w":
+		// Save the current selection as a profile
+		return m.startProfilePrompt(profilePromptSave)
+
+	case "p":
+		// Select the ports of a saved profile
+		return m.startProfilePrompt(profilePromptApply)
+
 	case "e":
 		// Edit local port
 		selectedIdx := m.discoveryTable.Cursor()
@@ -185,7 +206,24 @@ func (m *Model) enterServiceDiscovery() (tea.Model, tea.Cmd) {
 	m.discoveryPhase = PhaseClusterSelection
 	m.errorMsg = ""
 	m.statusMsg = ""
+	m.initDiscoveryInputs()
+
+	// Show the contexts listed earlier right away and refresh them in the
+	// background: listing can take seconds behind a slow credential plugin.
+	if len(m.knownClusters) > 0 {
+		m.discoveryClusterSources = m.knownClusterSources
+		m.buildClusterTable(m.knownClusters, m.currentContext, m.currentContext)
+		return m, loadClustersCmd()
+	}
 
+	// Kick off the cluster list fetch asynchronously so the UI stays responsive.
+	m.clustersPending = true
+	m.statusMsg = "Loading clusters..."
+	return m, tea.Batch(loadClustersCmd(), m.startDiscoveryLoading())
+}
+
+// initDiscoveryInputs creates empty filter and local-port inputs for discovery
+func (m *Model) initDiscoveryInputs() {
 	// Initialize discovery filter input
 	m.discoveryFilterInput = textinput.New()
 	m.discoveryFilterInput.Placeholder = "Filter..."
@@ -201,10 +239,11 @@ func (m *Model) enterServiceDiscovery() (tea.Model, tea.Cmd) {
 	m.discoveryEditInput.CharLimit = 5
 	m.discoveryEditInput.Width = 8
 
-	// Kick off the cluster list fetch asynchronously so the UI stays responsive.
-	m.discoveryLoading = true
-	m.statusMsg = "Loading clusters..."
-	return m, loadClustersCmd()
+	m.discoveryProfileInput = textinput.New()
+	m.discoveryProfileInput.Placeholder = "Profile name"
+	m.discoveryProfileInput.CharLimit = 64
+	m.discoveryProfileInput.Width = 30
+	m.discoveryProfilePrompt = profilePromptNone
 }
 
 // handleClusterSelection starts asynchronous service discovery for the selected
@@ -219,11 +258,50 @@ func (m *Model) handleClusterSelection() (tea.Model, tea.Cmd) {
 
 	selectedCluster := m.discoveryClusters[selectedIdx]
 	m.discoverySelectedCluster = selectedIdx
+	return m.discoverServices(config.DiscoveryNamespaceFilter(m.configStore.GetSettings(), selectedCluster))
+}
+
+// discoverServices starts asynchronous service discovery in the namespaces of
+// the selected cluster that match namespaceFilter
+func (m *Model) discoverServices(namespaceFilter string) (tea.Model, tea.Cmd) {
+	cluster := m.discoveryClusters[m.discoverySelectedCluster]
+	m.discoveryNamespaceFilter = namespaceFilter
 	m.errorMsg = ""
-	m.statusMsg = fmt.Sprintf("Discovering services in cluster '%s'...", selectedCluster)
+	m.statusMsg = fmt.Sprintf("Discovering services in cluster '%s'...", cluster)
+	if namespaceFilter != "*" {
+		m.statusMsg = fmt.Sprintf("Discovering services in cluster '%s', namespaces '%s'...", cluster, namespaceFilter)
+	}
+	spin := m.startDiscoveryLoading()
+	return m, tea.Batch(discoverServicesCmd(cluster, namespaceFilter, m.discoveryRun), spin)
+}
+
+// startDiscoveryLoading shows the loading view with a fresh spinner for a
+// kubectl operation starting now and returns the spinner's first tick
+func (m *Model) startDiscoveryLoading() tea.Cmd {
 	m.discoveryLoading = true
+	m.discoveryStartedAt = time.Now()
+	m.discoveryRun++
+	m.discoverySpinner = spinner.New(spinner.WithSpinner(spinner.MiniDot),
+		spinner.WithStyle(lipgloss.NewStyle().Foreground(lipgloss.Color(ColorTitle))))
+	return m.discoverySpinner.Tick
+}
 
-	return m, discoverServicesCmd(selectedCluster)
+// toggleAllNamespaces re-runs discovery of the selected cluster with all
+// namespaces, or with the configured default filter if it already covers all
+func (m *Model) toggleAllNamespaces() (tea.Model, tea.Cmd) {
+	if m.discoveryLoading {
+		return m, nil
+	}
+	cluster := m.discoveryClusters[m.discoverySelectedCluster]
+	defaultFilter := config.DiscoveryNamespaceFilter(m.configStore.GetSettings(), cluster)
+	if defaultFilter == "*" {
+		m.statusMsg = fmt.Sprintf("Already showing all namespaces (set %s to narrow discovery)", config.SettingDiscoveryNamespaces)
+		return m, nil
+	}
+	if m.discoveryNamespaceFilter == "*" {
+		return m.discoverServices(defaultFilter)
+	}
+	return m.discoverServices("*")
 }
 
 // refreshDiscoveryTable updates the discovery table based on current phase
@@ -261,6 +339,9 @@ func (m *Model) initializeServiceSelectionTable() {
 
 		// Determine local port display - show edit input if this row is being edited
 		localPortDisplay := fmt.Sprintf("%d", port.LocalPort)
+		if config.LocalPortWarning(port.LocalPort, 1) != "" {
+			localPortDisplay += " ⚠"
+		}
 
 		// Check if this row is being edited (need to find actual index in full list)
 		if m.discoveryEditMode {
@@ -289,18 +370,6 @@ func (m *Model) initializeServiceSelectionTable() {
 	// Create and configure the port selection table with dynamic columns
 	columns := m.calculateDiscoveryServiceColumns()
 
-	// Apply table styles (used only on first init)
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(ColorBorder)).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color(ColorSelectedFg)).
-		Background(lipgloss.Color(ColorSelectedBg)).
-		Bold(false)
-
 	// Calculate proper table height accounting for all UI elements
 	// Title (2 lines) + Filter (3 lines) + Instructions (2 lines) + Controls (2 lines) + margins
 	availableHeight := m.height - 9 // More conservative height calculation
@@ -322,14 +391,7 @@ func (m *Model) initializeServiceSelectionTable() {
 		m.discoveryTable.SetCursor(currentCursor)
 	} else {
 		// First-time initialization
-		m.discoveryTable = table.New(
-			table.WithColumns(columns),
-			table.WithRows(rows),
-			table.WithFocused(true),
-			table.WithHeight(tableHeight),
-			table.WithKeyMap(navTableKeyMap()),
-			table.WithStyles(s),
-		)
+		m.discoveryTable = newNavTable(columns, rows, tableHeight)
 	}
 }
 
@@ -350,6 +412,7 @@ func (m *Model) handleServiceToggle() (tea.Model, tea.Cmd) {
 		for i := range m.discoveryPorts {
 			if m.discoveryPorts[i].GeneratedID == selectedPort.GeneratedID {
 				m.discoveryPorts[i].Selected = !m.discoveryPorts[i].Selected
+				m.warnIfRemovingActive(m.discoveryPorts[i])
 				break
 			}
 		}
@@ -359,6 +422,7 @@ func (m *Model) handleServiceToggle() (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 		m.discoveryPorts[selectedIdx].Selected = !m.discoveryPorts[selectedIdx].Selected
+		m.warnIfRemovingActive(m.discoveryPorts[selectedIdx])
 	}
 
 	// Store current cursor position before refresh
@@ -369,6 +433,16 @@ func (m *Model) handleServiceToggle() (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
+// warnIfRemovingActive tells the user when deselecting port would remove a
+// forward that is running, so confirming stops it
+func (m *Model) warnIfRemovingActive(port PortSelection) {
+	m.statusMsg = ""
+	if port.Selected || port.ExistingConfigID == "" || !m.isForwardActive(port.ExistingConfigID) {
+		return
+	}
+	m.statusMsg = fmt.Sprintf("%s:%d is running - confirming will stop and remove it", port.ServiceName, port.Port.Port)
+}
+
 // applyDiscoveryPortFilter filters ports based on the filter input
 func (m *Model) applyDiscoveryPortFilter() []PortSelection {
 	filterText := strings.ToLower(strings.TrimSpace(m.discoveryFilterInput.Value()))
@@ -398,6 +472,7 @@ func (m *Model) handleServiceSelectionConfirm() (tea.Model, tea.Cmd) {
 	addedCount := 0
 	updatedCount := 0
 	removedCount := 0
+	stoppedCount := 0
 
 	// Process each port selection
 	for _, portSelection := range m.discoveryPorts {
@@ -409,16 +484,21 @@ func (m *Model) handleServiceSelectionConfirm() (tea.Model, tea.Cmd) {
 				logging.LogDebug("Port %s already exists in config, no changes needed", portSelection.GeneratedID)
 				// Note: We intentionally don't increment any counters here since no actual change is made
 			} else {
-				// Port is deselected - remove from config
-				existingCfg, exists := m.configStore.Get(portSelection.ExistingConfigIndex)
+				// Port is deselected - remove from config. The store stops
+				// the forward first (see SetBeforeDelete).
+				existingCfg, exists := m.configStore.GetConfigByID(portSelection.ExistingConfigID)
 				if exists {
 					if sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore); ok {
+						wasActive := m.isForwardActive(existingCfg.ID)
 						err := sqliteStore.DeletePortForward(existingCfg.ID)
 						if err != nil {
 							m.errorMsg = fmt.Sprintf("Failed to remove port: %v", err)
 							continue
 						}
 						removedCount++
+						if wasActive {
+							stoppedCount++
+						}
 						logging.LogDebug("Removed port %s from config", portSelection.GeneratedID)
 					}
 				}
@@ -434,6 +514,7 @@ func (m *Model) handleServiceSelectionConfirm() (tea.Model, tea.Cmd) {
 					Service:    portSelection.ServiceName,
 					PortRemote: int(portSelection.Port.Port),
 					PortLocal:  portSelection.LocalPort,
+					Kubeconfig: m.discoveryClusterSources[clusterName],
 				}
 
 				err := m.configStore.Add(cfg)
@@ -441,6 +522,17 @@ func (m *Model) handleServiceSelectionConfirm() (tea.Model, tea.Cmd) {
 					m.errorMsg = fmt.Sprintf("Failed to add port: %v", err)
 					continue
 				}
+				snapshot := config.ServiceSnapshot{
+					Type:         portSelection.ServiceType,
+					Labels:       portSelection.ServiceLabels,
+					TargetPort:   portSelection.Port.TargetPort,
+					PortName:     portSelection.Port.Name,
+					DiscoveredAt: time.Now(),
+				}
+				if err := m.configStore.SetServiceSnapshot(cfg.ID, snapshot); err != nil {
+					// The forward itself was added; prune just has less to go on
+					logging.LogError("Failed to record discovery snapshot for %s: %v", cfg.ID, err)
+				}
 				addedCount++
 				logging.LogDebug("Added new port %s to config", portSelection.GeneratedID)
 			}
@@ -459,6 +551,9 @@ func (m *Model) handleServiceSelectionConfirm() (tea.Model, tea.Cmd) {
 	if removedCount > 0 {
 		statusParts = append(statusParts, fmt.Sprintf("%d removed", removedCount))
 	}
+	if stoppedCount > 0 {
+		statusParts = append(statusParts, fmt.Sprintf("%d running forward(s) stopped", stoppedCount))
+	}
 
 	if len(statusParts) > 0 {
 		m.statusMsg = fmt.Sprintf("Port forwards: %s", strings.Join(statusParts, ", "))
@@ -566,6 +661,27 @@ func sanitizeIDPart(input string) string {
 	return result
 }
 
+// discoveryPortWarning returns the config.LocalPortWarning for the local
+// port being typed, or else for the highlighted row's
+func (m *Model) discoveryPortWarning() string {
+	if m.discoveryEditMode {
+		port, err := strconv.Atoi(strings.TrimSpace(m.discoveryEditInput.Value()))
+		if err != nil || port < 1 || port > 65535 {
+			return ""
+		}
+		return config.LocalPortWarning(port, 1)
+	}
+	ports := m.discoveryPorts
+	if m.discoveryFilterInput.Value() != "" {
+		ports = m.applyDiscoveryPortFilter()
+	}
+	cursor := m.discoveryTable.Cursor()
+	if cursor < 0 || cursor >= len(ports) {
+		return ""
+	}
+	return config.LocalPortWarning(ports[cursor].LocalPort, 1)
+}
+
 // handleDiscoveryEditStart enters edit mode for the local port of the currently selected row
 // NOTE: This function should only be called after checking that the port is not an existing configuration
 func (m *Model) handleDiscoveryEditStart() (tea.Model, tea.Cmd) {
@@ -691,3 +807,21 @@ func (m *Model) handleDiscoveryEditConfirm() (tea.Model, tea.Cmd) {
 
 	return m, nil
 }
+
+// resizeServiceDiscovery fits the discovery table of the current phase and
+// the filter input to the window
+func (m *Model) resizeServiceDiscovery() {
+	m.discoveryFilterInput.Width = max(m.width-4, 20)
+	if m.discoveryTable.Rows() == nil {
+		return
+	}
+	switch m.discoveryPhase {
+	case PhaseClusterSelection:
+		m.discoveryTable.SetColumns(m.calculateClusterSelectionColumns())
+		m.discoveryTable.SetHeight(min(len(m.discoveryTable.Rows())+2, m.height-6))
+	case PhaseServiceSelection:
+		m.discoveryTable.SetColumns(m.calculateDiscoveryServiceColumns())
+		// Title, filter, instructions and controls take about 9 lines
+		m.discoveryTable.SetHeight(min(len(m.discoveryTable.Rows())+2, max(m.height-9, 4)))
+	}
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
-	"github.com/xlttj/kprtfwd/pkg/k8s"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -37,6 +36,138 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 		}
 
+		if m.bulkEditMode {
+			switch msg.String() {
+			case "esc":
+				m.bulkEditMode = false
+				m.bulkEditInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitBulkEdit()
+				return m, nil
+			default:
+				m.bulkEditInput, cmd = m.bulkEditInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.argsEditMode {
+			switch msg.String() {
+			case "esc":
+				m.argsEditMode = false
+				m.argsEditInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitArgsEdit()
+				return m, nil
+			default:
+				m.argsEditInput, cmd = m.argsEditInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.openEditMode {
+			switch msg.String() {
+			case "esc":
+				m.openEditMode = false
+				m.openEditInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitOpenEdit()
+				return m, nil
+			default:
+				m.openEditInput, cmd = m.openEditInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.podEditMode {
+			switch msg.String() {
+			case "esc":
+				m.podEditMode = false
+				m.podEditInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitPodEdit()
+				return m, nil
+			default:
+				m.podEditInput, cmd = m.podEditInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.hookEditMode {
+			switch msg.String() {
+			case "esc":
+				m.hookEditMode = false
+				m.hookEditInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitHookEdit()
+				return m, nil
+			default:
+				m.hookEditInput, cmd = m.hookEditInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.healthEditMode {
+			switch msg.String() {
+			case "esc":
+				m.healthEditMode = false
+				m.healthEditInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitHealthEdit()
+				return m, nil
+			default:
+				m.healthEditInput, cmd = m.healthEditInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.addMode {
+			switch msg.String() {
+			case "esc":
+				m.addMode = false
+				m.addTemplate = ""
+				m.addInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitAddForward()
+				return m, nil
+			case "tab":
+				m.openTemplatePicker()
+				return m, nil
+			default:
+				m.addInput, cmd = m.addInput.Update(msg)
+				return m, cmd
+			}
+		}
+
+		if m.tagsEditMode {
+			switch msg.String() {
+			case "esc":
+				m.tagsEditMode = false
+				m.tagsEditInput.Blur()
+				m.portForwardsTable.Focus()
+				return m, nil
+			case "enter":
+				m.commitTagsEdit()
+				return m, nil
+			default:
+				m.tagsEditInput, cmd = m.tagsEditInput.Update(msg)
+				return m, cmd
+			}
+		}
+
 		// Handle filter mode second
 		if m.filterMode {
 			switch msg.String() {
@@ -53,17 +184,23 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Exit filter mode but keep filter applied
 				m.filterMode = false
 				m.filterInput.Blur()
+				m.flushFilter()
 				m.portForwardsTable.Focus()
 				return m, nil
 			default:
-				// Update filter input and apply filter
+				// Update filter input; the filter follows once typing pauses
 				m.filterInput, cmd = m.filterInput.Update(msg)
-				m.applyFilter()
-				m.refreshTable()
-				return m, cmd
+				return m, tea.Batch(cmd, m.scheduleFilter())
 			}
 		}
 
+		// A stop or start confirmation only applies to the key press right
+		// after it.
+		confirmStopID, confirmStartID := m.confirmStopID, m.confirmStartID
+		m.confirmStopID, m.confirmStartID = "", ""
+		confirmBulkStop := m.confirmBulkStop
+		m.confirmBulkStop = ""
+
 		switch msg.String() {
 		case "/":
 			// Enter filter mode
@@ -84,9 +221,18 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.refreshTable()
 				return m, nil
 			}
+			// Then the quick filters
+			if len(m.activeQuickFilters) > 0 {
+				m.activeQuickFilters = nil
+				m.filteredConfigs = nil
+				m.refreshTable()
+				return m, nil
+			}
 			// Do nothing, as there's no menu to go back to.
 			// Previously: m.uiState = StateMenu
 			return m, nil
+		case "enter": // Action menu for the selected forward
+			return m.enterActionMenu()
 		case " ": // Space key for toggling
 			m.errorMsg = ""  // Clear any previous error before attempting toggle
 			m.statusMsg = "" // Clear any previous status message
@@ -96,6 +242,7 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Toggle group expand/collapse
 				groupName := m.getSelectedGroupName()
 				if state, exists := m.groupStates[groupName]; exists {
+					m.touchGroup(groupName)
 					state.Expanded = !state.Expanded
 					// Refresh through refreshTable so any active filter is preserved
 					m.refreshTable()
@@ -122,7 +269,16 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 			}
 
 			// Check current runtime state to determine toggle action
-			if m.portForwarder.IsRunning(cfg.ID) { // Currently running - stop it
+			if m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID) { // Currently running or queued - stop it
+				// Warn before cutting off open connections (a long dump, say).
+				if open := m.portForwarder.ActiveConnections(cfg.ID); open > 0 && confirmStopID != cfg.ID {
+					m.confirmStopID = cfg.ID
+					m.statusMsg = fmt.Sprintf("%s has %d open connection(s); press Space again to stop (they get %s to finish)",
+						cfg.Service, open, m.portForwarder.DrainTimeout())
+					return m, nil
+				}
+				delete(m.snoozedUntil, cfg.ID)
+				delete(m.expiresAt, cfg.ID)
 				err := m.portForwarder.Stop(cfg.ID)
 				if err != nil {
 					logging.LogError("Error stopping port-forward '%s': %v", cfg.ID, err)
@@ -132,13 +288,16 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.refreshTable()
 				return m, nil
 			} else { // Currently stopped - start it
+				// Warn before exposing a cluster service to the local network.
+				if warning := m.exposureWarning(cfg); warning != "" && confirmStartID != cfg.ID {
+					m.confirmStartID = cfg.ID
+					m.errorMsg = warning + "; press Space again to start it"
+					return m, nil
+				}
+				delete(m.snoozedUntil, cfg.ID) // starting early ends a snooze
 				err := m.portForwarder.Start(cfg)
 				if err != nil {
-					if errors.Is(err, k8s.ErrPortInUse) {
-						m.errorMsg = fmt.Sprintf("Cannot start %s: %v", cfg.Service, err)
-					} else {
-						m.errorMsg = fmt.Sprintf("Error starting %s: %v", cfg.Service, err)
-					}
+					m.errorMsg = fmt.Sprintf("Cannot start %s: %s", cfg.Service, friendlyError(err))
 					// Refresh so the failed forward shows its Error status immediately
 					m.refreshTable()
 					return m, nil
@@ -154,6 +313,118 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Refresh table with new grouping mode
 			m.refreshTable()
 			return m, nil
+		case "i": // Toggle the detail pane for the selected forward
+			m.errorMsg = ""
+			m.statusMsg = ""
+			m.toggleDetail()
+			return m, nil
+		case "?": // Switch the footer between hints and every key
+			m.showAllKeys = !m.showAllKeys
+			return m, nil
+		case "L": // Toggle the LATENCY column
+			m.errorMsg = ""
+			m.statusMsg = ""
+			m.showLatency = !m.showLatency
+			m.applyColumnLayout()
+			if m.showLatency && !m.latencyProbing {
+				m.latencyProbing = true
+				return m, probeLatencyCmd(m.portForwarder)
+			}
+			return m, nil
+		case "I": // Toggle the ID column
+			m.errorMsg = ""
+			m.statusMsg = ""
+			m.showIDs = !m.showIDs
+			m.applyColumnLayout()
+			return m, nil
+		case "y": // Copy the selected forward's ID to the clipboard
+			m.errorMsg = ""
+			m.statusMsg = ""
+			if m.isGroupHeaderSelected() {
+				m.errorMsg = "Cannot copy the ID of a group header"
+				return m, nil
+			}
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot copy ID: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot copy ID: %v", err)
+				return m, nil
+			}
+			if err := copyToClipboard(cfg.ID); err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot copy %s: %v", cfg.ID, err)
+			} else {
+				m.statusMsg = fmt.Sprintf("Copied %s to the clipboard", cfg.ID)
+			}
+			return m, nil
+		case "z": // Toggle lazy mode (kubectl starts on the first connection)
+			m.errorMsg = ""
+			m.statusMsg = ""
+			if m.isGroupHeaderSelected() {
+				m.errorMsg = "Cannot change lazy mode of group headers"
+				return m, nil
+			}
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot toggle lazy mode: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot toggle lazy mode: %v", err)
+				return m, nil
+			}
+			if cfg.Ports() > 1 {
+				m.errorMsg = "Port ranges cannot be lazy"
+				return m, nil
+			}
+			m.toggleLazy(cfg)
+			return m, nil
+		case "T": // Cycle TLS mode: off → terminate → originate
+			m.errorMsg = ""
+			m.statusMsg = ""
+			if m.isGroupHeaderSelected() {
+				m.errorMsg = "Cannot change TLS mode of group headers"
+				return m, nil
+			}
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot change TLS mode: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot change TLS mode: %v", err)
+				return m, nil
+			}
+			if cfg.Ports() > 1 {
+				m.errorMsg = "Port ranges cannot use TLS"
+				return m, nil
+			}
+			m.cycleTLSMode(cfg)
+			return m, nil
+		case "A": // Cycle listen addresses: IPv4 → IPv6 → dual-stack
+			m.errorMsg = ""
+			m.statusMsg = ""
+			if m.isGroupHeaderSelected() {
+				m.errorMsg = "Cannot change listen addresses of group headers"
+				return m, nil
+			}
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot change listen addresses: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot change listen addresses: %v", err)
+				return m, nil
+			}
+			m.cycleListen(cfg)
+			return m, nil
 		case "o": // Open in browser
 			m.errorMsg = ""  // Clear error
 			m.statusMsg = "" // Clear status
@@ -178,6 +449,11 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			}
 
+			// A forward with an open command runs it instead of the browser
+			if cfg.OpenCommand != "" {
+				return m, runOpenCommand(cfg)
+			}
+
 			// Open the HTTP URL in browser
 			err = m.openInBrowser(cfg)
 			if err != nil {
@@ -186,6 +462,77 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.statusMsg = fmt.Sprintf("Opened http://localhost:%d in browser", cfg.PortLocal)
 			}
 			return m, nil
+		case "x": // Shell into a pod behind the forward
+			m.errorMsg = ""
+			m.statusMsg = ""
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot open a shell: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot get config: %v", err)
+				return m, nil
+			}
+			return m, m.openShell(cfg)
+		case "l": // Follow the logs of the pods behind the forward
+			m.errorMsg = ""
+			m.statusMsg = ""
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot show logs: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot get config: %v", err)
+				return m, nil
+			}
+			return m, m.enterLogs(cfg)
+		case "d": // Describe the service and its endpoints, or the workload
+			m.errorMsg = ""
+			m.statusMsg = ""
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot describe: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot get config: %v", err)
+				return m, nil
+			}
+			return m, m.enterDescribe(cfg)
+		case "p": // Pin the forward to another pod behind its service
+			m.errorMsg = ""
+			m.statusMsg = ""
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot pin: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot get config: %v", err)
+				return m, nil
+			}
+			return m, m.startPinPod(cfg)
+		case "delete": // Delete the forward, after confirmation
+			m.errorMsg = ""
+			m.statusMsg = ""
+			selectedIdx, err := m.getConfigIndexFromTableRow()
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot delete: %v", err)
+				return m, nil
+			}
+			cfg, err := m.configStore.GetWithError(selectedIdx)
+			if err != nil {
+				m.errorMsg = fmt.Sprintf("Cannot get config: %v", err)
+				return m, nil
+			}
+			m.confirmDelete(cfg)
+			return m, nil
 		case "e": // Edit local port
 			m.errorMsg = ""  // Clear any previous errors
 			m.statusMsg = "" // Clear any previous status
@@ -212,21 +559,42 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 			// Enter edit mode
 			m.editMode = true
-			m.editConfigIndex = selectedIdx
-			m.editInput.SetValue(fmt.Sprintf("%d", cfg.PortLocal))
+			m.editConfigID = cfg.ID
+			m.editInput.SetValue(formatPorts(cfg.PortLocal, cfg.Ports()))
 			m.editInput.Focus()
 			m.portForwardsTable.Blur()
 			return m, nil
-		case "S": // Stop all running port-forwards
+		case "E": // Rewrite the local ports of every listed forward
 			m.errorMsg = ""
 			m.statusMsg = ""
-			count := m.portForwarder.StopAllRunning()
-			if count > 0 {
-				m.statusMsg = fmt.Sprintf("Stopped %d port forward(s)", count)
-			} else {
-				m.statusMsg = "No running port forwards to stop"
+			if len(m.listedConfigs()) == 0 {
+				m.errorMsg = "No port forwards listed to rewrite"
+				return m, nil
 			}
-			m.refreshTable()
+			m.bulkEditMode = true
+			m.bulkEditInput.SetValue("")
+			m.bulkEditInput.Focus()
+			m.portForwardsTable.Blur()
+			return m, nil
+		case "+": // Add a forward from a pasted kubectl command or URL
+			m.startAddForward()
+			return m, nil
+		case "a": // Add a forward by filling in a form
+			m.enterAddForm()
+			return m, nil
+		case "!": // Configuration problems
+			m.enterProblems()
+			return m, nil
+		case "W": // Switch to another workspace
+			return m.enterWorkspaceSelector()
+		case "s": // Start or stop the forwards of the selected group
+			m.toggleGroup(confirmBulkStop == "s")
+			return m, nil
+		case "S": // Start every listed forward
+			m.startListed()
+			return m, nil
+		case "K": // Stop every listed forward
+			m.stopListed(confirmBulkStop == "K")
 			return m, nil
 		case ShortcutRestartForwards: // ctrl+r
 			m.errorMsg = "" // Clear any previous errors
@@ -237,9 +605,20 @@ func (m *Model) updatePortForwards(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case ShortcutDiscovery: // ctrl+d
 			// Switch to service discovery
 			return m.enterServiceDiscovery()
+		case ShortcutFinder: // ctrl+f
+			// Search forwards, projects and discovery results at once
+			return m.enterFinder()
+		case ShortcutPrune: // ctrl+u
+			// Review forwards whose service is gone
+			return m.enterPrune()
 
-		// Default case for keys not handled above: pass to table
+		// Default case for keys not handled above: quick filter toggles,
+		// else pass to table
 		default:
+			if qf, ok := quickFilterForKey(msg.String()); ok {
+				m.toggleQuickFilter(qf)
+				return m, nil
+			}
 			m.portForwardsTable, cmd = m.portForwardsTable.Update(msg)
 			return m, cmd
 		}
@@ -261,29 +640,35 @@ func (m *Model) commitPortEdit() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	// Parse the port number
-	newPort, err := strconv.Atoi(portStr)
-	if err != nil {
-		m.errorMsg = "Port must be a number"
+	// Get the current config
+	cfg, ok := m.configStore.GetConfigByID(m.editConfigID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.editConfigID)
 		m.editMode = false
 		m.editInput.Blur()
 		m.portForwardsTable.Focus()
 		return m, nil
 	}
 
-	// Validate port range
-	if newPort < 1 || newPort > 65535 {
-		m.errorMsg = "Port must be between 1 and 65535"
+	// Parse "PORT" (moves the forward, keeping its size) or "FIRST-LAST"
+	// (turns it into a range of that size).
+	newPort, newCount, err := parseLocalPorts(portStr, cfg.Ports())
+	if err != nil {
+		m.errorMsg = err.Error()
 		m.editMode = false
 		m.editInput.Blur()
 		m.portForwardsTable.Focus()
 		return m, nil
 	}
-
-	// Get the current config
-	cfg, err := m.configStore.GetWithError(m.editConfigIndex)
-	if err != nil {
-		m.errorMsg = fmt.Sprintf("Cannot get config to update: %v", err)
+	if newCount > 1 && (cfg.Lazy || cfg.TLSMode != config.TLSModeNone) {
+		m.errorMsg = "Lazy and TLS forwards cannot be port ranges"
+		m.editMode = false
+		m.editInput.Blur()
+		m.portForwardsTable.Focus()
+		return m, nil
+	}
+	if err := config.ValidatePortRange("Remote port", cfg.PortRemote, newCount); err != nil {
+		m.errorMsg = err.Error()
 		m.editMode = false
 		m.editInput.Blur()
 		m.portForwardsTable.Focus()
@@ -291,7 +676,7 @@ func (m *Model) commitPortEdit() (tea.Model, tea.Cmd) {
 	}
 
 	// Check if port has actually changed
-	if cfg.PortLocal == newPort {
+	if cfg.PortLocal == newPort && cfg.Ports() == newCount {
 		// No change, just exit edit mode
 		m.editMode = false
 		m.editInput.Blur()
@@ -313,46 +698,33 @@ func (m *Model) commitPortEdit() (tea.Model, tea.Cmd) {
 		}
 	}
 
-	// Update the config - use delete + add since we don't have update method
-	// First, delete the old config
-	if sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore); ok {
-		err = sqliteStore.DeletePortForward(cfg.ID)
-		if err != nil {
-			m.errorMsg = fmt.Sprintf("Error deleting old config: %v", err)
-			m.editMode = false
-			m.editInput.Blur()
-			m.portForwardsTable.Focus()
-			return m, nil
-		}
-
-		// Create updated config with new port
-		updatedCfg := cfg
-		updatedCfg.PortLocal = newPort
+	// Update the config in place so project membership and the discovery
+	// snapshot stay attached to it
+	updatedCfg := cfg
+	updatedCfg.PortLocal = newPort
+	updatedCfg.PortCount = newCount
+	if err := m.configStore.UpdatePortForward(cfg.ID, updatedCfg); err != nil {
+		m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+		m.editMode = false
+		m.editInput.Blur()
+		m.portForwardsTable.Focus()
+		return m, nil
+	}
 
-		// Add the updated config back
-		err = m.configStore.Add(updatedCfg)
+	// If it was running before, start it with the new port
+	if wasRunning {
+		err = m.portForwarder.Start(updatedCfg)
 		if err != nil {
-			m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
-			m.editMode = false
-			m.editInput.Blur()
-			m.portForwardsTable.Focus()
-			return m, nil
-		}
-
-		// If it was running before, start it with the new port
-		if wasRunning {
-			err = m.portForwarder.Start(updatedCfg)
-			if err != nil {
-				logging.LogError("Error restarting port-forward '%s' after edit: %v", updatedCfg.ID, err)
-				m.errorMsg = fmt.Sprintf("Updated port but failed to restart %s: %v", cfg.Service, err)
-			} else {
-				m.statusMsg = fmt.Sprintf("Updated %s local port to %d and restarted", cfg.Service, newPort)
-			}
+			logging.LogError("Error restarting port-forward '%s' after edit: %v", updatedCfg.ID, err)
+			m.errorMsg = fmt.Sprintf("Updated port but failed to restart %s: %s", cfg.Service, friendlyError(err))
 		} else {
-			m.statusMsg = fmt.Sprintf("Updated %s local port to %d", cfg.Service, newPort)
+			m.statusMsg = fmt.Sprintf("Updated %s local port to %s and restarted", cfg.Service, formatPorts(newPort, newCount))
 		}
 	} else {
-		m.errorMsg = "Update not supported with current config store"
+		m.statusMsg = fmt.Sprintf("Updated %s local port to %s", cfg.Service, formatPorts(newPort, newCount))
+	}
+	if warning := config.LocalPortWarning(newPort, newCount); warning != "" && m.errorMsg == "" {
+		m.statusMsg += " (⚠ " + warning + ")"
 	}
 
 	// Exit edit mode and refresh table
@@ -361,9 +733,493 @@ func (m *Model) commitPortEdit() (tea.Model, tea.Cmd) {
 	m.portForwardsTable.Focus()
 	// If a filter is active, rebuild its cached result from the updated store;
 	// otherwise the edited port would keep showing the stale cached value.
-	if m.filterMode || m.filterInput.Value() != "" {
+	if m.filtering() {
 		m.applyFilter()
 	}
 	m.refreshTable()
 	return m, nil
 }
+
+// commitBulkEdit rewrites the local ports of the listed forwards with the
+// entered rule and restarts the running ones on their new ports.
+func (m *Model) commitBulkEdit() {
+	m.bulkEditMode = false
+	m.bulkEditInput.Blur()
+	m.portForwardsTable.Focus()
+
+	rule, err := config.ParsePortRewrite(m.bulkEditInput.Value())
+	if err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	sqliteStore, ok := m.configStore.(*config.SQLiteConfigStore)
+	if !ok {
+		m.errorMsg = "Update not supported with current config store"
+		return
+	}
+
+	selected := m.listedConfigs()
+	listed := make(map[string]bool, len(selected))
+	for _, cfg := range selected {
+		listed[cfg.ID] = true
+	}
+	var others []config.PortForwardConfig
+	for _, cfg := range m.configStore.GetAll() {
+		if !listed[cfg.ID] {
+			others = append(others, cfg)
+		}
+	}
+
+	rewritten, err := config.RewriteLocalPorts(selected, others, rule)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot rewrite ports: %v", err)
+		return
+	}
+	if err := sqliteStore.UpdatePortForwards(rewritten); err != nil {
+		m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+		return
+	}
+
+	// Stop everything first: a forward may move onto a port another one
+	// in the selection is only now giving up.
+	var restart []config.PortForwardConfig
+	for _, cfg := range rewritten {
+		if m.portForwarder.IsRunning(cfg.ID) {
+			if err := m.portForwarder.Stop(cfg.ID); err != nil {
+				logging.LogError("Error stopping port-forward '%s' for rewrite: %v", cfg.ID, err)
+			}
+			restart = append(restart, cfg)
+		}
+	}
+	failed := 0
+	for _, cfg := range restart {
+		if err := m.portForwarder.Start(cfg); err != nil {
+			logging.LogError("Error restarting port-forward '%s' after rewrite: %v", cfg.ID, err)
+			failed++
+		}
+	}
+
+	m.statusMsg = fmt.Sprintf("Rewrote %d local port(s) with %s", len(rewritten), rule)
+	if failed > 0 {
+		m.errorMsg = fmt.Sprintf("Rewrote %d local port(s), but %d of %d restarts failed", len(rewritten), failed, len(restart))
+	}
+	if m.filtering() {
+		m.applyFilter()
+	}
+	m.refreshTable()
+}
+
+// startArgsEdit opens the kubectl arguments input for cfg
+func (m *Model) startArgsEdit(cfg config.PortForwardConfig) {
+	m.argsEditMode = true
+	m.argsEditID = cfg.ID
+	m.argsEditInput.SetValue(cfg.KubectlArgs)
+	m.argsEditInput.CursorEnd()
+	m.argsEditInput.Focus()
+	m.portForwardsTable.Blur()
+}
+
+// commitArgsEdit saves the entered kubectl arguments and restarts the forward
+// if it is running.
+func (m *Model) commitArgsEdit() {
+	m.argsEditMode = false
+	m.argsEditInput.Blur()
+	m.portForwardsTable.Focus()
+
+	cfg, ok := m.configStore.GetConfigByID(m.argsEditID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.argsEditID)
+		return
+	}
+	args := strings.Join(strings.Fields(m.argsEditInput.Value()), " ")
+	if args == cfg.KubectlArgs {
+		return
+	}
+	if err := config.ValidateKubectlArgs(args); err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	summary := fmt.Sprintf("Set kubectl arguments for %s to %s", cfg.Service, args)
+	if args == "" {
+		summary = fmt.Sprintf("Cleared kubectl arguments for %s", cfg.Service)
+	}
+	m.applyForwardUpdate(cfg, func(c *config.PortForwardConfig) { c.KubectlArgs = args }, summary)
+}
+
+// startPodEdit opens the pod selector input for cfg
+func (m *Model) startPodEdit(cfg config.PortForwardConfig) {
+	m.podEditMode = true
+	m.podEditID = cfg.ID
+	m.podEditInput.SetValue(cfg.PodSelector)
+	m.podEditInput.CursorEnd()
+	m.podEditInput.Focus()
+	m.portForwardsTable.Blur()
+}
+
+// commitPodEdit saves the entered pod selector and restarts the forward if it
+// is running, which resolves the selector to a pod again.
+func (m *Model) commitPodEdit() {
+	m.podEditMode = false
+	m.podEditInput.Blur()
+	m.portForwardsTable.Focus()
+
+	cfg, ok := m.configStore.GetConfigByID(m.podEditID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.podEditID)
+		return
+	}
+	selector := strings.TrimSpace(m.podEditInput.Value())
+	if selector == cfg.PodSelector {
+		return
+	}
+	if err := config.ValidatePodSelector(selector); err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	if err := config.ValidateTarget(cfg.TargetKind, selector); err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	summary := fmt.Sprintf("%s now forwards to pod %s", cfg.Service, selector)
+	if selector == "" {
+		summary = fmt.Sprintf("%s now forwards to the service", cfg.Service)
+	}
+	m.applyForwardUpdate(cfg, func(c *config.PortForwardConfig) { c.PodSelector = selector }, summary)
+}
+
+// startTagsEdit opens the tags input for cfg
+func (m *Model) startTagsEdit(cfg config.PortForwardConfig) {
+	m.tagsEditMode = true
+	m.tagsEditID = cfg.ID
+	m.tagsEditInput.SetValue(cfg.Tags)
+	m.tagsEditInput.CursorEnd()
+	m.tagsEditInput.Focus()
+	m.portForwardsTable.Blur()
+}
+
+// commitTagsEdit saves the entered tags. Tags only affect grouping, so a
+// running forward keeps running.
+func (m *Model) commitTagsEdit() {
+	m.tagsEditMode = false
+	m.tagsEditInput.Blur()
+	m.portForwardsTable.Focus()
+
+	cfg, ok := m.configStore.GetConfigByID(m.tagsEditID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.tagsEditID)
+		return
+	}
+	tags := strings.Join(strings.Fields(m.tagsEditInput.Value()), " ")
+	if tags == cfg.Tags {
+		return
+	}
+	if err := config.ValidateTags(tags); err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	if err := m.configStore.ModifyPortForward(cfg.ID, func(c *config.PortForwardConfig) error {
+		c.Tags = tags
+		return nil
+	}); err != nil {
+		m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+		return
+	}
+	m.statusMsg = fmt.Sprintf("Set tags of %s to %s", cfg.Service, tags)
+	if tags == "" {
+		m.statusMsg = fmt.Sprintf("Cleared tags of %s", cfg.Service)
+	}
+	if m.filtering() {
+		m.applyFilter()
+	}
+	m.refreshTable()
+}
+
+// startOpenEdit opens the open command input for cfg
+func (m *Model) startOpenEdit(cfg config.PortForwardConfig) {
+	m.openEditMode = true
+	m.openEditID = cfg.ID
+	m.openEditInput.SetValue(cfg.OpenCommand)
+	m.openEditInput.CursorEnd()
+	m.openEditInput.Focus()
+	m.portForwardsTable.Blur()
+}
+
+// commitOpenEdit saves the entered open command. It only changes what 'o'
+// does, so a running forward keeps running.
+func (m *Model) commitOpenEdit() {
+	m.openEditMode = false
+	m.openEditInput.Blur()
+	m.portForwardsTable.Focus()
+
+	cfg, ok := m.configStore.GetConfigByID(m.openEditID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.openEditID)
+		return
+	}
+	command := strings.TrimSpace(m.openEditInput.Value())
+	if command == cfg.OpenCommand {
+		return
+	}
+	if err := config.ValidateOpenCommand(command); err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	if err := m.configStore.ModifyPortForward(cfg.ID, func(c *config.PortForwardConfig) error {
+		c.OpenCommand = command
+		return nil
+	}); err != nil {
+		m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+		return
+	}
+	m.statusMsg = fmt.Sprintf("o now runs %s for %s", command, cfg.Service)
+	if command == "" {
+		m.statusMsg = fmt.Sprintf("o now opens %s in the browser", cfg.Service)
+	}
+}
+
+// startHookEdit opens the input for cfg's start or stop hook
+func (m *Model) startHookEdit(cfg config.PortForwardConfig, event string) {
+	m.hookEditMode = true
+	m.hookEditID = cfg.ID
+	m.hookEditEvent = event
+	m.hookEditInput.SetValue(cfg.StartHook)
+	if event == config.HookEventStop {
+		m.hookEditInput.SetValue(cfg.StopHook)
+	}
+	m.hookEditInput.CursorEnd()
+	m.hookEditInput.Focus()
+	m.portForwardsTable.Blur()
+}
+
+// commitHookEdit saves the entered hook. It applies from the forward's next
+// start on.
+func (m *Model) commitHookEdit() {
+	m.hookEditMode = false
+	m.hookEditInput.Blur()
+	m.portForwardsTable.Focus()
+
+	cfg, ok := m.configStore.GetConfigByID(m.hookEditID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.hookEditID)
+		return
+	}
+	command := strings.TrimSpace(m.hookEditInput.Value())
+	hook := func(c *config.PortForwardConfig) *string {
+		if m.hookEditEvent == config.HookEventStop {
+			return &c.StopHook
+		}
+		return &c.StartHook
+	}
+	if command == *hook(&cfg) {
+		return
+	}
+	if err := config.ValidateHook(command); err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	if err := m.configStore.ModifyPortForward(cfg.ID, func(c *config.PortForwardConfig) error {
+		*hook(c) = command
+		return nil
+	}); err != nil {
+		m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+		return
+	}
+	switch {
+	case command == "":
+		m.statusMsg = fmt.Sprintf("Removed the %s hook of %s", m.hookEditEvent, cfg.Service)
+	case m.portForwarder.IsRunning(cfg.ID):
+		m.statusMsg = fmt.Sprintf("Saved the %s hook of %s; it applies from the next start", m.hookEditEvent, cfg.Service)
+	default:
+		m.statusMsg = fmt.Sprintf("Saved the %s hook of %s", m.hookEditEvent, cfg.Service)
+	}
+}
+
+// startHealthEdit opens the health check path input for cfg
+func (m *Model) startHealthEdit(cfg config.PortForwardConfig) {
+	m.healthEditMode = true
+	m.healthEditID = cfg.ID
+	m.healthEditInput.SetValue(cfg.HealthPath)
+	m.healthEditInput.CursorEnd()
+	m.healthEditInput.Focus()
+	m.portForwardsTable.Blur()
+}
+
+// commitHealthEdit saves the entered health check path. Like a hook, it
+// applies from the forward's next start on.
+func (m *Model) commitHealthEdit() {
+	m.healthEditMode = false
+	m.healthEditInput.Blur()
+	m.portForwardsTable.Focus()
+
+	cfg, ok := m.configStore.GetConfigByID(m.healthEditID)
+	if !ok {
+		m.errorMsg = fmt.Sprintf("%s no longer exists", m.healthEditID)
+		return
+	}
+	path := strings.TrimSpace(m.healthEditInput.Value())
+	if path == cfg.HealthPath {
+		return
+	}
+	if err := config.ValidateHealthPath(path); err != nil {
+		m.errorMsg = err.Error()
+		return
+	}
+	if err := m.configStore.ModifyPortForward(cfg.ID, func(c *config.PortForwardConfig) error {
+		c.HealthPath = path
+		return nil
+	}); err != nil {
+		m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+		return
+	}
+	switch {
+	case path == "":
+		m.statusMsg = fmt.Sprintf("%s is only checked at the TCP level now", cfg.Service)
+	case m.portForwarder.IsRunning(cfg.ID):
+		m.statusMsg = fmt.Sprintf("The health check of %s GETs %s from the next start", cfg.Service, path)
+	default:
+		m.statusMsg = fmt.Sprintf("The health check of %s GETs %s", cfg.Service, path)
+	}
+}
+
+// localPortEditWarning returns the config.LocalPortWarning for the ports
+// typed into the local port editor, or "" while the input does not parse
+func (m *Model) localPortEditWarning() string {
+	cfg, ok := m.configStore.GetConfigByID(m.editConfigID)
+	if !ok {
+		return ""
+	}
+	first, count, err := parseLocalPorts(strings.TrimSpace(m.editInput.Value()), cfg.Ports())
+	if err != nil {
+		return ""
+	}
+	return config.LocalPortWarning(first, count)
+}
+
+// parseLocalPorts parses the local port edit input. A single port keeps the
+// forward's current size (a range moves as a whole); "FIRST-LAST" sets the
+// range explicitly, so "9000-9000" turns a range back into a single port.
+func parseLocalPorts(input string, currentCount int) (first, count int, err error) {
+	firstStr, lastStr, isRange := strings.Cut(input, "-")
+	first, err = strconv.Atoi(strings.TrimSpace(firstStr))
+	if err != nil {
+		return 0, 0, errors.New("Port must be a number or a range like 9000-9005")
+	}
+	count = currentCount
+	if isRange {
+		last, err := strconv.Atoi(strings.TrimSpace(lastStr))
+		if err != nil {
+			return 0, 0, errors.New("Port must be a number or a range like 9000-9005")
+		}
+		if last < first {
+			return 0, 0, errors.New("Port range end must not be below its start")
+		}
+		count = last - first + 1
+	}
+	if first < 1 || first+count-1 > 65535 {
+		return 0, 0, errors.New("Port must be between 1 and 65535")
+	}
+	return first, count, nil
+}
+
+// toggleLazy flips lazy mode for cfg.
+func (m *Model) toggleLazy(cfg config.PortForwardConfig) {
+	lazy := !cfg.Lazy
+	mode := "off"
+	if lazy {
+		mode = "on (kubectl starts on first connection)"
+	}
+	m.applyForwardUpdate(cfg, func(c *config.PortForwardConfig) { c.Lazy = lazy }, fmt.Sprintf("Lazy mode %s for %s", mode, cfg.Service))
+}
+
+// cycleTLSMode switches cfg to the next TLS mode: off, terminate, originate.
+func (m *Model) cycleTLSMode(cfg config.PortForwardConfig) {
+	var tlsMode, mode string
+	switch cfg.TLSMode {
+	case config.TLSModeNone:
+		tlsMode = config.TLSModeTerminate
+		mode = fmt.Sprintf("terminate (https://localhost:%d)", cfg.PortLocal)
+	case config.TLSModeTerminate:
+		tlsMode = config.TLSModeOriginate
+		mode = "originate (plain locally, TLS to the backend)"
+	default:
+		tlsMode = config.TLSModeNone
+		mode = "off"
+	}
+	m.applyForwardUpdate(cfg, func(c *config.PortForwardConfig) { c.TLSMode = tlsMode }, fmt.Sprintf("TLS %s for %s", mode, cfg.Service))
+}
+
+// cycleListen switches the loopback addresses cfg binds: IPv4, IPv6, both.
+func (m *Model) cycleListen(cfg config.PortForwardConfig) {
+	var listen string
+	switch cfg.Listen {
+	case config.ListenIPv4:
+		listen = config.ListenIPv6
+	case config.ListenIPv6:
+		listen = config.ListenDual
+	default:
+		listen = config.ListenIPv4
+	}
+	m.applyForwardUpdate(cfg, func(c *config.PortForwardConfig) { c.Listen = listen }, fmt.Sprintf("Listening on %s for %s",
+		strings.Join(config.ListenHosts(listen), " and "), cfg.Service))
+}
+
+// exposureWarning returns the warning to confirm before starting cfg, or ""
+// if it only listens on loopback or the security.confirm_exposed setting is
+// off.
+func (m *Model) exposureWarning(cfg config.PortForwardConfig) string {
+	address := cfg.ExposedAddress()
+	if address == "" || m.configStore.GetSettings()[config.SettingConfirmExposed] == "false" {
+		return ""
+	}
+	return fmt.Sprintf("Security warning: %s listens on %s and exposes %s/%s to your local network",
+		cfg.ID, address, cfg.Namespace, cfg.Service)
+}
+
+// applyForwardUpdate applies change to the stored forward cfg, as read again
+// (another kprtfwd may have changed it since cfg was), and, if the forward
+// is running, restarts it so the change takes effect immediately.
+func (m *Model) applyForwardUpdate(cfg config.PortForwardConfig, change func(c *config.PortForwardConfig), summary string) {
+	var updatedCfg config.PortForwardConfig
+	if err := m.configStore.ModifyPortForward(cfg.ID, func(c *config.PortForwardConfig) error {
+		change(c)
+		updatedCfg = *c
+		return nil
+	}); err != nil {
+		m.errorMsg = fmt.Sprintf("Error updating config: %v", err)
+		return
+	}
+
+	if m.portForwarder.IsRunning(cfg.ID) {
+		// A change that exposes the forward needs the same confirmation as
+		// starting it: stop it and let a Space start it again.
+		if warning := m.exposureWarning(updatedCfg); warning != "" && cfg.ExposedAddress() == "" {
+			if err := m.portForwarder.Stop(cfg.ID); err != nil {
+				logging.LogError("Error stopping port-forward '%s' after update: %v", cfg.ID, err)
+			}
+			m.confirmStartID = cfg.ID
+			m.errorMsg = fmt.Sprintf("%s and stopped it. %s; press Space to start it", summary, warning)
+			m.refreshTable()
+			return
+		}
+		if err := m.portForwarder.Restart(updatedCfg); err != nil {
+			logging.LogError("Error restarting port-forward '%s' after update: %v", cfg.ID, err)
+			m.errorMsg = fmt.Sprintf("%s, but restart failed: %s", summary, friendlyError(err))
+			m.refreshTable()
+			return
+		}
+	}
+	m.statusMsg = summary
+	if m.filtering() {
+		m.applyFilter()
+	}
+	m.refreshTable()
+}
+
+// resizePortForwards fits the port forwards table and the filter input to
+// the window
+func (m *Model) resizePortForwards() {
+	m.portForwardsTable.SetHeight(m.portForwardsTableHeight())
+	m.portForwardsTable.SetColumns(m.calculateColumnWidths())
+	// Leave some padding
+	m.filterInput.Width = max(m.width-4, 20)
+}
@@ -9,7 +9,6 @@ import (
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // enterProjectManagement switches to project management view
@@ -41,27 +40,16 @@ func (m *Model) initializeProjectManagement() {
 	}
 
 	// Create and configure the table
-	m.projectManagementTable = table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(min(len(rows)+2, m.height-8)),
-		table.WithKeyMap(navTableKeyMap()),
-	)
-
-	// Apply table styles
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(ColorBorder)).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color(ColorSelectedFg)).
-		Background(lipgloss.Color(ColorSelectedBg)).
-		Bold(false)
-
-	m.projectManagementTable.SetStyles(s)
+	m.projectManagementTable = newNavTable(columns, rows, min(len(rows)+2, m.height-8))
+}
+
+// resizeProjectManagement fits the project management table to the window
+func (m *Model) resizeProjectManagement() {
+	if m.projectManagementTable.Rows() == nil {
+		return
+	}
+	m.projectManagementTable.SetColumns(m.calculateProjectManagementColumns())
+	m.projectManagementTable.SetHeight(min(len(m.projectManagementTable.Rows())+2, m.height-8))
 }
 
 // updateProjectManagement handles updates in the project management view
@@ -237,32 +225,21 @@ func (m *Model) initializeProjectServiceSelection() {
 			checkbox = CheckboxUnchecked
 		}
 
-		ports := fmt.Sprintf("%d→%d", cfg.PortLocal, cfg.PortRemote)
-		rows[i] = table.Row{checkbox, cfg.Service, cfg.Namespace, cfg.Context, ports}
+		ports := fmt.Sprintf("%s→%s", formatPorts(cfg.PortLocal, cfg.Ports()), formatPorts(cfg.PortRemote, cfg.Ports()))
+		rows[i] = table.Row{checkbox, cfg.Service, cfg.Namespace, cfg.Context, ports, styleStatusText(m.statusFor(cfg.ID))}
 	}
 
 	// Create and configure the table
-	m.projectServiceTable = table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(min(len(rows)+2, m.height-10)),
-		table.WithKeyMap(navTableKeyMap()),
-	)
-
-	// Apply table styles
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(ColorBorder)).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color(ColorSelectedFg)).
-		Background(lipgloss.Color(ColorSelectedBg)).
-		Bold(false)
-
-	m.projectServiceTable.SetStyles(s)
+	m.projectServiceTable = newNavTable(columns, rows, min(len(rows)+2, m.height-10))
+}
+
+// resizeProjectServiceSelection fits the service selection table to the window
+func (m *Model) resizeProjectServiceSelection() {
+	if m.projectServiceTable.Rows() == nil {
+		return
+	}
+	m.projectServiceTable.SetColumns(m.calculateServiceSelectionColumns())
+	m.projectServiceTable.SetHeight(min(len(m.projectServiceTable.Rows())+2, m.height-10))
 }
 
 // updateProjectServiceSelection handles updates in the project service selection view
@@ -332,6 +309,11 @@ func (m *Model) toggleServiceInProject() (tea.Model, tea.Cmd) {
 			m.errorMsg = fmt.Sprintf("Failed to remove service: %v", err)
 		} else {
 			m.statusMsg = fmt.Sprintf("Removed %s from project %s", selectedConfig.Service, m.currentProject.Name)
+			if m.currentProject.Name == m.configStore.GetActiveProjectName() && m.portForwarder.IsRunning(selectedConfig.ID) {
+				// The project no longer lists it, so it would keep running
+				// unseen in the main view
+				m.statusMsg += fmt.Sprintf(" - %s is still running", selectedConfig.Service)
+			}
 		}
 	} else {
 		// Add service to project
@@ -2,13 +2,14 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 
 	"github.com/charmbracelet/bubbles/table"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 )
 
 // updateProjectSelector handles updates in the project selector view
@@ -31,6 +32,10 @@ func (m *Model) updateProjectSelector(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		// Enter project management mode
 		return m.enterProjectManagement()
 
+	case "r":
+		// Restart the running forwards of the highlighted project
+		return m.restartProjectSelection()
+
 	case "up", "k":
 		// Move up in project list
 		m.projectSelector, _ = m.projectSelector.Update(msg)
@@ -76,68 +81,180 @@ func (m *Model) initializeProjectSelector() {
 	}
 
 	// Create and configure the table
-	m.projectSelector = table.New(
-		table.WithColumns(columns),
-		table.WithRows(rows),
-		table.WithFocused(true),
-		table.WithHeight(min(len(rows)+2, m.height-6)),
-		table.WithKeyMap(navTableKeyMap()),
-	)
-
-	// Apply table styles
-	s := table.DefaultStyles()
-	s.Header = s.Header.
-		BorderStyle(lipgloss.NormalBorder()).
-		BorderForeground(lipgloss.Color(ColorBorder)).
-		BorderBottom(true).
-		Bold(false)
-	s.Selected = s.Selected.
-		Foreground(lipgloss.Color(ColorSelectedFg)).
-		Background(lipgloss.Color(ColorSelectedBg)).
-		Bold(false)
-
-	m.projectSelector.SetStyles(s)
+	m.projectSelector = newNavTable(columns, rows, min(len(rows)+2, m.height-6))
+}
+
+// resizeProjectSelector fits the project selector table to the window
+func (m *Model) resizeProjectSelector() {
+	if m.projectSelector.Rows() == nil {
+		return
+	}
+	m.projectSelector.SetColumns(m.calculateProjectSelectorColumns())
+	m.projectSelector.SetHeight(min(len(m.projectSelector.Rows())+2, m.height-6))
 }
 
 // handleProjectSelection processes project selection
 func (m *Model) handleProjectSelection() (tea.Model, tea.Cmd) {
 	selectedIdx := m.projectSelector.Cursor()
 
-	// Step 1: Stop all currently running port forwards
-	m.stopAllRunningPortForwards()
-
 	if selectedIdx == 0 {
-		// "All Projects" selected - clear active project
+		// "All Projects" selected - stop everything and clear active project
+		m.stopAllRunningPortForwards()
 		m.configStore.ClearActiveProject()
 		m.statusMsg = "Showing all port forwards (all running forwards stopped)"
+		m.refreshTable()
+		m.uiState = StatePortForwards
+		return m, nil
+	}
+
+	projects := m.configStore.GetAllProjects()
+	if selectedIdx-1 >= len(projects) {
+		return m, nil
+	}
+	m.beginProjectActivation(projects[selectedIdx-1])
+	return m, nil
+}
+
+// restartProjectSelection restarts the running forwards of the highlighted
+// project, or of every forward on "All Projects", without activating it
+func (m *Model) restartProjectSelection() (tea.Model, tea.Cmd) {
+	selectedIdx := m.projectSelector.Cursor()
+	if selectedIdx == 0 {
+		m.restartRunning("All Projects", m.configStore.GetAll())
+		return m, nil
+	}
+	projects := m.configStore.GetAllProjects()
+	if selectedIdx-1 >= len(projects) {
+		return m, nil
+	}
+	project := projects[selectedIdx-1]
+	var members []config.PortForwardConfig
+	for _, id := range project.Forwards {
+		if cfg, exists := m.configStore.GetConfigByID(id); exists {
+			members = append(members, cfg)
+		}
+	}
+	m.restartRunning(project.Name, members)
+	return m, nil
+}
+
+// beginProjectActivation activates project, or first opens the conflict
+// dialog if some of its forwards want the same local port.
+func (m *Model) beginProjectActivation(project config.Project) {
+	// Forwards that want the same local port would half-fail on start; let
+	// the user sort them out before anything is stopped.
+	forwards := m.projectForwards(project)
+	if _, count := findPortConflicts(forwards, nil); count > 0 {
+		m.activation = &projectActivation{
+			project:  project,
+			forwards: forwards,
+			skipped:  make(map[string]bool),
+			remapped: make(map[string]int),
+			target:   1,
+		}
+		m.errorMsg = ""
+		m.statusMsg = ""
+		m.uiState = StateProjectConflicts
+		return
+	}
+
+	m.activateProject(project, nil)
+}
+
+// activateProject stops all running forwards, makes project the active one
+// and starts its forwards, except the skipped ones, then returns to the main
+// view.
+func (m *Model) activateProject(project config.Project, skipped map[string]bool) {
+	// Step 1: Stop all currently running port forwards
+	m.stopAllRunningPortForwards()
+
+	err := m.configStore.SetActiveProject(project.Name)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Failed to set active project: %v", err)
 	} else {
-		// Actual project selected
-		projects := m.configStore.GetAllProjects()
-		if selectedIdx-1 < len(projects) {
-			selectedProject := projects[selectedIdx-1]
-			err := m.configStore.SetActiveProject(selectedProject.Name)
-			if err != nil {
-				m.errorMsg = fmt.Sprintf("Failed to set active project: %v", err)
-			} else {
-				// Step 2: Start all port forwards in the selected project
-				startedCount, startErrors := m.startProjectPortForwards(selectedProject)
-
-				if len(startErrors) > 0 {
-					m.errorMsg = fmt.Sprintf("Project '%s' activated, started %d/%d forwards. Errors: %s",
-						selectedProject.Name, startedCount, len(selectedProject.Forwards),
-						startErrors[0]) // Show first error
-				} else {
-					m.statusMsg = fmt.Sprintf("Project '%s' activated, started %d forwards",
-						selectedProject.Name, startedCount)
-				}
-			}
+		// Step 2: Start all port forwards in the selected project
+		startedCount, startErrors := m.startProjectPortForwards(project, skipped)
+		total := len(project.Forwards) - len(skipped)
+
+		if len(startErrors) > 0 {
+			m.errorMsg = fmt.Sprintf("Project '%s' activated, started %d/%d forwards. Errors: %s",
+				project.Name, startedCount, total,
+				startErrors[0]) // Show first error
+		} else {
+			m.statusMsg = fmt.Sprintf("Project '%s' activated, started %d forwards",
+				project.Name, startedCount)
 		}
 	}
 
 	// Refresh the port forwards table and return to main view
 	m.refreshTable()
 	m.uiState = StatePortForwards
-	return m, nil
+}
+
+// Startup prepares the model as the --project and --start-all flags ask:
+// project is activated as if picked in the project selector (port conflicts
+// open the conflict dialog) and startAll starts every configured forward.
+// An unknown project is an error, reported before the TUI starts.
+func (m *Model) Startup(project string, startAll bool) error {
+	if project == "" && !startAll {
+		return nil
+	}
+	if project != "" {
+		var selected *config.Project
+		for _, p := range m.configStore.GetAllProjects() {
+			if p.Name == project {
+				selected = &p
+				break
+			}
+		}
+		if selected == nil {
+			return fmt.Errorf("no project named '%s'", project)
+		}
+		// Backing out of the conflict dialog lands in the selector
+		m.initializeProjectSelector()
+		m.beginProjectActivation(*selected)
+	}
+	if startAll {
+		m.startAllPortForwards()
+	}
+	return nil
+}
+
+// startAllPortForwards starts every configured forward that is not running
+// yet. Forwards of a project awaiting conflict resolution are left alone.
+func (m *Model) startAllPortForwards() {
+	if m.uiState == StateProjectConflicts {
+		return
+	}
+	started := 0
+	var failed, exposed []string
+	for _, cfg := range m.configStore.GetAll() {
+		if m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID) {
+			continue
+		}
+		// Exposed forwards are only started one at a time, after confirmation
+		if m.exposureWarning(cfg) != "" {
+			exposed = append(exposed, cfg.ID)
+			continue
+		}
+		if err := m.portForwarder.Start(cfg); err != nil {
+			logging.LogError("Failed to start '%s' on startup: %v", cfg.ID, err)
+			failed = append(failed, cfg.ID)
+			continue
+		}
+		started++
+	}
+
+	switch {
+	case len(failed) > 0:
+		m.errorMsg = fmt.Sprintf("Started %d forwards, %d failed: %s", started, len(failed), strings.Join(failed, ", "))
+	case len(exposed) > 0:
+		m.errorMsg = fmt.Sprintf("Started %d forwards; not started because they listen outside loopback (start them with Space to confirm): %s",
+			started, strings.Join(exposed, ", "))
+	default:
+		m.statusMsg = fmt.Sprintf("Started %d forwards", started)
+	}
+	m.refreshTable()
 }
 
 // enterProjectSelector switches to project selector view
@@ -155,8 +272,8 @@ func (m *Model) stopAllRunningPortForwards() {
 	stoppedCount := 0
 
 	for _, cfg := range allConfigs {
-		if m.portForwarder.IsRunning(cfg.ID) {
-			err := m.portForwarder.Stop(cfg.ID)
+		if m.portForwarder.IsRunning(cfg.ID) || m.portForwarder.IsQueued(cfg.ID) {
+			err := m.portForwarder.StopFor(cfg.ID, k8s.StopReasonProjectSwitch)
 			if err != nil {
 				logging.LogError("Failed to stop port forward '%s' during project selection: %v", cfg.ID, err)
 			} else {
@@ -171,9 +288,10 @@ func (m *Model) stopAllRunningPortForwards() {
 	}
 }
 
-// startProjectPortForwards starts all port forwards in the given project
+// startProjectPortForwards starts all port forwards in the given project except
+// the skipped ones.
 // Returns the number of successfully started forwards and a list of error messages
-func (m *Model) startProjectPortForwards(project config.Project) (int, []string) {
+func (m *Model) startProjectPortForwards(project config.Project, skipped map[string]bool) (int, []string) {
 	startedCount := 0
 	var errorMessages []string
 
@@ -182,6 +300,11 @@ func (m *Model) startProjectPortForwards(project config.Project) (int, []string)
 	for _, forwardID := range project.Forwards {
 		logging.LogDebug("Project '%s': Processing forward ID '%s'", project.Name, forwardID)
 
+		if skipped[forwardID] {
+			logging.LogDebug("Project '%s': Forward '%s' skipped to resolve a port conflict", project.Name, forwardID)
+			continue
+		}
+
 		// Check if already running
 		if m.portForwarder.IsRunning(forwardID) {
 			logging.LogDebug("Project '%s': Forward '%s' is already running, skipping", project.Name, forwardID)
@@ -199,6 +322,12 @@ func (m *Model) startProjectPortForwards(project config.Project) (int, []string)
 		}
 		logging.LogDebug("Project '%s': Retrieved config for '%s': %s:%d -> %s:%d", project.Name, forwardID, cfg.Context, cfg.PortLocal, cfg.Service, cfg.PortRemote)
 
+		// Exposed forwards are only started one at a time, after confirmation
+		if m.exposureWarning(cfg) != "" {
+			errorMessages = append(errorMessages, fmt.Sprintf("'%s' listens on %s; start it with Space to confirm", forwardID, cfg.ExposedAddress()))
+			continue
+		}
+
 		// Start the port forward
 		logging.LogDebug("Project '%s': Attempting to start '%s'", project.Name, forwardID)
 		err := m.portForwarder.Start(cfg)
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/k8s"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// usageSampleInterval is how often the CPU and memory of the running
+// kubectl processes are sampled. A leak grows over hours, so this need not be
+// frequent; it also spaces the CPU share out over a useful window.
+const usageSampleInterval = 10 * time.Second
+
+// usageTickMsg starts a usage sample
+type usageTickMsg time.Time
+
+// usageSampledMsg carries the forwards whose kubectl is above the memory
+// limit and, with kubectl.memory_restart on, the outcome of restarting them
+type usageSampledMsg struct {
+	over      []string
+	restarted *k8s.RestartResult
+}
+
+func usageTickCmd() tea.Cmd {
+	return tea.Tick(usageSampleInterval, func(t time.Time) tea.Msg {
+		return usageTickMsg(t)
+	})
+}
+
+// sampleUsageCmd samples the kubectl processes off the event loop and
+// restarts those above the memory limit if the settings say so
+func sampleUsageCmd(pf *k8s.PortForwarder, configs []config.PortForwardConfig) tea.Cmd {
+	return func() tea.Msg {
+		msg := usageSampledMsg{over: pf.SampleUsage()}
+		if len(msg.over) == 0 || !pf.MemoryRestart() {
+			return msg
+		}
+		var bloated []config.PortForwardConfig
+		for _, cfg := range configs {
+			for _, id := range msg.over {
+				if cfg.ID == id {
+					bloated = append(bloated, cfg)
+				}
+			}
+		}
+		msg.restarted = pf.RestartRunning(bloated)
+		return msg
+	}
+}
+
+// handleUsageSampled reports kubectl processes newly above the memory limit,
+// or restarted for it, and schedules the next sample
+func (m *Model) handleUsageSampled(msg usageSampledMsg) (tea.Model, tea.Cmd) {
+	if msg.restarted != nil && msg.restarted.RestartedCount > 0 {
+		m.memoryWarned = nil
+		m.statusMsg = fmt.Sprintf("Restarted %s: kubectl used more memory than kubectl.memory_limit", strings.Join(m.serviceNames(msg.over), ", "))
+		if len(msg.restarted.Errors) > 0 {
+			m.errorMsg = m.formatRestartSummary(msg.restarted)
+		}
+		m.refreshTable()
+		return m, usageTickCmd()
+	}
+
+	warned := make(map[string]bool, len(msg.over))
+	var fresh []string
+	for _, id := range msg.over {
+		warned[id] = true
+		if !m.memoryWarned[id] {
+			fresh = append(fresh, id)
+		}
+	}
+	m.memoryWarned = warned // forgets forwards back under the limit or stopped
+	if len(fresh) > 0 {
+		m.statusMsg = fmt.Sprintf("kubectl of %s uses more memory than kubectl.memory_limit; Ctrl+R restarts it", strings.Join(m.serviceNames(fresh), ", "))
+	}
+	m.refreshTable()
+	return m, usageTickCmd()
+}
+
+// serviceNames returns the service of each forward in ids, or the ID of one
+// that no longer exists
+func (m *Model) serviceNames(ids []string) []string {
+	names := make([]string, len(ids))
+	for i, id := range ids {
+		names[i] = id
+		if cfg, ok := m.configStore.GetConfigByID(id); ok {
+			names[i] = cfg.Service
+		}
+	}
+	return names
+}
+
+// usageText describes kubectl's usage in state s for the detail pane, or ""
+// while it is unknown
+func usageText(s k8s.ForwardState) string {
+	if s.Usage.At.IsZero() {
+		return ""
+	}
+	text := fmt.Sprintf("kubectl %s, %.1f%% CPU", config.FormatMemorySize(s.Usage.RSS), s.Usage.CPUPercent)
+	if s.OverMemory {
+		text += " (above kubectl.memory_limit)"
+	}
+	return text
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/devmode"
 	"github.com/xlttj/kprtfwd/pkg/logging"
 
 	"github.com/charmbracelet/lipgloss"
@@ -13,19 +15,8 @@ import (
 func (m *Model) View() string {
 	logging.LogDebug("View called with uiState = %d", m.uiState)
 
-	switch m.uiState {
-	case StatePortForwards:
-		return m.viewPortForwards()
-	case StateProjectSelector:
-		return m.renderProjectSelector()
-	case StateServiceDiscovery:
-		return m.renderServiceDiscoveryView()
-	case StateProjectManagement:
-		return m.renderProjectManagement()
-	case StateProjectCreation:
-		return m.renderProjectCreation()
-	case StateProjectServiceSelection:
-		return m.renderProjectServiceSelection()
+	if s, ok := m.screens()[m.uiState]; ok {
+		return s.View()
 	}
 	return "Unknown state"
 }
@@ -40,50 +31,58 @@ func (m *Model) viewPortForwards() string {
 	} else {
 		titleText = "Port Forwards - All Projects"
 	}
-	title := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorTitle)).Bold(true).Render(titleText)
-
-	// Render help text based on screen width (include edit shortcut)
-	help := "Space: Toggle/Expand | E: Edit Port | G: Group Mode | O: Open URL | /: Filter | Ctrl+P: Projects | Q: Quit"
-	if m.width < 80 {
-		help = "Space:Toggle | E:Edit | G:Group | O:Open | /:Filter | Ctrl+P:Projects | Q:Quit"
+	if devmode.Enabled() {
+		titleText = "[developer mode] " + titleText
+	} else if workspace := config.CurrentWorkspace(); workspace != config.DefaultWorkspace {
+		titleText = fmt.Sprintf("[%s] %s", workspace, titleText)
 	}
+	title := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorTitle)).Bold(true).Render(titleText)
 
-	// Style help text
+	// Key help in the footer: hints for the selected row, or every key after "?"
 	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
-	helpText := helpStyle.Render(help)
+	bottom := helpStyle.Render(m.footerHelp())
+	if badge := m.problemsBadge(); badge != "" {
+		bottom = badge + helpStyle.Render(" | ") + bottom
+	}
 
 	// Render table
 	tableView := lipgloss.PlaceHorizontal(m.width, lipgloss.Left, m.portForwardsTable.View())
+	if accessibleMode {
+		tableView = m.renderAccessibleList()
+	}
+	if m.tableLoading {
+		loadingStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+		tableView = loadingStyle.Render(fmt.Sprintf("⏳ Loading %d port forwards...", len(m.configStore.GetAll())))
+	}
+	if m.showDetail {
+		tableView = lipgloss.JoinVertical(lipgloss.Left, tableView, m.renderForwardDetail())
+	}
+	if m.uiState == StateActionMenu {
+		tableView = lipgloss.JoinVertical(lipgloss.Left, tableView, m.renderActionMenu())
+	}
 
 	// Always reserve space for the filter input to prevent layout shift
 	var filterView string
 	if m.filterMode {
 		// Show the filter input with styled box
-		filterStyle := lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(ColorBorder)).
-			Padding(0, 1)
+		filterStyle := boxStyle(ColorBorder)
 
 		filterView = filterStyle.Render("Filter: " + m.filterInput.View())
 	} else if m.filterInput.Value() != "" {
 		// Show the current filter when not in edit mode with styled box
-		filterStyle := lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("8")). // Grey border for inactive
-			Foreground(lipgloss.Color("8")).       // Grey text for inactive
-			Padding(0, 1)
+		filterStyle := boxStyle("8").Foreground(lipgloss.Color("8")) // Grey border and text for inactive
 
 		filterView = filterStyle.Render(fmt.Sprintf("Filter: %s (Press / to edit, Esc to clear)", m.filterInput.Value()))
 	} else {
 		// Create a placeholder box to maintain consistent layout
-		placeholderStyle := lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")). // Very dim border
-			Foreground(lipgloss.Color("240")).       // Very dim text
-			Padding(0, 1)
+		placeholderStyle := boxStyle("240").Foreground(lipgloss.Color("240")) // Very dim border and text
 
 		filterView = placeholderStyle.Render("Press / to filter...")
 	}
+	// Quick filter chips to the right of the filter box, when they fit
+	if chips := m.renderQuickFilterChips(); lipgloss.Width(filterView)+2+lipgloss.Width(chips) <= m.width {
+		filterView = lipgloss.JoinHorizontal(lipgloss.Center, filterView, "  ", chips)
+	}
 
 	// Handle inline edit input display
 	var editView string
@@ -92,35 +91,57 @@ func (m *Model) viewPortForwards() string {
 		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11")) // Yellow for edit label
 		editLabel := editStyle.Render("Edit Local Port: ")
 		editView = editLabel + m.editInput.View() + " (Enter to save, Esc to cancel)"
-	}
-
-	// Format top area: title and potentially help text (if room)
-	var top string
-	if m.width >= 80 {
-		// Calculate spacing, ensure it's not negative
-		spacing := m.width - lipgloss.Width(title) - lipgloss.Width(helpText)
-		if spacing > 0 {
-			top = lipgloss.JoinHorizontal(lipgloss.Left, title, strings.Repeat(" ", spacing), helpText)
-		} else {
-			// Not enough room for both, just show title
-			top = title
-		}
-	} else {
-		top = title
-	}
-
-	// Format bottom area (for narrower screens)
-	var bottom string
-	if m.width < 80 {
-		bottom = helpStyle.Render(help)
+	} else if m.bulkEditMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("Rewrite local ports of %d listed forward(s): ", len(m.listedConfigs())))
+		editView = editLabel + m.bulkEditInput.View() + " (+N, -N or prefix D; Enter to apply, Esc to cancel)"
+	} else if m.argsEditMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("kubectl arguments for %s: ", m.argsEditID))
+		editView = editLabel + m.argsEditInput.View() + " (--flag=value ...; Enter to save, Esc to cancel)"
+	} else if m.openEditMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("Open command for %s: ", m.openEditID))
+		editView = editLabel + m.openEditInput.View() + " ({{host}}, {{port}}, {{url}}, ...; empty for the browser; Enter to save, Esc to cancel)"
+	} else if m.podEditMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("Pod selector for %s: ", m.podEditID))
+		editView = editLabel + m.podEditInput.View() + " (ordinal or label selector; empty for the service; Enter to save, Esc to cancel)"
+	} else if m.hookEditMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("%s hook for %s: ", strings.ToUpper(m.hookEditEvent[:1])+m.hookEditEvent[1:], m.hookEditID))
+		editView = editLabel + m.hookEditInput.View() + " ($KPRTFWD_LOCAL_PORT, $KPRTFWD_ID, ...; empty for none; Enter to save, Esc to cancel)"
+	} else if m.healthEditMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("Health check path for %s: ", m.healthEditID))
+		editView = editLabel + m.healthEditInput.View() + " (GET over HTTP; a 5xx or no answer is degraded; empty for TCP only; Enter to save, Esc to cancel)"
+	} else if m.addMode && m.addTemplate != "" {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("Add %s in context %s, namespace: ", m.addTemplate, m.currentContext))
+		editView = editLabel + m.addInput.View() + " (namespace or namespace/service; Enter to add, Esc to cancel)"
+	} else if m.addMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render("Add forward: ")
+		editView = editLabel + m.addInput.View() + " (paste a kubectl port-forward command or service URL; Tab for templates; Enter to add, Esc to cancel)"
+	} else if m.tagsEditMode {
+		editStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+		editLabel := editStyle.Render(fmt.Sprintf("Tags for %s: ", m.tagsEditID))
+		editView = editLabel + m.tagsEditInput.View() + " (separated by spaces; Enter to save, Esc to cancel)"
 	}
 
 	// Generate message text (error or status). Priority: a transient message
 	// from the last action, then the failure reason of the selected Error row.
 	var messageText string
+	portWarning := ""
+	if m.editMode {
+		portWarning = m.localPortEditWarning()
+	}
 	if m.errorMsg != "" {
 		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorError))
 		messageText = errorStyle.Render(fmt.Sprintf("ERROR: %s", m.errorMsg))
+	} else if portWarning != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorWarning))
+		messageText = warningStyle.Render("⚠ Local " + portWarning)
 	} else if m.statusMsg != "" {
 		// Use a different color for status messages (green for success)
 		statusStyle := lipgloss.NewStyle().Foreground(lipgloss.Color("10")) // Green
@@ -131,38 +152,14 @@ func (m *Model) viewPortForwards() string {
 	}
 
 	// Generate output with message, filter, and edit view
-	var output string
-	if m.editMode {
-		// Include edit view when in edit mode
-		if messageText != "" {
-			if m.width < 80 {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView, editView, messageText, bottom)
-			} else {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView, editView, messageText)
-			}
-		} else {
-			if m.width < 80 {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView, editView, bottom)
-			} else {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView, editView)
-			}
-		}
-	} else {
-		// Normal view without edit input
-		if messageText != "" {
-			if m.width < 80 {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView, messageText, bottom)
-			} else {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView, messageText)
-			}
-		} else {
-			if m.width < 80 {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView, bottom)
-			} else {
-				output = lipgloss.JoinVertical(lipgloss.Left, top, "", filterView, tableView)
-			}
-		}
+	lines := []string{title, "", filterView, tableView}
+	if m.editMode || m.bulkEditMode || m.argsEditMode || m.tagsEditMode || m.openEditMode || m.podEditMode || m.hookEditMode || m.healthEditMode || m.addMode {
+		lines = append(lines, editView)
+	}
+	if messageText != "" {
+		lines = append(lines, messageText)
 	}
+	output := lipgloss.JoinVertical(lipgloss.Left, append(lines, bottom)...)
 
 	return output
 }
@@ -3,6 +3,7 @@ package ui
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 )
@@ -43,7 +44,12 @@ func (m *Model) renderDiscoveryLoadingView() string {
 	if message == "" {
 		message = "Loading..."
 	}
-	content.WriteString(helpStyle.Render(message))
+	// Discovery of a large cluster takes a while; the elapsed time shows it
+	// still runs
+	if elapsed := time.Since(m.discoveryStartedAt); elapsed >= time.Second && !m.discoveryStartedAt.IsZero() {
+		message += fmt.Sprintf(" (%s)", sinceText(elapsed))
+	}
+	content.WriteString(m.discoverySpinner.View() + " " + helpStyle.Render(message))
 	content.WriteString("\n\n")
 	content.WriteString(helpStyle.Render("Please wait — Esc to cancel, Ctrl+C to quit"))
 
@@ -95,38 +101,41 @@ func (m *Model) renderServiceSelectionView() string {
 	if m.discoverySelectedCluster >= 0 && m.discoverySelectedCluster < len(m.discoveryClusters) {
 		clusterName = m.discoveryClusters[m.discoverySelectedCluster]
 	}
-	content.WriteString(titleStyle.Render(fmt.Sprintf("Service Discovery — %s", clusterName)))
+	namespaces := "all namespaces"
+	if m.discoveryNamespaceFilter != "" && m.discoveryNamespaceFilter != "*" {
+		namespaces = "namespaces " + m.discoveryNamespaceFilter
+	}
+	content.WriteString(titleStyle.Render(fmt.Sprintf("Service Discovery — %s (%s)", clusterName, namespaces)))
 	content.WriteString("\n")
-	content.WriteString(helpStyle.Render("Space: Toggle | e: Edit local port (new only) | /: Filter | Enter: Confirm | Esc: Back"))
+	content.WriteString(helpStyle.Render("Space: Toggle | e: Edit local port (new only) | /: Filter | a: All namespaces | w/p: Save/apply profile | Enter: Confirm | Esc: Back"))
 	content.WriteString("\n\n")
 
-	// Always show filter area to prevent layout shift
-	if m.discoveryFilterMode {
+	// Always show filter area to prevent layout shift; the profile prompt
+	// takes its place while open
+	if m.discoveryProfilePrompt != profilePromptNone {
+		promptStyle := boxStyle(ColorBorder)
+
+		label := "Save selection as: "
+		if m.discoveryProfilePrompt == profilePromptApply {
+			label = "Apply profile: "
+		}
+		content.WriteString(promptStyle.Render(label + m.discoveryProfileInput.View()))
+		content.WriteString("\n\n")
+	} else if m.discoveryFilterMode {
 		// Show the filter input with styled box
-		filterStyle := lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color(ColorBorder)).
-			Padding(0, 1)
+		filterStyle := boxStyle(ColorBorder)
 
 		content.WriteString(filterStyle.Render("Filter: " + m.discoveryFilterInput.View()))
 		content.WriteString("\n\n")
 	} else if m.discoveryFilterInput.Value() != "" {
 		// Show the current filter when not in edit mode with styled box
-		filterStyle := lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("8")). // Grey border for inactive
-			Foreground(lipgloss.Color("8")).       // Grey text for inactive
-			Padding(0, 1)
+		filterStyle := boxStyle("8").Foreground(lipgloss.Color("8")) // Grey border and text for inactive
 
 		content.WriteString(filterStyle.Render(fmt.Sprintf("Filter: %s (Press / to edit, Esc to clear)", m.discoveryFilterInput.Value())))
 		content.WriteString("\n\n")
 	} else {
 		// Create a placeholder box to maintain consistent layout
-		placeholderStyle := lipgloss.NewStyle().
-			Border(lipgloss.NormalBorder()).
-			BorderForeground(lipgloss.Color("240")). // Very dim border
-			Foreground(lipgloss.Color("240")).       // Very dim text
-			Padding(0, 1)
+		placeholderStyle := boxStyle("240").Foreground(lipgloss.Color("240")) // Very dim border and text
 
 		content.WriteString(placeholderStyle.Render("Press / to filter..."))
 		content.WriteString("\n\n")
@@ -147,12 +156,34 @@ func (m *Model) renderServiceSelectionView() string {
 	content.WriteString("\n\n")
 
 	// Controls at bottom (for narrower screens or reinforcement)
-	if m.discoveryEditMode {
+	if m.discoveryProfilePrompt == profilePromptApply {
+		names := strings.Join(m.discoveryProfileNames, ", ")
+		content.WriteString(helpStyle.Render(fmt.Sprintf("Saved: %s | Enter: Apply | Esc: Cancel", names)))
+	} else if m.discoveryProfilePrompt == profilePromptSave {
+		content.WriteString(helpStyle.Render("Type a name | Enter: Save selected ports | Esc: Cancel"))
+	} else if m.discoveryEditMode {
 		content.WriteString(helpStyle.Render("Type port number | Enter: Confirm | Esc: Cancel edit"))
 	} else if m.discoveryFilterMode {
 		content.WriteString(helpStyle.Render("Type to filter | Enter: Apply filter | Esc: Clear filter"))
 	} else {
-		content.WriteString(helpStyle.Render("↑/↓: Navigate | Space: Toggle | e: Edit local port (new only) | /: Filter | Enter: Confirm | Esc: Back"))
+		content.WriteString(helpStyle.Render("↑/↓: Navigate | Space: Toggle | e: Edit local port (new only) | /: Filter | a: All namespaces | w/p: Save/apply profile | Enter: Confirm | Esc: Back"))
+	}
+
+	if m.errorMsg != "" {
+		errorStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorError))
+		content.WriteString("\n")
+		content.WriteString(errorStyle.Render(fmt.Sprintf("ERROR: %s", m.errorMsg)))
+	} else if m.statusMsg != "" {
+		content.WriteString("\n")
+		content.WriteString(helpStyle.Render(m.statusMsg))
+	}
+
+	// The highlighted or edited local port's risk, on a line of its own so
+	// it does not hide the last status
+	if warning := m.discoveryPortWarning(); warning != "" {
+		warningStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorWarning))
+		content.WriteString("\n")
+		content.WriteString(warningStyle.Render("⚠ Local " + warning))
 	}
 
 	return content.String()
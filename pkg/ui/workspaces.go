@@ -0,0 +1,229 @@
+package ui
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+	"github.com/xlttj/kprtfwd/pkg/logging"
+
+	"github.com/charmbracelet/bubbles/table"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+// enterWorkspaceSelector switches to the workspace selector view
+func (m *Model) enterWorkspaceSelector() (tea.Model, tea.Cmd) {
+	names, err := config.Workspaces()
+	if err != nil {
+		m.errorMsg = err.Error()
+		return m, nil
+	}
+	m.uiState = StateWorkspaceSelector
+	m.errorMsg = ""
+	m.statusMsg = ""
+	m.workspaceCreating = false
+	m.initializeWorkspaceSelector(names)
+	return m, nil
+}
+
+// initializeWorkspaceSelector fills the workspace table, the cursor on the
+// current workspace
+func (m *Model) initializeWorkspaceSelector(names []string) {
+	current := config.CurrentWorkspace()
+	rows := make([]table.Row, len(names))
+	cursor := 0
+	for i, name := range names {
+		status := IndicatorUnselected
+		if name == current {
+			status = IndicatorSelected
+			cursor = i
+		}
+		rows[i] = table.Row{name, status}
+	}
+	m.workspaceSelector = newNavTable(m.calculateWorkspaceSelectorColumns(), rows, min(len(rows)+2, m.height-8))
+	m.workspaceSelector.SetCursor(cursor)
+}
+
+// calculateWorkspaceSelectorColumns returns the workspace table's columns
+// sized to the window
+func (m *Model) calculateWorkspaceSelectorColumns() []table.Column {
+	activeWidth := 6 // "ACTIVE"
+	return []table.Column{
+		{Title: "WORKSPACE", Width: max(m.width-8, 30) - activeWidth},
+		{Title: "ACTIVE", Width: activeWidth},
+	}
+}
+
+// resizeWorkspaceSelector fits the workspace table to the window
+func (m *Model) resizeWorkspaceSelector() {
+	if m.workspaceSelector.Rows() == nil {
+		return
+	}
+	m.workspaceSelector.SetColumns(m.calculateWorkspaceSelectorColumns())
+	m.workspaceSelector.SetHeight(min(len(m.workspaceSelector.Rows())+2, m.height-8))
+}
+
+// updateWorkspaceSelector handles keys in the workspace selector view
+func (m *Model) updateWorkspaceSelector(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.workspaceCreating {
+		switch msg.String() {
+		case "esc":
+			m.workspaceCreating = false
+			m.workspaceNameInput.Blur()
+			m.errorMsg = ""
+			return m, nil
+		case "enter":
+			name := strings.TrimSpace(m.workspaceNameInput.Value())
+			if err := config.ValidateWorkspaceName(name); err != nil {
+				m.errorMsg = err.Error()
+				return m, nil
+			}
+			return m.selectWorkspace(name)
+		default:
+			var cmd tea.Cmd
+			m.workspaceNameInput, cmd = m.workspaceNameInput.Update(msg)
+			return m, cmd
+		}
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.uiState = StatePortForwards
+		m.errorMsg = ""
+		m.statusMsg = ""
+		return m, nil
+	case "enter":
+		row := m.workspaceSelector.SelectedRow()
+		if row == nil {
+			return m, nil
+		}
+		return m.selectWorkspace(row[0])
+	case "n", "N":
+		m.workspaceNameInput = textinput.New()
+		m.workspaceNameInput.Placeholder = "client-name"
+		m.workspaceNameInput.CharLimit = 63
+		m.workspaceNameInput.Width = 30
+		m.workspaceNameInput.Focus()
+		m.workspaceCreating = true
+		m.errorMsg = ""
+		return m, textinput.Blink
+	default:
+		m.workspaceSelector, _ = m.workspaceSelector.Update(msg)
+		return m, nil
+	}
+}
+
+// selectWorkspace switches to the workspace name and returns to the main view
+func (m *Model) selectWorkspace(name string) (tea.Model, tea.Cmd) {
+	if name == config.CurrentWorkspace() {
+		m.uiState = StatePortForwards
+		m.statusMsg = fmt.Sprintf("Already in workspace '%s'", name)
+		return m, nil
+	}
+	stopped, err := m.switchWorkspace(name)
+	if err != nil {
+		m.errorMsg = fmt.Sprintf("Cannot switch to workspace '%s': %v", name, err)
+		return m, nil
+	}
+	m.workspaceCreating = false
+	m.uiState = StatePortForwards
+	m.errorMsg = ""
+	m.statusMsg = fmt.Sprintf("Switched to workspace '%s'", name)
+	if stopped > 0 {
+		m.statusMsg += fmt.Sprintf(" (stopped %d forwards of the previous one)", stopped)
+	}
+	return m, nil
+}
+
+// switchWorkspace opens the database of the workspace name in place of the
+// current one. The forwards of the current workspace are stopped first, as
+// they would no longer be listed; it returns how many were. On failure the
+// current workspace stays open and nothing is stopped.
+func (m *Model) switchWorkspace(name string) (int, error) {
+	previous := config.CurrentWorkspace()
+	if err := config.SetWorkspace(name); err != nil {
+		return 0, err
+	}
+	store, err := config.NewSQLiteConfigStore()
+	if err != nil {
+		_ = config.SetWorkspace(previous)
+		return 0, err
+	}
+
+	m.recordForwardEvents(true)
+	stopped := m.portForwarder.StopAllRunning()
+	if closer, ok := m.configStore.(interface{ Close() error }); ok {
+		if err := closer.Close(); err != nil {
+			logging.LogError("Failed to close the database of workspace %s: %v", previous, err)
+		}
+	}
+	logging.LogDebug("Switched from workspace %s to %s", previous, name)
+
+	// Settings are per workspace too
+	store.SetBeforeDelete(m.portForwarder.Stop)
+	m.configStore = store
+	m.storeStamp = store.ChangeStamp()
+	m.applySettings(store.GetSettings())
+
+	// Forget what belonged to the previous workspace's forwards
+	m.groupStates = make(map[string]*GroupState)
+	m.touchedGroups = nil
+	m.activeIDs = nil
+	m.snoozedUntil = nil
+	m.expiresAt = nil
+	m.latencies = nil
+	m.servicePods = nil
+	m.recentDiscovery = nil
+	m.filterMode = false
+	m.filterInput.SetValue("")
+	m.filteredConfigs = nil
+	m.refreshTable()
+	m.portForwardsTable.SetCursor(0)
+	return stopped, nil
+}
+
+// renderWorkspaceSelector renders the workspace selector view
+func (m Model) renderWorkspaceSelector() string {
+	var b strings.Builder
+
+	titleStyle := lipgloss.NewStyle().
+		Foreground(lipgloss.Color(ColorTitle)).
+		Bold(true).
+		Padding(0, 1)
+	b.WriteString(titleStyle.Render("🗂 Workspaces"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("Current: %s\n", config.CurrentWorkspace()))
+	b.WriteString("Each workspace has its own forwards, projects and settings. Switching stops the running forwards.\n\n")
+
+	b.WriteString(m.workspaceSelector.View())
+	b.WriteString("\n\n")
+
+	if m.workspaceCreating {
+		b.WriteString("New workspace: ")
+		b.WriteString(m.workspaceNameInput.View())
+		b.WriteString("\n\n")
+	}
+
+	helpStyle := lipgloss.NewStyle().Foreground(lipgloss.Color(ColorHelp))
+	if m.workspaceCreating {
+		b.WriteString(helpStyle.Render("Enter: Create and Switch | Esc: Cancel"))
+	} else {
+		b.WriteString(helpStyle.Render(ActionWorkspaces))
+	}
+	b.WriteString("\n")
+
+	if m.errorMsg != "" {
+		errorStyle := lipgloss.NewStyle().
+			Foreground(lipgloss.Color(ColorError)).
+			Bold(true)
+		b.WriteString(errorStyle.Render(fmt.Sprintf("Error: %s", m.errorMsg)))
+		b.WriteString("\n")
+	} else if m.statusMsg != "" {
+		b.WriteString(m.statusMsg)
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
@@ -0,0 +1,48 @@
+package ui
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/xlttj/kprtfwd/pkg/config"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func TestSwitchWorkspace(t *testing.T) {
+	t.Setenv(config.EnvDB, "")
+	t.Setenv(config.EnvWorkspace, "")
+
+	m, _ := newTestModel(t, config.PortForwardConfig{ID: "ctx.ns.api", Context: "ctx", Namespace: "ns", Service: "api", PortRemote: 80, PortLocal: 8080})
+	t.Cleanup(func() { m.configStore.(*config.SQLiteConfigStore).Close() })
+
+	// Create a workspace from the switcher
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("W")})
+	if m.uiState != StateWorkspaceSelector {
+		t.Fatalf("W should open the workspace selector, state %v", m.uiState)
+	}
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("n")})
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("client-a")})
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	if m.uiState != StatePortForwards || config.CurrentWorkspace() != "client-a" {
+		t.Fatalf("expected to be back in the main view of client-a, state %v, workspace %s (%s)", m.uiState, config.CurrentWorkspace(), m.errorMsg)
+	}
+	if n := len(m.portForwardsTable.Rows()); n != 0 {
+		t.Errorf("the new workspace should list no forwards, got %d rows", n)
+	}
+	if !strings.Contains(m.View(), "[client-a]") {
+		t.Error("the title should name the workspace")
+	}
+
+	// And back to the default one
+	m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("W")})
+	m.workspaceSelector.SetCursor(0)
+	m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if config.CurrentWorkspace() != config.DefaultWorkspace {
+		t.Fatalf("expected the default workspace, got %s (%s)", config.CurrentWorkspace(), m.errorMsg)
+	}
+	if _, ok := m.configStore.GetConfigByID("ctx.ns.api"); !ok {
+		t.Error("the default workspace's forward should be back")
+	}
+}